@@ -0,0 +1,77 @@
+package client
+
+import (
+	"context"
+
+	"go-coffee-log/models"
+	"go-coffee-log/service"
+)
+
+// CoffeeAPI mirrors service.CoffeeService over HTTP
+type CoffeeAPI interface {
+	ListCoffees(ctx context.Context) ([]models.Coffee, error)
+	GetCoffee(ctx context.Context, id string) (models.Coffee, error)
+	CreateCoffee(ctx context.Context, coffee models.Coffee) (models.Coffee, error)
+	UpdateCoffee(ctx context.Context, id string, coffee models.Coffee) (models.Coffee, error)
+	// PatchCoffee applies a JSON merge patch, e.g. {"rating": 9}
+	PatchCoffee(ctx context.Context, id string, patch map[string]interface{}) (models.Coffee, error)
+	DeleteCoffee(ctx context.Context, id string) error
+	SetFavorite(ctx context.Context, id string, favorite bool) (models.Coffee, error)
+	CloneCoffee(ctx context.Context, id string) (models.Coffee, error)
+	GetLineage(ctx context.Context, id string) (service.CoffeeLineage, error)
+	// ParseTasting reads tasting traits and notes from a free-text description
+	ParseTasting(ctx context.Context, description string) (service.ParsedTasting, error)
+}
+
+// BrewerAPI mirrors service.BrewerService over HTTP
+type BrewerAPI interface {
+	ListBrewers(ctx context.Context) ([]models.Brewer, error)
+	CreateBrewer(ctx context.Context, name, pokeballType string) (models.Brewer, error)
+	DeleteBrewer(ctx context.Context, id string) error
+	AddRecipe(ctx context.Context, brewerID string, recipe models.Recipe) error
+	RemoveRecipe(ctx context.Context, brewerID, recipeID string) error
+}
+
+// CollectionAPI mirrors service.CollectionService over HTTP
+type CollectionAPI interface {
+	ListCollections(ctx context.Context) ([]models.Collection, error)
+	CreateCollection(ctx context.Context, name, description string, coffeeIDs []string) (models.Collection, error)
+	UpdateCollection(ctx context.Context, id, name, description string) (models.Collection, error)
+	AddCollectionCoffee(ctx context.Context, id, coffeeID string) (models.Collection, error)
+}
+
+// RecipeAPI mirrors service.RecipeService over HTTP
+type RecipeAPI interface {
+	SuggestRecipe(ctx context.Context, coffeeID, brewerID string) (service.SuggestedRecipe, error)
+	// ConvertRecipe adapts a recipe to a registered brewer by ID, or to a
+	// brewer by name when brewerID is empty
+	ConvertRecipe(ctx context.Context, coffeeID, brewerID, brewer string) (service.ConvertedRecipe, error)
+	ConvertRecipeForBrewers(ctx context.Context, coffeeID string) ([]service.ConvertedRecipe, error)
+}
+
+// PokemonAPI mirrors the catching side of service.PokemonService over HTTP.
+// Catches land in the token's dex profile.
+type PokemonAPI interface {
+	// CatchPokemon catches a Pokemon for a coffee, waiting for the catch
+	// when the server uses a job queue
+	CatchPokemon(ctx context.Context, coffeeID string) (models.CoffeePokemon, error)
+	GetCoffeePokemon(ctx context.Context, coffeeID string) (models.CoffeePokemon, error)
+}
+
+// StatisticsAPI mirrors service.StatisticsService over HTTP
+type StatisticsAPI interface {
+	GetStatistics(ctx context.Context, favoritesOnly bool) (service.Statistics, error)
+}
+
+// API is everything the client covers. Code that takes an API can be
+// handed a fake in place of a server.
+type API interface {
+	CoffeeAPI
+	BrewerAPI
+	CollectionAPI
+	RecipeAPI
+	PokemonAPI
+	StatisticsAPI
+}
+
+var _ API = (*Client)(nil)
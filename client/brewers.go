@@ -0,0 +1,46 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"go-coffee-log/models"
+)
+
+// ListBrewers returns every registered brewer
+func (c *Client) ListBrewers(ctx context.Context) ([]models.Brewer, error) {
+	var brewers []models.Brewer
+	err := c.do(ctx, http.MethodGet, "/brewers", nil, nil, &brewers)
+	return brewers, err
+}
+
+// CreateBrewer registers a brewer with the pokeball it is shown as
+func (c *Client) CreateBrewer(ctx context.Context, name, pokeballType string) (models.Brewer, error) {
+	body := struct {
+		Name         string `json:"name"`
+		PokeballType string `json:"pokeball_type"`
+	}{name, pokeballType}
+	var brewer models.Brewer
+	err := c.do(ctx, http.MethodPost, "/brewers", nil, body, &brewer)
+	return brewer, err
+}
+
+// DeleteBrewer removes a brewer
+func (c *Client) DeleteBrewer(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/brewers/"+escape(id), nil, nil, nil)
+}
+
+// AddRecipe adds a standalone recipe to a brewer; a brewer holds up to 4
+func (c *Client) AddRecipe(ctx context.Context, brewerID string, recipe models.Recipe) error {
+	body := struct {
+		Name        string              `json:"name"`
+		Steps       []string            `json:"steps"`
+		Temperature *models.Temperature `json:"temperature,omitempty"`
+	}{recipe.Name, recipe.Steps, recipe.Temperature}
+	return c.do(ctx, http.MethodPost, "/brewers/"+escape(brewerID)+"/standalone-recipes", nil, body, nil)
+}
+
+// RemoveRecipe removes a standalone recipe from a brewer
+func (c *Client) RemoveRecipe(ctx context.Context, brewerID, recipeID string) error {
+	return c.do(ctx, http.MethodDelete, "/brewers/"+escape(brewerID)+"/standalone-recipes/"+escape(recipeID), nil, nil, nil)
+}
@@ -0,0 +1,85 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"go-coffee-log/models"
+	"go-coffee-log/service"
+)
+
+// ListCoffees returns every coffee
+func (c *Client) ListCoffees(ctx context.Context) ([]models.Coffee, error) {
+	var coffees []models.Coffee
+	err := c.do(ctx, http.MethodGet, "/coffees", nil, nil, &coffees)
+	return coffees, err
+}
+
+// GetCoffee returns a coffee with its dial-in
+func (c *Client) GetCoffee(ctx context.Context, id string) (models.Coffee, error) {
+	var coffee models.Coffee
+	err := c.do(ctx, http.MethodGet, "/coffees/"+escape(id), nil, nil, &coffee)
+	return coffee, err
+}
+
+// CreateCoffee saves a new coffee and returns it with its ID
+func (c *Client) CreateCoffee(ctx context.Context, coffee models.Coffee) (models.Coffee, error) {
+	var created models.Coffee
+	err := c.do(ctx, http.MethodPost, "/coffees", nil, coffee, &created)
+	return created, err
+}
+
+// UpdateCoffee replaces a coffee
+func (c *Client) UpdateCoffee(ctx context.Context, id string, coffee models.Coffee) (models.Coffee, error) {
+	var updated models.Coffee
+	err := c.do(ctx, http.MethodPut, "/coffees/"+escape(id), nil, coffee, &updated)
+	return updated, err
+}
+
+// PatchCoffee changes only the fields in patch; null resets a field
+func (c *Client) PatchCoffee(ctx context.Context, id string, patch map[string]interface{}) (models.Coffee, error) {
+	var patched models.Coffee
+	err := c.do(ctx, http.MethodPatch, "/coffees/"+escape(id), nil, patch, &patched)
+	return patched, err
+}
+
+// DeleteCoffee deletes a coffee and releases its Pokemon catches
+func (c *Client) DeleteCoffee(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/coffees/"+escape(id), nil, nil, nil)
+}
+
+// SetFavorite favorites or unfavorites a coffee
+func (c *Client) SetFavorite(ctx context.Context, id string, favorite bool) (models.Coffee, error) {
+	method := http.MethodDelete
+	if favorite {
+		method = http.MethodPost
+	}
+	var coffee models.Coffee
+	err := c.do(ctx, method, "/coffees/"+escape(id)+"/favorite", nil, nil, &coffee)
+	return coffee, err
+}
+
+// CloneCoffee starts a new bag of the same coffee
+func (c *Client) CloneCoffee(ctx context.Context, id string) (models.Coffee, error) {
+	var clone models.Coffee
+	err := c.do(ctx, http.MethodPost, "/coffees/"+escape(id)+"/clone", nil, nil, &clone)
+	return clone, err
+}
+
+// GetLineage compares every bag of the coffee a bag belongs to
+func (c *Client) GetLineage(ctx context.Context, id string) (service.CoffeeLineage, error) {
+	var lineage service.CoffeeLineage
+	err := c.do(ctx, http.MethodGet, "/coffees/"+escape(id)+"/lineage", nil, nil, &lineage)
+	return lineage, err
+}
+
+// ParseTasting asks the server's LLM to read tasting traits and notes from
+// a free-text description. Nothing is saved.
+func (c *Client) ParseTasting(ctx context.Context, description string) (service.ParsedTasting, error) {
+	var parsed service.ParsedTasting
+	body := struct {
+		Description string `json:"description"`
+	}{description}
+	err := c.do(ctx, http.MethodPost, "/coffees/parse", nil, body, &parsed)
+	return parsed, err
+}
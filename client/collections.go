@@ -0,0 +1,48 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"go-coffee-log/models"
+)
+
+// ListCollections returns every collection
+func (c *Client) ListCollections(ctx context.Context) ([]models.Collection, error) {
+	var collections []models.Collection
+	err := c.do(ctx, http.MethodGet, "/collections", nil, nil, &collections)
+	return collections, err
+}
+
+// CreateCollection creates a collection holding the given coffees
+func (c *Client) CreateCollection(ctx context.Context, name, description string, coffeeIDs []string) (models.Collection, error) {
+	body := struct {
+		Name        string   `json:"name"`
+		Description string   `json:"description"`
+		CoffeeIDs   []string `json:"coffee_ids"`
+	}{name, description, coffeeIDs}
+	var collection models.Collection
+	err := c.do(ctx, http.MethodPost, "/collections", nil, body, &collection)
+	return collection, err
+}
+
+// UpdateCollection renames a collection or changes its description
+func (c *Client) UpdateCollection(ctx context.Context, id, name, description string) (models.Collection, error) {
+	body := struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	}{name, description}
+	var collection models.Collection
+	err := c.do(ctx, http.MethodPut, "/collections/"+escape(id), nil, body, &collection)
+	return collection, err
+}
+
+// AddCollectionCoffee adds a coffee to a collection
+func (c *Client) AddCollectionCoffee(ctx context.Context, id, coffeeID string) (models.Collection, error) {
+	body := struct {
+		CoffeeID string `json:"coffee_id"`
+	}{coffeeID}
+	var collection models.Collection
+	err := c.do(ctx, http.MethodPost, "/collections/"+escape(id)+"/coffees", nil, body, &collection)
+	return collection, err
+}
@@ -0,0 +1,25 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"go-coffee-log/models"
+)
+
+// CatchPokemon catches a Pokemon for a coffee. A coffee caught already
+// returns its existing catch, so scripts can be rerun safely.
+func (c *Client) CatchPokemon(ctx context.Context, coffeeID string) (models.CoffeePokemon, error) {
+	query := url.Values{"wait": {"true"}, "idempotent": {"true"}}
+	var mapping models.CoffeePokemon
+	err := c.do(ctx, http.MethodPost, "/pokemon/"+escape(coffeeID), query, nil, &mapping)
+	return mapping, err
+}
+
+// GetCoffeePokemon returns a coffee's catch
+func (c *Client) GetCoffeePokemon(ctx context.Context, coffeeID string) (models.CoffeePokemon, error) {
+	var mapping models.CoffeePokemon
+	err := c.do(ctx, http.MethodGet, "/coffees/"+escape(coffeeID)+"/pokemon", nil, nil, &mapping)
+	return mapping, err
+}
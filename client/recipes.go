@@ -0,0 +1,43 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"go-coffee-log/service"
+)
+
+// SuggestRecipe suggests a recipe for a coffee, on the given registered
+// brewer or, with an empty brewerID, the coffee's own
+func (c *Client) SuggestRecipe(ctx context.Context, coffeeID, brewerID string) (service.SuggestedRecipe, error) {
+	query := url.Values{}
+	if brewerID != "" {
+		query.Set("brewer_id", brewerID)
+	}
+	var recipe service.SuggestedRecipe
+	err := c.do(ctx, http.MethodGet, "/coffees/"+escape(coffeeID)+"/suggested-recipe", query, nil, &recipe)
+	return recipe, err
+}
+
+// ConvertRecipe adapts a coffee's recipe to a registered brewer by ID, or
+// to a brewer by name when brewerID is empty
+func (c *Client) ConvertRecipe(ctx context.Context, coffeeID, brewerID, brewer string) (service.ConvertedRecipe, error) {
+	query := url.Values{}
+	if brewerID != "" {
+		query.Set("brewer_id", brewerID)
+	} else {
+		query.Set("brewer", brewer)
+	}
+	var recipe service.ConvertedRecipe
+	err := c.do(ctx, http.MethodGet, "/coffees/"+escape(coffeeID)+"/converted-recipe", query, nil, &recipe)
+	return recipe, err
+}
+
+// ConvertRecipeForBrewers adapts a coffee's recipe to every registered
+// brewer it was not written for
+func (c *Client) ConvertRecipeForBrewers(ctx context.Context, coffeeID string) ([]service.ConvertedRecipe, error) {
+	var recipes []service.ConvertedRecipe
+	err := c.do(ctx, http.MethodGet, "/coffees/"+escape(coffeeID)+"/converted-recipe", nil, nil, &recipes)
+	return recipes, err
+}
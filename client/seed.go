@@ -0,0 +1,562 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"go-coffee-log/models"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Seed declares the brewers, tags and coffees a server should have, for
+// reproducible demo and classroom setups. ApplySeed reconciles a server
+// against it: missing things are created, things that differ are updated
+// and everything else is left alone. Nothing is ever deleted.
+//
+// Tags are collections: a seeded coffee joins every collection named in
+// its "tags".
+type Seed struct {
+	Brewers []SeedBrewer `json:"brewers"`
+	Tags    []SeedTag    `json:"tags"`
+	Coffees []SeedCoffee `json:"coffees"`
+}
+
+// SeedBrewer is a brewer, matched by name, with its standalone recipes,
+// matched by name within the brewer
+type SeedBrewer struct {
+	Name         string          `json:"name"`
+	PokeballType string          `json:"pokeball_type"`
+	Recipes      []models.Recipe `json:"recipes"` // IDs are ignored
+}
+
+// SeedTag is a collection, matched by name
+type SeedTag struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// SeedCoffee is a coffee, matched by name and roaster. Only the fields
+// the seed sets are reconciled, so ratings or favorites changed on the
+// server since are kept unless the seed pins them.
+type SeedCoffee struct {
+	Fields map[string]json.RawMessage // the coffee's fields as in POST /coffees
+	Brewer string                     // name of the brewer it was brewed with
+	Tags   []string                   // names of the collections it belongs to
+}
+
+// seedServerFields are coffee fields the server sets, which a seed may not
+var seedServerFields = []string{"id", "brewer_id", "lineage", "dial_in", "created_at", "updated_at"}
+
+// UnmarshalJSON reads a coffee object with optional "brewer" and "tags"
+// keys alongside its fields
+func (c *SeedCoffee) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &c.Fields); err != nil {
+		return err
+	}
+	if raw, ok := c.Fields["brewer"]; ok {
+		if err := json.Unmarshal(raw, &c.Brewer); err != nil {
+			return fmt.Errorf("brewer must be a brewer name: %w", err)
+		}
+		delete(c.Fields, "brewer")
+	}
+	if raw, ok := c.Fields["tags"]; ok {
+		if err := json.Unmarshal(raw, &c.Tags); err != nil {
+			return fmt.Errorf("tags must be a list of tag names: %w", err)
+		}
+		delete(c.Fields, "tags")
+	}
+	return nil
+}
+
+// coffee decodes the seeded fields into a coffee, lowercased the way the
+// server stores it
+func (c SeedCoffee) coffee() (models.Coffee, error) {
+	var coffee models.Coffee
+	payload, err := json.Marshal(c.Fields)
+	if err != nil {
+		return coffee, err
+	}
+	if err := json.Unmarshal(payload, &coffee); err != nil {
+		return coffee, err
+	}
+	coffee.ProcessingMethod = strings.ToLower(coffee.ProcessingMethod)
+	coffee.RoastLevel = strings.ToLower(coffee.RoastLevel)
+	return coffee, nil
+}
+
+// SeedChange is what ApplySeed did, or would do in a dry run, to one
+// brewer, recipe, tag or coffee
+type SeedChange struct {
+	Action string `json:"action"` // one of the Seed* actions
+	Kind   string `json:"kind"`   // "brewer", "recipe", "tag" or "coffee"
+	Name   string `json:"name"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Seed change actions
+const (
+	SeedCreate   = "create"
+	SeedUpdate   = "update"
+	SeedNoop     = "no-op"
+	SeedConflict = "conflict" // differs in a way the API cannot change
+)
+
+// LoadSeed reads and validates a YAML seed file, or a JSON one when its
+// name ends in .json
+func LoadSeed(path string) (*Seed, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(filepath.Ext(path), ".json") {
+		if data, err = yamlToJSON(data); err != nil {
+			return nil, fmt.Errorf("invalid seed %s: %w", path, err)
+		}
+	}
+	var seed Seed
+	if err := json.Unmarshal(data, &seed); err != nil {
+		return nil, fmt.Errorf("invalid seed %s: %w", path, err)
+	}
+	if err := seed.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid seed %s: %w", path, err)
+	}
+	return &seed, nil
+}
+
+// yamlToJSON converts a YAML document to JSON, so YAML seeds are read with
+// the same field names and checks as JSON ones
+func yamlToJSON(data []byte) ([]byte, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	keepDatesAsText(&root)
+	var document interface{}
+	if err := root.Decode(&document); err != nil {
+		return nil, err
+	}
+	return json.Marshal(document)
+}
+
+// keepDatesAsText reads unquoted dates such as roast_date: 2024-05-01 as
+// the text written, which the coffee fields parse, rather than timestamps
+func keepDatesAsText(node *yaml.Node) {
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!timestamp" {
+		node.Tag = "!!str"
+	}
+	for _, child := range node.Content {
+		keepDatesAsText(child)
+	}
+}
+
+// Validate checks names and duplicates. A coffee's brewer and tags may name
+// brewers and collections already on the server, so those are checked when
+// the seed is applied.
+func (s *Seed) Validate() error {
+	brewers := make(map[string]bool, len(s.Brewers))
+	for i, seeded := range s.Brewers {
+		brewer := models.Brewer{Name: strings.TrimSpace(seeded.Name), PokeballType: seeded.PokeballType}
+		if err := brewer.Validate(); err != nil {
+			return fmt.Errorf("brewers[%d]: %w", i, err)
+		}
+		if brewers[seedKey(brewer.Name)] {
+			return fmt.Errorf("brewers[%d]: brewer %q is declared twice", i, brewer.Name)
+		}
+		brewers[seedKey(brewer.Name)] = true
+
+		if len(seeded.Recipes) > 4 {
+			return fmt.Errorf("brewer %q: a brewer holds at most 4 recipes", brewer.Name)
+		}
+		recipes := make(map[string]bool, len(seeded.Recipes))
+		for j, recipe := range seeded.Recipes {
+			if strings.TrimSpace(recipe.Name) == "" {
+				return fmt.Errorf("brewer %q: recipes[%d]: name is required", brewer.Name, j)
+			}
+			if recipes[seedKey(recipe.Name)] {
+				return fmt.Errorf("brewer %q: recipe %q is declared twice", brewer.Name, recipe.Name)
+			}
+			recipes[seedKey(recipe.Name)] = true
+			if recipe.Temperature != nil {
+				if err := recipe.Temperature.Validate(); err != nil {
+					return fmt.Errorf("brewer %q: recipe %q: %w", brewer.Name, recipe.Name, err)
+				}
+			}
+		}
+	}
+
+	tags := make(map[string]bool, len(s.Tags))
+	for i, seeded := range s.Tags {
+		tag := models.Collection{Name: seeded.Name, Description: seeded.Description}
+		if err := tag.Validate(); err != nil {
+			return fmt.Errorf("tags[%d]: %w", i, err)
+		}
+		if tags[seedKey(tag.Name)] {
+			return fmt.Errorf("tags[%d]: tag %q is declared twice", i, tag.Name)
+		}
+		tags[seedKey(tag.Name)] = true
+	}
+
+	coffees := make(map[string]bool, len(s.Coffees))
+	for i, seeded := range s.Coffees {
+		for _, field := range seedServerFields {
+			if _, ok := seeded.Fields[field]; ok {
+				return fmt.Errorf("coffees[%d]: %s is set by the server", i, field)
+			}
+		}
+		coffee, err := seeded.coffee()
+		if err != nil {
+			return fmt.Errorf("coffees[%d]: %w", i, err)
+		}
+		if strings.TrimSpace(coffee.Name) == "" {
+			return fmt.Errorf("coffees[%d]: name is required", i)
+		}
+		key := coffeeSeedKey(coffee)
+		if coffees[key] {
+			return fmt.Errorf("coffees[%d]: coffee %q by %q is declared twice", i, coffee.Name, coffee.Roaster)
+		}
+		coffees[key] = true
+	}
+	return nil
+}
+
+// seedApplier holds the server's state while a seed is applied
+type seedApplier struct {
+	api     API
+	dryRun  bool
+	changes []SeedChange
+
+	brewerIDs map[string]string // by seedKey(name); "" if only planned
+	tags      map[string]models.Collection
+}
+
+// ApplySeed reconciles the server behind api with the seed and returns
+// every change, no-ops included. With dryRun set nothing is written. It
+// stops at the first failed call; the changes made until then are kept
+// and returned with the error, and applying the seed again picks up from
+// there.
+func ApplySeed(ctx context.Context, api API, seed *Seed, dryRun bool) ([]SeedChange, error) {
+	a := &seedApplier{
+		api:       api,
+		dryRun:    dryRun,
+		brewerIDs: make(map[string]string),
+		tags:      make(map[string]models.Collection),
+	}
+	if err := a.applyBrewers(ctx, seed.Brewers); err != nil {
+		return a.changes, err
+	}
+	if err := a.applyTags(ctx, seed.Tags); err != nil {
+		return a.changes, err
+	}
+	if err := a.applyCoffees(ctx, seed.Coffees); err != nil {
+		return a.changes, err
+	}
+	return a.changes, nil
+}
+
+func (a *seedApplier) record(action, kind, name, detail string) {
+	a.changes = append(a.changes, SeedChange{Action: action, Kind: kind, Name: name, Detail: detail})
+}
+
+// applyBrewers creates missing brewers and reconciles their recipes.
+// Brewers are only listed when the seed or a coffee needs them, since
+// servers without MySQL storage have none.
+func (a *seedApplier) applyBrewers(ctx context.Context, seeded []SeedBrewer) error {
+	if len(seeded) == 0 {
+		return nil
+	}
+	brewers, err := a.listBrewers(ctx)
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]models.Brewer, len(brewers))
+	for _, brewer := range brewers {
+		existing[seedKey(brewer.Name)] = brewer
+		a.brewerIDs[seedKey(brewer.Name)] = brewer.ID
+	}
+
+	for _, want := range seeded {
+		name := strings.TrimSpace(want.Name)
+		brewer, ok := existing[seedKey(name)]
+		switch {
+		case !ok:
+			a.record(SeedCreate, "brewer", name, want.PokeballType)
+			a.brewerIDs[seedKey(name)] = ""
+			brewer = models.Brewer{Name: name}
+			if !a.dryRun {
+				if brewer, err = a.api.CreateBrewer(ctx, name, want.PokeballType); err != nil {
+					return fmt.Errorf("failed to create brewer %q: %w", name, err)
+				}
+				a.brewerIDs[seedKey(name)] = brewer.ID
+			}
+		case brewer.PokeballType != want.PokeballType:
+			a.record(SeedConflict, "brewer", name, fmt.Sprintf("is a %s on the server; brewers cannot be changed through the API", brewer.PokeballType))
+		default:
+			a.record(SeedNoop, "brewer", name, "")
+		}
+		if err := a.applyRecipes(ctx, brewer, want.Recipes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyRecipes adds missing recipes to a brewer and replaces those whose
+// steps or temperature differ, since recipes cannot be edited in place
+func (a *seedApplier) applyRecipes(ctx context.Context, brewer models.Brewer, seeded []models.Recipe) error {
+	existing := make(map[string]models.Recipe, len(brewer.Recipes))
+	for _, recipe := range brewer.Recipes {
+		existing[seedKey(recipe.Name)] = recipe
+	}
+
+	for _, want := range seeded {
+		want.Name = strings.TrimSpace(want.Name)
+		name := fmt.Sprintf("%s: %s", strings.TrimSpace(brewer.Name), want.Name)
+		recipe, ok := existing[seedKey(want.Name)]
+		switch {
+		case !ok:
+			a.record(SeedCreate, "recipe", name, "")
+		case reflect.DeepEqual(recipe.Steps, want.Steps) && reflect.DeepEqual(recipe.Temperature, want.Temperature):
+			a.record(SeedNoop, "recipe", name, "")
+			continue
+		default:
+			a.record(SeedUpdate, "recipe", name, "steps or temperature changed")
+		}
+		if a.dryRun {
+			continue
+		}
+		if ok {
+			if err := a.api.RemoveRecipe(ctx, brewer.ID, recipe.ID); err != nil {
+				return fmt.Errorf("failed to replace recipe %q: %w", name, err)
+			}
+		}
+		if err := a.api.AddRecipe(ctx, brewer.ID, want); err != nil {
+			return fmt.Errorf("failed to add recipe %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// applyTags creates missing collections and updates changed descriptions
+func (a *seedApplier) applyTags(ctx context.Context, seeded []SeedTag) error {
+	collections, err := a.api.ListCollections(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list collections: %w", err)
+	}
+	for _, collection := range collections {
+		a.tags[seedKey(collection.Name)] = collection
+	}
+
+	for _, want := range seeded {
+		name := strings.TrimSpace(want.Name)
+		collection, ok := a.tags[seedKey(name)]
+		switch {
+		case !ok:
+			a.record(SeedCreate, "tag", name, "")
+			collection = models.Collection{Name: name, Description: want.Description}
+			if !a.dryRun {
+				if collection, err = a.api.CreateCollection(ctx, name, want.Description, nil); err != nil {
+					return fmt.Errorf("failed to create tag %q: %w", name, err)
+				}
+			}
+		case collection.Description != want.Description:
+			a.record(SeedUpdate, "tag", name, "description changed")
+			collection.Description = want.Description
+			if !a.dryRun {
+				if collection, err = a.api.UpdateCollection(ctx, collection.ID, collection.Name, want.Description); err != nil {
+					return fmt.Errorf("failed to update tag %q: %w", name, err)
+				}
+			}
+		default:
+			a.record(SeedNoop, "tag", name, "")
+		}
+		a.tags[seedKey(name)] = collection
+	}
+	return nil
+}
+
+// applyCoffees creates missing coffees, patches the seeded fields that
+// differ and adds coffees to their tags
+func (a *seedApplier) applyCoffees(ctx context.Context, seeded []SeedCoffee) error {
+	if len(seeded) == 0 {
+		return nil
+	}
+	coffees, err := a.api.ListCoffees(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list coffees: %w", err)
+	}
+	existing := make(map[string]models.Coffee, len(coffees))
+	for _, coffee := range coffees {
+		existing[coffeeSeedKey(coffee)] = coffee
+	}
+
+	for _, want := range seeded {
+		coffee, err := want.coffee()
+		if err != nil {
+			return err
+		}
+		name := coffee.Name
+		if coffee.Roaster != "" {
+			name += " by " + coffee.Roaster
+		}
+		fields := want.Fields
+		if want.Brewer != "" {
+			brewerID, err := a.lookupBrewer(ctx, want.Brewer)
+			if err != nil {
+				return fmt.Errorf("coffee %q: %w", name, err)
+			}
+			coffee.BrewerID = brewerID
+			fields = make(map[string]json.RawMessage, len(want.Fields)+1)
+			for field, value := range want.Fields {
+				fields[field] = value
+			}
+			fields["brewer_id"] = nil
+		}
+
+		current, ok := existing[coffeeSeedKey(coffee)]
+		if !ok {
+			a.record(SeedCreate, "coffee", name, "")
+			if !a.dryRun {
+				if current, err = a.api.CreateCoffee(ctx, coffee); err != nil {
+					return fmt.Errorf("failed to create coffee %q: %w", name, err)
+				}
+			}
+		} else {
+			patch, err := coffeePatch(current, coffee, fields)
+			if err != nil {
+				return fmt.Errorf("coffee %q: %w", name, err)
+			}
+			if len(patch) == 0 {
+				a.record(SeedNoop, "coffee", name, "")
+			} else {
+				a.record(SeedUpdate, "coffee", name, strings.Join(sortedKeys(patch), ", ")+" changed")
+				if !a.dryRun {
+					if current, err = a.api.PatchCoffee(ctx, current.ID, patch); err != nil {
+						return fmt.Errorf("failed to update coffee %q: %w", name, err)
+					}
+				}
+			}
+		}
+
+		for _, tag := range want.Tags {
+			if err := a.tagCoffee(ctx, tag, current, name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (a *seedApplier) listBrewers(ctx context.Context) ([]models.Brewer, error) {
+	brewers, err := a.api.ListBrewers(ctx)
+	if IsNotFound(err) {
+		return nil, fmt.Errorf("the server has no brewers; they require MySQL storage")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list brewers: %w", err)
+	}
+	return brewers, nil
+}
+
+// lookupBrewer returns the ID of a brewer by name; "" for a brewer that a
+// dry run would create
+func (a *seedApplier) lookupBrewer(ctx context.Context, name string) (string, error) {
+	if len(a.brewerIDs) == 0 {
+		brewers, err := a.listBrewers(ctx)
+		if err != nil {
+			return "", err
+		}
+		for _, brewer := range brewers {
+			a.brewerIDs[seedKey(brewer.Name)] = brewer.ID
+		}
+	}
+	brewerID, ok := a.brewerIDs[seedKey(name)]
+	if !ok {
+		return "", fmt.Errorf("unknown brewer %q", name)
+	}
+	return brewerID, nil
+}
+
+// tagCoffee adds a coffee to a tag's collection unless it is in it already
+func (a *seedApplier) tagCoffee(ctx context.Context, tag string, coffee models.Coffee, name string) error {
+	collection, ok := a.tags[seedKey(tag)]
+	if !ok {
+		return fmt.Errorf("coffee %q: unknown tag %q", name, tag)
+	}
+	if coffee.ID != "" {
+		for _, id := range collection.CoffeeIDs {
+			if id == coffee.ID {
+				return nil
+			}
+		}
+	}
+
+	a.record(SeedUpdate, "tag", collection.Name, "add "+name)
+	if a.dryRun {
+		return nil
+	}
+	updated, err := a.api.AddCollectionCoffee(ctx, collection.ID, coffee.ID)
+	if err != nil {
+		return fmt.Errorf("failed to tag coffee %q with %q: %w", name, tag, err)
+	}
+	a.tags[seedKey(tag)] = updated
+	return nil
+}
+
+// coffeePatch returns a merge patch setting the given fields of want that
+// differ on current. Both sides are compared as the server encodes them, so
+// "2024-05-01" and an equal roast date do not count as a change.
+func coffeePatch(current, want models.Coffee, fields map[string]json.RawMessage) (map[string]interface{}, error) {
+	currentFields, err := coffeeFields(current)
+	if err != nil {
+		return nil, err
+	}
+	wantFields, err := coffeeFields(want)
+	if err != nil {
+		return nil, err
+	}
+
+	patch := make(map[string]interface{})
+	for field := range fields {
+		if !reflect.DeepEqual(currentFields[field], wantFields[field]) {
+			patch[field] = wantFields[field]
+		}
+	}
+	return patch, nil
+}
+
+// coffeeFields encodes a coffee as a generic JSON object
+func coffeeFields(coffee models.Coffee) (map[string]interface{}, error) {
+	payload, err := json.Marshal(coffee)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	err = json.Unmarshal(payload, &fields)
+	return fields, err
+}
+
+func sortedKeys(values map[string]interface{}) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// seedKey matches names the way a person would: ignoring case and the
+// spaces around them
+func seedKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+func coffeeSeedKey(coffee models.Coffee) string {
+	return seedKey(coffee.Name) + "\x00" + seedKey(coffee.Roaster)
+}
@@ -0,0 +1,21 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"go-coffee-log/service"
+)
+
+// GetStatistics returns the collection statistics, of favorites only if
+// favoritesOnly is set
+func (c *Client) GetStatistics(ctx context.Context, favoritesOnly bool) (service.Statistics, error) {
+	query := url.Values{}
+	if favoritesOnly {
+		query.Set("favorite", "true")
+	}
+	var stats service.Statistics
+	err := c.do(ctx, http.MethodGet, "/statistics", query, nil, &stats)
+	return stats, err
+}
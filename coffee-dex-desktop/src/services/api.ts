@@ -88,9 +88,13 @@ export class CoffeeDexAPI {
 
   // Pokemon endpoints
   async generatePokemon(coffeeId: string): Promise<CoffeePokemon> {
-    const response = await fetch(`${this.baseUrl}/pokemon/${coffeeId}`, {
-      method: "POST",
-    });
+    // wait=true answers with the catch even when the server queues generations
+    const response = await fetch(
+      `${this.baseUrl}/pokemon/${coffeeId}?wait=true`,
+      {
+        method: "POST",
+      }
+    );
     if (!response.ok) {
       throw new Error(`Failed to generate Pokemon: ${response.statusText}`);
     }
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-coffee-log/service"
+	"go-coffee-log/storage"
+	"log"
+	"net/http"
+)
+
+// AccountHandler handles HTTP requests for exporting and deleting user data
+type AccountHandler struct {
+	accountService *service.AccountService
+	authService    *service.AuthService // optional, set on shared servers
+	cipher         *storage.Cipher      // optional, enables encrypted exports
+}
+
+// NewAccountHandler creates a new account handler
+func NewAccountHandler(accountService *service.AccountService) *AccountHandler {
+	return &AccountHandler{
+		accountService: accountService,
+	}
+}
+
+// SetAuthService makes DELETE /users/me remove only the caller's account
+// for members who are not the server admin
+func (h *AccountHandler) SetAuthService(authService *service.AuthService) {
+	h.authService = authService
+}
+
+// SetCipher enables encrypted backup archives via ?encrypt=true
+func (h *AccountHandler) SetCipher(cipher *storage.Cipher) {
+	h.cipher = cipher
+}
+
+// ExportData handles GET /users/me/export
+// Members get only what they added; the full dump is the admin's.
+// With ?encrypt=true the archive is encrypted with the server's data key;
+// decrypt it with -decrypt-backup or import it as is.
+func (h *AccountHandler) ExportData(w http.ResponseWriter, r *http.Request) {
+	encrypt := r.URL.Query().Get("encrypt") == "true"
+	if encrypt && h.cipher == nil {
+		respondError(w, http.StatusBadRequest, "Encrypted exports require a data key to be configured")
+		return
+	}
+
+	var export *service.DataExport
+	var err error
+	if user, ok := UserFromContext(r.Context()); ok && !user.IsAdmin {
+		export, err = h.accountService.ExportUser(user.ID)
+	} else {
+		export, err = h.accountService.Export(callerID(r))
+	}
+	if err != nil {
+		log.Printf("ERROR: Data export failed: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to export data")
+		return
+	}
+
+	filename := fmt.Sprintf("coffee-dex-export-%s.json", export.ExportedAt.Format("20060102"))
+	if encrypt {
+		data, err := json.MarshalIndent(export, "", "  ")
+		if err == nil {
+			data, err = h.cipher.Seal(data)
+		}
+		if err != nil {
+			log.Printf("ERROR: Failed to encrypt data export: %v", err)
+			respondError(w, http.StatusInternalServerError, "Failed to export data")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+".enc"))
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write(data); err != nil {
+			log.Printf("ERROR: Failed to write data export: %v", err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(export); err != nil {
+		log.Printf("ERROR: Failed to write data export: %v", err)
+	}
+}
+
+// DeleteAccount handles DELETE /users/me?confirm=true
+func (h *AccountHandler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("confirm") != "true" {
+		respondError(w, http.StatusBadRequest, "This permanently deletes all data; repeat the request with ?confirm=true")
+		return
+	}
+
+	// Invited members leave the server with their own data; only the admin
+	// owns the collection
+	if user, ok := UserFromContext(r.Context()); ok && !user.IsAdmin && h.authService != nil {
+		if err := h.accountService.DeleteUserData(user.ID); err != nil {
+			log.Printf("ERROR: Failed to delete data of user %s: %v", user.ID, err)
+			respondError(w, http.StatusInternalServerError, "Failed to delete account")
+			return
+		}
+		if err := h.authService.DeleteUser(user.ID); err != nil {
+			log.Printf("ERROR: Failed to delete user %s: %v", user.ID, err)
+			respondError(w, http.StatusInternalServerError, "Failed to delete account")
+			return
+		}
+		respondJSON(w, http.StatusOK, map[string]string{"message": "Account deleted"})
+		return
+	}
+
+	summary, err := h.accountService.DeleteAll()
+	if err != nil {
+		log.Printf("ERROR: Data deletion failed: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to delete data")
+		return
+	}
+
+	log.Printf("INFO: Deleted all user data: %d coffees, %d brews, %d Pokemon, %d brewers",
+		summary.Coffees, summary.Brews, summary.Pokemon, summary.Brewers)
+	respondJSON(w, http.StatusOK, summary)
+}
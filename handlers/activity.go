@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"go-coffee-log/service"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// ActivityHandler handles HTTP requests for the activity log
+type ActivityHandler struct {
+	activity *service.ActivityLog
+}
+
+// NewActivityHandler creates a new activity handler
+func NewActivityHandler(activity *service.ActivityLog) *ActivityHandler {
+	return &ActivityHandler{activity: activity}
+}
+
+// ListActivity handles GET /activity?type=&limit=&offset=, recent events
+// across the whole instance, newest first
+func (h *ActivityHandler) ListActivity(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	limit, offset := 0, 0
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			respondError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = parsed
+	}
+	if value := query.Get("offset"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			respondError(w, http.StatusBadRequest, "offset must be a non-negative integer")
+			return
+		}
+		offset = parsed
+	}
+
+	page, err := h.activity.List(query.Get("type"), offset, limit)
+	if err != nil {
+		log.Printf("ERROR: Failed to list activity: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to list activity")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, page)
+}
@@ -0,0 +1,471 @@
+package handlers
+
+import (
+	"encoding/json"
+	"go-coffee-log/service"
+	"go-coffee-log/storage"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// maxImportBytes caps the size of an uploaded instance archive, photos
+// included
+const maxImportBytes = 512 << 20
+
+// AdminHandler handles HTTP requests for operational/admin endpoints
+type AdminHandler struct {
+	llmUsage           *service.LLMUsageTracker
+	llmLimiter         *service.LLMLimiter // optional
+	importService      *service.ImportService
+	replicationService *service.ReplicationService // optional
+	cipher             *storage.Cipher             // optional, opens encrypted archives
+	requestLog         *RequestLog                 // optional
+	integrity          storage.IntegrityChecker    // optional, MySQL only
+	schema             storage.SchemaInspector     // optional, MySQL only
+	querier            storage.AnalyticsQuerier    // optional, MySQL only
+	pokemonSync        *service.PokemonSyncService // optional, MySQL only
+	scheduler          *service.Scheduler          // optional
+	outbox             *service.Outbox             // optional
+}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(llmUsage *service.LLMUsageTracker, importService *service.ImportService) *AdminHandler {
+	return &AdminHandler{
+		llmUsage:      llmUsage,
+		importService: importService,
+	}
+}
+
+// SetReplicationService enables the replica consistency endpoints
+func (h *AdminHandler) SetReplicationService(replicationService *service.ReplicationService) {
+	h.replicationService = replicationService
+}
+
+// SetCipher lets ImportInstance accept archives encrypted with the data key
+func (h *AdminHandler) SetCipher(cipher *storage.Cipher) {
+	h.cipher = cipher
+}
+
+// SetRequestLog enables GET /admin/recent-requests
+func (h *AdminHandler) SetRequestLog(requestLog *RequestLog) {
+	h.requestLog = requestLog
+}
+
+// SetLLMLimiter adds the LLM call queue to GET /admin/llm/usage
+func (h *AdminHandler) SetLLMLimiter(limiter *service.LLMLimiter) {
+	h.llmLimiter = limiter
+}
+
+// GetLLMUsage handles GET /admin/llm/usage
+func (h *AdminHandler) GetLLMUsage(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r); !ok {
+		return
+	}
+
+	if h.llmUsage == nil {
+		respondError(w, http.StatusNotFound, "LLM usage tracking is not enabled")
+		return
+	}
+
+	usage := struct {
+		service.LLMUsage
+		Queue *service.LLMQueueStats `json:"queue,omitempty"`
+	}{LLMUsage: h.llmUsage.Snapshot()}
+	if h.llmLimiter != nil {
+		queue := h.llmLimiter.Snapshot()
+		usage.Queue = &queue
+	}
+	respondJSON(w, http.StatusOK, usage)
+}
+
+// ImportInstance handles POST /admin/import-instance?conflict=keep|skip|trade&dry_run=true
+// The body is an archive from another instance's GET /users/me/export,
+// optionally encrypted with this server's data key.
+func (h *AdminHandler) ImportInstance(w http.ResponseWriter, r *http.Request) {
+	// On shared servers only the admin may import
+	if _, ok := requireAdmin(w, r); !ok {
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxImportBytes))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid archive payload")
+		return
+	}
+	defer r.Body.Close()
+
+	if storage.IsEncrypted(body) {
+		if h.cipher == nil {
+			respondError(w, http.StatusBadRequest, "Archive is encrypted and no data key is configured")
+			return
+		}
+		if body, err = h.cipher.Open(body); err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	var archive service.DataExport
+	if err := json.Unmarshal(body, &archive); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid archive payload")
+		return
+	}
+
+	query := r.URL.Query()
+	report, err := h.importService.ImportInstance(archive, query.Get("conflict"), callerID(r), isDryRun(r))
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid") {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Printf("ERROR: Instance import failed: %v", err)
+		if report != nil {
+			// Partially imported; tell the caller what made it in
+			respondJSON(w, http.StatusInternalServerError, map[string]interface{}{
+				"error":  "Import failed part way through",
+				"report": report,
+			})
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "Failed to import archive")
+		return
+	}
+
+	status := http.StatusCreated
+	if report.DryRun {
+		status = http.StatusOK
+	}
+	respondJSON(w, status, report)
+}
+
+// CheckReplication handles GET /admin/replication (report drift) and
+// POST /admin/replication/repair (make the secondary match the primary)
+func (h *AdminHandler) CheckReplication(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r); !ok {
+		return
+	}
+	if h.replicationService == nil {
+		respondError(w, http.StatusNotFound, "Replication is not enabled")
+		return
+	}
+
+	repair := r.Method == http.MethodPost
+	report, err := h.replicationService.Check(repair)
+	if err != nil {
+		log.Printf("ERROR: Replication check failed: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to check replica")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, report)
+}
+
+// SetIntegrityChecker enables the data integrity endpoints
+func (h *AdminHandler) SetIntegrityChecker(integrity storage.IntegrityChecker) {
+	h.integrity = integrity
+}
+
+// CheckIntegrity handles GET /admin/doctor (report problems) and
+// POST /admin/doctor/repair (fix what can be fixed)
+func (h *AdminHandler) CheckIntegrity(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r); !ok {
+		return
+	}
+	if h.integrity == nil {
+		respondError(w, http.StatusNotFound, "Integrity checks require MySQL storage")
+		return
+	}
+
+	repair := r.Method == http.MethodPost
+	report, err := h.integrity.CheckIntegrity(repair)
+	if err != nil {
+		log.Printf("ERROR: Integrity check failed: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to check data integrity")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, report)
+}
+
+// SetSchemaInspector enables the schema export endpoint
+func (h *AdminHandler) SetSchemaInspector(schema storage.SchemaInspector) {
+	h.schema = schema
+}
+
+// GetSchema handles GET /admin/schema?format=json|dot, describing the
+// tables and relationships for external reporting tools
+func (h *AdminHandler) GetSchema(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r); !ok {
+		return
+	}
+	if h.schema == nil {
+		respondError(w, http.StatusNotFound, "Schema export requires MySQL storage")
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "dot" {
+		respondError(w, http.StatusBadRequest, "format must be json or dot")
+		return
+	}
+
+	schema, err := h.schema.Schema()
+	if err != nil {
+		log.Printf("ERROR: Failed to read schema: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to read schema")
+		return
+	}
+
+	if format == "dot" {
+		w.Header().Set("Content-Type", "text/vnd.graphviz; charset=utf-8")
+		w.Header().Set("Content-Disposition", `inline; filename="coffee-dex-schema.dot"`)
+		io.WriteString(w, schema.DOT())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, schema)
+}
+
+// SetAnalyticsQuerier enables the read-only SQL endpoint
+func (h *AdminHandler) SetAnalyticsQuerier(querier storage.AnalyticsQuerier) {
+	h.querier = querier
+}
+
+// RunQuery handles POST /admin/query with {"sql": "SELECT ...", "limit": N}.
+// Only single SELECT statements over the coffee tables are accepted.
+func (h *AdminHandler) RunQuery(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r); !ok {
+		return
+	}
+	if h.querier == nil {
+		respondError(w, http.StatusNotFound, "SQL queries require MySQL storage")
+		return
+	}
+
+	var req struct {
+		SQL   string `json:"sql"`
+		Limit int    `json:"limit"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	if req.Limit < 0 || req.Limit > storage.MaxQueryRows {
+		respondError(w, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(storage.MaxQueryRows))
+		return
+	}
+
+	result, err := h.querier.Query(r.Context(), req.SQL, req.Limit)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "invalid query"):
+			respondError(w, http.StatusBadRequest, err.Error())
+		case strings.Contains(err.Error(), "query failed"):
+			// Syntax errors, unknown columns and timeouts are the caller's to fix
+			respondError(w, http.StatusUnprocessableEntity, err.Error())
+		default:
+			log.Printf("ERROR: Analytics query failed: %v", err)
+			respondError(w, http.StatusInternalServerError, "Failed to run query")
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusOK, result)
+}
+
+// SetPokemonSyncService enables the Pokemon reference data re-sync endpoints
+func (h *AdminHandler) SetPokemonSyncService(pokemonSync *service.PokemonSyncService) {
+	h.pokemonSync = pokemonSync
+}
+
+// SyncPokemon handles GET /admin/pokemon-sync (show what a re-sync from
+// PokeAPI would change) and POST /admin/pokemon-sync/apply with an optional
+// {"remote_checksum": "..."} from the reviewed diff
+func (h *AdminHandler) SyncPokemon(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r); !ok {
+		return
+	}
+	if h.pokemonSync == nil {
+		respondError(w, http.StatusNotFound, "Pokemon features are not enabled")
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		diff, err := h.pokemonSync.Diff(r.Context())
+		if err != nil {
+			h.respondPokemonSyncError(w, err)
+			return
+		}
+		respondJSON(w, http.StatusOK, diff)
+		return
+	}
+
+	var req struct {
+		RemoteChecksum string `json:"remote_checksum"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid request payload")
+			return
+		}
+		defer r.Body.Close()
+	}
+
+	diff, err := h.pokemonSync.Apply(r.Context(), req.RemoteChecksum)
+	if err != nil {
+		h.respondPokemonSyncError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, diff)
+}
+
+// respondPokemonSyncError maps a re-sync failure to a status code
+func (h *AdminHandler) respondPokemonSyncError(w http.ResponseWriter, err error) {
+	switch {
+	case strings.Contains(err.Error(), "changed since the diff"):
+		respondError(w, http.StatusConflict, err.Error())
+	case strings.Contains(err.Error(), "PokeAPI"):
+		log.Printf("ERROR: Pokemon re-sync failed: %v", err)
+		respondError(w, http.StatusBadGateway, err.Error())
+	default:
+		log.Printf("ERROR: Pokemon re-sync failed: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to re-sync Pokemon data")
+	}
+}
+
+// GetRecentRequests handles GET /admin/recent-requests?limit=N
+func (h *AdminHandler) GetRecentRequests(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r); !ok {
+		return
+	}
+	if h.requestLog == nil {
+		respondError(w, http.StatusNotFound, "Request capture is not enabled")
+		return
+	}
+
+	limit := 0
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			respondError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = parsed
+	}
+
+	respondJSON(w, http.StatusOK, h.requestLog.Recent(limit))
+}
+
+// SetScheduler enables the background job endpoints
+func (h *AdminHandler) SetScheduler(scheduler *service.Scheduler) {
+	h.scheduler = scheduler
+}
+
+// ListJobs handles GET /admin/jobs
+func (h *AdminHandler) ListJobs(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r); !ok {
+		return
+	}
+
+	respondJSON(w, http.StatusOK, h.scheduler.Jobs())
+}
+
+// GetJob handles GET /admin/jobs/{name}
+func (h *AdminHandler) GetJob(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r); !ok {
+		return
+	}
+
+	job, err := h.scheduler.Job(r.PathValue("name"))
+	if err != nil {
+		h.respondJobError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, job)
+}
+
+// RunJob handles POST /admin/jobs/{name}/run, starting a job now. The job
+// runs in the background; poll GET /admin/jobs/{name} for the outcome.
+func (h *AdminHandler) RunJob(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r); !ok {
+		return
+	}
+
+	job, err := h.scheduler.Trigger(r.PathValue("name"))
+	if err != nil {
+		h.respondJobError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusAccepted, job)
+}
+
+// respondJobError maps scheduler errors to HTTP responses
+func (h *AdminHandler) respondJobError(w http.ResponseWriter, err error) {
+	switch {
+	case strings.Contains(err.Error(), "not found"):
+		respondError(w, http.StatusNotFound, "Job not found")
+	case strings.Contains(err.Error(), "already running"):
+		respondError(w, http.StatusConflict, err.Error())
+	default:
+		log.Printf("ERROR: Job request failed: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to run job")
+	}
+}
+
+// SetOutbox enables the outbox endpoints
+func (h *AdminHandler) SetOutbox(outbox *service.Outbox) {
+	h.outbox = outbox
+}
+
+// ListOutbox handles GET /admin/outbox?status=pending|delivered|dead
+func (h *AdminHandler) ListOutbox(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r); !ok {
+		return
+	}
+
+	messages, err := h.outbox.Messages(r.URL.Query().Get("status"))
+	if err != nil {
+		h.respondOutboxError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, messages)
+}
+
+// RetryOutbox handles POST /admin/outbox/{id}/retry, giving an undelivered
+// message a fresh set of attempts
+func (h *AdminHandler) RetryOutbox(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r); !ok {
+		return
+	}
+
+	message, err := h.outbox.Retry(r.PathValue("id"))
+	if err != nil {
+		h.respondOutboxError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusAccepted, message)
+}
+
+// respondOutboxError maps outbox errors to HTTP responses
+func (h *AdminHandler) respondOutboxError(w http.ResponseWriter, err error) {
+	switch {
+	case strings.Contains(err.Error(), "not found"):
+		respondError(w, http.StatusNotFound, "Message not found")
+	case strings.Contains(err.Error(), "invalid status"):
+		respondError(w, http.StatusBadRequest, err.Error())
+	case strings.Contains(err.Error(), "already delivered"):
+		respondError(w, http.StatusConflict, err.Error())
+	default:
+		log.Printf("ERROR: Outbox request failed: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to process outbox")
+	}
+}
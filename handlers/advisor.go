@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"encoding/json"
+	"go-coffee-log/service"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// AdvisorHandler handles HTTP requests for brew troubleshooting
+type AdvisorHandler struct {
+	advisorService *service.AdvisorService
+}
+
+// NewAdvisorHandler creates a new advisor handler
+func NewAdvisorHandler(advisorService *service.AdvisorService) *AdvisorHandler {
+	return &AdvisorHandler{
+		advisorService: advisorService,
+	}
+}
+
+// Diagnose handles POST /coffees/{id}/diagnose
+func (h *AdvisorHandler) Diagnose(w http.ResponseWriter, r *http.Request) {
+	coffeeID := r.PathValue("id")
+
+	var request service.DiagnoseRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	diagnosis, err := h.advisorService.Diagnose(r.Context(), coffeeID, request)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "not found"):
+			respondError(w, http.StatusNotFound, "Coffee not found")
+		case strings.Contains(err.Error(), "required"):
+			respondError(w, http.StatusBadRequest, err.Error())
+		default:
+			log.Printf("ERROR: Diagnose failed for coffee %s: %v", coffeeID, err)
+			respondError(w, http.StatusInternalServerError, "Failed to diagnose brew")
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusOK, diagnosis)
+}
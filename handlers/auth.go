@@ -0,0 +1,296 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"go-coffee-log/models"
+	"go-coffee-log/service"
+	"log"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// contextKey namespaces values stored in the request context
+type contextKey string
+
+// userContextKey holds the authenticated models.User
+const userContextKey contextKey = "user"
+
+// UserFromContext returns the authenticated user, if any
+func UserFromContext(ctx context.Context) (models.User, bool) {
+	user, ok := ctx.Value(userContextKey).(models.User)
+	return user, ok
+}
+
+// AuthHandler handles authentication, users and invitations
+type AuthHandler struct {
+	authService *service.AuthService
+	publicRead  atomic.Bool
+}
+
+// NewAuthHandler creates a new auth handler
+func NewAuthHandler(authService *service.AuthService) *AuthHandler {
+	return &AuthHandler{
+		authService: authService,
+	}
+}
+
+// publicReadPaths are the curated views served without a token in public
+// read-only mode: the Pokedex, statistics, recent catches and share pages
+var publicReadPaths = []string{
+	"/sitemap.xml",
+	"/pokedex",
+	"/pokedex/stats",
+	"/pokedex/living",
+	"/statistics",
+	"/statistics/temperature",
+	"/statistics/brews",
+	"/statistics/trends",
+	"/statistics/drift",
+	"/coffees/recent",
+}
+
+// EnablePublicRead lets anonymous visitors read the curated public views.
+// Everything else, and every mutation, still requires a token.
+func (h *AuthHandler) EnablePublicRead() {
+	h.SetPublicRead(true)
+}
+
+// SetPublicRead switches public read-only mode on or off at runtime
+func (h *AuthHandler) SetPublicRead(enabled bool) {
+	h.publicRead.Store(enabled)
+}
+
+// Middleware requires a valid API token on every request except the health
+// check, invite redemption and workshops. Tokens are read from "Authorization: Bearer"
+// or, for EventSource and WebSocket clients that cannot set headers, a token
+// query parameter.
+func (h *AuthHandler) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.isPublic(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token := r.URL.Query().Get("token")
+		if header := r.Header.Get("Authorization"); strings.HasPrefix(header, "Bearer ") {
+			token = strings.TrimPrefix(header, "Bearer ")
+		}
+
+		user, err := h.authService.Authenticate(token)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			respondError(w, http.StatusUnauthorized, "Authentication required")
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userContextKey, user)))
+	})
+}
+
+// isPublic reports whether a request may skip authentication
+func (h *AuthHandler) isPublic(r *http.Request) bool {
+	if r.URL.Path == "/health" {
+		return true
+	}
+	if r.Method == http.MethodPost &&
+		strings.HasPrefix(r.URL.Path, "/invites/") &&
+		strings.HasSuffix(r.URL.Path, "/redeem") {
+		return true
+	}
+	// Workshop participants are let in by the workshop's unguessable ID
+	if strings.HasPrefix(r.URL.Path, "/workshops/") {
+		return true
+	}
+
+	if !h.publicRead.Load() || (r.Method != http.MethodGet && r.Method != http.MethodHead) {
+		return false
+	}
+	for _, path := range publicReadPaths {
+		if r.URL.Path == path {
+			return true
+		}
+	}
+	// Sprites, so unfurled share pages can show their image
+	if strings.HasPrefix(r.URL.Path, "/static/") || strings.HasPrefix(r.URL.Path, "/images/pokemon-") {
+		return true
+	}
+
+	// Individual catches and their share pages
+	for _, prefix := range []string{"/pokemon/", "/share/coffees/"} {
+		rest, ok := strings.CutPrefix(r.URL.Path, prefix)
+		if ok && rest != "" && !strings.Contains(rest, "/") {
+			return true
+		}
+	}
+	return false
+}
+
+// requireAdmin writes a 403 and returns false unless the caller is an admin.
+// Servers without authentication have no users and let anyone in; with it,
+// every route but the public ones has a user by the time it gets here.
+func requireAdmin(w http.ResponseWriter, r *http.Request) (models.User, bool) {
+	user, ok := UserFromContext(r.Context())
+	if !ok {
+		return models.User{}, true
+	}
+	if !user.IsAdmin {
+		respondError(w, http.StatusForbidden, "Admin access required")
+		return models.User{}, false
+	}
+	return user, true
+}
+
+// CreateInvite handles POST /invites
+func (h *AuthHandler) CreateInvite(w http.ResponseWriter, r *http.Request) {
+	admin, ok := requireAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		ExpiresInHours int `json:"expires_in_hours"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid request payload")
+			return
+		}
+		defer r.Body.Close()
+	}
+	if req.ExpiresInHours < 0 {
+		respondError(w, http.StatusBadRequest, "expires_in_hours cannot be negative")
+		return
+	}
+
+	invite, err := h.authService.CreateInvite(admin.ID, time.Duration(req.ExpiresInHours)*time.Hour)
+	if err != nil {
+		log.Printf("ERROR: Failed to create invite: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to create invite")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, invite)
+}
+
+// ListInvites handles GET /invites
+func (h *AuthHandler) ListInvites(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r); !ok {
+		return
+	}
+
+	invites, err := h.authService.ListInvites()
+	if err != nil {
+		log.Printf("ERROR: Failed to list invites: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to list invites")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, invites)
+}
+
+// RevokeInvite handles DELETE /invites/{code}
+func (h *AuthHandler) RevokeInvite(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r); !ok {
+		return
+	}
+
+	if err := h.authService.RevokeInvite(r.PathValue("code")); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			respondError(w, http.StatusNotFound, "Invite not found")
+			return
+		}
+		log.Printf("ERROR: Failed to revoke invite: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to revoke invite")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RedeemInvite handles POST /invites/{code}/redeem
+func (h *AuthHandler) RedeemInvite(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	user, token, err := h.authService.RedeemInvite(r.PathValue("code"), req.Name)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "not found"):
+			respondError(w, http.StatusNotFound, "Invite not found")
+		case strings.Contains(err.Error(), "invite is"):
+			respondError(w, http.StatusGone, err.Error())
+		case strings.Contains(err.Error(), "name"):
+			respondError(w, http.StatusBadRequest, err.Error())
+		default:
+			log.Printf("ERROR: Failed to redeem invite: %v", err)
+			respondError(w, http.StatusInternalServerError, "Failed to redeem invite")
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, map[string]interface{}{
+		"user":  user,
+		"token": token,
+	})
+}
+
+// GetMe handles GET /users/me
+func (h *AuthHandler) GetMe(w http.ResponseWriter, r *http.Request) {
+	user, ok := UserFromContext(r.Context())
+	if !ok {
+		respondError(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, user)
+}
+
+// ListUsers handles GET /users
+func (h *AuthHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r); !ok {
+		return
+	}
+
+	users, err := h.authService.ListUsers()
+	if err != nil {
+		log.Printf("ERROR: Failed to list users: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to list users")
+		return
+	}
+	if users == nil {
+		users = []models.User{}
+	}
+
+	respondJSON(w, http.StatusOK, users)
+}
+
+// DeleteUser handles DELETE /users/{id}
+func (h *AuthHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r); !ok {
+		return
+	}
+
+	if err := h.authService.DeleteUser(r.PathValue("id")); err != nil {
+		switch {
+		case strings.Contains(err.Error(), "not found"):
+			respondError(w, http.StatusNotFound, "User not found")
+		case strings.Contains(err.Error(), "cannot be deleted"):
+			respondError(w, http.StatusBadRequest, err.Error())
+		default:
+			log.Printf("ERROR: Failed to delete user: %v", err)
+			respondError(w, http.StatusInternalServerError, "Failed to delete user")
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"go-coffee-log/service"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// AutocompleteHandler handles HTTP requests for value suggestions
+type AutocompleteHandler struct {
+	autocompleteService *service.AutocompleteService
+}
+
+// NewAutocompleteHandler creates a new autocomplete handler
+func NewAutocompleteHandler(autocompleteService *service.AutocompleteService) *AutocompleteHandler {
+	return &AutocompleteHandler{autocompleteService: autocompleteService}
+}
+
+// Suggest handles GET /autocomplete?field=roaster&q=...&limit=N
+func (h *AutocompleteHandler) Suggest(w http.ResponseWriter, r *http.Request) {
+	field := r.URL.Query().Get("field")
+	query := r.URL.Query().Get("q")
+
+	limit := 0
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			respondError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = parsed
+	}
+
+	suggestions, err := h.autocompleteService.Suggest(field, query, limit)
+	if err != nil {
+		if strings.Contains(err.Error(), "unknown field") {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Printf("ERROR: Failed to autocomplete %s: %v", field, err)
+		respondError(w, http.StatusInternalServerError, "Failed to load suggestions")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"field":       field,
+		"query":       query,
+		"suggestions": suggestions,
+	})
+}
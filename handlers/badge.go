@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"go-coffee-log/service"
+	"log"
+	"net/http"
+)
+
+// BadgeHandler handles HTTP requests for gym badges
+type BadgeHandler struct {
+	badgeService *service.BadgeService
+}
+
+// NewBadgeHandler creates a new badge handler
+func NewBadgeHandler(badgeService *service.BadgeService) *BadgeHandler {
+	return &BadgeHandler{badgeService: badgeService}
+}
+
+// ListBadges handles GET /badges with the caller's badges and the progress
+// toward the rest. Badges are awarded as Pokemon are caught; listing them
+// also picks up any the caller qualified for otherwise, e.g. by changing a
+// coffee's dripper.
+func (h *BadgeHandler) ListBadges(w http.ResponseWriter, r *http.Request) {
+	userID := callerID(r)
+	if _, err := h.badgeService.Award(userID); err != nil {
+		log.Printf("ERROR: Failed to award badges: %v", err)
+	}
+
+	badges, err := h.badgeService.List(userID)
+	if err != nil {
+		log.Printf("ERROR: Failed to list badges: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to fetch badges")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, badges)
+}
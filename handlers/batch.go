@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"encoding/json"
+	"go-coffee-log/service"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// BatchHandler handles HTTP requests that apply several changes at once
+type BatchHandler struct {
+	batchService *service.BatchService
+}
+
+// NewBatchHandler creates a new batch handler
+func NewBatchHandler(batchService *service.BatchService) *BatchHandler {
+	return &BatchHandler{batchService: batchService}
+}
+
+// ApplyBatch handles POST /batch with an array of operations: create,
+// update, patch and delete coffees, and add_tags. A created coffee can be
+// named with "ref" and changed by later operations through the same ref.
+// All operations are applied in one transaction or none are; a failed batch
+// answers with the status of the operation that stopped it.
+func (h *BatchHandler) ApplyBatch(w http.ResponseWriter, r *http.Request) {
+	var operations []service.BatchOperation
+	if err := json.NewDecoder(r.Body).Decode(&operations); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request payload; expected an array of operations")
+		return
+	}
+	defer r.Body.Close()
+	for _, operation := range operations {
+		if operation.Coffee != nil {
+			operation.Coffee.AddedBy = callerID(r)
+		}
+	}
+
+	report, err := h.batchService.Apply(operations, isDryRun(r))
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid") {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			// A coffee the batch changes was deleted after it was validated
+			respondError(w, http.StatusConflict, "Batch conflicts with a concurrent change: "+err.Error())
+			return
+		}
+		log.Printf("ERROR: Failed to apply batch: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to apply batch")
+		return
+	}
+
+	status := http.StatusOK
+	for _, result := range report.Results {
+		if result.Error != "" {
+			status = result.Status
+			break
+		}
+	}
+	respondJSON(w, status, report)
+}
@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"encoding/json"
+	"go-coffee-log/models"
+	"go-coffee-log/service"
+	"log"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// BrewHandler handles HTTP requests for brew sessions and dial-in
+type BrewHandler struct {
+	brewService      *service.BrewService
+	sharedCollection atomic.Bool
+}
+
+// NewBrewHandler creates a new brew handler
+func NewBrewHandler(brewService *service.BrewService) *BrewHandler {
+	return &BrewHandler{
+		brewService: brewService,
+	}
+}
+
+// EnableSharedCollection records the authenticated user on every logged brew
+func (h *BrewHandler) EnableSharedCollection() {
+	h.SetSharedCollection(true)
+}
+
+// SetSharedCollection switches recording who brewed on or off at runtime
+func (h *BrewHandler) SetSharedCollection(enabled bool) {
+	h.sharedCollection.Store(enabled)
+}
+
+// LogBrew handles POST /coffees/{id}/brews
+func (h *BrewHandler) LogBrew(w http.ResponseWriter, r *http.Request) {
+	coffeeID := r.PathValue("id")
+
+	var brew models.Brew
+	if err := json.NewDecoder(r.Body).Decode(&brew); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	brew.BrewedBy = h.brewedBy(r)
+
+	if isDryRun(r) {
+		respondPreview(w, func() (service.DryRun, error) {
+			return h.brewService.PreviewLogBrew(coffeeID, brew)
+		}, respondBrewError)
+		return
+	}
+
+	created, err := h.brewService.LogBrew(coffeeID, brew)
+	if err != nil {
+		respondBrewError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, created)
+}
+
+// respondBrewError maps the errors of logging a brew
+func respondBrewError(w http.ResponseWriter, err error) {
+	if strings.Contains(err.Error(), "not found") {
+		respondError(w, http.StatusNotFound, "Coffee not found")
+		return
+	}
+	respondError(w, http.StatusBadRequest, err.Error())
+}
+
+// brewedBy returns who to record as brewing, taken from the token and never
+// from the payload; empty unless shared-collection mode is on
+func (h *BrewHandler) brewedBy(r *http.Request) string {
+	if user, ok := UserFromContext(r.Context()); ok && h.sharedCollection.Load() {
+		return user.ID
+	}
+	return ""
+}
+
+// ListBrews handles GET /coffees/{id}/brews?brewed_by=
+// brewed_by accepts a user ID or "me"
+func (h *BrewHandler) ListBrews(w http.ResponseWriter, r *http.Request) {
+	coffeeID := r.PathValue("id")
+
+	brews, err := h.brewService.GetBrews(coffeeID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			respondError(w, http.StatusNotFound, "Coffee not found")
+			return
+		}
+		log.Printf("ERROR: Failed to list brews for coffee %s: %v", coffeeID, err)
+		respondError(w, http.StatusInternalServerError, "Failed to list brews")
+		return
+	}
+
+	if brewedBy := r.URL.Query().Get("brewed_by"); brewedBy != "" {
+		if user, ok := UserFromContext(r.Context()); ok && brewedBy == "me" {
+			brewedBy = user.ID
+		}
+		var filtered []models.Brew
+		for _, brew := range brews {
+			if brew.BrewedBy == brewedBy {
+				filtered = append(filtered, brew)
+			}
+		}
+		brews = filtered
+	}
+
+	if brews == nil {
+		brews = []models.Brew{}
+	}
+
+	respondJSON(w, http.StatusOK, brews)
+}
+
+// DeleteBrew handles DELETE /coffees/{id}/brews/{brew_id}
+func (h *BrewHandler) DeleteBrew(w http.ResponseWriter, r *http.Request) {
+	coffeeID := r.PathValue("id")
+	brewID := r.PathValue("brew_id")
+
+	if isDryRun(r) {
+		respondPreview(w, func() (service.DryRun, error) {
+			return h.brewService.PreviewDeleteBrew(coffeeID, brewID)
+		}, func(w http.ResponseWriter, err error) {
+			respondDeleteBrewError(w, brewID, err)
+		})
+		return
+	}
+
+	if err := h.brewService.DeleteBrew(coffeeID, brewID); err != nil {
+		respondDeleteBrewError(w, brewID, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// respondDeleteBrewError maps the errors of deleting a brew
+func respondDeleteBrewError(w http.ResponseWriter, brewID string, err error) {
+	if strings.Contains(err.Error(), "not found") {
+		respondError(w, http.StatusNotFound, "Brew not found")
+		return
+	}
+	log.Printf("ERROR: Failed to delete brew %s: %v", brewID, err)
+	respondError(w, http.StatusInternalServerError, "Failed to delete brew")
+}
+
+// GetDialIn handles GET /coffees/{id}/dial-in
+func (h *BrewHandler) GetDialIn(w http.ResponseWriter, r *http.Request) {
+	coffeeID := r.PathValue("id")
+
+	status, err := h.brewService.GetDialIn(coffeeID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			respondError(w, http.StatusNotFound, "Coffee not found")
+			return
+		}
+		log.Printf("ERROR: Failed to compute dial-in for coffee %s: %v", coffeeID, err)
+		respondError(w, http.StatusInternalServerError, "Failed to compute dial-in")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, status)
+}
+
+// GetControlChart handles GET /brews/control-chart?coffee_id=
+func (h *BrewHandler) GetControlChart(w http.ResponseWriter, r *http.Request) {
+	coffeeID := r.URL.Query().Get("coffee_id")
+
+	chart, err := h.brewService.GetControlChart(coffeeID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			respondError(w, http.StatusNotFound, "Coffee not found")
+			return
+		}
+		log.Printf("ERROR: Failed to build control chart: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to build control chart")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, chart)
+}
+
+// GetTemperatureStats handles GET /statistics/temperature?unit=C|F
+func (h *BrewHandler) GetTemperatureStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.brewService.GetTemperatureStats(r.URL.Query().Get("unit"))
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid temperature unit") {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Printf("ERROR: Failed to compute temperature statistics: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to compute temperature statistics")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, stats)
+}
+
+// GetBrewStats handles GET /statistics/brews?split=combined|person
+func (h *BrewHandler) GetBrewStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.brewService.GetBrewStats(r.URL.Query().Get("split"))
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid split") {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Printf("ERROR: Failed to compute brew statistics: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to compute brew statistics")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, stats)
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"encoding/json"
 	"fmt"
+	"go-coffee-log/models"
 	"go-coffee-log/service"
 	"log"
 	"net/http"
@@ -41,6 +42,15 @@ func (h *BrewerHandler) CreateBrewer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
+	if isDryRun(r) {
+		respondPreview(w, func() (service.DryRun, error) {
+			return h.brewerService.PreviewCreateBrewer(req.Name, req.PokeballType)
+		}, func(w http.ResponseWriter, err error) {
+			respondError(w, http.StatusBadRequest, err.Error())
+		})
+		return
+	}
+	
 	brewer, err := h.brewerService.CreateBrewer(req.Name, req.PokeballType)
 	if err != nil {
 		log.Printf("ERROR: CreateBrewer failed: %v", err)
@@ -69,6 +79,19 @@ func (h *BrewerHandler) GetAllBrewers(w http.ResponseWriter, r *http.Request) {
 func (h *BrewerHandler) DeleteBrewer(w http.ResponseWriter, r *http.Request) {
 	brewerID := r.PathValue("id")
 	
+	if isDryRun(r) {
+		respondPreview(w, func() (service.DryRun, error) {
+			return h.brewerService.PreviewDeleteBrewer(brewerID)
+		}, func(w http.ResponseWriter, err error) {
+			if strings.Contains(err.Error(), "not found") {
+				respondError(w, http.StatusNotFound, "Brewer not found")
+			} else {
+				respondError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to delete brewer: %v", err))
+			}
+		})
+		return
+	}
+	
 	if err := h.brewerService.DeleteBrewer(brewerID); err != nil {
 		if strings.Contains(err.Error(), "not found") {
 			log.Printf("ERROR: DeleteBrewer - brewer not found: %s", brewerID)
@@ -96,8 +119,9 @@ func (h *BrewerHandler) AddStandaloneRecipe(w http.ResponseWriter, r *http.Reque
 	brewerID := r.PathValue("id")
 	
 	var req struct {
-		Name  string   `json:"name"`
-		Steps []string `json:"steps"`
+		Name        string              `json:"name"`
+		Steps       []string            `json:"steps"`
+		Temperature *models.Temperature `json:"temperature,omitempty"`
 	}
 	
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -105,31 +129,55 @@ func (h *BrewerHandler) AddStandaloneRecipe(w http.ResponseWriter, r *http.Reque
 		return
 	}
 	
-	if err := h.brewerService.AddStandaloneRecipe(brewerID, req.Name, req.Steps); err != nil {
-		if strings.Contains(err.Error(), "maximum") {
-			respondError(w, http.StatusBadRequest, err.Error())
-		} else {
-			respondError(w, http.StatusInternalServerError, "Failed to add recipe")
-		}
+	if isDryRun(r) {
+		respondPreview(w, func() (service.DryRun, error) {
+			return h.brewerService.PreviewAddStandaloneRecipe(brewerID, req.Name, req.Steps, req.Temperature)
+		}, respondRecipeError)
+		return
+	}
+	
+	if err := h.brewerService.AddStandaloneRecipe(brewerID, req.Name, req.Steps, req.Temperature); err != nil {
+		respondRecipeError(w, err)
 		return
 	}
 	
 	respondJSON(w, http.StatusCreated, map[string]string{"message": "Recipe added to brewer"})
 }
 
+// respondRecipeError maps the errors of adding a recipe to a brewer
+func respondRecipeError(w http.ResponseWriter, err error) {
+	if strings.Contains(err.Error(), "maximum") || strings.Contains(err.Error(), "temperature") {
+		respondError(w, http.StatusBadRequest, err.Error())
+	} else {
+		respondError(w, http.StatusInternalServerError, "Failed to add recipe")
+	}
+}
+
 // RemoveStandaloneRecipe handles DELETE /brewers/{id}/standalone-recipes/{recipe_id}
 func (h *BrewerHandler) RemoveStandaloneRecipe(w http.ResponseWriter, r *http.Request) {
 	brewerID := r.PathValue("id")
 	recipeID := r.PathValue("recipe_id")
 	
+	if isDryRun(r) {
+		respondPreview(w, func() (service.DryRun, error) {
+			return h.brewerService.PreviewRemoveStandaloneRecipe(brewerID, recipeID)
+		}, respondRemoveRecipeError)
+		return
+	}
+	
 	if err := h.brewerService.RemoveStandaloneRecipe(brewerID, recipeID); err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			respondError(w, http.StatusNotFound, "Recipe not found for this brewer")
-		} else {
-			respondError(w, http.StatusInternalServerError, "Failed to remove recipe")
-		}
+		respondRemoveRecipeError(w, err)
 		return
 	}
 	
 	respondJSON(w, http.StatusOK, map[string]string{"message": "Recipe removed from brewer"})
+}
+
+// respondRemoveRecipeError maps the errors of removing a recipe from a brewer
+func respondRemoveRecipeError(w http.ResponseWriter, err error) {
+	if strings.Contains(err.Error(), "not found") {
+		respondError(w, http.StatusNotFound, "Recipe not found for this brewer")
+	} else {
+		respondError(w, http.StatusInternalServerError, "Failed to remove recipe")
+	}
 }
\ No newline at end of file
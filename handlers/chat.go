@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"encoding/json"
+	"go-coffee-log/service"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// ChatHandler handles HTTP requests for collection Q&A
+type ChatHandler struct {
+	chatService *service.ChatService
+}
+
+// NewChatHandler creates a new chat handler
+func NewChatHandler(chatService *service.ChatService) *ChatHandler {
+	return &ChatHandler{
+		chatService: chatService,
+	}
+}
+
+// Ask handles POST /chat
+func (h *ChatHandler) Ask(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Question string `json:"question"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	response, err := h.chatService.Ask(r.Context(), request.Question)
+	if err != nil {
+		if strings.Contains(err.Error(), "cannot be empty") {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Printf("ERROR: Chat failed: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to answer question")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, response)
+}
@@ -4,7 +4,12 @@ import (
 	"encoding/json"
 	"go-coffee-log/models"
 	"go-coffee-log/service"
+	"go-coffee-log/storage"
+	"io"
+	"log"
 	"net/http"
+	"strconv"
+	"strings"
 )
 
 // CoffeeHandler handles HTTP requests for coffee operations
@@ -12,6 +17,8 @@ import (
 //   - service (*service.CoffeeService) - the service layer to use
 type CoffeeHandler struct {
 	service *service.CoffeeService
+	brewService *service.BrewService // optional, adds dial-in status to coffee detail
+	currencyService *service.CurrencyService // optional, fills in a price's missing currency
 }
 
 // NewCoffeeHandler creates a new coffee handler
@@ -21,6 +28,25 @@ func NewCoffeeHandler(service *service.CoffeeService) *CoffeeHandler {
 	}
 }
 
+// SetBrewService enables the dial-in summary on GET /coffees/{id}
+func (h *CoffeeHandler) SetBrewService(brewService *service.BrewService) {
+	h.brewService = brewService
+}
+
+// SetCurrencyService makes prices entered without a currency use the
+// caller's default currency
+func (h *CoffeeHandler) SetCurrencyService(currencyService *service.CurrencyService) {
+	h.currencyService = currencyService
+}
+
+// applyDefaultCurrency fills in a price's missing currency, if enabled
+func (h *CoffeeHandler) applyDefaultCurrency(r *http.Request, coffee *models.Coffee) error {
+	if h.currencyService == nil {
+		return nil
+	}
+	return h.currencyService.ApplyDefaultCurrency(callerID(r), coffee)
+}
+
 // CreateCoffee handles POST /coffees
 // TODO: Implement this method
 // Requirements:
@@ -38,9 +64,34 @@ func (h *CoffeeHandler) CreateCoffee(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	defer r.Body.Close()
+	coffee.AddedBy = callerID(r)
+	
+	if err := h.applyDefaultCurrency(r, &coffee); err != nil {
+		log.Printf("ERROR: Failed to get default currency: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to create coffee")
+		return
+	}
+	
+	if isDryRun(r) {
+		preview, err := h.service.PreviewCreate(coffee)
+		if err != nil {
+			if strings.Contains(err.Error(), "quota exceeded") {
+				respondError(w, http.StatusPaymentRequired, err.Error())
+				return
+			}
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		respondJSON(w, http.StatusOK, preview)
+		return
+	}
 	
 	createdCoffee, err := h.service.CreateCoffee(coffee)
 	if err != nil {
+		if strings.Contains(err.Error(), "quota exceeded") {
+			respondError(w, http.StatusPaymentRequired, err.Error())
+			return
+		}
 		respondError(w, http.StatusBadRequest, err.Error())
 		return
 	}
@@ -64,27 +115,131 @@ func (h *CoffeeHandler) GetCoffee(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusNotFound, "Coffee not found")
 		return
 	}
+	
+	if h.brewService != nil {
+		dialIn, err := h.brewService.GetDialIn(id)
+		if err != nil {
+			log.Printf("ERROR: Failed to compute dial-in for coffee %s: %v", id, err)
+		} else {
+			coffee.DialIn = dialIn
+		}
+	}
 	respondJSON(w, http.StatusOK, coffee)
 }
 
-// ListCoffees handles GET /coffees
+// ListCoffees handles GET /coffees; filter, sort and paging parameters are
+// handled by findCoffees
 // TODO: Implement this method
 // Requirements:
 //   - Call service.ListCoffees
 //   - Return 200 OK with array of coffees
 // HINT: Even if no coffees exist, return an empty array []
 func (h *CoffeeHandler) ListCoffees(w http.ResponseWriter, r *http.Request) {
-	coffees, err := h.service.ListCoffees()
+	query := r.URL.Query()
+	paged := query.Has("limit") || query.Has("offset")
+	filtered := false
+	for _, param := range coffeeQueryParams {
+		filtered = filtered || query.Has(param)
+	}
+	if paged || filtered {
+		h.findCoffees(w, r, paged)
+		return
+	}
+	
+	coffees, err := h.service.ListCoffeesForDisplay()
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "Failed to list coffees")
 		return
 	}
 	
-	if coffees == nil {
-		coffees = []models.Coffee{}
+	respondCoffeeList(w, r, coffees)
+}
+
+// coffeeQueryParams are the GET /coffees parameters that filter or sort
+var coffeeQueryParams = []string{
+	"q", "origin", "roaster", "variety", "roast_level", "processing", "dripper",
+	"tasting_note", "min_rating", "sort",
+}
+
+// findCoffees handles a filtered, sorted or paged GET /coffees, e.g.
+// ?origin=Ethiopia&roaster=Verve&min_rating=8&processing=washed&sort=rating_desc.
+// Filters match whole values ignoring case, except q, which matches text
+// anywhere. With ?limit=N&offset=M one page is returned in a
+// {"coffees": [...], "total": ..., "next_offset": ...} envelope; favorites
+// can't be pinned within a page.
+func (h *CoffeeHandler) findCoffees(w http.ResponseWriter, r *http.Request, paged bool) {
+	query := r.URL.Query()
+	coffeeQuery := storage.CoffeeQuery{
+		Filter: models.SearchQuery{
+			Text:             query.Get("q"),
+			Origin:           query.Get("origin"),
+			Roaster:          query.Get("roaster"),
+			Variety:          query.Get("variety"),
+			RoastLevel:       query.Get("roast_level"),
+			ProcessingMethod: query.Get("processing"),
+			Dripper:          query.Get("dripper"),
+			TastingNote:      query.Get("tasting_note"),
+			FavoritesOnly:    query.Get("favorite") == "true",
+		},
+		Sort: query.Get("sort"),
+	}
+	if value := query.Get("min_rating"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "min_rating must be an integer")
+			return
+		}
+		coffeeQuery.Filter.MinRating = parsed
 	}
 	
-	respondJSON(w, http.StatusOK, coffees)
+	if !paged {
+		coffees, err := h.service.FindCoffees(coffeeQuery)
+		if err != nil {
+			respondCoffeeQueryError(w, err)
+			return
+		}
+		respondCoffeeList(w, r, coffees)
+		return
+	}
+	
+	if query.Get("pinned") == "true" {
+		respondError(w, http.StatusBadRequest, "pinned cannot be combined with limit or offset")
+		return
+	}
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			respondError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		coffeeQuery.Limit = parsed
+	}
+	if value := query.Get("offset"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			respondError(w, http.StatusBadRequest, "offset must be a non-negative integer")
+			return
+		}
+		coffeeQuery.Offset = parsed
+	}
+	
+	page, err := h.service.ListCoffeesPage(coffeeQuery)
+	if err != nil {
+		respondCoffeeQueryError(w, err)
+		return
+	}
+	
+	respondJSON(w, http.StatusOK, page)
+}
+
+// respondCoffeeQueryError maps coffee listing errors to HTTP responses
+func respondCoffeeQueryError(w http.ResponseWriter, err error) {
+	if strings.Contains(err.Error(), "invalid sort") || strings.Contains(err.Error(), "invalid min_rating") {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	log.Printf("ERROR: Failed to find coffees: %v", err)
+	respondError(w, http.StatusInternalServerError, "Failed to list coffees")
 }
 
 // GetRecentCoffees handles GET /coffees/recent
@@ -98,13 +253,90 @@ func (h *CoffeeHandler) GetRecentCoffees(w http.ResponseWriter, r *http.Request)
 		return
 	}
 	
+	respondCoffeeList(w, r, coffees)
+}
+
+// pinnedCoffeeList is a coffee list with favorites in their own section
+type pinnedCoffeeList struct {
+	Pinned  []models.Coffee `json:"pinned"`
+	Coffees []models.Coffee `json:"coffees"`
+}
+
+// respondCoffeeList writes a coffee list. ?favorite=true keeps only
+// favorites; ?pinned=true returns {"pinned": [...], "coffees": [...]} with
+// favorites moved into the pinned section.
+func respondCoffeeList(w http.ResponseWriter, r *http.Request, coffees []models.Coffee) {
 	if coffees == nil {
 		coffees = []models.Coffee{}
 	}
 	
+	query := r.URL.Query()
+	if query.Get("favorite") == "true" {
+		coffees, _ = service.PinnedCoffees(coffees)
+	}
+	if query.Get("pinned") == "true" {
+		pinned, others := service.PinnedCoffees(coffees)
+		respondJSON(w, http.StatusOK, pinnedCoffeeList{Pinned: pinned, Coffees: others})
+		return
+	}
+	
 	respondJSON(w, http.StatusOK, coffees)
 }
 
+// SetFavorite handles POST /coffees/{id}/favorite (favorite) and
+// DELETE /coffees/{id}/favorite (unfavorite)
+func (h *CoffeeHandler) SetFavorite(w http.ResponseWriter, r *http.Request) {
+	coffee, err := h.service.SetFavorite(r.PathValue("id"), r.Method == http.MethodPost)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			respondError(w, http.StatusNotFound, "Coffee not found")
+			return
+		}
+		log.Printf("ERROR: Failed to update favorite: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to update favorite")
+		return
+	}
+	
+	respondJSON(w, http.StatusOK, coffee)
+}
+
+// CloneCoffee handles POST /coffees/{id}/clone, starting a new bag of the
+// same coffee
+func (h *CoffeeHandler) CloneCoffee(w http.ResponseWriter, r *http.Request) {
+	clone, err := h.service.CloneCoffee(r.PathValue("id"), callerID(r))
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "coffee not found"):
+			respondError(w, http.StatusNotFound, "Coffee not found")
+		case strings.Contains(err.Error(), "quota exceeded"):
+			respondError(w, http.StatusPaymentRequired, err.Error())
+		default:
+			log.Printf("ERROR: Failed to clone coffee: %v", err)
+			respondError(w, http.StatusInternalServerError, "Failed to clone coffee")
+		}
+		return
+	}
+	
+	respondJSON(w, http.StatusCreated, clone)
+}
+
+// GetLineage handles GET /coffees/{id}/lineage, listing every bag of the
+// same coffee
+func (h *CoffeeHandler) GetLineage(w http.ResponseWriter, r *http.Request) {
+	lineage, err := h.service.GetLineage(r.PathValue("id"))
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			respondError(w, http.StatusNotFound, "Coffee not found")
+			return
+		}
+		log.Printf("ERROR: Failed to get lineage: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to get lineage")
+		return
+	}
+	
+	respondJSON(w, http.StatusOK, lineage)
+}
+
 // UpdateCoffee handles PUT /coffees/{id}
 // TODO: Implement this method
 // Requirements:
@@ -124,14 +356,73 @@ func (h *CoffeeHandler) UpdateCoffee(w http.ResponseWriter, r *http.Request) {
 	}
 	defer r.Body.Close()
 	
+	if err := h.applyDefaultCurrency(r, &coffee); err != nil {
+		log.Printf("ERROR: Failed to get default currency: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to update coffee")
+		return
+	}
+	
+	if isDryRun(r) {
+		preview, err := h.service.PreviewUpdate(id, coffee)
+		if err != nil {
+			respondDryRunError(w, err)
+			return
+		}
+		respondJSON(w, http.StatusOK, preview)
+		return
+	}
+	
 	updatedCoffee, err := h.service.UpdateCoffee(id, coffee)  // ← Renamed variable to avoid shadowing
 	if err != nil {
+		if strings.Contains(err.Error(), "invalid lineage") {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		respondError(w, http.StatusNotFound, "Coffee not found")  // ← Better status code
 		return  // ← Added missing return
 	}
 	respondJSON(w, http.StatusOK, updatedCoffee)  // ← Changed to StatusOK (200)
 }
 
+// PatchCoffee handles PATCH /coffees/{id} with a JSON merge patch, e.g.
+// {"rating": 9} or {"tasting_traits": {"florality": 8}}. Omitted fields keep
+// their current values; null resets a field.
+func (h *CoffeeHandler) PatchCoffee(w http.ResponseWriter, r *http.Request) {
+	patch, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	if isDryRun(r) {
+		preview, err := h.service.PreviewPatch(r.PathValue("id"), patch)
+		if err != nil {
+			respondDryRunError(w, err)
+			return
+		}
+		respondJSON(w, http.StatusOK, preview)
+		return
+	}
+
+	coffee, err := h.service.PatchCoffee(r.PathValue("id"), patch)
+	if err != nil {
+		message := err.Error()
+		switch {
+		case strings.Contains(message, "coffee not found"):
+			respondError(w, http.StatusNotFound, "Coffee not found")
+		case strings.Contains(message, "invalid"):
+			respondError(w, http.StatusBadRequest, message)
+		default:
+			log.Printf("ERROR: Failed to patch coffee: %v", err)
+			respondError(w, http.StatusInternalServerError, "Failed to update coffee")
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusOK, coffee)
+}
+
 // DeleteCoffee handles DELETE /coffees/{id}
 // TODO: Implement this method
 // Requirements:
@@ -142,8 +433,23 @@ func (h *CoffeeHandler) DeleteCoffee(w http.ResponseWriter, r *http.Request) {
 	// Extract ID from URL path parameter
 	id := r.PathValue("id")  // ← Use PathValue instead of manual parsing
 	
+	if isDryRun(r) {
+		preview, err := h.service.PreviewDelete(id)
+		if err != nil {
+			respondDryRunError(w, err)
+			return
+		}
+		respondJSON(w, http.StatusOK, preview)
+		return
+	}
+	
 	err := h.service.DeleteCoffee(id)
 	if err != nil {
+		if !strings.Contains(err.Error(), "coffee not found") {
+			log.Printf("ERROR: Failed to delete coffee %s: %v", id, err)
+			respondError(w, http.StatusInternalServerError, "Failed to delete coffee")
+			return
+		}
 		respondError(w, http.StatusNotFound, "Coffee not found")  // ← Better status code
 		return  // ← Added missing return
 	}
@@ -152,6 +458,23 @@ func (h *CoffeeHandler) DeleteCoffee(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)  // ← Don't use respondJSON for 204
 }
 
+// GetTraitDrift handles GET /statistics/drift?period=year|quarter|month,
+// how trait scoring moved over time
+func (h *CoffeeHandler) GetTraitDrift(w http.ResponseWriter, r *http.Request) {
+	drift, err := h.service.GetTraitDrift(r.URL.Query().Get("period"))
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid period") {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Printf("ERROR: Failed to compute trait drift: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to compute trait drift")
+		return
+	}
+	
+	respondJSON(w, http.StatusOK, drift)
+}
+
 // respondJSON is a helper function to send JSON responses
 // TODO: Implement this helper method
 // Requirements:
@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"encoding/json"
+	"go-coffee-log/service"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// CollectionHandler handles HTTP requests for coffee collections
+type CollectionHandler struct {
+	collectionService *service.CollectionService
+	statsService      *service.StatisticsService // optional, enables collection statistics
+}
+
+// NewCollectionHandler creates a new collection handler
+func NewCollectionHandler(collectionService *service.CollectionService) *CollectionHandler {
+	return &CollectionHandler{collectionService: collectionService}
+}
+
+// SetStatisticsService enables GET /collections/{id}/statistics
+func (h *CollectionHandler) SetStatisticsService(statsService *service.StatisticsService) {
+	h.statsService = statsService
+}
+
+// collectionRequest is the payload for creating or editing a collection
+type collectionRequest struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	CoffeeIDs   []string `json:"coffee_ids"` // only used on create
+}
+
+// CreateCollection handles POST /collections
+func (h *CollectionHandler) CreateCollection(w http.ResponseWriter, r *http.Request) {
+	var req collectionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	if isDryRun(r) {
+		respondPreview(w, func() (service.DryRun, error) {
+			return h.collectionService.PreviewCreateCollection(req.Name, req.Description, req.CoffeeIDs)
+		}, h.respondCollectionError)
+		return
+	}
+
+	collection, err := h.collectionService.CreateCollection(req.Name, req.Description, req.CoffeeIDs)
+	if err != nil {
+		h.respondCollectionError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, collection)
+}
+
+// ListCollections handles GET /collections
+func (h *CollectionHandler) ListCollections(w http.ResponseWriter, r *http.Request) {
+	collections, err := h.collectionService.ListCollections()
+	if err != nil {
+		h.respondCollectionError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, collections)
+}
+
+// GetCollection handles GET /collections/{id}
+func (h *CollectionHandler) GetCollection(w http.ResponseWriter, r *http.Request) {
+	collection, err := h.collectionService.GetCollection(r.PathValue("id"))
+	if err != nil {
+		h.respondCollectionError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, collection)
+}
+
+// UpdateCollection handles PUT /collections/{id}
+func (h *CollectionHandler) UpdateCollection(w http.ResponseWriter, r *http.Request) {
+	var req collectionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	if isDryRun(r) {
+		respondPreview(w, func() (service.DryRun, error) {
+			return h.collectionService.PreviewUpdateCollection(r.PathValue("id"), req.Name, req.Description)
+		}, h.respondCollectionError)
+		return
+	}
+
+	collection, err := h.collectionService.UpdateCollection(r.PathValue("id"), req.Name, req.Description)
+	if err != nil {
+		h.respondCollectionError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, collection)
+}
+
+// DeleteCollection handles DELETE /collections/{id}
+func (h *CollectionHandler) DeleteCollection(w http.ResponseWriter, r *http.Request) {
+	if isDryRun(r) {
+		respondPreview(w, func() (service.DryRun, error) {
+			return h.collectionService.PreviewDeleteCollection(r.PathValue("id"))
+		}, h.respondCollectionError)
+		return
+	}
+
+	if err := h.collectionService.DeleteCollection(r.PathValue("id")); err != nil {
+		h.respondCollectionError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListCoffees handles GET /collections/{id}/coffees; accepts the same
+// ?favorite= and ?pinned= options as GET /coffees
+func (h *CollectionHandler) ListCoffees(w http.ResponseWriter, r *http.Request) {
+	coffees, err := h.collectionService.ListCoffees(r.PathValue("id"))
+	if err != nil {
+		h.respondCollectionError(w, err)
+		return
+	}
+
+	respondCoffeeList(w, r, coffees)
+}
+
+// AddCoffee handles POST /collections/{id}/coffees with {"coffee_id": "..."}
+func (h *CollectionHandler) AddCoffee(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		CoffeeID string `json:"coffee_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.CoffeeID == "" {
+		respondError(w, http.StatusBadRequest, "coffee_id is required")
+		return
+	}
+	defer r.Body.Close()
+
+	if isDryRun(r) {
+		respondPreview(w, func() (service.DryRun, error) {
+			return h.collectionService.PreviewAddCoffee(r.PathValue("id"), req.CoffeeID)
+		}, h.respondCollectionError)
+		return
+	}
+
+	collection, err := h.collectionService.AddCoffee(r.PathValue("id"), req.CoffeeID)
+	if err != nil {
+		h.respondCollectionError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, collection)
+}
+
+// RemoveCoffee handles DELETE /collections/{id}/coffees/{coffee_id}
+func (h *CollectionHandler) RemoveCoffee(w http.ResponseWriter, r *http.Request) {
+	if isDryRun(r) {
+		respondPreview(w, func() (service.DryRun, error) {
+			return h.collectionService.PreviewRemoveCoffee(r.PathValue("id"), r.PathValue("coffee_id"))
+		}, h.respondCollectionError)
+		return
+	}
+
+	if err := h.collectionService.RemoveCoffee(r.PathValue("id"), r.PathValue("coffee_id")); err != nil {
+		h.respondCollectionError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetStatistics handles GET /collections/{id}/statistics, the same
+// statistics as GET /statistics computed over the collection's coffees
+func (h *CollectionHandler) GetStatistics(w http.ResponseWriter, r *http.Request) {
+	if h.statsService == nil {
+		respondError(w, http.StatusNotFound, "Statistics require MySQL storage")
+		return
+	}
+
+	collection, err := h.collectionService.GetCollection(r.PathValue("id"))
+	if err != nil {
+		h.respondCollectionError(w, err)
+		return
+	}
+
+	filter := service.StatisticsFilter{
+		FavoritesOnly: r.URL.Query().Get("favorite") == "true",
+		CoffeeIDs:     make(map[string]bool, len(collection.CoffeeIDs)),
+	}
+	for _, coffeeID := range collection.CoffeeIDs {
+		filter.CoffeeIDs[coffeeID] = true
+	}
+
+	stats, err := h.statsService.CalculateStatistics(filter)
+	if err != nil {
+		log.Printf("ERROR: Failed to calculate statistics for collection %s: %v", collection.ID, err)
+		respondError(w, http.StatusInternalServerError, "Failed to calculate statistics")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, stats)
+}
+
+// respondCollectionError maps collection service errors to HTTP responses
+func (h *CollectionHandler) respondCollectionError(w http.ResponseWriter, err error) {
+	message := err.Error()
+	switch {
+	case strings.Contains(message, "coffee not found"):
+		respondError(w, http.StatusNotFound, "Coffee not found")
+	case strings.Contains(message, "collection not found"):
+		respondError(w, http.StatusNotFound, "Collection not found")
+	case strings.Contains(message, "not in collection"):
+		respondError(w, http.StatusNotFound, "Coffee is not in the collection")
+	case strings.Contains(message, "name"):
+		respondError(w, http.StatusBadRequest, message)
+	default:
+		log.Printf("ERROR: Collection request failed: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to process collection")
+	}
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"encoding/json"
+	"go-coffee-log/models"
+	"go-coffee-log/service"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// CommentHandler handles HTTP requests for coffee comments
+type CommentHandler struct {
+	commentService *service.CommentService
+}
+
+// NewCommentHandler creates a new comment handler
+func NewCommentHandler(commentService *service.CommentService) *CommentHandler {
+	return &CommentHandler{
+		commentService: commentService,
+	}
+}
+
+// commentRequest is the payload for creating or editing a comment
+type commentRequest struct {
+	Body       string `json:"body"`
+	ParentID   string `json:"parent_id"`
+	AuthorName string `json:"author_name"` // only used without authentication
+}
+
+// commenter identifies the caller from the request context
+func commenter(r *http.Request, fallbackName string) service.Commenter {
+	if user, ok := UserFromContext(r.Context()); ok {
+		return service.Commenter{UserID: user.ID, Name: user.Name, IsAdmin: user.IsAdmin}
+	}
+	return service.Commenter{Name: strings.TrimSpace(fallbackName)}
+}
+
+// AddComment handles POST /coffees/{id}/comments
+func (h *CommentHandler) AddComment(w http.ResponseWriter, r *http.Request) {
+	var req commentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	if isDryRun(r) {
+		respondPreview(w, func() (service.DryRun, error) {
+			return h.commentService.PreviewAddComment(r.PathValue("id"), req.ParentID, req.Body, commenter(r, req.AuthorName))
+		}, h.respondCommentError)
+		return
+	}
+
+	comment, err := h.commentService.AddComment(r.PathValue("id"), req.ParentID, req.Body, commenter(r, req.AuthorName))
+	if err != nil {
+		h.respondCommentError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, comment)
+}
+
+// ListComments handles GET /coffees/{id}/comments
+func (h *CommentHandler) ListComments(w http.ResponseWriter, r *http.Request) {
+	comments, err := h.commentService.ListComments(r.PathValue("id"))
+	if err != nil {
+		h.respondCommentError(w, err)
+		return
+	}
+	if comments == nil {
+		comments = []models.Comment{}
+	}
+
+	respondJSON(w, http.StatusOK, comments)
+}
+
+// UpdateComment handles PUT /coffees/{id}/comments/{comment_id}
+func (h *CommentHandler) UpdateComment(w http.ResponseWriter, r *http.Request) {
+	var req commentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	if isDryRun(r) {
+		respondPreview(w, func() (service.DryRun, error) {
+			return h.commentService.PreviewUpdateComment(r.PathValue("id"), r.PathValue("comment_id"), req.Body, commenter(r, ""))
+		}, h.respondCommentError)
+		return
+	}
+
+	comment, err := h.commentService.UpdateComment(r.PathValue("id"), r.PathValue("comment_id"), req.Body, commenter(r, ""))
+	if err != nil {
+		h.respondCommentError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, comment)
+}
+
+// DeleteComment handles DELETE /coffees/{id}/comments/{comment_id}
+func (h *CommentHandler) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	if isDryRun(r) {
+		respondPreview(w, func() (service.DryRun, error) {
+			return h.commentService.PreviewDeleteComment(r.PathValue("id"), r.PathValue("comment_id"), commenter(r, ""))
+		}, h.respondCommentError)
+		return
+	}
+
+	if err := h.commentService.DeleteComment(r.PathValue("id"), r.PathValue("comment_id"), commenter(r, "")); err != nil {
+		h.respondCommentError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// respondCommentError maps comment service errors to HTTP responses
+func (h *CommentHandler) respondCommentError(w http.ResponseWriter, err error) {
+	message := err.Error()
+	switch {
+	case strings.Contains(message, "coffee not found"):
+		respondError(w, http.StatusNotFound, "Coffee not found")
+	case strings.Contains(message, "not found"):
+		respondError(w, http.StatusNotFound, "Comment not found")
+	case strings.Contains(message, "not allowed"):
+		respondError(w, http.StatusForbidden, message)
+	case strings.Contains(message, "body") || strings.Contains(message, "author"):
+		respondError(w, http.StatusBadRequest, message)
+	default:
+		log.Printf("ERROR: Comment request failed: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to process comment")
+	}
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"go-coffee-log/service"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// CSVImportHandler handles the two steps of a CSV import
+type CSVImportHandler struct {
+	csvImportService *service.CSVImportService
+}
+
+// NewCSVImportHandler creates a new CSV import handler
+func NewCSVImportHandler(csvImportService *service.CSVImportService) *CSVImportHandler {
+	return &CSVImportHandler{csvImportService: csvImportService}
+}
+
+// Upload handles POST /imports/csv with either the raw CSV as the body or a
+// multipart form with a "file" field. It returns the detected columns,
+// sample rows and a suggested mapping.
+func (h *CSVImportHandler) Upload(w http.ResponseWriter, r *http.Request) {
+	// Leave room for multipart framing around the file itself
+	r.Body = http.MaxBytesReader(w, r.Body, service.MaxCSVImportSize+1<<20)
+	defer r.Body.Close()
+
+	var body io.Reader = r.Body
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Expected a multipart \"file\" field")
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	data, err := io.ReadAll(io.LimitReader(body, service.MaxCSVImportSize+1))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondError(w, http.StatusRequestEntityTooLarge, "CSV file is too large")
+			return
+		}
+		respondError(w, http.StatusBadRequest, "Failed to read CSV file")
+		return
+	}
+	if len(data) > service.MaxCSVImportSize {
+		respondError(w, http.StatusRequestEntityTooLarge, "CSV file is too large")
+		return
+	}
+
+	preview, err := h.csvImportService.Upload(data)
+	if err != nil {
+		h.respondCSVImportError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, preview)
+}
+
+// Import handles POST /imports/csv/{id} with
+// {"mapping": [{"column": "Brew Time", "field": "end_time", "transform": "duration"}], "dry_run": true}
+func (h *CSVImportHandler) Import(w http.ResponseWriter, r *http.Request) {
+	var request service.CSVImportRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+	request.DryRun = request.DryRun || isDryRun(r)
+	request.AddedBy = callerID(r)
+
+	report, err := h.csvImportService.Import(r.PathValue("id"), request)
+	if err != nil {
+		h.respondCSVImportError(w, err)
+		return
+	}
+
+	status := http.StatusCreated
+	if report.DryRun {
+		status = http.StatusOK
+	}
+	respondJSON(w, status, report)
+}
+
+// respondCSVImportError maps CSV import errors to HTTP responses
+func (h *CSVImportHandler) respondCSVImportError(w http.ResponseWriter, err error) {
+	message := err.Error()
+	switch {
+	case strings.Contains(message, "not found"):
+		respondError(w, http.StatusNotFound, "CSV upload not found")
+	case strings.Contains(message, "invalid"):
+		respondError(w, http.StatusBadRequest, message)
+	case strings.Contains(message, "too many"):
+		respondError(w, http.StatusTooManyRequests, message)
+	default:
+		log.Printf("ERROR: CSV import failed: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to import CSV")
+	}
+}
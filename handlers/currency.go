@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"encoding/json"
+	"go-coffee-log/service"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// CurrencyHandler handles HTTP requests for currency settings and value
+// statistics
+type CurrencyHandler struct {
+	currencyService *service.CurrencyService
+}
+
+// NewCurrencyHandler creates a new currency handler
+func NewCurrencyHandler(currencyService *service.CurrencyService) *CurrencyHandler {
+	return &CurrencyHandler{currencyService: currencyService}
+}
+
+// currencyRequest is the body of PUT /profile/currency
+type currencyRequest struct {
+	Currency string `json:"currency"`
+}
+
+// GetCurrency handles GET /profile/currency with the caller's default
+// currency
+func (h *CurrencyHandler) GetCurrency(w http.ResponseWriter, r *http.Request) {
+	currency, err := h.currencyService.DefaultCurrency(callerID(r))
+	if err != nil {
+		log.Printf("ERROR: Failed to get default currency: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to get default currency")
+		return
+	}
+	respondJSON(w, http.StatusOK, currencyRequest{Currency: currency})
+}
+
+// SetCurrency handles PUT /profile/currency, e.g. {"currency": "EUR"}
+func (h *CurrencyHandler) SetCurrency(w http.ResponseWriter, r *http.Request) {
+	var req currencyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	currency, err := h.currencyService.SetDefaultCurrency(callerID(r), req.Currency)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid") {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Printf("ERROR: Failed to set default currency: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to set default currency")
+		return
+	}
+	respondJSON(w, http.StatusOK, currencyRequest{Currency: currency})
+}
+
+// GetValueStats handles GET /statistics/value, in ?currency= or the
+// caller's default currency
+func (h *CurrencyHandler) GetValueStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.currencyService.GetValueStats(callerID(r), r.URL.Query().Get("currency"))
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid") {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Printf("ERROR: Failed to get value statistics: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to get value statistics")
+		return
+	}
+	respondJSON(w, http.StatusOK, stats)
+}
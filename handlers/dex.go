@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"encoding/json"
+	"go-coffee-log/models"
+	"go-coffee-log/service"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// DexHandler handles HTTP requests for dex profiles. Anyone can pick the
+// profile they play; adding and removing profiles is reserved to admins.
+type DexHandler struct {
+	dexService *service.DexService
+}
+
+// NewDexHandler creates a new dex handler
+func NewDexHandler(dexService *service.DexService) *DexHandler {
+	return &DexHandler{dexService: dexService}
+}
+
+// callerID returns the caller's user ID, or "" when auth is off
+func callerID(r *http.Request) string {
+	if user, ok := UserFromContext(r.Context()); ok {
+		return user.ID
+	}
+	return ""
+}
+
+// ListProfiles handles GET /pokedex/profiles with every profile's progress
+func (h *DexHandler) ListProfiles(w http.ResponseWriter, r *http.Request) {
+	progress, err := h.dexService.Progress(callerID(r))
+	if err != nil {
+		h.respondDexError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, progress)
+}
+
+// CreateProfile handles POST /pokedex/profiles with
+// {"id": "best-of", "name": "Best-of-all-gens 151", "pokemon_ids": [...]}
+func (h *DexHandler) CreateProfile(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r); !ok {
+		return
+	}
+
+	var profile models.DexProfile
+	if err := json.NewDecoder(r.Body).Decode(&profile); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	created, err := h.dexService.CreateProfile(profile)
+	if err != nil {
+		h.respondDexError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, created)
+}
+
+// DeleteProfile handles DELETE /pokedex/profiles/{id}
+func (h *DexHandler) DeleteProfile(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r); !ok {
+		return
+	}
+
+	if err := h.dexService.DeleteProfile(r.PathValue("id")); err != nil {
+		h.respondDexError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetSelection handles GET /pokedex/profile with the profile the caller plays
+func (h *DexHandler) GetSelection(w http.ResponseWriter, r *http.Request) {
+	profile, err := h.dexService.Selected(callerID(r))
+	if err != nil {
+		h.respondDexError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, profile)
+}
+
+// SelectProfile handles PUT /pokedex/profile with {"dex": "johto"}
+func (h *DexHandler) SelectProfile(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Dex string `json:"dex"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Dex == "" {
+		respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	profile, err := h.dexService.Select(callerID(r), request.Dex)
+	if err != nil {
+		h.respondDexError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, profile)
+}
+
+// requestProfile returns the profile named by ?dex=, or the one the caller
+// plays
+func (h *DexHandler) requestProfile(r *http.Request) (models.DexProfile, error) {
+	if dex := r.URL.Query().Get("dex"); dex != "" {
+		return h.dexService.Profile(dex)
+	}
+	return h.dexService.Selected(callerID(r))
+}
+
+// GetDuplicates handles GET /pokedex/duplicates with the species caught
+// more than once and the nicknames reused in a dex profile
+func (h *DexHandler) GetDuplicates(w http.ResponseWriter, r *http.Request) {
+	profile, err := h.requestProfile(r)
+	if err != nil {
+		h.respondDexError(w, err)
+		return
+	}
+	duplicates, err := h.dexService.Duplicates(profile)
+	if err != nil {
+		h.respondDexError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, duplicates)
+}
+
+// GetLivingDex handles GET /pokedex/living with a dex profile's progress
+// split into base and evolved forms and by evolution line
+func (h *DexHandler) GetLivingDex(w http.ResponseWriter, r *http.Request) {
+	profile, err := h.requestProfile(r)
+	if err != nil {
+		h.respondDexError(w, err)
+		return
+	}
+	living, err := h.dexService.LivingDex(profile)
+	if err != nil {
+		h.respondDexError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, living)
+}
+
+// respondDexError maps dex profile errors to HTTP responses
+func (h *DexHandler) respondDexError(w http.ResponseWriter, err error) {
+	switch {
+	case strings.Contains(err.Error(), "not found"):
+		respondError(w, http.StatusNotFound, "Dex profile not found")
+	case strings.Contains(err.Error(), "invalid dex profile"):
+		respondError(w, http.StatusBadRequest, err.Error())
+	case strings.Contains(err.Error(), "already exists"), strings.Contains(err.Error(), "cannot be deleted"):
+		respondError(w, http.StatusConflict, err.Error())
+	default:
+		log.Printf("ERROR: Dex profile request failed: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to process dex profile")
+	}
+}
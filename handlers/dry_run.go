@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"go-coffee-log/service"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// isDryRun reports whether a request asks, with ?dry_run=true, to only
+// report what it would change
+func isDryRun(r *http.Request) bool {
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+	return dryRun
+}
+
+// DryRunGuard refuses ?dry_run on mutating routes that cannot honor it, so
+// a script previewing a change never applies it by accident. Workshop
+// routes are left to the workshop's own server, which guards them itself.
+func DryRunGuard(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		value := r.URL.Query().Get("dry_run")
+		if value == "" || !isMutation(r) || strings.HasPrefix(r.URL.Path, "/workshops/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if _, err := strconv.ParseBool(value); err != nil {
+			respondError(w, http.StatusBadRequest, "dry_run must be true or false")
+			return
+		}
+		if isDryRun(r) && !supportsDryRun(r) {
+			respondError(w, http.StatusBadRequest, "dry_run is not supported for "+r.Method+" "+r.URL.Path)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// supportsDryRun reports whether a mutating route handles ?dry_run=true
+func supportsDryRun(r *http.Request) bool {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case len(parts) == 1 && (parts[0] == "coffees" || parts[0] == "batch"):
+		return r.Method == http.MethodPost
+	case len(parts) == 2 && parts[0] == "coffees":
+		return r.Method == http.MethodPut || r.Method == http.MethodPatch || r.Method == http.MethodDelete
+	case len(parts) == 3 && parts[0] == "coffees" && parts[2] == "merge":
+		return r.Method == http.MethodPost
+	case len(parts) == 3 && parts[0] == "coffees" && (parts[2] == "brews" || parts[2] == "comments"):
+		return r.Method == http.MethodPost
+	case len(parts) == 4 && parts[0] == "coffees" && parts[2] == "brews":
+		return r.Method == http.MethodDelete
+	case len(parts) == 4 && parts[0] == "coffees" && parts[2] == "comments":
+		return r.Method == http.MethodPut || r.Method == http.MethodDelete
+	case len(parts) == 1 && (parts[0] == "collections" || parts[0] == "brewers" || parts[0] == "searches" || parts[0] == "plans"):
+		return r.Method == http.MethodPost
+	case len(parts) == 2 && parts[0] == "collections":
+		return r.Method == http.MethodPut || r.Method == http.MethodDelete
+	case len(parts) == 2 && (parts[0] == "brewers" || parts[0] == "searches" || parts[0] == "plans"):
+		return r.Method == http.MethodDelete
+	case len(parts) == 3 && parts[0] == "collections" && parts[2] == "coffees":
+		return r.Method == http.MethodPost
+	case len(parts) == 4 && parts[0] == "collections" && parts[2] == "coffees":
+		return r.Method == http.MethodDelete
+	case len(parts) == 3 && parts[0] == "brewers" && parts[2] == "standalone-recipes":
+		return r.Method == http.MethodPost
+	case len(parts) == 4 && parts[0] == "brewers" && parts[2] == "standalone-recipes":
+		return r.Method == http.MethodDelete
+	case len(parts) == 3 && parts[0] == "plans" && parts[2] == "brew":
+		return r.Method == http.MethodPost
+	case len(parts) == 2 && parts[0] == "pokemon":
+		return r.Method == http.MethodPost // generate mapping
+	case len(parts) == 3 && parts[0] == "pokemon" && parts[2] == "reroll":
+		return r.Method == http.MethodPost
+	case len(parts) == 3 && parts[0] == "imports" && parts[1] == "csv":
+		return r.Method == http.MethodPost
+	case len(parts) == 2 && parts[0] == "pokedex" && parts[1] == "nicknames":
+		return r.Method == http.MethodPost
+	case len(parts) == 2 && parts[0] == "admin" && parts[1] == "import-instance":
+		return r.Method == http.MethodPost
+	case len(parts) == 3 && parts[0] == "admin" && parts[1] == "mapper" && parts[2] == "rules":
+		return r.Method == http.MethodPut
+	}
+	return false
+}
+
+// respondPreview answers a dry run with its preview, or with the error the
+// write itself would answer with, mapped by respondErr
+func respondPreview(w http.ResponseWriter, preview func() (service.DryRun, error), respondErr func(http.ResponseWriter, error)) {
+	result, err := preview()
+	if err != nil {
+		respondErr(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, result)
+}
+
+// respondDryRunError maps the errors of a coffee dry run: a missing coffee
+// is 404, anything else means the change would be rejected
+func respondDryRunError(w http.ResponseWriter, err error) {
+	if strings.Contains(err.Error(), "coffee not found") {
+		respondError(w, http.StatusNotFound, "Coffee not found")
+		return
+	}
+	respondError(w, http.StatusBadRequest, err.Error())
+}
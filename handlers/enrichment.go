@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"encoding/json"
+	"go-coffee-log/service"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// EnrichmentHandler handles HTTP requests for roaster metadata
+type EnrichmentHandler struct {
+	enrichmentService *service.EnrichmentService
+}
+
+// NewEnrichmentHandler creates a new enrichment handler
+func NewEnrichmentHandler(enrichmentService *service.EnrichmentService) *EnrichmentHandler {
+	return &EnrichmentHandler{enrichmentService: enrichmentService}
+}
+
+// GetEnrichment handles GET /coffees/{id}/enrichment: the details the
+// coffee's roaster publishes, as changes to confirm
+func (h *EnrichmentHandler) GetEnrichment(w http.ResponseWriter, r *http.Request) {
+	proposal, err := h.enrichmentService.Propose(r.Context(), r.PathValue("id"))
+	if err != nil {
+		respondEnrichmentError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, proposal)
+}
+
+// ApplyEnrichment handles POST /coffees/{id}/enrichment with
+// {"fields": ["variety", ...]}, applying the confirmed changes
+func (h *EnrichmentHandler) ApplyEnrichment(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Fields []string `json:"fields"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	coffee, err := h.enrichmentService.Apply(r.Context(), r.PathValue("id"), request.Fields)
+	if err != nil {
+		respondEnrichmentError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, coffee)
+}
+
+// respondEnrichmentError maps enrichment errors to HTTP responses
+func respondEnrichmentError(w http.ResponseWriter, err error) {
+	message := err.Error()
+	switch {
+	case strings.Contains(message, "coffee not found"):
+		respondError(w, http.StatusNotFound, "Coffee not found")
+	case strings.Contains(message, "no roaster metadata found"):
+		respondError(w, http.StatusNotFound, message)
+	case strings.Contains(message, "invalid"):
+		respondError(w, http.StatusBadRequest, message)
+	case strings.Contains(message, "failed to look up"):
+		log.Printf("ERROR: Coffee enrichment failed: %v", err)
+		respondError(w, http.StatusBadGateway, message)
+	default:
+		log.Printf("ERROR: Coffee enrichment failed: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to enrich coffee")
+	}
+}
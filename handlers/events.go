@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"go-coffee-log/service"
+	"log"
+	"net/http"
+	"time"
+)
+
+// sseKeepAlive is how often an idle event stream sends a comment so proxies
+// don't close the connection
+const sseKeepAlive = 30 * time.Second
+
+// EventHandler handles HTTP requests for live events and reminders
+type EventHandler struct {
+	bus       *service.EventBus
+	reminders *service.ReminderService
+}
+
+// NewEventHandler creates a new event handler
+func NewEventHandler(bus *service.EventBus, reminders *service.ReminderService) *EventHandler {
+	return &EventHandler{
+		bus:       bus,
+		reminders: reminders,
+	}
+}
+
+// StreamEvents handles GET /events as a Server-Sent Events stream
+func (h *EventHandler) StreamEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		respondError(w, http.StatusInternalServerError, "Streaming not supported")
+		return
+	}
+
+	events, unsubscribe := h.bus.Subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if err := writeSSE(w, event.Type, event); err != nil {
+				log.Printf("ERROR: Failed to write event to stream: %v", err)
+				return
+			}
+			flusher.Flush()
+		case <-keepAlive.C:
+			if _, err := w.Write([]byte(": keep-alive\n\n")); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// GetReminders handles GET /reminders
+func (h *EventHandler) GetReminders(w http.ResponseWriter, r *http.Request) {
+	reminders, err := h.reminders.GetReminders(time.Now())
+	if err != nil {
+		log.Printf("ERROR: Failed to get reminders: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to get reminders")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, reminders)
+}
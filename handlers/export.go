@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"fmt"
+	"go-coffee-log/service"
+	"log"
+	"net/http"
+)
+
+// ExportHandler handles downloads of the coffee log
+type ExportHandler struct {
+	exportService *service.CoffeeExportService
+}
+
+// NewExportHandler creates a new export handler
+func NewExportHandler(exportService *service.CoffeeExportService) *ExportHandler {
+	return &ExportHandler{exportService: exportService}
+}
+
+// ExportCoffees handles GET /export?format=csv|json (default json). It
+// streams every coffee, with its Pokemon catches, as a downloadable file.
+func (h *ExportHandler) ExportCoffees(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = service.ExportJSON
+	}
+	contentType := "application/json"
+	switch format {
+	case service.ExportJSON:
+	case service.ExportCSV:
+		contentType = "text/csv; charset=utf-8"
+	default:
+		respondError(w, http.StatusBadRequest, "format must be csv or json")
+		return
+	}
+
+	export, err := h.exportService.Load()
+	if err != nil {
+		log.Printf("ERROR: Coffee export failed: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to export coffees")
+		return
+	}
+
+	filename := fmt.Sprintf("coffee-log-%s.%s", export.ExportedAt.Format("20060102"), format)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+
+	if format == service.ExportCSV {
+		err = export.WriteCSV(w)
+	} else {
+		err = export.WriteJSON(w)
+	}
+	if err != nil {
+		log.Printf("ERROR: Failed to write coffee export: %v", err)
+	}
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"go-coffee-log/service"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// GlobalSearchHandler handles HTTP requests for searching everything at once
+type GlobalSearchHandler struct {
+	searchService *service.GlobalSearchService
+}
+
+// NewGlobalSearchHandler creates a new global search handler
+func NewGlobalSearchHandler(searchService *service.GlobalSearchService) *GlobalSearchHandler {
+	return &GlobalSearchHandler{searchService: searchService}
+}
+
+// Search handles GET /search?q=...&limit=N, returning coffees, catches and
+// brewers in one ranked list
+func (h *GlobalSearchHandler) Search(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			respondError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = parsed
+	}
+
+	results, err := h.searchService.Search(r.URL.Query().Get("q"), limit)
+	if err != nil {
+		if strings.Contains(err.Error(), "query is required") {
+			respondError(w, http.StatusBadRequest, "q must contain at least one word")
+			return
+		}
+		log.Printf("ERROR: Failed to search: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to search")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, results)
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// Readiness fronts the server while it starts. /health is answered from
+// the first moment; every other request gets 503 until Ready hands over
+// the fully wired handler. Dependencies report their state as they connect.
+type Readiness struct {
+	mu           sync.RWMutex
+	handler      http.Handler
+	dependencies map[string]string // name -> "ok", "connecting", "unreachable"
+}
+
+// NewReadiness creates a readiness gate with nothing ready yet
+func NewReadiness() *Readiness {
+	return &Readiness{
+		dependencies: make(map[string]string),
+	}
+}
+
+// SetDependency records the state of a dependency
+func (g *Readiness) SetDependency(name, state string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.dependencies[name] = state
+}
+
+// Ready starts routing requests to handler
+func (g *Readiness) Ready(handler http.Handler) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.handler = handler
+}
+
+// ServeHTTP implements http.Handler
+func (g *Readiness) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g.mu.RLock()
+	handler := g.handler
+	g.mu.RUnlock()
+
+	if handler != nil {
+		handler.ServeHTTP(w, r)
+		return
+	}
+	if r.URL.Path == "/health" {
+		g.Health(w, r)
+		return
+	}
+
+	w.Header().Set("Retry-After", "5")
+	respondError(w, http.StatusServiceUnavailable, "Server is starting; waiting for dependencies")
+}
+
+// Health handles GET /health. The status is "ok" once the server is ready
+// and every dependency is reachable, "degraded" otherwise; the response is
+// 503 until the server is ready.
+func (g *Readiness) Health(w http.ResponseWriter, r *http.Request) {
+	g.mu.RLock()
+	ready := g.handler != nil
+	dependencies := make(map[string]string, len(g.dependencies))
+	names := make([]string, 0, len(g.dependencies))
+	for name, state := range g.dependencies {
+		dependencies[name] = state
+		names = append(names, name)
+	}
+	g.mu.RUnlock()
+
+	status := "ok"
+	sort.Strings(names)
+	for _, name := range names {
+		if dependencies[name] != "ok" {
+			status = "degraded"
+		}
+	}
+
+	code := http.StatusOK
+	if !ready {
+		status = "degraded"
+		code = http.StatusServiceUnavailable
+	}
+
+	respondJSON(w, code, map[string]interface{}{
+		"status":       status,
+		"ready":        ready,
+		"dependencies": dependencies,
+	})
+}
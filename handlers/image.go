@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"bytes"
+	"go-coffee-log/service"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// imageMaxAge is how long clients may cache a resized image. Shared caches
+// may keep sprites, but only the client keeps photos on servers with
+// authentication.
+const (
+	imageMaxAge        = "public, max-age=86400"
+	privateImageMaxAge = "private, max-age=86400"
+)
+
+// ImageHandler serves resized images
+type ImageHandler struct {
+	imageService *service.ImageService
+}
+
+// NewImageHandler creates a new image handler
+func NewImageHandler(imageService *service.ImageService) *ImageHandler {
+	return &ImageHandler{imageService: imageService}
+}
+
+// GetImage handles GET /images/{id}?w=&h=, e.g. /images/pokemon-25?w=48
+func (h *ImageHandler) GetImage(w http.ResponseWriter, r *http.Request) {
+	var size [2]int
+	for i, param := range []string{"w", "h"} {
+		value := r.URL.Query().Get(param)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, param+" must be a number of pixels")
+			return
+		}
+		size[i] = n
+	}
+
+	img, err := h.imageService.GetImage(r.PathValue("id"), size[0], size[1])
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "not found"):
+			respondError(w, http.StatusNotFound, "Image not found")
+		case strings.Contains(err.Error(), "invalid size"):
+			respondError(w, http.StatusBadRequest, err.Error())
+		default:
+			log.Printf("ERROR: Failed to resize image: %v", err)
+			respondError(w, http.StatusInternalServerError, "Failed to resize image")
+		}
+		return
+	}
+
+	cacheControl := imageMaxAge
+	if _, ok := UserFromContext(r.Context()); ok && strings.HasPrefix(r.PathValue("id"), "photo-") {
+		cacheControl = privateImageMaxAge
+	}
+	w.Header().Set("Content-Type", img.ContentType)
+	w.Header().Set("Cache-Control", cacheControl)
+	w.Header().Set("ETag", img.ETag)
+	// ServeContent answers If-None-Match / If-Modified-Since and ranges
+	http.ServeContent(w, r, "", img.ModTime, bytes.NewReader(img.Data))
+}
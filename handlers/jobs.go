@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"go-coffee-log/service"
+	"net/http"
+)
+
+// JobHandler handles HTTP requests for queued jobs
+type JobHandler struct {
+	queue *service.JobQueue
+}
+
+// NewJobHandler creates a new job handler
+func NewJobHandler(queue *service.JobQueue) *JobHandler {
+	return &JobHandler{queue: queue}
+}
+
+// GetJob handles GET /jobs/{id}: a job's status, and its result once it
+// has succeeded. Only the user who queued a job can see it.
+func (h *JobHandler) GetJob(w http.ResponseWriter, r *http.Request) {
+	job, err := h.queue.Job(r.PathValue("id"), callerID(r))
+	if err != nil {
+		respondError(w, http.StatusNotFound, "Job not found")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, job)
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"errors"
+	"go-coffee-log/service"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// LabelHandler handles HTTP requests for reading coffee bag labels
+type LabelHandler struct {
+	llmService *service.LLMService // may be nil when the LLM is disabled
+}
+
+// NewLabelHandler creates a new label handler
+func NewLabelHandler(llmService *service.LLMService) *LabelHandler {
+	return &LabelHandler{
+		llmService: llmService,
+	}
+}
+
+// FromLabel handles POST /coffees/from-label with a photo of a coffee bag,
+// either as the raw body or a multipart form with an "image" file field.
+// It answers with a draft coffee to confirm; nothing is saved.
+func (h *LabelHandler) FromLabel(w http.ResponseWriter, r *http.Request) {
+	// Leave room for multipart framing around the image itself
+	r.Body = http.MaxBytesReader(w, r.Body, service.MaxPhotoSize+1<<20)
+	defer r.Body.Close()
+
+	var body io.Reader = r.Body
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		file, _, err := r.FormFile("image")
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Expected a multipart \"image\" file field")
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	data, err := io.ReadAll(io.LimitReader(body, service.MaxPhotoSize+1))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondError(w, http.StatusRequestEntityTooLarge, "Image is too large")
+			return
+		}
+		respondError(w, http.StatusBadRequest, "Failed to read image")
+		return
+	}
+
+	draft, err := h.llmService.ReadCoffeeLabel(r.Context(), data)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "invalid image"):
+			respondError(w, http.StatusBadRequest, err.Error())
+		case strings.Contains(err.Error(), "not available"):
+			respondError(w, http.StatusServiceUnavailable, err.Error())
+		case errors.Is(err, service.ErrLLMBudgetExceeded), errors.Is(err, service.ErrLLMQueueTimeout):
+			respondError(w, http.StatusTooManyRequests, err.Error())
+		default:
+			log.Printf("ERROR: Label reading failed: %v", err)
+			respondError(w, http.StatusInternalServerError, "Failed to read label")
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusOK, draft)
+}
@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"go-coffee-log/service"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// websocketGUID is appended to the client's key to accept a WebSocket
+// handshake (RFC 6455, section 1.3)
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+const (
+	// wsPingInterval is how often an idle connection is pinged; clients
+	// that send nothing, not even a pong, for two intervals are dropped
+	wsPingInterval = 30 * time.Second
+	wsWriteTimeout = 10 * time.Second
+	// wsMaxFrame caps frames from clients, which only need control frames
+	wsMaxFrame = 4096
+)
+
+// WebSocket opcodes used by the live channel
+const (
+	wsOpText  = 0x1
+	wsOpClose = 0x8
+	wsOpPing  = 0x9
+	wsOpPong  = 0xA
+)
+
+// liveResources are the resources a client may subscribe to
+var liveResources = []string{service.ResourceCoffee, service.ResourcePokemon, service.ResourceBrewer}
+
+// LiveHandler handles the WebSocket live-update channel
+type LiveHandler struct {
+	hub *service.LiveHub
+}
+
+// NewLiveHandler creates a new live handler
+func NewLiveHandler(hub *service.LiveHub) *LiveHandler {
+	return &LiveHandler{hub: hub}
+}
+
+// ServeWS handles GET /ws, upgrading to a WebSocket that receives every
+// coffee, Pokemon and brewer change as a JSON text message.
+// ?resources=coffee,brewer limits it to some resources.
+func (h *LiveHandler) ServeWS(w http.ResponseWriter, r *http.Request) {
+	if !headerHasToken(r.Header, "Connection", "upgrade") || !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		w.Header().Set("Upgrade", "websocket")
+		respondError(w, http.StatusUpgradeRequired, "Expected a WebSocket upgrade")
+		return
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		respondError(w, http.StatusUpgradeRequired, "Unsupported WebSocket version")
+		return
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if key == "" {
+		respondError(w, http.StatusBadRequest, "Missing Sec-WebSocket-Key")
+		return
+	}
+	resources, err := parseLiveResources(r.URL.Query().Get("resources"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	conn, buffered, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		log.Printf("ERROR: Failed to take over connection for WebSocket: %v", err)
+		respondError(w, http.StatusInternalServerError, "WebSocket not supported")
+		return
+	}
+	defer conn.Close()
+
+	accept := sha1.Sum([]byte(key + websocketGUID))
+	handshake := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(accept[:]) + "\r\n\r\n"
+	conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	if _, err := conn.Write([]byte(handshake)); err != nil {
+		return
+	}
+
+	changes, unsubscribe := h.hub.Subscribe()
+	defer unsubscribe()
+
+	ws := &wsConn{conn: conn}
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		ws.readLoop(buffered.Reader)
+	}()
+
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case change, ok := <-changes:
+			if !ok {
+				return
+			}
+			if !resources[change.Resource] {
+				continue
+			}
+			message, err := json.Marshal(change)
+			if err != nil {
+				log.Printf("ERROR: Failed to encode %s change: %v", change.Resource, err)
+				continue
+			}
+			if err := ws.writeFrame(wsOpText, message); err != nil {
+				return
+			}
+		case <-ping.C:
+			if err := ws.writeFrame(wsOpPing, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// parseLiveResources reads the resources a client subscribes to; empty
+// means all of them
+func parseLiveResources(value string) (map[string]bool, error) {
+	known := make(map[string]bool)
+	for _, resource := range liveResources {
+		known[resource] = true
+	}
+	if value == "" {
+		return known, nil
+	}
+
+	resources := make(map[string]bool)
+	for _, resource := range strings.Split(value, ",") {
+		resource = strings.TrimSpace(resource)
+		if !known[resource] {
+			return nil, fmt.Errorf("invalid resource %q; use %s", resource, strings.Join(liveResources, ", "))
+		}
+		resources[resource] = true
+	}
+	return resources, nil
+}
+
+// headerHasToken reports whether a comma-separated header such as
+// "Connection: keep-alive, Upgrade" contains a token
+func headerHasToken(header http.Header, name, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, part := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// wsConn is a server-side WebSocket connection. Writes come from both the
+// change loop and the reader's replies to pings, so they are serialized.
+type wsConn struct {
+	conn net.Conn
+	mu   sync.Mutex
+}
+
+// readLoop answers pings and close frames until the client goes away.
+// Clients have nothing to say on this channel, so data frames are ignored.
+func (c *wsConn) readLoop(reader *bufio.Reader) {
+	for {
+		c.conn.SetReadDeadline(time.Now().Add(2 * wsPingInterval))
+		opcode, payload, err := readWSFrame(reader)
+		if err != nil {
+			return
+		}
+		switch opcode {
+		case wsOpClose:
+			// Echo the status code, if any, to complete the closing handshake
+			if len(payload) > 2 {
+				payload = payload[:2]
+			}
+			c.writeFrame(wsOpClose, payload)
+			return
+		case wsOpPing:
+			if err := c.writeFrame(wsOpPong, payload); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// writeFrame sends one unfragmented, unmasked frame
+func (c *wsConn) writeFrame(opcode byte, payload []byte) error {
+	frame := []byte{0x80 | opcode}
+	switch length := len(payload); {
+	case length < 126:
+		frame = append(frame, byte(length))
+	case length <= 0xFFFF:
+		frame = append(frame, 126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(length))
+	default:
+		frame = append(frame, 127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(length))
+	}
+	frame = append(frame, payload...)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	_, err := c.conn.Write(frame)
+	return err
+}
+
+// readWSFrame reads one frame from a client and unmasks its payload.
+// Clients must mask their frames (RFC 6455, section 5.1).
+func readWSFrame(reader *bufio.Reader) (byte, []byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(reader, header[:]); err != nil {
+		return 0, nil, err
+	}
+	opcode := header[0] & 0x0F
+	if header[1]&0x80 == 0 {
+		return 0, nil, fmt.Errorf("unmasked frame from client")
+	}
+
+	length := uint64(header[1] & 0x7F)
+	switch length {
+	case 126:
+		var extended [2]byte
+		if _, err := io.ReadFull(reader, extended[:]); err != nil {
+			return 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(extended[:]))
+	case 127:
+		var extended [8]byte
+		if _, err := io.ReadFull(reader, extended[:]); err != nil {
+			return 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(extended[:])
+	}
+	if length > wsMaxFrame {
+		return 0, nil, fmt.Errorf("frame of %d bytes is too large", length)
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(reader, mask[:]); err != nil {
+		return 0, nil, err
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		return 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return opcode, payload, nil
+}
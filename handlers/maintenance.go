@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultMaintenanceRetryAfter is suggested to clients when no estimate is given
+const defaultMaintenanceRetryAfter = 5 * time.Minute
+
+// MaintenanceStatus describes the current maintenance window
+type MaintenanceStatus struct {
+	Enabled           bool       `json:"enabled"`
+	Reason            string     `json:"reason,omitempty"`
+	Since             *time.Time `json:"since,omitempty"`
+	RetryAfterSeconds int        `json:"retry_after_seconds,omitempty"`
+}
+
+// MaintenanceHandler puts the server into a read-only maintenance mode:
+// mutating requests get 503 with Retry-After while reads keep working
+type MaintenanceHandler struct {
+	mu     sync.RWMutex
+	status MaintenanceStatus
+}
+
+// NewMaintenanceHandler creates a maintenance handler with maintenance off
+func NewMaintenanceHandler() *MaintenanceHandler {
+	return &MaintenanceHandler{}
+}
+
+// Middleware rejects mutations during maintenance. Admin endpoints stay
+// available so backups and migrations can run and maintenance can be
+// switched off again; POSTs that only read are let through.
+func (h *MaintenanceHandler) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := h.Status()
+		if !status.Enabled || !isMutation(r) || strings.HasPrefix(r.URL.Path, "/admin/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Retry-After", strconv.Itoa(status.RetryAfterSeconds))
+		message := "Server is in maintenance mode; changes are temporarily disabled"
+		if status.Reason != "" {
+			message += ": " + status.Reason
+		}
+		respondError(w, http.StatusServiceUnavailable, message)
+	})
+}
+
+// Status returns the current maintenance state
+func (h *MaintenanceHandler) Status() MaintenanceStatus {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.status
+}
+
+// GetMaintenance handles GET /admin/maintenance
+func (h *MaintenanceHandler) GetMaintenance(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r); !ok {
+		return
+	}
+	respondJSON(w, http.StatusOK, h.Status())
+}
+
+// SetMaintenance handles POST /admin/maintenance
+// Body: {"enabled": true, "reason": "nightly backup", "retry_after_seconds": 600}
+func (h *MaintenanceHandler) SetMaintenance(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r); !ok {
+		return
+	}
+
+	var request struct {
+		Enabled           bool   `json:"enabled"`
+		Reason            string `json:"reason"`
+		RetryAfterSeconds int    `json:"retry_after_seconds"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	if request.RetryAfterSeconds < 0 {
+		respondError(w, http.StatusBadRequest, "retry_after_seconds must not be negative")
+		return
+	}
+
+	h.mu.Lock()
+	if !request.Enabled {
+		h.status = MaintenanceStatus{}
+	} else {
+		if !h.status.Enabled {
+			since := time.Now()
+			h.status.Since = &since
+		}
+		h.status.Enabled = true
+		h.status.Reason = request.Reason
+		h.status.RetryAfterSeconds = request.RetryAfterSeconds
+		if h.status.RetryAfterSeconds == 0 {
+			h.status.RetryAfterSeconds = int(defaultMaintenanceRetryAfter / time.Second)
+		}
+	}
+	status := h.status
+	h.mu.Unlock()
+
+	respondJSON(w, http.StatusOK, status)
+}
+
+// readOnlyPosts lists the routes that answer a question over POST without
+// changing stored data; "{id}" matches any single path segment
+var readOnlyPosts = []string{
+	"/chat",
+	"/coffees/parse",
+	"/coffees/from-label",
+	"/coffees/{id}/diagnose",
+}
+
+// isMutation reports whether a request can change stored data
+func isMutation(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	case http.MethodPost:
+		for _, route := range readOnlyPosts {
+			if matchesRoute(route, r.URL.Path) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// matchesRoute reports whether path matches a route whose "{...}" segments
+// stand for any single segment
+func matchesRoute(route, path string) bool {
+	routeParts := strings.Split(strings.Trim(route, "/"), "/")
+	pathParts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(routeParts) != len(pathParts) {
+		return false
+	}
+	for i, part := range routeParts {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			if pathParts[i] == "" {
+				return false
+			}
+			continue
+		}
+		if part != pathParts[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"encoding/json"
+	"go-coffee-log/service"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// MergeHandler handles HTTP requests to merge duplicate coffee entries
+type MergeHandler struct {
+	mergeService *service.MergeService
+}
+
+// NewMergeHandler creates a new merge handler
+func NewMergeHandler(mergeService *service.MergeService) *MergeHandler {
+	return &MergeHandler{mergeService: mergeService}
+}
+
+// MergeCoffee handles POST /coffees/{id}/merge with
+// {"duplicate_id": "...", "dry_run": true}. The duplicate is folded into
+// {id}; a dry run, asked for in the body or with ?dry_run=true, returns the
+// same diff without changing anything.
+func (h *MergeHandler) MergeCoffee(w http.ResponseWriter, r *http.Request) {
+	var request service.MergeRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+	request.DryRun = request.DryRun || isDryRun(r)
+
+	result, err := h.mergeService.Merge(r.PathValue("id"), request)
+	if err != nil {
+		message := err.Error()
+		switch {
+		case strings.Contains(message, "coffee not found"):
+			respondError(w, http.StatusNotFound, "Coffee not found")
+		case strings.Contains(message, "required"), strings.Contains(message, "invalid"):
+			respondError(w, http.StatusBadRequest, message)
+		default:
+			log.Printf("ERROR: Failed to merge coffees: %v", err)
+			respondError(w, http.StatusInternalServerError, "Failed to merge coffees")
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"go-coffee-log/service"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// OriginCalendarHandler handles HTTP requests for the harvest calendar
+type OriginCalendarHandler struct {
+	calendarService *service.OriginCalendarService
+}
+
+// NewOriginCalendarHandler creates a new origin calendar handler
+func NewOriginCalendarHandler(calendarService *service.OriginCalendarService) *OriginCalendarHandler {
+	return &OriginCalendarHandler{
+		calendarService: calendarService,
+	}
+}
+
+// GetCalendar handles GET /origins/calendar?month=1-12 (default: this month)
+func (h *OriginCalendarHandler) GetCalendar(w http.ResponseWriter, r *http.Request) {
+	month := time.Now().Month()
+	if value := r.URL.Query().Get("month"); value != "" {
+		m, err := strconv.Atoi(value)
+		if err != nil || m < 1 || m > 12 {
+			respondError(w, http.StatusBadRequest, "month must be 1-12")
+			return
+		}
+		month = time.Month(m)
+	}
+
+	calendar, err := h.calendarService.GetCalendar(month)
+	if err != nil {
+		log.Printf("ERROR: Failed to build origin calendar: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to build origin calendar")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, calendar)
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"errors"
+	"go-coffee-log/service"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// PhotoHandler handles HTTP requests for coffee photos
+type PhotoHandler struct {
+	photoService *service.PhotoService
+}
+
+// NewPhotoHandler creates a new photo handler
+func NewPhotoHandler(photoService *service.PhotoService) *PhotoHandler {
+	return &PhotoHandler{photoService: photoService}
+}
+
+// UploadPhoto handles POST /coffees/{id}/photos with either the raw image
+// as the body or a multipart form with a "photo" file field
+func (h *PhotoHandler) UploadPhoto(w http.ResponseWriter, r *http.Request) {
+	// Leave room for multipart framing around the image itself
+	r.Body = http.MaxBytesReader(w, r.Body, service.MaxPhotoSize+1<<20)
+	defer r.Body.Close()
+
+	var body io.Reader = r.Body
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		file, _, err := r.FormFile("photo")
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Expected a multipart \"photo\" file field")
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	data, err := io.ReadAll(io.LimitReader(body, service.MaxPhotoSize+1))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondError(w, http.StatusRequestEntityTooLarge, "Photo is too large")
+			return
+		}
+		respondError(w, http.StatusBadRequest, "Failed to read photo")
+		return
+	}
+
+	photo, err := h.photoService.Upload(r.PathValue("id"), callerID(r), data)
+	if err != nil {
+		h.respondPhotoError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, photo)
+}
+
+// ListPhotos handles GET /coffees/{id}/photos
+func (h *PhotoHandler) ListPhotos(w http.ResponseWriter, r *http.Request) {
+	photos, err := h.photoService.ListPhotos(r.PathValue("id"))
+	if err != nil {
+		h.respondPhotoError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, photos)
+}
+
+// DeletePhoto handles DELETE /coffees/{id}/photos/{hash}
+func (h *PhotoHandler) DeletePhoto(w http.ResponseWriter, r *http.Request) {
+	if err := h.photoService.RemovePhoto(r.PathValue("id"), r.PathValue("hash")); err != nil {
+		h.respondPhotoError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CollectGarbage handles POST /admin/media/gc?grace=1h, deleting stored
+// images no coffee references any more
+func (h *PhotoHandler) CollectGarbage(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r); !ok {
+		return
+	}
+
+	grace := service.DefaultMediaGCGrace
+	if value := r.URL.Query().Get("grace"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed < 0 {
+			respondError(w, http.StatusBadRequest, "grace must be a duration such as 30m")
+			return
+		}
+		grace = parsed
+	}
+
+	report, err := h.photoService.CollectGarbage(grace)
+	if err != nil {
+		log.Printf("ERROR: Media garbage collection failed: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to collect garbage")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, report)
+}
+
+// respondPhotoError maps photo service errors to HTTP responses
+func (h *PhotoHandler) respondPhotoError(w http.ResponseWriter, err error) {
+	message := err.Error()
+	switch {
+	case strings.Contains(message, "coffee not found"):
+		respondError(w, http.StatusNotFound, "Coffee not found")
+	case strings.Contains(message, "not found"):
+		respondError(w, http.StatusNotFound, "Photo not found")
+	case strings.Contains(message, "invalid photo"):
+		respondError(w, http.StatusBadRequest, message)
+	case strings.Contains(message, "quota exceeded"):
+		respondError(w, http.StatusPaymentRequired, message)
+	default:
+		log.Printf("ERROR: Photo request failed: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to process photo")
+	}
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"encoding/json"
+	"go-coffee-log/models"
+	"go-coffee-log/service"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// PlanHandler handles HTTP requests for planned brews
+type PlanHandler struct {
+	planService *service.PlanService
+	brewHandler *BrewHandler // records who brewed in shared-collection mode
+}
+
+// NewPlanHandler creates a new plan handler
+func NewPlanHandler(planService *service.PlanService, brewHandler *BrewHandler) *PlanHandler {
+	return &PlanHandler{planService: planService, brewHandler: brewHandler}
+}
+
+// SchedulePlan handles POST /plans with
+// {"coffee_id": "...", "scheduled_for": "2024-06-01T09:00:00Z", "dripper": "Chemex", "notes": "...", "reminder_minutes": 60}
+func (h *PlanHandler) SchedulePlan(w http.ResponseWriter, r *http.Request) {
+	var plan models.PlannedBrew
+	if err := json.NewDecoder(r.Body).Decode(&plan); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	if isDryRun(r) {
+		respondPreview(w, func() (service.DryRun, error) {
+			return h.planService.PreviewSchedulePlan(plan)
+		}, h.respondPlanError)
+		return
+	}
+
+	created, err := h.planService.SchedulePlan(plan)
+	if err != nil {
+		h.respondPlanError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, created)
+}
+
+// ListPlans handles GET /plans; ?include_brewed=true also lists plans that
+// were already brewed
+func (h *PlanHandler) ListPlans(w http.ResponseWriter, r *http.Request) {
+	plans, err := h.planService.ListPlans(r.URL.Query().Get("include_brewed") == "true")
+	if err != nil {
+		h.respondPlanError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, plans)
+}
+
+// GetPlan handles GET /plans/{id}
+func (h *PlanHandler) GetPlan(w http.ResponseWriter, r *http.Request) {
+	plan, err := h.planService.GetPlan(r.PathValue("id"))
+	if err != nil {
+		h.respondPlanError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, plan)
+}
+
+// CancelPlan handles DELETE /plans/{id}
+func (h *PlanHandler) CancelPlan(w http.ResponseWriter, r *http.Request) {
+	if isDryRun(r) {
+		respondPreview(w, func() (service.DryRun, error) {
+			return h.planService.PreviewCancelPlan(r.PathValue("id"))
+		}, h.respondPlanError)
+		return
+	}
+
+	if err := h.planService.CancelPlan(r.PathValue("id")); err != nil {
+		h.respondPlanError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// BrewPlan handles POST /plans/{id}/brew with the brew's results in the
+// same shape as POST /coffees/{id}/brews
+func (h *PlanHandler) BrewPlan(w http.ResponseWriter, r *http.Request) {
+	var brew models.Brew
+	if err := json.NewDecoder(r.Body).Decode(&brew); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	brew.BrewedBy = h.brewHandler.brewedBy(r)
+
+	if isDryRun(r) {
+		respondPreview(w, func() (service.DryRun, error) {
+			return h.planService.PreviewBrewPlan(r.PathValue("id"), brew)
+		}, h.respondPlanError)
+		return
+	}
+
+	plan, logged, err := h.planService.BrewPlan(r.PathValue("id"), brew)
+	if err != nil {
+		h.respondPlanError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, map[string]interface{}{
+		"plan": plan,
+		"brew": logged,
+	})
+}
+
+// respondPlanError maps plan service errors to HTTP responses
+func (h *PlanHandler) respondPlanError(w http.ResponseWriter, err error) {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "plan not found"):
+		respondError(w, http.StatusNotFound, "Plan not found")
+	case strings.Contains(msg, "coffee not found"):
+		respondError(w, http.StatusNotFound, "Coffee not found")
+	case strings.Contains(msg, "already brewed"):
+		respondError(w, http.StatusConflict, msg)
+	case strings.Contains(msg, "failed"):
+		log.Printf("ERROR: Plan operation failed: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to process plan")
+	default:
+		respondError(w, http.StatusBadRequest, msg)
+	}
+}
@@ -1,32 +1,122 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"go-coffee-log/models"
 	"go-coffee-log/service"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 )
 
 // PokemonHandler handles HTTP requests for Pokemon operations
 type PokemonHandler struct {
-	pokemonService *service.PokemonService
-	coffeeService  *service.CoffeeService
+	pokemonService  *service.PokemonService
+	coffeeService   *service.CoffeeService
+	dexService      *service.DexService
+	reactionService *service.ReactionService // optional
+	badgeService    *service.BadgeService    // optional
+	remapService    *service.RemapService    // optional
+	jobQueue        *service.JobQueue        // optional, makes generation asynchronous
 }
 
 // NewPokemonHandler creates a new Pokemon handler
-func NewPokemonHandler(pokemonService *service.PokemonService, coffeeService *service.CoffeeService) *PokemonHandler {
+func NewPokemonHandler(pokemonService *service.PokemonService, coffeeService *service.CoffeeService, dexService *service.DexService) *PokemonHandler {
 	return &PokemonHandler{
 		pokemonService: pokemonService,
 		coffeeService:  coffeeService,
+		dexService:     dexService,
 	}
 }
 
-// GeneratePokemon handles POST /coffees/{id}/pokemon
+// requestDex returns the dex profile a request is about: ?dex= when given,
+// otherwise the one the caller plays
+func (h *PokemonHandler) requestDex(r *http.Request) (string, error) {
+	if dex := r.URL.Query().Get("dex"); dex != "" {
+		return dex, nil
+	}
+	userID := ""
+	if user, ok := UserFromContext(r.Context()); ok {
+		userID = user.ID
+	}
+	profile, err := h.dexService.Selected(userID)
+	if err != nil {
+		return "", err
+	}
+	return profile.ID, nil
+}
+
+// SetReactionService enables reactions and adds their counts to Pokedex responses
+func (h *PokemonHandler) SetReactionService(reactionService *service.ReactionService) {
+	h.reactionService = reactionService
+}
+
+// SetBadgeService awards gym badges as Pokemon are caught
+func (h *PokemonHandler) SetBadgeService(badgeService *service.BadgeService) {
+	h.badgeService = badgeService
+}
+
+// SetJobQueue makes POST /pokemon/{coffee_id} queue the mapping and answer
+// 202 with a job to poll, rather than wait on the LLM
+func (h *PokemonHandler) SetJobQueue(jobQueue *service.JobQueue) {
+	h.jobQueue = jobQueue
+}
+
+// SetRemapService enables batch remapping of a dex profile's catches
+func (h *PokemonHandler) SetRemapService(remapService *service.RemapService) {
+	h.remapService = remapService
+}
+
+// attachReactions adds reaction counts to entries; failures only cost the counts
+func (h *PokemonHandler) attachReactions(mappings []models.CoffeePokemon) {
+	if h.reactionService == nil {
+		return
+	}
+	if err := h.reactionService.AttachCounts(mappings); err != nil {
+		log.Printf("ERROR: Failed to load reaction counts: %v", err)
+	}
+}
+
+// pokemonDryRun is the response to a dry run of GeneratePokemon
+type pokemonDryRun struct {
+	DryRun  bool                  `json:"dry_run"`
+	Pokemon *models.CoffeePokemon `json:"pokemon"`
+}
+
+// pokemonConflict is the 409 response when a coffee is already caught
+type pokemonConflict struct {
+	Error   string                `json:"error"`
+	Pokemon *models.CoffeePokemon `json:"pokemon"`
+}
+
+// GeneratePokemon handles POST /pokemon/{coffee_id}; the Pokemon is caught
+// in the caller's dex profile, or the one named by ?dex=. A coffee already
+// caught in that dex gets 409 with its catch, unless ?replace=true asks to
+// catch again in its place, or ?idempotent=true returns that catch with 200.
+// ?llm=false skips the LLM for a deterministic, rule-based pick. With
+// ?dry_run=true it reports the Pokemon that would be caught instead.
+//
+// With a job queue the catch is made in the background: the response is 202
+// with a job to poll at GET /jobs/{id}, whose result is the catch.
+// ?wait=true waits for the catch instead.
 func (h *PokemonHandler) GeneratePokemon(w http.ResponseWriter, r *http.Request) {
 	coffeeID := r.PathValue("coffee_id")
 	log.Printf("GeneratePokemon called for coffee ID: %s", coffeeID)
 	
+	ctx, ok := mappingContext(w, r)
+	if !ok {
+		return
+	}
+	
+	dex, err := h.requestDex(r)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to load dex profile")
+		return
+	}
+	
 	// Get coffee from service
 	coffee, err := h.coffeeService.GetCoffee(coffeeID)
 	if err != nil {
@@ -35,39 +125,322 @@ func (h *PokemonHandler) GeneratePokemon(w http.ResponseWriter, r *http.Request)
 		return
 	}
 	
-	// Generate Pokemon mapping
-	mapping, err := h.pokemonService.MapCoffeeToPokemon(coffee)
+	replace := r.URL.Query().Get("replace") == "true"
+	idempotent := r.URL.Query().Get("idempotent") == "true"
+	if isDryRun(r) {
+		mapping, err := h.pokemonService.PreviewCoffeePokemon(ctx, coffee, dex, replace)
+		if err != nil && idempotent && isCaughtError(err) {
+			mapping, err = h.pokemonService.GetCoffeePokemon(coffeeID, dex)
+		}
+		if err != nil {
+			h.respondGenerateError(w, err, coffeeID, dex)
+			return
+		}
+		respondJSON(w, http.StatusOK, pokemonDryRun{DryRun: true, Pokemon: mapping})
+		return
+	}
+	
+	caller := callerID(r)
+	if h.jobQueue != nil && r.URL.Query().Get("wait") != "true" {
+		// Settle an existing catch now rather than queue a job bound to fail
+		if !replace {
+			if existing, err := h.pokemonService.GetCoffeePokemon(coffeeID, dex); err == nil {
+				if idempotent {
+					respondJSON(w, http.StatusOK, existing)
+				} else {
+					h.respondGenerateError(w, fmt.Errorf("coffee already has a Pokemon in the %s dex: %s", existing.Dex, existing.PokemonName), coffeeID, dex)
+				}
+				return
+			}
+		}
+		job, err := h.jobQueue.Submit(ctx, caller, "pokemon.generate", func(ctx context.Context) (interface{}, error) {
+			mapping, _, err := h.catchPokemon(ctx, coffee, dex, replace, idempotent, caller)
+			return mapping, err
+		})
+		if err != nil {
+			respondError(w, http.StatusServiceUnavailable, err.Error())
+			return
+		}
+		w.Header().Set("Location", "/jobs/"+job.ID)
+		respondJSON(w, http.StatusAccepted, job)
+		return
+	}
+	
+	mapping, status, err := h.catchPokemon(ctx, coffee, dex, replace, idempotent, caller)
 	if err != nil {
-		log.Printf("Error mapping coffee to Pokemon: %v", err)
-		respondError(w, http.StatusInternalServerError, err.Error())
+		h.respondGenerateError(w, err, coffeeID, dex)
 		return
 	}
+	respondJSON(w, status, mapping)
+}
+
+// catchPokemon maps a coffee to a Pokemon for GeneratePokemon, or with
+// replace catches it again, and reports the status to answer with. With
+// idempotent an existing catch is returned rather than refused.
+func (h *PokemonHandler) catchPokemon(ctx context.Context, coffee models.Coffee, dex string, replace, idempotent bool, caller string) (*models.CoffeePokemon, int, error) {
+	status := http.StatusCreated
+	var mapping *models.CoffeePokemon
+	var err error
+	if replace {
+		status = http.StatusOK
+		mapping, err = h.pokemonService.ReplaceCoffeePokemon(ctx, coffee, dex)
+	} else {
+		mapping, err = h.pokemonService.MapCoffeeToPokemon(ctx, coffee, dex)
+	}
+	if err != nil && idempotent && isCaughtError(err) {
+		if existing, getErr := h.pokemonService.GetCoffeePokemon(coffee.ID, dex); getErr == nil {
+			return existing, http.StatusOK, nil
+		}
+	}
+	if err != nil {
+		log.Printf("Error mapping coffee to Pokemon: %v", err)
+		return nil, 0, err
+	}
 	
 	log.Printf("Successfully generated Pokemon mapping: %+v", mapping)
-	respondJSON(w, http.StatusCreated, mapping)
+	
+	// The catch may complete a gym badge; a failure here doesn't undo it
+	if h.badgeService != nil {
+		if _, err := h.badgeService.Award(caller); err != nil {
+			log.Printf("ERROR: Failed to award badges: %v", err)
+		}
+	}
+	return mapping, status, nil
+}
+
+// RerollPokemon handles POST /pokemon/{coffee_id}/reroll: a caught coffee
+// is mapped again and its catch in the dex profile swapped for the new one.
+// ?exclude_current=true never picks the Pokemon it holds now; ?llm=false
+// and ?dry_run=true work as for GeneratePokemon.
+func (h *PokemonHandler) RerollPokemon(w http.ResponseWriter, r *http.Request) {
+	coffeeID := r.PathValue("coffee_id")
+	
+	ctx, ok := mappingContext(w, r)
+	if !ok {
+		return
+	}
+	excludeCurrent := false
+	if value := r.URL.Query().Get("exclude_current"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "exclude_current must be true or false")
+			return
+		}
+		excludeCurrent = parsed
+	}
+	
+	dex, err := h.requestDex(r)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to load dex profile")
+		return
+	}
+	coffee, err := h.coffeeService.GetCoffee(coffeeID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "Coffee not found")
+		return
+	}
+	
+	if isDryRun(r) {
+		mapping, err := h.pokemonService.PreviewReroll(ctx, coffee, dex, excludeCurrent)
+		if err != nil {
+			h.respondGenerateError(w, err, coffeeID, dex)
+			return
+		}
+		respondJSON(w, http.StatusOK, pokemonDryRun{DryRun: true, Pokemon: mapping})
+		return
+	}
+	
+	mapping, err := h.pokemonService.RerollCoffeePokemon(ctx, coffee, dex, excludeCurrent)
+	if err != nil {
+		log.Printf("Error rerolling Pokemon of coffee %s: %v", coffeeID, err)
+		h.respondGenerateError(w, err, coffeeID, dex)
+		return
+	}
+	respondJSON(w, http.StatusOK, mapping)
+}
+
+// StartRemap handles POST /pokedex/remap, mapping the catches of a dex
+// profile again in the background. ?below_confidence= limits it to catches
+// below that confidence and ?llm=false maps with the rules only. It responds
+// 202 with the job; poll GET /pokedex/remap/{id} for its progress.
+func (h *PokemonHandler) StartRemap(w http.ResponseWriter, r *http.Request) {
+	if h.remapService == nil {
+		respondError(w, http.StatusNotFound, "Remapping not available")
+		return
+	}
+	ctx, ok := mappingContext(w, r)
+	if !ok {
+		return
+	}
+	belowConfidence := 0.0
+	if value := r.URL.Query().Get("below_confidence"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed <= 0 || parsed > 1 {
+			respondError(w, http.StatusBadRequest, "below_confidence must be a number above 0, up to 1")
+			return
+		}
+		belowConfidence = parsed
+	}
+	
+	dex, err := h.requestDex(r)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to load dex profile")
+		return
+	}
+	job, err := h.remapService.Start(ctx, dex, belowConfidence)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "remap already running"):
+			respondError(w, http.StatusConflict, err.Error())
+		case strings.Contains(err.Error(), "not found"):
+			respondError(w, http.StatusNotFound, "Dex profile not found")
+		case strings.Contains(err.Error(), "invalid"):
+			respondError(w, http.StatusBadRequest, err.Error())
+		default:
+			log.Printf("ERROR: Failed to start remap: %v", err)
+			respondError(w, http.StatusInternalServerError, "Failed to start remap")
+		}
+		return
+	}
+	respondJSON(w, http.StatusAccepted, job)
+}
+
+// GetRemap handles GET /pokedex/remap/{id}
+func (h *PokemonHandler) GetRemap(w http.ResponseWriter, r *http.Request) {
+	if h.remapService == nil {
+		respondError(w, http.StatusNotFound, "Remapping not available")
+		return
+	}
+	job, err := h.remapService.Job(r.PathValue("id"))
+	if err != nil {
+		respondError(w, http.StatusNotFound, "Remap job not found")
+		return
+	}
+	respondJSON(w, http.StatusOK, job)
+}
+
+// mappingContext returns the context to map a coffee with, honoring
+// ?llm=false. It responds 400 and reports false for a bad value.
+func mappingContext(w http.ResponseWriter, r *http.Request) (context.Context, bool) {
+	value := r.URL.Query().Get("llm")
+	if value == "" {
+		return r.Context(), true
+	}
+	useLLM, err := strconv.ParseBool(value)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "llm must be true or false")
+		return nil, false
+	}
+	if !useLLM {
+		return service.WithRulesOnly(r.Context()), true
+	}
+	return r.Context(), true
+}
+
+// isCaughtError reports whether a mapping failed because the coffee is
+// already caught in the dex, whether found up front or by the unique index
+func isCaughtError(err error) bool {
+	return strings.Contains(err.Error(), "already has a Pokemon")
+}
+
+// respondGenerateError maps the errors of GeneratePokemon and RerollPokemon.
+// A coffee that is already caught gets 409 with its current catch, as does
+// losing a race for the picked Pokemon.
+func (h *PokemonHandler) respondGenerateError(w http.ResponseWriter, err error, coffeeID, dex string) {
+	message := err.Error()
+	switch {
+	case strings.Contains(message, "dex profile not found"):
+		respondError(w, http.StatusNotFound, "Dex profile not found")
+	case strings.Contains(message, "Pokemon mapping not found"):
+		respondError(w, http.StatusNotFound, "Coffee has no Pokemon in this dex to reroll")
+	case isCaughtError(err):
+		existing, _ := h.pokemonService.GetCoffeePokemon(coffeeID, dex)
+		respondJSON(w, http.StatusConflict, pokemonConflict{
+			Error:   message + "; use ?replace=true to catch again",
+			Pokemon: existing,
+		})
+	case strings.Contains(message, "just caught by another coffee"):
+		respondError(w, http.StatusConflict, message+"; try again")
+	default:
+		respondError(w, http.StatusInternalServerError, message)
+	}
 }
 
 // GetCoffeePokemon handles GET /coffees/{id}/pokemon
 func (h *PokemonHandler) GetCoffeePokemon(w http.ResponseWriter, r *http.Request) {
 	coffeeID := r.PathValue("coffee_id")
 	
-	mapping, err := h.pokemonService.GetCoffeePokemon(coffeeID)
+	dex, err := h.requestDex(r)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to load dex profile")
+		return
+	}
+	mapping, err := h.pokemonService.GetCoffeePokemon(coffeeID, dex)
 	if err != nil {
 		respondError(w, http.StatusNotFound, "Pokemon mapping not found")
 		return
 	}
 	
-	respondJSON(w, http.StatusOK, mapping)
+	entries := []models.CoffeePokemon{*mapping}
+	h.attachReactions(entries)
+	respondJSON(w, http.StatusOK, entries[0])
 }
 
-// GetCoffeeDex handles GET /pokedex
+// ExplainMapping handles GET /pokemon/{coffee_id}/explain: the per-type
+// scores of the rule-based mapper for the coffee, broken down into trait
+// contributions, keyword matches and processing and roast bonuses, along
+// with its catch in the dex profile if it has one
+func (h *PokemonHandler) ExplainMapping(w http.ResponseWriter, r *http.Request) {
+	coffeeID := r.PathValue("coffee_id")
+	
+	dex, err := h.requestDex(r)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to load dex profile")
+		return
+	}
+	explanation, err := h.pokemonService.ExplainMapping(coffeeID, dex)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "coffee not found"):
+			respondError(w, http.StatusNotFound, "Coffee not found")
+		case strings.Contains(err.Error(), "dex profile not found"):
+			respondError(w, http.StatusNotFound, "Dex profile not found")
+		default:
+			log.Printf("ERROR: Failed to explain mapping of coffee %s: %v", coffeeID, err)
+			respondError(w, http.StatusInternalServerError, "Failed to explain mapping")
+		}
+		return
+	}
+	
+	respondJSON(w, http.StatusOK, explanation)
+}
+
+// GetCoffeeDex handles GET /pokedex with the entries of the caller's dex
+// profile, or the one named by ?dex=; ?nickname= keeps entries whose
+// nickname contains the text
 func (h *PokemonHandler) GetCoffeeDex(w http.ResponseWriter, r *http.Request) {
-	mappings, err := h.pokemonService.GetAllCoffeePokemon()
+	dex, err := h.requestDex(r)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to load dex profile")
+		return
+	}
+	
+	var mappings []models.CoffeePokemon
+	if nickname := r.URL.Query().Get("nickname"); nickname != "" {
+		mappings, err = h.pokemonService.SearchByNickname(dex, nickname)
+	} else {
+		mappings, err = h.pokemonService.GetDexEntries(dex)
+	}
 	if err != nil {
+		if strings.Contains(err.Error(), "dex profile not found") {
+			respondError(w, http.StatusNotFound, "Dex profile not found")
+			return
+		}
 		respondError(w, http.StatusInternalServerError, "Failed to fetch CoffeeDex")
 		return
 	}
 	
+	h.attachReactions(mappings)
 	respondJSON(w, http.StatusOK, mappings)
 }
 
@@ -86,7 +459,12 @@ func (h *PokemonHandler) UpdateNickname(w http.ResponseWriter, r *http.Request)
 	}
 	defer r.Body.Close()
 	
-	if err := h.pokemonService.UpdateNickname(coffeeID, request.Nickname); err != nil {
+	dex, err := h.requestDex(r)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to load dex profile")
+		return
+	}
+	if err := h.pokemonService.UpdateNickname(coffeeID, dex, request.Nickname); err != nil {
 		respondError(w, http.StatusNotFound, "Pokemon mapping not found")
 		return
 	}
@@ -94,9 +472,76 @@ func (h *PokemonHandler) UpdateNickname(w http.ResponseWriter, r *http.Request)
 	respondJSON(w, http.StatusOK, map[string]string{"message": "Nickname updated successfully"})
 }
 
-// GetPokemonStats handles GET /pokedex/stats
+// BulkRename handles POST /pokedex/nicknames with
+// {"scheme": "{origin}-{type}", "coffee_ids": [...], "only_unnamed": false, "dry_run": true};
+// a dry run may also be asked for with ?dry_run=true
+func (h *PokemonHandler) BulkRename(w http.ResponseWriter, r *http.Request) {
+	var request service.BulkRenameRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+	request.DryRun = request.DryRun || isDryRun(r)
+	
+	dex, err := h.requestDex(r)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to load dex profile")
+		return
+	}
+	result, err := h.pokemonService.BulkRename(dex, request)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "scheme"):
+			respondError(w, http.StatusBadRequest, err.Error())
+		case strings.Contains(err.Error(), "not found"):
+			respondError(w, http.StatusNotFound, err.Error())
+		default:
+			log.Printf("ERROR: Failed to rename Pokedex entries: %v", err)
+			respondError(w, http.StatusInternalServerError, "Failed to rename Pokedex entries")
+		}
+		return
+	}
+	
+	respondJSON(w, http.StatusOK, result)
+}
+
+// GetPokemonStats handles GET /pokedex/stats for the caller's dex profile,
+// or the one named by ?dex=. Catches below ?min_confidence= (default
+// service.DefaultLowConfidence) are listed for a re-roll.
 func (h *PokemonHandler) GetPokemonStats(w http.ResponseWriter, r *http.Request) {
-	mappings, err := h.pokemonService.GetAllCoffeePokemon()
+	threshold := service.DefaultLowConfidence
+	if value := r.URL.Query().Get("min_confidence"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed < 0 || parsed > 1 {
+			respondError(w, http.StatusBadRequest, "min_confidence must be a number from 0 to 1")
+			return
+		}
+		threshold = parsed
+	}
+	
+	dexID, err := h.requestDex(r)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch stats")
+		return
+	}
+	dex, err := h.dexService.Profile(dexID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "Dex profile not found")
+		return
+	}
+	progress, err := h.dexService.ProfileProgress(dex)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch stats")
+		return
+	}
+	mappings, err := h.pokemonService.GetDexEntries(dex.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch stats")
+		return
+	}
+	
+	dataset, err := h.pokemonService.GetDatasetVersion()
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "Failed to fetch stats")
 		return
@@ -105,15 +550,112 @@ func (h *PokemonHandler) GetPokemonStats(w http.ResponseWriter, r *http.Request)
 	stats := map[string]interface{}{
 		"total_coffees": len(mappings),
 		"pokemon_used":  len(mappings),
-		"collection_complete": len(mappings) >= 151, // Gen 1 has 151 Pokemon
+		"collection_complete": progress.Complete,
+		"dex": progress,
 		"average_confidence": calculateAverageConfidence(mappings),
+		"confidence": service.BuildConfidenceReport(dex.ID, mappings, threshold),
+		"dataset": dataset,
 	}
 	
 	respondJSON(w, http.StatusOK, stats)
 }
 
+// RegenerateDescription handles POST /pokemon/{coffee_id}/description
+// With ?stream=true (or Accept: text/event-stream) the new entry is streamed
+// as server-sent events: "chunk" events carry text fragments, a final "done"
+// event carries the saved mapping and "error" reports a failure mid-stream.
+func (h *PokemonHandler) RegenerateDescription(w http.ResponseWriter, r *http.Request) {
+	coffeeID := r.PathValue("coffee_id")
+	
+	dex, err := h.requestDex(r)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to load dex profile")
+		return
+	}
+	
+	stream := r.URL.Query().Get("stream") == "true" ||
+		strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+	
+	if !stream {
+		mapping, err := h.pokemonService.RegenerateDescription(r.Context(), coffeeID, dex, nil)
+		if err != nil {
+			log.Printf("Error regenerating description: %v", err)
+			respondError(w, descriptionErrorStatus(err), err.Error())
+			return
+		}
+		respondJSON(w, http.StatusOK, mapping)
+		return
+	}
+	
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		respondError(w, http.StatusInternalServerError, "Streaming is not supported")
+		return
+	}
+	
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	
+	mapping, err := h.pokemonService.RegenerateDescription(r.Context(), coffeeID, dex, func(chunk string) error {
+		if err := writeSSE(w, "chunk", map[string]string{"text": chunk}); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	})
+	if err != nil {
+		log.Printf("Error streaming description: %v", err)
+		writeSSE(w, "error", map[string]string{"error": err.Error()})
+		flusher.Flush()
+		return
+	}
+	
+	writeSSE(w, "done", mapping)
+	flusher.Flush()
+}
+
+// GetDisagreements handles GET /pokedex/disagreements
+func (h *PokemonHandler) GetDisagreements(w http.ResponseWriter, r *http.Request) {
+	disagreements, err := h.pokemonService.GetDisagreements()
+	if err != nil {
+		respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	
+	if disagreements == nil {
+		disagreements = []models.MappingDisagreement{}
+	}
+	
+	respondJSON(w, http.StatusOK, disagreements)
+}
+
 // Helper functions
 
+// writeSSE writes a single server-sent event with a JSON payload
+func writeSSE(w http.ResponseWriter, event string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+	return err
+}
+
+// descriptionErrorStatus maps description regeneration errors to HTTP status codes
+func descriptionErrorStatus(err error) int {
+	switch {
+	case strings.Contains(err.Error(), "not found"):
+		return http.StatusNotFound
+	case strings.Contains(err.Error(), "not available"):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 func calculateAverageConfidence(mappings []models.CoffeePokemon) float64 {
 	if len(mappings) == 0 {
 		return 0.0
@@ -125,4 +667,51 @@ func calculateAverageConfidence(mappings []models.CoffeePokemon) float64 {
 	}
 	
 	return total / float64(len(mappings))
-}
\ No newline at end of file
+}
+// AddReaction handles POST /pokemon/{coffee_id}/reactions
+func (h *PokemonHandler) AddReaction(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Emoji string `json:"emoji"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	user, _ := UserFromContext(r.Context())
+	counts, err := h.reactionService.React(r.PathValue("coffee_id"), user.ID, user.Name, request.Emoji)
+	if err != nil {
+		h.respondReactionError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{"reactions": counts})
+}
+
+// RemoveReaction handles DELETE /pokemon/{coffee_id}/reactions/{emoji}
+func (h *PokemonHandler) RemoveReaction(w http.ResponseWriter, r *http.Request) {
+	user, _ := UserFromContext(r.Context())
+	counts, err := h.reactionService.Unreact(r.PathValue("coffee_id"), user.ID, r.PathValue("emoji"))
+	if err != nil {
+		h.respondReactionError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{"reactions": counts})
+}
+
+// respondReactionError maps reaction service errors to HTTP responses
+func (h *PokemonHandler) respondReactionError(w http.ResponseWriter, err error) {
+	switch {
+	case strings.Contains(err.Error(), "unsupported reaction"):
+		respondError(w, http.StatusBadRequest, err.Error())
+	case strings.Contains(err.Error(), "reaction not found"):
+		respondError(w, http.StatusNotFound, "Reaction not found")
+	case strings.Contains(err.Error(), "not found"):
+		respondError(w, http.StatusNotFound, "Pokemon mapping not found")
+	default:
+		log.Printf("ERROR: Reaction request failed: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to update reactions")
+	}
+}
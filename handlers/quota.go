@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"go-coffee-log/service"
+	"log"
+	"net/http"
+)
+
+// QuotaHandler handles HTTP requests for storage quota usage
+type QuotaHandler struct {
+	quotaService *service.QuotaService
+}
+
+// NewQuotaHandler creates a new quota handler
+func NewQuotaHandler(quotaService *service.QuotaService) *QuotaHandler {
+	return &QuotaHandler{quotaService: quotaService}
+}
+
+// GetUsage handles GET /users/me/usage, reporting how much of each quota
+// the caller uses
+func (h *QuotaHandler) GetUsage(w http.ResponseWriter, r *http.Request) {
+	usage, err := h.quotaService.Usage(callerID(r))
+	if err != nil {
+		log.Printf("ERROR: Failed to compute quota usage: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to compute usage")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, usage)
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"go-coffee-log/service"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// RecipeHandler handles HTTP requests for recipe suggestions
+type RecipeHandler struct {
+	recipeService *service.RecipeService
+}
+
+// NewRecipeHandler creates a new recipe handler
+func NewRecipeHandler(recipeService *service.RecipeService) *RecipeHandler {
+	return &RecipeHandler{
+		recipeService: recipeService,
+	}
+}
+
+// SuggestRecipe handles GET /coffees/{id}/suggested-recipe?brewer_id=
+func (h *RecipeHandler) SuggestRecipe(w http.ResponseWriter, r *http.Request) {
+	coffeeID := r.PathValue("id")
+	brewerID := r.URL.Query().Get("brewer_id")
+
+	suggestion, err := h.recipeService.SuggestRecipe(coffeeID, brewerID)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "brewer not found"):
+			respondError(w, http.StatusNotFound, "Brewer not found")
+		case strings.Contains(err.Error(), "not found"):
+			respondError(w, http.StatusNotFound, "Coffee not found")
+		case strings.Contains(err.Error(), "require"):
+			respondError(w, http.StatusBadRequest, err.Error())
+		default:
+			log.Printf("ERROR: Recipe suggestion failed for coffee %s: %v", coffeeID, err)
+			respondError(w, http.StatusInternalServerError, "Failed to suggest recipe")
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusOK, suggestion)
+}
+
+// ConvertRecipe handles GET /coffees/{id}/converted-recipe?brewer_id=&brewer=
+// Without a target brewer it converts to every brewer in the brewer list.
+func (h *RecipeHandler) ConvertRecipe(w http.ResponseWriter, r *http.Request) {
+	coffeeID := r.PathValue("id")
+	brewerID := r.URL.Query().Get("brewer_id")
+	brewer := r.URL.Query().Get("brewer")
+
+	var result interface{}
+	var err error
+	if brewerID == "" && brewer == "" {
+		result, err = h.recipeService.ConvertRecipeForBrewers(coffeeID)
+	} else {
+		result, err = h.recipeService.ConvertRecipe(coffeeID, brewerID, brewer)
+	}
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "brewer not found"):
+			respondError(w, http.StatusNotFound, "Brewer not found")
+		case strings.Contains(err.Error(), "not found"):
+			respondError(w, http.StatusNotFound, "Coffee not found")
+		case strings.Contains(err.Error(), "require"), strings.Contains(err.Error(), "invalid"):
+			respondError(w, http.StatusBadRequest, err.Error())
+		default:
+			log.Printf("ERROR: Recipe conversion failed for coffee %s: %v", coffeeID, err)
+			respondError(w, http.StatusInternalServerError, "Failed to convert recipe")
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusOK, result)
+}
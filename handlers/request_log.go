@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CapturedRequest is one request/response pair kept for debugging
+type CapturedRequest struct {
+	Time              time.Time `json:"time"`
+	Method            string    `json:"method"`
+	Path              string    `json:"path"`
+	Query             string    `json:"query,omitempty"`
+	Status            int       `json:"status"`
+	DurationMs        int64     `json:"duration_ms"`
+	RequestType       string    `json:"request_content_type,omitempty"`
+	RequestBody       string    `json:"request_body,omitempty"`
+	RequestTruncated  bool      `json:"request_truncated,omitempty"`
+	ResponseType      string    `json:"response_content_type,omitempty"`
+	ResponseBody      string    `json:"response_body,omitempty"`
+	ResponseTruncated bool      `json:"response_truncated,omitempty"`
+}
+
+// RequestLog keeps the most recent requests and responses in a ring
+// buffer. Bodies are cut at a size limit, and secrets and nicknames are
+// redacted before anything is stored.
+type RequestLog struct {
+	mu        sync.Mutex
+	entries   []CapturedRequest
+	next      int
+	full      bool
+	bodyLimit int
+}
+
+// NewRequestLog creates a ring buffer of size entries, keeping at most
+// bodyLimit bytes of each body
+func NewRequestLog(size, bodyLimit int) *RequestLog {
+	return &RequestLog{
+		entries:   make([]CapturedRequest, size),
+		bodyLimit: bodyLimit,
+	}
+}
+
+// Capture records every request passing through next
+func (l *RequestLog) Capture(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Viewing the log must not fill it with copies of itself
+		if r.URL.Path == "/admin/recent-requests" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		start := time.Now()
+		requestBody := &limitedBuffer{limit: l.bodyLimit}
+		if r.Body != nil {
+			r.Body = readCloser{Reader: io.TeeReader(r.Body, requestBody), Closer: r.Body}
+		}
+		recorder := &captureWriter{ResponseWriter: w, status: http.StatusOK, body: &limitedBuffer{limit: l.bodyLimit}}
+
+		next.ServeHTTP(recorder, r)
+
+		l.add(CapturedRequest{
+			Time:              start,
+			Method:            r.Method,
+			Path:              redactPath(r.URL.Path),
+			Query:             redactQuery(r.URL.RawQuery),
+			Status:            recorder.status,
+			DurationMs:        time.Since(start).Milliseconds(),
+			RequestType:       r.Header.Get("Content-Type"),
+			RequestBody:       redactBody(requestBody.String()),
+			RequestTruncated:  requestBody.truncated,
+			ResponseType:      recorder.Header().Get("Content-Type"),
+			ResponseBody:      redactBody(recorder.body.String()),
+			ResponseTruncated: recorder.body.truncated,
+		})
+	})
+}
+
+// add stores an entry, overwriting the oldest when full
+func (l *RequestLog) add(entry CapturedRequest) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.entries) == 0 {
+		return
+	}
+	l.entries[l.next] = entry
+	l.next = (l.next + 1) % len(l.entries)
+	if l.next == 0 {
+		l.full = true
+	}
+}
+
+// Recent returns up to limit captured requests, newest first
+func (l *RequestLog) Recent(limit int) []CapturedRequest {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	count := l.next
+	if l.full {
+		count = len(l.entries)
+	}
+	if limit <= 0 || limit > count {
+		limit = count
+	}
+
+	recent := make([]CapturedRequest, 0, limit)
+	for i := 1; i <= limit; i++ {
+		recent = append(recent, l.entries[(l.next-i+len(l.entries))%len(l.entries)])
+	}
+	return recent
+}
+
+// sensitiveKeys are JSON fields whose values never reach the log: secrets,
+// invite codes, webhook URLs (Discord puts the secret in the URL) and
+// nicknames people give their Pokemon
+const sensitiveKeys = `token|admin_token|password|secret|api_key|authorization|code|webhook_url|nickname`
+
+// sensitiveField matches a sensitive JSON field and its string or scalar value
+var sensitiveField = regexp.MustCompile(`(?i)("(?:` + sensitiveKeys + `)"\s*:\s*)("(?:[^"\\]|\\.)*"?|[^,}\]\s]+)`)
+
+// sensitiveParam matches sensitive query parameters
+var sensitiveParam = regexp.MustCompile(`(?i)(^|&)(` + sensitiveKeys + `)=[^&]*`)
+
+// sensitivePath matches invite codes, which appear in invite URLs
+var sensitivePath = regexp.MustCompile(`^/invites/[^/]+`)
+
+// redactPath masks secrets that are part of the path
+func redactPath(path string) string {
+	return sensitivePath.ReplaceAllString(path, "/invites/[REDACTED]")
+}
+
+// redactBody masks sensitive JSON values. It works on truncated bodies too.
+func redactBody(body string) string {
+	return sensitiveField.ReplaceAllString(body, `$1"[REDACTED]"`)
+}
+
+// redactQuery masks sensitive query parameters such as ?token=
+func redactQuery(query string) string {
+	return sensitiveParam.ReplaceAllString(query, `$1$2=[REDACTED]`)
+}
+
+// limitedBuffer keeps the first limit bytes written to it
+type limitedBuffer struct {
+	bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+// Write implements io.Writer, never failing so the request is unaffected
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.Len(); room < len(p) {
+		if room > 0 {
+			b.Buffer.Write(p[:room])
+		}
+		b.truncated = true
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
+
+// String returns the captured text, dropping a partial trailing rune
+func (b *limitedBuffer) String() string {
+	return strings.ToValidUTF8(b.Buffer.String(), "")
+}
+
+// readCloser pairs a reader with the original body's Close
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// captureWriter records the status and a copy of the response body
+type captureWriter struct {
+	http.ResponseWriter
+	status int
+	body   *limitedBuffer
+}
+
+// WriteHeader records the status code
+func (c *captureWriter) WriteHeader(code int) {
+	c.status = code
+	c.ResponseWriter.WriteHeader(code)
+}
+
+// Write copies the body into the capture buffer
+func (c *captureWriter) Write(p []byte) (int, error) {
+	c.body.Write(p)
+	return c.ResponseWriter.Write(p)
+}
+
+// Flush keeps server-sent event streams working through the capture
+func (c *captureWriter) Flush() {
+	if flusher, ok := c.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (c *captureWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-coffee-log/models"
+	"go-coffee-log/service"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// RulePackHandler handles HTTP requests for mapper rule packs. Packs change
+// how every coffee is typed, so managing them is reserved to admins.
+type RulePackHandler struct {
+	rulePackService *service.RulePackService
+}
+
+// NewRulePackHandler creates a new rule pack handler
+func NewRulePackHandler(rulePackService *service.RulePackService) *RulePackHandler {
+	return &RulePackHandler{rulePackService: rulePackService}
+}
+
+// ListPacks handles GET /admin/rule-packs
+func (h *RulePackHandler) ListPacks(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r); !ok {
+		return
+	}
+
+	list, err := h.rulePackService.ListPacks()
+	if err != nil {
+		h.respondRulePackError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, list)
+}
+
+// ExportPack handles GET /admin/rule-packs/export?name=&version=&author=&description=
+// and downloads the rules in use as a pack others can import
+func (h *RulePackHandler) ExportPack(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r); !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	name, version, author := query.Get("name"), query.Get("version"), query.Get("author")
+	if name == "" {
+		name = "my-rules"
+	}
+	if version == "" {
+		version = "1.0.0"
+	}
+	if author == "" {
+		author = "anonymous"
+		if user, ok := UserFromContext(r.Context()); ok {
+			author = user.Name
+		}
+	}
+
+	pack, err := h.rulePackService.Export(name, version, author, query.Get("description"))
+	if err != nil {
+		h.respondRulePackError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", pack.Name+"-"+pack.Version+".json"))
+	respondJSON(w, http.StatusOK, pack)
+}
+
+// ImportPack handles POST /admin/rule-packs with a pack as exported by
+// ExportPack; add ?activate=true to switch to it right away
+func (h *RulePackHandler) ImportPack(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r); !ok {
+		return
+	}
+
+	var pack models.RulePack
+	if err := json.NewDecoder(r.Body).Decode(&pack); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	imported, err := h.rulePackService.Import(pack, r.URL.Query().Get("activate") == "true")
+	if err != nil {
+		h.respondRulePackError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, imported)
+}
+
+// GetPack handles GET /admin/rule-packs/{name}; "builtin" returns the
+// rules the server ships with
+func (h *RulePackHandler) GetPack(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r); !ok {
+		return
+	}
+
+	pack, err := h.rulePackService.GetPack(r.PathValue("name"))
+	if err != nil {
+		h.respondRulePackError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, pack)
+}
+
+// ActivatePack handles POST /admin/rule-packs/{name}/activate; activating
+// "builtin" goes back to the built-in rules
+func (h *RulePackHandler) ActivatePack(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r); !ok {
+		return
+	}
+
+	if err := h.rulePackService.Activate(r.PathValue("name")); err != nil {
+		h.respondRulePackError(w, err)
+		return
+	}
+
+	h.ListPacks(w, r)
+}
+
+// DeletePack handles DELETE /admin/rule-packs/{name}
+func (h *RulePackHandler) DeletePack(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r); !ok {
+		return
+	}
+
+	if err := h.rulePackService.DeletePack(r.PathValue("name")); err != nil {
+		h.respondRulePackError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetMapperRules handles GET /admin/mapper/rules and returns the
+// type-mapping rules in use
+func (h *RulePackHandler) GetMapperRules(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r); !ok {
+		return
+	}
+
+	respondJSON(w, http.StatusOK, h.rulePackService.MapperRules())
+}
+
+// UpdateMapperRules handles PUT /admin/mapper/rules with
+// {"rules": {"fire": {...}}}. Types left out keep their rule. The result is
+// stored as the "edited" pack and made active; with ?dry_run=true it only
+// reports which coffees would change type.
+func (h *RulePackHandler) UpdateMapperRules(w http.ResponseWriter, r *http.Request) {
+	user, ok := requireAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Rules map[string]models.RulePackRule `json:"rules"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	author := user.Name
+	if author == "" {
+		author = "admin"
+	}
+
+	if isDryRun(r) {
+		preview, err := h.rulePackService.PreviewMapperRules(req.Rules, author)
+		if err != nil {
+			h.respondRulePackError(w, err)
+			return
+		}
+		respondJSON(w, http.StatusOK, preview)
+		return
+	}
+
+	rules, err := h.rulePackService.UpdateMapperRules(req.Rules, author)
+	if err != nil {
+		h.respondRulePackError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, rules)
+}
+
+// respondRulePackError maps rule pack errors to HTTP responses
+func (h *RulePackHandler) respondRulePackError(w http.ResponseWriter, err error) {
+	switch {
+	case strings.Contains(err.Error(), "not found"):
+		respondError(w, http.StatusNotFound, "Rule pack not found")
+	case strings.Contains(err.Error(), "invalid rule pack"):
+		respondError(w, http.StatusBadRequest, err.Error())
+	case strings.Contains(err.Error(), "cannot be deleted"):
+		respondError(w, http.StatusConflict, err.Error())
+	default:
+		log.Printf("ERROR: Rule pack request failed: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to process rule pack")
+	}
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"go-coffee-log/service"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// SafariHandler handles HTTP requests for the Safari Zone
+type SafariHandler struct {
+	safariService *service.SafariService
+}
+
+// NewSafariHandler creates a new safari handler
+func NewSafariHandler(safariService *service.SafariService) *SafariHandler {
+	return &SafariHandler{safariService: safariService}
+}
+
+// Attempt handles POST /safari/attempt: it rolls a random uncaught Pokemon
+// in the caller's dex profile and returns its target coffee spec. The next
+// coffee logged in time that meets the spec catches it.
+func (h *SafariHandler) Attempt(w http.ResponseWriter, r *http.Request) {
+	attempt, err := h.safariService.Attempt(callerID(r))
+	if err != nil {
+		h.respondSafariError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, attempt)
+}
+
+// ListAttempts handles GET /safari/attempts with the caller's attempts,
+// newest first
+func (h *SafariHandler) ListAttempts(w http.ResponseWriter, r *http.Request) {
+	attempts, err := h.safariService.List(callerID(r))
+	if err != nil {
+		h.respondSafariError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, attempts)
+}
+
+// GetAttempt handles GET /safari/attempts/{id}
+func (h *SafariHandler) GetAttempt(w http.ResponseWriter, r *http.Request) {
+	attempt, err := h.safariService.Get(callerID(r), r.PathValue("id"))
+	if err != nil {
+		h.respondSafariError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, attempt)
+}
+
+// respondSafariError maps safari service errors to HTTP responses
+func (h *SafariHandler) respondSafariError(w http.ResponseWriter, err error) {
+	message := err.Error()
+	switch {
+	case strings.Contains(message, "safari attempt not found"):
+		respondError(w, http.StatusNotFound, "Safari attempt not found")
+	case strings.Contains(message, "already active"), strings.Contains(message, "no uncaught Pokemon"):
+		respondError(w, http.StatusConflict, message)
+	default:
+		log.Printf("ERROR: Safari request failed: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to process safari attempt")
+	}
+}
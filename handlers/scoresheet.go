@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"encoding/json"
+	"go-coffee-log/models"
+	"go-coffee-log/service"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// ScoresheetHandler handles HTTP requests for competition scoresheets
+type ScoresheetHandler struct {
+	scoresheetService *service.ScoresheetService
+}
+
+// NewScoresheetHandler creates a new scoresheet handler
+func NewScoresheetHandler(scoresheetService *service.ScoresheetService) *ScoresheetHandler {
+	return &ScoresheetHandler{scoresheetService: scoresheetService}
+}
+
+// CreateScoresheet handles POST /coffees/{id}/scoresheets with
+// {"competition": "...", "competitor": "...", "categories": [{"name": "flavor", "weight": 2}], "max_score": 10};
+// categories and max_score are optional
+func (h *ScoresheetHandler) CreateScoresheet(w http.ResponseWriter, r *http.Request) {
+	var sheet models.Scoresheet
+	if err := json.NewDecoder(r.Body).Decode(&sheet); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	created, err := h.scoresheetService.CreateScoresheet(r.PathValue("id"), sheet)
+	if err != nil {
+		h.respondScoresheetError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, created)
+}
+
+// ListCoffeeScoresheets handles GET /coffees/{id}/scoresheets
+func (h *ScoresheetHandler) ListCoffeeScoresheets(w http.ResponseWriter, r *http.Request) {
+	sheets, err := h.scoresheetService.ListScoresheets(r.PathValue("id"), "")
+	if err != nil {
+		h.respondScoresheetError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, sheets)
+}
+
+// ListScoresheets handles GET /scoresheets?competition=
+func (h *ScoresheetHandler) ListScoresheets(w http.ResponseWriter, r *http.Request) {
+	sheets, err := h.scoresheetService.ListScoresheets("", r.URL.Query().Get("competition"))
+	if err != nil {
+		h.respondScoresheetError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, sheets)
+}
+
+// GetScoresheet handles GET /scoresheets/{id}
+func (h *ScoresheetHandler) GetScoresheet(w http.ResponseWriter, r *http.Request) {
+	sheet, err := h.scoresheetService.GetScoresheet(r.PathValue("id"))
+	if err != nil {
+		h.respondScoresheetError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, sheet)
+}
+
+// DeleteScoresheet handles DELETE /scoresheets/{id}
+func (h *ScoresheetHandler) DeleteScoresheet(w http.ResponseWriter, r *http.Request) {
+	if err := h.scoresheetService.DeleteScoresheet(r.PathValue("id")); err != nil {
+		h.respondScoresheetError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ScoreJudge handles PUT /scoresheets/{id}/judges/{judge} with
+// {"scores": {"aroma": 8.5, ...}, "notes": "..."}
+func (h *ScoresheetHandler) ScoreJudge(w http.ResponseWriter, r *http.Request) {
+	var score models.JudgeScore
+	if err := json.NewDecoder(r.Body).Decode(&score); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+	score.Judge = r.PathValue("judge")
+
+	saved, err := h.scoresheetService.ScoreJudge(r.PathValue("id"), score)
+	if err != nil {
+		h.respondScoresheetError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, saved)
+}
+
+// RemoveJudge handles DELETE /scoresheets/{id}/judges/{judge}
+func (h *ScoresheetHandler) RemoveJudge(w http.ResponseWriter, r *http.Request) {
+	if err := h.scoresheetService.RemoveJudge(r.PathValue("id"), r.PathValue("judge")); err != nil {
+		h.respondScoresheetError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetResults handles GET /scoresheets/{id}/results
+func (h *ScoresheetHandler) GetResults(w http.ResponseWriter, r *http.Request) {
+	result, err := h.scoresheetService.Results(r.PathValue("id"))
+	if err != nil {
+		h.respondScoresheetError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, result)
+}
+
+// GetStandings handles GET /scoresheets/standings?competition=
+func (h *ScoresheetHandler) GetStandings(w http.ResponseWriter, r *http.Request) {
+	standings, err := h.scoresheetService.Standings(r.URL.Query().Get("competition"))
+	if err != nil {
+		h.respondScoresheetError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, standings)
+}
+
+// respondScoresheetError maps scoresheet service errors to HTTP responses
+func (h *ScoresheetHandler) respondScoresheetError(w http.ResponseWriter, err error) {
+	message := err.Error()
+	switch {
+	case strings.Contains(message, "scoresheet not found"):
+		respondError(w, http.StatusNotFound, "Scoresheet not found")
+	case strings.Contains(message, "judge score not found"):
+		respondError(w, http.StatusNotFound, "Judge has not scored this sheet")
+	case strings.Contains(message, "coffee not found"):
+		respondError(w, http.StatusNotFound, "Coffee not found")
+	case strings.Contains(message, "failed"):
+		log.Printf("ERROR: Scoresheet request failed: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to process scoresheet")
+	default:
+		respondError(w, http.StatusBadRequest, message)
+	}
+}
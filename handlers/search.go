@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"encoding/json"
+	"go-coffee-log/models"
+	"go-coffee-log/service"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// SearchHandler handles HTTP requests for saved searches
+type SearchHandler struct {
+	searchService *service.SavedSearchService
+}
+
+// NewSearchHandler creates a new search handler
+func NewSearchHandler(searchService *service.SavedSearchService) *SearchHandler {
+	return &SearchHandler{searchService: searchService}
+}
+
+// SaveSearch handles POST /searches with
+// {"name": "...", "query": {...}, "webhook_url": "..."}; webhook_url is optional
+func (h *SearchHandler) SaveSearch(w http.ResponseWriter, r *http.Request) {
+	var search models.SavedSearch
+	if err := json.NewDecoder(r.Body).Decode(&search); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	// Webhooks make the server call arbitrary URLs, so only admins may add them
+	if user, ok := UserFromContext(r.Context()); ok && !user.IsAdmin && search.WebhookURL != "" {
+		respondError(w, http.StatusForbidden, "Admin access required for webhook_url")
+		return
+	}
+
+	if isDryRun(r) {
+		respondPreview(w, func() (service.DryRun, error) {
+			return h.searchService.PreviewSaveSearch(search)
+		}, h.respondSearchError)
+		return
+	}
+
+	saved, err := h.searchService.SaveSearch(search)
+	if err != nil {
+		h.respondSearchError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, saved)
+}
+
+// ListSearches handles GET /searches
+func (h *SearchHandler) ListSearches(w http.ResponseWriter, r *http.Request) {
+	searches, err := h.searchService.ListSearches()
+	if err != nil {
+		h.respondSearchError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, searches)
+}
+
+// GetSearch handles GET /searches/{id}
+func (h *SearchHandler) GetSearch(w http.ResponseWriter, r *http.Request) {
+	search, err := h.searchService.GetSearch(r.PathValue("id"))
+	if err != nil {
+		h.respondSearchError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, search)
+}
+
+// DeleteSearch handles DELETE /searches/{id}
+func (h *SearchHandler) DeleteSearch(w http.ResponseWriter, r *http.Request) {
+	if isDryRun(r) {
+		respondPreview(w, func() (service.DryRun, error) {
+			return h.searchService.PreviewDeleteSearch(r.PathValue("id"))
+		}, h.respondSearchError)
+		return
+	}
+
+	if err := h.searchService.DeleteSearch(r.PathValue("id")); err != nil {
+		h.respondSearchError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetResults handles GET /searches/{id}/results; accepts the same
+// ?favorite= and ?pinned= options as GET /coffees
+func (h *SearchHandler) GetResults(w http.ResponseWriter, r *http.Request) {
+	coffees, err := h.searchService.Results(r.PathValue("id"))
+	if err != nil {
+		h.respondSearchError(w, err)
+		return
+	}
+
+	respondCoffeeList(w, r, coffees)
+}
+
+// respondSearchError maps saved search errors to HTTP responses
+func (h *SearchHandler) respondSearchError(w http.ResponseWriter, err error) {
+	message := err.Error()
+	switch {
+	case strings.Contains(message, "not found"):
+		respondError(w, http.StatusNotFound, "Search not found")
+	case strings.Contains(message, "name") || strings.Contains(message, "query") || strings.Contains(message, "webhook_url"):
+		respondError(w, http.StatusBadRequest, message)
+	default:
+		log.Printf("ERROR: Search request failed: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to process search")
+	}
+}
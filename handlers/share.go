@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"bytes"
+	"go-coffee-log/models"
+	"go-coffee-log/service"
+	"go-coffee-log/web"
+	"log"
+	"net/http"
+)
+
+// ShareHandler serves public share pages and the sitemap
+type ShareHandler struct {
+	coffeeService  *service.CoffeeService
+	pokemonService *service.PokemonService // optional, adds the catch to pages
+	site           *web.Site
+}
+
+// NewShareHandler creates a new share handler
+func NewShareHandler(coffeeService *service.CoffeeService, pokemonService *service.PokemonService, site *web.Site) *ShareHandler {
+	return &ShareHandler{
+		coffeeService:  coffeeService,
+		pokemonService: pokemonService,
+		site:           site,
+	}
+}
+
+// ShareCoffee handles GET /share/coffees/{id}
+func (h *ShareHandler) ShareCoffee(w http.ResponseWriter, r *http.Request) {
+	coffee, err := h.coffeeService.GetCoffee(r.PathValue("id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	var mapping *models.CoffeePokemon
+	if h.pokemonService != nil {
+		mapping, _ = h.pokemonService.GetCoffeePokemon(coffee.ID, "")
+	}
+
+	// Render into a buffer so template errors don't send half a page
+	var page bytes.Buffer
+	if err := h.site.RenderCoffee(&page, h.site.NewCoffeePage(coffee, mapping)); err != nil {
+		log.Printf("ERROR: Failed to render share page for coffee %s: %v", coffee.ID, err)
+		http.Error(w, "Failed to render page", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(page.Bytes())
+}
+
+// Sitemap handles GET /sitemap.xml
+func (h *ShareHandler) Sitemap(w http.ResponseWriter, r *http.Request) {
+	coffees, err := h.coffeeService.ListCoffees()
+	if err != nil {
+		log.Printf("ERROR: Failed to list coffees for sitemap: %v", err)
+		http.Error(w, "Failed to build sitemap", http.StatusInternalServerError)
+		return
+	}
+
+	var sitemap bytes.Buffer
+	if err := h.site.WriteSitemap(&sitemap, coffees); err != nil {
+		log.Printf("ERROR: Failed to write sitemap: %v", err)
+		http.Error(w, "Failed to build sitemap", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(sitemap.Bytes())
+}
@@ -17,9 +17,12 @@ func NewStatisticsHandler(statsService *service.StatisticsService) *StatisticsHa
 	}
 }
 
-// GetStatistics handles GET /statistics
+// GetStatistics handles GET /statistics, limited to favorites with ?favorite=true
 func (h *StatisticsHandler) GetStatistics(w http.ResponseWriter, r *http.Request) {
-	stats, err := h.statsService.CalculateStatistics()
+	filter := service.StatisticsFilter{
+		FavoritesOnly: r.URL.Query().Get("favorite") == "true",
+	}
+	stats, err := h.statsService.CalculateStatistics(filter)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "Failed to calculate statistics")
 		return
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// StorageHealth reports whether the database can be reached
+type StorageHealth interface {
+	Healthy() bool
+	Check(ctx context.Context) error
+}
+
+// StorageGuard turns internal errors caused by a lost database into a
+// clear 503 "storage unavailable" response. When a handler answers 500,
+// the database is pinged; if it is down the original response is replaced.
+func StorageGuard(health StorageHealth, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(&storageGuardWriter{ResponseWriter: w, health: health, ctx: r.Context()}, r)
+	})
+}
+
+// storageGuardWriter intercepts 500 responses
+type storageGuardWriter struct {
+	http.ResponseWriter
+	health   StorageHealth
+	ctx      context.Context
+	replaced bool
+}
+
+// WriteHeader replaces a 500 with 503 when the database is unreachable
+func (g *storageGuardWriter) WriteHeader(code int) {
+	if code != http.StatusInternalServerError || g.health.Check(g.ctx) == nil {
+		g.ResponseWriter.WriteHeader(code)
+		return
+	}
+
+	g.replaced = true
+	header := g.ResponseWriter.Header()
+	header.Del("Content-Length")
+	header.Set("Content-Type", "application/json")
+	header.Set("Retry-After", "30")
+	g.ResponseWriter.WriteHeader(http.StatusServiceUnavailable)
+	json.NewEncoder(g.ResponseWriter).Encode(map[string]string{
+		"error": "Storage is unavailable; the database cannot be reached right now, try again shortly",
+	})
+}
+
+// Write discards the original body of a replaced response
+func (g *storageGuardWriter) Write(p []byte) (int, error) {
+	if g.replaced {
+		return len(p), nil
+	}
+	return g.ResponseWriter.Write(p)
+}
+
+// Flush keeps server-sent event streams working through the guard
+func (g *storageGuardWriter) Flush() {
+	if flusher, ok := g.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (g *storageGuardWriter) Unwrap() http.ResponseWriter {
+	return g.ResponseWriter
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"encoding/json"
+	"go-coffee-log/models"
+	"go-coffee-log/service"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// SubscriptionHandler handles HTTP requests for coffee subscriptions and
+// their eggs
+type SubscriptionHandler struct {
+	subscriptionService *service.SubscriptionService
+}
+
+// NewSubscriptionHandler creates a new subscription handler
+func NewSubscriptionHandler(subscriptionService *service.SubscriptionService) *SubscriptionHandler {
+	return &SubscriptionHandler{subscriptionService: subscriptionService}
+}
+
+// CreateSubscription handles POST /subscriptions with
+// {"roaster": "...", "name": "...", "interval_days": 30, "next_delivery": "2024-06-01"}
+func (h *SubscriptionHandler) CreateSubscription(w http.ResponseWriter, r *http.Request) {
+	var subscription models.Subscription
+	if err := json.NewDecoder(r.Body).Decode(&subscription); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	created, err := h.subscriptionService.CreateSubscription(subscription)
+	if err != nil {
+		h.respondSubscriptionError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, created)
+}
+
+// ListSubscriptions handles GET /subscriptions
+func (h *SubscriptionHandler) ListSubscriptions(w http.ResponseWriter, r *http.Request) {
+	subscriptions, err := h.subscriptionService.ListSubscriptions()
+	if err != nil {
+		h.respondSubscriptionError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, subscriptions)
+}
+
+// GetSubscription handles GET /subscriptions/{id}
+func (h *SubscriptionHandler) GetSubscription(w http.ResponseWriter, r *http.Request) {
+	subscription, err := h.subscriptionService.GetSubscription(r.PathValue("id"))
+	if err != nil {
+		h.respondSubscriptionError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, subscription)
+}
+
+// UpdateSubscription handles PUT /subscriptions/{id}
+func (h *SubscriptionHandler) UpdateSubscription(w http.ResponseWriter, r *http.Request) {
+	var subscription models.Subscription
+	if err := json.NewDecoder(r.Body).Decode(&subscription); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	updated, err := h.subscriptionService.UpdateSubscription(r.PathValue("id"), subscription)
+	if err != nil {
+		h.respondSubscriptionError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, updated)
+}
+
+// DeleteSubscription handles DELETE /subscriptions/{id}
+func (h *SubscriptionHandler) DeleteSubscription(w http.ResponseWriter, r *http.Request) {
+	if err := h.subscriptionService.DeleteSubscription(r.PathValue("id")); err != nil {
+		h.respondSubscriptionError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListEggs handles GET /eggs?status=waiting|hatched
+func (h *SubscriptionHandler) ListEggs(w http.ResponseWriter, r *http.Request) {
+	eggs, err := h.subscriptionService.ListEggs(r.URL.Query().Get("status"))
+	if err != nil {
+		h.respondSubscriptionError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, eggs)
+}
+
+// HatchEgg handles POST /eggs/{id}/hatch with {"coffee_id": "..."}, for
+// bags logged under a different roaster name
+func (h *SubscriptionHandler) HatchEgg(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		CoffeeID string `json:"coffee_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.CoffeeID == "" {
+		respondError(w, http.StatusBadRequest, "coffee_id is required")
+		return
+	}
+	defer r.Body.Close()
+
+	egg, err := h.subscriptionService.HatchEgg(r.PathValue("id"), req.CoffeeID)
+	if err != nil {
+		h.respondSubscriptionError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, egg)
+}
+
+// DeleteEgg handles DELETE /eggs/{id}
+func (h *SubscriptionHandler) DeleteEgg(w http.ResponseWriter, r *http.Request) {
+	if err := h.subscriptionService.DeleteEgg(r.PathValue("id")); err != nil {
+		h.respondSubscriptionError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// respondSubscriptionError maps subscription service errors to HTTP responses
+func (h *SubscriptionHandler) respondSubscriptionError(w http.ResponseWriter, err error) {
+	message := err.Error()
+	switch {
+	case strings.Contains(message, "subscription not found"):
+		respondError(w, http.StatusNotFound, "Subscription not found")
+	case strings.Contains(message, "egg not found"):
+		respondError(w, http.StatusNotFound, "Egg not found")
+	case strings.Contains(message, "coffee not found"):
+		respondError(w, http.StatusNotFound, "Coffee not found")
+	case strings.Contains(message, "already hatched"):
+		respondError(w, http.StatusConflict, message)
+	case strings.Contains(message, "required"), strings.Contains(message, "must be"), strings.Contains(message, "invalid"):
+		respondError(w, http.StatusBadRequest, message)
+	default:
+		log.Printf("ERROR: Subscription request failed: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to process subscription")
+	}
+}
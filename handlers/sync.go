@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"encoding/json"
+	"go-coffee-log/service"
+	"log"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// SyncHandler handles HTTP requests for offline client sync
+type SyncHandler struct {
+	syncService      *service.SyncService
+	sharedCollection atomic.Bool
+}
+
+// NewSyncHandler creates a new sync handler
+func NewSyncHandler(syncService *service.SyncService) *SyncHandler {
+	return &SyncHandler{
+		syncService: syncService,
+	}
+}
+
+// EnableSharedCollection records the authenticated user on every pushed brew
+func (h *SyncHandler) EnableSharedCollection() {
+	h.SetSharedCollection(true)
+}
+
+// SetSharedCollection switches recording who brewed on or off at runtime
+func (h *SyncHandler) SetSharedCollection(enabled bool) {
+	h.sharedCollection.Store(enabled)
+}
+
+// Sync handles POST /sync: push offline changes, pull server changes
+func (h *SyncHandler) Sync(w http.ResponseWriter, r *http.Request) {
+	var req service.SyncRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	h.respondSync(w, r, req)
+}
+
+// Pull handles GET /sync?since=RFC3339 without pushing anything
+func (h *SyncHandler) Pull(w http.ResponseWriter, r *http.Request) {
+	var req service.SyncRequest
+	if since := r.URL.Query().Get("since"); since != "" {
+		parsed, err := time.Parse(time.RFC3339Nano, since)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "since must be an RFC 3339 timestamp")
+			return
+		}
+		req.Since = parsed
+	}
+
+	h.respondSync(w, r, req)
+}
+
+// respondSync runs a sync and writes its result
+func (h *SyncHandler) respondSync(w http.ResponseWriter, r *http.Request, req service.SyncRequest) {
+	brewedBy := ""
+	if user, ok := UserFromContext(r.Context()); ok && h.sharedCollection.Load() {
+		brewedBy = user.ID
+	}
+
+	result, err := h.syncService.Sync(req, brewedBy)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid policy") {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Printf("ERROR: Sync failed: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to sync")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, result)
+}
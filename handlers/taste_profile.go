@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"fmt"
+	"go-coffee-log/service"
+	"log"
+	"net/http"
+)
+
+// TasteProfileHandler handles HTTP requests for the taste profile
+type TasteProfileHandler struct {
+	tasteProfileService *service.TasteProfileService
+}
+
+// NewTasteProfileHandler creates a new taste profile handler
+func NewTasteProfileHandler(tasteProfileService *service.TasteProfileService) *TasteProfileHandler {
+	return &TasteProfileHandler{tasteProfileService: tasteProfileService}
+}
+
+// GetTasteProfile handles GET /profile/taste. With ?download=true the
+// profile comes as a JSON file to share, e.g. with a roaster.
+func (h *TasteProfileHandler) GetTasteProfile(w http.ResponseWriter, r *http.Request) {
+	profile, err := h.tasteProfileService.GetTasteProfile()
+	if err != nil {
+		log.Printf("ERROR: Failed to build taste profile: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to build taste profile")
+		return
+	}
+
+	if r.URL.Query().Get("download") == "true" {
+		filename := fmt.Sprintf("taste-profile-%s.json", profile.GeneratedAt.Format("20060102"))
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	}
+	respondJSON(w, http.StatusOK, profile)
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"go-coffee-log/service"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// TastingHandler handles HTTP requests for reading tasting descriptions
+type TastingHandler struct {
+	llmService *service.LLMService // may be nil when the LLM is disabled
+}
+
+// NewTastingHandler creates a new tasting handler
+func NewTastingHandler(llmService *service.LLMService) *TastingHandler {
+	return &TastingHandler{
+		llmService: llmService,
+	}
+}
+
+// ParseTastingRequest is the body of POST /coffees/parse
+type ParseTastingRequest struct {
+	Description string `json:"description"`
+}
+
+// ParseTasting handles POST /coffees/parse, turning a free-text tasting
+// description into tasting traits and notes
+func (h *TastingHandler) ParseTasting(w http.ResponseWriter, r *http.Request) {
+	var request ParseTastingRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	parsed, err := h.llmService.ParseTasting(r.Context(), request.Description)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "invalid description"):
+			respondError(w, http.StatusBadRequest, err.Error())
+		case strings.Contains(err.Error(), "not available"):
+			respondError(w, http.StatusServiceUnavailable, err.Error())
+		case errors.Is(err, service.ErrLLMBudgetExceeded), errors.Is(err, service.ErrLLMQueueTimeout):
+			respondError(w, http.StatusTooManyRequests, err.Error())
+		default:
+			log.Printf("ERROR: Tasting parse failed: %v", err)
+			respondError(w, http.StatusInternalServerError, "Failed to parse tasting description")
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusOK, parsed)
+}
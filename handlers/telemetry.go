@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"go-coffee-log/service"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// TelemetryHandler counts feature usage and serves the telemetry preview
+type TelemetryHandler struct {
+	telemetry *service.Telemetry
+}
+
+// NewTelemetryHandler creates a new telemetry handler
+func NewTelemetryHandler(telemetry *service.Telemetry) *TelemetryHandler {
+	return &TelemetryHandler{
+		telemetry: telemetry,
+	}
+}
+
+// Middleware records the feature and outcome of every request
+func (h *TelemetryHandler) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+		h.telemetry.Record(telemetryFeature(r), recorder.status)
+	})
+}
+
+// Preview handles GET /telemetry/preview: the exact payload the next
+// report would send, whether or not reporting is enabled
+func (h *TelemetryHandler) Preview(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"enabled":  h.telemetry.Enabled(),
+		"endpoint": h.telemetry.Endpoint(),
+		"payload":  h.telemetry.Preview(),
+	})
+}
+
+// routeWord matches path segments that name a route rather than an entity
+var routeWord = regexp.MustCompile(`^[a-z][a-z.-]{0,23}$`)
+
+// telemetryFeature reduces a request to "METHOD /route" with every
+// identifier-like segment replaced by {id}, so no user data leaves the server
+func telemetryFeature(r *http.Request) string {
+	path := strings.Trim(r.URL.Path, "/")
+	if strings.HasPrefix(path, "static/") {
+		return r.Method + " /static"
+	}
+
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if !routeWord.MatchString(segment) {
+			segments[i] = "{id}"
+		}
+	}
+	return r.Method + " /" + strings.Join(segments, "/")
+}
+
+// statusRecorder captures the response status while passing streaming
+// support through
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader records the status code
+func (s *statusRecorder) WriteHeader(code int) {
+	s.status = code
+	s.ResponseWriter.WriteHeader(code)
+}
+
+// Flush keeps server-sent event streams working through the recorder
+func (s *statusRecorder) Flush() {
+	if flusher, ok := s.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
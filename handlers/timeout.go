@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// timeoutMessage is the body sent when a request runs out of time
+const timeoutMessage = `{"error":"Request timed out"}`
+
+// RouteTimeouts bounds how long a request may run: short for ordinary CRUD
+// routes, long for routes that wait on the LLM or move bulk data. A zero
+// duration disables that limit.
+type RouteTimeouts struct {
+	CRUD time.Duration
+	LLM  time.Duration
+}
+
+// Middleware applies the timeout for each request's route. Buffered routes
+// get http.TimeoutHandler (503 once the deadline passes); streaming and
+// bulk routes only get a context deadline so their output is not held back.
+// The event stream and the live WebSocket are long-lived by design and
+// are left alone, as are requests passed on to a workshop server, which
+// applies these limits itself once the /workshops/{id} prefix is gone.
+func (t RouteTimeouts) Middleware(next http.Handler) http.Handler {
+	crud := next
+	if t.CRUD > 0 {
+		crud = http.TimeoutHandler(next, t.CRUD, timeoutMessage)
+	}
+	llm := next
+	if t.LLM > 0 {
+		llm = http.TimeoutHandler(next, t.LLM, timeoutMessage)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/events" || r.URL.Path == "/ws" || strings.HasPrefix(r.URL.Path, "/workshops/"):
+			next.ServeHTTP(w, r)
+		case isStreamingRequest(r) || isBulkRoute(r):
+			if t.LLM <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			ctx, cancel := context.WithTimeout(r.Context(), t.LLM)
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		case isLLMRoute(r):
+			llm.ServeHTTP(w, r)
+		default:
+			crud.ServeHTTP(w, r)
+		}
+	})
+}
+
+// isLLMRoute reports whether a request may wait on the LLM
+func isLLMRoute(r *http.Request) bool {
+	if r.Method != http.MethodPost {
+		return false
+	}
+	path := strings.Trim(r.URL.Path, "/")
+	parts := strings.Split(path, "/")
+	switch {
+	case path == "chat", path == "coffees/parse", path == "coffees/from-label":
+		return true
+	case len(parts) == 3 && parts[0] == "coffees" && parts[2] == "diagnose":
+		return true
+	case len(parts) == 2 && parts[0] == "pokemon":
+		return true // generate mapping
+	case len(parts) == 3 && parts[0] == "pokemon" && parts[2] == "description":
+		return true
+	case len(parts) == 3 && parts[0] == "pokemon" && parts[2] == "reroll":
+		return true
+	}
+	return false
+}
+
+// isStreamingRequest reports whether the response is streamed as
+// server-sent events
+func isStreamingRequest(r *http.Request) bool {
+	return r.URL.Query().Get("stream") == "true" ||
+		strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+}
+
+// isBulkRoute reports whether a request moves a whole collection at once,
+// including the Pokemon reference data fetched from PokeAPI
+func isBulkRoute(r *http.Request) bool {
+	switch r.URL.Path {
+	case "/users/me/export", "/export", "/admin/import-instance", "/admin/replication/repair", "/sync",
+		"/admin/pokemon-sync", "/admin/pokemon-sync/apply":
+		return true
+	}
+	return false
+}
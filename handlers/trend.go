@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"go-coffee-log/service"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// TrendHandler handles HTTP requests for the precomputed daily statistics
+type TrendHandler struct {
+	dailyStats *service.DailyStatsService
+}
+
+// NewTrendHandler creates a new trend handler
+func NewTrendHandler(dailyStats *service.DailyStatsService) *TrendHandler {
+	return &TrendHandler{dailyStats: dailyStats}
+}
+
+// GetTrends handles GET /statistics/trends?dimension=origin&from=YYYY-MM-DD&to=YYYY-MM-DD
+func (h *TrendHandler) GetTrends(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	var from, to time.Time
+	for _, param := range []struct {
+		name   string
+		target *time.Time
+	}{{"from", &from}, {"to", &to}} {
+		value := query.Get(param.name)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, param.name+" must be a date (YYYY-MM-DD)")
+			return
+		}
+		*param.target = parsed
+	}
+
+	trend, err := h.dailyStats.GetTrend(query.Get("dimension"), from, to)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid") {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Printf("ERROR: Failed to load trends: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to load trends")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, trend)
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"encoding/json"
+	"go-coffee-log/service"
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"path"
+	"strings"
+	"time"
+)
+
+// WorkshopHandler handles HTTP requests for ephemeral workshops and passes
+// participants' requests through to the workshop servers
+type WorkshopHandler struct {
+	workshopService *service.WorkshopService
+}
+
+// NewWorkshopHandler creates a new workshop handler
+func NewWorkshopHandler(workshopService *service.WorkshopService) *WorkshopHandler {
+	return &WorkshopHandler{workshopService: workshopService}
+}
+
+// CreateWorkshop handles POST /admin/workshops with
+// {"name": "Saturday cupping", "ttl_minutes": 120}; ttl_minutes defaults to
+// -workshop-ttl
+func (h *WorkshopHandler) CreateWorkshop(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r); !ok {
+		return
+	}
+
+	var req struct {
+		Name       string `json:"name"`
+		TTLMinutes int    `json:"ttl_minutes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	workshop, err := h.workshopService.CreateWorkshop(r.Context(), req.Name, time.Duration(req.TTLMinutes)*time.Minute)
+	if err != nil {
+		h.respondWorkshopError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, workshop)
+}
+
+// ListWorkshops handles GET /admin/workshops
+func (h *WorkshopHandler) ListWorkshops(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r); !ok {
+		return
+	}
+
+	respondJSON(w, http.StatusOK, h.workshopService.ListWorkshops())
+}
+
+// GetWorkshop handles GET /admin/workshops/{id}
+func (h *WorkshopHandler) GetWorkshop(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r); !ok {
+		return
+	}
+
+	workshop, err := h.workshopService.GetWorkshop(r.PathValue("id"))
+	if err != nil {
+		h.respondWorkshopError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, workshop)
+}
+
+// DeleteWorkshop handles DELETE /admin/workshops/{id}, ending a workshop
+// before it expires
+func (h *WorkshopHandler) DeleteWorkshop(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r); !ok {
+		return
+	}
+
+	if err := h.workshopService.DeleteWorkshop(r.PathValue("id")); err != nil {
+		h.respondWorkshopError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Proxy handles /workshops/{id}/..., passing the request on to the
+// workshop's server with the prefix removed. The ID is the key: no token
+// is needed, and the caller's token is not passed on. The workshop server
+// has no admin, so its admin routes are refused rather than left open.
+func (h *WorkshopHandler) Proxy(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if isAdminPath(r.PathValue("path")) {
+		respondError(w, http.StatusForbidden, "Admin routes are not available in a workshop")
+		return
+	}
+	target, err := h.workshopService.Target(id)
+	if err != nil {
+		h.respondWorkshopError(w, err)
+		return
+	}
+
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(target)
+			pr.Out.URL.Path = "/" + r.PathValue("path")
+			pr.Out.URL.RawPath = ""
+			pr.Out.Header.Del("Authorization")
+			if query := pr.Out.URL.Query(); query.Has("token") {
+				query.Del("token")
+				pr.Out.URL.RawQuery = query.Encode()
+			}
+			pr.SetXForwarded()
+		},
+		FlushInterval: -1, // stream live updates as they come
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			log.Printf("ERROR: Workshop %s did not answer: %v", id, err)
+			respondError(w, http.StatusBadGateway, "Workshop server is not answering")
+		},
+	}
+	proxy.ServeHTTP(w, r)
+}
+
+// isAdminPath reports whether a path relative to a workshop server is one
+// of its admin routes
+func isAdminPath(relative string) bool {
+	cleaned := strings.TrimPrefix(path.Clean("/"+relative), "/")
+	return cleaned == "admin" || strings.HasPrefix(cleaned, "admin/")
+}
+
+// respondWorkshopError maps workshop service errors to HTTP responses
+func (h *WorkshopHandler) respondWorkshopError(w http.ResponseWriter, err error) {
+	message := err.Error()
+	switch {
+	case strings.Contains(message, "workshop not found"):
+		respondError(w, http.StatusNotFound, "Workshop not found")
+	case strings.Contains(message, "invalid"):
+		respondError(w, http.StatusBadRequest, message)
+	case strings.Contains(message, "limit reached"):
+		respondError(w, http.StatusTooManyRequests, message)
+	default:
+		log.Printf("ERROR: Workshop request failed: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to process workshop")
+	}
+}
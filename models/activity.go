@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// Activity is a published event kept in the instance-wide activity log
+type Activity struct {
+	ID        string                 `json:"id"`
+	Type      string                 `json:"type"` // e.g. "comment.added"
+	Message   string                 `json:"message"`
+	CoffeeID  string                 `json:"coffee_id,omitempty"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+	CreatedAt time.Time              `json:"created_at"`
+}
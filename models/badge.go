@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// Badge is a gym badge a user earned by brewing enough coffees of one
+// Pokemon type on the same brewer
+type Badge struct {
+	UserID     string    `json:"-"`
+	Badge      string    `json:"badge"` // e.g. "rainbow"
+	Name       string    `json:"name"`  // e.g. "Rainbow Badge"
+	Type       string    `json:"type"`  // the Pokemon type it is awarded for
+	BrewerID   string    `json:"brewer_id"`
+	BrewerName string    `json:"brewer_name"`
+	Catches    int       `json:"catches"` // catches of the type on the brewer when awarded
+	AwardedAt  time.Time `json:"awarded_at"`
+}
@@ -0,0 +1,132 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Brew represents a single brewing attempt of a coffee, used to dial in the
+// grind. Higher grind settings are assumed to be coarser.
+type Brew struct {
+	ID           string       `json:"id"`
+	CoffeeID     string       `json:"coffee_id"`
+	GrindSetting float64      `json:"grind_setting"`
+	BrewTime     DrawDownTime `json:"brew_time"`
+	Outcome      string       `json:"outcome"` // "under", "balanced", "over"
+	Rating       int          `json:"rating"`
+	Notes        string       `json:"notes"`
+	Temperature  *Temperature `json:"temperature,omitempty"`
+	BrewedBy     string       `json:"brewed_by,omitempty"` // user ID, recorded in shared-collection mode
+
+	// Optional refractometer data; extraction yield is computed from
+	// dose, TDS and beverage (or water) weight when not given
+	DoseGrams       float64 `json:"dose_grams,omitempty"`
+	WaterGrams      float64 `json:"water_grams,omitempty"`
+	BeverageGrams   float64 `json:"beverage_grams,omitempty"`
+	TDS             float64 `json:"tds,omitempty"`              // percent, e.g. 1.35
+	ExtractionYield float64 `json:"extraction_yield,omitempty"` // percent, e.g. 20.5
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// grounds retain roughly twice their dry weight in water
+const groundsRetentionRatio = 2.0
+
+// ComputeExtractionYield fills in the extraction yield from dose, TDS and the
+// beverage weight. Without a beverage weight it is estimated from the water
+// weight minus what the grounds retain. An explicit yield is kept as-is.
+func (b *Brew) ComputeExtractionYield() {
+	if b.ExtractionYield > 0 || b.TDS <= 0 || b.DoseGrams <= 0 {
+		return
+	}
+
+	beverage := b.BeverageGrams
+	if beverage <= 0 && b.WaterGrams > 0 {
+		beverage = b.WaterGrams - b.DoseGrams*groundsRetentionRatio
+	}
+	if beverage <= 0 {
+		return
+	}
+
+	b.ExtractionYield = math.Round(beverage*b.TDS/b.DoseGrams*100) / 100
+}
+
+// DialInStatus summarizes the dial-in progress of a coffee
+type DialInStatus struct {
+	Attempts         int     `json:"attempts"`
+	LastSetting      float64 `json:"last_setting,omitempty"`
+	SuggestedSetting float64 `json:"suggested_setting,omitempty"`
+	Direction        string  `json:"direction"` // "finer", "coarser", "hold", "none"
+	DialedIn         bool    `json:"dialed_in"`
+	DialedInSetting  float64 `json:"dialed_in_setting,omitempty"`
+	Reason           string  `json:"reason"`
+}
+
+// brewOutcomeAliases maps tasting words onto the three extraction outcomes
+var brewOutcomeAliases = map[string]string{
+	"under":           "under",
+	"under-extracted": "under",
+	"sour":            "under",
+	"balanced":        "balanced",
+	"good":            "balanced",
+	"sweet":           "balanced",
+	"over":            "over",
+	"over-extracted":  "over",
+	"bitter":          "over",
+}
+
+// ValidateOutcome normalizes the outcome to under, balanced or over
+func (b *Brew) ValidateOutcome() error {
+	outcome, ok := brewOutcomeAliases[strings.ToLower(strings.TrimSpace(b.Outcome))]
+	if !ok {
+		return fmt.Errorf("invalid outcome: %s (use under, balanced or over)", b.Outcome)
+	}
+	b.Outcome = outcome
+	return nil
+}
+
+// Validate checks if the Brew data is valid
+func (b *Brew) Validate() error {
+	if b.CoffeeID == "" {
+		return fmt.Errorf("coffee id is required")
+	}
+
+	if b.GrindSetting < 0 {
+		return fmt.Errorf("grind setting cannot be negative")
+	}
+
+	if err := b.ValidateOutcome(); err != nil {
+		return err
+	}
+
+	if b.Rating < 0 || b.Rating > 10 {
+		return fmt.Errorf("ratings must be out of 10")
+	}
+
+	if b.BrewTime.Minutes < 0 || b.BrewTime.Seconds < 0 || b.BrewTime.Seconds >= 60 {
+		return fmt.Errorf("invalid brew time")
+	}
+
+	if b.Temperature != nil {
+		if err := b.Temperature.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if b.DoseGrams < 0 || b.WaterGrams < 0 || b.BeverageGrams < 0 {
+		return fmt.Errorf("weights cannot be negative")
+	}
+
+	// TDS above 25% is not a drinkable brew; it is almost always a unit mistake
+	if b.TDS < 0 || b.TDS > 25 {
+		return fmt.Errorf("tds must be a percentage between 0 and 25")
+	}
+
+	if b.ExtractionYield < 0 || b.ExtractionYield > 40 {
+		return fmt.Errorf("extraction yield must be a percentage between 0 and 40")
+	}
+
+	return nil
+}
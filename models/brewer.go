@@ -7,9 +7,10 @@ import (
 
 // Recipe represents a standalone brewing recipe
 type Recipe struct {
-	ID    string   `json:"id"`
-	Name  string   `json:"name"`
-	Steps []string `json:"steps"`
+	ID          string       `json:"id"`
+	Name        string       `json:"name"`
+	Steps       []string     `json:"steps"`
+	Temperature *Temperature `json:"temperature,omitempty"` // optional structured brew temperature
 }
 
 // Brewer represents a coffee brewer with associated pokeball sprite
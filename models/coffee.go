@@ -42,6 +42,17 @@ type Coffee struct {
 	Recipe []string `json:"recipe"`
 	Dripper string `json:"dripper"`
 	EndTime DrawDownTime `json:"end_time"`
+	RoastDate *Date `json:"roast_date,omitempty"`
+	Favorite bool `json:"favorite"` // pinned to the top of lists; set via POST /coffees/{id}/favorite
+	BrewerID string `json:"brewer_id,omitempty"` // the brewer it was brewed with; see GET /statistics pokeballs
+	Altitude string `json:"altitude,omitempty"` // growing altitude as the roaster gives it, e.g. "1,800-2,000 masl"
+	Lot string `json:"lot,omitempty"` // lot, farm or producer details
+	Price *Money `json:"price,omitempty"` // what the bag cost; see GET /statistics/value
+	BagGrams int `json:"bag_grams,omitempty"` // size of the bag the price is for
+	Lineage string `json:"lineage,omitempty"` // ID of the first bag of the same coffee; set by POST /coffees/{id}/clone
+	AddedBy string `json:"added_by,omitempty"` // user ID of whoever added it, on servers with authentication; counts against their quota
+	Tags []string `json:"tags,omitempty"` // free-form labels, lowercased, e.g. "decaf" or "gift"
+	DialIn *DialInStatus `json:"dial_in,omitempty"` // computed from brews, not stored
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -134,10 +145,65 @@ func (c *Coffee) Validate() error {
 		return fmt.Errorf("invalid draw down time")
 	}
 	
+	// Validate roast date if provided
+	if c.RoastDate != nil && c.RoastDate.After(time.Now()) {
+		return fmt.Errorf("roast date cannot be in the future")
+	}
+	
 	// Validate tasting traits - allow default values
 	if err := c.TastingTraits.Validate(); err != nil {
 		return err
 	}
 	
+	// Validate price if provided
+	if c.Price != nil {
+		if err := c.Price.Validate(); err != nil {
+			return fmt.Errorf("invalid price: %w", err)
+		}
+	}
+	if c.BagGrams < 0 {
+		return fmt.Errorf("bag_grams cannot be negative")
+	}
+	
+	tags, err := NormalizeTags(c.Tags)
+	if err != nil {
+		return err
+	}
+	c.Tags = tags
+	
 	return nil
+}
+
+// Tag limits
+const (
+	MaxCoffeeTags = 20
+	MaxTagLength  = 50
+)
+
+// NormalizeTags trims and lowercases tags, dropping blanks and duplicates,
+// and checks them against the tag limits
+func NormalizeTags(tags []string) ([]string, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if len(tag) > MaxTagLength {
+			return nil, fmt.Errorf("invalid tag %q: tags are at most %d characters", tag, MaxTagLength)
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	if len(normalized) > MaxCoffeeTags {
+		return nil, fmt.Errorf("invalid tags: a coffee has at most %d tags", MaxCoffeeTags)
+	}
+	if len(normalized) == 0 {
+		return nil, nil
+	}
+	return normalized, nil
 }
\ No newline at end of file
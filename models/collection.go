@@ -0,0 +1,33 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// maxCollectionNameLength caps collection names
+const maxCollectionNameLength = 100
+
+// Collection is a user-defined group of coffees, such as "Competition
+// candidates" or "Office beans". A coffee can be in any number of collections.
+type Collection struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	CoffeeIDs   []string  `json:"coffee_ids"` // in the order they were added
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Validate checks that the collection has a name of reasonable length
+func (c *Collection) Validate() error {
+	c.Name = strings.TrimSpace(c.Name)
+	if c.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(c.Name) > maxCollectionNameLength {
+		return errors.New("name must be 100 characters or fewer")
+	}
+	return nil
+}
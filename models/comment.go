@@ -0,0 +1,39 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// maxCommentLength caps comment bodies
+const maxCommentLength = 2000
+
+// Comment represents a comment on a coffee. Replies reference their parent,
+// forming a thread.
+type Comment struct {
+	ID         string    `json:"id"`
+	CoffeeID   string    `json:"coffee_id"`
+	ParentID   string    `json:"parent_id,omitempty"`
+	AuthorID   string    `json:"author_id,omitempty"`
+	AuthorName string    `json:"author_name"`
+	Body       string    `json:"body"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	Replies    []Comment `json:"replies,omitempty"`
+}
+
+// Validate checks that the comment has a body of reasonable length
+func (c *Comment) Validate() error {
+	c.Body = strings.TrimSpace(c.Body)
+	if c.Body == "" {
+		return errors.New("body is required")
+	}
+	if len(c.Body) > maxCommentLength {
+		return errors.New("body must be 2000 characters or fewer")
+	}
+	if c.AuthorName == "" {
+		return errors.New("author name is required")
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// Dimensions of the daily statistics
+const (
+	DimensionAll              = "all"
+	DimensionOrigin           = "origin"
+	DimensionRoaster          = "roaster"
+	DimensionRoastLevel       = "roast_level"
+	DimensionProcessingMethod = "processing_method"
+)
+
+// StatDimensions lists every dimension the daily statistics are split by
+var StatDimensions = []string{
+	DimensionAll,
+	DimensionOrigin,
+	DimensionRoaster,
+	DimensionRoastLevel,
+	DimensionProcessingMethod,
+}
+
+// DailyStat is a precomputed aggregate of one day for one value of a
+// dimension, e.g. all coffees from Ethiopia logged on 2024-05-01. Brews are
+// counted under the dimensions of the coffee they brewed.
+type DailyStat struct {
+	Day               Date      `json:"day"`
+	Dimension         string    `json:"dimension"`
+	Value             string    `json:"value"` // "" for the "all" dimension
+	Coffees           int       `json:"coffees"`
+	AverageRating     float64   `json:"average_rating"`
+	Brews             int       `json:"brews"`
+	AverageBrewRating float64   `json:"average_brew_rating"`
+	ComputedAt        time.Time `json:"-"`
+}
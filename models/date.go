@@ -0,0 +1,51 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// dateLayout is the calendar date format used in the API
+const dateLayout = "2006-01-02"
+
+// Date represents a calendar date such as a roast date. It is encoded as
+// "YYYY-MM-DD" and also accepts full RFC 3339 timestamps.
+type Date struct {
+	time.Time
+}
+
+// NewDate truncates t to its calendar date
+func NewDate(t time.Time) Date {
+	year, month, day := t.Date()
+	return Date{time.Date(year, month, day, 0, 0, 0, 0, time.UTC)}
+}
+
+// MarshalJSON encodes the date as "YYYY-MM-DD"
+func (d Date) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.Format(dateLayout))
+}
+
+// UnmarshalJSON decodes "YYYY-MM-DD" or an RFC 3339 timestamp
+func (d *Date) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("date must be a string: %w", err)
+	}
+
+	if parsed, err := time.Parse(dateLayout, value); err == nil {
+		*d = NewDate(parsed)
+		return nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return fmt.Errorf("invalid date %q, expected YYYY-MM-DD", value)
+	}
+	*d = NewDate(parsed)
+	return nil
+}
+
+// DaysSince returns the number of whole days between the date and now
+func (d Date) DaysSince(now time.Time) int {
+	return int(NewDate(now).Sub(d.Time).Hours() / 24)
+}
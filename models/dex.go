@@ -0,0 +1,69 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultDex is the dex profile catches made before profiles existed
+// belong to
+const DefaultDex = "kanto"
+
+// MaxPokemonID is the highest National Dex number a profile may include
+const MaxPokemonID = 1025
+
+// dexIDPattern keeps dex profile IDs short, lower-case slugs like "johto"
+var dexIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,35}$`)
+
+// DexProfile is a named Pokedex, such as "Kanto classic 151". Each profile
+// has its own completion tracking, and a Pokemon can be caught once per
+// profile.
+type DexProfile struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	PokemonIDs  []int      `json:"pokemon_ids"` // National Dex numbers, in dex order
+	Builtin     bool       `json:"builtin"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"` // set on custom profiles
+}
+
+// Validate checks if the DexProfile data is valid
+func (p *DexProfile) Validate() error {
+	p.ID = strings.ToLower(strings.TrimSpace(p.ID))
+	if !dexIDPattern.MatchString(p.ID) {
+		return fmt.Errorf("dex id must be 1-36 lower-case letters, digits or dashes")
+	}
+	p.Name = strings.TrimSpace(p.Name)
+	if p.Name == "" || len(p.Name) > 100 {
+		return fmt.Errorf("name must be 1-100 characters")
+	}
+	if len(p.Description) > 1000 {
+		return fmt.Errorf("description must be at most 1000 characters")
+	}
+	if len(p.PokemonIDs) == 0 {
+		return fmt.Errorf("pokemon_ids cannot be empty")
+	}
+	seen := make(map[int]bool, len(p.PokemonIDs))
+	for _, id := range p.PokemonIDs {
+		if id < 1 || id > MaxPokemonID {
+			return fmt.Errorf("pokemon_ids must be between 1 and %d", MaxPokemonID)
+		}
+		if seen[id] {
+			return fmt.Errorf("pokemon_ids lists %d more than once", id)
+		}
+		seen[id] = true
+	}
+	return nil
+}
+
+// Contains reports whether a Pokemon belongs to the profile
+func (p *DexProfile) Contains(pokemonID int) bool {
+	for _, id := range p.PokemonIDs {
+		if id == pokemonID {
+			return true
+		}
+	}
+	return false
+}
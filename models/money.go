@@ -0,0 +1,47 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Money is an amount in a currency, e.g. what a bag of beans cost
+type Money struct {
+	Amount   float64 `json:"amount"`
+	Currency string  `json:"currency"` // ISO 4217 code, e.g. "EUR"
+}
+
+// NormalizeCurrency upper-cases an ISO 4217 currency code ("eur" -> "EUR")
+// and checks it is three letters
+func NormalizeCurrency(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) != 3 {
+		return "", fmt.Errorf("invalid currency: %q (use an ISO 4217 code such as USD or EUR)", code)
+	}
+	for _, letter := range code {
+		if letter < 'A' || letter > 'Z' {
+			return "", fmt.Errorf("invalid currency: %q (use an ISO 4217 code such as USD or EUR)", code)
+		}
+	}
+	return code, nil
+}
+
+// Validate normalizes the currency and checks the amount
+func (m *Money) Validate() error {
+	currency, err := NormalizeCurrency(m.Currency)
+	if err != nil {
+		return err
+	}
+	m.Currency = currency
+
+	if m.Amount < 0 || math.IsNaN(m.Amount) || math.IsInf(m.Amount, 0) {
+		return fmt.Errorf("amount cannot be negative")
+	}
+	return nil
+}
+
+// String renders the money like "18.50 EUR"
+func (m Money) String() string {
+	return fmt.Sprintf("%.2f %s", m.Amount, m.Currency)
+}
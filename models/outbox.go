@@ -0,0 +1,29 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Outbox message statuses
+const (
+	OutboxPending   = "pending"
+	OutboxDelivered = "delivered"
+	OutboxDead      = "dead" // gave up after the maximum number of attempts
+)
+
+// OutboxMessage is an event queued for delivery to an external sink
+// (webhook, Discord, email). Messages are stored before the first attempt,
+// so a delivery interrupted by a restart is retried rather than lost.
+type OutboxMessage struct {
+	ID            string          `json:"id"`
+	Sink          string          `json:"sink"`             // sink name, e.g. "webhook"
+	Target        string          `json:"target,omitempty"` // per-message webhook URL, e.g. a saved search's
+	Payload       json.RawMessage `json:"payload"`          // the event, as JSON
+	Status        string          `json:"status"`
+	Attempts      int             `json:"attempts"`
+	NextAttemptAt time.Time       `json:"next_attempt_at"`
+	LastError     string          `json:"last_error,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+	DeliveredAt   *time.Time      `json:"delivered_at,omitempty"`
+}
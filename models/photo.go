@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// CoffeePhoto links an uploaded photo to a coffee. The image itself lives
+// in media storage under its content hash, so the same photo attached to
+// several coffees is stored once.
+type CoffeePhoto struct {
+	CoffeeID    string    `json:"coffee_id"`
+	Hash        string    `json:"hash"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	UploadedBy  string    `json:"uploaded_by,omitempty"` // user ID, on servers with authentication; counts against their quota
+	CreatedAt   time.Time `json:"created_at"`
+	URL         string    `json:"url"` // resizable via ?w= and ?h=
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// Planned brew limits
+const (
+	DefaultPlanReminderMinutes = 60
+	maxPlanReminderMinutes     = 7 * 24 * 60
+	maxPlanNotesLength         = 500
+)
+
+// PlannedBrew is a brew scheduled ahead of time, such as "Saturday: Kenya
+// on the Chemex". Logging it turns it into a Brew and records the brew's ID.
+type PlannedBrew struct {
+	ID              string     `json:"id"`
+	CoffeeID        string     `json:"coffee_id"`
+	CoffeeName      string     `json:"coffee_name,omitempty"` // filled in when listed, not stored
+	Dripper         string     `json:"dripper"`
+	Notes           string     `json:"notes"`
+	ScheduledFor    time.Time  `json:"scheduled_for"`
+	ReminderMinutes int        `json:"reminder_minutes"` // how long before scheduled_for to remind; 0 uses the default
+	RemindedAt      *time.Time `json:"reminded_at,omitempty"`
+	BrewID          string     `json:"brew_id,omitempty"` // set once the plan is brewed
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// Brewed reports whether the plan has been turned into a logged brew
+func (p *PlannedBrew) Brewed() bool {
+	return p.BrewID != ""
+}
+
+// ReminderDue reports whether the plan's reminder should go out at now
+func (p *PlannedBrew) ReminderDue(now time.Time) bool {
+	if p.Brewed() || p.RemindedAt != nil {
+		return false
+	}
+	return !now.Before(p.ScheduledFor.Add(-time.Duration(p.ReminderMinutes) * time.Minute))
+}
+
+// Validate checks the plan and applies the default reminder lead time
+func (p *PlannedBrew) Validate() error {
+	if p.CoffeeID == "" {
+		return errors.New("coffee_id is required")
+	}
+	if p.ScheduledFor.IsZero() {
+		return errors.New("scheduled_for is required")
+	}
+	p.Dripper = strings.TrimSpace(p.Dripper)
+	p.Notes = strings.TrimSpace(p.Notes)
+	if len(p.Notes) > maxPlanNotesLength {
+		return errors.New("notes must be 500 characters or fewer")
+	}
+	if p.ReminderMinutes < 0 || p.ReminderMinutes > maxPlanReminderMinutes {
+		return errors.New("reminder_minutes must be between 0 and 10080")
+	}
+	if p.ReminderMinutes == 0 {
+		p.ReminderMinutes = DefaultPlanReminderMinutes
+	}
+	return nil
+}
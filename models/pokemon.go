@@ -25,6 +25,7 @@ type Stats struct {
 type CoffeePokemon struct {
 	ID                string          `json:"id"`
 	CoffeeID          string          `json:"coffee_id"`
+	Dex               string          `json:"dex"` // the dex profile the catch counts toward
 	PokemonID         int             `json:"pokemon_id"`
 	PokemonName       string          `json:"pokemon_name"`
 	Nickname          string          `json:"nickname"`
@@ -32,9 +33,31 @@ type CoffeePokemon struct {
 	MappingConfidence float64         `json:"mapping_confidence"`
 	LLMDescription    string          `json:"llm_description"`
 	TraitMapping      []TraitMapping  `json:"trait_mapping"`
+	Reactions         map[string]int  `json:"reactions,omitempty"` // emoji -> count
+	Provenance        *MappingProvenance `json:"provenance,omitempty"` // nil for catches made before it was recorded
 	CreatedAt         time.Time       `json:"created_at"`
 }
 
+// Mapping methods: how a catch's Pokemon was picked
+const (
+	MappingMethodRules     = "rules"     // the rule-based mapper's best type match
+	MappingMethodLLM       = "llm"       // an LLM picked from the candidates
+	MappingMethodConsensus = "consensus" // several LLMs voted
+	MappingMethodSafari    = "safari"    // lured out by a Safari Zone attempt
+)
+
+// MappingProvenance records how a catch was made, so it stays explainable
+// after the configuration changes
+type MappingProvenance struct {
+	Method          string   `json:"method"`
+	LLMOptOut       bool     `json:"llm_opt_out,omitempty"`     // the request asked to skip the LLM
+	Models          []string `json:"models,omitempty"`          // the LLMs that picked, for llm and consensus
+	PromptVersion   string   `json:"prompt_version,omitempty"`  // the mapping prompt they were given
+	RulePack        string   `json:"rule_pack"`                 // the type rules in use, as name@version
+	MapperOverrides bool     `json:"mapper_overrides,omitempty"` // config overrides applied on top of the rule pack
+	Candidates      []string `json:"candidates,omitempty"`      // the Pokemon the pick was made from
+}
+
 // TraitMapping represents how a coffee trait maps to Pokemon characteristics
 type TraitMapping struct {
 	Trait      string `json:"trait"`
@@ -57,6 +80,7 @@ type LLMMappingResponse struct {
 	Confidence      float64       `json:"confidence"`
 	Description     string        `json:"description"`
 	TraitMapping    []TraitMapping `json:"trait_mapping"`
+	Model           string         `json:"-"` // model that answered, set by the service
 }
 
 // PokemonMappingRequest represents a request to generate Pokemon for a coffee
@@ -69,4 +93,21 @@ type PokemonMappingResponse struct {
 	Success bool           `json:"success"`
 	Data    CoffeePokemon  `json:"data,omitempty"`
 	Error   string         `json:"error,omitempty"`
-}
\ No newline at end of file
+}
+// MappingProposal represents a single mapper's vote in consensus mode
+type MappingProposal struct {
+	Source      string  `json:"source"` // "rule-based" or "llm:<model>"
+	PokemonID   int     `json:"pokemon_id"`
+	PokemonName string  `json:"pokemon_name"`
+	Confidence  float64 `json:"confidence"`
+}
+
+// MappingDisagreement records a consensus round where the mappers did not agree
+type MappingDisagreement struct {
+	ID              string            `json:"id"`
+	CoffeeID        string            `json:"coffee_id"`
+	SelectedPokemon string            `json:"selected_pokemon"`
+	Agreement       float64           `json:"agreement"` // share of total weight behind the winner
+	Proposals       []MappingProposal `json:"proposals"`
+	CreatedAt       time.Time         `json:"created_at"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// ReactionEmoji lists the reactions that can be left on a catch
+var ReactionEmoji = []string{"☕", "🔥", "❤️", "😋", "🤯", "🤔", "🤢", "🍌"}
+
+// Reaction represents one user's emoji reaction on a Pokedex entry
+type Reaction struct {
+	CoffeeID  string    `json:"coffee_id"`
+	UserID    string    `json:"user_id,omitempty"`
+	Emoji     string    `json:"emoji"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ValidateEmoji checks that the emoji is one of the supported reactions
+func ValidateEmoji(emoji string) error {
+	for _, allowed := range ReactionEmoji {
+		if emoji == allowed {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported reaction %q: must be one of %v", emoji, ReactionEmoji)
+}
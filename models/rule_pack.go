@@ -0,0 +1,109 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// rulePackNamePattern keeps pack names short, lower-case slugs like
+// "nordic-light-roast", so they can be used in URLs
+var rulePackNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,49}$`)
+
+// BuiltinRulePack names the type-mapping rules the server ships with
+const BuiltinRulePack = "builtin"
+
+// EditedRulePack names the pack rules edited through PUT
+// /admin/mapper/rules are stored as
+const EditedRulePack = "edited"
+
+// RulePack is a shareable set of the rules that map coffees to Pokemon
+// types, so communities can trade mapping philosophies
+type RulePack struct {
+	Name        string                  `json:"name"`
+	Version     string                  `json:"version"`
+	Author      string                  `json:"author"`
+	Description string                  `json:"description,omitempty"`
+	Rules       map[string]RulePackRule `json:"rules,omitempty"` // Pokemon type -> rule
+	Active      bool                    `json:"active,omitempty"`
+	ImportedAt  *time.Time              `json:"imported_at,omitempty"` // set on imported packs
+}
+
+// RulePackRule decides how strongly a coffee matches one Pokemon type
+type RulePackRule struct {
+	PrimaryTraits    []RulePackTrait    `json:"primary_traits"`
+	SecondaryTraits  []RulePackTrait    `json:"secondary_traits,omitempty"`
+	Keywords         []string           `json:"keywords,omitempty"`          // matched against tasting notes
+	ProcessingBonus  map[string]float64 `json:"processing_bonus,omitempty"`  // processing method -> score multiplier
+	RoastLevelBonus  map[string]float64 `json:"roast_level_bonus,omitempty"` // roast level -> score multiplier
+	MinimumThreshold float64            `json:"minimum_threshold"`
+}
+
+// RulePackTrait weighs one tasting trait. The trait counts once it reaches
+// Min and scores fully at Max.
+type RulePackTrait struct {
+	Trait  string  `json:"trait"`
+	Weight float64 `json:"weight"`
+	Min    int     `json:"min"`
+	Max    int     `json:"max"`
+}
+
+// Validate checks if the RulePack data is valid. Whether its types and
+// traits exist is up to the mapper.
+func (p *RulePack) Validate() error {
+	p.Name = strings.ToLower(strings.TrimSpace(p.Name))
+	if !rulePackNamePattern.MatchString(p.Name) {
+		return fmt.Errorf("pack name must be 1-50 lower-case letters, digits or dashes")
+	}
+	if p.Name == BuiltinRulePack {
+		return fmt.Errorf("pack name %q is reserved", BuiltinRulePack)
+	}
+	p.Version = strings.TrimSpace(p.Version)
+	if p.Version == "" || len(p.Version) > 20 {
+		return fmt.Errorf("version must be 1-20 characters")
+	}
+	p.Author = strings.TrimSpace(p.Author)
+	if p.Author == "" || len(p.Author) > 100 {
+		return fmt.Errorf("author must be 1-100 characters")
+	}
+	if len(p.Description) > 1000 {
+		return fmt.Errorf("description must be at most 1000 characters")
+	}
+	if len(p.Rules) == 0 {
+		return fmt.Errorf("pack must contain at least one rule")
+	}
+
+	for typeName, rule := range p.Rules {
+		if len(rule.PrimaryTraits) == 0 {
+			return fmt.Errorf("rule %s needs at least one primary trait", typeName)
+		}
+		for _, trait := range append(append([]RulePackTrait{}, rule.PrimaryTraits...), rule.SecondaryTraits...) {
+			if trait.Weight < -10 || trait.Weight > 10 {
+				return fmt.Errorf("rule %s: weight of %s must be between -10 and 10", typeName, trait.Trait)
+			}
+			if trait.Min < 0 || trait.Max > 10 || trait.Min > trait.Max {
+				return fmt.Errorf("rule %s: min and max of %s must satisfy 0 <= min <= max <= 10", typeName, trait.Trait)
+			}
+		}
+		if len(rule.Keywords) > 50 {
+			return fmt.Errorf("rule %s: at most 50 keywords are allowed", typeName)
+		}
+		for _, keyword := range rule.Keywords {
+			if strings.TrimSpace(keyword) == "" || len(keyword) > 50 {
+				return fmt.Errorf("rule %s: keywords must be 1-50 characters", typeName)
+			}
+		}
+		for _, bonuses := range []map[string]float64{rule.ProcessingBonus, rule.RoastLevelBonus} {
+			for key, bonus := range bonuses {
+				if bonus <= 0 || bonus > 5 {
+					return fmt.Errorf("rule %s: bonus for %s must be above 0 and at most 5", typeName, key)
+				}
+			}
+		}
+		if rule.MinimumThreshold < 0 || rule.MinimumThreshold > 1 {
+			return fmt.Errorf("rule %s: minimum_threshold must be between 0 and 1", typeName)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+// Safari attempt statuses
+const (
+	SafariActive = "active" // the Pokemon waits for a matching coffee
+	SafariCaught = "caught" // a matching coffee was logged in time
+	SafariFled   = "fled"   // the window closed before a match was logged
+)
+
+// SafariTarget is one trait of a safari target coffee spec: a matching
+// coffee scores the trait between Min and Max
+type SafariTarget struct {
+	Trait string `json:"trait"`
+	Min   int    `json:"min"`
+	Max   int    `json:"max"`
+}
+
+// SafariAttempt is a Safari Zone encounter: a random uncaught Pokemon whose
+// type profile becomes a target coffee spec. Logging a coffee that meets
+// the spec before the attempt expires catches the Pokemon.
+type SafariAttempt struct {
+	ID          string         `json:"id"`
+	UserID      string         `json:"-"`
+	Dex         string         `json:"dex"`
+	PokemonID   int            `json:"pokemon_id"`
+	PokemonName string         `json:"pokemon_name"`
+	Type        string         `json:"type"` // the type the spec comes from
+	Target      []SafariTarget `json:"target"`
+	Hints       []string       `json:"hints,omitempty"` // tasting notes the type favours
+	Status      string         `json:"status"`          // computed, see SetStatus
+	CoffeeID    string         `json:"coffee_id,omitempty"`
+	Level       int            `json:"level,omitempty"` // the catch's level, bonus included
+	ExpiresAt   time.Time      `json:"expires_at"`
+	CaughtAt    *time.Time     `json:"caught_at,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+}
+
+// Caught reports whether a coffee caught the attempt's Pokemon
+func (a *SafariAttempt) Caught() bool {
+	return a.CaughtAt != nil
+}
+
+// Open reports whether a coffee logged at now can still catch the Pokemon
+func (a *SafariAttempt) Open(now time.Time) bool {
+	return !a.Caught() && now.Before(a.ExpiresAt)
+}
+
+// SetStatus fills in Status as of now
+func (a *SafariAttempt) SetStatus(now time.Time) {
+	switch {
+	case a.Caught():
+		a.Status = SafariCaught
+	case a.Open(now):
+		a.Status = SafariActive
+	default:
+		a.Status = SafariFled
+	}
+}
@@ -0,0 +1,139 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Scoresheet limits
+const (
+	DefaultScoresheetMaxScore = 10
+	maxScoresheetCategories   = 20
+	maxJudgeNameLength        = 100
+)
+
+// ScoresheetCategory is one scored aspect of a cup and its weight in the total
+type ScoresheetCategory struct {
+	Name   string  `json:"name"`
+	Weight float64 `json:"weight"`
+}
+
+// DefaultScoresheetCategories follow the Brewers Cup cup-taste sheet, where
+// flavor counts double and the overall impression triple
+var DefaultScoresheetCategories = []ScoresheetCategory{
+	{Name: "aroma", Weight: 1},
+	{Name: "flavor", Weight: 2},
+	{Name: "aftertaste", Weight: 1},
+	{Name: "acidity", Weight: 1},
+	{Name: "body", Weight: 1},
+	{Name: "balance", Weight: 1},
+	{Name: "overall", Weight: 3},
+}
+
+// Scoresheet is a competition entry for a coffee, scored by several judges
+// in weighted categories
+type Scoresheet struct {
+	ID          string               `json:"id"`
+	CoffeeID    string               `json:"coffee_id"`
+	Competition string               `json:"competition"` // groups sheets ranked against each other
+	Competitor  string               `json:"competitor"`  // who brewed the entry
+	Categories  []ScoresheetCategory `json:"categories"`
+	MaxScore    float64              `json:"max_score"` // per category; scores run from 0
+	CreatedAt   time.Time            `json:"created_at"`
+}
+
+// Validate checks the sheet and applies the default categories and scale
+func (s *Scoresheet) Validate() error {
+	if s.CoffeeID == "" {
+		return errors.New("coffee_id is required")
+	}
+	s.Competition = strings.TrimSpace(s.Competition)
+	s.Competitor = strings.TrimSpace(s.Competitor)
+	if s.Competition == "" {
+		return errors.New("competition is required")
+	}
+
+	if len(s.Categories) == 0 {
+		s.Categories = append([]ScoresheetCategory(nil), DefaultScoresheetCategories...)
+	}
+	if len(s.Categories) > maxScoresheetCategories {
+		return fmt.Errorf("a scoresheet can have at most %d categories", maxScoresheetCategories)
+	}
+	seen := make(map[string]bool, len(s.Categories))
+	for i := range s.Categories {
+		category := &s.Categories[i]
+		category.Name = strings.ToLower(strings.TrimSpace(category.Name))
+		if category.Name == "" {
+			return errors.New("category name is required")
+		}
+		if seen[category.Name] {
+			return fmt.Errorf("category %q is listed twice", category.Name)
+		}
+		seen[category.Name] = true
+		if category.Weight <= 0 {
+			return fmt.Errorf("category %q must have a positive weight", category.Name)
+		}
+	}
+
+	if s.MaxScore < 0 {
+		return errors.New("max_score must be positive")
+	}
+	if s.MaxScore == 0 {
+		s.MaxScore = DefaultScoresheetMaxScore
+	}
+	return nil
+}
+
+// JudgeScore is one judge's scores on a scoresheet, by category name
+type JudgeScore struct {
+	ScoresheetID string             `json:"scoresheet_id"`
+	Judge        string             `json:"judge"`
+	Scores       map[string]float64 `json:"scores"`
+	Notes        string             `json:"notes"`
+	UpdatedAt    time.Time          `json:"updated_at"`
+}
+
+// Validate checks that the judge scored every category of the sheet within
+// its scale
+func (j *JudgeScore) Validate(sheet Scoresheet) error {
+	j.Judge = strings.TrimSpace(j.Judge)
+	if j.Judge == "" {
+		return errors.New("judge is required")
+	}
+	if len(j.Judge) > maxJudgeNameLength {
+		return errors.New("judge must be 100 characters or fewer")
+	}
+
+	normalized := make(map[string]float64, len(j.Scores))
+	for name, score := range j.Scores {
+		normalized[strings.ToLower(strings.TrimSpace(name))] = score
+	}
+	for name := range normalized {
+		if !sheet.HasCategory(name) {
+			return fmt.Errorf("unknown category %q", name)
+		}
+	}
+	for _, category := range sheet.Categories {
+		score, ok := normalized[category.Name]
+		if !ok {
+			return fmt.Errorf("score for %q is required", category.Name)
+		}
+		if score < 0 || score > sheet.MaxScore {
+			return fmt.Errorf("score for %q must be between 0 and %g", category.Name, sheet.MaxScore)
+		}
+	}
+	j.Scores = normalized
+	return nil
+}
+
+// HasCategory reports whether the sheet scores the named category
+func (s *Scoresheet) HasCategory(name string) bool {
+	for _, category := range s.Categories {
+		if category.Name == name {
+			return true
+		}
+	}
+	return false
+}
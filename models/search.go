@@ -0,0 +1,107 @@
+package models
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// SearchQuery filters coffees. Every set field must match: text matches
+// anywhere in the name, roaster, origin, variety or tasting notes, the other
+// fields match whole values, all ignoring case.
+type SearchQuery struct {
+	Text             string `json:"text,omitempty"`
+	Origin           string `json:"origin,omitempty"`
+	Roaster          string `json:"roaster,omitempty"`
+	Variety          string `json:"variety,omitempty"`
+	RoastLevel       string `json:"roast_level,omitempty"`
+	ProcessingMethod string `json:"processing_method,omitempty"`
+	Dripper          string `json:"dripper,omitempty"`
+	TastingNote      string `json:"tasting_note,omitempty"`
+	MinRating        int    `json:"min_rating,omitempty"`
+	FavoritesOnly    bool   `json:"favorites_only,omitempty"`
+}
+
+// IsEmpty reports whether the query matches every coffee
+func (q SearchQuery) IsEmpty() bool {
+	return q == SearchQuery{}
+}
+
+// Matches reports whether a coffee satisfies the query
+func (q SearchQuery) Matches(c Coffee) bool {
+	if q.FavoritesOnly && !c.Favorite {
+		return false
+	}
+	if q.MinRating > 0 && c.Rating < q.MinRating {
+		return false
+	}
+
+	for _, field := range []struct{ want, have string }{
+		{q.Origin, c.Origin},
+		{q.Roaster, c.Roaster},
+		{q.Variety, c.Variety},
+		{q.RoastLevel, c.RoastLevel},
+		{q.ProcessingMethod, c.ProcessingMethod},
+		{q.Dripper, c.Dripper},
+	} {
+		if field.want != "" && !strings.EqualFold(strings.TrimSpace(field.have), strings.TrimSpace(field.want)) {
+			return false
+		}
+	}
+
+	if q.TastingNote != "" {
+		found := false
+		for _, note := range c.TastingNotes {
+			if strings.EqualFold(strings.TrimSpace(note), strings.TrimSpace(q.TastingNote)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if text := strings.ToLower(strings.TrimSpace(q.Text)); text != "" {
+		haystack := strings.ToLower(strings.Join(append([]string{c.Name, c.Roaster, c.Origin, c.Variety}, c.TastingNotes[:]...), " "))
+		if !strings.Contains(haystack, text) {
+			return false
+		}
+	}
+	return true
+}
+
+// SavedSearch is a named search that can be re-run, and optionally
+// notifies a webhook when a newly logged coffee matches it
+type SavedSearch struct {
+	ID         string      `json:"id"`
+	Name       string      `json:"name"`
+	Query      SearchQuery `json:"query"`
+	WebhookURL string      `json:"webhook_url,omitempty"`
+	CreatedAt  time.Time   `json:"created_at"`
+}
+
+// Validate checks the name, the query and the webhook URL
+func (s *SavedSearch) Validate() error {
+	s.Name = strings.TrimSpace(s.Name)
+	if s.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(s.Name) > 100 {
+		return errors.New("name must be 100 characters or fewer")
+	}
+	if s.Query.IsEmpty() {
+		return errors.New("query must set at least one filter")
+	}
+	if s.Query.MinRating < 0 || s.Query.MinRating > 10 {
+		return errors.New("query min_rating must be between 0 and 10")
+	}
+	if s.WebhookURL != "" {
+		u, err := url.Parse(s.WebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.New("webhook_url must be an http or https URL")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// maxSubscriptionIntervalDays caps how far apart drops can be
+const maxSubscriptionIntervalDays = 365
+
+// Subscription is a recurring coffee delivery from a roaster, such as a
+// monthly single-origin club
+type Subscription struct {
+	ID           string    `json:"id"`
+	Roaster      string    `json:"roaster"`
+	Name         string    `json:"name"` // e.g. "Single origin club"
+	IntervalDays int       `json:"interval_days"`
+	NextDelivery Date      `json:"next_delivery"`
+	Notes        string    `json:"notes"`
+	Paused       bool      `json:"paused"` // paused subscriptions lay no eggs
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// Validate checks that the subscription names a roaster and a schedule
+func (s *Subscription) Validate() error {
+	s.Roaster = strings.TrimSpace(s.Roaster)
+	s.Name = strings.TrimSpace(s.Name)
+	if s.Roaster == "" {
+		return errors.New("roaster is required")
+	}
+	if s.IntervalDays < 1 || s.IntervalDays > maxSubscriptionIntervalDays {
+		return errors.New("interval_days must be between 1 and 365")
+	}
+	if s.NextDelivery.IsZero() {
+		return errors.New("next_delivery is required")
+	}
+	return nil
+}
+
+// Egg statuses
+const (
+	EggWaiting = "waiting" // the bag is due or on its way
+	EggHatched = "hatched" // the bag was logged as a coffee
+)
+
+// Egg is a wishlist entry for a bag that is due from a subscription. It
+// hatches when the bag is logged as a coffee.
+type Egg struct {
+	ID             string     `json:"id"`
+	SubscriptionID string     `json:"subscription_id"`
+	Roaster        string     `json:"roaster"`
+	Name           string     `json:"name"`
+	ExpectedOn     Date       `json:"expected_on"`
+	Status         string     `json:"status"` // filled in when listed, not stored
+	CoffeeID       string     `json:"coffee_id,omitempty"`
+	HatchedAt      *time.Time `json:"hatched_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// Hatched reports whether the bag has been logged; the coffee may since
+// have been deleted
+func (e *Egg) Hatched() bool {
+	return e.HatchedAt != nil
+}
+
+// SetStatus fills in Status from whether the egg has hatched
+func (e *Egg) SetStatus() {
+	e.Status = EggWaiting
+	if e.Hatched() {
+		e.Status = EggHatched
+	}
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Temperature represents a brew water temperature with its unit
+type Temperature struct {
+	Value float64 `json:"value"`
+	Unit  string  `json:"unit"` // "C" or "F"
+}
+
+// NormalizeUnit accepts common spellings ("c", "°F", "celsius") and returns
+// "C" or "F". An empty unit defaults to Celsius.
+func NormalizeUnit(unit string) (string, error) {
+	switch strings.ToLower(strings.TrimPrefix(strings.TrimSpace(unit), "°")) {
+	case "", "c", "celsius":
+		return "C", nil
+	case "f", "fahrenheit":
+		return "F", nil
+	default:
+		return "", fmt.Errorf("invalid temperature unit: %s (use C or F)", unit)
+	}
+}
+
+// Validate normalizes the unit and checks the value is liquid water
+func (t *Temperature) Validate() error {
+	unit, err := NormalizeUnit(t.Unit)
+	if err != nil {
+		return err
+	}
+	t.Unit = unit
+
+	if celsius := t.Celsius(); celsius < 0 || celsius > 100 {
+		return fmt.Errorf("temperature must be between 0°C and 100°C (32°F and 212°F)")
+	}
+	return nil
+}
+
+// Celsius returns the temperature in degrees Celsius
+func (t Temperature) Celsius() float64 {
+	if t.Unit == "F" {
+		return FahrenheitToCelsius(t.Value)
+	}
+	return t.Value
+}
+
+// Fahrenheit returns the temperature in degrees Fahrenheit
+func (t Temperature) Fahrenheit() float64 {
+	if t.Unit == "F" {
+		return t.Value
+	}
+	return CelsiusToFahrenheit(t.Value)
+}
+
+// In returns the temperature converted to the given unit ("C" or "F")
+func (t Temperature) In(unit string) Temperature {
+	if unit == "F" {
+		return Temperature{Value: math.Round(t.Fahrenheit()*10) / 10, Unit: "F"}
+	}
+	return Temperature{Value: math.Round(t.Celsius()*10) / 10, Unit: "C"}
+}
+
+// String renders the temperature like "94°C"
+func (t Temperature) String() string {
+	return fmt.Sprintf("%g°%s", t.Value, t.Unit)
+}
+
+// CelsiusToFahrenheit converts °C to °F
+func CelsiusToFahrenheit(celsius float64) float64 {
+	return celsius*9/5 + 32
+}
+
+// FahrenheitToCelsius converts °F to °C
+func FahrenheitToCelsius(fahrenheit float64) float64 {
+	return (fahrenheit - 32) * 5 / 9
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// Entity types tracked by the sync journal
+const (
+	EntityCoffee = "coffee"
+	EntityBrew   = "brew"
+)
+
+// Tombstone records that an entity was deleted, so offline clients can
+// learn about deletions when they sync
+type Tombstone struct {
+	EntityType string    `json:"entity_type"` // "coffee", "brew"
+	EntityID   string    `json:"entity_id"`
+	DeletedAt  time.Time `json:"deleted_at"`
+}
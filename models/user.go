@@ -0,0 +1,50 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// User represents a member of a shared server
+type User struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	IsAdmin   bool      `json:"is_admin"`
+	TokenHash string    `json:"-"` // SHA-256 of the user's API token
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Invite represents a single-use, expiring invitation code
+type Invite struct {
+	Code      string     `json:"code"`
+	CreatedBy string     `json:"created_by"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedBy    string     `json:"used_by,omitempty"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Validate checks if the User data is valid
+func (u *User) Validate() error {
+	u.Name = strings.TrimSpace(u.Name)
+	if u.Name == "" {
+		return fmt.Errorf("name cannot be empty")
+	}
+	if len(u.Name) > 100 {
+		return fmt.Errorf("name must be at most 100 characters")
+	}
+	return nil
+}
+
+// Status reports whether the invite is "pending", "used" or "expired"
+func (i Invite) Status(now time.Time) string {
+	switch {
+	case i.UsedAt != nil:
+		return "used"
+	case now.After(i.ExpiresAt):
+		return "expired"
+	default:
+		return "pending"
+	}
+}
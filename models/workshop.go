@@ -0,0 +1,31 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Workshop is an ephemeral workspace for a tasting workshop or class. It
+// runs as its own server with its own coffees, brewers and Pokedex, reached
+// through /workshops/{id}/, and is deleted when it expires.
+type Workshop struct {
+	ID        string    `json:"id"` // unguessable; knowing it grants access
+	Name      string    `json:"name"`
+	Storage   string    `json:"storage"` // "memory", or the MySQL schema it lives in
+	URL       string    `json:"url"`     // path participants point their client at
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Validate checks if the Workshop data is valid
+func (w *Workshop) Validate() error {
+	w.Name = strings.TrimSpace(w.Name)
+	if w.Name == "" {
+		return fmt.Errorf("name cannot be empty")
+	}
+	if len(w.Name) > 100 {
+		return fmt.Errorf("name must be at most 100 characters")
+	}
+	return nil
+}
@@ -1,14 +1,13 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
+	"go-coffee-log/client"
 	"go-coffee-log/models"
-	"go-coffee-log/storage"
 	"log"
-	"time"
-
-	"github.com/google/uuid"
+	"os"
 )
 
 // Sample coffee data for testing
@@ -185,36 +184,20 @@ var sampleCoffees = []struct {
 
 func main() {
 	// Parse command-line flags
-	storageType := flag.String("storage", "mysql", "Storage type: memory or mysql")
-	mysqlHost := flag.String("mysql-host", "localhost:3306", "MySQL host")
-	mysqlUser := flag.String("mysql-user", "coffee_user", "MySQL user")
-	mysqlPassword := flag.String("mysql-password", "coffee_pass123", "MySQL password")
-	mysqlDB := flag.String("mysql-db", "coffee_log", "MySQL database name")
+	serverURL := flag.String("url", "http://localhost:8080", "URL of the running coffee log server")
+	token := flag.String("token", os.Getenv("COFFEE_LOG_TOKEN"), "API token (default: $COFFEE_LOG_TOKEN)")
 	count := flag.Int("count", 0, "Number of entries to create (0 = all sample data)")
+	catch := flag.Bool("catch", false, "Catch a Pokemon for every created coffee")
 	flag.Parse()
 
-	// Initialize storage
-	var store storage.CoffeeStorage
-	var err error
-
-	switch *storageType {
-	case "mysql":
-		store, err = storage.NewMySQLStorage(*mysqlHost, *mysqlUser, *mysqlPassword, *mysqlDB)
-		if err != nil {
-			log.Fatalf("Failed to initialize MySQL storage: %v", err)
-		}
-		fmt.Println("✅ Connected to MySQL storage")
-
-		// Close MySQL connection on exit
-		if mysqlStore, ok := store.(*storage.MySQLStorage); ok {
-			defer mysqlStore.Close()
-		}
-	case "memory":
-		store = storage.NewMemoryStorage()
-		fmt.Println("✅ Using in-memory storage")
-	default:
-		log.Fatalf("Invalid storage type: %s", *storageType)
+	// Entries go through the API, so they are validated and counted
+	// against quotas like any other coffee
+	api := client.New(*serverURL, *token)
+	ctx := context.Background()
+	if _, err := api.ListCoffees(ctx); err != nil {
+		log.Fatalf("Failed to reach the server at %s: %v", *serverURL, err)
 	}
+	fmt.Printf("✅ Connected to %s\n", *serverURL)
 
 	// Determine how many entries to create
 	numEntries := len(sampleCoffees)
@@ -225,12 +208,11 @@ func main() {
 	fmt.Printf("\n📦 Creating %d test coffee entries...\n\n", numEntries)
 
 	// Create coffee entries
+	created := 0
 	for i := 0; i < numEntries; i++ {
 		sample := sampleCoffees[i]
-		now := time.Now()
 
-		coffee := models.Coffee{
-			ID:               uuid.New().String(),
+		coffee, err := api.CreateCoffee(ctx, models.Coffee{
 			Name:             sample.name,
 			Origin:           sample.origin,
 			Roaster:          sample.roaster,
@@ -242,33 +224,25 @@ func main() {
 			Recipe:           sample.recipe,
 			Dripper:          sample.dripper,
 			EndTime:          sample.endTime,
-			CreatedAt:        now,
-			UpdatedAt:        now,
-		}
-
-		// Validate before saving
-		if err := coffee.Validate(); err != nil {
-			log.Printf("❌ Validation error for %s: %v", coffee.Name, err)
+		})
+		if err != nil {
+			log.Printf("❌ Failed to create %s: %v", sample.name, err)
 			continue
 		}
+		created++
+		fmt.Printf("✅ Created: %s (ID: %s)\n", coffee.Name, coffee.ID)
 
-		// Save to storage
-		if err := store.Save(coffee); err != nil {
-			log.Printf("❌ Failed to save %s: %v", coffee.Name, err)
-			continue
+		if *catch {
+			mapping, err := api.CatchPokemon(ctx, coffee.ID)
+			if err != nil {
+				log.Printf("❌ Failed to catch a Pokemon for %s: %v", coffee.Name, err)
+				continue
+			}
+			fmt.Printf("   Caught %s\n", mapping.PokemonName)
 		}
-
-		fmt.Printf("✅ Created: %s (ID: %s)\n", coffee.Name, coffee.ID)
 	}
 
-	fmt.Printf("\n🎉 Successfully created %d test entries!\n", numEntries)
+	fmt.Printf("\n🎉 Successfully created %d test entries!\n", created)
 	fmt.Println("\n📊 To view all entries:")
-	fmt.Println("   curl http://localhost:8080/coffees")
-	fmt.Println("\n🚀 To start the server:")
-	if *storageType == "mysql" {
-		fmt.Printf("   go run main.go -storage=mysql -mysql-user=%s -mysql-password=%s -mysql-db=%s\n",
-			*mysqlUser, *mysqlPassword, *mysqlDB)
-	} else {
-		fmt.Println("   go run main.go")
-	}
-}
\ No newline at end of file
+	fmt.Printf("   curl %s/coffees\n", *serverURL)
+}
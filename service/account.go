@@ -0,0 +1,430 @@
+package service
+
+import (
+	"fmt"
+	"go-coffee-log/models"
+	"go-coffee-log/storage"
+	"io"
+	"log"
+	"strings"
+	"time"
+)
+
+// exportFormatVersion is bumped whenever the archive layout changes
+const exportFormatVersion = 4
+
+// ArchiveStorage holds the storages of the data archived besides coffees,
+// brews, comments, catches and brewers. Any of them may be nil; their data
+// is then left out of exports and imports.
+type ArchiveStorage struct {
+	Collections   storage.CollectionStorage
+	Photos        storage.PhotoStorage
+	Media         storage.MediaStorage // the photo blobs
+	SavedSearches storage.SavedSearchStorage
+	Plans         storage.PlanStorage
+	Subscriptions storage.SubscriptionStorage
+	Scoresheets   storage.ScoresheetStorage
+	Badges        storage.BadgeStorage
+	Safari        storage.SafariStorage
+}
+
+// AccountService exports and deletes everything stored for the user
+type AccountService struct {
+	coffeeStorage    storage.CoffeeStorage
+	brewStorage      storage.BrewStorage
+	commentStorage   storage.CommentStorage
+	reactionStorage  storage.ReactionStorage  // optional
+	pokemonStorage   storage.PokemonStorage   // optional
+	brewerStorage    storage.BrewerStorage    // optional
+	consensusStorage storage.ConsensusStorage // optional
+	purger           storage.DataPurger
+	archive          ArchiveStorage
+}
+
+// NewAccountService creates a new account service
+func NewAccountService(
+	coffeeStorage storage.CoffeeStorage,
+	brewStorage storage.BrewStorage,
+	commentStorage storage.CommentStorage,
+	reactionStorage storage.ReactionStorage,
+	pokemonStorage storage.PokemonStorage,
+	brewerStorage storage.BrewerStorage,
+	consensusStorage storage.ConsensusStorage,
+	purger storage.DataPurger,
+) *AccountService {
+	return &AccountService{
+		coffeeStorage:    coffeeStorage,
+		brewStorage:      brewStorage,
+		commentStorage:   commentStorage,
+		reactionStorage:  reactionStorage,
+		pokemonStorage:   pokemonStorage,
+		brewerStorage:    brewerStorage,
+		consensusStorage: consensusStorage,
+		purger:           purger,
+	}
+}
+
+// SetArchiveStorage adds collections, photos, saved searches, plans,
+// subscriptions, scoresheets, badges and safari attempts to exports
+func (s *AccountService) SetArchiveStorage(archive ArchiveStorage) {
+	s.archive = archive
+}
+
+// DataExport represents a complete, machine-readable archive of user data.
+// Badges and safari attempts are the exporting user's; everything else
+// belongs to the whole collection.
+type DataExport struct {
+	Format         string                       `json:"format"`
+	Version        int                          `json:"version"`
+	ExportedAt     time.Time                    `json:"exported_at"`
+	Coffees        []models.Coffee              `json:"coffees"`
+	Brews          []models.Brew                `json:"brews"`
+	Comments       []models.Comment             `json:"comments"`
+	Reactions      []models.Reaction            `json:"reactions"`
+	Pokemon        []models.CoffeePokemon       `json:"pokemon"`
+	Brewers        []models.Brewer              `json:"brewers"`
+	Disagreements  []models.MappingDisagreement `json:"mapping_disagreements"`
+	Collections    []models.Collection          `json:"collections"`
+	Photos         []models.CoffeePhoto         `json:"photos"`
+	PhotoData      map[string][]byte            `json:"photo_data"` // the photo blobs by hash, base64-encoded
+	SavedSearches  []models.SavedSearch         `json:"saved_searches"`
+	Plans          []models.PlannedBrew         `json:"plans"`
+	Subscriptions  []models.Subscription        `json:"subscriptions"`
+	Eggs           []models.Egg                 `json:"eggs"`
+	Scoresheets    []models.Scoresheet          `json:"scoresheets"`
+	JudgeScores    []models.JudgeScore          `json:"judge_scores"`
+	Badges         []models.Badge               `json:"badges"`
+	SafariAttempts []models.SafariAttempt       `json:"safari_attempts"`
+}
+
+// DeletionSummary reports how much data was purged
+type DeletionSummary struct {
+	Coffees       int       `json:"coffees"`
+	Brews         int       `json:"brews"`
+	Comments      int       `json:"comments"`
+	Pokemon       int       `json:"pokemon"`
+	Brewers       int       `json:"brewers"`
+	Disagreements int       `json:"mapping_disagreements"`
+	Photos        int       `json:"photos"`
+	DeletedAt     time.Time `json:"deleted_at"`
+}
+
+// Export gathers all stored data into one archive, with userID's badges
+// and safari attempts
+func (s *AccountService) Export(userID string) (*DataExport, error) {
+	export, err := s.gather(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.exportPhotoData(export); err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+// ExportUser gathers what one member added: the coffees, brews, comments,
+// reactions and photos recorded as theirs, the catches of their coffees,
+// and their badges and safari attempts. The shared collection's brewers,
+// collections, plans and the rest stay in the full Export.
+func (s *AccountService) ExportUser(userID string) (*DataExport, error) {
+	full, err := s.gather(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	export := newDataExport()
+	export.Badges = full.Badges
+	export.SafariAttempts = full.SafariAttempts
+
+	coffeeIDs := make(map[string]bool)
+	for _, coffee := range full.Coffees {
+		if coffee.AddedBy == userID {
+			export.Coffees = append(export.Coffees, coffee)
+			coffeeIDs[coffee.ID] = true
+		}
+	}
+	for _, mapping := range full.Pokemon {
+		if coffeeIDs[mapping.CoffeeID] {
+			export.Pokemon = append(export.Pokemon, mapping)
+		}
+	}
+	for _, brew := range full.Brews {
+		if brew.BrewedBy == userID {
+			export.Brews = append(export.Brews, brew)
+		}
+	}
+	for _, comment := range full.Comments {
+		if comment.AuthorID == userID {
+			export.Comments = append(export.Comments, comment)
+		}
+	}
+	for _, reaction := range full.Reactions {
+		if reaction.UserID == userID {
+			export.Reactions = append(export.Reactions, reaction)
+		}
+	}
+	for _, photo := range full.Photos {
+		if photo.UploadedBy == userID {
+			export.Photos = append(export.Photos, photo)
+		}
+	}
+
+	if err := s.exportPhotoData(export); err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+// newDataExport returns an empty archive of the current format
+func newDataExport() *DataExport {
+	return &DataExport{
+		Format:         "coffee-dex-export",
+		Version:        exportFormatVersion,
+		ExportedAt:     time.Now(),
+		Coffees:        []models.Coffee{},
+		Brews:          []models.Brew{},
+		Comments:       []models.Comment{},
+		Reactions:      []models.Reaction{},
+		Pokemon:        []models.CoffeePokemon{},
+		Brewers:        []models.Brewer{},
+		Disagreements:  []models.MappingDisagreement{},
+		Collections:    []models.Collection{},
+		Photos:         []models.CoffeePhoto{},
+		PhotoData:      map[string][]byte{},
+		SavedSearches:  []models.SavedSearch{},
+		Plans:          []models.PlannedBrew{},
+		Subscriptions:  []models.Subscription{},
+		Eggs:           []models.Egg{},
+		Scoresheets:    []models.Scoresheet{},
+		JudgeScores:    []models.JudgeScore{},
+		Badges:         []models.Badge{},
+		SafariAttempts: []models.SafariAttempt{},
+	}
+}
+
+// gather collects everything Export archives but the photo blobs
+func (s *AccountService) gather(userID string) (*DataExport, error) {
+	export := newDataExport()
+
+	coffees, err := s.coffeeStorage.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to export coffees: %w", err)
+	}
+	export.Coffees = append(export.Coffees, coffees...)
+
+	brews, err := s.brewStorage.GetAllBrews()
+	if err != nil {
+		return nil, fmt.Errorf("failed to export brews: %w", err)
+	}
+	export.Brews = append(export.Brews, brews...)
+
+	comments, err := s.commentStorage.GetAllComments()
+	if err != nil {
+		return nil, fmt.Errorf("failed to export comments: %w", err)
+	}
+	export.Comments = append(export.Comments, comments...)
+
+	if s.pokemonStorage != nil {
+		mappings, err := s.pokemonStorage.GetAllCoffeePokemon()
+		if err != nil {
+			return nil, fmt.Errorf("failed to export Pokemon: %w", err)
+		}
+		export.Pokemon = append(export.Pokemon, mappings...)
+	}
+
+	if s.reactionStorage != nil {
+		reactions, err := s.reactionStorage.GetAllReactions()
+		if err != nil {
+			return nil, fmt.Errorf("failed to export reactions: %w", err)
+		}
+		export.Reactions = append(export.Reactions, reactions...)
+	}
+
+	if s.brewerStorage != nil {
+		brewers, err := s.brewerStorage.GetAllBrewers()
+		if err != nil {
+			return nil, fmt.Errorf("failed to export brewers: %w", err)
+		}
+		export.Brewers = append(export.Brewers, brewers...)
+	}
+
+	if s.consensusStorage != nil {
+		disagreements, err := s.consensusStorage.GetAllDisagreements()
+		if err != nil {
+			return nil, fmt.Errorf("failed to export mapping disagreements: %w", err)
+		}
+		export.Disagreements = append(export.Disagreements, disagreements...)
+	}
+
+	if err := s.gatherArchive(export, userID); err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+// gatherArchive adds the data of the archive storages to an export
+func (s *AccountService) gatherArchive(export *DataExport, userID string) error {
+	if s.archive.Collections != nil {
+		collections, err := s.archive.Collections.GetAllCollections()
+		if err != nil {
+			return fmt.Errorf("failed to export collections: %w", err)
+		}
+		export.Collections = append(export.Collections, collections...)
+	}
+
+	if s.archive.Photos != nil {
+		photos, err := s.archive.Photos.GetAllPhotos()
+		if err != nil {
+			return fmt.Errorf("failed to export photos: %w", err)
+		}
+		export.Photos = append(export.Photos, photos...)
+	}
+
+	if s.archive.SavedSearches != nil {
+		searches, err := s.archive.SavedSearches.GetAllSearches()
+		if err != nil {
+			return fmt.Errorf("failed to export saved searches: %w", err)
+		}
+		export.SavedSearches = append(export.SavedSearches, searches...)
+	}
+
+	if s.archive.Plans != nil {
+		plans, err := s.archive.Plans.GetAllPlans()
+		if err != nil {
+			return fmt.Errorf("failed to export plans: %w", err)
+		}
+		export.Plans = append(export.Plans, plans...)
+	}
+
+	if s.archive.Subscriptions != nil {
+		subscriptions, err := s.archive.Subscriptions.GetAllSubscriptions()
+		if err != nil {
+			return fmt.Errorf("failed to export subscriptions: %w", err)
+		}
+		export.Subscriptions = append(export.Subscriptions, subscriptions...)
+
+		eggs, err := s.archive.Subscriptions.GetAllEggs()
+		if err != nil {
+			return fmt.Errorf("failed to export eggs: %w", err)
+		}
+		export.Eggs = append(export.Eggs, eggs...)
+	}
+
+	if s.archive.Scoresheets != nil {
+		sheets, err := s.archive.Scoresheets.GetAllScoresheets()
+		if err != nil {
+			return fmt.Errorf("failed to export scoresheets: %w", err)
+		}
+		export.Scoresheets = append(export.Scoresheets, sheets...)
+
+		for _, sheet := range sheets {
+			scores, err := s.archive.Scoresheets.GetJudgeScores(sheet.ID)
+			if err != nil {
+				return fmt.Errorf("failed to export judge scores: %w", err)
+			}
+			export.JudgeScores = append(export.JudgeScores, scores...)
+		}
+	}
+
+	if s.archive.Badges != nil {
+		badges, err := s.archive.Badges.GetBadges(userID)
+		if err != nil {
+			return fmt.Errorf("failed to export badges: %w", err)
+		}
+		export.Badges = append(export.Badges, badges...)
+	}
+
+	if s.archive.Safari != nil {
+		attempts, err := s.archive.Safari.GetAttempts(userID)
+		if err != nil {
+			return fmt.Errorf("failed to export safari attempts: %w", err)
+		}
+		export.SafariAttempts = append(export.SafariAttempts, attempts...)
+	}
+
+	return nil
+}
+
+// exportPhotoData adds the blob of every exported photo, once per hash.
+// Photos whose blob is missing from media storage are exported without one.
+func (s *AccountService) exportPhotoData(export *DataExport) error {
+	if s.archive.Media == nil {
+		return nil
+	}
+	for _, photo := range export.Photos {
+		if _, ok := export.PhotoData[photo.Hash]; ok {
+			continue
+		}
+		blob, _, err := s.archive.Media.Open(photo.Hash)
+		if err != nil && strings.Contains(err.Error(), "not found") {
+			log.Printf("WARNING: Exporting photo %s of coffee %s without its missing blob", photo.Hash, photo.CoffeeID)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to export photo %s: %w", photo.Hash, err)
+		}
+		data, err := io.ReadAll(blob)
+		blob.Close()
+		if err != nil {
+			return fmt.Errorf("failed to export photo %s: %w", photo.Hash, err)
+		}
+		export.PhotoData[photo.Hash] = data
+	}
+	return nil
+}
+
+// DeleteAll purges every coffee, brew, Pokemon mapping and brewer in a
+// single transaction, then deletes the photo blobs, and reports what was
+// removed
+func (s *AccountService) DeleteAll() (*DeletionSummary, error) {
+	export, err := s.gather("")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.purger.PurgeAll(); err != nil {
+		return nil, fmt.Errorf("failed to delete data: %w", err)
+	}
+	s.deletePhotoBlobs(export.Photos)
+
+	return &DeletionSummary{
+		Coffees:       len(export.Coffees),
+		Brews:         len(export.Brews),
+		Comments:      len(export.Comments),
+		Pokemon:       len(export.Pokemon),
+		Brewers:       len(export.Brewers),
+		Disagreements: len(export.Disagreements),
+		Photos:        len(export.Photos),
+		DeletedAt:     time.Now(),
+	}, nil
+}
+
+// DeleteUserData deletes a member's brews, comments with the replies below
+// them, reactions, badges, safari attempts and settings in a single
+// transaction, and clears their ID from the coffees and photos they added
+// to the shared collection
+func (s *AccountService) DeleteUserData(userID string) error {
+	if err := s.purger.PurgeUser(userID); err != nil {
+		return fmt.Errorf("failed to delete data: %w", err)
+	}
+	return nil
+}
+
+// deletePhotoBlobs deletes the blobs of purged photos from media storage.
+// The photos are gone already, so a blob that fails to delete is only
+// logged and left for the media-gc job.
+func (s *AccountService) deletePhotoBlobs(photos []models.CoffeePhoto) {
+	if s.archive.Media == nil {
+		return
+	}
+	deleted := make(map[string]bool)
+	for _, photo := range photos {
+		if deleted[photo.Hash] {
+			continue
+		}
+		deleted[photo.Hash] = true
+		if err := s.archive.Media.Delete(photo.Hash); err != nil {
+			log.Printf("ERROR: Failed to delete blob %s of purged photo: %v", photo.Hash, err)
+		}
+	}
+}
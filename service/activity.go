@@ -0,0 +1,92 @@
+package service
+
+import (
+	"fmt"
+	"go-coffee-log/models"
+	"go-coffee-log/storage"
+	"time"
+)
+
+const (
+	// Activity page sizes for ActivityLog.List
+	DefaultActivityPageSize = 50
+	MaxActivityPageSize     = 200
+	// ActivityRetention is how long activity is kept
+	ActivityRetention = 90 * 24 * time.Hour
+)
+
+// ActivityPage is one page of the activity log
+type ActivityPage struct {
+	Activity   []models.Activity `json:"activity"`
+	Total      int               `json:"total"`
+	Offset     int               `json:"offset"`
+	Limit      int               `json:"limit"`
+	HasMore    bool              `json:"has_more"`
+	NextOffset *int              `json:"next_offset,omitempty"` // offset of the next page, if any
+}
+
+// ActivityLog keeps every published event, so recent activity across the
+// whole instance can be listed after the fact. Add it to the event bus as a
+// sink.
+type ActivityLog struct {
+	storage storage.ActivityStorage
+}
+
+// NewActivityLog creates a new activity log
+func NewActivityLog(storage storage.ActivityStorage) *ActivityLog {
+	return &ActivityLog{storage: storage}
+}
+
+// Name identifies the sink in logs
+func (a *ActivityLog) Name() string {
+	return "activity"
+}
+
+// Send records an event
+func (a *ActivityLog) Send(event Event) error {
+	err := a.storage.SaveActivity(models.Activity{
+		ID:        event.ID,
+		Type:      event.Type,
+		Message:   event.Message,
+		CoffeeID:  event.CoffeeID,
+		Data:      event.Data,
+		CreatedAt: event.CreatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record activity: %w", err)
+	}
+	return nil
+}
+
+// List retrieves one page of activity of a type (all when empty), newest
+// first. A limit of 0 uses DefaultActivityPageSize; larger limits are
+// capped at MaxActivityPageSize.
+func (a *ActivityLog) List(eventType string, offset, limit int) (ActivityPage, error) {
+	if offset < 0 {
+		offset = 0
+	}
+	if limit <= 0 {
+		limit = DefaultActivityPageSize
+	}
+	if limit > MaxActivityPageSize {
+		limit = MaxActivityPageSize
+	}
+
+	activity, total, err := a.storage.GetActivity(eventType, offset, limit)
+	if err != nil {
+		return ActivityPage{}, err
+	}
+
+	page := ActivityPage{Activity: activity, Total: total, Offset: offset, Limit: limit}
+	if next := offset + len(activity); next < total {
+		page.HasMore = true
+		page.NextOffset = &next
+	}
+	return page, nil
+}
+
+// Prune removes activity older than ActivityRetention. Run it periodically.
+func (a *ActivityLog) Prune(now time.Time) error {
+	_, err := a.storage.DeleteActivityBefore(now.Add(-ActivityRetention))
+	return err
+}
@@ -0,0 +1,260 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"go-coffee-log/models"
+	"log"
+	"math"
+	"strings"
+)
+
+// AdvisorService diagnoses brewing problems from tasting symptoms. The
+// suggestions are rule-based; an LLM can optionally elaborate on them.
+type AdvisorService struct {
+	coffeeService *CoffeeService
+	llmService    *LLMService // optional
+}
+
+// NewAdvisorService creates a new brew advisor
+func NewAdvisorService(coffeeService *CoffeeService, llmService *LLMService) *AdvisorService {
+	return &AdvisorService{
+		coffeeService: coffeeService,
+		llmService:    llmService,
+	}
+}
+
+// DiagnoseRequest represents the symptoms reported for a brew
+type DiagnoseRequest struct {
+	Symptoms  []string `json:"symptoms"`
+	Recipe    []string `json:"recipe,omitempty"`  // defaults to the coffee's recipe
+	Dripper   string   `json:"dripper,omitempty"` // defaults to the coffee's dripper
+	Elaborate bool     `json:"elaborate"`         // ask the LLM to explain the suggestions
+}
+
+// Adjustment represents a single suggested recipe change
+type Adjustment struct {
+	Variable string `json:"variable"` // grind, temperature, ratio, time, agitation
+	Change   string `json:"change"`   // e.g. "finer", "raise to 96°C"
+	Reason   string `json:"reason"`
+	Priority int    `json:"priority"` // 1 = try first
+}
+
+// Diagnosis represents the advisor's assessment of a brew
+type Diagnosis struct {
+	CoffeeID         string       `json:"coffee_id"`
+	Symptoms         []string     `json:"symptoms"`
+	Assessment       string       `json:"assessment"` // under-extracted, over-extracted, uneven, strength, balanced, unclear
+	DrawdownSeconds  int          `json:"drawdown_seconds,omitempty"`
+	TargetDrawdown   [2]int       `json:"target_drawdown_seconds"`
+	RecipeParameters RecipeParams `json:"recipe_parameters"`
+	Adjustments      []Adjustment `json:"adjustments"`
+	Elaboration      string       `json:"elaboration,omitempty"`
+}
+
+// symptom keyword groups
+var (
+	underExtractedSymptoms = []string{"sour", "acidic", "sharp", "salty", "thin", "under", "grassy", "vegetal", "lacking sweetness"}
+	overExtractedSymptoms  = []string{"bitter", "harsh", "astringent", "dry", "drying", "over", "hollow", "ashy", "chalky"}
+	weakSymptoms           = []string{"weak", "watery", "diluted", "flat"}
+	strongSymptoms         = []string{"strong", "heavy", "intense", "too much"}
+	unevenSymptoms         = []string{"muddy", "uneven", "channel", "stall", "clog", "choked", "both sour and bitter"}
+	fastSymptoms           = []string{"fast", "quick", "rushed"}
+	slowSymptoms           = []string{"slow", "long drawdown", "stall"}
+)
+
+// Diagnose evaluates the symptoms for a coffee and suggests adjustments
+func (s *AdvisorService) Diagnose(ctx context.Context, coffeeID string, request DiagnoseRequest) (*Diagnosis, error) {
+	if len(request.Symptoms) == 0 {
+		return nil, fmt.Errorf("at least one symptom is required")
+	}
+
+	coffee, err := s.coffeeService.GetCoffee(coffeeID)
+	if err != nil {
+		return nil, err
+	}
+
+	recipe := request.Recipe
+	if len(recipe) == 0 {
+		recipe = coffee.Recipe
+	}
+	dripper := request.Dripper
+	if dripper == "" {
+		dripper = coffee.Dripper
+	}
+
+	params := parseRecipe(recipe)
+	minTime, maxTime := drawdownWindow(dripper)
+
+	diagnosis := &Diagnosis{
+		CoffeeID:         coffeeID,
+		Symptoms:         request.Symptoms,
+		TargetDrawdown:   [2]int{minTime, maxTime},
+		RecipeParameters: params,
+		Adjustments:      []Adjustment{},
+	}
+
+	symptoms := strings.ToLower(strings.Join(request.Symptoms, " "))
+
+	// Drawdown time: explicit "1:40" in the symptoms wins over the logged time
+	if seconds, ok := parseDrawdownSeconds(symptoms); ok {
+		diagnosis.DrawdownSeconds = seconds
+	} else if logged := coffee.EndTime.Minutes*60 + coffee.EndTime.Seconds; logged > 0 {
+		diagnosis.DrawdownSeconds = logged
+	}
+
+	under := containsAny(symptoms, underExtractedSymptoms...)
+	over := containsAny(symptoms, overExtractedSymptoms...)
+	uneven := containsAny(symptoms, unevenSymptoms...) || (under && over)
+	fast := containsAny(symptoms, fastSymptoms...) || (diagnosis.DrawdownSeconds > 0 && diagnosis.DrawdownSeconds < minTime)
+	slow := containsAny(symptoms, slowSymptoms...) || diagnosis.DrawdownSeconds > maxTime
+
+	switch {
+	case uneven:
+		diagnosis.Assessment = "uneven"
+		s.adviseUneven(diagnosis, slow)
+	case under:
+		diagnosis.Assessment = "under-extracted"
+		s.adviseUnder(diagnosis, params, fast)
+	case over:
+		diagnosis.Assessment = "over-extracted"
+		s.adviseOver(diagnosis, params, slow)
+	case fast:
+		diagnosis.Assessment = "under-extracted"
+		s.adviseUnder(diagnosis, params, true)
+	case slow:
+		diagnosis.Assessment = "over-extracted"
+		s.adviseOver(diagnosis, params, true)
+	}
+
+	// Strength is independent of extraction and is adjusted with the ratio
+	if containsAny(symptoms, weakSymptoms...) {
+		s.adviseStrength(diagnosis, params, true)
+	} else if containsAny(symptoms, strongSymptoms...) {
+		s.adviseStrength(diagnosis, params, false)
+	}
+
+	if diagnosis.Assessment == "" {
+		if len(diagnosis.Adjustments) > 0 {
+			diagnosis.Assessment = "strength"
+		} else if containsAny(symptoms, "balanced", "good", "great", "perfect", "sweet") {
+			diagnosis.Assessment = "balanced"
+		} else {
+			diagnosis.Assessment = "unclear"
+		}
+	}
+
+	if request.Elaborate && s.llmService.Available() && len(diagnosis.Adjustments) > 0 {
+		elaboration, err := s.llmService.Complete(ctx, s.buildDiagnosisPrompt(coffee, dripper, recipe, diagnosis))
+		if err != nil {
+			log.Printf("Advisor: LLM elaboration failed: %v", err)
+		} else {
+			diagnosis.Elaboration = elaboration
+		}
+	}
+
+	return diagnosis, nil
+}
+
+// adviseUnder suggests changes that increase extraction
+func (s *AdvisorService) adviseUnder(d *Diagnosis, params RecipeParams, fast bool) {
+	reason := "Sourness and thin body point to under-extraction"
+	if fast {
+		reason = fmt.Sprintf("The brew finished faster than the %s target window", formatWindow(d.TargetDrawdown))
+	}
+	d.addAdjustment("grind", "finer", reason)
+
+	if params.TemperatureC > 0 && params.TemperatureC < 96 {
+		d.addAdjustment("temperature", fmt.Sprintf("raise to %.0f°C", math.Min(params.TemperatureC+2, 100)),
+			"Hotter water extracts more sweetness and tempers acidity")
+	} else if params.TemperatureC == 0 {
+		d.addAdjustment("temperature", "use water just off the boil (94-96°C)",
+			"Hotter water extracts more sweetness and tempers acidity")
+	}
+
+	d.addAdjustment("agitation", "increase", "A firmer swirl or stir during the bloom improves extraction evenness")
+}
+
+// adviseOver suggests changes that reduce extraction
+func (s *AdvisorService) adviseOver(d *Diagnosis, params RecipeParams, slow bool) {
+	reason := "Bitterness and dryness point to over-extraction"
+	if slow {
+		reason = fmt.Sprintf("The brew ran longer than the %s target window", formatWindow(d.TargetDrawdown))
+	}
+	d.addAdjustment("grind", "coarser", reason)
+
+	if params.TemperatureC > 88 {
+		d.addAdjustment("temperature", fmt.Sprintf("lower to %.0f°C", params.TemperatureC-3),
+			"Cooler water pulls fewer harsh compounds")
+	}
+
+	d.addAdjustment("time", "shorten contact time", "Pour faster or reduce steep time to limit late-stage bitterness")
+}
+
+// adviseUneven suggests changes that fix channeling and stalls
+func (s *AdvisorService) adviseUneven(d *Diagnosis, slow bool) {
+	if slow {
+		d.addAdjustment("grind", "coarser", "A stalled bed usually means too many fines")
+	}
+	d.addAdjustment("agitation", "gentler, more even pours", "Uneven flavors suggest channeling through the bed")
+	d.addAdjustment("bloom", "use 2-3x the dose in water and wait 45s", "A full bloom saturates the bed evenly before the main pours")
+}
+
+// adviseStrength suggests ratio changes for weak or overpowering cups
+func (s *AdvisorService) adviseStrength(d *Diagnosis, params RecipeParams, weak bool) {
+	ratio := params.Ratio()
+
+	if weak {
+		change := "use more coffee (tighter ratio)"
+		if ratio > 0 {
+			change = fmt.Sprintf("tighten ratio from 1:%.1f to 1:%.1f", ratio, math.Max(ratio-1, 12))
+		}
+		d.addAdjustment("ratio", change, "A weak cup needs more coffee per gram of water")
+		return
+	}
+
+	change := "use less coffee (wider ratio)"
+	if ratio > 0 {
+		change = fmt.Sprintf("widen ratio from 1:%.1f to 1:%.1f", ratio, math.Min(ratio+1, 18))
+	}
+	d.addAdjustment("ratio", change, "An overpowering cup can be opened up with more water")
+}
+
+// addAdjustment appends an adjustment with the next priority
+func (d *Diagnosis) addAdjustment(variable, change, reason string) {
+	d.Adjustments = append(d.Adjustments, Adjustment{
+		Variable: variable,
+		Change:   change,
+		Reason:   reason,
+		Priority: len(d.Adjustments) + 1,
+	})
+}
+
+// formatWindow renders a drawdown window like "2:30-3:30"
+func formatWindow(window [2]int) string {
+	return fmt.Sprintf("%d:%02d-%d:%02d", window[0]/60, window[0]%60, window[1]/60, window[1]%60)
+}
+
+// buildDiagnosisPrompt asks the LLM to explain the rule-based suggestions
+func (s *AdvisorService) buildDiagnosisPrompt(coffee models.Coffee, dripper string, recipe []string, d *Diagnosis) string {
+	var suggestions []string
+	for _, adjustment := range d.Adjustments {
+		suggestions = append(suggestions, fmt.Sprintf("%d. %s: %s (%s)", adjustment.Priority, adjustment.Variable, adjustment.Change, adjustment.Reason))
+	}
+
+	return fmt.Sprintf(`You are a coffee brewing coach. A home brewer reported problems with a cup.
+
+Coffee: %s from %s (%s process, %s roast)
+Brewer: %s
+Recipe: %s
+Symptoms: %s
+Assessment: %s
+
+Suggested adjustments:
+%s
+
+Explain in 3-4 friendly sentences why these adjustments should help, in the order given. Do not add new adjustments. Respond with plain text only.`,
+		coffee.Name, coffee.Origin, coffee.ProcessingMethod, coffee.RoastLevel,
+		dripper, strings.Join(recipe, "; "), strings.Join(d.Symptoms, ", "),
+		d.Assessment, strings.Join(suggestions, "\n"))
+}
@@ -0,0 +1,208 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"go-coffee-log/models"
+	"go-coffee-log/storage"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// inviteAlphabet avoids characters that are easy to misread (0/O, 1/I)
+const inviteAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// AuthService handles users, API tokens and invitations for shared servers.
+// There is no open registration: the admin is configured with a token at
+// startup and everyone else joins by redeeming an invite.
+type AuthService struct {
+	storage   storage.UserStorage
+	inviteTTL time.Duration
+}
+
+// NewAuthService creates a new auth service
+func NewAuthService(storage storage.UserStorage, inviteTTL time.Duration) *AuthService {
+	return &AuthService{
+		storage:   storage,
+		inviteTTL: inviteTTL,
+	}
+}
+
+// InviteSummary represents an invite with its current status
+type InviteSummary struct {
+	models.Invite
+	Status string `json:"status"` // "pending", "used", "expired"
+}
+
+// EnsureAdmin makes sure an admin user exists for the configured token
+func (s *AuthService) EnsureAdmin(token string) (models.User, error) {
+	hash := hashToken(token)
+	if user, err := s.storage.GetUserByTokenHash(hash); err == nil {
+		return user, nil
+	}
+
+	admin := models.User{
+		ID:        uuid.New().String(),
+		Name:      "admin",
+		IsAdmin:   true,
+		TokenHash: hash,
+		CreatedAt: time.Now(),
+	}
+	if err := s.storage.SaveUser(admin); err != nil {
+		return models.User{}, fmt.Errorf("failed to create admin user: %w", err)
+	}
+
+	log.Printf("INFO: Created admin user %s", admin.ID)
+	return admin, nil
+}
+
+// Authenticate resolves an API token to its user
+func (s *AuthService) Authenticate(token string) (models.User, error) {
+	if token == "" {
+		return models.User{}, fmt.Errorf("missing token")
+	}
+
+	user, err := s.storage.GetUserByTokenHash(hashToken(token))
+	if err != nil {
+		return models.User{}, fmt.Errorf("invalid token")
+	}
+	return user, nil
+}
+
+// CreateInvite generates a single-use invite code. A zero ttl uses the
+// server default.
+func (s *AuthService) CreateInvite(createdBy string, ttl time.Duration) (models.Invite, error) {
+	if ttl <= 0 {
+		ttl = s.inviteTTL
+	}
+
+	code, err := generateInviteCode()
+	if err != nil {
+		return models.Invite{}, err
+	}
+
+	now := time.Now()
+	invite := models.Invite{
+		Code:      code,
+		CreatedBy: createdBy,
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	}
+	if err := s.storage.SaveInvite(invite); err != nil {
+		return models.Invite{}, err
+	}
+
+	return invite, nil
+}
+
+// ListInvites retrieves all invites with their status
+func (s *AuthService) ListInvites() ([]InviteSummary, error) {
+	invites, err := s.storage.GetAllInvites()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	summaries := []InviteSummary{}
+	for _, invite := range invites {
+		summaries = append(summaries, InviteSummary{Invite: invite, Status: invite.Status(now)})
+	}
+	return summaries, nil
+}
+
+// RevokeInvite deletes an invite so it can no longer be redeemed
+func (s *AuthService) RevokeInvite(code string) error {
+	return s.storage.DeleteInvite(normalizeInviteCode(code))
+}
+
+// RedeemInvite consumes an invite and creates a user. The returned token
+// is only shown once; only its hash is stored.
+func (s *AuthService) RedeemInvite(code, name string) (models.User, string, error) {
+	token, err := generateToken()
+	if err != nil {
+		return models.User{}, "", err
+	}
+
+	user := models.User{
+		ID:        uuid.New().String(),
+		Name:      name,
+		TokenHash: hashToken(token),
+		CreatedAt: time.Now(),
+	}
+	if err := user.Validate(); err != nil {
+		return models.User{}, "", err
+	}
+
+	// Claim the invite first so two people can't redeem the same code
+	if err := s.storage.RedeemInvite(normalizeInviteCode(code), user.ID, user.CreatedAt); err != nil {
+		return models.User{}, "", err
+	}
+
+	if err := s.storage.SaveUser(user); err != nil {
+		return models.User{}, "", err
+	}
+
+	log.Printf("INFO: User %s joined with invite %s", user.ID, normalizeInviteCode(code))
+	return user, token, nil
+}
+
+// ListUsers retrieves all users
+func (s *AuthService) ListUsers() ([]models.User, error) {
+	return s.storage.GetAllUsers()
+}
+
+// DeleteUser removes a member. Admins are configured at startup and cannot
+// be removed through the API.
+func (s *AuthService) DeleteUser(id string) error {
+	user, err := s.storage.GetUserByID(id)
+	if err != nil {
+		return err
+	}
+	if user.IsAdmin {
+		return fmt.Errorf("the admin user cannot be deleted")
+	}
+	return s.storage.DeleteUser(id)
+}
+
+// hashToken returns the hex SHA-256 of a token
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// generateToken creates a random API token
+func generateToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return "cdx_" + hex.EncodeToString(buf), nil
+}
+
+// generateInviteCode creates a random code like "K7QM-3XPA"
+func generateInviteCode() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate invite code: %w", err)
+	}
+
+	code := make([]byte, len(buf))
+	for i, b := range buf {
+		code[i] = inviteAlphabet[int(b)%len(inviteAlphabet)]
+	}
+	return string(code[:4]) + "-" + string(code[4:]), nil
+}
+
+// normalizeInviteCode accepts codes typed in lower case or without the dash
+func normalizeInviteCode(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) == 8 && !strings.Contains(code, "-") {
+		code = code[:4] + "-" + code[4:]
+	}
+	return code
+}
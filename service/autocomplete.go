@@ -0,0 +1,226 @@
+package service
+
+import (
+	"fmt"
+	"go-coffee-log/models"
+	"go-coffee-log/storage"
+	"sort"
+	"strings"
+)
+
+// Autocomplete limits
+const (
+	DefaultAutocompleteLimit = 10
+	MaxAutocompleteLimit     = 50
+)
+
+// Autocomplete match kinds, best first
+const (
+	MatchPrefix   = "prefix"   // the value or one of its words starts with the query
+	MatchContains = "contains" // the query appears inside the value
+	MatchFuzzy    = "fuzzy"    // the query is a likely misspelling of the value
+)
+
+// autocompleteFields maps the fields suggestions are offered for to the
+// coffee values they draw on
+var autocompleteFields = map[string]func(models.Coffee) string{
+	"name":              func(c models.Coffee) string { return c.Name },
+	"roaster":           func(c models.Coffee) string { return c.Roaster },
+	"origin":            func(c models.Coffee) string { return c.Origin },
+	"variety":           func(c models.Coffee) string { return c.Variety },
+	"processing_method": func(c models.Coffee) string { return c.ProcessingMethod },
+	"dripper":           func(c models.Coffee) string { return c.Dripper },
+}
+
+// AutocompleteSuggestion is a previously entered value
+type AutocompleteSuggestion struct {
+	Value    string `json:"value"`
+	Count    int    `json:"count"` // coffees using the value in any spelling
+	Match    string `json:"match,omitempty"`
+	Distance int    `json:"distance,omitempty"` // edits between query and value, for fuzzy matches
+}
+
+// AutocompleteService suggests values entered before, so spellings stay
+// consistent and statistics group them together
+type AutocompleteService struct {
+	coffeeStorage storage.CoffeeStorage
+}
+
+// NewAutocompleteService creates a new autocomplete service
+func NewAutocompleteService(coffeeStorage storage.CoffeeStorage) *AutocompleteService {
+	return &AutocompleteService{coffeeStorage: coffeeStorage}
+}
+
+// AutocompleteFieldNames lists the fields suggestions are offered for
+func AutocompleteFieldNames() []string {
+	names := make([]string, 0, len(autocompleteFields))
+	for name := range autocompleteFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Suggest returns up to limit values of field matching query. Values that
+// differ only in case or spacing count as one, shown in their most common
+// spelling. An empty query returns the most used values.
+func (s *AutocompleteService) Suggest(field, query string, limit int) ([]AutocompleteSuggestion, error) {
+	value, ok := autocompleteFields[field]
+	if !ok {
+		return nil, fmt.Errorf("unknown field %q; use one of %s", field, strings.Join(AutocompleteFieldNames(), ", "))
+	}
+	if limit <= 0 {
+		limit = DefaultAutocompleteLimit
+	}
+	limit = min(limit, MaxAutocompleteLimit)
+
+	coffees, err := s.coffeeStorage.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load coffees: %w", err)
+	}
+
+	// Group spellings by normalized value, counting each spelling
+	spellings := make(map[string]map[string]int)
+	for _, coffee := range coffees {
+		raw := strings.Join(strings.Fields(value(coffee)), " ")
+		if raw == "" {
+			continue
+		}
+		key := strings.ToLower(raw)
+		if spellings[key] == nil {
+			spellings[key] = make(map[string]int)
+		}
+		spellings[key][raw]++
+	}
+
+	query = strings.ToLower(strings.Join(strings.Fields(query), " "))
+	type candidate struct {
+		suggestion AutocompleteSuggestion
+		rank       int
+	}
+	var candidates []candidate
+	for key, variants := range spellings {
+		suggestion := AutocompleteSuggestion{Value: mostCommonSpelling(variants)}
+		for _, count := range variants {
+			suggestion.Count += count
+		}
+
+		rank := 0
+		if query != "" {
+			suggestion.Match, suggestion.Distance = matchAutocomplete(key, query)
+			switch suggestion.Match {
+			case MatchPrefix:
+				rank = 0
+			case MatchContains:
+				rank = 1
+			case MatchFuzzy:
+				rank = 2
+			default:
+				continue
+			}
+		}
+		candidates = append(candidates, candidate{suggestion: suggestion, rank: rank})
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.rank != b.rank {
+			return a.rank < b.rank
+		}
+		if a.suggestion.Distance != b.suggestion.Distance {
+			return a.suggestion.Distance < b.suggestion.Distance
+		}
+		if a.suggestion.Count != b.suggestion.Count {
+			return a.suggestion.Count > b.suggestion.Count
+		}
+		return a.suggestion.Value < b.suggestion.Value
+	})
+
+	suggestions := []AutocompleteSuggestion{}
+	for i := 0; i < len(candidates) && i < limit; i++ {
+		suggestions = append(suggestions, candidates[i].suggestion)
+	}
+	return suggestions, nil
+}
+
+// mostCommonSpelling picks the most used spelling, breaking ties
+// alphabetically so results are stable
+func mostCommonSpelling(variants map[string]int) string {
+	best, bestCount := "", 0
+	for spelling, count := range variants {
+		if count > bestCount || count == bestCount && spelling < best {
+			best, bestCount = spelling, count
+		}
+	}
+	return best
+}
+
+// matchAutocomplete classifies how a normalized value matches a normalized
+// query, returning "" when it does not
+func matchAutocomplete(value, query string) (string, int) {
+	if strings.HasPrefix(value, query) {
+		return MatchPrefix, 0
+	}
+	for _, word := range strings.Fields(value) {
+		if strings.HasPrefix(word, query) {
+			return MatchPrefix, 0
+		}
+	}
+	if strings.Contains(value, query) {
+		return MatchContains, 0
+	}
+
+	allowed := allowedTypos(query)
+	if allowed == 0 {
+		return "", 0
+	}
+	// Compare with the whole value for finished words and with a same-length
+	// start of it for words still being typed
+	queryRunes, valueRunes := []rune(query), []rune(value)
+	distance := editDistance(queryRunes, valueRunes)
+	if len(valueRunes) > len(queryRunes) {
+		distance = min(distance, editDistance(queryRunes, valueRunes[:len(queryRunes)]))
+	}
+	if distance <= allowed {
+		return MatchFuzzy, distance
+	}
+	return "", 0
+}
+
+// allowedTypos is how many edits a query of this length may be from a value
+func allowedTypos(query string) int {
+	switch n := len([]rune(query)); {
+	case n < 3:
+		return 0
+	case n < 6:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// editDistance is the Damerau-Levenshtein (optimal string alignment)
+// distance, so a swapped pair of letters counts as one typo
+func editDistance(a, b []rune) int {
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(min(prev[j]+1, cur[j-1]+1), prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-coffee-log/storage"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// backupPrefix starts the name of every backup file, so pruning never
+// touches other files in the directory
+const backupPrefix = "coffee-dex-backup-"
+
+// BackupService writes the data export archive to a directory, keeping
+// the newest few. Archives are encrypted when a data key is configured and
+// can be restored with POST /admin/import-instance.
+type BackupService struct {
+	accountService *AccountService
+	dir            string
+	keep           int
+	cipher         *storage.Cipher // optional
+}
+
+// NewBackupService creates a backup service writing to dir and keeping
+// the newest keep archives (0 = keep all)
+func NewBackupService(accountService *AccountService, dir string, keep int, cipher *storage.Cipher) *BackupService {
+	return &BackupService{
+		accountService: accountService,
+		dir:            dir,
+		keep:           keep,
+		cipher:         cipher,
+	}
+}
+
+// Backup writes one archive and prunes old ones. Backups are made for no
+// user, so only servers without authentication get badges and safari
+// attempts in them.
+func (s *BackupService) Backup() error {
+	export, err := s.accountService.Export("")
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode backup: %w", err)
+	}
+
+	name := backupPrefix + export.ExportedAt.Format("20060102-150405") + ".json"
+	if s.cipher != nil {
+		if data, err = s.cipher.Seal(data); err != nil {
+			return fmt.Errorf("failed to encrypt backup: %w", err)
+		}
+		name += ".enc"
+	}
+
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(s.dir, ".backup-*")
+	if err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(s.dir, name)); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+	log.Printf("INFO: Wrote backup %s (%d coffees)", name, len(export.Coffees))
+
+	return s.prune()
+}
+
+// prune deletes all but the newest keep backups. The timestamp in the
+// names sorts them oldest first.
+func (s *BackupService) prune() error {
+	if s.keep == 0 {
+		return nil
+	}
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return fmt.Errorf("failed to list backups: %w", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasPrefix(entry.Name(), backupPrefix) {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	for len(names) > s.keep {
+		if err := os.Remove(filepath.Join(s.dir, names[0])); err != nil {
+			return fmt.Errorf("failed to prune backup: %w", err)
+		}
+		names = names[1:]
+	}
+	return nil
+}
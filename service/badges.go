@@ -0,0 +1,225 @@
+package service
+
+import (
+	"fmt"
+	"go-coffee-log/models"
+	"go-coffee-log/storage"
+	"log"
+	"strings"
+	"time"
+)
+
+// DefaultBadgeCatches is how many catches of a gym's type one brewer needs
+// for its badge
+const DefaultBadgeCatches = 8
+
+// gymBadge is one of the Kanto gym badges and the type it is awarded for
+type gymBadge struct {
+	ID   string
+	Name string
+	Type string
+}
+
+// gymBadges are the eight Kanto gym badges, in gym order
+var gymBadges = []gymBadge{
+	{"boulder", "Boulder Badge", "Rock"},
+	{"cascade", "Cascade Badge", "Water"},
+	{"thunder", "Thunder Badge", "Electric"},
+	{"rainbow", "Rainbow Badge", "Grass"},
+	{"soul", "Soul Badge", "Poison"},
+	{"marsh", "Marsh Badge", "Psychic"},
+	{"volcano", "Volcano Badge", "Fire"},
+	{"earth", "Earth Badge", "Ground"},
+}
+
+// BadgeProgress is how close the collection is to one gym badge: the
+// brewer with the most catches of its type, and how many it has
+type BadgeProgress struct {
+	Badge      string `json:"badge"`
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	Earned     bool   `json:"earned"`
+	BrewerID   string `json:"brewer_id,omitempty"`
+	BrewerName string `json:"brewer_name,omitempty"`
+	Catches    int    `json:"catches"`
+	Required   int    `json:"required"`
+}
+
+// BadgeList is a user's badge case
+type BadgeList struct {
+	Earned   []models.Badge  `json:"earned"`
+	Progress []BadgeProgress `json:"progress"` // every badge, earned or not
+}
+
+// BadgeService awards gym badges when a brewer has been used for enough
+// coffees of one Pokemon type, e.g. 8 Grass-type catches on the V60 earn
+// the Rainbow Badge. A coffee's brewer is the one named by its dripper.
+type BadgeService struct {
+	storage        storage.BadgeStorage
+	coffeeService  *CoffeeService
+	pokemonStorage storage.PokemonStorage
+	brewerStorage  storage.BrewerStorage
+	bus            *EventBus
+	required       int
+}
+
+// NewBadgeService creates a badge service; required is the catches of a
+// type one brewer needs for the badge (DefaultBadgeCatches if not positive)
+func NewBadgeService(
+	storage storage.BadgeStorage,
+	coffeeService *CoffeeService,
+	pokemonStorage storage.PokemonStorage,
+	brewerStorage storage.BrewerStorage,
+	bus *EventBus,
+	required int,
+) *BadgeService {
+	if required <= 0 {
+		required = DefaultBadgeCatches
+	}
+	return &BadgeService{
+		storage:        storage,
+		coffeeService:  coffeeService,
+		pokemonStorage: pokemonStorage,
+		brewerStorage:  brewerStorage,
+		bus:            bus,
+		required:       required,
+	}
+}
+
+// List returns the badges a user earned and the progress toward each
+func (s *BadgeService) List(userID string) (BadgeList, error) {
+	earned, err := s.storage.GetBadges(userID)
+	if err != nil {
+		return BadgeList{}, err
+	}
+	progress, err := s.progress()
+	if err != nil {
+		return BadgeList{}, err
+	}
+
+	held := make(map[string]bool, len(earned))
+	for _, badge := range earned {
+		held[badge.Badge] = true
+	}
+	for i := range progress {
+		progress[i].Earned = held[progress[i].Badge]
+	}
+	return BadgeList{Earned: earned, Progress: progress}, nil
+}
+
+// Award gives a user every badge the collection now qualifies for and
+// returns the ones they did not hold yet
+func (s *BadgeService) Award(userID string) ([]models.Badge, error) {
+	progress, err := s.progress()
+	if err != nil {
+		return nil, err
+	}
+
+	awarded := []models.Badge{}
+	for _, p := range progress {
+		if p.Catches < p.Required {
+			continue
+		}
+		badge := models.Badge{
+			UserID:     userID,
+			Badge:      p.Badge,
+			Name:       p.Name,
+			Type:       p.Type,
+			BrewerID:   p.BrewerID,
+			BrewerName: p.BrewerName,
+			Catches:    p.Catches,
+			AwardedAt:  time.Now(),
+		}
+		added, err := s.storage.AwardBadge(badge)
+		if err != nil {
+			return awarded, err
+		}
+		if !added {
+			continue
+		}
+
+		log.Printf("INFO: Awarded the %s for %d %s-type catches on %s", badge.Name, badge.Catches, badge.Type, badge.BrewerName)
+		s.bus.Publish(Event{
+			Type:    "badge.earned",
+			Message: fmt.Sprintf("Earned the %s: %d %s-type catches on the %s", badge.Name, badge.Catches, badge.Type, badge.BrewerName),
+			Data: map[string]interface{}{
+				"badge":   badge.Badge,
+				"brewer":  badge.BrewerName,
+				"catches": badge.Catches,
+			},
+		})
+		awarded = append(awarded, badge)
+	}
+	return awarded, nil
+}
+
+// progress finds, for every gym badge, the brewer with the most coffees
+// caught as a Pokemon of the badge's type. A coffee caught in several dex
+// profiles counts once per type.
+func (s *BadgeService) progress() ([]BadgeProgress, error) {
+	brewers, err := s.brewerStorage.GetAllBrewers()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get brewers: %w", err)
+	}
+	coffees, err := s.coffeeService.ListCoffees()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get coffees: %w", err)
+	}
+	pokemon, err := s.pokemonStorage.GetAllPokemon()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Pokemon: %w", err)
+	}
+	catches, err := s.pokemonStorage.GetAllCoffeePokemon()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get catches: %w", err)
+	}
+
+	brewerOf := make(map[string]int) // coffee ID -> index into brewers
+	for _, coffee := range coffees {
+		dripper := strings.TrimSpace(coffee.Dripper)
+		for i, brewer := range brewers {
+			if dripper != "" && strings.EqualFold(dripper, brewer.Name) {
+				brewerOf[coffee.ID] = i
+				break
+			}
+		}
+	}
+
+	typesOf := make(map[int][]string, len(pokemon))
+	for _, p := range pokemon {
+		typesOf[p.ID] = strings.Split(p.Type, "/")
+	}
+
+	// brewer index -> type -> coffee IDs
+	counted := make(map[int]map[string]map[string]bool)
+	for _, catch := range catches {
+		brewer, ok := brewerOf[catch.CoffeeID]
+		if !ok {
+			continue
+		}
+		if counted[brewer] == nil {
+			counted[brewer] = make(map[string]map[string]bool)
+		}
+		for _, pokemonType := range typesOf[catch.PokemonID] {
+			pokemonType = strings.TrimSpace(pokemonType)
+			if counted[brewer][pokemonType] == nil {
+				counted[brewer][pokemonType] = make(map[string]bool)
+			}
+			counted[brewer][pokemonType][catch.CoffeeID] = true
+		}
+	}
+
+	progress := make([]BadgeProgress, 0, len(gymBadges))
+	for _, gym := range gymBadges {
+		p := BadgeProgress{Badge: gym.ID, Name: gym.Name, Type: gym.Type, Required: s.required}
+		for i, brewer := range brewers {
+			if n := len(counted[i][gym.Type]); n > p.Catches {
+				p.Catches = n
+				p.BrewerID = brewer.ID
+				p.BrewerName = brewer.Name
+			}
+		}
+		progress = append(progress, p)
+	}
+	return progress, nil
+}
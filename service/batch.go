@@ -0,0 +1,376 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-coffee-log/models"
+	"go-coffee-log/storage"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxBatchOperations caps the operations in one batch
+const MaxBatchOperations = 100
+
+// Batch operations
+const (
+	BatchCreate  = "create"
+	BatchUpdate  = "update"
+	BatchPatch   = "patch"
+	BatchDelete  = "delete"
+	BatchAddTags = "add_tags"
+)
+
+// BatchOperation is one change in a batch. A created coffee can be given a
+// ref so later operations in the same batch can name it before it has an ID.
+type BatchOperation struct {
+	Op     string          `json:"op"`
+	ID     string          `json:"id,omitempty"`     // the coffee to change
+	Ref    string          `json:"ref,omitempty"`    // on create: names the new coffee; otherwise: names the coffee to change
+	Coffee *models.Coffee  `json:"coffee,omitempty"` // create, update
+	Patch  json.RawMessage `json:"patch,omitempty"`  // patch, as a JSON merge patch
+	Tags   []string        `json:"tags,omitempty"`   // add_tags
+}
+
+// BatchOperationResult is the outcome of one operation, with an HTTP-style
+// status as if it had been sent on its own
+type BatchOperationResult struct {
+	Index  int            `json:"index"`
+	Op     string         `json:"op"`
+	Status int            `json:"status"`
+	ID     string         `json:"id,omitempty"`
+	Ref    string         `json:"ref,omitempty"`
+	Coffee *models.Coffee `json:"coffee,omitempty"`
+	Error  string         `json:"error,omitempty"`
+}
+
+// BatchReport is the outcome of a batch. Either every operation was applied
+// or none was; results explain which operation stopped it.
+type BatchReport struct {
+	DryRun  bool                   `json:"dry_run"`
+	Applied bool                   `json:"applied"`
+	Results []BatchOperationResult `json:"results"`
+}
+
+// BatchService applies several coffee changes all or nothing, to save sync
+// clients round trips
+type BatchService struct {
+	coffeeService *CoffeeService
+	batcher       storage.CoffeeBatcher
+}
+
+// NewBatchService creates a new batch service
+func NewBatchService(coffeeService *CoffeeService, batcher storage.CoffeeBatcher) *BatchService {
+	return &BatchService{
+		coffeeService: coffeeService,
+		batcher:       batcher,
+	}
+}
+
+// plannedOperation is a validated operation, ready to apply
+type plannedOperation struct {
+	BatchOperation
+	coffee   models.Coffee // the coffee as the operation leaves it
+	previous models.Coffee // update, patch, delete, add_tags: the coffee before
+}
+
+// batchView tracks coffees as earlier operations in a batch leave them
+type batchView struct {
+	coffees *CoffeeService
+	changed map[string]*models.Coffee // nil once deleted
+	refs    map[string]bool
+	adding  map[string]int // coffees created per user, for their quota
+}
+
+// Apply validates every operation and, if all are valid, stores them in
+// one storage transaction. Live clients, OnCreate listeners and the
+// deletion journal hear of the changes only once the transaction commits.
+// A dry run stops after validating.
+func (s *BatchService) Apply(operations []BatchOperation, dryRun bool) (BatchReport, error) {
+	if len(operations) == 0 {
+		return BatchReport{}, fmt.Errorf("invalid batch: no operations")
+	}
+	if len(operations) > MaxBatchOperations {
+		return BatchReport{}, fmt.Errorf("invalid batch: at most %d operations are allowed", MaxBatchOperations)
+	}
+
+	report := BatchReport{DryRun: dryRun, Results: make([]BatchOperationResult, len(operations))}
+	view := &batchView{
+		coffees: s.coffeeService,
+		changed: make(map[string]*models.Coffee),
+		refs:    make(map[string]bool),
+		adding:  make(map[string]int),
+	}
+
+	planned := make([]plannedOperation, len(operations))
+	failed := false
+	for i, operation := range operations {
+		report.Results[i] = BatchOperationResult{Index: i, Op: operation.Op, ID: operation.ID, Ref: operation.Ref}
+		if failed {
+			report.Results[i].Status = http.StatusFailedDependency
+			continue
+		}
+		plan, err := s.plan(view, operation)
+		if err != nil {
+			report.Results[i].Status = batchErrorStatus(err)
+			report.Results[i].Error = err.Error()
+			failed = true
+			continue
+		}
+		planned[i] = plan
+	}
+	if failed {
+		for i := range report.Results {
+			if report.Results[i].Status == 0 {
+				report.Results[i].Status = http.StatusFailedDependency
+			}
+		}
+		return report, nil
+	}
+
+	if dryRun {
+		report.fill(planned)
+		return report, nil
+	}
+
+	writes := prepareWrites(planned)
+	released := s.releasedCatches(planned)
+	if err := s.batcher.ApplyCoffeeWrites(writes); err != nil {
+		return BatchReport{}, err
+	}
+	s.publish(planned, released)
+
+	report.fill(planned)
+	report.Applied = true
+	return report, nil
+}
+
+// prepareWrites gives created coffees their IDs and turns the planned
+// operations into storage writes, resolving refs to the new IDs
+func prepareWrites(planned []plannedOperation) []storage.CoffeeWrite {
+	now := time.Now()
+	refIDs := make(map[string]string)
+	writes := make([]storage.CoffeeWrite, len(planned))
+	for i := range planned {
+		plan := &planned[i]
+		if ref, ok := strings.CutPrefix(plan.ID, "ref:"); ok {
+			plan.ID = refIDs[ref]
+		}
+
+		switch plan.Op {
+		case BatchCreate:
+			plan.coffee.ID = uuid.New().String()
+			plan.coffee.CreatedAt = now
+			plan.coffee.UpdatedAt = now
+			plan.ID = plan.coffee.ID
+			if plan.Ref != "" {
+				refIDs[plan.Ref] = plan.ID
+			}
+			writes[i] = storage.CoffeeWrite{Kind: storage.CoffeeWriteSave, Coffee: plan.coffee}
+		case BatchUpdate, BatchPatch, BatchAddTags:
+			plan.coffee.ID = plan.ID
+			plan.coffee.UpdatedAt = now
+			writes[i] = storage.CoffeeWrite{Kind: storage.CoffeeWriteUpdate, ID: plan.ID, Coffee: plan.coffee}
+		case BatchDelete:
+			writes[i] = storage.CoffeeWrite{Kind: storage.CoffeeWriteDelete, ID: plan.ID}
+		}
+	}
+	return writes
+}
+
+// releasedCatches looks up the Pokemon catches of the stored coffees the
+// batch deletes, only to log them once they are free again
+func (s *BatchService) releasedCatches(planned []plannedOperation) map[string][]models.CoffeePokemon {
+	released := make(map[string][]models.CoffeePokemon)
+	for _, plan := range planned {
+		if plan.Op != BatchDelete || plan.previous.ID == "" {
+			continue
+		}
+		catches, err := s.coffeeService.catchesOf(plan.ID)
+		if err != nil {
+			log.Printf("WARNING: Failed to look up Pokemon of coffee %s before deleting it: %v", plan.ID, err)
+			continue
+		}
+		released[plan.ID] = catches
+	}
+	return released
+}
+
+// publish announces a committed batch, operation by operation, the way
+// the single-coffee endpoints do
+func (s *BatchService) publish(planned []plannedOperation, released map[string][]models.CoffeePokemon) {
+	for _, plan := range planned {
+		switch plan.Op {
+		case BatchCreate:
+			s.coffeeService.live.Publish(ResourceCoffee, ChangeCreated, plan.ID, plan.coffee)
+			for _, listener := range s.coffeeService.onCreate {
+				listener(plan.coffee)
+			}
+		case BatchUpdate, BatchPatch, BatchAddTags:
+			s.coffeeService.live.Publish(ResourceCoffee, ChangeUpdated, plan.ID, plan.coffee)
+		case BatchDelete:
+			for _, catch := range released[plan.ID] {
+				log.Printf("INFO: Released %s (#%d) in the %s dex with deleted coffee %s", catch.PokemonName, catch.PokemonID, catch.Dex, plan.ID)
+			}
+			s.coffeeService.recordDeletion(plan.ID)
+		}
+	}
+}
+
+// fill records the results of successful operations
+func (r *BatchReport) fill(planned []plannedOperation) {
+	for i, plan := range planned {
+		result := &r.Results[i]
+		if !strings.HasPrefix(plan.ID, "ref:") {
+			result.ID = plan.ID
+		}
+		switch plan.Op {
+		case BatchCreate:
+			result.Status = http.StatusCreated
+			result.Coffee = &planned[i].coffee
+		case BatchUpdate, BatchPatch, BatchAddTags:
+			result.Status = http.StatusOK
+			result.Coffee = &planned[i].coffee
+		case BatchDelete:
+			result.Status = http.StatusNoContent
+		}
+	}
+}
+
+// plan validates an operation against the coffees as the earlier
+// operations leave them
+func (s *BatchService) plan(view *batchView, operation BatchOperation) (plannedOperation, error) {
+	plan := plannedOperation{BatchOperation: operation}
+	switch operation.Op {
+	case BatchCreate:
+		if operation.Coffee == nil {
+			return plan, fmt.Errorf("invalid operation: coffee is required")
+		}
+		if operation.ID != "" {
+			return plan, fmt.Errorf("invalid operation: create takes a ref, not an id")
+		}
+		if operation.Ref != "" && view.refs[operation.Ref] {
+			return plan, fmt.Errorf("invalid operation: ref %q is already used", operation.Ref)
+		}
+		preview, err := s.coffeeService.PreviewCreate(*operation.Coffee)
+		if err != nil {
+			return plan, err
+		}
+		if quotas := s.coffeeService.quotas; quotas != nil {
+			view.adding[operation.Coffee.AddedBy]++
+			if err := quotas.CheckCoffees(operation.Coffee.AddedBy, view.adding[operation.Coffee.AddedBy]); err != nil {
+				return plan, err
+			}
+		}
+		plan.coffee = *preview.Coffee
+		if operation.Ref != "" {
+			view.refs[operation.Ref] = true
+			view.changed["ref:"+operation.Ref] = &plan.coffee
+		}
+		return plan, nil
+
+	case BatchUpdate, BatchPatch, BatchDelete, BatchAddTags:
+		key, err := view.key(operation)
+		if err != nil {
+			return plan, err
+		}
+		current, err := view.get(key)
+		if err != nil {
+			return plan, err
+		}
+		plan.ID = key
+		plan.previous = current
+
+		switch operation.Op {
+		case BatchUpdate:
+			if operation.Coffee == nil {
+				return plan, fmt.Errorf("invalid operation: coffee is required")
+			}
+			coffee := *operation.Coffee
+			coffee.ID = current.ID
+			coffee.CreatedAt = current.CreatedAt
+			coffee.DialIn = nil
+			if err := coffee.Validate(); err != nil {
+				return plan, err
+			}
+			plan.coffee = coffee
+		case BatchPatch:
+			if len(operation.Patch) == 0 {
+				return plan, fmt.Errorf("invalid operation: patch is required")
+			}
+			patched, err := patchCoffee(current, operation.Patch)
+			if err != nil {
+				return plan, err
+			}
+			plan.coffee = patched
+		case BatchAddTags:
+			if len(operation.Tags) == 0 {
+				return plan, fmt.Errorf("invalid operation: tags are required")
+			}
+			coffee := current
+			coffee.Tags = append(append([]string{}, current.Tags...), operation.Tags...)
+			if err := coffee.Validate(); err != nil {
+				return plan, err
+			}
+			plan.coffee = coffee
+		case BatchDelete:
+			view.changed[key] = nil
+			return plan, nil
+		}
+		// Check the brewer and lineage as UpdateCoffee does
+		preview, err := s.coffeeService.previewChange(current, plan.coffee)
+		if err != nil {
+			return plan, err
+		}
+		plan.coffee = *preview.Coffee
+		view.changed[key] = &plan.coffee
+		return plan, nil
+	}
+	return plan, fmt.Errorf("invalid operation: unknown op %q", operation.Op)
+}
+
+// key names the coffee an operation changes: its ID, or "ref:" and the ref
+// of a coffee created earlier in the batch
+func (v *batchView) key(operation BatchOperation) (string, error) {
+	switch {
+	case operation.ID != "" && operation.Ref != "":
+		return "", fmt.Errorf("invalid operation: give an id or a ref, not both")
+	case operation.Ref != "":
+		if !v.refs[operation.Ref] {
+			return "", fmt.Errorf("invalid operation: ref %q is not created earlier in the batch", operation.Ref)
+		}
+		return "ref:" + operation.Ref, nil
+	case operation.ID != "":
+		return operation.ID, nil
+	}
+	return "", fmt.Errorf("invalid operation: id is required")
+}
+
+// get returns a coffee as the earlier operations leave it
+func (v *batchView) get(key string) (models.Coffee, error) {
+	if coffee, ok := v.changed[key]; ok {
+		if coffee == nil {
+			return models.Coffee{}, fmt.Errorf("coffee not found")
+		}
+		return *coffee, nil
+	}
+	return v.coffees.GetCoffee(key)
+}
+
+// batchErrorStatus picks the HTTP-style status of a failed operation
+func batchErrorStatus(err error) int {
+	message := err.Error()
+	switch {
+	case strings.Contains(message, "not found"):
+		return http.StatusNotFound
+	case strings.Contains(message, "quota exceeded"):
+		return http.StatusPaymentRequired
+	case strings.Contains(message, "failed to"):
+		return http.StatusInternalServerError
+	}
+	return http.StatusBadRequest
+}
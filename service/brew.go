@@ -0,0 +1,345 @@
+package service
+
+import (
+	"fmt"
+	"go-coffee-log/models"
+	"go-coffee-log/storage"
+	"log"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BrewService handles business logic for brew sessions and grind dial-in
+type BrewService struct {
+	storage       storage.BrewStorage
+	coffeeService *CoffeeService
+	userStorage   storage.UserStorage      // optional, names brewers in per-person statistics
+	tombstones    storage.TombstoneStorage // optional, journals deletions for sync
+}
+
+// NewBrewService creates a new brew service
+func NewBrewService(storage storage.BrewStorage, coffeeService *CoffeeService) *BrewService {
+	return &BrewService{
+		storage:       storage,
+		coffeeService: coffeeService,
+	}
+}
+
+// SetUserStorage enables per-person statistics to show user names
+func (s *BrewService) SetUserStorage(userStorage storage.UserStorage) {
+	s.userStorage = userStorage
+}
+
+// SetTombstones records a tombstone for every deleted brew
+func (s *BrewService) SetTombstones(tombstones storage.TombstoneStorage) {
+	s.tombstones = tombstones
+}
+
+// LogBrew records a brewing attempt for a coffee
+func (s *BrewService) LogBrew(coffeeID string, brew models.Brew) (models.Brew, error) {
+	brew, err := s.newBrew(coffeeID, brew)
+	if err != nil {
+		return models.Brew{}, err
+	}
+
+	if err := s.storage.SaveBrew(brew); err != nil {
+		return models.Brew{}, err
+	}
+
+	return brew, nil
+}
+
+// PreviewLogBrew validates a brew like LogBrew, without saving it
+func (s *BrewService) PreviewLogBrew(coffeeID string, brew models.Brew) (DryRun, error) {
+	brew, err := s.newBrew(coffeeID, brew)
+	if err != nil {
+		return DryRun{}, err
+	}
+	return previewCreate(brew), nil
+}
+
+// newBrew builds and validates a brew of a stored coffee
+func (s *BrewService) newBrew(coffeeID string, brew models.Brew) (models.Brew, error) {
+	if _, err := s.coffeeService.GetCoffee(coffeeID); err != nil {
+		return models.Brew{}, err
+	}
+
+	brew.ID = uuid.New().String()
+	brew.CoffeeID = coffeeID
+	brew.CreatedAt = time.Now()
+
+	brew.ComputeExtractionYield()
+	if err := brew.Validate(); err != nil {
+		return models.Brew{}, err
+	}
+	return brew, nil
+}
+
+// GetBrews retrieves all brews of a coffee, oldest first
+func (s *BrewService) GetBrews(coffeeID string) ([]models.Brew, error) {
+	if _, err := s.coffeeService.GetCoffee(coffeeID); err != nil {
+		return nil, err
+	}
+	return s.storage.GetBrewsByCoffee(coffeeID)
+}
+
+// DeleteBrew removes a brew, making sure it belongs to the coffee
+func (s *BrewService) DeleteBrew(coffeeID, brewID string) error {
+	if _, err := s.findBrew(coffeeID, brewID); err != nil {
+		return err
+	}
+	if err := s.storage.DeleteBrew(brewID); err != nil {
+		return err
+	}
+	if s.tombstones != nil {
+		tombstone := models.Tombstone{EntityType: models.EntityBrew, EntityID: brewID, DeletedAt: time.Now()}
+		if err := s.tombstones.RecordDeletion(tombstone); err != nil {
+			log.Printf("ERROR: Failed to record deletion of brew %s: %v", brewID, err)
+		}
+	}
+	return nil
+}
+
+// PreviewDeleteBrew reports the brew DeleteBrew would remove
+func (s *BrewService) PreviewDeleteBrew(coffeeID, brewID string) (DryRun, error) {
+	brew, err := s.findBrew(coffeeID, brewID)
+	if err != nil {
+		return DryRun{}, err
+	}
+	return previewDelete(brew), nil
+}
+
+// findBrew returns a brew of a coffee
+func (s *BrewService) findBrew(coffeeID, brewID string) (models.Brew, error) {
+	brews, err := s.storage.GetBrewsByCoffee(coffeeID)
+	if err != nil {
+		return models.Brew{}, err
+	}
+	for _, brew := range brews {
+		if brew.ID == brewID {
+			return brew, nil
+		}
+	}
+	return models.Brew{}, fmt.Errorf("brew not found")
+}
+
+// GetDialIn computes the dial-in status of a coffee from its brews
+func (s *BrewService) GetDialIn(coffeeID string) (*models.DialInStatus, error) {
+	coffee, err := s.coffeeService.GetCoffee(coffeeID)
+	if err != nil {
+		return nil, err
+	}
+
+	brews, err := s.storage.GetBrewsByCoffee(coffeeID)
+	if err != nil {
+		return nil, err
+	}
+
+	return computeDialIn(brews, coffee.Dripper), nil
+}
+
+// computeDialIn suggests the next grind setting. Under-extracted brews were
+// too coarse and over-extracted brews too fine, so once both have been seen
+// the ideal setting is bracketed and the suggestion bisects the bracket.
+// A coffee is dialed in when its last two brews were balanced within one
+// grind step of each other.
+func computeDialIn(brews []models.Brew, dripper string) *models.DialInStatus {
+	status := &models.DialInStatus{
+		Attempts:  len(brews),
+		Direction: "none",
+	}
+	if len(brews) == 0 {
+		status.Reason = "No brews logged yet; log an attempt to start dialing in"
+		return status
+	}
+
+	step := grindStep(brews)
+	last := brews[len(brews)-1]
+	status.LastSetting = last.GrindSetting
+
+	if len(brews) >= 2 {
+		previous := brews[len(brews)-2]
+		if previous.Outcome == "balanced" && last.Outcome == "balanced" &&
+			math.Abs(previous.GrindSetting-last.GrindSetting) <= step {
+			setting := roundSetting((previous.GrindSetting + last.GrindSetting) / 2)
+			status.DialedIn = true
+			status.DialedInSetting = setting
+			status.SuggestedSetting = setting
+			status.Direction = "hold"
+			status.Reason = fmt.Sprintf("The last two brews were balanced around %s", formatSetting(setting))
+			return status
+		}
+	}
+
+	// Bracket: the finest setting that was still too fine, the coarsest that was still too coarse
+	tooFine, tooCoarse := math.Inf(-1), math.Inf(1)
+	for _, brew := range brews {
+		switch brew.Outcome {
+		case "over":
+			tooFine = math.Max(tooFine, brew.GrindSetting)
+		case "under":
+			tooCoarse = math.Min(tooCoarse, brew.GrindSetting)
+		}
+	}
+
+	switch last.Outcome {
+	case "under":
+		status.Direction = "finer"
+		status.SuggestedSetting = last.GrindSetting - step
+		status.Reason = "The last brew was under-extracted; grind finer"
+		if !math.IsInf(tooFine, -1) && tooFine < last.GrindSetting {
+			status.SuggestedSetting = (tooFine + last.GrindSetting) / 2
+			status.Reason = fmt.Sprintf("The last brew was under-extracted and %s was over-extracted; try halfway", formatSetting(tooFine))
+		}
+	case "over":
+		status.Direction = "coarser"
+		status.SuggestedSetting = last.GrindSetting + step
+		status.Reason = "The last brew was over-extracted; grind coarser"
+		if !math.IsInf(tooCoarse, 1) && tooCoarse > last.GrindSetting {
+			status.SuggestedSetting = (tooCoarse + last.GrindSetting) / 2
+			status.Reason = fmt.Sprintf("The last brew was over-extracted and %s was under-extracted; try halfway", formatSetting(tooCoarse))
+		}
+	default:
+		status.Direction = "hold"
+		status.SuggestedSetting = last.GrindSetting
+		status.Reason = "The last brew was balanced; repeat the setting to confirm"
+	}
+
+	if seconds := last.BrewTime.Minutes*60 + last.BrewTime.Seconds; seconds > 0 {
+		minTime, maxTime := drawdownWindow(dripper)
+		if seconds < minTime || seconds > maxTime {
+			status.Reason += fmt.Sprintf(" (brew time %d:%02d is outside the %s window)",
+				seconds/60, seconds%60, formatWindow([2]int{minTime, maxTime}))
+		}
+	}
+
+	status.SuggestedSetting = math.Max(roundSetting(status.SuggestedSetting), 0)
+	return status
+}
+
+// grindStep infers the grinder's step size from the smallest change between
+// logged settings, defaulting to one click
+func grindStep(brews []models.Brew) float64 {
+	step := math.Inf(1)
+	for i := range brews {
+		for j := i + 1; j < len(brews); j++ {
+			if diff := math.Abs(brews[i].GrindSetting - brews[j].GrindSetting); diff > 0 && diff < step {
+				step = diff
+			}
+		}
+	}
+	if math.IsInf(step, 1) {
+		return 1
+	}
+	return step
+}
+
+// roundSetting rounds a grind setting to one decimal place
+func roundSetting(setting float64) float64 {
+	return math.Round(setting*10) / 10
+}
+
+// formatSetting renders a grind setting without trailing zeros
+func formatSetting(setting float64) string {
+	return fmt.Sprintf("%g", roundSetting(setting))
+}
+
+// Brewing control chart targets (SCA): 18-22% extraction, 1.15-1.35% TDS
+var (
+	idealExtraction = [2]float64{18, 22}
+	idealTDS        = [2]float64{1.15, 1.35}
+)
+
+// ControlChartPoint represents a brew plotted on the brewing control chart
+type ControlChartPoint struct {
+	BrewID          string  `json:"brew_id"`
+	CoffeeID        string  `json:"coffee_id"`
+	CoffeeName      string  `json:"coffee_name"`
+	TDS             float64 `json:"tds"`
+	ExtractionYield float64 `json:"extraction_yield"`
+	Strength        string  `json:"strength"` // "weak", "ideal", "strong"
+	Outcome         string  `json:"outcome"`
+	Rating          int     `json:"rating"`
+}
+
+// ControlChart represents brews grouped into extraction zones
+type ControlChart struct {
+	IdealExtraction [2]float64                     `json:"ideal_extraction"`
+	IdealTDS        [2]float64                     `json:"ideal_tds"`
+	Zones           map[string][]ControlChartPoint `json:"zones"` // "under", "ideal", "over"
+	TotalBrews      int                            `json:"total_brews"`
+	MeasuredBrews   int                            `json:"measured_brews"`
+}
+
+// GetControlChart groups measured brews into under/ideal/over-extracted
+// zones, optionally limited to one coffee. Brews without a TDS or
+// extraction yield are counted but not plotted.
+func (s *BrewService) GetControlChart(coffeeID string) (*ControlChart, error) {
+	var brews []models.Brew
+	var err error
+	if coffeeID != "" {
+		brews, err = s.GetBrews(coffeeID)
+	} else {
+		brews, err = s.storage.GetAllBrews()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	coffees, err := s.coffeeService.ListCoffees()
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]string)
+	for _, coffee := range coffees {
+		names[coffee.ID] = coffee.Name
+	}
+
+	chart := &ControlChart{
+		IdealExtraction: idealExtraction,
+		IdealTDS:        idealTDS,
+		Zones: map[string][]ControlChartPoint{
+			"under": {},
+			"ideal": {},
+			"over":  {},
+		},
+		TotalBrews: len(brews),
+	}
+
+	for _, brew := range brews {
+		if brew.TDS <= 0 || brew.ExtractionYield <= 0 {
+			continue
+		}
+		chart.MeasuredBrews++
+
+		point := ControlChartPoint{
+			BrewID:          brew.ID,
+			CoffeeID:        brew.CoffeeID,
+			CoffeeName:      names[brew.CoffeeID],
+			TDS:             brew.TDS,
+			ExtractionYield: brew.ExtractionYield,
+			Strength:        classifyRange(brew.TDS, idealTDS, "weak", "ideal", "strong"),
+			Outcome:         brew.Outcome,
+			Rating:          brew.Rating,
+		}
+
+		zone := classifyRange(brew.ExtractionYield, idealExtraction, "under", "ideal", "over")
+		chart.Zones[zone] = append(chart.Zones[zone], point)
+	}
+
+	return chart, nil
+}
+
+// classifyRange labels a value as below, within or above a range
+func classifyRange(value float64, bounds [2]float64, below, within, above string) string {
+	switch {
+	case value < bounds[0]:
+		return below
+	case value > bounds[1]:
+		return above
+	default:
+		return within
+	}
+}
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"fmt"
+	"go-coffee-log/models"
+	"math"
+	"sort"
+)
+
+// Statistics splits for GetBrewStats
+const (
+	SplitCombined = "combined"
+	SplitPerson   = "person"
+)
+
+// BrewSummary aggregates a set of brews
+type BrewSummary struct {
+	Brews                  int            `json:"brews"`
+	RatedBrews             int            `json:"rated_brews"`
+	AverageRating          float64        `json:"average_rating"`
+	AverageExtractionYield float64        `json:"average_extraction_yield,omitempty"`
+	Outcomes               map[string]int `json:"outcomes"` // "under", "balanced", "over"
+	Coffees                int            `json:"coffees"`
+	FavoriteCoffee         string         `json:"favorite_coffee,omitempty"` // highest average rating
+}
+
+// PersonBrewStats represents the brews of one member of a shared collection
+type PersonBrewStats struct {
+	UserID string `json:"user_id,omitempty"`
+	Name   string `json:"name"`
+	BrewSummary
+}
+
+// BrewStats represents brew statistics for the whole collection, optionally
+// split per person
+type BrewStats struct {
+	Split    string            `json:"split"`
+	Combined BrewSummary       `json:"combined"`
+	People   []PersonBrewStats `json:"people,omitempty"`
+}
+
+// GetBrewStats summarizes all brews. With split "person" the summary is also
+// broken down by who brewed; brews logged without a user are grouped as
+// "unknown".
+func (s *BrewService) GetBrewStats(split string) (*BrewStats, error) {
+	if split == "" {
+		split = SplitCombined
+	}
+	if split != SplitCombined && split != SplitPerson {
+		return nil, fmt.Errorf("invalid split %q: must be %q or %q", split, SplitCombined, SplitPerson)
+	}
+
+	brews, err := s.storage.GetAllBrews()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get brews: %w", err)
+	}
+	coffees, err := s.coffeeService.ListCoffees()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get coffees: %w", err)
+	}
+	coffeeNames := make(map[string]string)
+	for _, coffee := range coffees {
+		coffeeNames[coffee.ID] = coffee.Name
+	}
+
+	stats := &BrewStats{
+		Split:    split,
+		Combined: summarizeBrews(brews, coffeeNames),
+	}
+	if split == SplitCombined {
+		return stats, nil
+	}
+
+	byPerson := make(map[string][]models.Brew)
+	for _, brew := range brews {
+		byPerson[brew.BrewedBy] = append(byPerson[brew.BrewedBy], brew)
+	}
+
+	userNames := s.userNames()
+	stats.People = []PersonBrewStats{}
+	for userID, personBrews := range byPerson {
+		name := userNames[userID]
+		if name == "" {
+			name = "unknown"
+		}
+		stats.People = append(stats.People, PersonBrewStats{
+			UserID:      userID,
+			Name:        name,
+			BrewSummary: summarizeBrews(personBrews, coffeeNames),
+		})
+	}
+	sort.Slice(stats.People, func(i, j int) bool {
+		if stats.People[i].Brews != stats.People[j].Brews {
+			return stats.People[i].Brews > stats.People[j].Brews
+		}
+		return stats.People[i].Name < stats.People[j].Name
+	})
+
+	return stats, nil
+}
+
+// summarizeBrews aggregates ratings, outcomes and extraction across brews
+func summarizeBrews(brews []models.Brew, coffeeNames map[string]string) BrewSummary {
+	summary := BrewSummary{
+		Brews:    len(brews),
+		Outcomes: map[string]int{},
+	}
+
+	type coffeeTotals struct {
+		count  int
+		rating int
+	}
+	perCoffee := make(map[string]*coffeeTotals)
+	var ratingSum int
+	var yieldSum float64
+	var yieldCount int
+
+	for _, brew := range brews {
+		summary.Outcomes[brew.Outcome]++
+
+		if perCoffee[brew.CoffeeID] == nil {
+			perCoffee[brew.CoffeeID] = &coffeeTotals{}
+		}
+		if brew.Rating > 0 {
+			summary.RatedBrews++
+			ratingSum += brew.Rating
+			perCoffee[brew.CoffeeID].count++
+			perCoffee[brew.CoffeeID].rating += brew.Rating
+		}
+		if brew.ExtractionYield > 0 {
+			yieldSum += brew.ExtractionYield
+			yieldCount++
+		}
+	}
+
+	summary.Coffees = len(perCoffee)
+	if summary.RatedBrews > 0 {
+		summary.AverageRating = math.Round(float64(ratingSum)/float64(summary.RatedBrews)*10) / 10
+	}
+	if yieldCount > 0 {
+		summary.AverageExtractionYield = math.Round(yieldSum/float64(yieldCount)*100) / 100
+	}
+
+	// Pick the favorite deterministically: best average, then name
+	bestAverage := 0.0
+	for coffeeID, totals := range perCoffee {
+		if totals.count == 0 {
+			continue
+		}
+		average := float64(totals.rating) / float64(totals.count)
+		name := coffeeNames[coffeeID]
+		if name == "" {
+			continue
+		}
+		if average > bestAverage || (average == bestAverage && name < summary.FavoriteCoffee) {
+			bestAverage = average
+			summary.FavoriteCoffee = name
+		}
+	}
+
+	return summary
+}
+
+// userNames maps user IDs to names when user storage is available
+func (s *BrewService) userNames() map[string]string {
+	names := make(map[string]string)
+	if s.userStorage == nil {
+		return names
+	}
+
+	users, err := s.userStorage.GetAllUsers()
+	if err != nil {
+		return names
+	}
+	for _, user := range users {
+		names[user.ID] = user.Name
+	}
+	return names
+}
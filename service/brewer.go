@@ -12,6 +12,7 @@ import (
 // BrewerService handles brewer business logic
 type BrewerService struct {
 	storage storage.BrewerStorage
+	live    *LiveHub // optional, pushes brewer changes to live clients
 }
 
 // NewBrewerService creates a new brewer service
@@ -21,23 +22,47 @@ func NewBrewerService(storage storage.BrewerStorage) *BrewerService {
 	}
 }
 
+// SetLiveHub pushes every brewer change to live clients
+func (s *BrewerService) SetLiveHub(live *LiveHub) {
+	s.live = live
+}
+
 // CreateBrewer creates a new brewer
 func (s *BrewerService) CreateBrewer(name, pokeballType string) (models.Brewer, error) {
+	brewer, err := newBrewer(name, pokeballType)
+	if err != nil {
+		return models.Brewer{}, err
+	}
+	
+	if err := s.storage.SaveBrewer(brewer); err != nil {
+		return models.Brewer{}, err
+	}
+	s.live.Publish(ResourceBrewer, ChangeCreated, brewer.ID, brewer)
+	
+	return brewer, nil
+}
+
+// PreviewCreateBrewer validates a new brewer like CreateBrewer, without
+// saving it
+func (s *BrewerService) PreviewCreateBrewer(name, pokeballType string) (DryRun, error) {
+	brewer, err := newBrewer(name, pokeballType)
+	if err != nil {
+		return DryRun{}, err
+	}
+	return previewCreate(brewer), nil
+}
+
+// newBrewer builds and validates a brewer
+func newBrewer(name, pokeballType string) (models.Brewer, error) {
 	brewer := models.Brewer{
 		ID:           uuid.New().String(),
 		Name:         name,
 		PokeballType: pokeballType,
 		CreatedAt:    time.Now(),
 	}
-	
 	if err := brewer.Validate(); err != nil {
 		return models.Brewer{}, err
 	}
-	
-	if err := s.storage.SaveBrewer(brewer); err != nil {
-		return models.Brewer{}, err
-	}
-	
 	return brewer, nil
 }
 
@@ -53,45 +78,104 @@ func (s *BrewerService) GetAllBrewers() ([]models.Brewer, error) {
 
 // DeleteBrewer removes a brewer and all its recipes
 func (s *BrewerService) DeleteBrewer(id string) error {
-	return s.storage.DeleteBrewer(id)
+	if err := s.storage.DeleteBrewer(id); err != nil {
+		return err
+	}
+	s.live.Publish(ResourceBrewer, ChangeDeleted, id, nil)
+	return nil
+}
+
+// PreviewDeleteBrewer reports the brewer DeleteBrewer would remove
+func (s *BrewerService) PreviewDeleteBrewer(id string) (DryRun, error) {
+	brewer, err := s.storage.GetBrewerByID(id)
+	if err != nil {
+		return DryRun{}, err
+	}
+	return previewDelete(brewer), nil
 }
 
 // AddStandaloneRecipe adds a standalone brewing recipe to a brewer
-func (s *BrewerService) AddStandaloneRecipe(brewerID, name string, steps []string) error {
-	brewer, err := s.storage.GetBrewerByID(brewerID)
+func (s *BrewerService) AddStandaloneRecipe(brewerID, name string, steps []string, temperature *models.Temperature) error {
+	_, brewer, err := s.withRecipe(brewerID, name, steps, temperature)
 	if err != nil {
 		return err
 	}
+	return s.updateRecipes(brewer)
+}
+
+// PreviewAddStandaloneRecipe validates a recipe like AddStandaloneRecipe
+// and reports the brewer as it would be, without saving it
+func (s *BrewerService) PreviewAddStandaloneRecipe(brewerID, name string, steps []string, temperature *models.Temperature) (DryRun, error) {
+	previous, brewer, err := s.withRecipe(brewerID, name, steps, temperature)
+	if err != nil {
+		return DryRun{}, err
+	}
+	return previewUpdate(previous, brewer)
+}
+
+// withRecipe returns a stored brewer and the brewer with a new recipe added
+func (s *BrewerService) withRecipe(brewerID, name string, steps []string, temperature *models.Temperature) (models.Brewer, models.Brewer, error) {
+	if temperature != nil {
+		if err := temperature.Validate(); err != nil {
+			return models.Brewer{}, models.Brewer{}, err
+		}
+	}
+	
+	previous, err := s.storage.GetBrewerByID(brewerID)
+	if err != nil {
+		return models.Brewer{}, models.Brewer{}, err
+	}
 	
 	// Check recipe limit
-	if len(brewer.Recipes) >= 4 {
-		return fmt.Errorf("brewer already has maximum of 4 recipes")
+	if len(previous.Recipes) >= 4 {
+		return models.Brewer{}, models.Brewer{}, fmt.Errorf("brewer already has maximum of 4 recipes")
 	}
 	
 	// Create new recipe
 	recipe := models.Recipe{
-		ID:    uuid.New().String(),
-		Name:  name,
-		Steps: steps,
+		ID:          uuid.New().String(),
+		Name:        name,
+		Steps:       steps,
+		Temperature: temperature,
 	}
 	
 	// Add recipe to brewer
-	brewer.Recipes = append(brewer.Recipes, recipe)
+	brewer := previous
+	brewer.Recipes = append(append([]models.Recipe{}, previous.Recipes...), recipe)
 	
-	return s.storage.UpdateBrewerRecipes(brewerID, brewer.Recipes)
+	return previous, brewer, nil
 }
 
 // RemoveStandaloneRecipe removes a standalone recipe from a brewer
 func (s *BrewerService) RemoveStandaloneRecipe(brewerID, recipeID string) error {
-	brewer, err := s.storage.GetBrewerByID(brewerID)
+	_, brewer, err := s.withoutRecipe(brewerID, recipeID)
 	if err != nil {
 		return err
 	}
+	return s.updateRecipes(brewer)
+}
+
+// PreviewRemoveStandaloneRecipe reports the brewer as RemoveStandaloneRecipe
+// would leave it, without saving it
+func (s *BrewerService) PreviewRemoveStandaloneRecipe(brewerID, recipeID string) (DryRun, error) {
+	previous, brewer, err := s.withoutRecipe(brewerID, recipeID)
+	if err != nil {
+		return DryRun{}, err
+	}
+	return previewUpdate(previous, brewer)
+}
+
+// withoutRecipe returns a stored brewer and the brewer with a recipe removed
+func (s *BrewerService) withoutRecipe(brewerID, recipeID string) (models.Brewer, models.Brewer, error) {
+	previous, err := s.storage.GetBrewerByID(brewerID)
+	if err != nil {
+		return models.Brewer{}, models.Brewer{}, err
+	}
 	
 	// Find and remove recipe
 	var updatedRecipes []models.Recipe
 	found := false
-	for _, recipe := range brewer.Recipes {
+	for _, recipe := range previous.Recipes {
 		if recipe.ID != recipeID {
 			updatedRecipes = append(updatedRecipes, recipe)
 		} else {
@@ -100,10 +184,22 @@ func (s *BrewerService) RemoveStandaloneRecipe(brewerID, recipeID string) error
 	}
 	
 	if !found {
-		return fmt.Errorf("recipe not found")
+		return models.Brewer{}, models.Brewer{}, fmt.Errorf("recipe not found")
 	}
 	
-	return s.storage.UpdateBrewerRecipes(brewerID, updatedRecipes)
+	brewer := previous
+	brewer.Recipes = updatedRecipes
+	return previous, brewer, nil
+}
+
+// updateRecipes saves a brewer's recipes and pushes the brewer to live
+// clients
+func (s *BrewerService) updateRecipes(brewer models.Brewer) error {
+	if err := s.storage.UpdateBrewerRecipes(brewer.ID, brewer.Recipes); err != nil {
+		return err
+	}
+	s.live.Publish(ResourceBrewer, ChangeUpdated, brewer.ID, brewer)
+	return nil
 }
 
 // GetAvailablePokeballTypes returns the list of valid pokeball types
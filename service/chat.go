@@ -0,0 +1,355 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-coffee-log/models"
+	"go-coffee-log/storage"
+	"log"
+	"sort"
+	"strings"
+)
+
+// maxChatRecords caps how many coffees are handed to the LLM as context
+const maxChatRecords = 5
+
+// ChatService answers natural-language questions about the coffee collection.
+// Answers are always grounded in records retrieved from storage; the LLM only
+// summarizes those records and never sees anything outside the collection.
+type ChatService struct {
+	coffeeStorage  storage.CoffeeStorage
+	pokemonStorage storage.PokemonStorage // optional
+	llmService     *LLMService            // optional
+}
+
+// NewChatService creates a new chat service
+func NewChatService(
+	coffeeStorage storage.CoffeeStorage,
+	pokemonStorage storage.PokemonStorage,
+	llmService *LLMService,
+) *ChatService {
+	return &ChatService{
+		coffeeStorage:  coffeeStorage,
+		pokemonStorage: pokemonStorage,
+		llmService:     llmService,
+	}
+}
+
+// ChatRecord represents a coffee retrieved as context for an answer
+type ChatRecord struct {
+	ID               string   `json:"id"`
+	Name             string   `json:"name"`
+	Origin           string   `json:"origin"`
+	Roaster          string   `json:"roaster"`
+	ProcessingMethod string   `json:"processing_method"`
+	RoastLevel       string   `json:"roast_level"`
+	Rating           int      `json:"rating"`
+	TastingNotes     []string `json:"tasting_notes"`
+	PokemonName      string   `json:"pokemon_name,omitempty"`
+}
+
+// ChatResponse represents the answer to a chat question
+type ChatResponse struct {
+	Question     string            `json:"question"`
+	Answer       string            `json:"answer"`
+	Filters      map[string]string `json:"filters"`
+	TotalMatches int               `json:"total_matches"`
+	Records      []ChatRecord      `json:"records"`
+	LLMUsed      bool              `json:"llm_used"`
+}
+
+// chatFacets lists the coffee fields a question can filter on
+var chatFacets = map[string]func(models.Coffee) []string{
+	"origin":            func(c models.Coffee) []string { return []string{c.Origin} },
+	"roaster":           func(c models.Coffee) []string { return []string{c.Roaster} },
+	"variety":           func(c models.Coffee) []string { return []string{c.Variety} },
+	"processing_method": func(c models.Coffee) []string { return []string{c.ProcessingMethod} },
+	"roast_level":       func(c models.Coffee) []string { return []string{c.RoastLevel} },
+	"dripper":           func(c models.Coffee) []string { return []string{c.Dripper} },
+	"tasting_note":      func(c models.Coffee) []string { return c.TastingNotes[:] },
+	"favorite":          favoriteChatValues,
+}
+
+// favoriteChatValues lets "my favorites" in a question restrict answers to
+// coffees marked as favorites
+func favoriteChatValues(c models.Coffee) []string {
+	if !c.Favorite {
+		return nil
+	}
+	return []string{"favorites", "favorited", "pinned"}
+}
+
+// chatIntent describes how matching records should be ordered and summarized
+type chatIntent int
+
+const (
+	intentList chatIntent = iota
+	intentBest
+	intentWorst
+	intentRecent
+	intentCount
+)
+
+// Ask answers a question about the collection
+func (s *ChatService) Ask(ctx context.Context, question string) (*ChatResponse, error) {
+	question = strings.TrimSpace(question)
+	if question == "" {
+		return nil, fmt.Errorf("question cannot be empty")
+	}
+
+	coffees, err := s.coffeeStorage.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get coffees: %w", err)
+	}
+
+	lowered := strings.ToLower(question)
+	filters := s.detectFilters(lowered, coffees)
+	intent := s.detectIntent(lowered)
+
+	matches := filterChatCoffees(coffees, filters)
+	sortChatCoffees(matches, intent)
+
+	response := &ChatResponse{
+		Question:     question,
+		Filters:      filters,
+		TotalMatches: len(matches),
+		Records:      []ChatRecord{},
+	}
+
+	limit := maxChatRecords
+	if len(matches) < limit {
+		limit = len(matches)
+	}
+	pokemonNames := s.pokemonNames()
+	for _, coffee := range matches[:limit] {
+		response.Records = append(response.Records, toChatRecord(coffee, pokemonNames[coffee.ID]))
+	}
+
+	response.Answer = summarizeChat(intent, filters, response.TotalMatches, response.Records)
+
+	if s.llmService.Available() && len(response.Records) > 0 {
+		answer, err := s.llmService.Complete(ctx, s.buildChatPrompt(question, response))
+		if err != nil {
+			log.Printf("Chat: LLM summarization failed, using rule-based answer: %v", err)
+		} else if answer != "" {
+			response.Answer = answer
+			response.LLMUsed = true
+		}
+	}
+
+	return response, nil
+}
+
+// detectFilters finds collection values (origins, roasters, ...) mentioned in
+// the question. Only values that already exist in the collection can match.
+func (s *ChatService) detectFilters(question string, coffees []models.Coffee) map[string]string {
+	filters := make(map[string]string)
+	for facet, values := range chatFacets {
+		best := ""
+		for _, coffee := range coffees {
+			for _, value := range values(coffee) {
+				value = strings.TrimSpace(value)
+				// Very short values ("na", "v6") match too much by accident
+				if len(value) < 3 || !strings.Contains(question, strings.ToLower(value)) {
+					continue
+				}
+				// Prefer the most specific value, e.g. "medium dark" over "dark"
+				if len(value) > len(best) {
+					best = value
+				}
+			}
+		}
+		if best != "" {
+			filters[facet] = best
+		}
+	}
+
+	return filters
+}
+
+// detectIntent picks the ordering and answer style from question keywords
+func (s *ChatService) detectIntent(question string) chatIntent {
+	switch {
+	case strings.Contains(question, "how many"):
+		return intentCount
+	case containsAny(question, "worst", "lowest", "least favorite", "disappoint"):
+		return intentWorst
+	case containsAny(question, "best", "top", "highest", "favorite", "favourite"):
+		return intentBest
+	case containsAny(question, "recent", "latest", "last", "newest"):
+		return intentRecent
+	default:
+		return intentList
+	}
+}
+
+// filterChatCoffees keeps coffees matching every detected filter
+func filterChatCoffees(coffees []models.Coffee, filters map[string]string) []models.Coffee {
+	matches := []models.Coffee{}
+	for _, coffee := range coffees {
+		if chatMatches(coffee, filters) {
+			matches = append(matches, coffee)
+		}
+	}
+	return matches
+}
+
+// chatMatches reports whether a coffee satisfies all filters
+func chatMatches(coffee models.Coffee, filters map[string]string) bool {
+	for facet, want := range filters {
+		values := chatFacets[facet](coffee)
+
+		found := false
+		for _, value := range values {
+			if strings.EqualFold(strings.TrimSpace(value), want) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// sortChatCoffees orders matches according to the question intent
+func sortChatCoffees(coffees []models.Coffee, intent chatIntent) {
+	switch intent {
+	case intentWorst:
+		sort.SliceStable(coffees, func(i, j int) bool { return coffees[i].Rating < coffees[j].Rating })
+	case intentRecent:
+		sort.SliceStable(coffees, func(i, j int) bool { return coffees[i].CreatedAt.After(coffees[j].CreatedAt) })
+	default:
+		sort.SliceStable(coffees, func(i, j int) bool { return coffees[i].Rating > coffees[j].Rating })
+	}
+}
+
+// summarizeChat builds the rule-based answer used when no LLM is available
+func summarizeChat(intent chatIntent, filters map[string]string, total int, records []ChatRecord) string {
+	scope := describeChatFilters(filters, "coffees")
+	single := describeChatFilters(filters, "coffee")
+
+	if total == 0 {
+		return fmt.Sprintf("I couldn't find any %s in your collection.", scope)
+	}
+
+	top := records[0]
+	switch intent {
+	case intentCount:
+		return fmt.Sprintf("You have %d %s in your collection.", total, scope)
+	case intentBest:
+		return fmt.Sprintf("Your best %s is %s%s, rated %d/10.", single, top.Name, roasterSuffix(top), top.Rating)
+	case intentWorst:
+		return fmt.Sprintf("Your lowest-rated %s is %s%s, rated %d/10.", single, top.Name, roasterSuffix(top), top.Rating)
+	case intentRecent:
+		return fmt.Sprintf("Your most recent %s is %s%s, rated %d/10.", single, top.Name, roasterSuffix(top), top.Rating)
+	}
+
+	var names []string
+	for _, record := range records {
+		names = append(names, fmt.Sprintf("%s (%d/10)", record.Name, record.Rating))
+	}
+	return fmt.Sprintf("Found %d %s: %s.", total, scope, strings.Join(names, ", "))
+}
+
+// describeChatFilters renders filters as a phrase like "natural Ethiopia coffees"
+func describeChatFilters(filters map[string]string, noun string) string {
+	var parts []string
+	for _, facet := range []string{"roast_level", "processing_method", "origin", "variety"} {
+		if value, ok := filters[facet]; ok {
+			parts = append(parts, value)
+		}
+	}
+	if _, ok := filters["favorite"]; ok {
+		parts = append([]string{"favorite"}, parts...)
+	}
+	phrase := strings.Join(append(parts, noun), " ")
+
+	if roaster, ok := filters["roaster"]; ok {
+		phrase += " from " + roaster
+	}
+	if note, ok := filters["tasting_note"]; ok {
+		phrase += " with " + note + " notes"
+	}
+	if dripper, ok := filters["dripper"]; ok {
+		phrase += " brewed on " + dripper
+	}
+	return phrase
+}
+
+// roasterSuffix renders " by <roaster>" when the roaster is known
+func roasterSuffix(record ChatRecord) string {
+	if record.Roaster == "" {
+		return ""
+	}
+	return " by " + record.Roaster
+}
+
+// buildChatPrompt creates the summarization prompt from retrieved records
+func (s *ChatService) buildChatPrompt(question string, response *ChatResponse) string {
+	recordsJSON, _ := json.MarshalIndent(response.Records, "", "  ")
+
+	return fmt.Sprintf(`You are a helpful assistant answering questions about a personal coffee tasting log.
+Answer the question using ONLY the records below. They are the top %d of %d matching coffees from the user's own collection.
+If the records do not answer the question, say so. Do not invent coffees. Answer in 1-3 sentences of plain text.
+
+Question: %s
+
+Records:
+%s`, len(response.Records), response.TotalMatches, question, string(recordsJSON))
+}
+
+// pokemonNames maps coffee IDs to the names of their caught Pokemon
+func (s *ChatService) pokemonNames() map[string]string {
+	names := make(map[string]string)
+	if s.pokemonStorage == nil {
+		return names
+	}
+
+	mappings, err := s.pokemonStorage.GetAllCoffeePokemon()
+	if err != nil {
+		log.Printf("Chat: failed to load Pokemon mappings: %v", err)
+		return names
+	}
+	for _, mapping := range mappings {
+		if _, ok := names[mapping.CoffeeID]; ok && mapping.Dex != models.DefaultDex {
+			continue // prefer the Kanto catch of coffees caught in several dexes
+		}
+		names[mapping.CoffeeID] = mapping.PokemonName
+	}
+	return names
+}
+
+// toChatRecord converts a coffee into its chat context representation
+func toChatRecord(coffee models.Coffee, pokemonName string) ChatRecord {
+	notes := []string{}
+	for _, note := range coffee.TastingNotes {
+		if note != "" {
+			notes = append(notes, note)
+		}
+	}
+
+	return ChatRecord{
+		ID:               coffee.ID,
+		Name:             coffee.Name,
+		Origin:           coffee.Origin,
+		Roaster:          coffee.Roaster,
+		ProcessingMethod: coffee.ProcessingMethod,
+		RoastLevel:       coffee.RoastLevel,
+		Rating:           coffee.Rating,
+		TastingNotes:     notes,
+		PokemonName:      pokemonName,
+	}
+}
+
+// containsAny reports whether s contains any of the given substrings
+func containsAny(s string, substrings ...string) bool {
+	for _, sub := range substrings {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,8 +1,13 @@
 package service
 
 import (
+	"encoding/json"
+	"fmt"
 	"go-coffee-log/models"
 	"go-coffee-log/storage"
+	"log"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,7 +17,15 @@ import (
 // TODO: Add the following field:
 //   - storage (storage.CoffeeStorage) - the storage implementation to use
 type CoffeeService struct {
-	storage storage.CoffeeStorage
+	storage    storage.CoffeeStorage
+	reads      storage.CoffeeStorage                // optional, read replica serving the list endpoints
+	tombstones storage.TombstoneStorage             // optional, journals deletions for sync
+	quotas     *QuotaService                        // optional, caps the collection size
+	brewers    storage.BrewerStorage                // optional, checks brewer IDs
+	pokemon    storage.PokemonStorage               // optional, reports catches a delete releases
+	live       *LiveHub                             // optional, pushes changes to live clients
+	onCreate   []func(models.Coffee)                // called after a coffee is logged
+	onPreview  []func(models.Coffee) []CatchPreview // predicts what onCreate would catch
 }
 
 // NewCoffeeService creates a new coffee service
@@ -20,7 +33,95 @@ func NewCoffeeService(storage storage.CoffeeStorage) *CoffeeService {
 	return &CoffeeService{storage: storage}
 }
 
-// CreateCoffee creates a new coffee entry
+// SetReadReplica serves the coffee list endpoints from a read replica.
+// Lists may trail writes by the replication lag; every other read, and
+// any read a write depends on, still goes to the primary.
+func (s *CoffeeService) SetReadReplica(reads storage.CoffeeStorage) {
+	s.reads = reads
+}
+
+// listStorage returns the storage the list endpoints read from
+func (s *CoffeeService) listStorage() storage.CoffeeStorage {
+	if s.reads != nil {
+		return s.reads
+	}
+	return s.storage
+}
+
+// SetTombstones records a tombstone for every deleted coffee
+func (s *CoffeeService) SetTombstones(tombstones storage.TombstoneStorage) {
+	s.tombstones = tombstones
+}
+
+// SetQuotas refuses new coffees once the collection reaches its quota
+func (s *CoffeeService) SetQuotas(quotas *QuotaService) {
+	s.quotas = quotas
+}
+
+// SetBrewers refuses coffees linked to a brewer that does not exist
+func (s *CoffeeService) SetBrewers(brewers storage.BrewerStorage) {
+	s.brewers = brewers
+}
+
+// SetPokemon lets deletes report the Pokemon catches they release
+func (s *CoffeeService) SetPokemon(pokemon storage.PokemonStorage) {
+	s.pokemon = pokemon
+}
+
+// SetLiveHub pushes every coffee change to live clients
+func (s *CoffeeService) SetLiveHub(live *LiveHub) {
+	s.live = live
+}
+
+// catchesOf returns a coffee's Pokemon catches in every dex profile
+func (s *CoffeeService) catchesOf(id string) ([]models.CoffeePokemon, error) {
+	if s.pokemon == nil {
+		return nil, nil
+	}
+	catches, err := s.pokemon.GetCoffeeCatches(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Pokemon of coffee: %w", err)
+	}
+	return catches, nil
+}
+
+// checkQuota verifies the user adding a new coffee may add another
+func (s *CoffeeService) checkQuota(coffee models.Coffee) error {
+	if s.quotas == nil {
+		return nil
+	}
+	return s.quotas.CheckCoffee(coffee.AddedBy)
+}
+
+// checkBrewer verifies the brewer a coffee is linked to, if any
+func (s *CoffeeService) checkBrewer(coffee models.Coffee) error {
+	if coffee.BrewerID == "" || s.brewers == nil {
+		return nil
+	}
+	if _, err := s.brewers.GetBrewerByID(coffee.BrewerID); err != nil {
+		if strings.Contains(err.Error(), "brewer not found") {
+			return fmt.Errorf("invalid coffee: unknown brewer_id %s", coffee.BrewerID)
+		}
+		return err
+	}
+	return nil
+}
+
+// OnCreate registers a function called with every coffee logged through
+// CreateCoffee. Register listeners at startup, before serving requests.
+func (s *CoffeeService) OnCreate(listener func(models.Coffee)) {
+	s.onCreate = append(s.onCreate, listener)
+}
+
+// OnPreview registers a function that reports the Pokemon a coffee would
+// catch if it were logged, for dry runs of CreateCoffee. It must not change
+// anything.
+func (s *CoffeeService) OnPreview(preview func(models.Coffee) []CatchPreview) {
+	s.onPreview = append(s.onPreview, preview)
+}
+
+// CreateCoffee creates a new coffee entry, counted against the quota of
+// the user in its AddedBy
 // TODO: Implement this method
 // Requirements:
 //   - Generate a unique ID (you can use a simple counter or UUID)
@@ -32,15 +133,29 @@ func (s *CoffeeService) CreateCoffee(coffee models.Coffee) (models.Coffee, error
 	coffee.ID = uuid.New().String()
 	coffee.CreatedAt = time.Now()
 	coffee.UpdatedAt = time.Now()
+	coffee.DialIn = nil
 	
 	if err := coffee.Validate(); err != nil {
 		return models.Coffee{}, err
 	}
+	if err := s.checkBrewer(coffee); err != nil {
+		return models.Coffee{}, err
+	}
+	if err := s.resolveLineage(&coffee); err != nil {
+		return models.Coffee{}, err
+	}
+	if err := s.checkQuota(coffee); err != nil {
+		return models.Coffee{}, err
+	}
 	
 	if err := s.storage.Save(coffee); err != nil {
 		return models.Coffee{}, err
 	}
 	
+	s.live.Publish(ResourceCoffee, ChangeCreated, coffee.ID, coffee)
+	for _, listener := range s.onCreate {
+		listener(coffee)
+	}
 	return coffee, nil
 }
 
@@ -62,9 +177,95 @@ func (s *CoffeeService) ListCoffees() ([]models.Coffee, error) {
 	return s.storage.GetAll()
 }
 
-// GetRecentCoffees retrieves the most recent coffees
+// ListCoffeesForDisplay retrieves all coffees for GET /coffees, from the
+// read replica if one is set
+func (s *CoffeeService) ListCoffeesForDisplay() ([]models.Coffee, error) {
+	return s.listStorage().GetAll()
+}
+
+// Coffee page sizes for ListCoffeesPage
+const (
+	DefaultCoffeePageSize = 50
+	MaxCoffeePageSize     = 200
+)
+
+// CoffeePage is one page of the coffee list
+type CoffeePage struct {
+	Coffees    []models.Coffee `json:"coffees"`
+	Total      int             `json:"total"`
+	Offset     int             `json:"offset"`
+	Limit      int             `json:"limit"`
+	HasMore    bool            `json:"has_more"`
+	NextOffset *int            `json:"next_offset,omitempty"` // offset of the next page, if any
+}
+
+// FindCoffees retrieves every coffee matching a query's filter, in its sort
+// order; the query's offset and limit are ignored. Like the other list
+// methods it reads from the read replica if one is set.
+func (s *CoffeeService) FindCoffees(query storage.CoffeeQuery) ([]models.Coffee, error) {
+	if err := validateCoffeeQuery(query); err != nil {
+		return nil, err
+	}
+	query.Offset, query.Limit = 0, 0
+	
+	coffees, _, err := s.listStorage().Find(query)
+	return coffees, err
+}
+
+// ListCoffeesPage retrieves one page of the coffees matching a query. A
+// limit of 0 uses DefaultCoffeePageSize; larger limits are capped at
+// MaxCoffeePageSize.
+func (s *CoffeeService) ListCoffeesPage(query storage.CoffeeQuery) (CoffeePage, error) {
+	if err := validateCoffeeQuery(query); err != nil {
+		return CoffeePage{}, err
+	}
+	if query.Offset < 0 {
+		query.Offset = 0
+	}
+	if query.Limit <= 0 {
+		query.Limit = DefaultCoffeePageSize
+	}
+	if query.Limit > MaxCoffeePageSize {
+		query.Limit = MaxCoffeePageSize
+	}
+	
+	coffees, total, err := s.listStorage().Find(query)
+	if err != nil {
+		return CoffeePage{}, err
+	}
+	if coffees == nil {
+		coffees = []models.Coffee{}
+	}
+	
+	offset := query.Offset
+	page := CoffeePage{Coffees: coffees, Total: total, Offset: offset, Limit: query.Limit}
+	if next := offset + len(coffees); next < total {
+		page.HasMore = true
+		page.NextOffset = &next
+	}
+	return page, nil
+}
+
+// validateCoffeeQuery checks a listing's sort and rating filter
+func validateCoffeeQuery(query storage.CoffeeQuery) error {
+	if _, ok := storage.CoffeeSorts[query.Sort]; query.Sort != "" && !ok {
+		sorts := make([]string, 0, len(storage.CoffeeSorts))
+		for name := range storage.CoffeeSorts {
+			sorts = append(sorts, name)
+		}
+		sort.Strings(sorts)
+		return fmt.Errorf("invalid sort %q: use one of %s", query.Sort, strings.Join(sorts, ", "))
+	}
+	if query.Filter.MinRating < 0 || query.Filter.MinRating > 10 {
+		return fmt.Errorf("invalid min_rating: must be between 0 and 10")
+	}
+	return nil
+}
+
+// GetRecentCoffees retrieves the most recent coffees, from the read replica
+// if one is set
 func (s *CoffeeService) GetRecentCoffees(limit int) ([]models.Coffee, error) {
-	return s.storage.GetRecent(limit)
+	return s.listStorage().GetRecent(limit)
 }
 
 // UpdateCoffee modifies an existing coffee
@@ -76,24 +277,218 @@ func (s *CoffeeService) GetRecentCoffees(limit int) ([]models.Coffee, error) {
 func (s *CoffeeService) UpdateCoffee(id string, coffee models.Coffee) (models.Coffee, error) {
 	coffee.ID = id  // Set the ID from the URL
 	coffee.UpdatedAt = time.Now()
+	coffee.DialIn = nil
 	
 	if err := coffee.Validate(); err != nil {
 		return models.Coffee{}, err
 	}
+	if err := s.checkBrewer(coffee); err != nil {
+		return models.Coffee{}, err
+	}
+	if err := s.resolveLineage(&coffee); err != nil {
+		return models.Coffee{}, err
+	}
 	
 	if err := s.storage.Update(id, coffee); err != nil {
 		return models.Coffee{}, err
 	}
+	s.live.Publish(ResourceCoffee, ChangeUpdated, id, coffee)
 	
 	return coffee, nil  // ← Return the updated coffee, not empty!
 }
 
+// patchReadOnlyFields are coffee fields a patch may not change
+var patchReadOnlyFields = []string{"id", "dial_in", "added_by", "created_at", "updated_at"}
+
+// PatchCoffee applies a JSON merge patch (RFC 7386) to a coffee, so callers
+// can change a single field or tasting trait without resending the rest.
+// A null removes a field, resetting it to its zero value.
+func (s *CoffeeService) PatchCoffee(id string, patch []byte) (models.Coffee, error) {
+	_, patched, err := s.applyPatch(id, patch)
+	if err != nil {
+		return models.Coffee{}, err
+	}
+	return s.UpdateCoffee(id, patched)
+}
+
+// applyPatch returns a stored coffee and the validated result of applying a
+// merge patch to it
+func (s *CoffeeService) applyPatch(id string, patch []byte) (models.Coffee, models.Coffee, error) {
+	coffee, err := s.storage.GetByID(id)
+	if err != nil {
+		return models.Coffee{}, models.Coffee{}, err
+	}
+	patched, err := patchCoffee(coffee, patch)
+	if err != nil {
+		return models.Coffee{}, models.Coffee{}, err
+	}
+	return coffee, patched, nil
+}
+
+// patchCoffee returns the validated result of applying a merge patch to a
+// coffee; read-only fields are left as they are
+func patchCoffee(coffee models.Coffee, patch []byte) (models.Coffee, error) {
+	var changes map[string]interface{}
+	if err := json.Unmarshal(patch, &changes); err != nil || changes == nil {
+		return models.Coffee{}, fmt.Errorf("invalid patch: expected a JSON object")
+	}
+	for _, field := range patchReadOnlyFields {
+		delete(changes, field)
+	}
+
+	current, err := json.Marshal(coffee)
+	if err != nil {
+		return models.Coffee{}, fmt.Errorf("failed to encode coffee: %w", err)
+	}
+	var document map[string]interface{}
+	if err := json.Unmarshal(current, &document); err != nil {
+		return models.Coffee{}, fmt.Errorf("failed to decode coffee: %w", err)
+	}
+
+	merged, err := json.Marshal(mergePatch(document, changes))
+	if err != nil {
+		return models.Coffee{}, fmt.Errorf("failed to encode patched coffee: %w", err)
+	}
+	var patched models.Coffee
+	if err := json.Unmarshal(merged, &patched); err != nil {
+		return models.Coffee{}, fmt.Errorf("invalid patch: %v", err)
+	}
+	patched.CreatedAt = coffee.CreatedAt
+	if err := patched.Validate(); err != nil {
+		return models.Coffee{}, fmt.Errorf("invalid coffee: %w", err)
+	}
+	return patched, nil
+}
+
+// mergePatch applies patch to target following RFC 7386: nested objects
+// merge, null deletes, and anything else replaces
+func mergePatch(target, patch map[string]interface{}) map[string]interface{} {
+	if target == nil {
+		target = make(map[string]interface{})
+	}
+	for key, value := range patch {
+		if value == nil {
+			delete(target, key)
+			continue
+		}
+		if object, ok := value.(map[string]interface{}); ok {
+			existing, _ := target[key].(map[string]interface{})
+			target[key] = mergePatch(existing, object)
+			continue
+		}
+		target[key] = value
+	}
+	return target
+}
+
+// SetFavorite marks or unmarks a coffee as a favorite
+func (s *CoffeeService) SetFavorite(id string, favorite bool) (models.Coffee, error) {
+	coffee, err := s.storage.GetByID(id)
+	if err != nil {
+		return models.Coffee{}, err
+	}
+	if coffee.Favorite == favorite {
+		return coffee, nil
+	}
+	
+	coffee.Favorite = favorite
+	coffee.UpdatedAt = time.Now()
+	if err := s.storage.Update(id, coffee); err != nil {
+		return models.Coffee{}, err
+	}
+	s.live.Publish(ResourceCoffee, ChangeUpdated, id, coffee)
+	return coffee, nil
+}
+
+// CloneCoffee starts a new bag of an existing coffee. The clone keeps the
+// coffee's details and tasting profile but none of the bag's own history:
+// rating, roast date, recipe and brewer are reset, and brews and Pokemon
+// stay with the original. Its lineage points at the first bag, and it
+// counts against the quota of addedBy, who starts it.
+func (s *CoffeeService) CloneCoffee(id, addedBy string) (models.Coffee, error) {
+	original, err := s.storage.GetByID(id)
+	if err != nil {
+		return models.Coffee{}, err
+	}
+	
+	clone := original
+	clone.Lineage = original.ID
+	clone.Rating = 0
+	clone.Favorite = false
+	clone.RoastDate = nil
+	clone.Recipe = nil
+	clone.Dripper = ""
+	clone.EndTime = models.DrawDownTime{}
+	clone.BrewerID = ""
+	clone.AddedBy = addedBy
+	return s.CreateCoffee(clone)
+}
+
+// resolveLineage checks that a coffee's lineage names another coffee and
+// points it at the first bag, so every bag of a coffee shares one lineage
+func (s *CoffeeService) resolveLineage(coffee *models.Coffee) error {
+	if coffee.Lineage == "" {
+		return nil
+	}
+	if coffee.Lineage == coffee.ID {
+		return fmt.Errorf("invalid lineage: a coffee cannot descend from itself")
+	}
+	parent, err := s.storage.GetByID(coffee.Lineage)
+	if err != nil {
+		return fmt.Errorf("invalid lineage: no coffee %s", coffee.Lineage)
+	}
+	if parent.Lineage != "" {
+		if parent.Lineage == coffee.ID {
+			return fmt.Errorf("invalid lineage: a coffee cannot descend from itself")
+		}
+		coffee.Lineage = parent.Lineage
+	}
+	return nil
+}
+
+// PinnedCoffees splits coffees into favorites and the rest, keeping the
+// original order within each
+func PinnedCoffees(coffees []models.Coffee) (pinned, others []models.Coffee) {
+	pinned, others = []models.Coffee{}, []models.Coffee{}
+	for _, coffee := range coffees {
+		if coffee.Favorite {
+			pinned = append(pinned, coffee)
+		} else {
+			others = append(others, coffee)
+		}
+	}
+	return pinned, others
+}
+
 // DeleteCoffee removes a coffee entry
 // TODO: Implement this method
 // HINT: Delegate to storage.Delete
 func (s *CoffeeService) DeleteCoffee(id string) error {
+	// Storage deletes the catches with the coffee; look them up first only
+	// to log which Pokemon are free again
+	catches, err := s.catchesOf(id)
+	if err != nil {
+		log.Printf("WARNING: Failed to look up Pokemon of coffee %s before deleting it: %v", id, err)
+	}
 	if err := s.storage.Delete(id); err != nil {
 		return err
 	}
+	for _, catch := range catches {
+		log.Printf("INFO: Released %s (#%d) in the %s dex with deleted coffee %s", catch.PokemonName, catch.PokemonID, catch.Dex, id)
+	}
+	s.recordDeletion(id)
 	return nil
+}
+
+// recordDeletion records a tombstone for a coffee deleted from storage,
+// e.g. one merged into another, so sync and live clients drop it too
+func (s *CoffeeService) recordDeletion(id string) {
+	s.live.Publish(ResourceCoffee, ChangeDeleted, id, nil)
+	if s.tombstones == nil {
+		return
+	}
+	tombstone := models.Tombstone{EntityType: models.EntityCoffee, EntityID: id, DeletedAt: time.Now()}
+	if err := s.tombstones.RecordDeletion(tombstone); err != nil {
+		log.Printf("ERROR: Failed to record deletion of coffee %s: %v", id, err)
+	}
 }
\ No newline at end of file
@@ -0,0 +1,277 @@
+package service
+
+import (
+	"fmt"
+	"go-coffee-log/models"
+	"go-coffee-log/storage"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CollectionService manages user-defined groups of coffees
+type CollectionService struct {
+	storage       storage.CollectionStorage
+	coffeeService *CoffeeService
+}
+
+// NewCollectionService creates a new collection service
+func NewCollectionService(storage storage.CollectionStorage, coffeeService *CoffeeService) *CollectionService {
+	return &CollectionService{
+		storage:       storage,
+		coffeeService: coffeeService,
+	}
+}
+
+// CreateCollection creates a collection, optionally with initial coffees
+func (s *CollectionService) CreateCollection(name, description string, coffeeIDs []string) (models.Collection, error) {
+	collection, err := s.newCollection(name, description, coffeeIDs)
+	if err != nil {
+		return models.Collection{}, err
+	}
+
+	if err := s.storage.SaveCollection(collection); err != nil {
+		return models.Collection{}, err
+	}
+	return collection, nil
+}
+
+// PreviewCreateCollection validates a collection like CreateCollection,
+// without saving it
+func (s *CollectionService) PreviewCreateCollection(name, description string, coffeeIDs []string) (DryRun, error) {
+	collection, err := s.newCollection(name, description, coffeeIDs)
+	if err != nil {
+		return DryRun{}, err
+	}
+	return previewCreate(collection), nil
+}
+
+// newCollection builds and validates a collection of stored coffees
+func (s *CollectionService) newCollection(name, description string, coffeeIDs []string) (models.Collection, error) {
+	now := time.Now()
+	collection := models.Collection{
+		ID:          uuid.New().String(),
+		Name:        name,
+		Description: description,
+		CoffeeIDs:   []string{},
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := collection.Validate(); err != nil {
+		return models.Collection{}, err
+	}
+
+	seen := make(map[string]bool)
+	for _, coffeeID := range coffeeIDs {
+		if _, err := s.coffeeService.GetCoffee(coffeeID); err != nil {
+			return models.Collection{}, err
+		}
+		if !seen[coffeeID] {
+			seen[coffeeID] = true
+			collection.CoffeeIDs = append(collection.CoffeeIDs, coffeeID)
+		}
+	}
+	return collection, nil
+}
+
+// GetCollection retrieves a collection. Coffees deleted since they were
+// added are left out.
+func (s *CollectionService) GetCollection(id string) (models.Collection, error) {
+	collection, err := s.storage.GetCollection(id)
+	if err != nil {
+		return models.Collection{}, err
+	}
+	existing, err := s.coffeeIDs()
+	if err != nil {
+		return models.Collection{}, err
+	}
+	collection.CoffeeIDs = keepExisting(collection.CoffeeIDs, existing)
+	return collection, nil
+}
+
+// ListCollections returns every collection ordered by name
+func (s *CollectionService) ListCollections() ([]models.Collection, error) {
+	collections, err := s.storage.GetAllCollections()
+	if err != nil {
+		return nil, err
+	}
+	existing, err := s.coffeeIDs()
+	if err != nil {
+		return nil, err
+	}
+	for i := range collections {
+		collections[i].CoffeeIDs = keepExisting(collections[i].CoffeeIDs, existing)
+	}
+	return collections, nil
+}
+
+// UpdateCollection renames a collection or changes its description
+func (s *CollectionService) UpdateCollection(id, name, description string) (models.Collection, error) {
+	_, collection, err := s.editCollection(id, name, description)
+	if err != nil {
+		return models.Collection{}, err
+	}
+
+	if err := s.storage.UpdateCollection(collection); err != nil {
+		return models.Collection{}, err
+	}
+	return collection, nil
+}
+
+// PreviewUpdateCollection validates an edit like UpdateCollection, without
+// saving it
+func (s *CollectionService) PreviewUpdateCollection(id, name, description string) (DryRun, error) {
+	previous, collection, err := s.editCollection(id, name, description)
+	if err != nil {
+		return DryRun{}, err
+	}
+	return previewUpdate(previous, collection)
+}
+
+// editCollection returns a collection and the collection with a new name
+// and description
+func (s *CollectionService) editCollection(id, name, description string) (models.Collection, models.Collection, error) {
+	previous, err := s.GetCollection(id)
+	if err != nil {
+		return models.Collection{}, models.Collection{}, err
+	}
+	collection := previous
+	collection.Name = name
+	collection.Description = description
+	collection.UpdatedAt = time.Now()
+	if err := collection.Validate(); err != nil {
+		return models.Collection{}, models.Collection{}, err
+	}
+	return previous, collection, nil
+}
+
+// DeleteCollection removes a collection without touching its coffees
+func (s *CollectionService) DeleteCollection(id string) error {
+	return s.storage.DeleteCollection(id)
+}
+
+// PreviewDeleteCollection reports the collection DeleteCollection would
+// remove
+func (s *CollectionService) PreviewDeleteCollection(id string) (DryRun, error) {
+	collection, err := s.GetCollection(id)
+	if err != nil {
+		return DryRun{}, err
+	}
+	return previewDelete(collection), nil
+}
+
+// AddCoffee adds a coffee to a collection
+func (s *CollectionService) AddCoffee(id, coffeeID string) (models.Collection, error) {
+	if err := s.checkAddCoffee(id, coffeeID); err != nil {
+		return models.Collection{}, err
+	}
+	if err := s.storage.AddCoffee(id, coffeeID); err != nil {
+		return models.Collection{}, err
+	}
+	return s.GetCollection(id)
+}
+
+// PreviewAddCoffee reports the collection as AddCoffee would leave it,
+// without changing it
+func (s *CollectionService) PreviewAddCoffee(id, coffeeID string) (DryRun, error) {
+	if err := s.checkAddCoffee(id, coffeeID); err != nil {
+		return DryRun{}, err
+	}
+	previous, err := s.GetCollection(id)
+	if err != nil {
+		return DryRun{}, err
+	}
+	collection := previous
+	if !containsString(previous.CoffeeIDs, coffeeID) {
+		collection.CoffeeIDs = append(append([]string{}, previous.CoffeeIDs...), coffeeID)
+	}
+	return previewUpdate(previous, collection)
+}
+
+// checkAddCoffee verifies both the collection and the coffee exist
+func (s *CollectionService) checkAddCoffee(id, coffeeID string) error {
+	if _, err := s.storage.GetCollection(id); err != nil {
+		return err
+	}
+	if _, err := s.coffeeService.GetCoffee(coffeeID); err != nil {
+		return err
+	}
+	return nil
+}
+
+// RemoveCoffee removes a coffee from a collection
+func (s *CollectionService) RemoveCoffee(id, coffeeID string) error {
+	return s.storage.RemoveCoffee(id, coffeeID)
+}
+
+// PreviewRemoveCoffee reports the collection as RemoveCoffee would leave
+// it, without changing it
+func (s *CollectionService) PreviewRemoveCoffee(id, coffeeID string) (DryRun, error) {
+	stored, err := s.storage.GetCollection(id)
+	if err != nil {
+		return DryRun{}, err
+	}
+	if !containsString(stored.CoffeeIDs, coffeeID) {
+		return DryRun{}, fmt.Errorf("coffee not in collection")
+	}
+	previous, err := s.GetCollection(id)
+	if err != nil {
+		return DryRun{}, err
+	}
+	collection := previous
+	collection.CoffeeIDs = []string{}
+	for _, id := range previous.CoffeeIDs {
+		if id != coffeeID {
+			collection.CoffeeIDs = append(collection.CoffeeIDs, id)
+		}
+	}
+	return previewUpdate(previous, collection)
+}
+
+// ListCoffees returns the coffees in a collection, in the order they were added
+func (s *CollectionService) ListCoffees(id string) ([]models.Coffee, error) {
+	collection, err := s.storage.GetCollection(id)
+	if err != nil {
+		return nil, err
+	}
+	coffees, err := s.coffeeService.ListCoffees()
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]models.Coffee, len(coffees))
+	for _, coffee := range coffees {
+		byID[coffee.ID] = coffee
+	}
+
+	members := []models.Coffee{}
+	for _, coffeeID := range collection.CoffeeIDs {
+		if coffee, ok := byID[coffeeID]; ok {
+			members = append(members, coffee)
+		}
+	}
+	return members, nil
+}
+
+// coffeeIDs returns the IDs of every stored coffee
+func (s *CollectionService) coffeeIDs() (map[string]bool, error) {
+	coffees, err := s.coffeeService.ListCoffees()
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[string]bool, len(coffees))
+	for _, coffee := range coffees {
+		ids[coffee.ID] = true
+	}
+	return ids, nil
+}
+
+// keepExisting drops IDs of coffees that no longer exist
+func keepExisting(coffeeIDs []string, existing map[string]bool) []string {
+	kept := []string{}
+	for _, id := range coffeeIDs {
+		if existing[id] {
+			kept = append(kept, id)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,255 @@
+package service
+
+import (
+	"fmt"
+	"go-coffee-log/models"
+	"go-coffee-log/storage"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// anonymousAuthor names comments posted without authentication
+const anonymousAuthor = "anonymous"
+
+// Commenter identifies who is writing, editing or deleting a comment.
+// An empty UserID means the server runs without authentication.
+type Commenter struct {
+	UserID  string
+	Name    string
+	IsAdmin bool
+}
+
+// CommentService handles threaded comments on coffees
+type CommentService struct {
+	storage       storage.CommentStorage
+	coffeeService *CoffeeService
+	bus           *EventBus
+}
+
+// NewCommentService creates a new comment service
+func NewCommentService(storage storage.CommentStorage, coffeeService *CoffeeService, bus *EventBus) *CommentService {
+	return &CommentService{
+		storage:       storage,
+		coffeeService: coffeeService,
+		bus:           bus,
+	}
+}
+
+// AddComment posts a comment on a coffee, or a reply when parentID is set
+func (s *CommentService) AddComment(coffeeID, parentID, body string, author Commenter) (models.Comment, error) {
+	comment, coffee, err := s.newComment(coffeeID, parentID, body, author)
+	if err != nil {
+		return models.Comment{}, err
+	}
+
+	if err := s.storage.SaveComment(comment); err != nil {
+		return models.Comment{}, err
+	}
+
+	s.bus.Publish(Event{
+		Type:     "comment.added",
+		Message:  fmt.Sprintf("%s commented on %s", comment.AuthorName, coffee.Name),
+		CoffeeID: coffeeID,
+		Data: map[string]interface{}{
+			"comment_id": comment.ID,
+			"parent_id":  comment.ParentID,
+		},
+	})
+
+	return comment, nil
+}
+
+// PreviewAddComment validates a comment like AddComment, without posting it
+func (s *CommentService) PreviewAddComment(coffeeID, parentID, body string, author Commenter) (DryRun, error) {
+	comment, _, err := s.newComment(coffeeID, parentID, body, author)
+	if err != nil {
+		return DryRun{}, err
+	}
+	return previewCreate(comment), nil
+}
+
+// newComment builds and validates a comment on a stored coffee
+func (s *CommentService) newComment(coffeeID, parentID, body string, author Commenter) (models.Comment, models.Coffee, error) {
+	coffee, err := s.coffeeService.GetCoffee(coffeeID)
+	if err != nil {
+		return models.Comment{}, models.Coffee{}, err
+	}
+
+	if parentID != "" {
+		parent, err := s.storage.GetComment(parentID)
+		if err != nil || parent.CoffeeID != coffeeID {
+			return models.Comment{}, models.Coffee{}, fmt.Errorf("parent comment not found")
+		}
+	}
+
+	now := time.Now()
+	comment := models.Comment{
+		ID:         uuid.New().String(),
+		CoffeeID:   coffeeID,
+		ParentID:   parentID,
+		AuthorID:   author.UserID,
+		AuthorName: author.Name,
+		Body:       body,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if comment.AuthorName == "" {
+		comment.AuthorName = anonymousAuthor
+	}
+	if err := comment.Validate(); err != nil {
+		return models.Comment{}, models.Coffee{}, err
+	}
+	return comment, coffee, nil
+}
+
+// ListComments retrieves the comment threads of a coffee, oldest first
+func (s *CommentService) ListComments(coffeeID string) ([]models.Comment, error) {
+	if _, err := s.coffeeService.GetCoffee(coffeeID); err != nil {
+		return nil, err
+	}
+
+	comments, err := s.storage.GetCommentsByCoffee(coffeeID)
+	if err != nil {
+		return nil, err
+	}
+	return buildThreads(comments), nil
+}
+
+// UpdateComment edits the body of a comment. Only its author may edit it.
+func (s *CommentService) UpdateComment(coffeeID, commentID, body string, editor Commenter) (models.Comment, error) {
+	_, comment, err := s.editComment(coffeeID, commentID, body, editor)
+	if err != nil {
+		return models.Comment{}, err
+	}
+
+	if err := s.storage.UpdateComment(comment); err != nil {
+		return models.Comment{}, err
+	}
+	return comment, nil
+}
+
+// PreviewUpdateComment validates an edit like UpdateComment, without saving it
+func (s *CommentService) PreviewUpdateComment(coffeeID, commentID, body string, editor Commenter) (DryRun, error) {
+	previous, comment, err := s.editComment(coffeeID, commentID, body, editor)
+	if err != nil {
+		return DryRun{}, err
+	}
+	return previewUpdate(previous, comment)
+}
+
+// editComment returns a stored comment and the comment with a new body
+func (s *CommentService) editComment(coffeeID, commentID, body string, editor Commenter) (models.Comment, models.Comment, error) {
+	previous, err := s.getComment(coffeeID, commentID)
+	if err != nil {
+		return models.Comment{}, models.Comment{}, err
+	}
+	if previous.AuthorID != editor.UserID {
+		return models.Comment{}, models.Comment{}, fmt.Errorf("not allowed: only the author can edit a comment")
+	}
+
+	comment := previous
+	comment.Body = body
+	comment.UpdatedAt = time.Now()
+	if err := comment.Validate(); err != nil {
+		return models.Comment{}, models.Comment{}, err
+	}
+	return previous, comment, nil
+}
+
+// DeleteComment removes a comment and all replies below it. The author or
+// an admin may delete.
+func (s *CommentService) DeleteComment(coffeeID, commentID string, editor Commenter) error {
+	thread, err := s.commentThread(coffeeID, commentID, editor)
+	if err != nil {
+		return err
+	}
+
+	ids := make([]string, len(thread))
+	for i, comment := range thread {
+		ids[i] = comment.ID
+	}
+	return s.storage.DeleteComments(ids)
+}
+
+// PreviewDeleteComment reports the comment and replies DeleteComment would
+// remove
+func (s *CommentService) PreviewDeleteComment(coffeeID, commentID string, editor Commenter) (DryRun, error) {
+	thread, err := s.commentThread(coffeeID, commentID, editor)
+	if err != nil {
+		return DryRun{}, err
+	}
+	return previewDelete(thread), nil
+}
+
+// commentThread returns a comment the editor may delete, followed by all
+// replies below it
+func (s *CommentService) commentThread(coffeeID, commentID string, editor Commenter) ([]models.Comment, error) {
+	comment, err := s.getComment(coffeeID, commentID)
+	if err != nil {
+		return nil, err
+	}
+	if comment.AuthorID != editor.UserID && !editor.IsAdmin {
+		return nil, fmt.Errorf("not allowed: only the author can delete a comment")
+	}
+
+	comments, err := s.storage.GetCommentsByCoffee(coffeeID)
+	if err != nil {
+		return nil, err
+	}
+
+	children := make(map[string][]models.Comment)
+	for _, c := range comments {
+		children[c.ParentID] = append(children[c.ParentID], c)
+	}
+
+	thread := []models.Comment{comment}
+	for i := 0; i < len(thread); i++ {
+		thread = append(thread, children[thread[i].ID]...)
+	}
+	return thread, nil
+}
+
+// getComment retrieves a comment, making sure it belongs to the coffee
+func (s *CommentService) getComment(coffeeID, commentID string) (models.Comment, error) {
+	comment, err := s.storage.GetComment(commentID)
+	if err != nil {
+		return models.Comment{}, err
+	}
+	if comment.CoffeeID != coffeeID {
+		return models.Comment{}, fmt.Errorf("comment not found")
+	}
+	return comment, nil
+}
+
+// buildThreads nests replies under their parents. Comments must be sorted
+// oldest first; replies whose parent is missing are shown at the top level.
+func buildThreads(comments []models.Comment) []models.Comment {
+	byID := make(map[string]bool, len(comments))
+	children := make(map[string][]models.Comment)
+	for _, comment := range comments {
+		byID[comment.ID] = true
+	}
+	for _, comment := range comments {
+		parent := comment.ParentID
+		if !byID[parent] {
+			parent = ""
+		}
+		children[parent] = append(children[parent], comment)
+	}
+
+	var attach func(parentID string) []models.Comment
+	attach = func(parentID string) []models.Comment {
+		thread := []models.Comment{}
+		for _, comment := range children[parentID] {
+			comment.Replies = attach(comment.ID)
+			if len(comment.Replies) == 0 {
+				comment.Replies = nil
+			}
+			thread = append(thread, comment)
+		}
+		return thread
+	}
+
+	return attach("")
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"fmt"
+	"go-coffee-log/models"
+	"math"
+	"sort"
+	"strings"
+)
+
+// DefaultLowConfidence is the mapping confidence below which a catch is
+// flagged for a re-roll. Fallback mappings score 0.5, so they are flagged.
+const DefaultLowConfidence = 0.6
+
+// confidenceBuckets is the number of equal-width histogram buckets over [0, 1]
+const confidenceBuckets = 10
+
+// ConfidenceBucket counts the mappings with a confidence in [Min, Max), or
+// [Min, Max] for the last bucket
+type ConfidenceBucket struct {
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Count int     `json:"count"`
+}
+
+// LowConfidenceMapping is a catch flagged for a re-roll
+type LowConfidenceMapping struct {
+	CoffeeID          string  `json:"coffee_id"`
+	PokemonID         int     `json:"pokemon_id"`
+	PokemonName       string  `json:"pokemon_name"`
+	Level             int     `json:"level"`
+	MappingConfidence float64 `json:"mapping_confidence"`
+	Fallback          bool    `json:"fallback"` // made by a fallback rather than a real match
+	Reroll            string  `json:"reroll"`   // the request that catches again
+}
+
+// ConfidenceReport is the spread of mapping confidence in a dex profile
+// and the catches sketchy enough to re-roll
+type ConfidenceReport struct {
+	Threshold     float64                `json:"threshold"`
+	Histogram     []ConfidenceBucket     `json:"histogram"`
+	LowConfidence []LowConfidenceMapping `json:"low_confidence"`
+}
+
+// BuildConfidenceReport buckets the mappings of a dex profile by confidence
+// and flags those below threshold, or made by a fallback, least confident
+// first
+func BuildConfidenceReport(dex string, mappings []models.CoffeePokemon, threshold float64) ConfidenceReport {
+	report := ConfidenceReport{
+		Threshold:     threshold,
+		Histogram:     make([]ConfidenceBucket, confidenceBuckets),
+		LowConfidence: []LowConfidenceMapping{},
+	}
+	for i := range report.Histogram {
+		report.Histogram[i] = ConfidenceBucket{
+			Min: float64(i) / confidenceBuckets,
+			Max: float64(i+1) / confidenceBuckets,
+		}
+	}
+
+	for _, mapping := range mappings {
+		confidence := math.Max(0, math.Min(1, mapping.MappingConfidence))
+		bucket := min(int(confidence*confidenceBuckets), confidenceBuckets-1)
+		report.Histogram[bucket].Count++
+
+		fallback := strings.HasPrefix(mapping.LLMDescription, "Fallback mapping")
+		if mapping.MappingConfidence >= threshold && !fallback {
+			continue
+		}
+		report.LowConfidence = append(report.LowConfidence, LowConfidenceMapping{
+			CoffeeID:          mapping.CoffeeID,
+			PokemonID:         mapping.PokemonID,
+			PokemonName:       mapping.PokemonName,
+			Level:             mapping.Level,
+			MappingConfidence: mapping.MappingConfidence,
+			Fallback:          fallback,
+			Reroll:            fmt.Sprintf("POST /pokemon/%s/reroll?dex=%s&exclude_current=true", mapping.CoffeeID, dex),
+		})
+	}
+
+	sort.SliceStable(report.LowConfidence, func(i, j int) bool {
+		return report.LowConfidence[i].MappingConfidence < report.LowConfidence[j].MappingConfidence
+	})
+	return report
+}
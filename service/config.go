@@ -0,0 +1,127 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// RuntimeConfig holds the settings that can be changed without a restart.
+// A config file only needs the keys it changes; the rest keep the values
+// given on the command line.
+type RuntimeConfig struct {
+	OllamaModel         string                        `json:"ollama_model"`
+	LLMDailyTokenBudget int                           `json:"llm_daily_token_budget"`
+	StaleAfterDays      int                           `json:"stale_after_days"`
+	ReminderWarnDays    int                           `json:"reminder_warn_days"`
+	PublicRead          bool                          `json:"public_read"`
+	SharedCollection    bool                          `json:"shared_collection"`
+	MaxCoffees          int                           `json:"max_coffees"`
+	MaxPhotoBytes       int64                         `json:"max_photo_bytes"`
+	MapperRules         map[string]MapperRuleOverride `json:"mapper_rules,omitempty"`
+	Jobs                map[string]string             `json:"jobs,omitempty"` // job name -> cron expression or "off"
+}
+
+// Validate checks that the settings make sense together
+func (c *RuntimeConfig) Validate() error {
+	if c.OllamaModel == "" {
+		return fmt.Errorf("ollama_model must not be empty")
+	}
+	if c.LLMDailyTokenBudget < 0 {
+		return fmt.Errorf("llm_daily_token_budget must not be negative")
+	}
+	if c.StaleAfterDays < 1 {
+		return fmt.Errorf("stale_after_days must be at least 1")
+	}
+	if c.ReminderWarnDays < 0 || c.ReminderWarnDays >= c.StaleAfterDays {
+		return fmt.Errorf("reminder_warn_days must be between 0 and stale_after_days")
+	}
+	if c.MaxCoffees < 0 || c.MaxPhotoBytes < 0 {
+		return fmt.Errorf("max_coffees and max_photo_bytes must not be negative")
+	}
+	return nil
+}
+
+// LoadRuntimeConfig reads a JSON config file over defaults
+func LoadRuntimeConfig(path string, defaults RuntimeConfig) (*RuntimeConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	config := defaults
+	config.MapperRules = nil
+	config.Jobs = nil
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&config); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+
+	return &config, nil
+}
+
+// ConfigWatcher reloads a config file when it changes on disk or when
+// asked to (e.g. on SIGHUP). A file that fails to load or apply is logged
+// and the running settings stay as they were.
+type ConfigWatcher struct {
+	path     string
+	defaults RuntimeConfig
+	apply    func(*RuntimeConfig) error
+	modTime  time.Time
+}
+
+// NewConfigWatcher creates a watcher that passes each loaded config to apply
+func NewConfigWatcher(path string, defaults RuntimeConfig, apply func(*RuntimeConfig) error) *ConfigWatcher {
+	return &ConfigWatcher{
+		path:     path,
+		defaults: defaults,
+		apply:    apply,
+	}
+}
+
+// Reload loads and applies the config file
+func (w *ConfigWatcher) Reload() error {
+	if info, err := os.Stat(w.path); err == nil {
+		w.modTime = info.ModTime()
+	}
+
+	config, err := LoadRuntimeConfig(w.path, w.defaults)
+	if err != nil {
+		return err
+	}
+	return w.apply(config)
+}
+
+// Run reloads whenever reload fires or the file's modification time
+// changes, polling every interval, until ctx is cancelled
+func (w *ConfigWatcher) Run(ctx context.Context, interval time.Duration, reload <-chan os.Signal) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-reload:
+		case <-ticker.C:
+			info, err := os.Stat(w.path)
+			if err != nil || info.ModTime().Equal(w.modTime) {
+				continue
+			}
+		}
+
+		if err := w.Reload(); err != nil {
+			log.Printf("Config: reload failed, keeping current settings: %v", err)
+			continue
+		}
+		log.Printf("Config: reloaded %s", w.path)
+	}
+}
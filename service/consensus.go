@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"go-coffee-log/models"
+	"go-coffee-log/storage"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// consensusVote accumulates the weight behind a single Pokemon
+type consensusVote struct {
+	pokemon      models.Pokemon
+	weight       float64
+	description  string
+	traitMapping []models.TraitMapping
+	fromLLM      bool
+}
+
+// EnableConsensus turns on multi-model consensus mapping. Every model in
+// llms proposes a Pokemon alongside the rule-based mapper and the winner is
+// picked by confidence weighting. Rounds where the proposals differ are
+// recorded in consensusStorage for later analysis.
+func (s *PokemonService) EnableConsensus(llms []*LLMService, consensusStorage storage.ConsensusStorage) {
+	s.consensusLLMs = llms
+	s.consensusStorage = consensusStorage
+}
+
+// ConsensusEnabled reports whether consensus mapping is active
+func (s *PokemonService) ConsensusEnabled() bool {
+	return len(s.consensusLLMs) > 0
+}
+
+// GetDisagreements returns the recorded consensus disagreements
+func (s *PokemonService) GetDisagreements() ([]models.MappingDisagreement, error) {
+	if s.consensusStorage == nil {
+		return nil, fmt.Errorf("consensus mode is not enabled")
+	}
+	return s.consensusStorage.GetAllDisagreements()
+}
+
+// selectByConsensus collects proposals from the rule-based mapper and every
+// consensus model, then picks the Pokemon with the highest summed confidence
+func (s *PokemonService) selectByConsensus(ctx context.Context, coffee models.Coffee, candidates []models.Pokemon, primaryType string, typeScore float64) (*models.Pokemon, float64, string, []models.TraitMapping) {
+	var proposals []models.MappingProposal
+	votes := make(map[int]*consensusVote)
+	var order []int
+
+	addVote := func(source string, pokemon models.Pokemon, confidence float64, description string, traitMapping []models.TraitMapping) {
+		proposals = append(proposals, models.MappingProposal{
+			Source:      source,
+			PokemonID:   pokemon.ID,
+			PokemonName: pokemon.Name,
+			Confidence:  confidence,
+		})
+
+		vote, ok := votes[pokemon.ID]
+		if !ok {
+			vote = &consensusVote{pokemon: pokemon}
+			votes[pokemon.ID] = vote
+			order = append(order, pokemon.ID)
+		}
+		vote.weight += confidence
+
+		// Prefer LLM-written descriptions over the rule-based summary
+		isLLM := strings.HasPrefix(source, "llm:")
+		if vote.description == "" || (isLLM && !vote.fromLLM) {
+			vote.description = description
+			vote.traitMapping = traitMapping
+			vote.fromLLM = isLLM
+		}
+	}
+
+	rulePokemon, ruleConfidence, ruleDescription, ruleTraits := s.getBestTypeMatch(coffee, candidates, primaryType, typeScore)
+	addVote("rule-based", *rulePokemon, ruleConfidence, ruleDescription, ruleTraits)
+
+	for _, llm := range s.consensusLLMs {
+		source := "llm:" + llm.Model()
+		response, err := llm.MapCoffeeToPokemon(ctx, coffee, candidates)
+		if err != nil {
+			log.Printf("Consensus: %s failed, skipping its vote: %v", source, err)
+			continue
+		}
+		source = "llm:" + response.Model
+
+		var selected *models.Pokemon
+		for i := range candidates {
+			if strings.EqualFold(candidates[i].Name, response.SelectedPokemon) {
+				selected = &candidates[i]
+				break
+			}
+		}
+		if selected == nil {
+			log.Printf("Consensus: %s selected unknown Pokemon %s, skipping its vote", source, response.SelectedPokemon)
+			continue
+		}
+
+		addVote(source, *selected, response.Confidence, response.Description, response.TraitMapping)
+	}
+
+	// Pick the heaviest vote; ties go to the earliest proposal
+	var winner *consensusVote
+	totalWeight := 0.0
+	for _, id := range order {
+		vote := votes[id]
+		totalWeight += vote.weight
+		if winner == nil || vote.weight > winner.weight {
+			winner = vote
+		}
+	}
+
+	agreement := 1.0
+	if totalWeight > 0 {
+		agreement = winner.weight / totalWeight
+	}
+
+	supporters := 0
+	for _, proposal := range proposals {
+		if proposal.PokemonID == winner.pokemon.ID {
+			supporters++
+		}
+	}
+	confidence := (winner.weight / float64(supporters)) * agreement
+
+	if len(votes) > 1 {
+		s.recordDisagreement(coffee.ID, winner.pokemon.Name, agreement, proposals)
+	}
+
+	log.Printf("Consensus: selected %s with %.0f%% agreement across %d proposals",
+		winner.pokemon.Name, agreement*100, len(proposals))
+
+	pokemon := winner.pokemon
+	return &pokemon, confidence, winner.description, winner.traitMapping
+}
+
+// recordDisagreement persists a disagreement without failing the mapping
+func (s *PokemonService) recordDisagreement(coffeeID, selected string, agreement float64, proposals []models.MappingProposal) {
+	if s.consensusStorage == nil {
+		return
+	}
+
+	disagreement := models.MappingDisagreement{
+		ID:              uuid.New().String(),
+		CoffeeID:        coffeeID,
+		SelectedPokemon: selected,
+		Agreement:       agreement,
+		Proposals:       proposals,
+		CreatedAt:       time.Now(),
+	}
+
+	if err := s.consensusStorage.SaveDisagreement(disagreement); err != nil {
+		log.Printf("Consensus: failed to record disagreement for coffee %s: %v", coffeeID, err)
+	}
+}
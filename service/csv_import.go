@@ -0,0 +1,536 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"go-coffee-log/models"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxCSVImportSize caps the size of an uploaded CSV file
+const MaxCSVImportSize = 8 << 20
+
+const (
+	csvSampleRows = 5         // rows shown in an upload's preview
+	csvUploadTTL  = time.Hour // how long an upload waits for its mapping
+	maxCSVUploads = 20        // pending uploads kept at once
+)
+
+// CSV column transforms. A mapping without a transform uses its field's
+// default.
+const (
+	CSVText     = "text"     // the trimmed cell
+	CSVInt      = "int"      // a whole number; "8.5" rounds to 9
+	CSVScale5   = "scale_5"  // a number out of 5, doubled to be out of 10
+	CSVList     = "list"     // split on commas, semicolons or pipes
+	CSVDuration = "duration" // "3:45", "3m45s", "225s" or "225" to a draw down time
+	CSVSeconds  = "seconds"  // a number of seconds to a draw down time
+	CSVDate     = "date"     // YYYY-MM-DD, DD.MM.YYYY or MM/DD/YYYY
+	CSVBool     = "bool"     // yes/no, true/false, 1/0, x or blank
+)
+
+// csvField is a coffee field a CSV column can fill in
+type csvField struct {
+	transforms []string // the first is the default
+	set        func(coffee *models.Coffee, value interface{})
+}
+
+// csvTextField fills in a text field
+func csvTextField(set func(coffee *models.Coffee, value string)) csvField {
+	return csvField{
+		transforms: []string{CSVText},
+		set:        func(coffee *models.Coffee, value interface{}) { set(coffee, value.(string)) },
+	}
+}
+
+// csvNumberField fills in a 0-10 score such as the rating or a trait
+func csvNumberField(set func(coffee *models.Coffee, value int)) csvField {
+	return csvField{
+		transforms: []string{CSVInt, CSVScale5},
+		set:        func(coffee *models.Coffee, value interface{}) { set(coffee, value.(int)) },
+	}
+}
+
+// csvFields are the coffee fields columns can map to. Several columns may
+// map to tasting_notes or recipe; the rest take one column each.
+var csvFields = map[string]csvField{
+	"name":              csvTextField(func(c *models.Coffee, v string) { c.Name = v }),
+	"origin":            csvTextField(func(c *models.Coffee, v string) { c.Origin = v }),
+	"roaster":           csvTextField(func(c *models.Coffee, v string) { c.Roaster = v }),
+	"variety":           csvTextField(func(c *models.Coffee, v string) { c.Variety = v }),
+	"roast_level":       csvTextField(func(c *models.Coffee, v string) { c.RoastLevel = strings.ToLower(v) }),
+	"processing_method": csvTextField(func(c *models.Coffee, v string) { c.ProcessingMethod = strings.ToLower(v) }),
+	"dripper":           csvTextField(func(c *models.Coffee, v string) { c.Dripper = v }),
+	"rating":            csvNumberField(func(c *models.Coffee, v int) { c.Rating = v }),
+	"tasting_notes": {
+		transforms: []string{CSVList, CSVText},
+		set: func(c *models.Coffee, v interface{}) {
+			for _, note := range v.([]string) {
+				for i := range c.TastingNotes {
+					if c.TastingNotes[i] == "" {
+						c.TastingNotes[i] = note
+						break
+					}
+				}
+			}
+		},
+	},
+	"recipe": {
+		transforms: []string{CSVList, CSVText},
+		set:        func(c *models.Coffee, v interface{}) { c.Recipe = append(c.Recipe, v.([]string)...) },
+	},
+	"end_time": {
+		transforms: []string{CSVDuration, CSVSeconds},
+		set:        func(c *models.Coffee, v interface{}) { c.EndTime = v.(models.DrawDownTime) },
+	},
+	"roast_date": {
+		transforms: []string{CSVDate},
+		set: func(c *models.Coffee, v interface{}) {
+			date := v.(models.Date)
+			c.RoastDate = &date
+		},
+	},
+	"favorite": {
+		transforms: []string{CSVBool},
+		set:        func(c *models.Coffee, v interface{}) { c.Favorite = v.(bool) },
+	},
+	"berry_intensity":         csvNumberField(func(c *models.Coffee, v int) { c.TastingTraits.BerryIntensity = v }),
+	"stonefruit_intensity":    csvNumberField(func(c *models.Coffee, v int) { c.TastingTraits.StonefruitIntensity = v }),
+	"roast_intensity":         csvNumberField(func(c *models.Coffee, v int) { c.TastingTraits.RoastIntensity = v }),
+	"citrus_fruits_intensity": csvNumberField(func(c *models.Coffee, v int) { c.TastingTraits.CitrusFruitsIntensity = v }),
+	"bitterness":              csvNumberField(func(c *models.Coffee, v int) { c.TastingTraits.Bitterness = v }),
+	"florality":               csvNumberField(func(c *models.Coffee, v int) { c.TastingTraits.Florality = v }),
+	"spice":                   csvNumberField(func(c *models.Coffee, v int) { c.TastingTraits.Spice = v }),
+	"sweetness":               csvNumberField(func(c *models.Coffee, v int) { c.TastingTraits.Sweetness = v }),
+	"aromatic_intensity":      csvNumberField(func(c *models.Coffee, v int) { c.TastingTraits.AromaticIntensity = v }),
+	"savory":                  csvNumberField(func(c *models.Coffee, v int) { c.TastingTraits.Savory = v }),
+	"body":                    csvNumberField(func(c *models.Coffee, v int) { c.TastingTraits.Body = v }),
+	"cleanliness":             csvNumberField(func(c *models.Coffee, v int) { c.TastingTraits.Cleanliness = v }),
+}
+
+// csvFieldAliases are common header names for coffee fields, normalized
+var csvFieldAliases = map[string]string{
+	"coffee":         "name",
+	"coffee_name":    "name",
+	"bean":           "name",
+	"country":        "origin",
+	"region":         "origin",
+	"roastery":       "roaster",
+	"varietal":       "variety",
+	"roast":          "roast_level",
+	"process":        "processing_method",
+	"processing":     "processing_method",
+	"brewer":         "dripper",
+	"method":         "dripper",
+	"brew_method":    "dripper",
+	"score":          "rating",
+	"notes":          "tasting_notes",
+	"tasting_notes":  "tasting_notes",
+	"flavors":        "tasting_notes",
+	"flavor_notes":   "tasting_notes",
+	"time":           "end_time",
+	"brew_time":      "end_time",
+	"drawdown":       "end_time",
+	"draw_down_time": "end_time",
+	"roasted":        "roast_date",
+	"roasted_on":     "roast_date",
+	"fav":            "favorite",
+	"favourite":      "favorite",
+	"acidity":        "citrus_fruits_intensity",
+	"citrus":         "citrus_fruits_intensity",
+	"berry":          "berry_intensity",
+	"stonefruit":     "stonefruit_intensity",
+	"floral":         "florality",
+	"aroma":          "aromatic_intensity",
+}
+
+// CSVColumnMapping maps one CSV column to a coffee field
+type CSVColumnMapping struct {
+	Column    string `json:"column"`
+	Field     string `json:"field"`
+	Transform string `json:"transform,omitempty"` // the field's default if empty
+}
+
+// CSVPreview is what an upload found: its columns, the first rows and a
+// suggested mapping to start the wizard from
+type CSVPreview struct {
+	ID         string              `json:"id"`
+	Columns    []string            `json:"columns"`
+	SampleRows [][]string          `json:"sample_rows"`
+	RowCount   int                 `json:"row_count"`
+	Suggested  []CSVColumnMapping  `json:"suggested_mapping"`
+	Fields     map[string][]string `json:"fields"` // field -> transforms, default first
+	ExpiresAt  time.Time           `json:"expires_at"`
+}
+
+// CSVImportRequest is the mapping that drives an import
+type CSVImportRequest struct {
+	Mapping []CSVColumnMapping `json:"mapping"`
+	DryRun  bool               `json:"dry_run"`
+	AddedBy string             `json:"-"` // the importing user, whose quota the coffees count against
+}
+
+// CSVRowError is a row that could not be imported
+type CSVRowError struct {
+	Row   int    `json:"row"` // the first row below the header is 1
+	Error string `json:"error"`
+}
+
+// CSVImportReport reports what an import created, or would create
+type CSVImportReport struct {
+	DryRun   bool            `json:"dry_run"`
+	Imported int             `json:"imported"`
+	Coffees  []models.Coffee `json:"coffees"`
+	Errors   []CSVRowError   `json:"errors"`
+}
+
+// csvUpload is an uploaded file waiting for its mapping
+type csvUpload struct {
+	columns   []string
+	rows      [][]string
+	expiresAt time.Time
+}
+
+// CSVImportService imports coffees from spreadsheets in two steps: an
+// upload is parsed and previewed, then a column mapping chosen from the
+// preview drives the import. Uploads are kept in memory until imported or
+// expired.
+type CSVImportService struct {
+	coffeeService *CoffeeService
+
+	mu      sync.Mutex
+	uploads map[string]csvUpload
+}
+
+// NewCSVImportService creates a new CSV import service
+func NewCSVImportService(coffeeService *CoffeeService) *CSVImportService {
+	return &CSVImportService{
+		coffeeService: coffeeService,
+		uploads:       make(map[string]csvUpload),
+	}
+}
+
+// Upload parses a CSV file with a header row and keeps it for the import
+func (s *CSVImportService) Upload(data []byte) (*CSVPreview, error) {
+	columns, rows, err := parseCSV(data)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	for id, upload := range s.uploads {
+		if now.After(upload.expiresAt) {
+			delete(s.uploads, id)
+		}
+	}
+	if len(s.uploads) >= maxCSVUploads {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("too many pending CSV uploads; import or wait for one to expire")
+	}
+	id := uuid.New().String()
+	upload := csvUpload{columns: columns, rows: rows, expiresAt: now.Add(csvUploadTTL)}
+	s.uploads[id] = upload
+	s.mu.Unlock()
+
+	preview := &CSVPreview{
+		ID:         id,
+		Columns:    columns,
+		SampleRows: rows[:min(len(rows), csvSampleRows)],
+		RowCount:   len(rows),
+		Suggested:  suggestCSVMapping(columns),
+		Fields:     make(map[string][]string, len(csvFields)),
+		ExpiresAt:  upload.expiresAt,
+	}
+	for name, field := range csvFields {
+		preview.Fields[name] = field.transforms
+	}
+	return preview, nil
+}
+
+// Import applies a mapping to every row of an upload and, unless this is a
+// dry run, creates the coffees. Rows that fail are reported and skipped; an
+// upload is forgotten once it has been imported.
+func (s *CSVImportService) Import(id string, req CSVImportRequest) (*CSVImportReport, error) {
+	s.mu.Lock()
+	upload, ok := s.uploads[id]
+	if ok && time.Now().After(upload.expiresAt) {
+		delete(s.uploads, id)
+		ok = false
+	}
+	s.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("CSV upload not found")
+	}
+
+	if err := validateCSVMapping(req.Mapping, upload.columns); err != nil {
+		return nil, err
+	}
+	index := make(map[string]int, len(upload.columns))
+	for i, column := range upload.columns {
+		index[column] = i
+	}
+
+	report := &CSVImportReport{DryRun: req.DryRun, Coffees: []models.Coffee{}, Errors: []CSVRowError{}}
+	for i, row := range upload.rows {
+		coffee, err := mapCSVRow(row, req.Mapping, index)
+		coffee.AddedBy = req.AddedBy
+		if err == nil {
+			err = coffee.Validate()
+		}
+		if err == nil && !req.DryRun {
+			coffee, err = s.coffeeService.CreateCoffee(coffee)
+		}
+		if err != nil {
+			report.Errors = append(report.Errors, CSVRowError{Row: i + 1, Error: err.Error()})
+			continue
+		}
+		report.Imported++
+		report.Coffees = append(report.Coffees, coffee)
+	}
+
+	if !req.DryRun {
+		s.mu.Lock()
+		delete(s.uploads, id)
+		s.mu.Unlock()
+	}
+	return report, nil
+}
+
+// parseCSV reads the header and data rows, guessing the delimiter from the
+// header line. Blank rows are dropped and short rows padded.
+func parseCSV(data []byte) ([]string, [][]string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	header, _, _ := bytes.Cut(data, []byte("\n"))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = ','
+	for _, delimiter := range []rune{';', '\t'} {
+		if bytes.Count(header, []byte(string(delimiter))) > bytes.Count(header, []byte(string(reader.Comma))) {
+			reader.Comma = delimiter
+		}
+	}
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, nil, fmt.Errorf("invalid CSV: the file is empty")
+	}
+
+	columns := make([]string, len(records[0]))
+	seen := make(map[string]bool, len(columns))
+	for i, column := range records[0] {
+		column = strings.TrimSpace(column)
+		if column == "" {
+			column = fmt.Sprintf("column %d", i+1)
+		}
+		if seen[column] {
+			return nil, nil, fmt.Errorf("invalid CSV: column %q appears twice", column)
+		}
+		seen[column] = true
+		columns[i] = column
+	}
+
+	rows := [][]string{}
+	for _, record := range records[1:] {
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+		row := make([]string, len(columns))
+		copy(row, record)
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return nil, nil, fmt.Errorf("invalid CSV: no rows below the header")
+	}
+	return columns, rows, nil
+}
+
+var csvHeaderSeparators = regexp.MustCompile(`[^a-z0-9]+`)
+
+// suggestCSVMapping maps the columns whose header names a coffee field
+func suggestCSVMapping(columns []string) []CSVColumnMapping {
+	suggested := []CSVColumnMapping{}
+	taken := make(map[string]bool)
+	for _, column := range columns {
+		key := strings.Trim(csvHeaderSeparators.ReplaceAllString(strings.ToLower(column), "_"), "_")
+		field, ok := csvFieldAliases[key]
+		if !ok {
+			if _, known := csvFields[key]; !known {
+				continue
+			}
+			field = key
+		}
+		if taken[field] && !csvMultiColumn(field) {
+			continue
+		}
+		taken[field] = true
+		suggested = append(suggested, CSVColumnMapping{Column: column, Field: field, Transform: csvFields[field].transforms[0]})
+	}
+	return suggested
+}
+
+// csvMultiColumn reports whether several columns may map to a field
+func csvMultiColumn(field string) bool {
+	return field == "tasting_notes" || field == "recipe"
+}
+
+// validateCSVMapping checks columns, fields and transforms before any row
+// is read, so a bad mapping fails as a whole
+func validateCSVMapping(mapping []CSVColumnMapping, columns []string) error {
+	if len(mapping) == 0 {
+		return fmt.Errorf("invalid mapping: map at least one column")
+	}
+	known := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		known[column] = true
+	}
+
+	mapped := make(map[string]bool)
+	hasName := false
+	for _, m := range mapping {
+		if !known[m.Column] {
+			return fmt.Errorf("invalid mapping: no column %q in the upload", m.Column)
+		}
+		field, ok := csvFields[m.Field]
+		if !ok {
+			return fmt.Errorf("invalid mapping: unknown field %q for column %q; use %s", m.Field, m.Column, strings.Join(csvFieldNames(), ", "))
+		}
+		if m.Transform != "" && !containsString(field.transforms, m.Transform) {
+			return fmt.Errorf("invalid mapping: transform %q does not apply to %s; use %s", m.Transform, m.Field, strings.Join(field.transforms, ", "))
+		}
+		if mapped[m.Field] && !csvMultiColumn(m.Field) {
+			return fmt.Errorf("invalid mapping: field %s is mapped twice", m.Field)
+		}
+		mapped[m.Field] = true
+		hasName = hasName || m.Field == "name"
+	}
+	if !hasName {
+		return fmt.Errorf("invalid mapping: a column must map to name")
+	}
+	return nil
+}
+
+// csvFieldNames returns the mappable fields in order
+func csvFieldNames() []string {
+	names := make([]string, 0, len(csvFields))
+	for name := range csvFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// containsString reports whether values include value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// mapCSVRow builds a coffee from one row; blank cells leave fields unset
+func mapCSVRow(row []string, mapping []CSVColumnMapping, index map[string]int) (models.Coffee, error) {
+	var coffee models.Coffee
+	for _, m := range mapping {
+		cell := strings.TrimSpace(row[index[m.Column]])
+		if cell == "" {
+			continue
+		}
+		field := csvFields[m.Field]
+		transform := m.Transform
+		if transform == "" {
+			transform = field.transforms[0]
+		}
+		value, err := transformCSVCell(cell, transform)
+		if err != nil {
+			return models.Coffee{}, fmt.Errorf("%s: %w", m.Column, err)
+		}
+		field.set(&coffee, value)
+	}
+	return coffee, nil
+}
+
+var csvDuration = regexp.MustCompile(`^(?:(\d+)\s*m(?:in)?)?\s*(?:(\d+)\s*s(?:ec)?)?$`)
+
+// transformCSVCell converts a non-blank cell
+func transformCSVCell(cell, transform string) (interface{}, error) {
+	switch transform {
+	case CSVText:
+		return cell, nil
+	case CSVList:
+		items := strings.FieldsFunc(cell, func(r rune) bool { return r == ',' || r == ';' || r == '|' })
+		list := make([]string, 0, len(items))
+		for _, item := range items {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		return list, nil
+	case CSVInt, CSVScale5:
+		value, err := strconv.ParseFloat(strings.TrimSuffix(strings.ReplaceAll(cell, ",", "."), "/10"), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", cell)
+		}
+		if transform == CSVScale5 {
+			value *= 2
+		}
+		return int(math.Round(value)), nil
+	case CSVDuration:
+		if seconds, ok := parseDrawdownSeconds(cell); ok && drawdownPattern.FindString(cell) == cell {
+			return drawDownTime(seconds), nil
+		}
+		if seconds, err := strconv.Atoi(cell); err == nil && seconds >= 0 {
+			return drawDownTime(seconds), nil
+		}
+		match := csvDuration.FindStringSubmatch(strings.ToLower(cell))
+		if match == nil || (match[1] == "" && match[2] == "") {
+			return nil, fmt.Errorf("%q is not a time like 3:45", cell)
+		}
+		minutes, _ := strconv.Atoi(match[1])
+		seconds, _ := strconv.Atoi(match[2])
+		return drawDownTime(minutes*60 + seconds), nil
+	case CSVSeconds:
+		seconds, err := strconv.Atoi(cell)
+		if err != nil || seconds < 0 {
+			return nil, fmt.Errorf("%q is not a number of seconds", cell)
+		}
+		return drawDownTime(seconds), nil
+	case CSVDate:
+		for _, layout := range []string{"2006-01-02", "02.01.2006", "01/02/2006", "2006/01/02"} {
+			if parsed, err := time.Parse(layout, cell); err == nil {
+				return models.NewDate(parsed), nil
+			}
+		}
+		return nil, fmt.Errorf("%q is not a date like 2024-05-01", cell)
+	case CSVBool:
+		switch strings.ToLower(cell) {
+		case "yes", "y", "true", "1", "x":
+			return true, nil
+		case "no", "n", "false", "0":
+			return false, nil
+		}
+		return nil, fmt.Errorf("%q is not yes or no", cell)
+	}
+	return nil, fmt.Errorf("unknown transform %q", transform)
+}
+
+// drawDownTime splits seconds into a draw down time
+func drawDownTime(seconds int) models.DrawDownTime {
+	return models.DrawDownTime{Minutes: seconds / 60, Seconds: seconds % 60}
+}
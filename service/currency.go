@@ -0,0 +1,231 @@
+package service
+
+import (
+	"fmt"
+	"go-coffee-log/models"
+	"go-coffee-log/storage"
+	"sort"
+)
+
+// preferenceCurrency is the preference holding a user's default currency
+const preferenceCurrency = "currency"
+
+// CoffeeValue is one priced coffee in the value statistics
+type CoffeeValue struct {
+	CoffeeID     string        `json:"coffee_id"`
+	Name         string        `json:"name"`
+	Roaster      string        `json:"roaster"`
+	Rating       int           `json:"rating"`
+	Price        models.Money  `json:"price"`               // as entered
+	Converted    *models.Money `json:"converted,omitempty"` // in the requested currency
+	BagGrams     int           `json:"bag_grams,omitempty"`
+	PricePer100g float64       `json:"price_per_100g,omitempty"` // in the requested currency
+	ValueScore   float64       `json:"value_score,omitempty"`    // rating per unit of price per 100 g
+}
+
+// CurrencyTotal is the spend in one currency, as entered
+type CurrencyTotal struct {
+	Currency string  `json:"currency"`
+	Coffees  int     `json:"coffees"`
+	Total    float64 `json:"total"`
+}
+
+// ValueStats sums up what coffees cost and how well they rated for the
+// money. Prices are converted to Currency where an exchange rate is known;
+// the others are listed under Unconverted and left out of the averages.
+type ValueStats struct {
+	Currency            string          `json:"currency"`
+	PricedCoffees       int             `json:"priced_coffees"`
+	TotalSpent          float64         `json:"total_spent"`
+	AveragePrice        float64         `json:"average_price"`
+	AveragePricePer100g float64         `json:"average_price_per_100g"`
+	ByCurrency          []CurrencyTotal `json:"by_currency"`
+	Coffees             []CoffeeValue   `json:"coffees"` // best value first
+	Unconverted         []CoffeeValue   `json:"unconverted"`
+}
+
+// CurrencyService keeps each user's default currency and converts prices
+// between currencies for the value statistics
+type CurrencyService struct {
+	coffeeService *CoffeeService
+	preferences   storage.PreferenceStorage
+	base          string
+	rates         map[string]float64 // value of one unit of the currency in base
+}
+
+// NewCurrencyService creates a new currency service. base is the server's
+// default currency; rates give the value of one unit of other currencies
+// in base, e.g. {"EUR": 1.08} with base USD. Without rates, prices are
+// only compared within the same currency.
+func NewCurrencyService(coffeeService *CoffeeService, preferences storage.PreferenceStorage, base string, rates map[string]float64) (*CurrencyService, error) {
+	base, err := models.NormalizeCurrency(base)
+	if err != nil {
+		return nil, err
+	}
+	normalized := make(map[string]float64, len(rates))
+	for code, rate := range rates {
+		currency, err := models.NormalizeCurrency(code)
+		if err != nil {
+			return nil, err
+		}
+		if rate <= 0 {
+			return nil, fmt.Errorf("invalid exchange rate for %s: must be positive", currency)
+		}
+		normalized[currency] = rate
+	}
+	normalized[base] = 1
+
+	return &CurrencyService{
+		coffeeService: coffeeService,
+		preferences:   preferences,
+		base:          base,
+		rates:         normalized,
+	}, nil
+}
+
+// DefaultCurrency returns the currency a user picked, or the server's
+// default if they haven't
+func (s *CurrencyService) DefaultCurrency(userID string) (string, error) {
+	currency, err := s.preferences.GetPreference(userID, preferenceCurrency)
+	if err != nil {
+		return "", err
+	}
+	if currency == "" {
+		return s.base, nil
+	}
+	return currency, nil
+}
+
+// SetDefaultCurrency records the currency a user enters prices in and
+// reads statistics in
+func (s *CurrencyService) SetDefaultCurrency(userID, code string) (string, error) {
+	currency, err := models.NormalizeCurrency(code)
+	if err != nil {
+		return "", err
+	}
+	if err := s.preferences.SetPreference(userID, preferenceCurrency, currency); err != nil {
+		return "", err
+	}
+	return currency, nil
+}
+
+// ApplyDefaultCurrency fills in the currency of a price entered without
+// one from the user's default
+func (s *CurrencyService) ApplyDefaultCurrency(userID string, coffee *models.Coffee) error {
+	if coffee.Price == nil || coffee.Price.Currency != "" {
+		return nil
+	}
+	currency, err := s.DefaultCurrency(userID)
+	if err != nil {
+		return err
+	}
+	coffee.Price.Currency = currency
+	return nil
+}
+
+// Convert converts money to another currency, reporting false when an
+// exchange rate is missing
+func (s *CurrencyService) Convert(money models.Money, to string) (models.Money, bool) {
+	if money.Currency == to {
+		return money, true
+	}
+	from, ok := s.rates[money.Currency]
+	if !ok {
+		return models.Money{}, false
+	}
+	target, ok := s.rates[to]
+	if !ok {
+		return models.Money{}, false
+	}
+	return models.Money{Amount: round2(money.Amount * from / target), Currency: to}, true
+}
+
+// GetValueStats builds the value statistics in the given currency, or in
+// the user's default currency when empty
+func (s *CurrencyService) GetValueStats(userID, currency string) (*ValueStats, error) {
+	if currency == "" {
+		defaultCurrency, err := s.DefaultCurrency(userID)
+		if err != nil {
+			return nil, err
+		}
+		currency = defaultCurrency
+	}
+	currency, err := models.NormalizeCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+
+	coffees, err := s.coffeeService.ListCoffees()
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &ValueStats{
+		Currency:    currency,
+		ByCurrency:  []CurrencyTotal{},
+		Coffees:     []CoffeeValue{},
+		Unconverted: []CoffeeValue{},
+	}
+	totals := make(map[string]*CurrencyTotal)
+	var per100gSum float64
+	var per100gCount int
+
+	for _, coffee := range coffees {
+		if coffee.Price == nil {
+			continue
+		}
+		stats.PricedCoffees++
+
+		total, ok := totals[coffee.Price.Currency]
+		if !ok {
+			total = &CurrencyTotal{Currency: coffee.Price.Currency}
+			totals[coffee.Price.Currency] = total
+		}
+		total.Coffees++
+		total.Total += coffee.Price.Amount
+
+		value := CoffeeValue{
+			CoffeeID: coffee.ID,
+			Name:     coffee.Name,
+			Roaster:  coffee.Roaster,
+			Rating:   coffee.Rating,
+			Price:    *coffee.Price,
+			BagGrams: coffee.BagGrams,
+		}
+		converted, ok := s.Convert(*coffee.Price, currency)
+		if !ok {
+			stats.Unconverted = append(stats.Unconverted, value)
+			continue
+		}
+		value.Converted = &converted
+		stats.TotalSpent += converted.Amount
+
+		if coffee.BagGrams > 0 {
+			per100g := converted.Amount * 100 / float64(coffee.BagGrams)
+			value.PricePer100g = round2(per100g)
+			per100gSum += per100g
+			per100gCount++
+			if coffee.Rating > 0 && per100g > 0 {
+				value.ValueScore = round2(float64(coffee.Rating) / per100g)
+			}
+		}
+		stats.Coffees = append(stats.Coffees, value)
+	}
+
+	if len(stats.Coffees) > 0 {
+		stats.AveragePrice = round2(stats.TotalSpent / float64(len(stats.Coffees)))
+	}
+	if per100gCount > 0 {
+		stats.AveragePricePer100g = round2(per100gSum / float64(per100gCount))
+	}
+	stats.TotalSpent = round2(stats.TotalSpent)
+
+	for _, total := range totals {
+		total.Total = round2(total.Total)
+		stats.ByCurrency = append(stats.ByCurrency, *total)
+	}
+	sort.Slice(stats.ByCurrency, func(i, j int) bool { return stats.ByCurrency[i].Currency < stats.ByCurrency[j].Currency })
+	sort.SliceStable(stats.Coffees, func(i, j int) bool { return stats.Coffees[i].ValueScore > stats.Coffees[j].ValueScore })
+
+	return stats, nil
+}
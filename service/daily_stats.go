@@ -0,0 +1,204 @@
+package service
+
+import (
+	"fmt"
+	"go-coffee-log/models"
+	"go-coffee-log/storage"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultTrendDays is the range of a trend request without from/to
+const defaultTrendDays = 90
+
+// Trend is a series of daily statistics for one dimension
+type Trend struct {
+	Dimension  string             `json:"dimension"`
+	From       models.Date        `json:"from"`
+	To         models.Date        `json:"to"`
+	ComputedAt time.Time          `json:"computed_at"`
+	Days       []models.DailyStat `json:"days"`
+}
+
+// DailyStatsService materializes per-day aggregates so trend requests read
+// precomputed rows instead of scanning every coffee and brew
+type DailyStatsService struct {
+	coffeeStorage storage.CoffeeStorage
+	brewStorage   storage.BrewStorage
+	stats         storage.DailyStatsStorage
+
+	mu         sync.Mutex // serializes refreshes and guards computedAt
+	computedAt time.Time
+}
+
+// NewDailyStatsService creates a new daily stats service
+func NewDailyStatsService(
+	coffeeStorage storage.CoffeeStorage,
+	brewStorage storage.BrewStorage,
+	stats storage.DailyStatsStorage,
+) *DailyStatsService {
+	return &DailyStatsService{
+		coffeeStorage: coffeeStorage,
+		brewStorage:   brewStorage,
+		stats:         stats,
+	}
+}
+
+// dailyTotals accumulates one day of one dimension value
+type dailyTotals struct {
+	coffees, ratingSum   int
+	brews, brewRatingSum int
+}
+
+// Refresh recomputes every daily aggregate from the coffees and brews
+func (s *DailyStatsService) Refresh() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	coffees, err := s.coffeeStorage.GetAll()
+	if err != nil {
+		return fmt.Errorf("failed to load coffees: %w", err)
+	}
+	brews, err := s.brewStorage.GetAllBrews()
+	if err != nil {
+		return fmt.Errorf("failed to load brews: %w", err)
+	}
+
+	type key struct {
+		day       time.Time
+		dimension string
+		value     string
+	}
+	totals := make(map[key]*dailyTotals)
+	add := func(coffee models.Coffee, at time.Time, update func(t *dailyTotals)) {
+		day := models.NewDate(at).Time
+		for _, dimension := range models.StatDimensions {
+			k := key{day: day, dimension: dimension, value: dimensionValue(coffee, dimension)}
+			if totals[k] == nil {
+				totals[k] = &dailyTotals{}
+			}
+			update(totals[k])
+		}
+	}
+
+	byID := make(map[string]models.Coffee, len(coffees))
+	for _, coffee := range coffees {
+		byID[coffee.ID] = coffee
+		add(coffee, coffee.CreatedAt, func(t *dailyTotals) {
+			t.coffees++
+			t.ratingSum += coffee.Rating
+		})
+	}
+	for _, brew := range brews {
+		coffee, ok := byID[brew.CoffeeID]
+		if !ok {
+			continue
+		}
+		add(coffee, brew.CreatedAt, func(t *dailyTotals) {
+			t.brews++
+			t.brewRatingSum += brew.Rating
+		})
+	}
+
+	now := time.Now()
+	stats := make([]models.DailyStat, 0, len(totals))
+	for k, t := range totals {
+		stat := models.DailyStat{
+			Day:        models.Date{Time: k.day},
+			Dimension:  k.dimension,
+			Value:      k.value,
+			Coffees:    t.coffees,
+			Brews:      t.brews,
+			ComputedAt: now,
+		}
+		if t.coffees > 0 {
+			stat.AverageRating = float64(t.ratingSum) / float64(t.coffees)
+		}
+		if t.brews > 0 {
+			stat.AverageBrewRating = float64(t.brewRatingSum) / float64(t.brews)
+		}
+		stats = append(stats, stat)
+	}
+
+	if err := s.stats.ReplaceDailyStats(stats); err != nil {
+		return err
+	}
+	s.computedAt = now
+	return nil
+}
+
+// dimensionValue returns the value of a coffee for one dimension
+func dimensionValue(coffee models.Coffee, dimension string) string {
+	var value string
+	switch dimension {
+	case models.DimensionAll:
+		return ""
+	case models.DimensionOrigin:
+		value = coffee.Origin
+	case models.DimensionRoaster:
+		value = coffee.Roaster
+	case models.DimensionRoastLevel:
+		value = strings.ToLower(coffee.RoastLevel)
+	case models.DimensionProcessingMethod:
+		value = strings.ToLower(coffee.ProcessingMethod)
+	}
+	if value == "" {
+		return "unknown"
+	}
+	return value
+}
+
+// GetTrend returns the daily statistics of a dimension between from and to
+// inclusive. Zero dates default to the last 90 days.
+func (s *DailyStatsService) GetTrend(dimension string, from, to time.Time) (*Trend, error) {
+	if dimension == "" {
+		dimension = models.DimensionAll
+	}
+	valid := false
+	for _, d := range models.StatDimensions {
+		valid = valid || d == dimension
+	}
+	if !valid {
+		return nil, fmt.Errorf("invalid dimension %q, expected one of %s", dimension, strings.Join(models.StatDimensions, ", "))
+	}
+
+	if to.IsZero() {
+		to = time.Now()
+	}
+	if from.IsZero() {
+		from = to.AddDate(0, 0, -defaultTrendDays)
+	}
+	if from.After(to) {
+		return nil, fmt.Errorf("invalid range: from is after to")
+	}
+
+	// Before the first background refresh, compute once on demand
+	s.mu.Lock()
+	computedAt := s.computedAt
+	s.mu.Unlock()
+	if computedAt.IsZero() {
+		if err := s.Refresh(); err != nil {
+			return nil, err
+		}
+		s.mu.Lock()
+		computedAt = s.computedAt
+		s.mu.Unlock()
+	}
+
+	trend := &Trend{
+		Dimension:  dimension,
+		From:       models.NewDate(from),
+		To:         models.NewDate(to),
+		ComputedAt: computedAt,
+	}
+	days, err := s.stats.GetDailyStats(dimension, trend.From.Time, trend.To.Time)
+	if err != nil {
+		return nil, err
+	}
+	trend.Days = days
+	if trend.Days == nil {
+		trend.Days = []models.DailyStat{}
+	}
+	return trend, nil
+}
@@ -0,0 +1,272 @@
+package service
+
+import (
+	"fmt"
+	"go-coffee-log/models"
+	"go-coffee-log/storage"
+	"sort"
+	"time"
+)
+
+// builtinDexes are the dex profiles every server has. Custom profiles, such
+// as a best-of-all-generations 151, are created by the admin.
+var builtinDexes = []models.DexProfile{
+	{
+		ID:          models.DefaultDex,
+		Name:        "Kanto classic 151",
+		Description: "The original 151 Pokemon of Red and Blue",
+		PokemonIDs:  dexRange(1, 151),
+		Builtin:     true,
+	},
+	{
+		ID:          "johto",
+		Name:        "Johto",
+		Description: "The 100 Pokemon introduced in Gold and Silver",
+		PokemonIDs:  dexRange(152, 251),
+		Builtin:     true,
+	},
+}
+
+// dexRange returns the National Dex numbers from first to last
+func dexRange(first, last int) []int {
+	ids := make([]int, 0, last-first+1)
+	for id := first; id <= last; id++ {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// DexProgress is how far a dex profile has been completed
+type DexProgress struct {
+	ID          string  `json:"id"`
+	Name        string  `json:"name"`
+	Description string  `json:"description,omitempty"`
+	Builtin     bool    `json:"builtin"`
+	Caught      int     `json:"caught"`
+	Total       int     `json:"total"`
+	Percent     float64 `json:"percent"`
+	Complete    bool    `json:"complete"`
+	Selected    bool    `json:"selected"` // the caller's current profile
+}
+
+// DexService manages the dex profiles a server offers and which one each
+// user plays. Catches, completion and the once-per-dex rule are all scoped
+// to a profile.
+type DexService struct {
+	storage        storage.DexStorage
+	pokemonStorage storage.PokemonStorage
+	defaultDex     string
+}
+
+// NewDexService creates a dex service whose users start in the Kanto dex
+func NewDexService(storage storage.DexStorage, pokemonStorage storage.PokemonStorage) *DexService {
+	return &DexService{
+		storage:        storage,
+		pokemonStorage: pokemonStorage,
+		defaultDex:     models.DefaultDex,
+	}
+}
+
+// SetDefault changes the profile users play until they select another
+func (s *DexService) SetDefault(id string) error {
+	if _, err := s.Profile(id); err != nil {
+		return fmt.Errorf("default dex %q: %w", id, err)
+	}
+	s.defaultDex = id
+	return nil
+}
+
+// Profiles returns the built-in profiles followed by the custom ones
+func (s *DexService) Profiles() ([]models.DexProfile, error) {
+	custom, err := s.storage.GetAllProfiles()
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]models.DexProfile{}, builtinDexes...), custom...), nil
+}
+
+// Profile returns a profile by ID; "" returns the default profile
+func (s *DexService) Profile(id string) (models.DexProfile, error) {
+	if id == "" {
+		id = s.defaultDex
+	}
+	for _, profile := range builtinDexes {
+		if profile.ID == id {
+			return profile, nil
+		}
+	}
+	return s.storage.GetProfile(id)
+}
+
+// CreateProfile adds a custom profile
+func (s *DexService) CreateProfile(profile models.DexProfile) (models.DexProfile, error) {
+	if err := profile.Validate(); err != nil {
+		return models.DexProfile{}, fmt.Errorf("invalid dex profile: %w", err)
+	}
+	for _, builtin := range builtinDexes {
+		if builtin.ID == profile.ID {
+			return models.DexProfile{}, fmt.Errorf("dex profile already exists")
+		}
+	}
+
+	now := time.Now()
+	profile.Builtin = false
+	profile.CreatedAt = &now
+	if err := s.storage.SaveProfile(profile); err != nil {
+		return models.DexProfile{}, err
+	}
+	return profile, nil
+}
+
+// DeleteProfile removes a custom profile nobody has caught anything in.
+// Users who selected it go back to the default profile.
+func (s *DexService) DeleteProfile(id string) error {
+	profile, err := s.Profile(id)
+	if err != nil {
+		return err
+	}
+	if profile.Builtin {
+		return fmt.Errorf("built-in dex profiles cannot be deleted")
+	}
+	if profile.ID == s.defaultDex {
+		return fmt.Errorf("dex profile %s cannot be deleted while it is the server default", id)
+	}
+
+	caught, err := s.catches(profile.ID)
+	if err != nil {
+		return err
+	}
+	if len(caught) > 0 {
+		return fmt.Errorf("dex profile %s cannot be deleted while it has %d catches", id, len(caught))
+	}
+	return s.storage.DeleteProfile(id)
+}
+
+// Selected returns the profile a user plays. Users who never selected one,
+// or whose profile was deleted, play the default profile.
+func (s *DexService) Selected(userID string) (models.DexProfile, error) {
+	id, err := s.storage.GetSelection(userID)
+	if err != nil {
+		return models.DexProfile{}, err
+	}
+	profile, err := s.Profile(id)
+	if err != nil && id != "" {
+		return s.Profile("")
+	}
+	return profile, err
+}
+
+// Select switches the profile a user plays
+func (s *DexService) Select(userID, id string) (models.DexProfile, error) {
+	profile, err := s.Profile(id)
+	if err != nil {
+		return models.DexProfile{}, err
+	}
+	if err := s.storage.SetSelection(userID, profile.ID); err != nil {
+		return models.DexProfile{}, err
+	}
+	return profile, nil
+}
+
+// Progress reports how far every profile has been completed, marking the
+// one the user plays
+func (s *DexService) Progress(userID string) ([]DexProgress, error) {
+	profiles, err := s.Profiles()
+	if err != nil {
+		return nil, err
+	}
+	selected, err := s.Selected(userID)
+	if err != nil {
+		return nil, err
+	}
+	mappings, err := s.pokemonStorage.GetAllCoffeePokemon()
+	if err != nil {
+		return nil, err
+	}
+
+	progress := make([]DexProgress, 0, len(profiles))
+	for _, profile := range profiles {
+		p := dexProgress(profile, mappings)
+		p.Selected = profile.ID == selected.ID
+		progress = append(progress, p)
+	}
+	return progress, nil
+}
+
+// ProfileProgress reports how far one profile has been completed
+func (s *DexService) ProfileProgress(profile models.DexProfile) (DexProgress, error) {
+	mappings, err := s.pokemonStorage.GetAllCoffeePokemon()
+	if err != nil {
+		return DexProgress{}, err
+	}
+	return dexProgress(profile, mappings), nil
+}
+
+// catches returns the catches made in a profile
+func (s *DexService) catches(id string) ([]models.CoffeePokemon, error) {
+	mappings, err := s.pokemonStorage.GetAllCoffeePokemon()
+	if err != nil {
+		return nil, err
+	}
+	return filterDex(mappings, id), nil
+}
+
+// dexProgress counts the distinct profile members caught in the profile
+func dexProgress(profile models.DexProfile, mappings []models.CoffeePokemon) DexProgress {
+	members := make(map[int]bool, len(profile.PokemonIDs))
+	for _, id := range profile.PokemonIDs {
+		members[id] = true
+	}
+	caught := make(map[int]bool)
+	for _, mapping := range filterDex(mappings, profile.ID) {
+		if members[mapping.PokemonID] {
+			caught[mapping.PokemonID] = true
+		}
+	}
+
+	progress := DexProgress{
+		ID:          profile.ID,
+		Name:        profile.Name,
+		Description: profile.Description,
+		Builtin:     profile.Builtin,
+		Caught:      len(caught),
+		Total:       len(profile.PokemonIDs),
+	}
+	if progress.Total > 0 {
+		progress.Percent = float64(progress.Caught) / float64(progress.Total) * 100
+	}
+	progress.Complete = progress.Total > 0 && progress.Caught == progress.Total
+	return progress
+}
+
+// filterDex keeps the catches made in one profile
+func filterDex(mappings []models.CoffeePokemon, dex string) []models.CoffeePokemon {
+	filtered := []models.CoffeePokemon{}
+	for _, mapping := range mappings {
+		if mapping.Dex == dex {
+			filtered = append(filtered, mapping)
+		}
+	}
+	return filtered
+}
+
+// PokemonIDs returns the National Dex numbers of every profile's members,
+// in order, so the reference data can cover them all
+func (s *DexService) PokemonIDs() ([]int, error) {
+	profiles, err := s.Profiles()
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[int]bool)
+	ids := []int{}
+	for _, profile := range profiles {
+		for _, id := range profile.PokemonIDs {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	sort.Ints(ids)
+	return ids, nil
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"go-coffee-log/models"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DexCatch is one catch listed in the duplicate views
+type DexCatch struct {
+	CoffeeID    string    `json:"coffee_id"`
+	PokemonID   int       `json:"pokemon_id"`
+	PokemonName string    `json:"pokemon_name"`
+	Nickname    string    `json:"nickname,omitempty"`
+	Level       int       `json:"level"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// DuplicateSpecies is a Pokemon caught by more than one coffee in a dex
+type DuplicateSpecies struct {
+	PokemonID   int        `json:"pokemon_id"`
+	PokemonName string     `json:"pokemon_name"`
+	Count       int        `json:"count"`
+	Catches     []DexCatch `json:"catches"` // oldest first
+}
+
+// NicknameReuse is a nickname given to more than one catch in a dex
+type NicknameReuse struct {
+	Nickname string     `json:"nickname"`
+	Count    int        `json:"count"`
+	Catches  []DexCatch `json:"catches"` // oldest first
+}
+
+// DexDuplicates lists the species and nicknames used more than once in a
+// dex profile
+type DexDuplicates struct {
+	Dex       string             `json:"dex"`
+	Species   []DuplicateSpecies `json:"species"`
+	Nicknames []NicknameReuse    `json:"nicknames"`
+}
+
+// Duplicates lists the species caught more than once in a profile and the
+// nicknames given to more than one catch, most repeated first. New catches
+// are unique per profile, so species only repeat in imported catches or
+// ones made before the rule; nicknames are compared ignoring case.
+func (s *DexService) Duplicates(profile models.DexProfile) (*DexDuplicates, error) {
+	mappings, err := s.catches(profile.ID)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(mappings, func(i, j int) bool { return mappings[i].CreatedAt.Before(mappings[j].CreatedAt) })
+
+	bySpecies := make(map[int][]DexCatch)
+	byNickname := make(map[string][]DexCatch)
+	for _, mapping := range mappings {
+		catch := DexCatch{
+			CoffeeID:    mapping.CoffeeID,
+			PokemonID:   mapping.PokemonID,
+			PokemonName: mapping.PokemonName,
+			Nickname:    mapping.Nickname,
+			Level:       mapping.Level,
+			CreatedAt:   mapping.CreatedAt,
+		}
+		bySpecies[mapping.PokemonID] = append(bySpecies[mapping.PokemonID], catch)
+		if key := strings.ToLower(strings.TrimSpace(mapping.Nickname)); key != "" {
+			byNickname[key] = append(byNickname[key], catch)
+		}
+	}
+
+	duplicates := &DexDuplicates{
+		Dex:       profile.ID,
+		Species:   []DuplicateSpecies{},
+		Nicknames: []NicknameReuse{},
+	}
+	for id, catches := range bySpecies {
+		if len(catches) > 1 {
+			duplicates.Species = append(duplicates.Species, DuplicateSpecies{
+				PokemonID:   id,
+				PokemonName: catches[0].PokemonName,
+				Count:       len(catches),
+				Catches:     catches,
+			})
+		}
+	}
+	for _, catches := range byNickname {
+		if len(catches) > 1 {
+			duplicates.Nicknames = append(duplicates.Nicknames, NicknameReuse{
+				Nickname: strings.TrimSpace(catches[0].Nickname),
+				Count:    len(catches),
+				Catches:  catches,
+			})
+		}
+	}
+	sort.Slice(duplicates.Species, func(i, j int) bool {
+		a, b := duplicates.Species[i], duplicates.Species[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.PokemonID < b.PokemonID
+	})
+	sort.Slice(duplicates.Nicknames, func(i, j int) bool {
+		a, b := duplicates.Nicknames[i], duplicates.Nicknames[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return strings.ToLower(a.Nickname) < strings.ToLower(b.Nickname)
+	})
+	return duplicates, nil
+}
+
+// DexCoverage is how many of some profile members have been caught
+type DexCoverage struct {
+	Caught  int     `json:"caught"`
+	Total   int     `json:"total"`
+	Percent float64 `json:"percent"`
+}
+
+// add counts one member
+func (c *DexCoverage) add(caught bool) {
+	c.Total++
+	if caught {
+		c.Caught++
+	}
+	c.Percent = round2(float64(c.Caught) / float64(c.Total) * 100)
+}
+
+// LivingDexEntry is one profile member in the living dex
+type LivingDexEntry struct {
+	PokemonID   int    `json:"pokemon_id"`
+	PokemonName string `json:"pokemon_name,omitempty"` // empty until the reference data has it
+	Stage       string `json:"stage"`
+	Caught      bool   `json:"caught"`
+}
+
+// LivingDexFamily is one evolution line, limited to the profile's members
+type LivingDexFamily struct {
+	Base     int              `json:"base"` // National Dex number of the line's base form
+	Members  []LivingDexEntry `json:"members"`
+	Caught   int              `json:"caught"`
+	Total    int              `json:"total"`
+	Complete bool             `json:"complete"`
+}
+
+// LivingDex is a profile's completion split by evolution stage and line
+type LivingDex struct {
+	Dex              string            `json:"dex"`
+	Name             string            `json:"name"`
+	Overall          DexCoverage       `json:"overall"`
+	BaseForms        DexCoverage       `json:"base_forms"`
+	Evolved          DexCoverage       `json:"evolved"`
+	UnknownStage     *DexCoverage      `json:"unknown_stage,omitempty"` // members beyond the evolution data
+	FamiliesComplete int               `json:"families_complete"`
+	Families         []LivingDexFamily `json:"families"` // in dex order of their first member
+}
+
+// LivingDex reports a profile's completion per evolution stage, so base
+// forms and evolved forms can be tracked apart, and per evolution line.
+// Stages are known for Kanto and Johto; later species count as unknown.
+func (s *DexService) LivingDex(profile models.DexProfile) (*LivingDex, error) {
+	mappings, err := s.catches(profile.ID)
+	if err != nil {
+		return nil, err
+	}
+	caught := make(map[int]bool)
+	for _, mapping := range mappings {
+		caught[mapping.PokemonID] = true
+	}
+
+	names := make(map[int]string)
+	pokemon, err := s.pokemonStorage.GetAllPokemon()
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range pokemon {
+		names[p.ID] = p.Name
+	}
+
+	living := &LivingDex{Dex: profile.ID, Name: profile.Name, Families: []LivingDexFamily{}}
+	unknown := DexCoverage{}
+	families := make(map[int]int) // base form -> index in living.Families
+	for _, id := range profile.PokemonIDs {
+		entry := LivingDexEntry{
+			PokemonID:   id,
+			PokemonName: names[id],
+			Stage:       evolutionStage(id),
+			Caught:      caught[id],
+		}
+		living.Overall.add(entry.Caught)
+		switch entry.Stage {
+		case StageBase:
+			living.BaseForms.add(entry.Caught)
+		case StageEvolved:
+			living.Evolved.add(entry.Caught)
+		default:
+			unknown.add(entry.Caught)
+		}
+
+		base := familyBase(id)
+		index, ok := families[base]
+		if !ok {
+			index = len(living.Families)
+			families[base] = index
+			living.Families = append(living.Families, LivingDexFamily{Base: base})
+		}
+		family := &living.Families[index]
+		family.Members = append(family.Members, entry)
+		family.Total++
+		if entry.Caught {
+			family.Caught++
+		}
+	}
+
+	for i := range living.Families {
+		family := &living.Families[i]
+		family.Complete = family.Caught == family.Total
+		if family.Complete {
+			living.FamiliesComplete++
+		}
+	}
+	if unknown.Total > 0 {
+		living.UnknownStage = &unknown
+	}
+	return living, nil
+}
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-coffee-log/models"
+	"reflect"
+	"time"
+)
+
+// Dry run actions
+const (
+	DryRunCreate = "create"
+	DryRunUpdate = "update"
+	DryRunDelete = "delete"
+)
+
+// CoffeeDryRun describes what a coffee request would change. Nothing is
+// stored, no events are published and no Pokemon are caught.
+type CoffeeDryRun struct {
+	DryRun   bool                   `json:"dry_run"`
+	Action   string                 `json:"action"`
+	Coffee   *models.Coffee         `json:"coffee,omitempty"`   // as it would be stored
+	Previous *models.Coffee         `json:"previous,omitempty"` // as it is stored now
+	Changes  map[string]FieldChange `json:"changes,omitempty"`  // nested fields by path, e.g. "tasting_traits.florality"
+	Catches  []CatchPreview         `json:"catches,omitempty"`
+	Released []models.CoffeePokemon `json:"released,omitempty"` // catches a delete frees to be caught again
+}
+
+// CatchPreview is a Pokemon a new coffee would catch
+type CatchPreview struct {
+	Source      string `json:"source"` // the feature that catches it, e.g. "safari"
+	Dex         string `json:"dex"`
+	PokemonID   int    `json:"pokemon_id"`
+	PokemonName string `json:"pokemon_name"`
+	Level       int    `json:"level"`
+}
+
+// diffIgnoredFields are bookkeeping fields left out of dry run changes
+var diffIgnoredFields = map[string]bool{"id": true, "dial_in": true, "added_by": true, "created_at": true, "updated_at": true}
+
+// PreviewCreate validates a new coffee like CreateCoffee and reports the
+// Pokemon it would catch, without saving it
+func (s *CoffeeService) PreviewCreate(coffee models.Coffee) (CoffeeDryRun, error) {
+	coffee.ID = ""
+	coffee.CreatedAt = time.Now()
+	coffee.UpdatedAt = coffee.CreatedAt
+	coffee.DialIn = nil
+
+	if err := coffee.Validate(); err != nil {
+		return CoffeeDryRun{}, err
+	}
+	if err := s.checkBrewer(coffee); err != nil {
+		return CoffeeDryRun{}, err
+	}
+	if err := s.resolveLineage(&coffee); err != nil {
+		return CoffeeDryRun{}, err
+	}
+	if err := s.checkQuota(coffee); err != nil {
+		return CoffeeDryRun{}, err
+	}
+
+	result := CoffeeDryRun{DryRun: true, Action: DryRunCreate, Coffee: &coffee}
+	for _, preview := range s.onPreview {
+		result.Catches = append(result.Catches, preview(coffee)...)
+	}
+	return result, nil
+}
+
+// PreviewUpdate validates a replacement coffee like UpdateCoffee and reports
+// the fields it would change
+func (s *CoffeeService) PreviewUpdate(id string, coffee models.Coffee) (CoffeeDryRun, error) {
+	coffee.ID = id
+	coffee.UpdatedAt = time.Now()
+	coffee.DialIn = nil
+
+	if err := coffee.Validate(); err != nil {
+		return CoffeeDryRun{}, err
+	}
+
+	previous, err := s.storage.GetByID(id)
+	if err != nil {
+		return CoffeeDryRun{}, err
+	}
+	coffee.CreatedAt = previous.CreatedAt
+	return s.previewChange(previous, coffee)
+}
+
+// PreviewPatch applies a merge patch like PatchCoffee and reports the
+// fields it would change
+func (s *CoffeeService) PreviewPatch(id string, patch []byte) (CoffeeDryRun, error) {
+	previous, patched, err := s.applyPatch(id, patch)
+	if err != nil {
+		return CoffeeDryRun{}, err
+	}
+	patched.ID = id
+	patched.UpdatedAt = time.Now()
+	patched.DialIn = nil
+	return s.previewChange(previous, patched)
+}
+
+// PreviewDelete reports the coffee DeleteCoffee would remove and the
+// Pokemon catches it would release
+func (s *CoffeeService) PreviewDelete(id string) (CoffeeDryRun, error) {
+	previous, err := s.storage.GetByID(id)
+	if err != nil {
+		return CoffeeDryRun{}, err
+	}
+	released, err := s.catchesOf(id)
+	if err != nil {
+		return CoffeeDryRun{}, err
+	}
+	return CoffeeDryRun{DryRun: true, Action: DryRunDelete, Previous: &previous, Released: released}, nil
+}
+
+// previewChange builds the dry run of replacing previous with coffee
+func (s *CoffeeService) previewChange(previous, coffee models.Coffee) (CoffeeDryRun, error) {
+	if err := s.checkBrewer(coffee); err != nil {
+		return CoffeeDryRun{}, err
+	}
+	if err := s.resolveLineage(&coffee); err != nil {
+		return CoffeeDryRun{}, err
+	}
+	changes, err := diffFields(previous, coffee)
+	if err != nil {
+		return CoffeeDryRun{}, err
+	}
+	return CoffeeDryRun{
+		DryRun:   true,
+		Action:   DryRunUpdate,
+		Coffee:   &coffee,
+		Previous: &previous,
+		Changes:  changes,
+	}, nil
+}
+
+// diffFields maps the fields that differ between two versions of a
+// record, e.g. two coffees, as they appear in the API
+func diffFields(from, to interface{}) (map[string]FieldChange, error) {
+	fromFields, err := flattenFields(from)
+	if err != nil {
+		return nil, err
+	}
+	toFields, err := flattenFields(to)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]bool, len(fromFields))
+	for field := range fromFields {
+		fields[field] = true
+	}
+	for field := range toFields {
+		fields[field] = true
+	}
+
+	changes := make(map[string]FieldChange)
+	for field := range fields {
+		if !reflect.DeepEqual(fromFields[field], toFields[field]) {
+			changes[field] = FieldChange{From: fromFields[field], To: toFields[field]}
+		}
+	}
+	return changes, nil
+}
+
+// flattenFields maps each JSON field of a record, with nested objects
+// flattened into dotted paths
+func flattenFields(record interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode record: %w", err)
+	}
+	var document map[string]interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("failed to decode record: %w", err)
+	}
+
+	fields := make(map[string]interface{})
+	var flatten func(prefix string, object map[string]interface{})
+	flatten = func(prefix string, object map[string]interface{}) {
+		for key, value := range object {
+			if prefix == "" && diffIgnoredFields[key] {
+				continue
+			}
+			if nested, ok := value.(map[string]interface{}); ok {
+				flatten(prefix+key+".", nested)
+				continue
+			}
+			fields[prefix+key] = value
+		}
+	}
+	flatten("", document)
+	return fields, nil
+}
+
+// DryRun describes what a write other than a coffee write would change.
+// Nothing is stored, no events are published and nothing is sent.
+type DryRun struct {
+	DryRun   bool                   `json:"dry_run"`
+	Action   string                 `json:"action"`
+	Result   interface{}            `json:"result,omitempty"`   // as it would be stored
+	Previous interface{}            `json:"previous,omitempty"` // as it is stored now
+	Changes  map[string]FieldChange `json:"changes,omitempty"`  // update only
+}
+
+// previewCreate is the dry run of storing a new record
+func previewCreate(result interface{}) DryRun {
+	return DryRun{DryRun: true, Action: DryRunCreate, Result: result}
+}
+
+// previewUpdate is the dry run of replacing previous with result
+func previewUpdate(previous, result interface{}) (DryRun, error) {
+	changes, err := diffFields(previous, result)
+	if err != nil {
+		return DryRun{}, err
+	}
+	return DryRun{DryRun: true, Action: DryRunUpdate, Result: result, Previous: previous, Changes: changes}, nil
+}
+
+// previewDelete is the dry run of removing a record
+func previewDelete(previous interface{}) DryRun {
+	return DryRun{DryRun: true, Action: DryRunDelete, Previous: previous}
+}
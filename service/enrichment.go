@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"go-coffee-log/models"
+	"log"
+	"strings"
+)
+
+// CoffeeMetadata is what a roaster publishes about one of its coffees.
+// Empty fields are unknown.
+type CoffeeMetadata struct {
+	Variety          string `json:"variety,omitempty"`
+	Origin           string `json:"origin,omitempty"`
+	ProcessingMethod string `json:"processing_method,omitempty"`
+	Altitude         string `json:"altitude,omitempty"`
+	Lot              string `json:"lot,omitempty"`
+	URL              string `json:"url,omitempty"` // the page it was read from
+}
+
+// RoasterEnricher looks up coffee metadata from a roaster's website or API
+type RoasterEnricher interface {
+	Name() string
+	// Lookup finds a roaster's coffee by name. It returns nil, without an
+	// error, when it doesn't cover the roaster or can't find the coffee.
+	Lookup(ctx context.Context, roaster, coffeeName string) (*CoffeeMetadata, error)
+}
+
+// EnrichmentChange is a field the roaster's metadata would change
+type EnrichmentChange struct {
+	Field    string `json:"field"`
+	Current  string `json:"current"`
+	Proposed string `json:"proposed"`
+}
+
+// EnrichmentProposal is the metadata found for a coffee, as changes to
+// confirm before they are applied
+type EnrichmentProposal struct {
+	CoffeeID string             `json:"coffee_id"`
+	Source   string             `json:"source"` // the enricher that found it
+	URL      string             `json:"url,omitempty"`
+	Changes  []EnrichmentChange `json:"changes"`
+}
+
+// EnrichmentService fills in coffee details from roaster websites and
+// APIs. Nothing is saved until the caller confirms the fields to apply.
+type EnrichmentService struct {
+	coffeeService *CoffeeService
+	enrichers     []RoasterEnricher
+}
+
+// NewEnrichmentService creates a new enrichment service
+func NewEnrichmentService(coffeeService *CoffeeService) *EnrichmentService {
+	return &EnrichmentService{coffeeService: coffeeService}
+}
+
+// AddEnricher registers an enricher. Enrichers are asked in the order they
+// were added; the first to find the coffee wins. Add them at startup.
+func (s *EnrichmentService) AddEnricher(enricher RoasterEnricher) {
+	s.enrichers = append(s.enrichers, enricher)
+}
+
+// enrichmentFields are the coffee fields metadata can fill in
+var enrichmentFields = []struct {
+	name     string
+	metadata func(*CoffeeMetadata) string
+	coffee   func(*models.Coffee) *string
+}{
+	{"variety", func(m *CoffeeMetadata) string { return m.Variety }, func(c *models.Coffee) *string { return &c.Variety }},
+	{"origin", func(m *CoffeeMetadata) string { return m.Origin }, func(c *models.Coffee) *string { return &c.Origin }},
+	{"processing_method", func(m *CoffeeMetadata) string { return m.ProcessingMethod }, func(c *models.Coffee) *string { return &c.ProcessingMethod }},
+	{"altitude", func(m *CoffeeMetadata) string { return m.Altitude }, func(c *models.Coffee) *string { return &c.Altitude }},
+	{"lot", func(m *CoffeeMetadata) string { return m.Lot }, func(c *models.Coffee) *string { return &c.Lot }},
+}
+
+// Propose looks a coffee up with its roaster and reports the fields the
+// metadata found would change
+func (s *EnrichmentService) Propose(ctx context.Context, coffeeID string) (*EnrichmentProposal, error) {
+	coffee, err := s.coffeeService.GetCoffee(coffeeID)
+	if err != nil {
+		return nil, err
+	}
+	return s.propose(ctx, coffee)
+}
+
+// Apply looks a coffee up again and applies the proposed changes to fields,
+// which the caller has confirmed. A field without a proposed change is an
+// error, so a stale confirmation never applies something unseen.
+func (s *EnrichmentService) Apply(ctx context.Context, coffeeID string, fields []string) (models.Coffee, error) {
+	if len(fields) == 0 {
+		return models.Coffee{}, fmt.Errorf("invalid enrichment: confirm the fields to apply")
+	}
+	coffee, err := s.coffeeService.GetCoffee(coffeeID)
+	if err != nil {
+		return models.Coffee{}, err
+	}
+	proposal, err := s.propose(ctx, coffee)
+	if err != nil {
+		return models.Coffee{}, err
+	}
+
+	proposed := make(map[string]string)
+	for _, change := range proposal.Changes {
+		proposed[change.Field] = change.Proposed
+	}
+	for _, field := range fields {
+		value, ok := proposed[field]
+		if !ok {
+			return models.Coffee{}, fmt.Errorf("invalid enrichment: no change proposed for %s", field)
+		}
+		for _, known := range enrichmentFields {
+			if known.name == field {
+				*known.coffee(&coffee) = value
+			}
+		}
+	}
+
+	updated, err := s.coffeeService.UpdateCoffee(coffee.ID, coffee)
+	if err != nil {
+		return models.Coffee{}, err
+	}
+	log.Printf("INFO: Enriched coffee %s from %s: %s", coffee.ID, proposal.Source, strings.Join(fields, ", "))
+	return updated, nil
+}
+
+// propose asks the enrichers about a coffee
+func (s *EnrichmentService) propose(ctx context.Context, coffee models.Coffee) (*EnrichmentProposal, error) {
+	if strings.TrimSpace(coffee.Roaster) == "" {
+		return nil, fmt.Errorf("invalid enrichment: the coffee has no roaster to look it up with")
+	}
+
+	for _, enricher := range s.enrichers {
+		metadata, err := enricher.Lookup(ctx, coffee.Roaster, coffee.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up coffee with %s: %w", enricher.Name(), err)
+		}
+		if metadata == nil {
+			continue
+		}
+
+		proposal := &EnrichmentProposal{
+			CoffeeID: coffee.ID,
+			Source:   enricher.Name(),
+			URL:      metadata.URL,
+			Changes:  []EnrichmentChange{},
+		}
+		for _, field := range enrichmentFields {
+			current, value := *field.coffee(&coffee), strings.TrimSpace(field.metadata(metadata))
+			if value != "" && !strings.EqualFold(value, strings.TrimSpace(current)) {
+				proposal.Changes = append(proposal.Changes, EnrichmentChange{Field: field.name, Current: current, Proposed: value})
+			}
+		}
+		return proposal, nil
+	}
+	return nil, fmt.Errorf("no roaster metadata found for %q by %s", coffee.Name, coffee.Roaster)
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// shopifyLabels map the labels roasters use in product descriptions to
+// metadata fields
+var shopifyLabels = map[string]string{
+	"variety": "variety", "varietal": "variety", "varietals": "variety", "varieties": "variety", "cultivar": "variety",
+	"origin": "origin", "country": "origin",
+	"process": "processing_method", "processing": "processing_method",
+	"altitude": "altitude", "elevation": "altitude",
+	"lot": "lot", "farm": "lot", "producer": "lot", "producers": "lot", "washing station": "lot",
+}
+
+// Product descriptions are read as text: line breaks and the ends of block
+// elements become new lines, and other tags are dropped
+var (
+	htmlLineBreak = regexp.MustCompile(`(?i)<(br|/p|/li|/div|/tr|/h[1-6])\b[^>]*>`)
+	htmlTag       = regexp.MustCompile(`<[^>]*>`)
+)
+
+// ShopifyEnricher reads coffee metadata from roasters' Shopify stores,
+// which publish their products at /products.json. Details come from
+// "Label: value" lines and tags, e.g. "Variety: Gesha".
+type ShopifyEnricher struct {
+	shops  map[string]string // lowercase roaster name -> store URL
+	client *http.Client
+}
+
+// NewShopifyEnricher creates an enricher for the stores of the given
+// roasters, keyed by roaster name
+func NewShopifyEnricher(shops map[string]string) *ShopifyEnricher {
+	enricher := &ShopifyEnricher{
+		shops:  make(map[string]string),
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+	for roaster, url := range shops {
+		enricher.shops[strings.ToLower(strings.TrimSpace(roaster))] = strings.TrimRight(url, "/")
+	}
+	return enricher
+}
+
+// Name identifies the enricher in proposals
+func (e *ShopifyEnricher) Name() string {
+	return "shopify"
+}
+
+// shopifyProduct is the part of a Shopify product the enricher reads
+type shopifyProduct struct {
+	Title    string   `json:"title"`
+	Handle   string   `json:"handle"`
+	BodyHTML string   `json:"body_html"`
+	Tags     []string `json:"tags"`
+}
+
+// Lookup finds a coffee among the products of its roaster's store
+func (e *ShopifyEnricher) Lookup(ctx context.Context, roaster, coffeeName string) (*CoffeeMetadata, error) {
+	store, ok := e.shops[strings.ToLower(strings.TrimSpace(roaster))]
+	if !ok {
+		return nil, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, store+"/products.json?limit=250", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %s", store, resp.Status)
+	}
+
+	var catalog struct {
+		Products []shopifyProduct `json:"products"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&catalog); err != nil {
+		return nil, fmt.Errorf("failed to decode products: %w", err)
+	}
+
+	product := matchProduct(catalog.Products, coffeeName)
+	if product == nil {
+		return nil, nil
+	}
+	metadata := parseProductMetadata(*product)
+	metadata.URL = store + "/products/" + product.Handle
+	return &metadata, nil
+}
+
+// matchProduct picks the product named like a coffee: an exact title if
+// there is one, otherwise the longest title either name contains
+func matchProduct(products []shopifyProduct, coffeeName string) *shopifyProduct {
+	name := strings.ToLower(strings.TrimSpace(coffeeName))
+	if name == "" {
+		return nil
+	}
+
+	var best *shopifyProduct
+	for i := range products {
+		title := strings.ToLower(strings.TrimSpace(products[i].Title))
+		if title == name {
+			return &products[i]
+		}
+		if title != "" && (strings.Contains(title, name) || strings.Contains(name, title)) {
+			if best == nil || len(title) > len(best.Title) {
+				best = &products[i]
+			}
+		}
+	}
+	return best
+}
+
+// parseProductMetadata reads "Label: value" lines from a product's
+// description and tags. The first value found for a field wins.
+func parseProductMetadata(product shopifyProduct) CoffeeMetadata {
+	text := htmlLineBreak.ReplaceAllString(product.BodyHTML, "\n")
+	text = html.UnescapeString(htmlTag.ReplaceAllString(text, ""))
+	lines := append(strings.Split(text, "\n"), product.Tags...)
+
+	found := make(map[string]string)
+	for _, line := range lines {
+		label, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		field, known := shopifyLabels[strings.ToLower(strings.TrimSpace(label))]
+		value = strings.TrimSpace(value)
+		if known && value != "" && found[field] == "" {
+			found[field] = value
+		}
+	}
+	return CoffeeMetadata{
+		Variety:          found["variety"],
+		Origin:           found["origin"],
+		ProcessingMethod: strings.ToLower(found["processing_method"]),
+		Altitude:         found["altitude"],
+		Lot:              found["lot"],
+	}
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// subscriberBuffer is how many events a slow subscriber may fall behind
+// before further events are dropped for it
+const subscriberBuffer = 16
+
+// Event represents something worth telling the user about
+type Event struct {
+	ID        string                 `json:"id"`
+	Type      string                 `json:"type"` // e.g. "reminder.stale"
+	Message   string                 `json:"message"`
+	CoffeeID  string                 `json:"coffee_id,omitempty"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+// EventSink delivers events outside the process (webhook, email, ...)
+type EventSink interface {
+	Name() string
+	Send(event Event) error
+}
+
+// EventBus fans events out to in-process subscribers (SSE streams) and to
+// external sinks. Publishing never blocks on a slow consumer.
+type EventBus struct {
+	mu          sync.RWMutex
+	subscribers map[chan Event]struct{}
+	sinks       []EventSink
+}
+
+// NewEventBus creates a new event bus
+func NewEventBus() *EventBus {
+	return &EventBus{
+		subscribers: make(map[chan Event]struct{}),
+	}
+}
+
+// AddSink registers an external sink for every published event
+func (b *EventBus) AddSink(sink EventSink) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.sinks = append(b.sinks, sink)
+}
+
+// Subscribe returns a channel receiving future events and a function that
+// must be called to unsubscribe
+func (b *EventBus) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers, ch)
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// Publish stamps and delivers an event to all subscribers and sinks
+func (b *EventBus) Publish(event Event) {
+	if event.ID == "" {
+		event.ID = uuid.New().String()
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+			log.Printf("Events: subscriber is falling behind, dropped %s event", event.Type)
+		}
+	}
+
+	for _, sink := range b.sinks {
+		go func(sink EventSink) {
+			if err := sink.Send(event); err != nil {
+				log.Printf("Events: %s delivery failed for %s event: %v", sink.Name(), event.Type, err)
+			}
+		}(sink)
+	}
+}
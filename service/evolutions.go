@@ -0,0 +1,56 @@
+package service
+
+// evolutionDataMax is the highest National Dex number evolvesFrom covers;
+// later species have no known stage
+const evolutionDataMax = 251
+
+// evolvesFrom maps each Kanto and Johto Pokemon that evolves from another
+// to its pre-evolution, including the Johto babies and branch evolutions
+// of Kanto lines. Pokemon missing here are base forms.
+var evolvesFrom = map[int]int{
+	// Kanto
+	2: 1, 3: 2, 5: 4, 6: 5, 8: 7, 9: 8, 11: 10, 12: 11, 14: 13, 15: 14,
+	17: 16, 18: 17, 20: 19, 22: 21, 24: 23, 25: 172, 26: 25, 28: 27,
+	30: 29, 31: 30, 33: 32, 34: 33, 35: 173, 36: 35, 38: 37, 39: 174,
+	40: 39, 42: 41, 44: 43, 45: 44, 47: 46, 49: 48, 51: 50, 53: 52,
+	55: 54, 57: 56, 59: 58, 61: 60, 62: 61, 64: 63, 65: 64, 67: 66,
+	68: 67, 70: 69, 71: 70, 73: 72, 75: 74, 76: 75, 78: 77, 80: 79,
+	82: 81, 85: 84, 87: 86, 89: 88, 91: 90, 93: 92, 94: 93, 97: 96,
+	99: 98, 101: 100, 103: 102, 105: 104, 106: 236, 107: 236, 110: 109,
+	112: 111, 117: 116, 119: 118, 121: 120, 124: 238, 125: 239, 126: 240,
+	130: 129, 134: 133, 135: 133, 136: 133, 139: 138, 141: 140, 148: 147,
+	149: 148,
+	// Johto
+	153: 152, 154: 153, 156: 155, 157: 156, 159: 158, 160: 159, 162: 161,
+	164: 163, 166: 165, 168: 167, 169: 42, 171: 170, 176: 175, 178: 177,
+	180: 179, 181: 180, 182: 44, 184: 183, 186: 61, 188: 187, 189: 188,
+	192: 191, 195: 194, 196: 133, 197: 133, 199: 79, 205: 204, 208: 95,
+	210: 209, 212: 123, 217: 216, 219: 218, 221: 220, 224: 223, 229: 228,
+	230: 117, 232: 231, 233: 137, 237: 236, 242: 113, 247: 246, 248: 247,
+}
+
+// Evolution stages of a Pokemon
+const (
+	StageBase    = "base"
+	StageEvolved = "evolved"
+	StageUnknown = "unknown" // beyond the evolution data
+)
+
+// evolutionStage reports whether a Pokemon is a base form or evolved
+func evolutionStage(id int) string {
+	switch {
+	case id > evolutionDataMax:
+		return StageUnknown
+	case evolvesFrom[id] != 0:
+		return StageEvolved
+	}
+	return StageBase
+}
+
+// familyBase returns the base form a Pokemon's evolution line starts from
+func familyBase(id int) int {
+	for evolvesFrom[id] != 0 {
+		id = evolvesFrom[id]
+	}
+	return id
+}
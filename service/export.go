@@ -0,0 +1,164 @@
+package service
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"go-coffee-log/models"
+	"go-coffee-log/storage"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Coffee log export formats
+const (
+	ExportCSV  = "csv"
+	ExportJSON = "json"
+)
+
+// exportCSVHeader names the CSV columns. They match the fields of the CSV
+// import, so an export can be imported again as is.
+var exportCSVHeader = []string{
+	"id", "name", "origin", "roaster", "variety", "roast_level", "processing_method",
+	"rating", "tasting_notes", "recipe", "dripper", "end_time", "roast_date", "favorite",
+	"berry_intensity", "stonefruit_intensity", "roast_intensity", "citrus_fruits_intensity",
+	"bitterness", "florality", "spice", "sweetness", "aromatic_intensity", "savory", "body", "cleanliness",
+	"pokemon", "created_at", "updated_at",
+}
+
+// ExportedCoffee is a coffee with its Pokemon catches in every dex profile
+type ExportedCoffee struct {
+	models.Coffee
+	Pokemon []models.CoffeePokemon `json:"pokemon"`
+}
+
+// CoffeeExport is the full coffee log, loaded and ready to write out
+type CoffeeExport struct {
+	ExportedAt time.Time
+	coffees    []models.Coffee
+	pokemon    map[string][]models.CoffeePokemon // by coffee ID
+}
+
+// CoffeeExportService exports the coffee log for backups and spreadsheets
+type CoffeeExportService struct {
+	coffeeService  *CoffeeService
+	pokemonStorage storage.PokemonStorage // optional, joins in the catches
+}
+
+// NewCoffeeExportService creates a new coffee export service
+func NewCoffeeExportService(coffeeService *CoffeeService, pokemonStorage storage.PokemonStorage) *CoffeeExportService {
+	return &CoffeeExportService{
+		coffeeService:  coffeeService,
+		pokemonStorage: pokemonStorage,
+	}
+}
+
+// Load reads every coffee and catch. Loading first means a storage failure
+// can still be reported before any of the file has been sent.
+func (s *CoffeeExportService) Load() (*CoffeeExport, error) {
+	coffees, err := s.coffeeService.ListCoffees()
+	if err != nil {
+		return nil, fmt.Errorf("failed to export coffees: %w", err)
+	}
+
+	export := &CoffeeExport{
+		ExportedAt: time.Now(),
+		coffees:    coffees,
+		pokemon:    make(map[string][]models.CoffeePokemon),
+	}
+	if s.pokemonStorage != nil {
+		mappings, err := s.pokemonStorage.GetAllCoffeePokemon()
+		if err != nil {
+			return nil, fmt.Errorf("failed to export Pokemon: %w", err)
+		}
+		for _, mapping := range mappings {
+			export.pokemon[mapping.CoffeeID] = append(export.pokemon[mapping.CoffeeID], mapping)
+		}
+		for _, catches := range export.pokemon {
+			sort.Slice(catches, func(i, j int) bool { return catches[i].Dex < catches[j].Dex })
+		}
+	}
+	return export, nil
+}
+
+// WriteJSON writes the coffees as a JSON array, one coffee at a time
+func (e *CoffeeExport) WriteJSON(w io.Writer) error {
+	if _, err := io.WriteString(w, "[\n"); err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	for i, coffee := range e.coffees {
+		if i > 0 {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+		catches := e.pokemon[coffee.ID]
+		if catches == nil {
+			catches = []models.CoffeePokemon{}
+		}
+		if err := encoder.Encode(ExportedCoffee{Coffee: coffee, Pokemon: catches}); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(w, "]\n")
+	return err
+}
+
+// WriteCSV writes the coffees as CSV, one row per coffee. Lists are joined
+// with "; " and the Pokemon column reads like "Pikachu (Lv. 30)", with the
+// dex profile named for catches outside the default one.
+func (e *CoffeeExport) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(exportCSVHeader); err != nil {
+		return err
+	}
+	for _, coffee := range e.coffees {
+		if err := writer.Write(exportCSVRow(coffee, e.pokemon[coffee.ID])); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// exportCSVRow formats a coffee in the order of exportCSVHeader
+func exportCSVRow(coffee models.Coffee, catches []models.CoffeePokemon) []string {
+	var notes []string
+	for _, note := range coffee.TastingNotes {
+		if note != "" {
+			notes = append(notes, note)
+		}
+	}
+	roastDate := ""
+	if coffee.RoastDate != nil {
+		roastDate = coffee.RoastDate.Format("2006-01-02")
+	}
+	favorite := "no"
+	if coffee.Favorite {
+		favorite = "yes"
+	}
+	pokemon := make([]string, 0, len(catches))
+	for _, catch := range catches {
+		entry := fmt.Sprintf("%s (Lv. %d)", catch.PokemonName, catch.Level)
+		if catch.Dex != models.DefaultDex {
+			entry = catch.Dex + ": " + entry
+		}
+		pokemon = append(pokemon, entry)
+	}
+
+	traits := coffee.TastingTraits
+	return []string{
+		coffee.ID, coffee.Name, coffee.Origin, coffee.Roaster, coffee.Variety, coffee.RoastLevel, coffee.ProcessingMethod,
+		strconv.Itoa(coffee.Rating), strings.Join(notes, "; "), strings.Join(coffee.Recipe, "; "), coffee.Dripper,
+		fmt.Sprintf("%d:%02d", coffee.EndTime.Minutes, coffee.EndTime.Seconds), roastDate, favorite,
+		strconv.Itoa(traits.BerryIntensity), strconv.Itoa(traits.StonefruitIntensity), strconv.Itoa(traits.RoastIntensity),
+		strconv.Itoa(traits.CitrusFruitsIntensity), strconv.Itoa(traits.Bitterness), strconv.Itoa(traits.Florality),
+		strconv.Itoa(traits.Spice), strconv.Itoa(traits.Sweetness), strconv.Itoa(traits.AromaticIntensity),
+		strconv.Itoa(traits.Savory), strconv.Itoa(traits.Body), strconv.Itoa(traits.Cleanliness),
+		strings.Join(pokemon, "; "), coffee.CreatedAt.Format(time.RFC3339), coffee.UpdatedAt.Format(time.RFC3339),
+	}
+}
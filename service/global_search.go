@@ -0,0 +1,253 @@
+package service
+
+import (
+	"fmt"
+	"go-coffee-log/storage"
+	"strings"
+	"unicode"
+)
+
+// Global search limits
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+	searchSnippetRunes = 120
+)
+
+// Search engines reported with results
+const (
+	SearchEngineMySQL    = "mysql-fulltext"
+	SearchEngineEmbedded = "embedded"
+)
+
+// searchFieldOrder lists hit fields in the order they are reported as
+// matched; the snippet comes from the first matched one
+var searchFieldOrder = []string{
+	"name", "pokemon_name", "nickname", "roaster", "origin", "variety",
+	"processing_method", "tasting_notes", "llm_description",
+}
+
+// GlobalSearchResults is the response of a global search
+type GlobalSearchResults struct {
+	Query  string              `json:"query"`
+	Engine string              `json:"engine"`
+	Total  int                 `json:"total"`
+	Hits   []storage.SearchHit `json:"hits"`
+}
+
+// GlobalSearchService searches coffees, tasting notes, catches and brewers
+// at once. It uses the database full-text index when one is set, and
+// otherwise an in-memory index built from storage.
+type GlobalSearchService struct {
+	coffeeStorage  storage.CoffeeStorage
+	pokemonStorage storage.PokemonStorage // optional
+	brewerStorage  storage.BrewerStorage  // optional
+	fullText       storage.FullTextSearcher
+}
+
+// NewGlobalSearchService creates a global search service. The Pokemon and
+// brewer storages may be nil, leaving those results out.
+func NewGlobalSearchService(coffeeStorage storage.CoffeeStorage, pokemonStorage storage.PokemonStorage, brewerStorage storage.BrewerStorage) *GlobalSearchService {
+	return &GlobalSearchService{
+		coffeeStorage:  coffeeStorage,
+		pokemonStorage: pokemonStorage,
+		brewerStorage:  brewerStorage,
+	}
+}
+
+// SetFullTextSearcher searches with a database full-text index instead of
+// the in-memory one
+func (s *GlobalSearchService) SetFullTextSearcher(searcher storage.FullTextSearcher) {
+	s.fullText = searcher
+}
+
+// Search returns up to limit results for query, best first
+func (s *GlobalSearchService) Search(query string, limit int) (*GlobalSearchResults, error) {
+	query = strings.TrimSpace(query)
+	terms := storage.SearchTerms(query)
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("query is required")
+	}
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+	limit = min(limit, MaxSearchLimit)
+
+	results := &GlobalSearchResults{Query: query}
+	var hits []storage.SearchHit
+	if s.fullText != nil {
+		results.Engine = SearchEngineMySQL
+		found, err := s.fullText.FullTextSearch(query, limit)
+		if err != nil {
+			return nil, err
+		}
+		hits = found
+	} else {
+		results.Engine = SearchEngineEmbedded
+		index, err := s.buildIndex()
+		if err != nil {
+			return nil, err
+		}
+		hits = index.search(query, limit)
+	}
+
+	for i := range hits {
+		hits[i].MatchedFields, hits[i].Snippet = matchFields(hits[i].Fields, terms)
+	}
+	if hits == nil {
+		hits = []storage.SearchHit{}
+	}
+	results.Hits = hits
+	results.Total = len(hits)
+	return results, nil
+}
+
+// buildIndex indexes every coffee, catch and brewer. Names weigh most,
+// then coffee details, then free text.
+func (s *GlobalSearchService) buildIndex() (*searchIndex, error) {
+	index := newSearchIndex()
+
+	coffees, err := s.coffeeStorage.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load coffees: %w", err)
+	}
+	for _, coffee := range coffees {
+		index.add(storage.SearchHit{
+			Type:     storage.SearchTypeCoffee,
+			ID:       coffee.ID,
+			CoffeeID: coffee.ID,
+			Title:    coffee.Name,
+		}, []searchField{
+			{"name", coffee.Name, 3},
+			{"roaster", coffee.Roaster, 2},
+			{"origin", coffee.Origin, 2},
+			{"variety", coffee.Variety, 2},
+			{"processing_method", coffee.ProcessingMethod, 2},
+			{"tasting_notes", joinNotes(coffee.TastingNotes), 1.5},
+		})
+	}
+
+	if s.pokemonStorage != nil {
+		catches, err := s.pokemonStorage.GetAllCoffeePokemon()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load catches: %w", err)
+		}
+		for _, catch := range catches {
+			title := catch.PokemonName
+			if catch.Nickname != "" {
+				title = catch.Nickname + " (" + catch.PokemonName + ")"
+			}
+			index.add(storage.SearchHit{
+				Type:     storage.SearchTypePokemon,
+				ID:       catch.CoffeeID,
+				CoffeeID: catch.CoffeeID,
+				Title:    title,
+			}, []searchField{
+				{"pokemon_name", catch.PokemonName, 2},
+				{"nickname", catch.Nickname, 2},
+				{"llm_description", catch.LLMDescription, 1},
+			})
+		}
+	}
+
+	if s.brewerStorage != nil {
+		brewers, err := s.brewerStorage.GetAllBrewers()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load brewers: %w", err)
+		}
+		for _, brewer := range brewers {
+			index.add(storage.SearchHit{
+				Type:  storage.SearchTypeBrewer,
+				ID:    brewer.ID,
+				Title: brewer.Name,
+			}, []searchField{{"name", brewer.Name, 2}})
+		}
+	}
+
+	return index, nil
+}
+
+// joinNotes joins the filled-in tasting notes
+func joinNotes(notes [5]string) string {
+	var kept []string
+	for _, note := range notes {
+		if note != "" {
+			kept = append(kept, note)
+		}
+	}
+	return strings.Join(kept, ", ")
+}
+
+// matchFields reports which fields contain a word starting with one of the
+// terms, and an excerpt around the first match
+func matchFields(fields map[string]string, terms []string) ([]string, string) {
+	matched := []string{}
+	snippet := ""
+	for _, name := range searchFieldOrder {
+		text, ok := fields[name]
+		if !ok || text == "" {
+			continue
+		}
+		if at := findTerm(text, terms); at >= 0 {
+			matched = append(matched, name)
+			if snippet == "" {
+				snippet = excerpt(text, at)
+			}
+		}
+	}
+	return matched, snippet
+}
+
+// findTerm returns the rune offset of the first word in text starting with
+// one of the terms, or -1
+func findTerm(text string, terms []string) int {
+	runes := []rune(text)
+	for start := 0; start < len(runes); start++ {
+		if !isWordRune(runes[start]) || start > 0 && isWordRune(runes[start-1]) {
+			continue
+		}
+		for _, term := range terms {
+			if hasRunePrefix(runes[start:], term) {
+				return start
+			}
+		}
+	}
+	return -1
+}
+
+// hasRunePrefix reports whether runes start with term, ignoring case
+func hasRunePrefix(runes []rune, term string) bool {
+	i := 0
+	for _, r := range term {
+		if i >= len(runes) || unicode.ToLower(runes[i]) != r {
+			return false
+		}
+		i++
+	}
+	return true
+}
+
+// isWordRune matches the characters storage.SearchTerms keeps
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// excerpt returns about searchSnippetRunes of text around the rune offset at
+func excerpt(text string, at int) string {
+	runes := []rune(text)
+	if len(runes) <= searchSnippetRunes {
+		return text
+	}
+	start := max(0, at-searchSnippetRunes/3)
+	end := min(len(runes), start+searchSnippetRunes)
+	start = max(0, end-searchSnippetRunes)
+
+	snippet := strings.TrimSpace(string(runes[start:end]))
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(runes) {
+		snippet += "…"
+	}
+	return snippet
+}
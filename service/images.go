@@ -0,0 +1,266 @@
+package service
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	_ "image/gif" // decoder
+)
+
+// Image size limits
+const (
+	MaxImageDimension = 1024             // of a served image, either side
+	MaxImagePixels    = 40 * 1000 * 1000 // of an original; about 160 MB decoded
+	imageCacheBytes   = 64 << 20
+)
+
+// ImageSource opens original images of one kind by key
+type ImageSource interface {
+	// Open returns the image data and when it last changed; the error
+	// contains "not found" for unknown keys
+	Open(key string) (io.ReadCloser, time.Time, error)
+}
+
+// SpriteSource serves Pokemon sprites such as "025.png" by Pokemon number
+type SpriteSource struct {
+	dir string
+}
+
+// NewSpriteSource creates a source for the sprites in dir
+func NewSpriteSource(dir string) *SpriteSource {
+	return &SpriteSource{dir: dir}
+}
+
+// Open opens the sprite of the Pokemon numbered key
+func (s *SpriteSource) Open(key string) (io.ReadCloser, time.Time, error) {
+	id, err := strconv.Atoi(key)
+	if err != nil || id < 1 || id > 999 {
+		return nil, time.Time{}, fmt.Errorf("sprite %q not found", key)
+	}
+	f, err := os.Open(filepath.Join(s.dir, fmt.Sprintf("%03d.png", id)))
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("sprite %q not found", key)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, time.Time{}, err
+	}
+	return f, info.ModTime(), nil
+}
+
+// ResizedImage is an encoded image ready to serve
+type ResizedImage struct {
+	Data        []byte
+	ContentType string
+	ETag        string
+	ModTime     time.Time
+}
+
+// ImageService resizes images from registered sources on demand and keeps
+// recent results in memory. Image IDs are "<kind>-<key>", e.g. "pokemon-25".
+type ImageService struct {
+	sources map[string]ImageSource
+
+	mu         sync.Mutex
+	cache      map[string]*ResizedImage
+	order      []string // cache keys, oldest first
+	cacheBytes int      // encoded bytes of the cached images
+}
+
+// NewImageService creates an image service without sources
+func NewImageService() *ImageService {
+	return &ImageService{
+		sources: make(map[string]ImageSource),
+		cache:   make(map[string]*ResizedImage),
+	}
+}
+
+// RegisterSource serves IDs of the form "<kind>-<key>" from source
+func (s *ImageService) RegisterSource(kind string, source ImageSource) {
+	s.sources[kind] = source
+}
+
+// GetImage returns the image with the given ID scaled to fit within
+// width x height, keeping its aspect ratio. A zero dimension is derived
+// from the other; both zero returns the original size. Either way no side
+// is larger than MaxImageDimension.
+func (s *ImageService) GetImage(id string, width, height int) (*ResizedImage, error) {
+	if width < 0 || height < 0 || width > MaxImageDimension || height > MaxImageDimension {
+		return nil, fmt.Errorf("invalid size: w and h must be between 0 and %d", MaxImageDimension)
+	}
+	kind, key, ok := strings.Cut(id, "-")
+	source := s.sources[kind]
+	if !ok || source == nil {
+		return nil, fmt.Errorf("image %q not found", id)
+	}
+
+	r, modTime, err := source.Open(key)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	cacheKey := fmt.Sprintf("%s@%dx%d", id, width, height)
+	s.mu.Lock()
+	cached, ok := s.cache[cacheKey]
+	s.mu.Unlock()
+	if ok && cached.ModTime.Equal(modTime) {
+		return cached, nil
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image %s: %w", id, err)
+	}
+	// Check the header first, so a small file claiming a huge size never
+	// gets decoded
+	if err := checkPixels(bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("failed to decode image %s: %w", id, err)
+	}
+	original, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image %s: %w", id, err)
+	}
+
+	bounds := original.Bounds()
+	targetWidth, targetHeight := fitWithin(bounds.Dx(), bounds.Dy(), width, height)
+	if targetWidth > MaxImageDimension || targetHeight > MaxImageDimension {
+		targetWidth, targetHeight = fitWithin(targetWidth, targetHeight, MaxImageDimension, MaxImageDimension)
+	}
+	scaled := original
+	if targetWidth != bounds.Dx() || targetHeight != bounds.Dy() {
+		scaled = resizeImage(original, targetWidth, targetHeight)
+	}
+
+	var encoded bytes.Buffer
+	resized := &ResizedImage{ModTime: modTime}
+	if format == "jpeg" {
+		resized.ContentType = "image/jpeg"
+		err = jpeg.Encode(&encoded, scaled, &jpeg.Options{Quality: 85})
+	} else {
+		resized.ContentType = "image/png"
+		err = png.Encode(&encoded, scaled)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode image %s: %w", id, err)
+	}
+	resized.Data = encoded.Bytes()
+	sum := sha256.Sum256(resized.Data)
+	resized.ETag = `"` + hex.EncodeToString(sum[:8]) + `"`
+
+	s.store(cacheKey, resized)
+	return resized, nil
+}
+
+// store caches an image, evicting the oldest entries once the cache holds
+// more than imageCacheBytes
+func (s *ImageService) store(key string, resized *ResizedImage) {
+	if len(resized.Data) > imageCacheBytes {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if previous, ok := s.cache[key]; ok {
+		s.cacheBytes -= len(previous.Data)
+	} else {
+		s.order = append(s.order, key)
+	}
+	s.cache[key] = resized
+	s.cacheBytes += len(resized.Data)
+	for s.cacheBytes > imageCacheBytes {
+		s.cacheBytes -= len(s.cache[s.order[0]].Data)
+		delete(s.cache, s.order[0])
+		s.order = s.order[1:]
+	}
+}
+
+// checkPixels reads an image's header and refuses images with more than
+// MaxImagePixels, which would take too much memory to decode
+func checkPixels(r io.Reader) error {
+	config, _, err := image.DecodeConfig(r)
+	if err != nil {
+		return err
+	}
+	if config.Width <= 0 || config.Height <= 0 {
+		return fmt.Errorf("image has no pixels")
+	}
+	if int64(config.Width)*int64(config.Height) > MaxImagePixels {
+		return fmt.Errorf("image is %dx%d pixels, more than %d megapixels", config.Width, config.Height, MaxImagePixels/1000/1000)
+	}
+	return nil
+}
+
+// fitWithin scales width x height to fit the requested box, keeping the
+// aspect ratio
+func fitWithin(width, height, maxWidth, maxHeight int) (int, int) {
+	if width == 0 || height == 0 || maxWidth == 0 && maxHeight == 0 {
+		return width, height
+	}
+	scale := 0.0
+	switch {
+	case maxWidth == 0:
+		scale = float64(maxHeight) / float64(height)
+	case maxHeight == 0:
+		scale = float64(maxWidth) / float64(width)
+	default:
+		scale = math.Min(float64(maxWidth)/float64(width), float64(maxHeight)/float64(height))
+	}
+	return max(1, int(float64(width)*scale+0.5)), max(1, int(float64(height)*scale+0.5))
+}
+
+// resizeImage scales src to width x height. Shrinking averages every source
+// pixel under a target pixel, which keeps photos smooth; enlarging repeats
+// pixels, which keeps pixel-art sprites crisp.
+func resizeImage(src image.Image, width, height int) image.Image {
+	bounds := src.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	scaleX := float64(bounds.Dx()) / float64(width)
+	scaleY := float64(bounds.Dy()) / float64(height)
+
+	for y := 0; y < height; y++ {
+		y0 := bounds.Min.Y + int(float64(y)*scaleY)
+		y1 := max(y0+1, bounds.Min.Y+int(float64(y+1)*scaleY))
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + int(float64(x)*scaleX)
+			x1 := max(x0+1, bounds.Min.X+int(float64(x+1)*scaleX))
+
+			// Average in premultiplied alpha so transparent edges don't darken
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa)
+					n++
+				}
+			}
+			r, g, b, a = r/n, g/n, b/n, a/n
+			if a == 0 {
+				continue
+			}
+			dst.SetNRGBA(x, y, color.NRGBA{
+				R: uint8(r * 0xffff / a >> 8),
+				G: uint8(g * 0xffff / a >> 8),
+				B: uint8(b * 0xffff / a >> 8),
+				A: uint8(a >> 8),
+			})
+		}
+	}
+	return dst
+}
@@ -0,0 +1,602 @@
+package service
+
+import (
+	"fmt"
+	"go-coffee-log/models"
+	"go-coffee-log/storage"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Pokemon conflict policies for ImportInstance. A conflict is an imported
+// catch whose Pokemon is already caught by a coffee on this instance in the
+// same dex profile.
+const (
+	ConflictKeep  = "keep"  // keep the local catch; import the coffee uncaught
+	ConflictSkip  = "skip"  // leave the conflicting coffee (and its brews) out
+	ConflictTrade = "trade" // give the imported coffee an unused Pokemon of the same type
+)
+
+// ImportService merges another instance's export archive into this one
+type ImportService struct {
+	coffeeStorage  storage.CoffeeStorage
+	brewStorage    storage.BrewStorage
+	commentStorage storage.CommentStorage
+	pokemonStorage storage.PokemonStorage // optional
+	brewerStorage  storage.BrewerStorage  // optional
+	dexes          *DexService            // optional
+	archive        ArchiveStorage
+}
+
+// NewImportService creates a new import service
+func NewImportService(
+	coffeeStorage storage.CoffeeStorage,
+	brewStorage storage.BrewStorage,
+	commentStorage storage.CommentStorage,
+	pokemonStorage storage.PokemonStorage,
+	brewerStorage storage.BrewerStorage,
+) *ImportService {
+	return &ImportService{
+		coffeeStorage:  coffeeStorage,
+		brewStorage:    brewStorage,
+		commentStorage: commentStorage,
+		pokemonStorage: pokemonStorage,
+		brewerStorage:  brewerStorage,
+	}
+}
+
+// SetDexService lets imported catches be checked against this instance's dex
+// profiles; catches in profiles it lacks are dropped
+func (s *ImportService) SetDexService(dexes *DexService) {
+	s.dexes = dexes
+}
+
+// SetArchiveStorage lets imports bring in collections, photos, saved
+// searches, plans, subscriptions, scoresheets, badges and safari attempts
+func (s *ImportService) SetArchiveStorage(archive ArchiveStorage) {
+	s.archive = archive
+}
+
+// PokemonConflict describes how one conflicting catch was resolved
+type PokemonConflict struct {
+	CoffeeName  string `json:"coffee_name"`
+	PokemonName string `json:"pokemon_name"`
+	Resolution  string `json:"resolution"` // "keep", "skip", "trade"
+	TradedFor   string `json:"traded_for,omitempty"`
+}
+
+// ImportReport summarizes an import
+type ImportReport struct {
+	DryRun          bool              `json:"dry_run"`
+	Policy          string            `json:"policy"`
+	Coffees         int               `json:"coffees"`
+	Brews           int               `json:"brews"`
+	Comments        int               `json:"comments"`
+	Pokemon         int               `json:"pokemon"`
+	Brewers         int               `json:"brewers"`
+	Collections     int               `json:"collections"`
+	Photos          int               `json:"photos"`
+	SavedSearches   int               `json:"saved_searches"`
+	Plans           int               `json:"plans"`
+	Subscriptions   int               `json:"subscriptions"`
+	Eggs            int               `json:"eggs"`
+	Scoresheets     int               `json:"scoresheets"`
+	JudgeScores     int               `json:"judge_scores"`
+	Badges          int               `json:"badges"`
+	SafariAttempts  int               `json:"safari_attempts"`
+	SkippedCoffees  int               `json:"skipped_coffees"`
+	DroppedPokemon  int               `json:"dropped_pokemon"` // catches lost to "keep" or missing Pokemon storage
+	DroppedPhotos   int               `json:"dropped_photos"`  // photos whose image is in neither the archive nor media storage
+	Conflicts       []PokemonConflict `json:"conflicts"`
+	CoffeeIDMapping map[string]string `json:"coffee_id_mapping"` // archive ID -> new ID
+}
+
+// ImportInstance imports an archive produced by GET /users/me/export. Every
+// entity gets a new ID; references between them are remapped. User
+// references (brewed_by, added_by, comment authors) are dropped since users
+// are not part of the archive, and the archive's badges and safari attempts
+// go to userID, the importing user. Reactions and mapping disagreements are
+// not imported. With dryRun nothing is written and the report shows what
+// would happen.
+func (s *ImportService) ImportInstance(archive DataExport, policy, userID string, dryRun bool) (*ImportReport, error) {
+	if archive.Format != "coffee-dex-export" {
+		return nil, fmt.Errorf("invalid archive: unknown format %q", archive.Format)
+	}
+	if archive.Version < 1 || archive.Version > exportFormatVersion {
+		return nil, fmt.Errorf("invalid archive: unsupported version %d", archive.Version)
+	}
+	if policy == "" {
+		policy = ConflictKeep
+	}
+	if policy != ConflictKeep && policy != ConflictSkip && policy != ConflictTrade {
+		return nil, fmt.Errorf("invalid conflict policy %q: must be keep, skip or trade", policy)
+	}
+	for hash, data := range archive.PhotoData {
+		if storage.ContentHash(data) != hash {
+			return nil, fmt.Errorf("invalid archive: photo data does not match hash %s", hash)
+		}
+	}
+
+	report := &ImportReport{
+		DryRun:          dryRun,
+		Policy:          policy,
+		Conflicts:       []PokemonConflict{},
+		CoffeeIDMapping: make(map[string]string),
+	}
+
+	// Decide what happens to every catch before writing anything
+	catches, skipped, err := s.planCatches(archive, policy, report)
+	if err != nil {
+		return nil, err
+	}
+
+	// Brewers come first so coffees can point at their new IDs
+	brewerIDs := make(map[string]string)
+	if s.brewerStorage != nil {
+		for _, brewer := range archive.Brewers {
+			oldID := brewer.ID
+			brewer.ID = uuid.New().String()
+			brewerIDs[oldID] = brewer.ID
+			for i := range brewer.Recipes {
+				brewer.Recipes[i].ID = uuid.New().String()
+			}
+			if !dryRun {
+				if err := s.brewerStorage.SaveBrewer(brewer); err != nil {
+					return report, fmt.Errorf("failed to import brewer %q: %w", brewer.Name, err)
+				}
+			}
+			report.Brewers++
+		}
+	}
+
+	// Every coffee's new ID is known up front, since lineages point at
+	// other coffees
+	for _, coffee := range archive.Coffees {
+		if !skipped[coffee.ID] {
+			report.CoffeeIDMapping[coffee.ID] = uuid.New().String()
+		}
+	}
+
+	for _, coffee := range archive.Coffees {
+		if skipped[coffee.ID] {
+			report.SkippedCoffees++
+			continue
+		}
+
+		oldID := coffee.ID
+		coffee.ID = report.CoffeeIDMapping[oldID]
+		coffee.DialIn = nil
+		coffee.BrewerID = brewerIDs[coffee.BrewerID]
+		coffee.Lineage = report.CoffeeIDMapping[coffee.Lineage]
+		coffee.AddedBy = ""
+		if !dryRun {
+			if err := s.coffeeStorage.Save(coffee); err != nil {
+				return report, fmt.Errorf("failed to import coffee %q: %w", coffee.Name, err)
+			}
+		}
+		report.Coffees++
+
+		for _, mapping := range catches[oldID] {
+			mapping.ID = uuid.New().String()
+			mapping.CoffeeID = coffee.ID
+			mapping.Reactions = nil
+			if !dryRun {
+				if err := s.pokemonStorage.CreateCoffeePokemon(mapping); err != nil {
+					return report, fmt.Errorf("failed to import catch for %q: %w", coffee.Name, err)
+				}
+			}
+			report.Pokemon++
+		}
+	}
+
+	brewIDs := make(map[string]string)
+	for _, brew := range archive.Brews {
+		coffeeID, ok := report.CoffeeIDMapping[brew.CoffeeID]
+		if !ok {
+			continue
+		}
+		oldID := brew.ID
+		brew.ID = uuid.New().String()
+		brewIDs[oldID] = brew.ID
+		brew.CoffeeID = coffeeID
+		brew.BrewedBy = ""
+		if !dryRun {
+			if err := s.brewStorage.SaveBrew(brew); err != nil {
+				return report, fmt.Errorf("failed to import brew: %w", err)
+			}
+		}
+		report.Brews++
+	}
+
+	if err := s.importComments(archive.Comments, report, dryRun); err != nil {
+		return report, err
+	}
+	if err := s.importArchive(archive, userID, brewerIDs, brewIDs, report, dryRun); err != nil {
+		return report, err
+	}
+
+	if !dryRun {
+		log.Printf("INFO: Imported %d coffees, %d brews, %d catches (%d conflicts, policy %s)",
+			report.Coffees, report.Brews, report.Pokemon, len(report.Conflicts), policy)
+	}
+	return report, nil
+}
+
+// dexPokemon identifies a Pokemon within one dex profile
+type dexPokemon struct {
+	dex string
+	id  int
+}
+
+// planCatches resolves which catches to import, keyed by archive coffee ID,
+// and which coffees to skip entirely
+func (s *ImportService) planCatches(archive DataExport, policy string, report *ImportReport) (map[string][]models.CoffeePokemon, map[string]bool, error) {
+	catches := make(map[string][]models.CoffeePokemon)
+	skipped := make(map[string]bool)
+
+	if s.pokemonStorage == nil {
+		report.DroppedPokemon = len(archive.Pokemon)
+		return catches, skipped, nil
+	}
+
+	coffeeNames := make(map[string]string)
+	for _, coffee := range archive.Coffees {
+		coffeeNames[coffee.ID] = coffee.Name
+	}
+
+	claimed := make(map[dexPokemon]bool) // Pokemon given out during this import
+	for _, mapping := range archive.Pokemon {
+		if _, ok := coffeeNames[mapping.CoffeeID]; !ok {
+			continue
+		}
+		if mapping.Dex == "" {
+			// Archives from before dex profiles only have Kanto catches
+			mapping.Dex = models.DefaultDex
+		}
+		dex, err := s.dexProfile(mapping.Dex)
+		if err != nil {
+			log.Printf("WARNING: Dropping imported catch in dex %s: %v", mapping.Dex, err)
+			report.DroppedPokemon++
+			continue
+		}
+
+		used, err := s.isTaken(dex, mapping.PokemonID, claimed)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !used {
+			claimed[dexPokemon{dex.ID, mapping.PokemonID}] = true
+			catches[mapping.CoffeeID] = append(catches[mapping.CoffeeID], mapping)
+			continue
+		}
+
+		conflict := PokemonConflict{
+			CoffeeName:  coffeeNames[mapping.CoffeeID],
+			PokemonName: mapping.PokemonName,
+			Resolution:  policy,
+		}
+
+		switch policy {
+		case ConflictSkip:
+			skipped[mapping.CoffeeID] = true
+		case ConflictTrade:
+			traded, err := s.findTrade(dex, mapping.PokemonID, claimed)
+			if err != nil {
+				return nil, nil, err
+			}
+			if traded == nil {
+				// Nothing left to trade for; fall back to keeping the local catch
+				conflict.Resolution = ConflictKeep
+				report.DroppedPokemon++
+				break
+			}
+			claimed[dexPokemon{dex.ID, traded.ID}] = true
+			conflict.TradedFor = traded.Name
+			mapping.PokemonID = traded.ID
+			mapping.PokemonName = traded.Name
+			catches[mapping.CoffeeID] = append(catches[mapping.CoffeeID], mapping)
+		default:
+			report.DroppedPokemon++
+		}
+
+		report.Conflicts = append(report.Conflicts, conflict)
+	}
+
+	// Skipped coffees take every one of their catches with them
+	for coffeeID := range skipped {
+		delete(catches, coffeeID)
+	}
+
+	return catches, skipped, nil
+}
+
+// dexProfile looks up an imported catch's dex profile on this instance.
+// Without a dex service only the default profile is known.
+func (s *ImportService) dexProfile(id string) (models.DexProfile, error) {
+	if s.dexes != nil {
+		return s.dexes.Profile(id)
+	}
+	if id != models.DefaultDex {
+		return models.DexProfile{}, fmt.Errorf("dex profile not found")
+	}
+	return builtinDexes[0], nil
+}
+
+// isTaken reports whether a Pokemon is caught locally or already claimed in
+// a dex profile
+func (s *ImportService) isTaken(dex models.DexProfile, pokemonID int, claimed map[dexPokemon]bool) (bool, error) {
+	if claimed[dexPokemon{dex.ID, pokemonID}] {
+		return true, nil
+	}
+	used, err := s.pokemonStorage.IsPokemonUsed(dex.ID, pokemonID)
+	if err != nil {
+		return false, fmt.Errorf("failed to check Pokemon usage: %w", err)
+	}
+	return used, nil
+}
+
+// findTrade picks an unused Pokemon of the same type from the dex profile,
+// or nil if none is left
+func (s *ImportService) findTrade(dex models.DexProfile, pokemonID int, claimed map[dexPokemon]bool) (*models.Pokemon, error) {
+	original, err := s.pokemonStorage.GetPokemonByID(pokemonID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Pokemon %d: %w", pokemonID, err)
+	}
+
+	candidates, err := s.pokemonStorage.GetPokemonByType(original.Type)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Pokemon by type: %w", err)
+	}
+
+	for _, candidate := range inDex(candidates, dex) {
+		taken, err := s.isTaken(dex, candidate.ID, claimed)
+		if err != nil {
+			return nil, err
+		}
+		if !taken {
+			return &candidate, nil
+		}
+	}
+	return nil, nil
+}
+
+// importComments imports comments on imported coffees, remapping threads
+func (s *ImportService) importComments(comments []models.Comment, report *ImportReport, dryRun bool) error {
+	commentIDs := make(map[string]string)
+	for _, comment := range comments {
+		if _, ok := report.CoffeeIDMapping[comment.CoffeeID]; ok {
+			commentIDs[comment.ID] = uuid.New().String()
+		}
+	}
+
+	now := time.Now()
+	for _, comment := range comments {
+		newID, ok := commentIDs[comment.ID]
+		if !ok {
+			continue
+		}
+		comment.ID = newID
+		comment.CoffeeID = report.CoffeeIDMapping[comment.CoffeeID]
+		comment.ParentID = commentIDs[comment.ParentID]
+		comment.AuthorID = ""
+		comment.Replies = nil
+		if comment.UpdatedAt.IsZero() {
+			comment.UpdatedAt = now
+		}
+		if !dryRun {
+			if err := s.commentStorage.SaveComment(comment); err != nil {
+				return fmt.Errorf("failed to import comment: %w", err)
+			}
+		}
+		report.Comments++
+	}
+	return nil
+}
+
+// importArchive imports the data of the archive storages, remapping
+// references to imported coffees, brewers and brews. Data referring to a
+// coffee that was not imported is left out.
+func (s *ImportService) importArchive(archive DataExport, userID string, brewerIDs, brewIDs map[string]string, report *ImportReport, dryRun bool) error {
+	coffeeIDs := report.CoffeeIDMapping
+
+	if s.archive.Collections != nil {
+		for _, collection := range archive.Collections {
+			collection.ID = uuid.New().String()
+			var members []string
+			for _, coffeeID := range collection.CoffeeIDs {
+				if id, ok := coffeeIDs[coffeeID]; ok {
+					members = append(members, id)
+				}
+			}
+			collection.CoffeeIDs = members
+			if !dryRun {
+				if err := s.archive.Collections.SaveCollection(collection); err != nil {
+					return fmt.Errorf("failed to import collection %q: %w", collection.Name, err)
+				}
+			}
+			report.Collections++
+		}
+	}
+
+	if s.archive.Photos != nil && s.archive.Media != nil {
+		for _, photo := range archive.Photos {
+			coffeeID, ok := coffeeIDs[photo.CoffeeID]
+			if !ok {
+				continue
+			}
+			stored, err := s.importPhotoData(photo.Hash, archive.PhotoData[photo.Hash], dryRun)
+			if err != nil {
+				return err
+			}
+			if !stored {
+				report.DroppedPhotos++
+				continue
+			}
+			photo.CoffeeID = coffeeID
+			photo.UploadedBy = ""
+			photo.URL = ""
+			if !dryRun {
+				if err := s.archive.Photos.SavePhoto(photo); err != nil {
+					return fmt.Errorf("failed to import photo: %w", err)
+				}
+			}
+			report.Photos++
+		}
+	}
+
+	if s.archive.SavedSearches != nil {
+		for _, search := range archive.SavedSearches {
+			search.ID = uuid.New().String()
+			if !dryRun {
+				if err := s.archive.SavedSearches.SaveSearch(search); err != nil {
+					return fmt.Errorf("failed to import saved search %q: %w", search.Name, err)
+				}
+			}
+			report.SavedSearches++
+		}
+	}
+
+	if s.archive.Plans != nil {
+		for _, plan := range archive.Plans {
+			coffeeID, ok := coffeeIDs[plan.CoffeeID]
+			if !ok {
+				continue
+			}
+			plan.ID = uuid.New().String()
+			plan.CoffeeID = coffeeID
+			plan.CoffeeName = ""
+			plan.BrewID = brewIDs[plan.BrewID]
+			if !dryRun {
+				if err := s.archive.Plans.SavePlan(plan); err != nil {
+					return fmt.Errorf("failed to import plan: %w", err)
+				}
+			}
+			report.Plans++
+		}
+	}
+
+	if s.archive.Subscriptions != nil {
+		subscriptionIDs := make(map[string]string)
+		for _, subscription := range archive.Subscriptions {
+			oldID := subscription.ID
+			subscription.ID = uuid.New().String()
+			subscriptionIDs[oldID] = subscription.ID
+			if !dryRun {
+				if err := s.archive.Subscriptions.SaveSubscription(subscription); err != nil {
+					return fmt.Errorf("failed to import subscription %q: %w", subscription.Name, err)
+				}
+			}
+			report.Subscriptions++
+		}
+		for _, egg := range archive.Eggs {
+			subscriptionID, ok := subscriptionIDs[egg.SubscriptionID]
+			if !ok {
+				continue
+			}
+			egg.ID = uuid.New().String()
+			egg.SubscriptionID = subscriptionID
+			egg.CoffeeID = coffeeIDs[egg.CoffeeID]
+			egg.Status = ""
+			if !dryRun {
+				if err := s.archive.Subscriptions.SaveEgg(egg); err != nil {
+					return fmt.Errorf("failed to import egg: %w", err)
+				}
+			}
+			report.Eggs++
+		}
+	}
+
+	if s.archive.Scoresheets != nil {
+		sheetIDs := make(map[string]string)
+		for _, sheet := range archive.Scoresheets {
+			coffeeID, ok := coffeeIDs[sheet.CoffeeID]
+			if !ok {
+				continue
+			}
+			oldID := sheet.ID
+			sheet.ID = uuid.New().String()
+			sheetIDs[oldID] = sheet.ID
+			sheet.CoffeeID = coffeeID
+			if !dryRun {
+				if err := s.archive.Scoresheets.SaveScoresheet(sheet); err != nil {
+					return fmt.Errorf("failed to import scoresheet: %w", err)
+				}
+			}
+			report.Scoresheets++
+		}
+		for _, score := range archive.JudgeScores {
+			sheetID, ok := sheetIDs[score.ScoresheetID]
+			if !ok {
+				continue
+			}
+			score.ScoresheetID = sheetID
+			if !dryRun {
+				if err := s.archive.Scoresheets.SaveJudgeScore(score); err != nil {
+					return fmt.Errorf("failed to import judge score: %w", err)
+				}
+			}
+			report.JudgeScores++
+		}
+	}
+
+	if s.archive.Badges != nil {
+		for _, badge := range archive.Badges {
+			badge.UserID = userID
+			badge.BrewerID = brewerIDs[badge.BrewerID]
+			if !dryRun {
+				awarded, err := s.archive.Badges.AwardBadge(badge)
+				if err != nil {
+					return fmt.Errorf("failed to import badge %q: %w", badge.Name, err)
+				}
+				if !awarded {
+					continue
+				}
+			}
+			report.Badges++
+		}
+	}
+
+	if s.archive.Safari != nil {
+		for _, attempt := range archive.SafariAttempts {
+			attempt.ID = uuid.New().String()
+			attempt.UserID = userID
+			attempt.CoffeeID = coffeeIDs[attempt.CoffeeID]
+			attempt.Status = ""
+			if !dryRun {
+				if err := s.archive.Safari.SaveAttempt(attempt); err != nil {
+					return fmt.Errorf("failed to import safari attempt: %w", err)
+				}
+			}
+			report.SafariAttempts++
+		}
+	}
+
+	return nil
+}
+
+// importPhotoData stores an imported photo's image in media storage and
+// reports whether the photo has one. Archives without the image can still
+// refer to one this instance already stores.
+func (s *ImportService) importPhotoData(hash string, data []byte, dryRun bool) (bool, error) {
+	if len(data) > 0 {
+		if !dryRun {
+			if _, err := s.archive.Media.Put(data); err != nil {
+				return false, fmt.Errorf("failed to import photo %s: %w", hash, err)
+			}
+		}
+		return true, nil
+	}
+
+	blob, _, err := s.archive.Media.Open(hash)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check photo %s: %w", hash, err)
+	}
+	blob.Close()
+	return true, nil
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Queued job statuses
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+)
+
+const (
+	// DefaultJobWorkers is how many queued jobs run at once by default
+	DefaultJobWorkers = 2
+	// jobQueueCapacity caps the jobs waiting for a worker
+	jobQueueCapacity = 100
+	// jobRetention is how long a finished job stays available for polling
+	jobRetention = time.Hour
+)
+
+// QueuedJob is a unit of slow work, e.g. an LLM-backed Pokemon mapping,
+// run by a worker after the request that queued it has been answered
+type QueuedJob struct {
+	ID         string      `json:"id"`
+	Kind       string      `json:"kind"` // e.g. "pokemon.generate"
+	Owner      string      `json:"-"`    // the user who queued it; empty without authentication
+	Status     string      `json:"status"`
+	Result     interface{} `json:"result,omitempty"`
+	Error      string      `json:"error,omitempty"`
+	CreatedAt  time.Time   `json:"created_at"`
+	StartedAt  *time.Time  `json:"started_at,omitempty"`
+	FinishedAt *time.Time  `json:"finished_at,omitempty"`
+}
+
+// queuedWork is a job waiting for a worker
+type queuedWork struct {
+	job *QueuedJob
+	ctx context.Context
+	run func(ctx context.Context) (interface{}, error)
+}
+
+// JobQueue runs queued jobs on a fixed pool of workers. Jobs live in
+// memory; a restart forgets them, including those not yet run.
+type JobQueue struct {
+	workers int
+	timeout time.Duration // per job; 0 for none
+	queue   chan queuedWork
+
+	mu   sync.Mutex
+	jobs map[string]*QueuedJob
+}
+
+// NewJobQueue creates a job queue run by workers workers (at least one),
+// each job limited to timeout (0 for no limit)
+func NewJobQueue(workers int, timeout time.Duration) *JobQueue {
+	return &JobQueue{
+		workers: max(workers, 1),
+		timeout: timeout,
+		queue:   make(chan queuedWork, jobQueueCapacity),
+		jobs:    make(map[string]*QueuedJob),
+	}
+}
+
+// Submit queues run on behalf of owner. It gets a context that keeps ctx's
+// values, e.g. the caller or ?llm=false, but not its cancellation, so a job
+// outlives the request that queued it. Poll Job with the returned job's ID.
+func (q *JobQueue) Submit(ctx context.Context, owner, kind string, run func(ctx context.Context) (interface{}, error)) (QueuedJob, error) {
+	job := &QueuedJob{
+		ID:        uuid.New().String(),
+		Kind:      kind,
+		Owner:     owner,
+		Status:    JobQueued,
+		CreatedAt: time.Now(),
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.prune(job.CreatedAt)
+
+	select {
+	case q.queue <- queuedWork{job: job, ctx: context.WithoutCancel(ctx), run: run}:
+	default:
+		return QueuedJob{}, fmt.Errorf("job queue is full: %d jobs waiting", jobQueueCapacity)
+	}
+	q.jobs[job.ID] = job
+	return *job, nil
+}
+
+// Job returns a job's status, and its result once it has succeeded. Other
+// users' jobs are not found.
+func (q *JobQueue) Job(id, owner string) (QueuedJob, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job, ok := q.jobs[id]
+	if !ok || job.Owner != owner {
+		return QueuedJob{}, fmt.Errorf("job not found")
+	}
+	return *job, nil
+}
+
+// Run works through queued jobs until ctx is cancelled
+func (q *JobQueue) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < q.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case work := <-q.queue:
+					q.work(work)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// work runs one job and records its outcome
+func (q *JobQueue) work(work queuedWork) {
+	started := time.Now()
+	q.mu.Lock()
+	work.job.Status = JobRunning
+	work.job.StartedAt = &started
+	q.mu.Unlock()
+
+	ctx := work.ctx
+	if q.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, q.timeout)
+		defer cancel()
+	}
+	result, err := work.run(ctx)
+
+	finished := time.Now()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	work.job.FinishedAt = &finished
+	if err != nil {
+		work.job.Status = JobFailed
+		work.job.Error = err.Error()
+		log.Printf("Jobs: %s job %s failed after %s: %v", work.job.Kind, work.job.ID, finished.Sub(started).Round(time.Millisecond), err)
+		return
+	}
+	work.job.Status = JobSucceeded
+	work.job.Result = result
+}
+
+// prune forgets jobs finished more than jobRetention ago. The caller holds
+// q.mu.
+func (q *JobQueue) prune(now time.Time) {
+	for id, job := range q.jobs {
+		if job.FinishedAt != nil && now.Sub(*job.FinishedAt) > jobRetention {
+			delete(q.jobs, id)
+		}
+	}
+}
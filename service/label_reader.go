@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go-coffee-log/models"
+)
+
+// Values a label's processing method and roast level may be read as. ""
+// means the label does not say.
+var (
+	labelProcessingMethods = []string{"", "washed", "natural", "honey", "coferment", "experimental"}
+	labelRoastLevels       = []string{"", "light", "light medium", "medium", "medium dark", "dark"}
+)
+
+// labelFields are the coffee fields read from a bag label
+var labelFields = []string{"name", "roaster", "origin", "processing_method", "roast_level"}
+
+// LabelDraft is a coffee read from a photo of its bag. Nothing is saved;
+// the draft is meant to be checked and sent to POST /coffees.
+type LabelDraft struct {
+	Coffee  models.Coffee `json:"coffee"`
+	Missing []string      `json:"missing"` // fields the label did not show
+	Model   string        `json:"model"`   // the model that read it
+}
+
+// labelSchema is the JSON schema of a label reading, in OpenAI's strict
+// subset. Unreadable fields are empty strings.
+func labelSchema() map[string]interface{} {
+	properties := make(map[string]interface{}, len(labelFields))
+	for _, field := range labelFields {
+		properties[field] = map[string]interface{}{"type": "string"}
+	}
+	properties["processing_method"] = map[string]interface{}{"type": "string", "enum": labelProcessingMethods}
+	properties["roast_level"] = map[string]interface{}{"type": "string", "enum": labelRoastLevels}
+	return map[string]interface{}{
+		"type":                 "object",
+		"additionalProperties": false,
+		"required":             labelFields,
+		"properties":           properties,
+	}
+}
+
+// ReadCoffeeLabel sends a photo of a coffee bag to the vision model and
+// returns a draft coffee with the name, roaster, origin, processing method
+// and roast level printed on it
+func (s *LLMService) ReadCoffeeLabel(ctx context.Context, image []byte) (*LabelDraft, error) {
+	if !s.Available() {
+		return nil, fmt.Errorf("LLM service is not available")
+	}
+	if len(image) == 0 {
+		return nil, fmt.Errorf("invalid image: empty upload")
+	}
+	if len(image) > MaxPhotoSize {
+		return nil, fmt.Errorf("invalid image: larger than %d MB", MaxPhotoSize>>20)
+	}
+	contentType := http.DetectContentType(image)
+	if !photoContentTypes[contentType] {
+		return nil, fmt.Errorf("invalid image: %s is not a JPEG, PNG or GIF image", contentType)
+	}
+
+	prompt := s.buildLabelPrompt()
+	schema := labelSchema()
+	label := LLMImage{MediaType: contentType, Data: image}
+
+	// Only the vision model can read the image, so there is no fallback
+	var draft *LabelDraft
+	model, err := s.retryModels(ctx, []string{s.visionModel()}, func(model string) error {
+		response, err := s.complete(ctx, model, prompt, &label, schema)
+		if err != nil {
+			return err
+		}
+		draft, err = parseLabelResponse(response)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	draft.Model = model
+	return draft, nil
+}
+
+// buildLabelPrompt asks the LLM to transcribe a coffee bag label
+func (s *LLMService) buildLabelPrompt() string {
+	return fmt.Sprintf(`This is a photo of a specialty coffee bag. Read its label and fill in:
+
+- name: the coffee's name as printed, e.g. the farm, lot or blend name
+- roaster: the company that roasted it
+- origin: the country, and region if printed, e.g. "Ethiopia, Guji"
+- processing_method: one of %s
+- roast_level: one of %s
+
+Copy names as printed. Use an empty string for anything the label does not show; do not guess.
+
+Respond with ONLY valid JSON:
+{"name": "Kochere", "roaster": "Tim Wendelboe", "origin": "Ethiopia, Yirgacheffe", "processing_method": "washed", "roast_level": "light"}`,
+		strings.Join(labelProcessingMethods[1:], ", "), strings.Join(labelRoastLevels[1:], ", "))
+}
+
+// parseLabelResponse decodes and checks a label reading, since not every
+// provider enforces the schema
+func parseLabelResponse(response string) (*LabelDraft, error) {
+	response = strings.TrimSpace(response)
+	response = strings.ReplaceAll(response, "```json", "")
+	response = strings.ReplaceAll(response, "```", "")
+
+	var fields map[string]string
+	if err := json.Unmarshal([]byte(response), &fields); err != nil {
+		return nil, fmt.Errorf("%w: %v", errMalformedLLMResponse, err)
+	}
+	for name, value := range fields {
+		fields[name] = strings.TrimSpace(value)
+	}
+
+	draft := &LabelDraft{
+		Coffee: models.Coffee{
+			Name:             fields["name"],
+			Roaster:          fields["roaster"],
+			Origin:           fields["origin"],
+			ProcessingMethod: strings.ToLower(fields["processing_method"]),
+			RoastLevel:       strings.ToLower(fields["roast_level"]),
+		},
+		Missing: []string{},
+	}
+	if !containsString(labelProcessingMethods, draft.Coffee.ProcessingMethod) {
+		return nil, fmt.Errorf("%w: unknown processing_method %q", errMalformedLLMResponse, draft.Coffee.ProcessingMethod)
+	}
+	if !containsString(labelRoastLevels, draft.Coffee.RoastLevel) {
+		return nil, fmt.Errorf("%w: unknown roast_level %q", errMalformedLLMResponse, draft.Coffee.RoastLevel)
+	}
+	for _, field := range labelFields {
+		if fields[field] == "" {
+			draft.Missing = append(draft.Missing, field)
+		}
+	}
+	return draft, nil
+}
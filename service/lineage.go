@@ -0,0 +1,111 @@
+package service
+
+import (
+	"sort"
+	"time"
+
+	"go-coffee-log/models"
+)
+
+// LineageBag is one bag of a coffee, compared with the bag before it.
+// Deltas are left out when either bag is unrated or the trait is unscored.
+type LineageBag struct {
+	ID            string               `json:"id"`
+	Name          string               `json:"name"`
+	RoastDate     *models.Date         `json:"roast_date,omitempty"`
+	CreatedAt     time.Time            `json:"created_at"`
+	Rating        int                  `json:"rating"`
+	TastingTraits models.TastingTraits `json:"tasting_traits"`
+	RatingDelta   *int                 `json:"rating_delta,omitempty"`
+	TraitDeltas   map[string]int       `json:"trait_deltas,omitempty"` // traits that changed
+}
+
+// CoffeeLineage lists every bag of a coffee, oldest first
+type CoffeeLineage struct {
+	Lineage      string       `json:"lineage"` // ID of the first bag
+	Bags         []LineageBag `json:"bags"`
+	RatingChange *int         `json:"rating_change,omitempty"` // latest rated bag minus the first rated one
+	BestBag      string       `json:"best_bag,omitempty"`      // ID of the highest-rated bag, the latest on ties
+}
+
+// bagDate orders bags by roast date, falling back to when they were logged
+func bagDate(coffee models.Coffee) time.Time {
+	if coffee.RoastDate != nil {
+		return coffee.RoastDate.Time
+	}
+	return coffee.CreatedAt
+}
+
+// GetLineage returns all bags of the coffee a bag belongs to, oldest first,
+// with the rating and trait changes from one bag to the next
+func (s *CoffeeService) GetLineage(id string) (CoffeeLineage, error) {
+	coffee, err := s.storage.GetByID(id)
+	if err != nil {
+		return CoffeeLineage{}, err
+	}
+	root := coffee.Lineage
+	if root == "" {
+		root = coffee.ID
+	}
+
+	coffees, err := s.storage.GetAll()
+	if err != nil {
+		return CoffeeLineage{}, err
+	}
+	var bags []models.Coffee
+	for _, c := range coffees {
+		if c.ID == root || c.Lineage == root {
+			bags = append(bags, c)
+		}
+	}
+	sort.SliceStable(bags, func(i, j int) bool {
+		return bagDate(bags[i]).Before(bagDate(bags[j]))
+	})
+
+	lineage := CoffeeLineage{Lineage: root, Bags: make([]LineageBag, 0, len(bags))}
+	var firstRated, lastRated *models.Coffee
+	best := 0
+	for i, c := range bags {
+		bag := LineageBag{
+			ID:            c.ID,
+			Name:          c.Name,
+			RoastDate:     c.RoastDate,
+			CreatedAt:     c.CreatedAt,
+			Rating:        c.Rating,
+			TastingTraits: c.TastingTraits,
+		}
+		if i > 0 {
+			previous := bags[i-1]
+			if c.Rating > 0 && previous.Rating > 0 {
+				delta := c.Rating - previous.Rating
+				bag.RatingDelta = &delta
+			}
+			for _, name := range traitNames {
+				before, after := traitValue(previous.TastingTraits, name), traitValue(c.TastingTraits, name)
+				if before > 0 && after > 0 && before != after {
+					if bag.TraitDeltas == nil {
+						bag.TraitDeltas = make(map[string]int)
+					}
+					bag.TraitDeltas[name] = after - before
+				}
+			}
+		}
+		lineage.Bags = append(lineage.Bags, bag)
+
+		if c.Rating > 0 {
+			if firstRated == nil {
+				firstRated = &bags[i]
+			}
+			lastRated = &bags[i]
+			if c.Rating >= best {
+				best = c.Rating
+				lineage.BestBag = c.ID
+			}
+		}
+	}
+	if firstRated != nil && lastRated != firstRated {
+		change := lastRated.Rating - firstRated.Rating
+		lineage.RatingChange = &change
+	}
+	return lineage, nil
+}
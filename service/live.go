@@ -0,0 +1,92 @@
+package service
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// Resources a live change can be about
+const (
+	ResourceCoffee  = "coffee"
+	ResourcePokemon = "pokemon"
+	ResourceBrewer  = "brewer"
+)
+
+// Actions a live change reports
+const (
+	ChangeCreated = "created"
+	ChangeUpdated = "updated"
+	ChangeDeleted = "deleted"
+)
+
+// Change is a coffee, Pokemon catch or brewer that was created, updated or
+// deleted, as pushed to live clients
+type Change struct {
+	Resource string      `json:"resource"`
+	Action   string      `json:"action"`
+	ID       string      `json:"id"`             // coffee ID for Pokemon catches
+	Data     interface{} `json:"data,omitempty"` // the resource as it is now; absent on deletes
+	At       time.Time   `json:"at"`
+}
+
+// LiveHub fans changes out to connected live clients (the /ws endpoint).
+// Unlike the EventBus, changes never reach external sinks: they are too
+// frequent for webhooks and only matter to clients that are watching.
+// Publishing never blocks on a slow client.
+type LiveHub struct {
+	mu          sync.RWMutex
+	subscribers map[chan Change]struct{}
+}
+
+// NewLiveHub creates a new live hub
+func NewLiveHub() *LiveHub {
+	return &LiveHub{subscribers: make(map[chan Change]struct{})}
+}
+
+// Subscribe returns a channel receiving future changes and a function that
+// must be called to unsubscribe
+func (h *LiveHub) Subscribe() (<-chan Change, func()) {
+	ch := make(chan Change, subscriberBuffer)
+
+	h.mu.Lock()
+	h.subscribers[ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subscribers, ch)
+			h.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// Subscribers returns how many clients are connected
+func (h *LiveHub) Subscribers() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.subscribers)
+}
+
+// Publish stamps and delivers a change to every subscriber. A nil hub
+// drops it, so services without live updates need no checks.
+func (h *LiveHub) Publish(resource, action, id string, data interface{}) {
+	if h == nil {
+		return
+	}
+	change := Change{Resource: resource, Action: action, ID: id, Data: data, At: time.Now()}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for ch := range h.subscribers {
+		select {
+		case ch <- change:
+		default:
+			log.Printf("Live: subscriber is falling behind, dropped %s %s change", resource, action)
+		}
+	}
+}
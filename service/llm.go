@@ -1,82 +1,298 @@
 package service
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"go-coffee-log/models"
-	"io"
 	"log"
-	"net/http"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
-// LLMService handles communication with Ollama for Pokemon mapping
+// Retry policy for non-streaming LLM calls, applied to each model of the
+// chain in turn
+const (
+	llmAttempts     = 3
+	llmRetryInitial = 500 * time.Millisecond
+	llmRetryMax     = 4 * time.Second
+)
+
+// errMalformedLLMResponse is returned when the model's answer is not the
+// JSON the prompt asked for
+var errMalformedLLMResponse = errors.New("malformed LLM response")
+
+// LLMService handles communication with an LLM for Pokemon mapping. The
+// provider decides which API it talks to (Ollama, OpenAI, Anthropic).
 type LLMService struct {
-	client  *http.Client
-	baseURL string
-	model   string
-	timeout time.Duration
+	provider LLMProvider
+	model    string // guarded by modelMu; changes on config reload
+	fallback string // guarded by modelMu; tried when model keeps failing
+	vision   string // guarded by modelMu; reads images, "" uses model
+	modelMu  sync.RWMutex
+	usage    *LLMUsageTracker
+	limiter  *LLMLimiter // optional, caps the calls in flight
+
+	available atomic.Bool // set by TestConnection
 }
 
-// NewLLMService creates a new LLM service for Ollama
-func NewLLMService(baseURL string, model string) *LLMService {
+// NewLLMService creates a new LLM service for a model of a provider
+func NewLLMService(provider LLMProvider, model string) *LLMService {
 	return &LLMService{
-		client:  &http.Client{Timeout: 30 * time.Second},
-		baseURL: baseURL,
-		model:   model,
-		timeout: 30 * time.Second,
+		provider: provider,
+		model:    model,
 	}
 }
 
-// MapCoffeeToPokemon maps coffee to Pokemon using LLM
-func (s *LLMService) MapCoffeeToPokemon(coffee models.Coffee, candidates []models.Pokemon) (*models.LLMMappingResponse, error) {
+// Provider returns the name of the provider this service talks to
+func (s *LLMService) Provider() string {
+	return s.provider.Name()
+}
+
+// Available reports whether the last connection test succeeded. It is safe
+// to call on a nil service, which is never available.
+func (s *LLMService) Available() bool {
+	return s != nil && s.available.Load()
+}
+
+// Model returns the name of the model this service talks to
+func (s *LLMService) Model() string {
+	s.modelMu.RLock()
+	defer s.modelMu.RUnlock()
+	return s.model
+}
+
+// SetModel switches to another model for subsequent calls
+func (s *LLMService) SetModel(model string) {
+	s.modelMu.Lock()
+	defer s.modelMu.Unlock()
+	s.model = model
+}
+
+// SetFallbackModel sets a secondary model that non-streaming calls switch
+// to once the primary model has failed all its attempts. "" disables it.
+func (s *LLMService) SetFallbackModel(model string) {
+	s.modelMu.Lock()
+	defer s.modelMu.Unlock()
+	s.fallback = model
+}
+
+// SetVisionModel sets the model that reads images, such as coffee bag
+// labels. "" sends images to the main model, which must then support them.
+func (s *LLMService) SetVisionModel(model string) {
+	s.modelMu.Lock()
+	defer s.modelMu.Unlock()
+	s.vision = model
+}
+
+// visionModel returns the model images are sent to
+func (s *LLMService) visionModel() string {
+	s.modelMu.RLock()
+	defer s.modelMu.RUnlock()
+	if s.vision == "" {
+		return s.model
+	}
+	return s.vision
+}
+
+// modelChain returns the models to try, primary first
+func (s *LLMService) modelChain() []string {
+	s.modelMu.RLock()
+	defer s.modelMu.RUnlock()
+	if s.fallback == "" || s.fallback == s.model {
+		return []string{s.model}
+	}
+	return []string{s.model, s.fallback}
+}
+
+// SetLimiter caps the calls in flight with a limiter that may be shared
+// with other services talking to the same server
+func (s *LLMService) SetLimiter(limiter *LLMLimiter) {
+	s.limiter = limiter
+}
+
+// SetUsageTracker attaches a token usage tracker that records every call
+// and enforces the daily budget
+func (s *LLMService) SetUsageTracker(usage *LLMUsageTracker) {
+	s.usage = usage
+}
+
+// MapCoffeeToPokemon maps coffee to Pokemon using LLM. Timeouts, server
+// errors and malformed JSON are retried, then the fallback model is tried;
+// the returned error means every model failed. The response records which
+// model answered.
+func (s *LLMService) MapCoffeeToPokemon(ctx context.Context, coffee models.Coffee, candidates []models.Pokemon) (*models.LLMMappingResponse, error) {
 	prompt := s.buildPrompt(coffee, candidates)
 	
-	payload := map[string]interface{}{
-		"model":  s.model,
-		"prompt": prompt,
-		"stream": false,
-		"format": "json",
-	}
+	schema := mappingSchema(candidates)
 	
-	jsonPayload, err := json.Marshal(payload)
+	var mapping *models.LLMMappingResponse
+	model, err := s.withRetry(ctx, func(model string) error {
+		response, err := s.generate(ctx, model, prompt, schema)
+		if err != nil {
+			return err
+		}
+		mapping, err = s.parseLLMResponse(response, candidates)
+		return err
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+		return nil, err
 	}
-	
-	req, err := http.NewRequest("POST", s.baseURL+"/api/generate", bytes.NewBuffer(jsonPayload))
+	mapping.Model = model
+	return mapping, nil
+}
+
+// Complete sends a free-form prompt and returns the model's plain-text answer
+func (s *LLMService) Complete(ctx context.Context, prompt string) (string, error) {
+	var response string
+	_, err := s.withRetry(ctx, func(model string) error {
+		var err error
+		response, err = s.generate(ctx, model, prompt, nil)
+		return err
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return "", err
 	}
+	return strings.TrimSpace(response), nil
+}
+
+// withRetry runs call against each model of the chain, retrying transient
+// failures with backoff, and returns the model that succeeded. Errors that
+// another attempt cannot fix (budget, bad request, cancelled ctx) end the
+// whole chain.
+func (s *LLMService) withRetry(ctx context.Context, call func(model string) error) (string, error) {
+	return s.retryModels(ctx, s.modelChain(), call)
+}
+
+// retryModels is withRetry over a given chain of models
+func (s *LLMService) retryModels(ctx context.Context, chain []string, call func(model string) error) (string, error) {
+	var err error
+	for i, model := range chain {
+		if i > 0 {
+			log.Printf("LLM model %s failed, falling back to %s: %v", chain[i-1], model, err)
+		}
+		err = RetryN(ctx, llmAttempts, llmRetryInitial, llmRetryMax, func() error {
+			err := call(model)
+			if err != nil && !retryableLLMError(ctx, err) {
+				return StopRetry(err)
+			}
+			return err
+		}, func(attempt int, err error, next time.Duration) {
+			log.Printf("LLM call to %s failed (attempt %d/%d), retrying in %v: %v", model, attempt, llmAttempts, next, err)
+		})
+		if err == nil {
+			return model, nil
+		}
+		if !retryableLLMError(ctx, err) {
+			return "", err
+		}
+	}
+	return "", err
+}
+
+// retryableLLMError reports whether err may go away on another attempt
+func retryableLLMError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, ErrLLMBudgetExceeded) || errors.Is(err, ErrLLMQueueTimeout) {
+		return false
+	}
+	var status *LLMStatusError
+	if errors.As(err, &status) {
+		return status.Retryable()
+	}
+	return true
+}
+
+// generate sends a single non-streaming prompt to model and returns the
+// raw response text. With a schema the model is asked for JSON following
+// it. Cancelling ctx aborts the call.
+func (s *LLMService) generate(ctx context.Context, model, prompt string, schema map[string]interface{}) (string, error) {
+	return s.complete(ctx, model, prompt, nil, schema)
+}
+
+// complete is generate with an optional image, counted against the budget
+// and the limiter like any other call
+func (s *LLMService) complete(ctx context.Context, model, prompt string, image *LLMImage, schema map[string]interface{}) (string, error) {
+	if s.usage != nil {
+		if err := s.usage.Allow(); err != nil {
+			return "", err
+		}
+	}
+	release, err := s.limiter.Acquire(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer release()
 	
-	req.Header.Set("Content-Type", "application/json")
-	
-	client := &http.Client{Timeout: s.timeout}
-	resp, err := client.Do(req)
+	var completion LLMCompletion
+	if image != nil {
+		completion, err = s.provider.GenerateVision(ctx, model, prompt, *image, schema)
+	} else {
+		completion, err = s.provider.Generate(ctx, model, prompt, schema)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to call LLM: %w", err)
+		return "", err
 	}
-	defer resp.Body.Close()
 	
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("LLM API returned status %d: %s", resp.StatusCode, string(body))
+	if s.usage != nil {
+		s.usage.Record(model, completion.PromptTokens, completion.CompletionTokens)
 	}
 	
-	var response struct {
-		Response string `json:"response"`
+	return completion.Text, nil
+}
+
+// GenerateDescription writes a fresh Pokedex entry for a coffee's Pokemon.
+// The model output is streamed and every fragment is passed to onChunk as
+// it arrives (onChunk may be nil). The full text is returned once the
+// model finishes. Cancelling ctx aborts the generation.
+func (s *LLMService) GenerateDescription(ctx context.Context, coffee models.Coffee, pokemon models.Pokemon, onChunk func(string) error) (string, error) {
+	if s.usage != nil {
+		if err := s.usage.Allow(); err != nil {
+			return "", err
+		}
+	}
+	release, err := s.limiter.Acquire(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	
+	model := s.Model()
+	completion, err := s.provider.Stream(ctx, model, s.buildDescriptionPrompt(coffee, pokemon), onChunk)
+	if err != nil {
+		return "", err
 	}
 	
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return nil, fmt.Errorf("failed to decode LLM response: %w", err)
+	if s.usage != nil {
+		s.usage.Record(model, completion.PromptTokens, completion.CompletionTokens)
 	}
 	
-	// Parse the JSON response from LLM
-	return s.parseLLMResponse(response.Response)
+	return strings.TrimSpace(completion.Text), nil
 }
 
+// buildDescriptionPrompt creates the prompt for a Pokedex entry
+func (s *LLMService) buildDescriptionPrompt(coffee models.Coffee, pokemon models.Pokemon) string {
+	return fmt.Sprintf(`You are writing a Pokedex entry for a coffee that has been caught as a Pokemon.
+
+Pokemon: %s (%s-type)
+Coffee: %s from %s
+Roaster: %s
+Processing: %s, Roast: %s
+Tasting Notes: %s
+Dominant Traits: %s
+
+Write a vivid Pokedex-style description (3-5 sentences) connecting the coffee's flavors to the Pokemon's characteristics. Respond with plain text only.`,
+		pokemon.Name, pokemon.Type, coffee.Name, coffee.Origin, coffee.Roaster,
+		coffee.ProcessingMethod, coffee.RoastLevel,
+		strings.Join(coffee.TastingNotes[:], ", "), s.formatTraits(coffee.TastingTraits))
+}
+
+// MappingPromptVersion versions the mapping prompt of buildPrompt. Bump it
+// whenever the prompt changes, so catches record which prompt made them.
+const MappingPromptVersion = "1"
+
 // buildPrompt creates the prompt for LLM mapping
 func (s *LLMService) buildPrompt(coffee models.Coffee, candidates []models.Pokemon) string {
 	var candidateNames []string
@@ -139,8 +355,46 @@ func (s *LLMService) formatTraits(traits models.TastingTraits) string {
 	return strings.Join(highTraits, ", ")
 }
 
-// parseLLMResponse parses the LLM response
-func (s *LLMService) parseLLMResponse(response string) (*models.LLMMappingResponse, error) {
+// mappingStats are the Pokemon stats a trait mapping may name
+var mappingStats = []string{"HP", "Attack", "Defense", "Speed", "Special"}
+
+// mappingSchema is the JSON schema of a mapping answer. The Pokemon must be
+// one of the candidates. It keeps to the subset OpenAI's strict mode
+// accepts; ranges are checked by parseLLMResponse.
+func mappingSchema(candidates []models.Pokemon) map[string]interface{} {
+	names := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		names = append(names, candidate.Name)
+	}
+	return map[string]interface{}{
+		"type":                 "object",
+		"additionalProperties": false,
+		"required":             []string{"selected_pokemon", "confidence", "description", "trait_mapping"},
+		"properties": map[string]interface{}{
+			"selected_pokemon": map[string]interface{}{"type": "string", "enum": names},
+			"confidence":       map[string]interface{}{"type": "number"},
+			"description":      map[string]interface{}{"type": "string"},
+			"trait_mapping": map[string]interface{}{
+				"type": "array",
+				"items": map[string]interface{}{
+					"type":                 "object",
+					"additionalProperties": false,
+					"required":             []string{"trait", "pokemon_stat", "reasoning"},
+					"properties": map[string]interface{}{
+						"trait":        map[string]interface{}{"type": "string"},
+						"pokemon_stat": map[string]interface{}{"type": "string", "enum": mappingStats},
+						"reasoning":    map[string]interface{}{"type": "string"},
+					},
+				},
+			},
+		},
+	}
+}
+
+// parseLLMResponse parses the LLM response and checks it against the
+// mapping schema, since not every provider enforces it. The Pokemon and
+// stat names are normalized to their canonical spelling.
+func (s *LLMService) parseLLMResponse(response string, candidates []models.Pokemon) (*models.LLMMappingResponse, error) {
 	// Clean up the response to extract JSON
 	response = strings.TrimSpace(response)
 	
@@ -150,59 +404,53 @@ func (s *LLMService) parseLLMResponse(response string) (*models.LLMMappingRespon
 	
 	var mappingResponse models.LLMMappingResponse
 	if err := json.Unmarshal([]byte(response), &mappingResponse); err != nil {
-		// Try to fix common JSON issues
-		log.Printf("Failed to parse LLM response as JSON: %s", response)
-		
-		// Fallback: try to extract Pokemon name using regex-like parsing
-		return s.fallbackParse(response), nil
+		return nil, fmt.Errorf("%w: %v", errMalformedLLMResponse, err)
 	}
 	
-	return &mappingResponse, nil
-}
-
-// fallbackParse provides a basic fallback when JSON parsing fails
-func (s *LLMService) fallbackParse(response string) *models.LLMMappingResponse {
-	// Simple fallback - look for common Pokemon names
-	pokemonNames := []string{"bulbasaur", "charmander", "squirtle", "pikachu", "jigglypuff"}
-	
-	var selectedPokemon string
-	for _, name := range pokemonNames {
-		if strings.Contains(strings.ToLower(response), name) {
-			selectedPokemon = name
+	selected := ""
+	for _, candidate := range candidates {
+		if strings.EqualFold(candidate.Name, strings.TrimSpace(mappingResponse.SelectedPokemon)) {
+			selected = candidate.Name
 			break
 		}
 	}
-	
-	if selectedPokemon == "" {
-		selectedPokemon = "bulbasaur" // Default fallback
+	if selected == "" {
+		return nil, fmt.Errorf("%w: selected_pokemon %q is not a candidate", errMalformedLLMResponse, mappingResponse.SelectedPokemon)
 	}
+	mappingResponse.SelectedPokemon = selected
 	
-	return &models.LLMMappingResponse{
-		SelectedPokemon: selectedPokemon,
-		Confidence:      0.5,
-		Description:     "Fallback mapping due to parsing error",
-		TraitMapping: []models.TraitMapping{
-			{Trait: "general", PokemonStat: "HP", Reasoning: "Basic fallback mapping"},
-		},
+	if mappingResponse.Confidence < 0 || mappingResponse.Confidence > 1 {
+		return nil, fmt.Errorf("%w: confidence %v is outside 0-1", errMalformedLLMResponse, mappingResponse.Confidence)
+	}
+	if strings.TrimSpace(mappingResponse.Description) == "" {
+		return nil, fmt.Errorf("%w: empty description", errMalformedLLMResponse)
+	}
+	for i, mapping := range mappingResponse.TraitMapping {
+		stat := ""
+		for _, name := range mappingStats {
+			if strings.EqualFold(name, mapping.PokemonStat) {
+				stat = name
+			}
+		}
+		if stat == "" {
+			return nil, fmt.Errorf("%w: unknown pokemon_stat %q", errMalformedLLMResponse, mapping.PokemonStat)
+		}
+		mappingResponse.TraitMapping[i].PokemonStat = stat
 	}
+	
+	return &mappingResponse, nil
 }
 
 // TestConnection tests the connection to LLM service
 func (s *LLMService) TestConnection() error {
-	req, err := http.NewRequest("GET", s.baseURL+"/api/tags", nil)
-	if err != nil {
-		return fmt.Errorf("failed to create test request: %w", err)
-	}
-	
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to connect to LLM: %w", err)
-	}
-	defer resp.Body.Close()
-	
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("LLM service returned status %d", resp.StatusCode)
-	}
-	
-	return nil
-}
\ No newline at end of file
+	err := s.testConnection()
+	s.available.Store(err == nil)
+	return err
+}
+
+// testConnection checks that the provider answers
+func (s *LLMService) testConnection() error {
+	ctx, cancel := context.WithTimeout(context.Background(), llmTimeout)
+	defer cancel()
+	return s.provider.Ping(ctx)
+}
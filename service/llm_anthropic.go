@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	// anthropicVersion is the Messages API version requests are shaped for
+	anthropicVersion = "2023-06-01"
+	// anthropicMaxTokens caps an answer; the API requires a limit and
+	// mappings and Pokedex entries stay well below it
+	anthropicMaxTokens = 1024
+	// anthropicJSONSystem asks for JSON output, which the Messages API has
+	// no dedicated mode for
+	anthropicJSONSystem = "Respond with a single valid JSON object and nothing else: no prose and no code fences."
+)
+
+// AnthropicProvider talks to the Anthropic Messages API
+type AnthropicProvider struct {
+	baseURL string
+	apiKey  string
+}
+
+// NewAnthropicProvider creates a provider for the Anthropic API
+func NewAnthropicProvider(baseURL, apiKey string) *AnthropicProvider {
+	if baseURL == "" {
+		baseURL = "https://api.anthropic.com"
+	}
+	return &AnthropicProvider{baseURL: strings.TrimRight(baseURL, "/"), apiKey: apiKey}
+}
+
+// Name returns "anthropic"
+func (p *AnthropicProvider) Name() string {
+	return ProviderAnthropic
+}
+
+// headers authenticates with the API key and pins the API version
+func (p *AnthropicProvider) headers() map[string]string {
+	return map[string]string{
+		"x-api-key":         p.apiKey,
+		"anthropic-version": anthropicVersion,
+	}
+}
+
+// request builds a Messages API request with the prompt as the only user
+// message
+func (p *AnthropicProvider) request(model, prompt string) map[string]interface{} {
+	return map[string]interface{}{
+		"model":      model,
+		"max_tokens": anthropicMaxTokens,
+		"messages": []map[string]string{
+			{"role": "user", "content": prompt},
+		},
+	}
+}
+
+// anthropicUsage is the token usage of a message
+type anthropicUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// Generate calls /v1/messages. JSON output and its schema are asked for in
+// the system prompt, so the answer still needs checking.
+func (p *AnthropicProvider) Generate(ctx context.Context, model, prompt string, schema map[string]interface{}) (LLMCompletion, error) {
+	return p.generate(ctx, p.request(model, prompt), schema)
+}
+
+// GenerateVision calls /v1/messages with a base64 image block before the
+// prompt, where the API reads images best
+func (p *AnthropicProvider) GenerateVision(ctx context.Context, model, prompt string, image LLMImage, schema map[string]interface{}) (LLMCompletion, error) {
+	payload := map[string]interface{}{
+		"model":      model,
+		"max_tokens": anthropicMaxTokens,
+		"messages": []map[string]interface{}{
+			{"role": "user", "content": []map[string]interface{}{
+				{"type": "image", "source": map[string]string{"type": "base64", "media_type": image.MediaType, "data": image.base64()}},
+				{"type": "text", "text": prompt},
+			}},
+		},
+	}
+	return p.generate(ctx, payload, schema)
+}
+
+// generate sends a non-streaming Messages API request
+func (p *AnthropicProvider) generate(ctx context.Context, payload map[string]interface{}, schema map[string]interface{}) (LLMCompletion, error) {
+	if schema != nil {
+		encoded, err := json.Marshal(schema)
+		if err != nil {
+			return LLMCompletion{}, fmt.Errorf("failed to marshal schema: %w", err)
+		}
+		payload["system"] = anthropicJSONSystem + " The object must match this JSON schema: " + string(encoded)
+	}
+
+	resp, err := postLLM(ctx, p.baseURL+"/v1/messages", p.headers(), payload, false)
+	if err != nil {
+		return LLMCompletion{}, err
+	}
+	defer resp.Body.Close()
+
+	var response struct {
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+		Usage anthropicUsage `json:"usage"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return LLMCompletion{}, fmt.Errorf("failed to decode LLM response: %w", err)
+	}
+	var text strings.Builder
+	for _, block := range response.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+	return LLMCompletion{
+		Text:             text.String(),
+		PromptTokens:     response.Usage.InputTokens,
+		CompletionTokens: response.Usage.OutputTokens,
+	}, nil
+}
+
+// Stream calls /v1/messages as server-sent events. Input tokens come with
+// message_start and the output count with the final message_delta.
+func (p *AnthropicProvider) Stream(ctx context.Context, model, prompt string, onChunk func(string) error) (LLMCompletion, error) {
+	payload := p.request(model, prompt)
+	payload["stream"] = true
+
+	resp, err := postLLM(ctx, p.baseURL+"/v1/messages", p.headers(), payload, true)
+	if err != nil {
+		return LLMCompletion{}, err
+	}
+	defer resp.Body.Close()
+
+	var completion LLMCompletion
+	var text strings.Builder
+	err = readSSE(resp.Body, func(data []byte) (bool, error) {
+		var event struct {
+			Type    string `json:"type"`
+			Message struct {
+				Usage anthropicUsage `json:"usage"`
+			} `json:"message"`
+			Delta struct {
+				Type string `json:"type"`
+				Text string `json:"text"`
+			} `json:"delta"`
+			Usage anthropicUsage `json:"usage"`
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal(data, &event); err != nil {
+			return false, fmt.Errorf("failed to decode LLM stream: %w", err)
+		}
+
+		switch event.Type {
+		case "message_start":
+			completion.PromptTokens = event.Message.Usage.InputTokens
+		case "content_block_delta":
+			if event.Delta.Type != "text_delta" || event.Delta.Text == "" {
+				return false, nil
+			}
+			text.WriteString(event.Delta.Text)
+			if onChunk != nil {
+				if err := onChunk(event.Delta.Text); err != nil {
+					return false, err
+				}
+			}
+		case "message_delta":
+			completion.CompletionTokens = event.Usage.OutputTokens
+		case "message_stop":
+			return true, nil
+		case "error":
+			return false, fmt.Errorf("LLM stream failed: %s", event.Error.Message)
+		}
+		return false, nil
+	})
+	if err != nil {
+		return LLMCompletion{}, err
+	}
+
+	completion.Text = text.String()
+	return completion, nil
+}
+
+// Ping lists the models the key may use
+func (p *AnthropicProvider) Ping(ctx context.Context) error {
+	return pingLLM(ctx, p.baseURL+"/v1/models", p.headers())
+}
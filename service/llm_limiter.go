@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// ErrLLMQueueTimeout is returned when a call waited too long for a free
+// LLM slot
+var ErrLLMQueueTimeout = errors.New("timed out waiting for a free LLM slot")
+
+// LLMQueueStats is a snapshot of the LLM limiter
+type LLMQueueStats struct {
+	MaxConcurrent int   `json:"max_concurrent"`
+	InFlight      int   `json:"in_flight"`
+	Queued        int   `json:"queued"`
+	TimedOut      int64 `json:"timed_out"` // calls given up on since startup
+}
+
+// LLMLimiter caps the number of LLM calls in flight. Calls beyond the cap
+// queue until a slot frees up or their queue timeout passes. A single
+// limiter is shared by every LLMService so that consensus voters running
+// on the same GPU count against the same cap.
+type LLMLimiter struct {
+	slots        chan struct{}
+	queueTimeout time.Duration
+	queued       atomic.Int64
+	timedOut     atomic.Int64
+}
+
+// NewLLMLimiter creates a limiter for maxConcurrent calls. A queueTimeout
+// of 0 lets calls wait as long as their context allows.
+func NewLLMLimiter(maxConcurrent int, queueTimeout time.Duration) *LLMLimiter {
+	return &LLMLimiter{
+		slots:        make(chan struct{}, maxConcurrent),
+		queueTimeout: queueTimeout,
+	}
+}
+
+// Acquire waits for a free slot and returns the function that frees it.
+// A nil limiter never waits.
+func (l *LLMLimiter) Acquire(ctx context.Context) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case l.slots <- struct{}{}:
+		return l.release, nil
+	default:
+	}
+
+	l.queued.Add(1)
+	defer l.queued.Add(-1)
+
+	var timeout <-chan time.Time
+	if l.queueTimeout > 0 {
+		timer := time.NewTimer(l.queueTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case l.slots <- struct{}{}:
+		return l.release, nil
+	case <-timeout:
+		l.timedOut.Add(1)
+		return nil, ErrLLMQueueTimeout
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// release frees the slot taken by Acquire
+func (l *LLMLimiter) release() {
+	<-l.slots
+}
+
+// Snapshot reports the cap and the calls running and waiting
+func (l *LLMLimiter) Snapshot() LLMQueueStats {
+	return LLMQueueStats{
+		MaxConcurrent: cap(l.slots),
+		InFlight:      len(l.slots),
+		Queued:        int(l.queued.Load()),
+		TimedOut:      l.timedOut.Load(),
+	}
+}
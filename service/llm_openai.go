@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// OpenAIProvider talks to the OpenAI chat completions API or any server
+// compatible with it, such as vLLM, LM Studio or OpenRouter
+type OpenAIProvider struct {
+	baseURL string
+	apiKey  string
+}
+
+// NewOpenAIProvider creates a provider for an OpenAI-compatible API.
+// baseURL includes the version, e.g. "https://api.openai.com/v1".
+func NewOpenAIProvider(baseURL, apiKey string) *OpenAIProvider {
+	if baseURL == "" {
+		baseURL = "https://api.openai.com/v1"
+	}
+	return &OpenAIProvider{baseURL: strings.TrimRight(baseURL, "/"), apiKey: apiKey}
+}
+
+// Name returns "openai"
+func (p *OpenAIProvider) Name() string {
+	return ProviderOpenAI
+}
+
+// headers authenticates with a bearer token; local servers may need none
+func (p *OpenAIProvider) headers() map[string]string {
+	if p.apiKey == "" {
+		return nil
+	}
+	return map[string]string{"Authorization": "Bearer " + p.apiKey}
+}
+
+// request builds a chat completion request with the prompt as the only
+// user message
+func (p *OpenAIProvider) request(model, prompt string) map[string]interface{} {
+	return map[string]interface{}{
+		"model": model,
+		"messages": []map[string]string{
+			{"role": "user", "content": prompt},
+		},
+	}
+}
+
+// openAIUsage is the token usage of a chat completion
+type openAIUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+// Generate calls /chat/completions. A schema is sent as a strict
+// json_schema response format, so every property must be required and
+// objects must forbid additional properties.
+func (p *OpenAIProvider) Generate(ctx context.Context, model, prompt string, schema map[string]interface{}) (LLMCompletion, error) {
+	return p.generate(ctx, p.request(model, prompt), schema)
+}
+
+// GenerateVision calls /chat/completions with the image as a data URL
+// next to the prompt in the user message
+func (p *OpenAIProvider) GenerateVision(ctx context.Context, model, prompt string, image LLMImage, schema map[string]interface{}) (LLMCompletion, error) {
+	payload := map[string]interface{}{
+		"model": model,
+		"messages": []map[string]interface{}{
+			{"role": "user", "content": []map[string]interface{}{
+				{"type": "text", "text": prompt},
+				{"type": "image_url", "image_url": map[string]string{"url": "data:" + image.MediaType + ";base64," + image.base64()}},
+			}},
+		},
+	}
+	return p.generate(ctx, payload, schema)
+}
+
+// generate sends a non-streaming chat completion request
+func (p *OpenAIProvider) generate(ctx context.Context, payload map[string]interface{}, schema map[string]interface{}) (LLMCompletion, error) {
+	if schema != nil {
+		payload["response_format"] = map[string]interface{}{
+			"type": "json_schema",
+			"json_schema": map[string]interface{}{
+				"name":   "response",
+				"strict": true,
+				"schema": schema,
+			},
+		}
+	}
+
+	resp, err := postLLM(ctx, p.baseURL+"/chat/completions", p.headers(), payload, false)
+	if err != nil {
+		return LLMCompletion{}, err
+	}
+	defer resp.Body.Close()
+
+	var response struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+		Usage openAIUsage `json:"usage"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return LLMCompletion{}, fmt.Errorf("failed to decode LLM response: %w", err)
+	}
+	if len(response.Choices) == 0 {
+		return LLMCompletion{}, fmt.Errorf("LLM response has no choices")
+	}
+	return LLMCompletion{
+		Text:             response.Choices[0].Message.Content,
+		PromptTokens:     response.Usage.PromptTokens,
+		CompletionTokens: response.Usage.CompletionTokens,
+	}, nil
+}
+
+// Stream calls /chat/completions as server-sent events, asking for the
+// usage in the final chunk
+func (p *OpenAIProvider) Stream(ctx context.Context, model, prompt string, onChunk func(string) error) (LLMCompletion, error) {
+	payload := p.request(model, prompt)
+	payload["stream"] = true
+	payload["stream_options"] = map[string]bool{"include_usage": true}
+
+	resp, err := postLLM(ctx, p.baseURL+"/chat/completions", p.headers(), payload, true)
+	if err != nil {
+		return LLMCompletion{}, err
+	}
+	defer resp.Body.Close()
+
+	var completion LLMCompletion
+	var text strings.Builder
+	err = readSSE(resp.Body, func(data []byte) (bool, error) {
+		if string(data) == "[DONE]" {
+			return true, nil
+		}
+		var chunk struct {
+			Choices []struct {
+				Delta struct {
+					Content string `json:"content"`
+				} `json:"delta"`
+			} `json:"choices"`
+			Usage *openAIUsage `json:"usage"`
+		}
+		if err := json.Unmarshal(data, &chunk); err != nil {
+			return false, fmt.Errorf("failed to decode LLM stream: %w", err)
+		}
+		if chunk.Usage != nil {
+			completion.PromptTokens = chunk.Usage.PromptTokens
+			completion.CompletionTokens = chunk.Usage.CompletionTokens
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content == "" {
+				continue
+			}
+			text.WriteString(choice.Delta.Content)
+			if onChunk != nil {
+				if err := onChunk(choice.Delta.Content); err != nil {
+					return false, err
+				}
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		return LLMCompletion{}, err
+	}
+
+	completion.Text = text.String()
+	return completion, nil
+}
+
+// Ping lists the models the key may use
+func (p *OpenAIProvider) Ping(ctx context.Context) error {
+	return pingLLM(ctx, p.baseURL+"/models", p.headers())
+}
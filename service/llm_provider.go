@@ -0,0 +1,285 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// llmTimeout bounds a non-streaming LLM call, and how long a streaming one
+// may take to start answering
+const llmTimeout = 30 * time.Second
+
+// LLM providers selectable with -llm-provider
+const (
+	ProviderOllama    = "ollama"
+	ProviderOpenAI    = "openai"
+	ProviderAnthropic = "anthropic"
+)
+
+// LLMCompletion is a model's answer to one prompt and the tokens it took
+type LLMCompletion struct {
+	Text             string
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// LLMImage is an image sent along with a prompt
+type LLMImage struct {
+	MediaType string // e.g. "image/jpeg"
+	Data      []byte
+}
+
+// base64 returns the image data base64-encoded, as every API expects it
+func (i LLMImage) base64() string {
+	return base64.StdEncoding.EncodeToString(i.Data)
+}
+
+// LLMProvider sends prompts to one kind of LLM API. LLMService builds the
+// prompts, tracks usage and parses answers; a provider only shapes the
+// requests and reads the responses of its API.
+type LLMProvider interface {
+	Name() string
+	// Generate sends a prompt and waits for the whole answer. With a
+	// schema the model is asked for a JSON object following that JSON
+	// schema, as strictly as the API allows; nil asks for plain text.
+	Generate(ctx context.Context, model, prompt string, schema map[string]interface{}) (LLMCompletion, error)
+	// GenerateVision is Generate with an image attached to the prompt. The
+	// model must be able to read images.
+	GenerateVision(ctx context.Context, model, prompt string, image LLMImage, schema map[string]interface{}) (LLMCompletion, error)
+	// Stream sends a prompt and passes every fragment of the answer to
+	// onChunk as it arrives, then returns the whole answer
+	Stream(ctx context.Context, model, prompt string, onChunk func(string) error) (LLMCompletion, error)
+	// Ping checks that the API answers and accepts the credentials
+	Ping(ctx context.Context) error
+}
+
+// NewLLMProvider creates the provider named by -llm-provider. An empty
+// baseURL uses the provider's public API; apiKey is ignored by Ollama.
+func NewLLMProvider(name, baseURL, apiKey string) (LLMProvider, error) {
+	switch name {
+	case ProviderOllama, "":
+		return NewOllamaProvider(baseURL), nil
+	case ProviderOpenAI:
+		return NewOpenAIProvider(baseURL, apiKey), nil
+	case ProviderAnthropic:
+		return NewAnthropicProvider(baseURL, apiKey), nil
+	}
+	return nil, fmt.Errorf("unknown LLM provider %q: use %s, %s or %s", name, ProviderOllama, ProviderOpenAI, ProviderAnthropic)
+}
+
+// LLMStatusError is returned when the LLM API answers with a non-200 status
+type LLMStatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *LLMStatusError) Error() string {
+	return fmt.Sprintf("LLM API returned status %d: %s", e.StatusCode, e.Body)
+}
+
+// Retryable reports whether the same request may succeed later: rate
+// limits and server errors are, bad requests and auth failures are not
+func (e *LLMStatusError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// postLLM sends a JSON request and returns the response, or an error
+// carrying the body of a non-200 answer. Streaming requests get no overall
+// timeout, only one for the response to start.
+func postLLM(ctx context.Context, url string, headers map[string]string, payload interface{}, stream bool) (*http.Response, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	client := &http.Client{Timeout: llmTimeout}
+	if stream {
+		client = &http.Client{Transport: &http.Transport{ResponseHeaderTimeout: llmTimeout}}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call LLM: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, &LLMStatusError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+	return resp, nil
+}
+
+// pingLLM checks that a GET to url answers 200
+func pingLLM(ctx context.Context, url string, headers map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create test request: %w", err)
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	client := &http.Client{Timeout: llmTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to connect to LLM: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("LLM service returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// readSSE calls onData with the data of every server-sent event in body
+// until it returns done or the stream ends
+func readSSE(body io.Reader, onData func(data []byte) (done bool, err error)) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		done, err := onData([]byte(strings.TrimSpace(strings.TrimPrefix(line, "data:"))))
+		if err != nil || done {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read LLM stream: %w", err)
+	}
+	return nil
+}
+
+// OllamaProvider talks to a local Ollama server
+type OllamaProvider struct {
+	baseURL string
+}
+
+// NewOllamaProvider creates a provider for the Ollama server at baseURL
+func NewOllamaProvider(baseURL string) *OllamaProvider {
+	if baseURL == "" {
+		baseURL = "http://localhost:11434"
+	}
+	return &OllamaProvider{baseURL: strings.TrimRight(baseURL, "/")}
+}
+
+// Name returns "ollama"
+func (p *OllamaProvider) Name() string {
+	return ProviderOllama
+}
+
+// Generate calls /api/generate without streaming. A schema is passed as
+// the format, which makes Ollama constrain the output to it.
+func (p *OllamaProvider) Generate(ctx context.Context, model, prompt string, schema map[string]interface{}) (LLMCompletion, error) {
+	return p.generate(ctx, model, prompt, nil, schema)
+}
+
+// GenerateVision calls /api/generate with the image in images
+func (p *OllamaProvider) GenerateVision(ctx context.Context, model, prompt string, image LLMImage, schema map[string]interface{}) (LLMCompletion, error) {
+	return p.generate(ctx, model, prompt, &image, schema)
+}
+
+// generate sends a non-streaming /api/generate request
+func (p *OllamaProvider) generate(ctx context.Context, model, prompt string, image *LLMImage, schema map[string]interface{}) (LLMCompletion, error) {
+	payload := map[string]interface{}{
+		"model":  model,
+		"prompt": prompt,
+		"stream": false,
+	}
+	if image != nil {
+		payload["images"] = []string{image.base64()}
+	}
+	if schema != nil {
+		payload["format"] = schema
+	}
+
+	resp, err := postLLM(ctx, p.baseURL+"/api/generate", nil, payload, false)
+	if err != nil {
+		return LLMCompletion{}, err
+	}
+	defer resp.Body.Close()
+
+	var response struct {
+		Response        string `json:"response"`
+		PromptEvalCount int    `json:"prompt_eval_count"`
+		EvalCount       int    `json:"eval_count"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return LLMCompletion{}, fmt.Errorf("failed to decode LLM response: %w", err)
+	}
+	return LLMCompletion{Text: response.Response, PromptTokens: response.PromptEvalCount, CompletionTokens: response.EvalCount}, nil
+}
+
+// Stream calls /api/generate, which streams newline-delimited JSON objects
+func (p *OllamaProvider) Stream(ctx context.Context, model, prompt string, onChunk func(string) error) (LLMCompletion, error) {
+	payload := map[string]interface{}{
+		"model":  model,
+		"prompt": prompt,
+		"stream": true,
+	}
+	resp, err := postLLM(ctx, p.baseURL+"/api/generate", nil, payload, true)
+	if err != nil {
+		return LLMCompletion{}, err
+	}
+	defer resp.Body.Close()
+
+	var completion LLMCompletion
+	var text strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var chunk struct {
+			Response        string `json:"response"`
+			Done            bool   `json:"done"`
+			PromptEvalCount int    `json:"prompt_eval_count"`
+			EvalCount       int    `json:"eval_count"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &chunk); err != nil {
+			return LLMCompletion{}, fmt.Errorf("failed to decode LLM stream: %w", err)
+		}
+
+		if chunk.Response != "" {
+			text.WriteString(chunk.Response)
+			if onChunk != nil {
+				if err := onChunk(chunk.Response); err != nil {
+					return LLMCompletion{}, err
+				}
+			}
+		}
+
+		if chunk.Done {
+			completion.PromptTokens = chunk.PromptEvalCount
+			completion.CompletionTokens = chunk.EvalCount
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return LLMCompletion{}, fmt.Errorf("failed to read LLM stream: %w", err)
+	}
+
+	completion.Text = text.String()
+	return completion, nil
+}
+
+// Ping lists the local models
+func (p *OllamaProvider) Ping(ctx context.Context) error {
+	return pingLLM(ctx, p.baseURL+"/api/tags", nil)
+}
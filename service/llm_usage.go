@@ -0,0 +1,149 @@
+package service
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrLLMBudgetExceeded is returned when the daily token budget is used up
+var ErrLLMBudgetExceeded = errors.New("daily LLM token budget exceeded")
+
+// ModelUsage represents token counts for a single model
+type ModelUsage struct {
+	Calls          int `json:"calls"`
+	PromptTokens   int `json:"prompt_tokens"`
+	ResponseTokens int `json:"response_tokens"`
+}
+
+// LLMUsage represents a snapshot of LLM token usage
+type LLMUsage struct {
+	Date            string                `json:"date"`
+	DailyBudget     int                   `json:"daily_budget"` // 0 means unlimited
+	TokensUsed      int                   `json:"tokens_used"`
+	TokensRemaining int                   `json:"tokens_remaining,omitempty"`
+	BudgetExceeded  bool                  `json:"budget_exceeded"`
+	Calls           int                   `json:"calls"`
+	UnreportedCalls int                   `json:"unreported_calls"` // provider sent no token counts
+	RejectedCalls   int                   `json:"rejected_calls"`   // skipped because of the budget
+	EstimatedCost   float64               `json:"estimated_cost"`
+	Models          map[string]ModelUsage `json:"models"`
+	LifetimeTokens  int                   `json:"lifetime_tokens"`
+}
+
+// LLMUsageTracker counts tokens per call and enforces a daily budget.
+// A single tracker is shared by every LLMService so that consensus voters
+// draw from the same budget.
+type LLMUsageTracker struct {
+	mu             sync.Mutex
+	dailyBudget    int
+	costPer1K      float64
+	date           string
+	calls          int
+	unreported     int
+	rejected       int
+	promptTokens   int
+	responseTokens int
+	models         map[string]ModelUsage
+	lifetimeTokens int
+}
+
+// NewLLMUsageTracker creates a usage tracker. A dailyBudget of 0 disables
+// enforcement; costPer1K is used only to estimate spend.
+func NewLLMUsageTracker(dailyBudget int, costPer1K float64) *LLMUsageTracker {
+	return &LLMUsageTracker{
+		dailyBudget: dailyBudget,
+		costPer1K:   costPer1K,
+		date:        time.Now().Format("2006-01-02"),
+		models:      make(map[string]ModelUsage),
+	}
+}
+
+// SetDailyBudget changes the daily token budget; 0 disables enforcement
+func (t *LLMUsageTracker) SetDailyBudget(dailyBudget int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.dailyBudget = dailyBudget
+}
+
+// rollover resets the daily counters when the date changes. Caller holds mu.
+func (t *LLMUsageTracker) rollover() {
+	today := time.Now().Format("2006-01-02")
+	if today == t.date {
+		return
+	}
+	t.date = today
+	t.calls = 0
+	t.unreported = 0
+	t.rejected = 0
+	t.promptTokens = 0
+	t.responseTokens = 0
+	t.models = make(map[string]ModelUsage)
+}
+
+// Allow checks whether another call fits in today's budget
+func (t *LLMUsageTracker) Allow() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rollover()
+
+	if t.dailyBudget > 0 && t.promptTokens+t.responseTokens >= t.dailyBudget {
+		t.rejected++
+		return ErrLLMBudgetExceeded
+	}
+	return nil
+}
+
+// Record adds the token counts reported for a completed call
+func (t *LLMUsageTracker) Record(model string, promptTokens, responseTokens int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rollover()
+
+	t.calls++
+	if promptTokens == 0 && responseTokens == 0 {
+		t.unreported++
+	}
+	t.promptTokens += promptTokens
+	t.responseTokens += responseTokens
+	t.lifetimeTokens += promptTokens + responseTokens
+
+	usage := t.models[model]
+	usage.Calls++
+	usage.PromptTokens += promptTokens
+	usage.ResponseTokens += responseTokens
+	t.models[model] = usage
+}
+
+// Snapshot returns the current usage figures
+func (t *LLMUsageTracker) Snapshot() LLMUsage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rollover()
+
+	used := t.promptTokens + t.responseTokens
+	usage := LLMUsage{
+		Date:            t.date,
+		DailyBudget:     t.dailyBudget,
+		TokensUsed:      used,
+		Calls:           t.calls,
+		UnreportedCalls: t.unreported,
+		RejectedCalls:   t.rejected,
+		EstimatedCost:   float64(used) / 1000.0 * t.costPer1K,
+		Models:          make(map[string]ModelUsage, len(t.models)),
+		LifetimeTokens:  t.lifetimeTokens,
+	}
+
+	if t.dailyBudget > 0 {
+		usage.BudgetExceeded = used >= t.dailyBudget
+		if !usage.BudgetExceeded {
+			usage.TokensRemaining = t.dailyBudget - used
+		}
+	}
+
+	for model, m := range t.models {
+		usage.Models[model] = m
+	}
+
+	return usage
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"go-coffee-log/models"
+	"strings"
+)
+
+// MappingExplanation shows how the rule-based mapper types a coffee, for
+// GET /pokemon/{coffee_id}/explain
+type MappingExplanation struct {
+	CoffeeID  string                `json:"coffee_id"`
+	Name      string                `json:"name"`
+	Rules     string                `json:"rules"`     // the rule pack in use, as name@version
+	Overrides bool                  `json:"overrides"` // whether mapper_rules from the config file apply on top
+	Primary   string                `json:"primary"`
+	Secondary string                `json:"secondary"`
+	Scores    map[string]float64    `json:"scores"` // type -> score, as CalculatePokemonTypes returns them
+	Types     []TypeExplanation     `json:"types"`  // best match first
+	Catch     *models.CoffeePokemon `json:"catch,omitempty"`
+}
+
+// TypeExplanation breaks a type's score down. Points from the traits and
+// keywords are multiplied by the bonuses, then divided by MaxPoints to give
+// Score, capped at 1.
+type TypeExplanation struct {
+	Type            string              `json:"type"`
+	Score           float64             `json:"score"`
+	Threshold       float64             `json:"threshold"` // the score needed to be the primary type; 80% of it for the secondary
+	Traits          []TraitContribution `json:"traits"`
+	KeywordMatches  []KeywordMatch      `json:"keyword_matches,omitempty"`
+	KeywordPoints   float64             `json:"keyword_points"`
+	ProcessingBonus float64             `json:"processing_bonus,omitempty"`  // multiplier for the coffee's processing method
+	RoastLevelBonus float64             `json:"roast_level_bonus,omitempty"` // multiplier for the coffee's roast level
+	Points          float64             `json:"points"`
+	MaxPoints       float64             `json:"max_points"`
+}
+
+// TraitContribution is what one weighted trait added to a type's score. A
+// trait counts once it reaches Min; values above Max count as Max.
+type TraitContribution struct {
+	Trait     string  `json:"trait"`
+	Role      string  `json:"role"` // "primary" or "secondary" trait of the rule
+	Value     int     `json:"value"`
+	Weight    float64 `json:"weight"`
+	Min       int     `json:"min"`
+	Max       int     `json:"max"`
+	Counted   bool    `json:"counted"`
+	Points    float64 `json:"points"`
+	MaxPoints float64 `json:"max_points"`
+}
+
+// KeywordMatch is a tasting note containing one of a rule's keywords
+type KeywordMatch struct {
+	Note    string `json:"note"`
+	Keyword string `json:"keyword"`
+}
+
+// Explain scores a coffee against every type rule like
+// CalculatePokemonTypes and returns each rule's breakdown
+func (pm *PokemonMapper) Explain(coffee models.Coffee) MappingExplanation {
+	typeRules := pm.rules()
+	pack, overrides := pm.rulePack()
+	explanation := MappingExplanation{
+		CoffeeID:  coffee.ID,
+		Name:      coffee.Name,
+		Rules:     pack,
+		Overrides: overrides,
+		Scores:    make(map[string]float64, len(typeRules)),
+		Types:     make([]TypeExplanation, 0, len(typeRules)),
+	}
+
+	byType := make(map[string]TypeExplanation, len(typeRules))
+	for typeName, rule := range typeRules {
+		typeExplanation := pm.explainTypeScore(coffee, rule)
+		typeExplanation.Type = typeName
+		byType[typeName] = typeExplanation
+		explanation.Scores[typeName] = typeExplanation.Score
+	}
+
+	ranked := rankTypes(explanation.Scores)
+	explanation.Primary, explanation.Secondary = pickTypes(ranked, typeRules)
+	for _, typeScore := range ranked {
+		explanation.Types = append(explanation.Types, byType[typeScore.Type])
+	}
+	return explanation
+}
+
+// ExplainMapping explains how the mapper types a coffee now, along with its
+// catch in a dex, if any. The catch may predate the current rules; its
+// provenance names the rule pack it was made with.
+func (s *PokemonService) ExplainMapping(coffeeID, dexID string) (MappingExplanation, error) {
+	coffee, err := s.coffeeService.GetCoffee(coffeeID)
+	if err != nil {
+		return MappingExplanation{}, err
+	}
+
+	explanation := s.mapper.Explain(coffee)
+	catch, err := s.GetCoffeePokemon(coffeeID, dexID)
+	switch {
+	case err == nil:
+		explanation.Catch = catch
+	case !strings.Contains(err.Error(), "Pokemon mapping not found"):
+		return MappingExplanation{}, err
+	}
+	return explanation, nil
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+
+	"go-coffee-log/models"
+)
+
+// MapperFixture is one golden case of the rule-based mapper: a coffee's
+// mapper inputs and the types and scores the rules gave it
+type MapperFixture struct {
+	Coffee    models.Coffee      `json:"coffee"`
+	Primary   string             `json:"primary"`
+	Secondary string             `json:"secondary"`
+	Scores    map[string]float64 `json:"scores"`
+}
+
+// MapperFixtureFile is the golden file written by -mapper-fixtures
+type MapperFixtureFile struct {
+	Rules    string          `json:"rules"` // the rules recorded with, as name@version
+	Fixtures []MapperFixture `json:"fixtures"`
+}
+
+// MapperFixtureDiff is a fixture whose mapping changed under the current
+// rules. Kind is "assignment" when the primary or secondary type moved and
+// "score" when only type scores did.
+type MapperFixtureDiff struct {
+	CoffeeID string `json:"coffee_id"`
+	Name     string `json:"name"`
+	Kind     string `json:"kind"`
+	Detail   string `json:"detail"`
+}
+
+// mapperFixtureTolerance is how far a recorded type score may drift before
+// it counts as changed
+const mapperFixtureTolerance = 0.0001
+
+// mapperInputs keeps the coffee fields the mapper reads, so fixtures stay
+// small and unaffected by unrelated edits to the coffee
+func mapperInputs(coffee models.Coffee) models.Coffee {
+	return models.Coffee{
+		ID:               coffee.ID,
+		Name:             coffee.Name,
+		RoastLevel:       coffee.RoastLevel,
+		ProcessingMethod: coffee.ProcessingMethod,
+		TastingNotes:     coffee.TastingNotes,
+		TastingTraits:    coffee.TastingTraits,
+	}
+}
+
+// GenerateMapperFixtures records what mapper makes of each coffee. Fixtures
+// are sorted by coffee ID so regenerated files diff cleanly.
+func GenerateMapperFixtures(mapper *PokemonMapper, coffees []models.Coffee) MapperFixtureFile {
+	pack, _ := mapper.rulePack()
+	file := MapperFixtureFile{Rules: pack, Fixtures: make([]MapperFixture, 0, len(coffees))}
+	for _, coffee := range coffees {
+		coffee = mapperInputs(coffee)
+		primary, secondary, scores := mapper.CalculatePokemonTypes(coffee)
+		rounded := make(map[string]float64, len(scores))
+		for typeName, score := range scores {
+			rounded[typeName] = math.Round(score*10000) / 10000
+		}
+		file.Fixtures = append(file.Fixtures, MapperFixture{
+			Coffee:    coffee,
+			Primary:   primary,
+			Secondary: secondary,
+			Scores:    rounded,
+		})
+	}
+	sort.Slice(file.Fixtures, func(i, j int) bool {
+		return file.Fixtures[i].Coffee.ID < file.Fixtures[j].Coffee.ID
+	})
+	return file
+}
+
+// VerifyMapperFixtures maps every fixture's coffee again and reports the
+// ones whose types or scores no longer match the file
+func VerifyMapperFixtures(mapper *PokemonMapper, file MapperFixtureFile) []MapperFixtureDiff {
+	var diffs []MapperFixtureDiff
+	for _, fixture := range file.Fixtures {
+		primary, secondary, scores := mapper.CalculatePokemonTypes(fixture.Coffee)
+		diff := MapperFixtureDiff{CoffeeID: fixture.Coffee.ID, Name: fixture.Coffee.Name}
+
+		if primary != fixture.Primary || secondary != fixture.Secondary {
+			diff.Kind = "assignment"
+			diff.Detail = fmt.Sprintf("types %s/%s -> %s/%s", fixture.Primary, fixture.Secondary, primary, secondary)
+			diffs = append(diffs, diff)
+			continue
+		}
+
+		var changed []string
+		for typeName := range unionKeys(fixture.Scores, scores) {
+			if math.Abs(scores[typeName]-fixture.Scores[typeName]) > mapperFixtureTolerance {
+				changed = append(changed, fmt.Sprintf("%s %.4f -> %.4f", typeName, fixture.Scores[typeName], scores[typeName]))
+			}
+		}
+		if len(changed) > 0 {
+			sort.Strings(changed)
+			diff.Kind = "score"
+			diff.Detail = fmt.Sprint(changed)
+			diffs = append(diffs, diff)
+		}
+	}
+	return diffs
+}
+
+// unionKeys returns the keys found in either map
+func unionKeys(a, b map[string]float64) map[string]bool {
+	keys := make(map[string]bool, len(a)+len(b))
+	for k := range a {
+		keys[k] = true
+	}
+	for k := range b {
+		keys[k] = true
+	}
+	return keys
+}
+
+// ReadMapperFixtures loads a golden file
+func ReadMapperFixtures(path string) (MapperFixtureFile, error) {
+	var file MapperFixtureFile
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return file, fmt.Errorf("failed to read mapper fixtures: %w", err)
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return file, fmt.Errorf("invalid mapper fixtures %s: %w", path, err)
+	}
+	return file, nil
+}
+
+// WriteMapperFixtures saves a golden file as indented JSON
+func WriteMapperFixtures(path string, file MapperFixtureFile) error {
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode mapper fixtures: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write mapper fixtures: %w", err)
+	}
+	return nil
+}
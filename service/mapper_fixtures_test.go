@@ -0,0 +1,82 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"go-coffee-log/models"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata from the current rules")
+
+// TestMapperFixturesGolden renders every golden file's coffees with the
+// built-in rules and compares the result with the file byte for byte
+func TestMapperFixturesGolden(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "mapper", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no golden files in testdata/mapper")
+	}
+
+	for _, path := range paths {
+		t.Run(strings.TrimSuffix(filepath.Base(path), ".json"), func(t *testing.T) {
+			golden, err := ReadMapperFixtures(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			coffees := make([]models.Coffee, 0, len(golden.Fixtures))
+			for _, fixture := range golden.Fixtures {
+				coffees = append(coffees, fixture.Coffee)
+			}
+
+			rendered := GenerateMapperFixtures(NewPokemonMapper(), coffees)
+			if *updateGolden {
+				if err := WriteMapperFixtures(path, rendered); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			for _, diff := range VerifyMapperFixtures(NewPokemonMapper(), golden) {
+				t.Errorf("%s (%s): %s change: %s", diff.Name, diff.CoffeeID, diff.Kind, diff.Detail)
+			}
+			want, err := json.MarshalIndent(golden, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := json.MarshalIndent(rendered, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("rendered fixtures differ from %s; run go test ./service -run TestMapperFixturesGolden -update if the change is intended\n%s", path, firstDifference(string(want), string(got)))
+			}
+		})
+	}
+}
+
+// firstDifference describes the first line where two renderings part ways
+func firstDifference(want, got string) string {
+	wantLines := strings.Split(want, "\n")
+	gotLines := strings.Split(got, "\n")
+	for i := 0; i < len(wantLines) || i < len(gotLines); i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w != g {
+			return "line " + strconv.Itoa(i+1) + ":\n- " + w + "\n+ " + g
+		}
+	}
+	return ""
+}
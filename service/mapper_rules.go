@@ -0,0 +1,150 @@
+package service
+
+import (
+	"fmt"
+	"go-coffee-log/models"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MapperRules is the type-mapping rule set as GET /admin/mapper/rules
+// shows it
+type MapperRules struct {
+	Pack      string                         `json:"pack"`      // the base rules, as name@version
+	Overrides bool                           `json:"overrides"` // whether mapper_rules from the config file apply on top
+	Rules     map[string]models.RulePackRule `json:"rules"`     // the rules in use, overrides included
+}
+
+// RetypedCoffee is a coffee the proposed rules would give other types
+type RetypedCoffee struct {
+	CoffeeID     string `json:"coffee_id"`
+	Name         string `json:"name"`
+	Primary      string `json:"primary"`
+	Secondary    string `json:"secondary"`
+	NewPrimary   string `json:"new_primary"`
+	NewSecondary string `json:"new_secondary"`
+}
+
+// MapperRulesPreview is what a rule change would do to existing coffees.
+// Only the rule-based types are compared; catches keep their Pokemon until
+// they are remapped.
+type MapperRulesPreview struct {
+	Pack       string            `json:"pack"` // the pack the rules would be stored as
+	Coffees    int               `json:"coffees"`
+	Retyped    []RetypedCoffee   `json:"retyped"`
+	TypeCounts map[string][2]int `json:"type_counts"` // primary type -> coffees before and after
+}
+
+// MapperRules returns the rules the mapper uses
+func (s *RulePackService) MapperRules() MapperRules {
+	pack, overrides := s.pokemonService.mapper.rulePack()
+	view := MapperRules{Pack: pack, Overrides: overrides, Rules: make(map[string]models.RulePackRule)}
+	for typeName, rule := range s.pokemonService.MapperRules() {
+		view.Rules[typeName] = toRulePackRule(rule)
+	}
+	return view
+}
+
+// editedPack returns the current base rules with rules replacing the
+// rules of their types, as the next version of the "edited" pack
+func (s *RulePackService) editedPack(rules map[string]models.RulePackRule, author string) (models.RulePack, error) {
+	if len(rules) == 0 {
+		return models.RulePack{}, fmt.Errorf("invalid rule pack: no rules given")
+	}
+
+	version := 1
+	if previous, err := s.storage.GetPack(models.EditedRulePack); err == nil {
+		if n, err := strconv.Atoi(previous.Version); err == nil {
+			version = n + 1
+		}
+	}
+	label, base := s.pokemonService.mapper.baseRules()
+	pack := models.RulePack{
+		Name:        models.EditedRulePack,
+		Version:     strconv.Itoa(version),
+		Author:      author,
+		Description: "Edited through the API, based on " + label,
+		Rules:       make(map[string]models.RulePackRule, len(base)),
+	}
+	for typeName, rule := range base {
+		pack.Rules[typeName] = toRulePackRule(rule)
+	}
+	for typeName, rule := range rules {
+		pack.Rules[strings.ToLower(typeName)] = rule
+	}
+
+	if err := pack.Validate(); err != nil {
+		return models.RulePack{}, fmt.Errorf("invalid rule pack: %w", err)
+	}
+	if err := CheckTypeRules(packRules(pack)); err != nil {
+		return models.RulePack{}, fmt.Errorf("invalid rule pack: %w", err)
+	}
+	return pack, nil
+}
+
+// UpdateMapperRules replaces the rules of the types in rules, keeping the
+// others, and switches the mapper to the result. The rules are stored as
+// the next version of the "edited" pack so they survive restarts.
+func (s *RulePackService) UpdateMapperRules(rules map[string]models.RulePackRule, author string) (MapperRules, error) {
+	pack, err := s.editedPack(rules, author)
+	if err != nil {
+		return MapperRules{}, err
+	}
+	if _, err := s.Import(pack, true); err != nil {
+		return MapperRules{}, err
+	}
+	return s.MapperRules(), nil
+}
+
+// PreviewMapperRules maps every coffee with the rules UpdateMapperRules
+// would switch to and reports the coffees whose types would change
+func (s *RulePackService) PreviewMapperRules(rules map[string]models.RulePackRule, author string) (MapperRulesPreview, error) {
+	pack, err := s.editedPack(rules, author)
+	if err != nil {
+		return MapperRulesPreview{}, err
+	}
+	label := rulePackLabel(pack)
+	current := s.pokemonService.mapper
+	proposed, err := current.withBaseRules(label, packRules(pack))
+	if err != nil {
+		return MapperRulesPreview{}, fmt.Errorf("invalid rule pack: %w", err)
+	}
+
+	coffees, err := s.pokemonService.coffeeService.ListCoffees()
+	if err != nil {
+		return MapperRulesPreview{}, err
+	}
+	preview := MapperRulesPreview{
+		Pack:       label,
+		Coffees:    len(coffees),
+		Retyped:    []RetypedCoffee{},
+		TypeCounts: make(map[string][2]int),
+	}
+	for _, coffee := range coffees {
+		primary, secondary, _ := current.CalculatePokemonTypes(coffee)
+		newPrimary, newSecondary, _ := proposed.CalculatePokemonTypes(coffee)
+
+		counts := preview.TypeCounts[primary]
+		counts[0]++
+		preview.TypeCounts[primary] = counts
+		counts = preview.TypeCounts[newPrimary]
+		counts[1]++
+		preview.TypeCounts[newPrimary] = counts
+
+		if primary != newPrimary || secondary != newSecondary {
+			preview.Retyped = append(preview.Retyped, RetypedCoffee{
+				CoffeeID:     coffee.ID,
+				Name:         coffee.Name,
+				Primary:      primary,
+				Secondary:    secondary,
+				NewPrimary:   newPrimary,
+				NewSecondary: newSecondary,
+			})
+		}
+	}
+	sort.Slice(preview.Retyped, func(i, j int) bool {
+		return preview.Retyped[i].Name < preview.Retyped[j].Name
+	})
+	return preview, nil
+}
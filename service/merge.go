@@ -0,0 +1,393 @@
+package service
+
+import (
+	"fmt"
+	"go-coffee-log/models"
+	"go-coffee-log/storage"
+	"strconv"
+	"strings"
+)
+
+// Catch resolutions in a merge
+const (
+	CatchKept     = "kept"     // the kept coffee's catch stays
+	CatchMoved    = "moved"    // the duplicate's catch moves to the kept coffee
+	CatchReleased = "released" // the catch is dropped and its Pokemon can be caught again
+)
+
+// MergeRequest folds DuplicateID into the coffee it duplicates
+type MergeRequest struct {
+	DuplicateID string `json:"duplicate_id"`
+	DryRun      bool   `json:"dry_run"`
+}
+
+// MergeFieldChange is a field of the kept coffee the merge fills in, or a
+// field where the two entries disagree and the kept value wins
+type MergeFieldChange struct {
+	Field     string `json:"field"`
+	Keep      string `json:"keep"`
+	Duplicate string `json:"duplicate"`
+}
+
+// MergeCatch is how one Pokemon catch is resolved
+type MergeCatch struct {
+	Dex         string `json:"dex"`
+	CoffeeID    string `json:"coffee_id"` // the coffee the catch was made with
+	PokemonID   int    `json:"pokemon_id"`
+	PokemonName string `json:"pokemon_name"`
+	Level       int    `json:"level"`
+	Resolution  string `json:"resolution"`
+}
+
+// MergeResult is the diff of a merge: what the kept coffee becomes and
+// what moves to it from the duplicate
+type MergeResult struct {
+	DryRun    bool               `json:"dry_run"`
+	Coffee    models.Coffee      `json:"coffee"` // the kept coffee after the merge
+	Duplicate models.Coffee      `json:"duplicate"`
+	Filled    []MergeFieldChange `json:"filled"`    // blank on the kept coffee, taken from the duplicate
+	Conflicts []MergeFieldChange `json:"conflicts"` // set on both; the kept value wins
+
+	// Rows that move from the duplicate; reactions, photos and collection
+	// memberships the kept coffee already has are not counted
+	Brews       int `json:"brews"`
+	Comments    int `json:"comments"`
+	Reactions   int `json:"reactions"`
+	Photos      int `json:"photos"`
+	Collections int `json:"collections"`
+
+	Catches []MergeCatch `json:"catches"`
+}
+
+// MergeService merges duplicate entries for the same bag (the Daycare).
+// The duplicate's brews, and with them its ratings history, its comments,
+// reactions, photos and collection memberships move to the kept coffee;
+// blank fields of the kept coffee are filled in from the duplicate, and the
+// duplicate is deleted. In a dex profile where both entries caught a
+// Pokemon, the higher-level catch stays and the other is released.
+type MergeService struct {
+	coffeeService     *CoffeeService
+	merger            storage.CoffeeMerger
+	brewStorage       storage.BrewStorage
+	commentStorage    storage.CommentStorage
+	photoStorage      storage.PhotoStorage
+	collectionStorage storage.CollectionStorage
+	reactionStorage   storage.ReactionStorage // optional
+	pokemonStorage    storage.PokemonStorage  // optional
+	bus               *EventBus
+}
+
+// NewMergeService creates a new merge service
+func NewMergeService(
+	coffeeService *CoffeeService,
+	merger storage.CoffeeMerger,
+	brewStorage storage.BrewStorage,
+	commentStorage storage.CommentStorage,
+	photoStorage storage.PhotoStorage,
+	collectionStorage storage.CollectionStorage,
+	reactionStorage storage.ReactionStorage,
+	pokemonStorage storage.PokemonStorage,
+	bus *EventBus,
+) *MergeService {
+	return &MergeService{
+		coffeeService:     coffeeService,
+		merger:            merger,
+		brewStorage:       brewStorage,
+		commentStorage:    commentStorage,
+		photoStorage:      photoStorage,
+		collectionStorage: collectionStorage,
+		reactionStorage:   reactionStorage,
+		pokemonStorage:    pokemonStorage,
+		bus:               bus,
+	}
+}
+
+// Merge folds the duplicate into the kept coffee and, unless this is a dry
+// run, applies the result
+func (s *MergeService) Merge(keepID string, req MergeRequest) (*MergeResult, error) {
+	if strings.TrimSpace(req.DuplicateID) == "" {
+		return nil, fmt.Errorf("duplicate_id is required")
+	}
+	if req.DuplicateID == keepID {
+		return nil, fmt.Errorf("invalid merge: a coffee cannot be merged into itself")
+	}
+
+	keep, err := s.coffeeService.GetCoffee(keepID)
+	if err != nil {
+		return nil, err
+	}
+	duplicate, err := s.coffeeService.GetCoffee(req.DuplicateID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &MergeResult{DryRun: req.DryRun, Duplicate: duplicate}
+	result.Coffee, result.Filled, result.Conflicts = mergeFields(keep, duplicate)
+	if err := s.countMoves(result, keep.ID, duplicate.ID); err != nil {
+		return nil, err
+	}
+	drop, err := s.resolveCatches(result, keep.ID, duplicate.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.DryRun {
+		return result, nil
+	}
+
+	// Fill the kept coffee in first: if the merge then fails, both entries
+	// are still there to retry with
+	if len(result.Filled) > 0 {
+		updated, err := s.coffeeService.UpdateCoffee(keep.ID, result.Coffee)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update kept coffee: %w", err)
+		}
+		result.Coffee = updated
+	}
+	if err := s.merger.MergeCoffee(keep.ID, duplicate.ID, drop); err != nil {
+		return nil, err
+	}
+	s.coffeeService.recordDeletion(duplicate.ID)
+
+	s.bus.Publish(Event{
+		Type:     "coffee.merged",
+		Message:  fmt.Sprintf("Merged a duplicate entry into %s", keep.Name),
+		CoffeeID: keep.ID,
+		Data: map[string]interface{}{
+			"duplicate_id": duplicate.ID,
+			"brews":        result.Brews,
+			"catches":      len(result.Catches),
+		},
+	})
+	return result, nil
+}
+
+// countMoves counts the duplicate's rows that move to the kept coffee
+func (s *MergeService) countMoves(result *MergeResult, keepID, duplicateID string) error {
+	brews, err := s.brewStorage.GetBrewsByCoffee(duplicateID)
+	if err != nil {
+		return fmt.Errorf("failed to get brews: %w", err)
+	}
+	result.Brews = len(brews)
+
+	comments, err := s.commentStorage.GetCommentsByCoffee(duplicateID)
+	if err != nil {
+		return fmt.Errorf("failed to get comments: %w", err)
+	}
+	result.Comments = len(comments)
+
+	keptPhotos, err := s.photoStorage.GetPhotos(keepID)
+	if err != nil {
+		return fmt.Errorf("failed to get photos: %w", err)
+	}
+	photos, err := s.photoStorage.GetPhotos(duplicateID)
+	if err != nil {
+		return fmt.Errorf("failed to get photos: %w", err)
+	}
+	hashes := make(map[string]bool, len(keptPhotos))
+	for _, photo := range keptPhotos {
+		hashes[photo.Hash] = true
+	}
+	for _, photo := range photos {
+		if !hashes[photo.Hash] {
+			result.Photos++
+		}
+	}
+
+	collections, err := s.collectionStorage.GetAllCollections()
+	if err != nil {
+		return fmt.Errorf("failed to get collections: %w", err)
+	}
+	for _, collection := range collections {
+		members := make(map[string]bool, len(collection.CoffeeIDs))
+		for _, id := range collection.CoffeeIDs {
+			members[id] = true
+		}
+		if members[duplicateID] && !members[keepID] {
+			result.Collections++
+		}
+	}
+
+	if s.reactionStorage != nil {
+		reactions, err := s.reactionStorage.GetAllReactions()
+		if err != nil {
+			return fmt.Errorf("failed to get reactions: %w", err)
+		}
+		kept := make(map[string]bool)
+		for _, reaction := range reactions {
+			if reaction.CoffeeID == keepID {
+				kept[reaction.UserID+"\x00"+reaction.Emoji] = true
+			}
+		}
+		for _, reaction := range reactions {
+			if reaction.CoffeeID == duplicateID && !kept[reaction.UserID+"\x00"+reaction.Emoji] {
+				result.Reactions++
+			}
+		}
+	}
+	return nil
+}
+
+// resolveCatches decides, for every dex profile either coffee has a catch
+// in, which catch the kept coffee ends up with, and returns the catches to
+// drop
+func (s *MergeService) resolveCatches(result *MergeResult, keepID, duplicateID string) ([]storage.CatchRef, error) {
+	result.Catches = []MergeCatch{}
+	if s.pokemonStorage == nil {
+		return nil, nil
+	}
+
+	mappings, err := s.pokemonStorage.GetAllCoffeePokemon()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get catches: %w", err)
+	}
+	kept := make(map[string]models.CoffeePokemon)
+	duplicates := []models.CoffeePokemon{}
+	for _, mapping := range mappings {
+		switch mapping.CoffeeID {
+		case keepID:
+			kept[mapping.Dex] = mapping
+		case duplicateID:
+			duplicates = append(duplicates, mapping)
+		}
+	}
+
+	drop := []storage.CatchRef{}
+	resolved := make(map[string]bool)
+	for _, dup := range duplicates {
+		resolved[dup.Dex] = true
+		existing, ok := kept[dup.Dex]
+		switch {
+		case !ok:
+			result.Catches = append(result.Catches, mergeCatch(dup, CatchMoved))
+		case dup.Level > existing.Level:
+			result.Catches = append(result.Catches, mergeCatch(existing, CatchReleased), mergeCatch(dup, CatchMoved))
+			drop = append(drop, storage.CatchRef{CoffeeID: keepID, Dex: dup.Dex})
+		default:
+			result.Catches = append(result.Catches, mergeCatch(existing, CatchKept), mergeCatch(dup, CatchReleased))
+			drop = append(drop, storage.CatchRef{CoffeeID: duplicateID, Dex: dup.Dex})
+		}
+	}
+	for _, mapping := range mappings {
+		if mapping.CoffeeID == keepID && !resolved[mapping.Dex] {
+			result.Catches = append(result.Catches, mergeCatch(mapping, CatchKept))
+		}
+	}
+	return drop, nil
+}
+
+// mergeCatch describes a catch and how a merge resolves it
+func mergeCatch(mapping models.CoffeePokemon, resolution string) MergeCatch {
+	return MergeCatch{
+		Dex:         mapping.Dex,
+		CoffeeID:    mapping.CoffeeID,
+		PokemonID:   mapping.PokemonID,
+		PokemonName: mapping.PokemonName,
+		Level:       mapping.Level,
+		Resolution:  resolution,
+	}
+}
+
+// mergeFields fills the kept coffee's blank fields in from the duplicate
+// and reports the fields it filled and those where the two disagree. A
+// favorite stays a favorite if either entry was one.
+func mergeFields(keep, duplicate models.Coffee) (models.Coffee, []MergeFieldChange, []MergeFieldChange) {
+	merged := keep
+	filled, conflicts := []MergeFieldChange{}, []MergeFieldChange{}
+
+	compare := func(field string, kept, dup string, fill func()) {
+		switch {
+		case dup == "" || kept == dup:
+		case kept == "":
+			fill()
+			filled = append(filled, MergeFieldChange{Field: field, Keep: kept, Duplicate: dup})
+		default:
+			conflicts = append(conflicts, MergeFieldChange{Field: field, Keep: kept, Duplicate: dup})
+		}
+	}
+
+	compare("name", keep.Name, duplicate.Name, func() { merged.Name = duplicate.Name })
+	compare("origin", keep.Origin, duplicate.Origin, func() { merged.Origin = duplicate.Origin })
+	compare("roaster", keep.Roaster, duplicate.Roaster, func() { merged.Roaster = duplicate.Roaster })
+	compare("variety", keep.Variety, duplicate.Variety, func() { merged.Variety = duplicate.Variety })
+	compare("roast_level", keep.RoastLevel, duplicate.RoastLevel, func() { merged.RoastLevel = duplicate.RoastLevel })
+	compare("processing_method", keep.ProcessingMethod, duplicate.ProcessingMethod, func() { merged.ProcessingMethod = duplicate.ProcessingMethod })
+	compare("dripper", keep.Dripper, duplicate.Dripper, func() { merged.Dripper = duplicate.Dripper })
+	compare("brewer_id", keep.BrewerID, duplicate.BrewerID, func() { merged.BrewerID = duplicate.BrewerID })
+	compare("altitude", keep.Altitude, duplicate.Altitude, func() { merged.Altitude = duplicate.Altitude })
+	compare("lot", keep.Lot, duplicate.Lot, func() { merged.Lot = duplicate.Lot })
+	compare("price", priceString(keep.Price), priceString(duplicate.Price), func() { merged.Price = duplicate.Price })
+	compare("bag_grams", gramsString(keep.BagGrams), gramsString(duplicate.BagGrams), func() { merged.BagGrams = duplicate.BagGrams })
+	// Tasting notes are a set: the duplicate's new notes fill free slots
+	for _, note := range duplicate.TastingNotes {
+		if note == "" || hasTastingNote(merged.TastingNotes, note) {
+			continue
+		}
+		for i := range merged.TastingNotes {
+			if merged.TastingNotes[i] == "" {
+				merged.TastingNotes[i] = note
+				filled = append(filled, MergeFieldChange{Field: "tasting_notes", Duplicate: note})
+				break
+			}
+		}
+	}
+	compare("recipe", strings.Join(keep.Recipe, "; "), strings.Join(duplicate.Recipe, "; "),
+		func() { merged.Recipe = append([]string{}, duplicate.Recipe...) })
+	compare("roast_date", formatDate(keep.RoastDate), formatDate(duplicate.RoastDate), func() { merged.RoastDate = duplicate.RoastDate })
+	compare("rating", formatRating(keep.Rating), formatRating(duplicate.Rating), func() { merged.Rating = duplicate.Rating })
+	if keep.TastingTraits == (models.TastingTraits{}) {
+		merged.TastingTraits = duplicate.TastingTraits
+		if duplicate.TastingTraits != (models.TastingTraits{}) {
+			filled = append(filled, MergeFieldChange{Field: "tasting_traits", Keep: "", Duplicate: "set"})
+		}
+	} else if duplicate.TastingTraits != (models.TastingTraits{}) && duplicate.TastingTraits != keep.TastingTraits {
+		conflicts = append(conflicts, MergeFieldChange{Field: "tasting_traits", Keep: "set", Duplicate: "set"})
+	}
+	if duplicate.Favorite && !keep.Favorite {
+		merged.Favorite = true
+		filled = append(filled, MergeFieldChange{Field: "favorite", Keep: "false", Duplicate: "true"})
+	}
+	return merged, filled, conflicts
+}
+
+// hasTastingNote reports whether notes include note, ignoring case
+func hasTastingNote(notes [5]string, note string) bool {
+	for _, existing := range notes {
+		if strings.EqualFold(strings.TrimSpace(existing), strings.TrimSpace(note)) {
+			return true
+		}
+	}
+	return false
+}
+
+// formatDate formats an optional date for a merge diff
+func formatDate(date *models.Date) string {
+	if date == nil {
+		return ""
+	}
+	return date.Format("2006-01-02")
+}
+
+// formatRating formats a rating for a merge diff, where 0 is unrated
+func formatRating(rating int) string {
+	if rating == 0 {
+		return ""
+	}
+	return strconv.Itoa(rating)
+}
+
+// priceString renders an optional price for merge comparison
+func priceString(price *models.Money) string {
+	if price == nil {
+		return ""
+	}
+	return price.String()
+}
+
+// gramsString renders a bag size for merge comparison, blank when unset
+func gramsString(grams int) string {
+	if grams == 0 {
+		return ""
+	}
+	return strconv.Itoa(grams)
+}
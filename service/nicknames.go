@@ -0,0 +1,191 @@
+package service
+
+import (
+	"fmt"
+	"go-coffee-log/models"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MaxNicknameLength matches the coffee_pokemon.nickname column
+const MaxNicknameLength = 100
+
+// nicknamePlaceholder matches "{origin}"-style fields in a naming scheme
+var nicknamePlaceholder = regexp.MustCompile(`\{([a-z_]+)\}`)
+
+// nicknameFields are the placeholders a naming scheme may use
+var nicknameFields = map[string]func(nicknameSubject) string{
+	"name":    func(s nicknameSubject) string { return s.coffee.Name },
+	"origin":  func(s nicknameSubject) string { return s.coffee.Origin },
+	"roaster": func(s nicknameSubject) string { return s.coffee.Roaster },
+	"variety": func(s nicknameSubject) string { return s.coffee.Variety },
+	"process": func(s nicknameSubject) string { return s.coffee.ProcessingMethod },
+	"roast":   func(s nicknameSubject) string { return s.coffee.RoastLevel },
+	"pokemon": func(s nicknameSubject) string { return s.mapping.PokemonName },
+	"type":    func(s nicknameSubject) string { return s.primaryType },
+	"level":   func(s nicknameSubject) string { return strconv.Itoa(s.mapping.Level) },
+	"n":       func(s nicknameSubject) string { return strconv.Itoa(s.number) },
+}
+
+// nicknameSubject is what a naming scheme is filled in from
+type nicknameSubject struct {
+	coffee      models.Coffee
+	mapping     models.CoffeePokemon
+	primaryType string
+	number      int // position in the dex, oldest first, from 1
+}
+
+// BulkRenameRequest applies a naming scheme such as "{origin}-{type}" to
+// Pokedex entries
+type BulkRenameRequest struct {
+	Scheme      string   `json:"scheme"`
+	CoffeeIDs   []string `json:"coffee_ids,omitempty"`   // all entries when empty
+	OnlyUnnamed bool     `json:"only_unnamed,omitempty"` // skip entries that have a nickname
+	DryRun      bool     `json:"dry_run"`
+}
+
+// NicknameChange is one entry's nickname before and after a bulk rename
+type NicknameChange struct {
+	CoffeeID    string `json:"coffee_id"`
+	PokemonName string `json:"pokemon_name"`
+	OldNickname string `json:"old_nickname"`
+	NewNickname string `json:"new_nickname"`
+}
+
+// BulkRenameResult reports what a bulk rename changed, or would change
+type BulkRenameResult struct {
+	Scheme    string           `json:"scheme"`
+	DryRun    bool             `json:"dry_run"`
+	Changes   []NicknameChange `json:"changes"`
+	Unchanged int              `json:"unchanged"` // entries already named as the scheme would
+}
+
+// NicknameSchemeFields lists the placeholders naming schemes may use
+func NicknameSchemeFields() []string {
+	fields := make([]string, 0, len(nicknameFields))
+	for field := range nicknameFields {
+		fields = append(fields, "{"+field+"}")
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// SearchByNickname returns the entries of a dex profile whose nickname
+// contains query, ignoring case
+func (s *PokemonService) SearchByNickname(dexID, query string) ([]models.CoffeePokemon, error) {
+	mappings, err := s.GetDexEntries(dexID)
+	if err != nil {
+		return nil, err
+	}
+
+	query = strings.ToLower(strings.TrimSpace(query))
+	matches := []models.CoffeePokemon{}
+	for _, mapping := range mappings {
+		if mapping.Nickname != "" && strings.Contains(strings.ToLower(mapping.Nickname), query) {
+			matches = append(matches, mapping)
+		}
+	}
+	return matches, nil
+}
+
+// BulkRename fills the naming scheme in for each selected entry of a dex
+// profile and, unless this is a dry run, saves the new nicknames
+func (s *PokemonService) BulkRename(dexID string, req BulkRenameRequest) (*BulkRenameResult, error) {
+	if err := validateNicknameScheme(req.Scheme); err != nil {
+		return nil, err
+	}
+
+	dexID, err := s.dexID(dexID)
+	if err != nil {
+		return nil, err
+	}
+	mappings, err := s.GetDexEntries(dexID)
+	if err != nil {
+		return nil, err
+	}
+	// Number entries in the order they were caught
+	sort.SliceStable(mappings, func(i, j int) bool { return mappings[i].CreatedAt.Before(mappings[j].CreatedAt) })
+
+	selected := make(map[string]bool, len(req.CoffeeIDs))
+	for _, id := range req.CoffeeIDs {
+		selected[id] = true
+	}
+	found := make(map[string]bool, len(req.CoffeeIDs))
+
+	result := &BulkRenameResult{Scheme: req.Scheme, DryRun: req.DryRun, Changes: []NicknameChange{}}
+	for i, mapping := range mappings {
+		if len(selected) > 0 && !selected[mapping.CoffeeID] {
+			continue
+		}
+		found[mapping.CoffeeID] = true
+		if req.OnlyUnnamed && mapping.Nickname != "" {
+			continue
+		}
+
+		coffee, err := s.coffeeService.GetCoffee(mapping.CoffeeID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load coffee %s: %w", mapping.CoffeeID, err)
+		}
+		subject := nicknameSubject{coffee: coffee, mapping: mapping, number: i + 1}
+		if pokemon, err := s.storage.GetPokemonByID(mapping.PokemonID); err == nil {
+			subject.primaryType, _, _ = strings.Cut(pokemon.Type, "/")
+		}
+
+		nickname := applyNicknameScheme(req.Scheme, subject)
+		if nickname == "" || nickname == mapping.Nickname {
+			result.Unchanged++
+			continue
+		}
+		result.Changes = append(result.Changes, NicknameChange{
+			CoffeeID:    mapping.CoffeeID,
+			PokemonName: mapping.PokemonName,
+			OldNickname: mapping.Nickname,
+			NewNickname: nickname,
+		})
+	}
+
+	for _, id := range req.CoffeeIDs {
+		if !found[id] {
+			return nil, fmt.Errorf("Pokemon mapping for coffee %s not found", id)
+		}
+	}
+
+	if req.DryRun {
+		return result, nil
+	}
+	for _, change := range result.Changes {
+		if err := s.storage.UpdateCoffeePokemonNickname(change.CoffeeID, dexID, change.NewNickname); err != nil {
+			return nil, fmt.Errorf("failed to rename %s: %w", change.CoffeeID, err)
+		}
+		s.publishCatch(change.CoffeeID, dexID)
+	}
+	return result, nil
+}
+
+// validateNicknameScheme rejects empty schemes and unknown placeholders
+func validateNicknameScheme(scheme string) error {
+	if strings.TrimSpace(scheme) == "" {
+		return fmt.Errorf("scheme is required")
+	}
+	for _, match := range nicknamePlaceholder.FindAllStringSubmatch(scheme, -1) {
+		if _, ok := nicknameFields[match[1]]; !ok {
+			return fmt.Errorf("unknown scheme field {%s}; use %s", match[1], strings.Join(NicknameSchemeFields(), ", "))
+		}
+	}
+	return nil
+}
+
+// applyNicknameScheme fills the scheme in, collapsing whitespace and
+// trimming the result to the column length
+func applyNicknameScheme(scheme string, subject nicknameSubject) string {
+	filled := nicknamePlaceholder.ReplaceAllStringFunc(scheme, func(placeholder string) string {
+		return strings.TrimSpace(nicknameFields[placeholder[1:len(placeholder)-1]](subject))
+	})
+	filled = strings.Join(strings.Fields(filled), " ")
+	if runes := []rune(filled); len(runes) > MaxNicknameLength {
+		filled = strings.TrimSpace(string(runes[:MaxNicknameLength]))
+	}
+	return filled
+}
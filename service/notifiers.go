@@ -0,0 +1,141 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// WebhookSink POSTs events as JSON to a URL
+type WebhookSink struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookSink creates a webhook sink
+func NewWebhookSink(url string) *WebhookSink {
+	return &WebhookSink{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Name identifies the sink in logs
+func (s *WebhookSink) Name() string {
+	return "webhook"
+}
+
+// Send delivers an event to the webhook
+func (s *WebhookSink) Send(event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	resp, err := s.client.Post(s.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to call webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// EmailSink sends events as plain-text email over SMTP
+type EmailSink struct {
+	addr string // host:port
+	auth smtp.Auth
+	from string
+	to   []string
+}
+
+// NewEmailSink creates an email sink. Authentication is skipped when no
+// username is given (e.g. a local relay).
+func NewEmailSink(addr, username, password, from string, to []string) *EmailSink {
+	var auth smtp.Auth
+	if username != "" {
+		host := addr
+		if i := strings.LastIndex(addr, ":"); i >= 0 {
+			host = addr[:i]
+		}
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &EmailSink{
+		addr: addr,
+		auth: auth,
+		from: from,
+		to:   to,
+	}
+}
+
+// Name identifies the sink in logs
+func (s *EmailSink) Name() string {
+	return "email"
+}
+
+// Send delivers an event by email
+func (s *EmailSink) Send(event Event) error {
+	message := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: Coffee Dex: %s\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s\r\n",
+		s.from, strings.Join(s.to, ", "), event.Message, event.Message)
+
+	if err := smtp.SendMail(s.addr, s.auth, s.from, s.to, []byte(message)); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// discordMessageLimit is the most characters Discord accepts in a message
+const discordMessageLimit = 2000
+
+// DiscordSink posts events to a Discord channel through an incoming webhook
+type DiscordSink struct {
+	url    string
+	client *http.Client
+}
+
+// NewDiscordSink creates a Discord sink for a channel webhook URL
+func NewDiscordSink(url string) *DiscordSink {
+	return &DiscordSink{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Name identifies the sink in logs
+func (s *DiscordSink) Name() string {
+	return "discord"
+}
+
+// Send posts an event's message to the channel
+func (s *DiscordSink) Send(event Event) error {
+	content := event.Message
+	if runes := []rune(content); len(runes) > discordMessageLimit {
+		content = string(runes[:discordMessageLimit-1]) + "…"
+	}
+	body, err := json.Marshal(map[string]string{
+		"username": "Coffee Dex",
+		"content":  content,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	resp, err := s.client.Post(s.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to call Discord: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("Discord returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go-coffee-log/models"
+)
+
+// originSeason is when an origin is harvested and when its fresh crop
+// reaches roasters in Europe and North America, in typical years
+type originSeason struct {
+	origin  string
+	aliases []string // lower-case names matched in a coffee's origin
+	harvest [2]time.Month
+	fresh   [2]time.Month
+}
+
+// harvestCalendar holds the main crop of the major origins. Months are
+// inclusive ranges that may wrap around the new year.
+var harvestCalendar = []originSeason{
+	{"Ethiopia", []string{"ethiopia", "yirgacheffe", "sidama", "guji"}, [2]time.Month{time.October, time.January}, [2]time.Month{time.May, time.September}},
+	{"Kenya", []string{"kenya"}, [2]time.Month{time.October, time.December}, [2]time.Month{time.June, time.October}},
+	{"Rwanda", []string{"rwanda"}, [2]time.Month{time.March, time.July}, [2]time.Month{time.August, time.December}},
+	{"Burundi", []string{"burundi"}, [2]time.Month{time.March, time.July}, [2]time.Month{time.September, time.December}},
+	{"Uganda", []string{"uganda"}, [2]time.Month{time.October, time.February}, [2]time.Month{time.March, time.July}},
+	{"Tanzania", []string{"tanzania"}, [2]time.Month{time.July, time.December}, [2]time.Month{time.January, time.May}},
+	{"Yemen", []string{"yemen"}, [2]time.Month{time.October, time.December}, [2]time.Month{time.March, time.July}},
+	{"Colombia", []string{"colombia"}, [2]time.Month{time.September, time.December}, [2]time.Month{time.January, time.May}},
+	{"Brazil", []string{"brazil"}, [2]time.Month{time.May, time.September}, [2]time.Month{time.October, time.February}},
+	{"Peru", []string{"peru"}, [2]time.Month{time.May, time.September}, [2]time.Month{time.October, time.February}},
+	{"Bolivia", []string{"bolivia"}, [2]time.Month{time.July, time.October}, [2]time.Month{time.November, time.March}},
+	{"Ecuador", []string{"ecuador"}, [2]time.Month{time.May, time.September}, [2]time.Month{time.October, time.February}},
+	{"Guatemala", []string{"guatemala"}, [2]time.Month{time.December, time.March}, [2]time.Month{time.May, time.August}},
+	{"Costa Rica", []string{"costa rica"}, [2]time.Month{time.November, time.March}, [2]time.Month{time.April, time.August}},
+	{"Honduras", []string{"honduras"}, [2]time.Month{time.November, time.April}, [2]time.Month{time.May, time.September}},
+	{"El Salvador", []string{"el salvador", "salvador"}, [2]time.Month{time.November, time.March}, [2]time.Month{time.April, time.August}},
+	{"Nicaragua", []string{"nicaragua"}, [2]time.Month{time.November, time.March}, [2]time.Month{time.April, time.August}},
+	{"Panama", []string{"panama", "boquete"}, [2]time.Month{time.December, time.March}, [2]time.Month{time.May, time.September}},
+	{"Mexico", []string{"mexico", "chiapas", "oaxaca"}, [2]time.Month{time.November, time.March}, [2]time.Month{time.April, time.August}},
+	{"Indonesia", []string{"indonesia", "sumatra", "java", "sulawesi", "bali", "flores"}, [2]time.Month{time.October, time.March}, [2]time.Month{time.April, time.August}},
+	{"Papua New Guinea", []string{"papua"}, [2]time.Month{time.April, time.September}, [2]time.Month{time.October, time.February}},
+	{"India", []string{"india"}, [2]time.Month{time.November, time.February}, [2]time.Month{time.April, time.July}},
+	{"Yunnan", []string{"yunnan", "china"}, [2]time.Month{time.November, time.March}, [2]time.Month{time.April, time.August}},
+	{"Hawaii", []string{"hawaii", "kona"}, [2]time.Month{time.August, time.January}, [2]time.Month{time.October, time.March}},
+}
+
+// monthsBetween lists the months of an inclusive range, wrapping around
+// the new year
+func monthsBetween(span [2]time.Month) []time.Month {
+	months := []time.Month{span[0]}
+	for m := span[0]; m != span[1]; {
+		m = m%12 + 1
+		months = append(months, m)
+	}
+	return months
+}
+
+// inSpan reports whether month falls in an inclusive range
+func inSpan(month time.Month, span [2]time.Month) bool {
+	for _, m := range monthsBetween(span) {
+		if m == month {
+			return true
+		}
+	}
+	return false
+}
+
+// monthNames spells out months
+func monthNames(months []time.Month) []string {
+	names := make([]string, len(months))
+	for i, m := range months {
+		names[i] = m.String()
+	}
+	return names
+}
+
+// CalendarOrigin is an origin's season and what the collection holds of it
+type CalendarOrigin struct {
+	Origin          string     `json:"origin"`
+	HarvestMonths   []string   `json:"harvest_months"`
+	FreshCropMonths []string   `json:"fresh_crop_months"`
+	Coffees         int        `json:"coffees"`                  // bags in the collection
+	AverageRating   float64    `json:"average_rating,omitempty"` // of the rated bags
+	LastAdded       *time.Time `json:"last_added,omitempty"`
+}
+
+// OriginSuggestion recommends buying an origin now
+type OriginSuggestion struct {
+	Origin string `json:"origin"`
+	Reason string `json:"reason"`
+}
+
+// OriginCalendar is the fresh-crop outlook for a month
+type OriginCalendar struct {
+	Month       string             `json:"month"`
+	InSeason    []CalendarOrigin   `json:"in_season"`   // fresh crop on offer this month
+	ComingSoon  []CalendarOrigin   `json:"coming_soon"` // fresh crop arriving next month
+	Suggestions []OriginSuggestion `json:"suggestions"`
+}
+
+// OriginCalendarService reports which origins are in fresh-crop season
+type OriginCalendarService struct {
+	coffeeService *CoffeeService
+}
+
+// NewOriginCalendarService creates a new origin calendar service
+func NewOriginCalendarService(coffeeService *CoffeeService) *OriginCalendarService {
+	return &OriginCalendarService{coffeeService: coffeeService}
+}
+
+// GetCalendar lists the origins whose fresh crop is on offer in month and
+// the next, with the collection's bags of each, and suggests the ones worth
+// buying: favorites coming back into season and origins not yet tried
+func (s *OriginCalendarService) GetCalendar(month time.Month) (OriginCalendar, error) {
+	coffees, err := s.coffeeService.ListCoffees()
+	if err != nil {
+		return OriginCalendar{}, err
+	}
+
+	next := month%12 + 1
+	calendar := OriginCalendar{
+		Month:       month.String(),
+		InSeason:    []CalendarOrigin{},
+		ComingSoon:  []CalendarOrigin{},
+		Suggestions: []OriginSuggestion{},
+	}
+	var favorites, untried []OriginSuggestion
+	for _, season := range harvestCalendar {
+		inSeason, comingSoon := inSpan(month, season.fresh), !inSpan(month, season.fresh) && inSpan(next, season.fresh)
+		if !inSeason && !comingSoon {
+			continue
+		}
+
+		origin := collectOrigin(season, coffees)
+		if comingSoon {
+			calendar.ComingSoon = append(calendar.ComingSoon, origin)
+			continue
+		}
+		calendar.InSeason = append(calendar.InSeason, origin)
+
+		switch {
+		case origin.Coffees == 0:
+			untried = append(untried, OriginSuggestion{
+				Origin: origin.Origin,
+				Reason: fmt.Sprintf("Fresh %s is in season and not in your collection yet", origin.Origin),
+			})
+		case origin.AverageRating >= 7:
+			favorites = append(favorites, OriginSuggestion{
+				Origin: origin.Origin,
+				Reason: fmt.Sprintf("Fresh %s is in season and you rate it %.1f on average", origin.Origin, origin.AverageRating),
+			})
+		}
+	}
+
+	// Favorites first, best rated first, then origins to discover
+	sort.SliceStable(favorites, func(i, j int) bool {
+		return ratingOf(calendar.InSeason, favorites[i].Origin) > ratingOf(calendar.InSeason, favorites[j].Origin)
+	})
+	calendar.Suggestions = append(append(calendar.Suggestions, favorites...), untried...)
+	return calendar, nil
+}
+
+// ratingOf returns the average rating of an origin among origins
+func ratingOf(origins []CalendarOrigin, name string) float64 {
+	for _, origin := range origins {
+		if origin.Origin == name {
+			return origin.AverageRating
+		}
+	}
+	return 0
+}
+
+// collectOrigin summarizes the collection's bags of an origin
+func collectOrigin(season originSeason, coffees []models.Coffee) CalendarOrigin {
+	origin := CalendarOrigin{
+		Origin:          season.origin,
+		HarvestMonths:   monthNames(monthsBetween(season.harvest)),
+		FreshCropMonths: monthNames(monthsBetween(season.fresh)),
+	}
+	ratingSum, rated := 0, 0
+	for _, coffee := range coffees {
+		if !containsAny(strings.ToLower(coffee.Origin), season.aliases...) {
+			continue
+		}
+		origin.Coffees++
+		if coffee.Rating > 0 {
+			ratingSum += coffee.Rating
+			rated++
+		}
+		if origin.LastAdded == nil || coffee.CreatedAt.After(*origin.LastAdded) {
+			added := coffee.CreatedAt
+			origin.LastAdded = &added
+		}
+	}
+	if rated > 0 {
+		origin.AverageRating = round2(float64(ratingSum) / float64(rated))
+	}
+	return origin
+}
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-coffee-log/models"
+	"go-coffee-log/storage"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// outboxMaxAttempts is how many times a message is tried before it is
+	// marked dead
+	outboxMaxAttempts = 10
+	// outboxInitialBackoff doubles after every failed attempt, up to
+	// outboxMaxBackoff
+	outboxInitialBackoff = 30 * time.Second
+	outboxMaxBackoff     = 2 * time.Hour
+	// outboxLease is how long an attempt in flight holds its message, so a
+	// retry pass never sends it twice at once
+	outboxLease = 2 * time.Minute
+	// outboxBatch caps the messages one retry pass sends
+	outboxBatch = 50
+	// OutboxRetention is how long delivered messages are kept
+	OutboxRetention = 7 * 24 * time.Hour
+)
+
+// Outbox stores events bound for external sinks before sending them, and
+// retries failed deliveries with exponential backoff. Because a message is
+// stored before its first attempt, a delivery interrupted by a restart is
+// retried rather than lost; sinks may therefore see an event twice.
+//
+// The outbox is itself an EventSink: add it to the event bus and add the
+// real sinks to the outbox.
+type Outbox struct {
+	storage storage.OutboxStorage
+	sinks   map[string]EventSink
+
+	dispatchMu sync.Mutex // one retry pass at a time
+}
+
+// NewOutbox creates a new outbox
+func NewOutbox(storage storage.OutboxStorage) *Outbox {
+	return &Outbox{
+		storage: storage,
+		sinks:   make(map[string]EventSink),
+	}
+}
+
+// AddSink registers a sink by name. Register sinks at startup, before
+// events are published.
+func (o *Outbox) AddSink(sink EventSink) {
+	o.sinks[sink.Name()] = sink
+}
+
+// Name identifies the sink in logs
+func (o *Outbox) Name() string {
+	return "outbox"
+}
+
+// Send queues an event for every registered sink
+func (o *Outbox) Send(event Event) error {
+	for name := range o.sinks {
+		if err := o.enqueue(name, "", event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EnqueueWebhook queues an event for a webhook URL that is not a
+// registered sink, e.g. a saved search's
+func (o *Outbox) EnqueueWebhook(url string, event Event) error {
+	return o.enqueue("webhook", url, event)
+}
+
+// enqueue stores a message and makes the first attempt in the background
+func (o *Outbox) enqueue(sink, target string, event Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	now := time.Now()
+	message := models.OutboxMessage{
+		ID:            uuid.New().String(),
+		Sink:          sink,
+		Target:        target,
+		Payload:       payload,
+		Status:        models.OutboxPending,
+		NextAttemptAt: now.Add(outboxLease),
+		CreatedAt:     now,
+	}
+	if err := o.storage.SaveMessage(message); err != nil {
+		return fmt.Errorf("failed to queue %s delivery: %w", sink, err)
+	}
+
+	go o.attempt(message)
+	return nil
+}
+
+// Dispatch retries every message that is due. Run it periodically.
+func (o *Outbox) Dispatch(now time.Time) error {
+	o.dispatchMu.Lock()
+	defer o.dispatchMu.Unlock()
+
+	messages, err := o.storage.GetDueMessages(now, outboxBatch)
+	if err != nil {
+		return err
+	}
+	for _, message := range messages {
+		// Claim the message so a slow attempt isn't picked up again
+		message.NextAttemptAt = time.Now().Add(outboxLease)
+		if err := o.storage.UpdateMessage(message); err != nil {
+			return err
+		}
+		o.attempt(message)
+	}
+
+	if _, err := o.storage.DeleteDelivered(now.Add(-OutboxRetention)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Messages lists queued messages with a status (all when empty)
+func (o *Outbox) Messages(status string) ([]models.OutboxMessage, error) {
+	switch status {
+	case "", models.OutboxPending, models.OutboxDelivered, models.OutboxDead:
+	default:
+		return nil, fmt.Errorf("invalid status %q, expected pending, delivered or dead", status)
+	}
+	return o.storage.GetMessages(status)
+}
+
+// Retry gives an undelivered message a fresh set of attempts, starting now
+func (o *Outbox) Retry(id string) (models.OutboxMessage, error) {
+	message, err := o.storage.GetMessage(id)
+	if err != nil {
+		return models.OutboxMessage{}, err
+	}
+	if message.Status == models.OutboxDelivered {
+		return models.OutboxMessage{}, fmt.Errorf("message was already delivered")
+	}
+
+	message.Status = models.OutboxPending
+	message.Attempts = 0
+	message.NextAttemptAt = time.Now().Add(outboxLease)
+	if err := o.storage.UpdateMessage(message); err != nil {
+		return models.OutboxMessage{}, err
+	}
+
+	go o.attempt(message)
+	return message, nil
+}
+
+// attempt sends a message once and records the outcome
+func (o *Outbox) attempt(message models.OutboxMessage) {
+	err := o.send(message)
+
+	now := time.Now()
+	message.Attempts++
+	switch {
+	case err == nil:
+		message.Status = models.OutboxDelivered
+		message.DeliveredAt = &now
+		message.LastError = ""
+	case message.Attempts >= outboxMaxAttempts:
+		message.Status = models.OutboxDead
+		message.LastError = err.Error()
+		log.Printf("Outbox: giving up on %s delivery %s after %d attempts: %v", message.Sink, message.ID, message.Attempts, err)
+	default:
+		backoff := outboxInitialBackoff << (message.Attempts - 1)
+		if backoff > outboxMaxBackoff || backoff <= 0 {
+			backoff = outboxMaxBackoff
+		}
+		message.NextAttemptAt = now.Add(backoff)
+		message.LastError = err.Error()
+		log.Printf("Outbox: %s delivery %s failed (attempt %d, retrying in %s): %v", message.Sink, message.ID, message.Attempts, backoff, err)
+	}
+
+	if err := o.storage.UpdateMessage(message); err != nil {
+		log.Printf("ERROR: Failed to record outbox delivery %s: %v", message.ID, err)
+	}
+}
+
+// send delivers a message through its sink
+func (o *Outbox) send(message models.OutboxMessage) error {
+	var event Event
+	if err := json.Unmarshal(message.Payload, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+
+	if message.Target != "" {
+		return NewWebhookSink(message.Target).Send(event)
+	}
+	sink, ok := o.sinks[message.Sink]
+	if !ok {
+		return fmt.Errorf("sink %s is not configured", message.Sink)
+	}
+	return sink.Send(event)
+}
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"go-coffee-log/models"
+	"go-coffee-log/storage"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// MaxPhotoSize caps uploaded photos
+const MaxPhotoSize = 10 << 20
+
+// DefaultMediaGCGrace is how old an unreferenced blob must be before garbage
+// collection deletes it, so uploads in progress are never collected
+const DefaultMediaGCGrace = time.Hour
+
+// photoContentTypes are the accepted upload formats, which the image
+// service can also decode and resize
+var photoContentTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+// MediaGCReport summarizes one garbage collection run
+type MediaGCReport struct {
+	Blobs         int       `json:"blobs"`
+	Referenced    int       `json:"referenced"`
+	Deleted       []string  `json:"deleted"`
+	FreedBytes    int64     `json:"freed_bytes"`
+	DroppedPhotos int       `json:"dropped_photos"` // references to deleted coffees
+	RanAt         time.Time `json:"ran_at"`
+}
+
+// PhotoService attaches uploaded photos to coffees. Images are stored once
+// per content hash in media storage; blobs no coffee references any more
+// are removed by garbage collection.
+type PhotoService struct {
+	media         storage.MediaStorage
+	photos        storage.PhotoStorage
+	coffeeService *CoffeeService
+	quotas        *QuotaService // optional, caps the bytes of stored photos
+
+	// gcMu keeps garbage collection from deleting a blob between an upload
+	// storing it and the upload recording its reference
+	gcMu sync.RWMutex
+}
+
+// NewPhotoService creates a new photo service
+func NewPhotoService(media storage.MediaStorage, photos storage.PhotoStorage, coffeeService *CoffeeService) *PhotoService {
+	return &PhotoService{
+		media:         media,
+		photos:        photos,
+		coffeeService: coffeeService,
+	}
+}
+
+// SetQuotas refuses uploads that would go over the photo quota
+func (s *PhotoService) SetQuotas(quotas *QuotaService) {
+	s.quotas = quotas
+}
+
+// photoURL is where a photo is served by the image service
+func photoURL(hash string) string {
+	return "/images/photo-" + hash
+}
+
+// Upload stores a photo a user uploaded and attaches it to a coffee.
+// Uploading an image that is already stored reuses the existing blob.
+func (s *PhotoService) Upload(coffeeID, uploadedBy string, data []byte) (models.CoffeePhoto, error) {
+	if _, err := s.coffeeService.GetCoffee(coffeeID); err != nil {
+		return models.CoffeePhoto{}, err
+	}
+	if len(data) == 0 {
+		return models.CoffeePhoto{}, fmt.Errorf("invalid photo: empty upload")
+	}
+	if len(data) > MaxPhotoSize {
+		return models.CoffeePhoto{}, fmt.Errorf("invalid photo: larger than %d MB", MaxPhotoSize>>20)
+	}
+	contentType := http.DetectContentType(data)
+	if !photoContentTypes[contentType] {
+		return models.CoffeePhoto{}, fmt.Errorf("invalid photo: %s is not a JPEG, PNG or GIF image", contentType)
+	}
+	if err := checkPixels(bytes.NewReader(data)); err != nil {
+		return models.CoffeePhoto{}, fmt.Errorf("invalid photo: %w", err)
+	}
+	if s.quotas != nil {
+		if err := s.quotas.CheckPhoto(uploadedBy, storage.ContentHash(data), int64(len(data))); err != nil {
+			return models.CoffeePhoto{}, err
+		}
+	}
+
+	s.gcMu.RLock()
+	defer s.gcMu.RUnlock()
+
+	hash, err := s.media.Put(data)
+	if err != nil {
+		return models.CoffeePhoto{}, err
+	}
+	photo := models.CoffeePhoto{
+		CoffeeID:    coffeeID,
+		Hash:        hash,
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		UploadedBy:  uploadedBy,
+		CreatedAt:   time.Now(),
+	}
+	if err := s.photos.SavePhoto(photo); err != nil {
+		return models.CoffeePhoto{}, err
+	}
+	photo.URL = photoURL(hash)
+	return photo, nil
+}
+
+// ListPhotos returns the photos of a coffee, oldest first
+func (s *PhotoService) ListPhotos(coffeeID string) ([]models.CoffeePhoto, error) {
+	if _, err := s.coffeeService.GetCoffee(coffeeID); err != nil {
+		return nil, err
+	}
+	photos, err := s.photos.GetPhotos(coffeeID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range photos {
+		photos[i].URL = photoURL(photos[i].Hash)
+	}
+	return photos, nil
+}
+
+// RemovePhoto detaches a photo from a coffee. The blob stays until garbage
+// collection finds it unreferenced.
+func (s *PhotoService) RemovePhoto(coffeeID, hash string) error {
+	return s.photos.DeletePhoto(coffeeID, hash)
+}
+
+// Open serves stored photos to the image service, keyed by hash
+func (s *PhotoService) Open(hash string) (io.ReadCloser, time.Time, error) {
+	return s.media.Open(hash)
+}
+
+// CollectGarbage drops photo references to deleted coffees, then deletes
+// every blob no photo references that is older than grace
+func (s *PhotoService) CollectGarbage(grace time.Duration) (*MediaGCReport, error) {
+	s.gcMu.Lock()
+	defer s.gcMu.Unlock()
+
+	report := &MediaGCReport{Deleted: []string{}, RanAt: time.Now()}
+
+	coffees, err := s.coffeeService.ListCoffees()
+	if err != nil {
+		return nil, err
+	}
+	coffeeIDs := make(map[string]bool, len(coffees))
+	for _, coffee := range coffees {
+		coffeeIDs[coffee.ID] = true
+	}
+
+	photos, err := s.photos.GetAllPhotos()
+	if err != nil {
+		return nil, err
+	}
+	referenced := make(map[string]bool, len(photos))
+	for _, photo := range photos {
+		if !coffeeIDs[photo.CoffeeID] {
+			if err := s.photos.DeletePhoto(photo.CoffeeID, photo.Hash); err != nil {
+				return nil, err
+			}
+			report.DroppedPhotos++
+			continue
+		}
+		referenced[photo.Hash] = true
+	}
+
+	blobs, err := s.media.List()
+	if err != nil {
+		return nil, err
+	}
+	report.Blobs = len(blobs)
+	cutoff := time.Now().Add(-grace)
+	for _, blob := range blobs {
+		if referenced[blob.Hash] {
+			report.Referenced++
+			continue
+		}
+		if blob.ModTime.After(cutoff) {
+			continue
+		}
+		if err := s.media.Delete(blob.Hash); err != nil {
+			return nil, err
+		}
+		report.Deleted = append(report.Deleted, blob.Hash)
+		report.FreedBytes += blob.Size
+	}
+
+	if len(report.Deleted) > 0 || report.DroppedPhotos > 0 {
+		log.Printf("INFO: Media garbage collection deleted %d blobs (%d bytes) and %d stale photo references",
+			len(report.Deleted), report.FreedBytes, report.DroppedPhotos)
+	}
+	return report, nil
+}
+
@@ -0,0 +1,256 @@
+package service
+
+import (
+	"fmt"
+	"go-coffee-log/models"
+	"go-coffee-log/storage"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PlanService schedules brews, publishes a reminder event as each one comes
+// up, and turns a plan into a logged brew
+type PlanService struct {
+	storage       storage.PlanStorage
+	coffeeService *CoffeeService
+	brewService   *BrewService
+	bus           *EventBus
+
+	mu sync.Mutex // serializes reminder checks and brewing a plan
+}
+
+// NewPlanService creates a new plan service
+func NewPlanService(planStorage storage.PlanStorage, coffeeService *CoffeeService, brewService *BrewService, bus *EventBus) *PlanService {
+	return &PlanService{
+		storage:       planStorage,
+		coffeeService: coffeeService,
+		brewService:   brewService,
+		bus:           bus,
+	}
+}
+
+// SchedulePlan plans a brew of an existing coffee
+func (s *PlanService) SchedulePlan(plan models.PlannedBrew) (models.PlannedBrew, error) {
+	plan, coffee, err := s.newPlan(plan)
+	if err != nil {
+		return models.PlannedBrew{}, err
+	}
+	if err := s.storage.SavePlan(plan); err != nil {
+		return models.PlannedBrew{}, err
+	}
+
+	plan.CoffeeName = coffee.Name
+	return plan, nil
+}
+
+// PreviewSchedulePlan validates a plan like SchedulePlan, without saving it
+func (s *PlanService) PreviewSchedulePlan(plan models.PlannedBrew) (DryRun, error) {
+	plan, coffee, err := s.newPlan(plan)
+	if err != nil {
+		return DryRun{}, err
+	}
+	plan.CoffeeName = coffee.Name
+	return previewCreate(plan), nil
+}
+
+// newPlan validates a plan of a stored coffee and gives it its ID
+func (s *PlanService) newPlan(plan models.PlannedBrew) (models.PlannedBrew, models.Coffee, error) {
+	if err := plan.Validate(); err != nil {
+		return models.PlannedBrew{}, models.Coffee{}, err
+	}
+	coffee, err := s.coffeeService.GetCoffee(plan.CoffeeID)
+	if err != nil {
+		return models.PlannedBrew{}, models.Coffee{}, err
+	}
+
+	plan.ID = uuid.New().String()
+	plan.CoffeeName = ""
+	plan.RemindedAt = nil
+	plan.BrewID = ""
+	plan.CreatedAt = time.Now()
+	return plan, coffee, nil
+}
+
+// ListPlans returns plans soonest first. Brewed plans are left out unless
+// includeBrewed is set, as are plans whose coffee has since been deleted.
+func (s *PlanService) ListPlans(includeBrewed bool) ([]models.PlannedBrew, error) {
+	plans, err := s.storage.GetAllPlans()
+	if err != nil {
+		return nil, err
+	}
+	names, err := s.coffeeNames()
+	if err != nil {
+		return nil, err
+	}
+
+	kept := []models.PlannedBrew{}
+	for _, plan := range plans {
+		name, ok := names[plan.CoffeeID]
+		if !ok || plan.Brewed() && !includeBrewed {
+			continue
+		}
+		plan.CoffeeName = name
+		kept = append(kept, plan)
+	}
+	return kept, nil
+}
+
+// GetPlan retrieves a plan by ID
+func (s *PlanService) GetPlan(id string) (models.PlannedBrew, error) {
+	plan, err := s.storage.GetPlan(id)
+	if err != nil {
+		return models.PlannedBrew{}, err
+	}
+	coffee, err := s.coffeeService.GetCoffee(plan.CoffeeID)
+	if err != nil {
+		return models.PlannedBrew{}, fmt.Errorf("plan not found")
+	}
+	plan.CoffeeName = coffee.Name
+	return plan, nil
+}
+
+// CancelPlan deletes a plan
+func (s *PlanService) CancelPlan(id string) error {
+	return s.storage.DeletePlan(id)
+}
+
+// PreviewCancelPlan reports the plan CancelPlan would delete
+func (s *PlanService) PreviewCancelPlan(id string) (DryRun, error) {
+	plan, err := s.storage.GetPlan(id)
+	if err != nil {
+		return DryRun{}, err
+	}
+	return previewDelete(plan), nil
+}
+
+// BrewPlan logs the planned brew with the results in brew and links it to
+// the plan. The plan's notes are used when the brew has none.
+func (s *PlanService) BrewPlan(id string, brew models.Brew) (models.PlannedBrew, models.Brew, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	plan, brew, err := s.planBrew(id, brew)
+	if err != nil {
+		return models.PlannedBrew{}, models.Brew{}, err
+	}
+	logged, err := s.brewService.LogBrew(plan.CoffeeID, brew)
+	if err != nil {
+		return models.PlannedBrew{}, models.Brew{}, err
+	}
+
+	plan.BrewID = logged.ID
+	if err := s.storage.UpdatePlan(plan); err != nil {
+		return models.PlannedBrew{}, models.Brew{}, fmt.Errorf("brew %s was logged but the plan was not updated: %w", logged.ID, err)
+	}
+	return plan, logged, nil
+}
+
+// PreviewBrewPlan validates the brew like BrewPlan and reports the brew it
+// would log and the plan it would link it to, without saving either
+func (s *PlanService) PreviewBrewPlan(id string, brew models.Brew) (DryRun, error) {
+	previous, brew, err := s.planBrew(id, brew)
+	if err != nil {
+		return DryRun{}, err
+	}
+	preview, err := s.brewService.PreviewLogBrew(previous.CoffeeID, brew)
+	if err != nil {
+		return DryRun{}, err
+	}
+	logged := preview.Result.(models.Brew)
+	plan := previous
+	plan.BrewID = logged.ID
+	return DryRun{
+		DryRun:   true,
+		Action:   DryRunCreate,
+		Result:   map[string]interface{}{"plan": plan, "brew": logged},
+		Previous: previous,
+	}, nil
+}
+
+// planBrew returns a plan that is still to be brewed and the brew to log
+// for it
+func (s *PlanService) planBrew(id string, brew models.Brew) (models.PlannedBrew, models.Brew, error) {
+	plan, err := s.GetPlan(id)
+	if err != nil {
+		return models.PlannedBrew{}, models.Brew{}, err
+	}
+	if plan.Brewed() {
+		return models.PlannedBrew{}, models.Brew{}, fmt.Errorf("plan was already brewed as %s", plan.BrewID)
+	}
+
+	if strings.TrimSpace(brew.Notes) == "" {
+		brew.Notes = plan.Notes
+	}
+	return plan, brew, nil
+}
+
+// Check publishes a "plan.reminder" event for every plan whose reminder is
+// due and records that it was sent
+func (s *PlanService) Check(now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	plans, err := s.ListPlans(false)
+	if err != nil {
+		return err
+	}
+
+	for _, plan := range plans {
+		if !plan.ReminderDue(now) {
+			continue
+		}
+		remindedAt := now
+		plan.RemindedAt = &remindedAt
+		if err := s.storage.UpdatePlan(plan); err != nil {
+			return err
+		}
+
+		s.bus.Publish(Event{
+			Type:     "plan.reminder",
+			Message:  planReminderMessage(plan, now),
+			CoffeeID: plan.CoffeeID,
+			Data: map[string]interface{}{
+				"plan_id":       plan.ID,
+				"scheduled_for": plan.ScheduledFor,
+				"dripper":       plan.Dripper,
+				"notes":         plan.Notes,
+			},
+		})
+	}
+	return nil
+}
+
+// coffeeNames maps coffee IDs to names
+func (s *PlanService) coffeeNames() (map[string]string, error) {
+	coffees, err := s.coffeeService.ListCoffees()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get coffees: %w", err)
+	}
+	names := make(map[string]string, len(coffees))
+	for _, coffee := range coffees {
+		names[coffee.ID] = coffee.Name
+	}
+	return names, nil
+}
+
+// planReminderMessage renders a reminder such as "time to brew Kenya AA on
+// the Chemex in 45 minutes"
+func planReminderMessage(plan models.PlannedBrew, now time.Time) string {
+	subject := plan.CoffeeName
+	if plan.Dripper != "" {
+		subject += " on the " + plan.Dripper
+	}
+
+	until := plan.ScheduledFor.Sub(now).Round(time.Minute)
+	switch {
+	case until <= 0:
+		return fmt.Sprintf("time to brew %s", subject)
+	case until < time.Hour:
+		return fmt.Sprintf("time to brew %s in %d minutes", subject, int(until.Minutes()))
+	default:
+		return fmt.Sprintf("brew %s planned for %s", subject, plan.ScheduledFor.Local().Format("Mon Jan 2 15:04"))
+	}
+}
@@ -1,10 +1,13 @@
 package service
 
 import (
+	"context"
 	"fmt"
 	"go-coffee-log/models"
 	"go-coffee-log/storage"
+	"go-coffee-log/typechart"
 	"log"
+	"sort"
 	"strings"
 	"time"
 
@@ -17,6 +20,12 @@ type PokemonService struct {
 	coffeeService *CoffeeService
 	llmService   *LLMService
 	mapper       *PokemonMapper
+	dexes        *DexService
+	live         *LiveHub // optional, pushes catch changes to live clients
+
+	// Consensus mode (optional)
+	consensusLLMs    []*LLMService
+	consensusStorage storage.ConsensusStorage
 }
 
 // NewPokemonService creates a new Pokemon service
@@ -24,23 +33,224 @@ func NewPokemonService(
 	pokemonStorage storage.PokemonStorage,
 	coffeeService *CoffeeService,
 	llmService *LLMService,
+	dexes *DexService,
 ) *PokemonService {
 	return &PokemonService{
 		storage:      pokemonStorage,
 		coffeeService: coffeeService,
 		llmService:   llmService,
 		mapper:       NewPokemonMapper(),
+		dexes:        dexes,
+	}
+}
+
+// SetLiveHub pushes every new, replaced or renamed catch to live clients
+func (s *PokemonService) SetLiveHub(live *LiveHub) {
+	s.live = live
+}
+
+// SetMapperRules replaces the rule-based mapper's overrides; types left out
+// use the built-in rules
+func (s *PokemonService) SetMapperRules(overrides map[string]MapperRuleOverride) error {
+	return s.mapper.ApplyOverrides(overrides)
+}
+
+// SetBaseRules replaces the rules the mapper overrides apply to, e.g. with
+// an imported rule pack named pack (name@version, recorded in catches);
+// nil restores the built-in rules
+func (s *PokemonService) SetBaseRules(pack string, rules map[string]TypeMappingRule) error {
+	return s.mapper.SetBaseRules(pack, rules)
+}
+
+// MapperRules returns the rules the mapper currently uses, overrides
+// included. The map must not be modified.
+func (s *PokemonService) MapperRules() map[string]TypeMappingRule {
+	return s.mapper.rules()
+}
+
+// Mapper returns the rule-based mapper with the active rule pack and
+// overrides applied
+func (s *PokemonService) Mapper() *PokemonMapper {
+	return s.mapper
+}
+
+// dexID resolves a dex profile ID, where "" means the default profile
+func (s *PokemonService) dexID(id string) (string, error) {
+	profile, err := s.dexes.Profile(id)
+	if err != nil {
+		return "", err
+	}
+	return profile.ID, nil
+}
+
+// rulesOnlyKey marks a context whose Pokemon mappings skip the LLM
+type rulesOnlyKey struct{}
+
+// WithRulesOnly returns a context whose Pokemon mappings use only the
+// rule-based mapper, for deterministic results, even when an LLM is set up
+func WithRulesOnly(ctx context.Context) context.Context {
+	return context.WithValue(ctx, rulesOnlyKey{}, true)
+}
+
+// rulesOnly reports whether a context asks to skip the LLM
+func rulesOnly(ctx context.Context) bool {
+	skip, _ := ctx.Value(rulesOnlyKey{}).(bool)
+	return skip
+}
+
+// catchMode is how selectPokemon treats a coffee already caught in the dex
+type catchMode int
+
+const (
+	catchNew         catchMode = iota // refuse it
+	catchReplace                      // catch again; the current Pokemon may be picked
+	catchReroll                       // like catchReplace, but the coffee must be caught
+	catchRerollFresh                  // like catchReroll, but never the current Pokemon
+)
+
+// rerollMode picks the catch mode of a reroll
+func rerollMode(excludeCurrent bool) catchMode {
+	if excludeCurrent {
+		return catchRerollFresh
+	}
+	return catchReroll
+}
+
+// MapCoffeeToPokemon maps a coffee to a Pokemon of a dex profile ("" for
+// the default) using enhanced type system + LLM. A coffee is caught once
+// per dex; use ReplaceCoffeePokemon to catch again.
+func (s *PokemonService) MapCoffeeToPokemon(ctx context.Context, coffee models.Coffee, dexID string) (*models.CoffeePokemon, error) {
+	mapping, err := s.selectPokemon(ctx, coffee, dexID, catchNew)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.storeCatch(mapping, s.storage.ReservePokemon); err != nil {
+		return nil, fmt.Errorf("failed to create Pokemon mapping: %w", err)
+	}
+	s.live.Publish(ResourcePokemon, ChangeCreated, mapping.CoffeeID, mapping)
+	return mapping, nil
+}
+
+// maxCatchAttempts bounds how often storeCatch picks an alternative when
+// concurrent catches keep taking its Pokemon
+const maxCatchAttempts = 3
+
+// storeCatch records a mapping with store. If another coffee caught its
+// Pokemon since it was picked, the catch moves to a free alternative of the
+// same type, as ensureUniquePokemon would have picked, and is tried again.
+func (s *PokemonService) storeCatch(mapping *models.CoffeePokemon, store func(models.CoffeePokemon) error) error {
+	for attempt := 1; ; attempt++ {
+		err := store(*mapping)
+		if err == nil || attempt == maxCatchAttempts || !strings.Contains(err.Error(), "just caught by another coffee") {
+			return err
+		}
+		log.Printf("INFO: %v; picking an alternative (attempt %d of %d)", err, attempt+1, maxCatchAttempts)
+		
+		taken, err := s.storage.GetPokemonByID(mapping.PokemonID)
+		if err != nil {
+			return err
+		}
+		dex, err := s.dexes.Profile(mapping.Dex)
+		if err != nil {
+			return err
+		}
+		alternative, err := s.ensureUniquePokemon(dex, *taken, 0)
+		if err != nil {
+			return fmt.Errorf("no unique Pokemon available: %w", err)
+		}
+		mapping.PokemonID = alternative.ID
+		mapping.PokemonName = alternative.Name
+	}
+}
+
+// ReplaceCoffeePokemon maps a coffee again and swaps its catch in the dex
+// profile for the new one. The current Pokemon may be picked again.
+func (s *PokemonService) ReplaceCoffeePokemon(ctx context.Context, coffee models.Coffee, dexID string) (*models.CoffeePokemon, error) {
+	return s.recatch(ctx, coffee, dexID, catchReplace)
+}
+
+// RerollCoffeePokemon maps a caught coffee again and swaps its catch in the
+// dex profile for the new one. With excludeCurrent the Pokemon it holds now
+// is not picked again.
+func (s *PokemonService) RerollCoffeePokemon(ctx context.Context, coffee models.Coffee, dexID string, excludeCurrent bool) (*models.CoffeePokemon, error) {
+	return s.recatch(ctx, coffee, dexID, rerollMode(excludeCurrent))
+}
+
+// recatch selects a Pokemon in replace or reroll mode and swaps it in
+func (s *PokemonService) recatch(ctx context.Context, coffee models.Coffee, dexID string, mode catchMode) (*models.CoffeePokemon, error) {
+	mapping, err := s.selectPokemon(ctx, coffee, dexID, mode)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.storeCatch(mapping, s.storage.ReplaceCoffeePokemon); err != nil {
+		return nil, fmt.Errorf("failed to replace Pokemon mapping: %w", err)
+	}
+	log.Printf("INFO: Replaced the %s dex catch of coffee %s with %s", mapping.Dex, coffee.ID, mapping.PokemonName)
+	s.live.Publish(ResourcePokemon, ChangeUpdated, mapping.CoffeeID, mapping)
+	return mapping, nil
+}
+
+// PreviewCoffeePokemon reports the Pokemon MapCoffeeToPokemon, or with
+// replace ReplaceCoffeePokemon, would catch, without recording the catch
+func (s *PokemonService) PreviewCoffeePokemon(ctx context.Context, coffee models.Coffee, dexID string, replace bool) (*models.CoffeePokemon, error) {
+	mode := catchNew
+	if replace {
+		mode = catchReplace
 	}
+	return s.previewCatch(ctx, coffee, dexID, mode)
 }
 
-// MapCoffeeToPokemon maps a coffee to a Pokemon using enhanced type system + LLM
-func (s *PokemonService) MapCoffeeToPokemon(coffee models.Coffee) (*models.CoffeePokemon, error) {
+// PreviewReroll reports the Pokemon RerollCoffeePokemon would catch, without
+// recording the catch
+func (s *PokemonService) PreviewReroll(ctx context.Context, coffee models.Coffee, dexID string, excludeCurrent bool) (*models.CoffeePokemon, error) {
+	return s.previewCatch(ctx, coffee, dexID, rerollMode(excludeCurrent))
+}
+
+// previewCatch selects a Pokemon without recording the catch
+func (s *PokemonService) previewCatch(ctx context.Context, coffee models.Coffee, dexID string, mode catchMode) (*models.CoffeePokemon, error) {
+	mapping, err := s.selectPokemon(ctx, coffee, dexID, mode)
+	if err != nil {
+		return nil, err
+	}
+	mapping.ID = ""
+	return mapping, nil
+}
+
+// selectPokemon picks the Pokemon a coffee maps to in a dex profile. A
+// coffee already caught in the dex is refused in catchNew mode, and one not
+// yet caught in the reroll modes, before any LLM call is made.
+func (s *PokemonService) selectPokemon(ctx context.Context, coffee models.Coffee, dexID string, mode catchMode) (*models.CoffeePokemon, error) {
+	dex, err := s.dexes.Profile(dexID)
+	if err != nil {
+		return nil, err
+	}
+	
+	// 0. One catch per coffee and dex
+	keep := 0    // the Pokemon the coffee holds now, which it may keep
+	exclude := 0 // the Pokemon the coffee holds now, when rerolling away from it
+	existing, err := s.storage.GetCoffeePokemon(coffee.ID, dex.ID)
+	switch {
+	case err == nil && mode == catchNew:
+		return nil, fmt.Errorf("coffee already has a Pokemon in the %s dex: %s", dex.ID, existing.PokemonName)
+	case err == nil && mode == catchRerollFresh:
+		exclude = existing.PokemonID
+	case err == nil:
+		keep = existing.PokemonID
+	case !strings.Contains(err.Error(), "not found"):
+		return nil, fmt.Errorf("failed to check existing Pokemon: %w", err)
+	case mode == catchReroll || mode == catchRerollFresh:
+		return nil, err
+	}
+	
 	// 1. Use enhanced mapper to determine Pokemon types
 	primaryType, secondaryType, typeScores := s.mapper.CalculatePokemonTypes(coffee)
 	log.Printf("Coffee types: primary=%s, secondary=%s, scores=%v", primaryType, secondaryType, typeScores)
 	
-	// 2. Get candidate Pokemon based on types
-	candidates := s.getTypedCandidates(primaryType, secondaryType)
+	// 2. Get candidate Pokemon of the dex based on types
+	candidates := s.getTypedCandidates(primaryType, secondaryType, typeScores, dex)
+	if exclude != 0 {
+		candidates = withoutPokemon(candidates, exclude)
+	}
 	if len(candidates) == 0 {
 		return nil, fmt.Errorf("no Pokemon candidates found for types %s/%s", primaryType, secondaryType)
 	}
@@ -50,10 +260,21 @@ func (s *PokemonService) MapCoffeeToPokemon(coffee models.Coffee) (*models.Coffe
 	var confidence float64
 	var description string
 	var traitMapping []models.TraitMapping
+	provenance := s.newProvenance(models.MappingMethodRules, candidates)
+	provenance.LLMOptOut = rulesOnly(ctx)
 
-	if s.llmService != nil {
+	if provenance.LLMOptOut {
+		selectedPokemon, confidence, description, traitMapping = s.getBestTypeMatch(coffee, candidates, primaryType, typeScores[primaryType])
+	} else if s.ConsensusEnabled() {
+		provenance.Method = models.MappingMethodConsensus
+		provenance.PromptVersion = MappingPromptVersion
+		for _, llm := range s.consensusLLMs {
+			provenance.Models = append(provenance.Models, llm.Model())
+		}
+		selectedPokemon, confidence, description, traitMapping = s.selectByConsensus(ctx, coffee, candidates, primaryType, typeScores[primaryType])
+	} else if s.llmService.Available() {
 		// Give LLM the type context to help it choose
-		llmResponse, err := s.llmService.MapCoffeeToPokemon(coffee, candidates)
+		llmResponse, err := s.llmService.MapCoffeeToPokemon(ctx, coffee, candidates)
 		if err != nil {
 			log.Printf("LLM mapping failed, using best type match: %v", err)
 			selectedPokemon, confidence, description, traitMapping = s.getBestTypeMatch(coffee, candidates, primaryType, typeScores[primaryType])
@@ -69,6 +290,9 @@ func (s *PokemonService) MapCoffeeToPokemon(coffee models.Coffee) (*models.Coffe
 				log.Printf("LLM selected unknown Pokemon: %s, using best type match", llmResponse.SelectedPokemon)
 				selectedPokemon, confidence, description, traitMapping = s.getBestTypeMatch(coffee, candidates, primaryType, typeScores[primaryType])
 			} else {
+				provenance.Method = models.MappingMethodLLM
+				provenance.Models = []string{llmResponse.Model}
+				provenance.PromptVersion = MappingPromptVersion
 				confidence = llmResponse.Confidence
 				description = llmResponse.Description
 				traitMapping = llmResponse.TraitMapping
@@ -78,21 +302,19 @@ func (s *PokemonService) MapCoffeeToPokemon(coffee models.Coffee) (*models.Coffe
 		selectedPokemon, confidence, description, traitMapping = s.getBestTypeMatch(coffee, candidates, primaryType, typeScores[primaryType])
 	}
 
-	// 4. Ensure uniqueness
-	finalPokemon, err := s.ensureUniquePokemon(coffee.ID, *selectedPokemon)
+	// 4. Ensure uniqueness within the dex
+	finalPokemon, err := s.ensureUniquePokemon(dex, *selectedPokemon, keep)
 	if err != nil {
 		return nil, fmt.Errorf("no unique Pokemon available: %w", err)
 	}
 
 	// 5. Create mapping with type info
-	typeDescription := s.mapper.GetTypeDescription(primaryType, coffee)
-	if secondaryType != "" {
-		typeDescription += fmt.Sprintf(" and %s", s.mapper.GetTypeDescription(secondaryType, coffee))
-	}
+	typeDescription := s.describeTypes(coffee, primaryType, secondaryType)
 	
 	mapping := &models.CoffeePokemon{
 		ID:                uuid.New().String(),
 		CoffeeID:          coffee.ID,
+		Dex:               dex.ID,
 		PokemonID:         finalPokemon.ID,
 		PokemonName:       finalPokemon.Name,
 		Nickname:          "",
@@ -100,17 +322,35 @@ func (s *PokemonService) MapCoffeeToPokemon(coffee models.Coffee) (*models.Coffe
 		MappingConfidence: confidence,
 		LLMDescription:    fmt.Sprintf("%s\n\nType Analysis: %s", description, typeDescription),
 		TraitMapping:      traitMapping,
+		Provenance:        provenance,
 		CreatedAt:         time.Now(),
 	}
+	return mapping, nil
+}
 
-	if err := s.storage.CreateCoffeePokemon(*mapping); err != nil {
-		return nil, fmt.Errorf("failed to create Pokemon mapping: %w", err)
+// newProvenance starts the provenance of a catch picked from candidates
+// with the mapper's current rules
+func (s *PokemonService) newProvenance(method string, candidates []models.Pokemon) *models.MappingProvenance {
+	pack, overridden := s.mapper.rulePack()
+	provenance := &models.MappingProvenance{Method: method, RulePack: pack, MapperOverrides: overridden}
+	for _, candidate := range candidates {
+		provenance.Candidates = append(provenance.Candidates, candidate.Name)
 	}
-	return mapping, nil
+	return provenance
+}
+
+// describeTypes builds the type analysis appended to Pokedex entries
+func (s *PokemonService) describeTypes(coffee models.Coffee, primaryType, secondaryType string) string {
+	typeDescription := s.mapper.GetTypeDescription(primaryType, coffee)
+	if secondaryType != "" {
+		typeDescription += fmt.Sprintf(" and %s", s.mapper.GetTypeDescription(secondaryType, coffee))
+	}
+	return typeDescription
 }
 
-// getTypedCandidates gets Pokemon candidates based on calculated types
-func (s *PokemonService) getTypedCandidates(primaryType, secondaryType string) []models.Pokemon {
+// getTypedCandidates gets Pokemon candidates of a dex based on calculated
+// types, best type combination first
+func (s *PokemonService) getTypedCandidates(primaryType, secondaryType string, typeScores map[string]float64, dex models.DexProfile) []models.Pokemon {
 	candidates := make([]models.Pokemon, 0)
 	
 	// Get Pokemon of primary type
@@ -118,7 +358,7 @@ func (s *PokemonService) getTypedCandidates(primaryType, secondaryType string) [
 	if err != nil {
 		log.Printf("Failed to get Pokemon by type %s: %v", primaryType, err)
 	} else {
-		candidates = append(candidates, primary...)
+		candidates = append(candidates, inDex(primary, dex)...)
 	}
 	
 	// Get Pokemon of secondary type if exists
@@ -127,7 +367,7 @@ func (s *PokemonService) getTypedCandidates(primaryType, secondaryType string) [
 		if err != nil {
 			log.Printf("Failed to get Pokemon by type %s: %v", secondaryType, err)
 		} else {
-			candidates = append(candidates, secondary...)
+			candidates = append(candidates, inDex(secondary, dex)...)
 		}
 	}
 	
@@ -135,10 +375,26 @@ func (s *PokemonService) getTypedCandidates(primaryType, secondaryType string) [
 	if len(candidates) == 0 {
 		normal, err := s.storage.GetPokemonByType("Normal")
 		if err == nil {
-			candidates = append(candidates, normal...)
+			candidates = append(candidates, inDex(normal, dex)...)
 		}
 	}
 	
+	// Small custom dexes may have none of those types
+	if len(candidates) == 0 {
+		all, err := s.storage.GetAllPokemon()
+		if err == nil {
+			candidates = append(candidates, inDex(all, dex)...)
+		}
+	}
+	
+	// A Pokemon of both types is found twice; rank the rest so dual-type
+	// Pokemon matching both come first
+	candidates = uniquePokemon(candidates)
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return typechart.CombinationScore(typeScores, typechart.Parse(candidates[i].Type)) >
+			typechart.CombinationScore(typeScores, typechart.Parse(candidates[j].Type))
+	})
+	
 	// Limit to 10 candidates for LLM
 	if len(candidates) > 10 {
 		candidates = candidates[:10]
@@ -147,6 +403,41 @@ func (s *PokemonService) getTypedCandidates(primaryType, secondaryType string) [
 	return candidates
 }
 
+// uniquePokemon drops repeated Pokemon, keeping the first of each
+func uniquePokemon(candidates []models.Pokemon) []models.Pokemon {
+	seen := make(map[int]bool, len(candidates))
+	unique := make([]models.Pokemon, 0, len(candidates))
+	for _, candidate := range candidates {
+		if !seen[candidate.ID] {
+			seen[candidate.ID] = true
+			unique = append(unique, candidate)
+		}
+	}
+	return unique
+}
+
+// withoutPokemon drops a Pokemon from candidates
+func withoutPokemon(candidates []models.Pokemon, pokemonID int) []models.Pokemon {
+	kept := make([]models.Pokemon, 0, len(candidates))
+	for _, candidate := range candidates {
+		if candidate.ID != pokemonID {
+			kept = append(kept, candidate)
+		}
+	}
+	return kept
+}
+
+// inDex keeps the Pokemon that belong to a dex profile
+func inDex(pokemon []models.Pokemon, dex models.DexProfile) []models.Pokemon {
+	members := make([]models.Pokemon, 0, len(pokemon))
+	for _, p := range pokemon {
+		if dex.Contains(p.ID) {
+			members = append(members, p)
+		}
+	}
+	return members
+}
+
 // getBestTypeMatch selects best Pokemon from candidates based on type score
 func (s *PokemonService) getBestTypeMatch(coffee models.Coffee, candidates []models.Pokemon, primaryType string, typeScore float64) (*models.Pokemon, float64, string, []models.TraitMapping) {
 	if len(candidates) == 0 {
@@ -215,9 +506,13 @@ func (s *PokemonService) buildTraitMapping(traits models.TastingTraits, pokemon
 }
 
 
-// ensureUniquePokemon ensures each Pokemon is unique
-func (s *PokemonService) ensureUniquePokemon(coffeeID string, pokemon models.Pokemon) (*models.Pokemon, error) {
-	used, err := s.storage.IsPokemonUsed(pokemon.ID)
+// ensureUniquePokemon ensures each Pokemon is caught once per dex; keep is
+// a Pokemon that counts as free because the coffee being mapped holds it
+func (s *PokemonService) ensureUniquePokemon(dex models.DexProfile, pokemon models.Pokemon, keep int) (*models.Pokemon, error) {
+	if pokemon.ID == keep {
+		return &pokemon, nil
+	}
+	used, err := s.storage.IsPokemonUsed(dex.ID, pokemon.ID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to check Pokemon usage: %w", err)
 	}
@@ -232,8 +527,11 @@ func (s *PokemonService) ensureUniquePokemon(coffeeID string, pokemon models.Pok
 		return nil, fmt.Errorf("failed to get alternative Pokemon: %w", err)
 	}
 
-	for _, alt := range alternatives {
-		altUsed, err := s.storage.IsPokemonUsed(alt.ID)
+	for _, alt := range inDex(alternatives, dex) {
+		if alt.ID == keep {
+			return &alt, nil
+		}
+		altUsed, err := s.storage.IsPokemonUsed(dex.ID, alt.ID)
 		if err != nil {
 			continue
 		}
@@ -278,19 +576,113 @@ func (s *PokemonService) calculateTraitVariance(traits models.TastingTraits) int
 	return variance / len(traitValues)
 }
 
-// GetCoffeePokemon gets Pokemon mapping for a specific coffee
-func (s *PokemonService) GetCoffeePokemon(coffeeID string) (*models.CoffeePokemon, error) {
-	return s.storage.GetCoffeePokemon(coffeeID)
+// GetCoffeePokemon gets the Pokemon mapping for a specific coffee in a dex
+// profile ("" for the default)
+func (s *PokemonService) GetCoffeePokemon(coffeeID, dexID string) (*models.CoffeePokemon, error) {
+	dexID, err := s.dexID(dexID)
+	if err != nil {
+		return nil, err
+	}
+	return s.storage.GetCoffeePokemon(coffeeID, dexID)
 }
 
-// GetAllCoffeePokemon gets all coffee-Pokemon mappings
+// GetAllCoffeePokemon gets the coffee-Pokemon mappings of every dex profile
 func (s *PokemonService) GetAllCoffeePokemon() ([]models.CoffeePokemon, error) {
 	return s.storage.GetAllCoffeePokemon()
 }
 
+// GetDexEntries gets the coffee-Pokemon mappings of one dex profile ("" for
+// the default)
+func (s *PokemonService) GetDexEntries(dexID string) ([]models.CoffeePokemon, error) {
+	dexID, err := s.dexID(dexID)
+	if err != nil {
+		return nil, err
+	}
+	mappings, err := s.storage.GetAllCoffeePokemon()
+	if err != nil {
+		return nil, err
+	}
+	return filterDex(mappings, dexID), nil
+}
+
 // UpdateNickname updates Pokemon nickname
-func (s *PokemonService) UpdateNickname(coffeeID, nickname string) error {
-	return s.storage.UpdateCoffeePokemonNickname(coffeeID, nickname)
+func (s *PokemonService) UpdateNickname(coffeeID, dexID, nickname string) error {
+	dexID, err := s.dexID(dexID)
+	if err != nil {
+		return err
+	}
+	if err := s.storage.UpdateCoffeePokemonNickname(coffeeID, dexID, nickname); err != nil {
+		return err
+	}
+	s.publishCatch(coffeeID, dexID)
+	return nil
+}
+
+// publishCatch pushes a coffee's catch in a dex profile, as stored, to
+// live clients
+func (s *PokemonService) publishCatch(coffeeID, dexID string) {
+	if s.live == nil {
+		return
+	}
+	mapping, err := s.storage.GetCoffeePokemon(coffeeID, dexID)
+	if err != nil {
+		log.Printf("WARNING: Failed to load the %s dex catch of coffee %s for live clients: %v", dexID, coffeeID, err)
+		return
+	}
+	s.live.Publish(ResourcePokemon, ChangeUpdated, coffeeID, mapping)
+}
+
+// RegenerateDescription asks the LLM for a new Pokedex entry for an existing
+// mapping and saves it. Fragments are passed to onChunk while the model writes.
+func (s *PokemonService) RegenerateDescription(ctx context.Context, coffeeID, dexID string, onChunk func(string) error) (*models.CoffeePokemon, error) {
+	if !s.llmService.Available() {
+		return nil, fmt.Errorf("LLM service is not available")
+	}
+	
+	dexID, err := s.dexID(dexID)
+	if err != nil {
+		return nil, err
+	}
+	mapping, err := s.storage.GetCoffeePokemon(coffeeID, dexID)
+	if err != nil {
+		return nil, err
+	}
+	
+	coffee, err := s.coffeeService.GetCoffee(coffeeID)
+	if err != nil {
+		return nil, err
+	}
+	
+	pokemon, err := s.storage.GetPokemonByID(mapping.PokemonID)
+	if err != nil {
+		return nil, err
+	}
+	
+	description, err := s.llmService.GenerateDescription(ctx, coffee, *pokemon, onChunk)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate description: %w", err)
+	}
+	
+	// Keep the type analysis suffix used by freshly generated mappings
+	primaryType, secondaryType, _ := s.mapper.CalculatePokemonTypes(coffee)
+	typeDescription := s.describeTypes(coffee, primaryType, secondaryType)
+	mapping.LLMDescription = fmt.Sprintf("%s\n\nType Analysis: %s", description, typeDescription)
+	
+	if err := s.storage.UpdateCoffeePokemonDescription(coffeeID, dexID, mapping.LLMDescription); err != nil {
+		return nil, err
+	}
+	s.live.Publish(ResourcePokemon, ChangeUpdated, coffeeID, mapping)
+	
+	return mapping, nil
+}
+
+// GetDatasetVersion returns the version of the loaded Pokemon reference data
+func (s *PokemonService) GetDatasetVersion() (PokemonDatasetVersion, error) {
+	pokemon, err := s.storage.GetAllPokemon()
+	if err != nil {
+		return PokemonDatasetVersion{}, err
+	}
+	return DatasetVersion(pokemon), nil
 }
 
 // InitializePokemonData checks if Pokemon data exists in database
@@ -298,7 +690,8 @@ func (s *PokemonService) InitializePokemonData() error {
 	// Check if Pokemon data already exists
 	existing, err := s.storage.GetAllPokemon()
 	if err == nil && len(existing) > 0 {
-		log.Printf("Pokemon data already loaded: %d Pokemon in database", len(existing))
+		version := DatasetVersion(existing)
+		log.Printf("Pokemon data already loaded: %d Pokemon in database (%s)", len(existing), version.Checksum)
 		return nil
 	}
 
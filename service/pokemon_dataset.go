@@ -0,0 +1,372 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"go-coffee-log/models"
+	"go-coffee-log/storage"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// gen1Count is the number of Pokemon in the Kanto dex, the reference
+// dataset's minimum
+const gen1Count = 151
+
+// pokeAPIWorkers bounds concurrent requests to PokeAPI
+const pokeAPIWorkers = 8
+
+// PokemonDatasetVersion identifies the contents of a Pokemon dataset
+type PokemonDatasetVersion struct {
+	Count    int    `json:"count"`
+	Checksum string `json:"checksum"` // SHA-256 of the normalized dataset
+}
+
+// DatasetVersion computes the version of a dataset. Sprite paths point at
+// local files and are left out, so only the reference data itself counts.
+func DatasetVersion(pokemon []models.Pokemon) PokemonDatasetVersion {
+	sorted := append([]models.Pokemon(nil), pokemon...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	hash := sha256.New()
+	encoder := json.NewEncoder(hash)
+	for _, p := range sorted {
+		p.SpritePath = ""
+		encoder.Encode(p)
+	}
+	return PokemonDatasetVersion{
+		Count:    len(sorted),
+		Checksum: "sha256:" + hex.EncodeToString(hash.Sum(nil)),
+	}
+}
+
+// FieldChange is the old and new value of one changed field
+type FieldChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// PokemonChange lists the fields of one Pokemon a re-sync would change
+type PokemonChange struct {
+	ID     int                    `json:"id"`
+	Name   string                 `json:"name"`
+	Fields map[string]FieldChange `json:"fields"`
+}
+
+// PokemonDatasetDiff is what a re-sync from PokeAPI would change
+type PokemonDatasetDiff struct {
+	Current   PokemonDatasetVersion `json:"current"`
+	Remote    PokemonDatasetVersion `json:"remote"`
+	Added     []models.Pokemon      `json:"added"`
+	Changed   []PokemonChange       `json:"changed"`
+	Unchanged int                   `json:"unchanged"`
+}
+
+// PokemonSyncService compares the stored Pokemon reference data with
+// PokeAPI and applies a re-sync on request
+type PokemonSyncService struct {
+	storage storage.PokemonStorage
+	baseURL string
+	client  *http.Client
+	dexes   *DexService // optional, widens the dataset to every dex profile's members
+}
+
+// NewPokemonSyncService creates a sync service for the PokeAPI at baseURL,
+// e.g. "https://pokeapi.co/api/v2"
+func NewPokemonSyncService(pokemonStorage storage.PokemonStorage, baseURL string) *PokemonSyncService {
+	return &PokemonSyncService{
+		storage: pokemonStorage,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// SetDexService syncs the members of every dex profile rather than only
+// the Kanto 151
+func (s *PokemonSyncService) SetDexService(dexes *DexService) {
+	s.dexes = dexes
+}
+
+// pokemonIDs returns the National Dex numbers the dataset should hold
+func (s *PokemonSyncService) pokemonIDs() ([]int, error) {
+	if s.dexes == nil {
+		return dexRange(1, gen1Count), nil
+	}
+	return s.dexes.PokemonIDs()
+}
+
+// Diff fetches the dataset from PokeAPI and compares it with the stored one
+// without changing anything
+func (s *PokemonSyncService) Diff(ctx context.Context) (*PokemonDatasetDiff, error) {
+	current, err := s.storage.GetAllPokemon()
+	if err != nil {
+		return nil, err
+	}
+	remote, err := s.fetchAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return diffDatasets(current, remote), nil
+}
+
+// Apply re-syncs the stored dataset from PokeAPI. When expectedChecksum is
+// set, the remote data must still match the diff the caller reviewed.
+func (s *PokemonSyncService) Apply(ctx context.Context, expectedChecksum string) (*PokemonDatasetDiff, error) {
+	current, err := s.storage.GetAllPokemon()
+	if err != nil {
+		return nil, err
+	}
+	remote, err := s.fetchAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := diffDatasets(current, remote)
+	if expectedChecksum != "" && diff.Remote.Checksum != expectedChecksum {
+		return nil, fmt.Errorf("remote dataset changed since the diff: now %s", diff.Remote.Checksum)
+	}
+	if len(diff.Added) == 0 && len(diff.Changed) == 0 {
+		return diff, nil
+	}
+
+	// Keep the sprite files already on disk for existing Pokemon
+	sprites := make(map[int]string, len(current))
+	for _, p := range current {
+		sprites[p.ID] = p.SpritePath
+	}
+	for i := range remote {
+		if path, ok := sprites[remote[i].ID]; ok {
+			remote[i].SpritePath = path
+		}
+	}
+
+	if err := s.storage.UpsertPokemon(remote); err != nil {
+		return nil, err
+	}
+	log.Printf("INFO: Re-synced Pokemon data from PokeAPI: %d added, %d changed (%s)",
+		len(diff.Added), len(diff.Changed), diff.Remote.Checksum)
+	return diff, nil
+}
+
+// diffDatasets compares the stored dataset with the remote one
+func diffDatasets(current, remote []models.Pokemon) *PokemonDatasetDiff {
+	diff := &PokemonDatasetDiff{
+		Current: DatasetVersion(current),
+		Remote:  DatasetVersion(remote),
+		Added:   []models.Pokemon{},
+		Changed: []PokemonChange{},
+	}
+
+	byID := make(map[int]models.Pokemon, len(current))
+	for _, p := range current {
+		byID[p.ID] = p
+	}
+
+	for _, r := range remote {
+		c, ok := byID[r.ID]
+		if !ok {
+			diff.Added = append(diff.Added, r)
+			continue
+		}
+
+		fields := make(map[string]FieldChange)
+		if c.Name != r.Name {
+			fields["name"] = FieldChange{From: c.Name, To: r.Name}
+		}
+		if c.Type != r.Type {
+			fields["type"] = FieldChange{From: c.Type, To: r.Type}
+		}
+		if c.BaseStats != r.BaseStats {
+			fields["base_stats"] = FieldChange{From: c.BaseStats, To: r.BaseStats}
+		}
+		if c.Description != r.Description {
+			fields["description"] = FieldChange{From: c.Description, To: r.Description}
+		}
+
+		if len(fields) == 0 {
+			diff.Unchanged++
+		} else {
+			diff.Changed = append(diff.Changed, PokemonChange{ID: r.ID, Name: c.Name, Fields: fields})
+		}
+	}
+	return diff
+}
+
+// fetchAll downloads the Pokemon of every dex profile from PokeAPI,
+// ordered by ID
+func (s *PokemonSyncService) fetchAll(ctx context.Context) ([]models.Pokemon, error) {
+	wanted, err := s.pokemonIDs()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ids := make(chan int)
+	results := make([]models.Pokemon, len(wanted))
+	var wg sync.WaitGroup
+	var errOnce sync.Once
+	var fetchErr error
+
+	for w := 0; w < pokeAPIWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range ids {
+				pokemon, err := s.fetchPokemon(ctx, wanted[i])
+				if err != nil {
+					errOnce.Do(func() {
+						fetchErr = err
+						cancel()
+					})
+					continue
+				}
+				results[i] = pokemon
+			}
+		}()
+	}
+
+feed:
+	for i := range wanted {
+		select {
+		case ids <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(ids)
+	wg.Wait()
+
+	if fetchErr != nil {
+		return nil, fetchErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// pokeAPIPokemon is the subset of /pokemon/{id} the dataset uses
+type pokeAPIPokemon struct {
+	Name  string `json:"name"`
+	Types []struct {
+		Slot int `json:"slot"`
+		Type struct {
+			Name string `json:"name"`
+		} `json:"type"`
+	} `json:"types"`
+	Stats []struct {
+		BaseStat int `json:"base_stat"`
+		Stat     struct {
+			Name string `json:"name"`
+		} `json:"stat"`
+	} `json:"stats"`
+}
+
+// pokeAPISpecies is the subset of /pokemon-species/{id} the dataset uses
+type pokeAPISpecies struct {
+	Names []struct {
+		Name     string `json:"name"`
+		Language struct {
+			Name string `json:"name"`
+		} `json:"language"`
+	} `json:"names"`
+	FlavorTextEntries []struct {
+		FlavorText string `json:"flavor_text"`
+		Language   struct {
+			Name string `json:"name"`
+		} `json:"language"`
+		Version struct {
+			Name string `json:"name"`
+		} `json:"version"`
+	} `json:"flavor_text_entries"`
+}
+
+// fetchPokemon downloads one Pokemon and converts it to the dataset's
+// conventions: "/"-joined capitalized types, Gen 1 "special" taken from
+// special attack, and the English Red/Blue Pokedex entry where there is one
+func (s *PokemonSyncService) fetchPokemon(ctx context.Context, id int) (models.Pokemon, error) {
+	var apiPokemon pokeAPIPokemon
+	if err := s.getJSON(ctx, fmt.Sprintf("%s/pokemon/%d", s.baseURL, id), &apiPokemon); err != nil {
+		return models.Pokemon{}, err
+	}
+	var species pokeAPISpecies
+	if err := s.getJSON(ctx, fmt.Sprintf("%s/pokemon-species/%d", s.baseURL, id), &species); err != nil {
+		return models.Pokemon{}, err
+	}
+
+	pokemon := models.Pokemon{
+		ID:         id,
+		Name:       capitalize(apiPokemon.Name),
+		SpritePath: fmt.Sprintf("/sprites/%03d-%s.png", id, apiPokemon.Name),
+	}
+	for _, name := range species.Names {
+		if name.Language.Name == "en" {
+			pokemon.Name = name.Name
+			break
+		}
+	}
+
+	sort.Slice(apiPokemon.Types, func(i, j int) bool { return apiPokemon.Types[i].Slot < apiPokemon.Types[j].Slot })
+	var types []string
+	for _, t := range apiPokemon.Types {
+		types = append(types, capitalize(t.Type.Name))
+	}
+	pokemon.Type = strings.Join(types, "/")
+
+	for _, stat := range apiPokemon.Stats {
+		switch stat.Stat.Name {
+		case "hp":
+			pokemon.BaseStats.HP = stat.BaseStat
+		case "attack":
+			pokemon.BaseStats.Attack = stat.BaseStat
+		case "defense":
+			pokemon.BaseStats.Defense = stat.BaseStat
+		case "speed":
+			pokemon.BaseStats.Speed = stat.BaseStat
+		case "special-attack":
+			pokemon.BaseStats.Special = stat.BaseStat
+		}
+	}
+
+	// Later generations have no Red/Blue entry, so fall back to their
+	// first English one
+	for _, version := range []string{"red", "blue", ""} {
+		for _, entry := range species.FlavorTextEntries {
+			if entry.Language.Name == "en" && (version == "" || entry.Version.Name == version) && pokemon.Description == "" {
+				pokemon.Description = strings.Join(strings.Fields(entry.FlavorText), " ")
+			}
+		}
+	}
+
+	return pokemon, nil
+}
+
+// getJSON fetches url and decodes the JSON response into v
+func (s *PokemonSyncService) getJSON(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("PokeAPI request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("PokeAPI request failed: %s returned %s", url, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("PokeAPI request failed: invalid response from %s: %w", url, err)
+	}
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"math"
 	"sort"
 	"strings"
+	"sync"
 
 	"go-coffee-log/models"
 )
@@ -25,6 +26,11 @@ type TypeScore struct {
 // PokemonMapper handles the sophisticated mapping of coffee to Pokemon types
 type PokemonMapper struct {
 	typeRules map[string]TypeMappingRule
+	mu        sync.RWMutex // guards swapping typeRules; the map itself is never modified once in use
+
+	base      map[string]TypeMappingRule // the built-in rules or the active rule pack
+	pack      string                     // names the base rules as name@version
+	overrides map[string]MapperRuleOverride
 }
 
 // TypeMappingRule defines how a Pokemon type is determined
@@ -46,15 +52,158 @@ type TraitWeight struct {
 	Max    int // Maximum value for optimal score
 }
 
+// mapperTraits are the tasting traits type rules can weigh
+var mapperTraits = map[string]bool{
+	"berry_intensity": true, "stonefruit_intensity": true, "roast_intensity": true,
+	"citrus_fruits_intensity": true, "bitterness": true, "florality": true, "spice": true,
+	"sweetness": true, "aromatic_intensity": true, "savory": true, "body": true, "cleanliness": true,
+}
+
 // NewPokemonMapper creates a new Pokemon mapper with all type rules
 func NewPokemonMapper() *PokemonMapper {
 	mapper := &PokemonMapper{
 		typeRules: make(map[string]TypeMappingRule),
 	}
 	mapper.initializeTypeRules()
+	mapper.base = mapper.typeRules
+	mapper.pack = builtinRulePackLabel
 	return mapper
 }
 
+// MapperRuleOverride tunes one built-in type rule
+type MapperRuleOverride struct {
+	MinimumThreshold *float64 `json:"minimum_threshold,omitempty"`
+	Keywords         []string `json:"keywords,omitempty"` // replaces the rule's tasting-note keywords
+}
+
+// ApplyOverrides rebuilds the type rules from the base rules (the built-in
+// defaults or the active rule pack) with overrides applied. Types left out
+// revert to their base rule.
+func (pm *PokemonMapper) ApplyOverrides(overrides map[string]MapperRuleOverride) error {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	typeRules, err := applyOverrides(pm.base, overrides)
+	if err != nil {
+		return err
+	}
+	pm.typeRules = typeRules
+	pm.overrides = overrides
+	return nil
+}
+
+// SetBaseRules replaces the rules overrides are applied to, e.g. with an
+// imported rule pack named pack. Types left out use the built-in rule; nil
+// restores the built-in rules entirely.
+func (pm *PokemonMapper) SetBaseRules(pack string, rules map[string]TypeMappingRule) error {
+	if err := CheckTypeRules(rules); err != nil {
+		return err
+	}
+	base := NewPokemonMapper().typeRules
+	for typeName, rule := range rules {
+		rule.Type = typeName
+		base[typeName] = rule
+	}
+
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	typeRules, err := applyOverrides(base, pm.overrides)
+	if err != nil {
+		return err
+	}
+	pm.base = base
+	pm.pack = pack
+	if rules == nil {
+		pm.pack = builtinRulePackLabel
+	}
+	pm.typeRules = typeRules
+	return nil
+}
+
+// baseRules returns the rules overrides are applied to and their pack
+// label. The map must not be modified.
+func (pm *PokemonMapper) baseRules() (string, map[string]TypeMappingRule) {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+	return pm.pack, pm.base
+}
+
+// withBaseRules returns a new mapper with rules as its base rules and the
+// same overrides, for previewing rules without switching to them
+func (pm *PokemonMapper) withBaseRules(pack string, rules map[string]TypeMappingRule) (*PokemonMapper, error) {
+	pm.mu.RLock()
+	overrides := pm.overrides
+	pm.mu.RUnlock()
+
+	preview := NewPokemonMapper()
+	preview.overrides = overrides
+	if err := preview.SetBaseRules(pack, rules); err != nil {
+		return nil, err
+	}
+	return preview, nil
+}
+
+// rulePack names the rules in use for mapping provenance, and reports
+// whether config overrides are applied on top of them
+func (pm *PokemonMapper) rulePack() (string, bool) {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+	return pm.pack, len(pm.overrides) > 0
+}
+
+// CheckTypeRules checks that rules only name known Pokemon types and traits
+func CheckTypeRules(rules map[string]TypeMappingRule) error {
+	builtin := NewPokemonMapper().typeRules
+	for typeName, rule := range rules {
+		if _, ok := builtin[typeName]; !ok {
+			return fmt.Errorf("unknown Pokemon type %q in rule pack", typeName)
+		}
+		for _, tw := range append(append([]TraitWeight{}, rule.PrimaryTraits...), rule.SecondaryTraits...) {
+			if !mapperTraits[tw.Trait] {
+				return fmt.Errorf("unknown trait %q in rule pack rule %s", tw.Trait, typeName)
+			}
+		}
+	}
+	return nil
+}
+
+// applyOverrides returns a copy of base with overrides applied
+func applyOverrides(base map[string]TypeMappingRule, overrides map[string]MapperRuleOverride) (map[string]TypeMappingRule, error) {
+	typeRules := make(map[string]TypeMappingRule, len(base))
+	for typeName, rule := range base {
+		typeRules[typeName] = rule
+	}
+
+	for typeName, override := range overrides {
+		rule, ok := typeRules[typeName]
+		if !ok {
+			return nil, fmt.Errorf("unknown Pokemon type %q in mapper rules", typeName)
+		}
+		if override.MinimumThreshold != nil {
+			if *override.MinimumThreshold < 0 || *override.MinimumThreshold > 1 {
+				return nil, fmt.Errorf("minimum_threshold for %s must be between 0 and 1", typeName)
+			}
+			rule.MinimumThreshold = *override.MinimumThreshold
+		}
+		if override.Keywords != nil {
+			rule.KeywordMatches = make([]string, len(override.Keywords))
+			for i, keyword := range override.Keywords {
+				rule.KeywordMatches[i] = strings.ToLower(keyword)
+			}
+		}
+		typeRules[typeName] = rule
+	}
+	return typeRules, nil
+}
+
+// rules returns the current type rules. The map must not be modified.
+func (pm *PokemonMapper) rules() map[string]TypeMappingRule {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+	return pm.typeRules
+}
+
 // initializeTypeRules sets up the sophisticated type mapping rules
 func (pm *PokemonMapper) initializeTypeRules() {
 	// Normal: Generic Coffee Taste - balanced, no strong characteristics
@@ -268,100 +417,138 @@ func (pm *PokemonMapper) initializeTypeRules() {
 // CalculatePokemonTypes determines primary and secondary types for a coffee
 func (pm *PokemonMapper) CalculatePokemonTypes(coffee models.Coffee) (string, string, map[string]float64) {
 	scores := make(map[string]float64)
+	typeRules := pm.rules()
 
 	// Calculate score for each type
-	for typeName, rule := range pm.typeRules {
+	for typeName, rule := range typeRules {
 		score := pm.calculateTypeScore(coffee, rule)
 		scores[typeName] = score
 	}
 
-	// Sort types by score
+	primaryType, secondaryType := pickTypes(rankTypes(scores), typeRules)
+	return primaryType, secondaryType, scores
+}
+
+// rankTypes sorts types by score; ties (scores are capped at 1) go by name
+// so the same rules always give the same types
+func rankTypes(scores map[string]float64) []TypeScore {
 	var typeScores []TypeScore
 	for typeName, score := range scores {
 		typeScores = append(typeScores, TypeScore{Type: typeName, Score: score})
 	}
 	sort.Slice(typeScores, func(i, j int) bool {
-		return typeScores[i].Score > typeScores[j].Score
+		if typeScores[i].Score != typeScores[j].Score {
+			return typeScores[i].Score > typeScores[j].Score
+		}
+		return typeScores[i].Type < typeScores[j].Type
 	})
+	return typeScores
+}
 
-	// Get primary and secondary types
+// pickTypes chooses the primary and secondary types from ranked scores: the
+// best type if it reaches its threshold, "normal" otherwise, and the
+// runner-up if it reaches 80% of its threshold
+func pickTypes(typeScores []TypeScore, typeRules map[string]TypeMappingRule) (string, string) {
 	primaryType := "normal"
 	secondaryType := ""
 
-	if len(typeScores) > 0 && typeScores[0].Score >= pm.typeRules[typeScores[0].Type].MinimumThreshold {
+	if len(typeScores) > 0 && typeScores[0].Score >= typeRules[typeScores[0].Type].MinimumThreshold {
 		primaryType = typeScores[0].Type
 	}
 
-	if len(typeScores) > 1 && typeScores[1].Score >= pm.typeRules[typeScores[1].Type].MinimumThreshold*0.8 {
+	if len(typeScores) > 1 && typeScores[1].Score >= typeRules[typeScores[1].Type].MinimumThreshold*0.8 {
 		secondaryType = typeScores[1].Type
 	}
 
-	return primaryType, secondaryType, scores
+	return primaryType, secondaryType
 }
 
 // calculateTypeScore calculates how well a coffee matches a type rule
 func (pm *PokemonMapper) calculateTypeScore(coffee models.Coffee, rule TypeMappingRule) float64 {
+	return pm.explainTypeScore(coffee, rule).Score
+}
+
+// explainTypeScore scores a coffee against a type rule and records what
+// each part of the rule added
+func (pm *PokemonMapper) explainTypeScore(coffee models.Coffee, rule TypeMappingRule) TypeExplanation {
+	explanation := TypeExplanation{
+		Type:      rule.Type,
+		Threshold: rule.MinimumThreshold,
+		Traits:    make([]TraitContribution, 0, len(rule.PrimaryTraits)+len(rule.SecondaryTraits)),
+	}
 	score := 0.0
 	maxPossibleScore := 0.0
 
-	// Calculate primary trait scores
-	for _, tw := range rule.PrimaryTraits {
-		traitValue := pm.getTraitValue(coffee.TastingTraits, tw.Trait)
-		maxPossibleScore += tw.Weight * 10.0
-
-		if traitValue >= tw.Min {
-			// Scale score based on how close to optimal range
-			normalizedValue := float64(traitValue)
-			if normalizedValue > float64(tw.Max) {
-				normalizedValue = float64(tw.Max)
+	// Primary traits first, then secondary ones; both count the same way
+	traits := []struct {
+		role    string
+		weights []TraitWeight
+	}{{"primary", rule.PrimaryTraits}, {"secondary", rule.SecondaryTraits}}
+	for _, group := range traits {
+		for _, tw := range group.weights {
+			traitValue := pm.getTraitValue(coffee.TastingTraits, tw.Trait)
+			maxPossibleScore += tw.Weight * 10.0
+			contribution := TraitContribution{
+				Trait:     tw.Trait,
+				Role:      group.role,
+				Value:     traitValue,
+				Weight:    tw.Weight,
+				Min:       tw.Min,
+				Max:       tw.Max,
+				MaxPoints: tw.Weight * 10.0,
 			}
-			contribution := (normalizedValue / 10.0) * tw.Weight * 10.0
-			score += contribution
-		}
-	}
-
-	// Calculate secondary trait scores
-	for _, tw := range rule.SecondaryTraits {
-		traitValue := pm.getTraitValue(coffee.TastingTraits, tw.Trait)
-		maxPossibleScore += tw.Weight * 10.0
 
-		if traitValue >= tw.Min {
-			normalizedValue := float64(traitValue)
-			if normalizedValue > float64(tw.Max) {
-				normalizedValue = float64(tw.Max)
+			if traitValue >= tw.Min {
+				// Scale score based on how close to optimal range
+				normalizedValue := float64(traitValue)
+				if normalizedValue > float64(tw.Max) {
+					normalizedValue = float64(tw.Max)
+				}
+				contribution.Points = (normalizedValue / 10.0) * tw.Weight * 10.0
+				contribution.Counted = true
+				score += contribution.Points
 			}
-			contribution := (normalizedValue / 10.0) * tw.Weight * 10.0
-			score += contribution
+			explanation.Traits = append(explanation.Traits, contribution)
 		}
 	}
 
 	// Keyword matching bonus
 	if len(rule.KeywordMatches) > 0 {
-		keywordScore := pm.calculateKeywordScore(coffee.TastingNotes, rule.KeywordMatches)
-		score += keywordScore * 20.0 // Keyword matches are valuable
+		explanation.KeywordMatches = pm.matchKeywords(coffee.TastingNotes, rule.KeywordMatches)
+		keywordScore := float64(len(explanation.KeywordMatches)) / 5.0
+		explanation.KeywordPoints = keywordScore * 20.0 // Keyword matches are valuable
+		score += explanation.KeywordPoints
 		maxPossibleScore += 20.0
 	}
 
 	// Processing method bonus
 	if bonus, ok := rule.ProcessingBonus[coffee.ProcessingMethod]; ok {
+		explanation.ProcessingBonus = bonus
 		score *= bonus
 	}
 
 	// Roast level bonus
 	if bonus, ok := rule.RoastLevelBonus[coffee.RoastLevel]; ok {
+		explanation.RoastLevelBonus = bonus
 		score *= bonus
 	}
 
 	// Normalize score to 0-1 range
+	explanation.Points = score
+	explanation.MaxPoints = maxPossibleScore
 	if maxPossibleScore > 0 {
-		return math.Min(score/maxPossibleScore, 1.0)
+		explanation.Score = math.Min(score/maxPossibleScore, 1.0)
 	}
-
-	return 0.0
+	return explanation
 }
 
 // getTraitValue extracts a trait value from TastingTraits
 func (pm *PokemonMapper) getTraitValue(traits models.TastingTraits, traitName string) int {
+	return traitValue(traits, traitName)
+}
+
+// traitValue extracts a trait by its JSON name; unknown names read as 0
+func traitValue(traits models.TastingTraits, traitName string) int {
 	switch traitName {
 	case "berry_intensity":
 		return traits.BerryIntensity
@@ -392,9 +579,10 @@ func (pm *PokemonMapper) getTraitValue(traits models.TastingTraits, traitName st
 	}
 }
 
-// calculateKeywordScore checks tasting notes for keyword matches
-func (pm *PokemonMapper) calculateKeywordScore(tastingNotes [5]string, keywords []string) float64 {
-	matches := 0
+// matchKeywords returns the tasting notes containing a keyword, each with
+// the first keyword it contains
+func (pm *PokemonMapper) matchKeywords(tastingNotes [5]string, keywords []string) []KeywordMatch {
+	var matches []KeywordMatch
 	for _, note := range tastingNotes {
 		if note == "" {
 			continue
@@ -402,17 +590,17 @@ func (pm *PokemonMapper) calculateKeywordScore(tastingNotes [5]string, keywords
 		noteLower := strings.ToLower(note)
 		for _, keyword := range keywords {
 			if strings.Contains(noteLower, keyword) {
-				matches++
+				matches = append(matches, KeywordMatch{Note: note, Keyword: keyword})
 				break // Count each note only once
 			}
 		}
 	}
-	return float64(matches) / 5.0 // Normalize to 0-1
+	return matches
 }
 
 // GetTypeDescription returns a description of why a type was chosen
 func (pm *PokemonMapper) GetTypeDescription(typeName string, coffee models.Coffee) string {
-	rule, ok := pm.typeRules[typeName]
+	rule, ok := pm.rules()[typeName]
 	if !ok {
 		return fmt.Sprintf("Unknown type: %s", typeName)
 	}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"fmt"
+	"go-coffee-log/storage"
+	"sync"
+)
+
+// QuotaLimits caps how much each user may store; zero means unlimited
+type QuotaLimits struct {
+	MaxCoffees    int   `json:"max_coffees"`
+	MaxPhotoBytes int64 `json:"max_photo_bytes"`
+}
+
+// QuotaMeter reports usage of one quota
+type QuotaMeter struct {
+	Used  int64 `json:"used"`
+	Limit int64 `json:"limit"` // 0 = unlimited
+}
+
+// QuotaUsage reports usage of every quota
+type QuotaUsage struct {
+	Coffees    QuotaMeter `json:"coffees"`
+	PhotoBytes QuotaMeter `json:"photo_bytes"`
+}
+
+// QuotaService enforces storage quotas per user: each user may add up to
+// the limits, counting the coffees they added and the photos they uploaded.
+// On servers without authentication everything is added by the same empty
+// user, so the quotas cap the whole collection.
+type QuotaService struct {
+	coffees storage.CoffeeStorage
+	photos  storage.PhotoStorage
+
+	mu     sync.RWMutex
+	limits QuotaLimits
+}
+
+// NewQuotaService creates a new quota service
+func NewQuotaService(coffees storage.CoffeeStorage, photos storage.PhotoStorage, limits QuotaLimits) *QuotaService {
+	return &QuotaService{
+		coffees: coffees,
+		photos:  photos,
+		limits:  limits,
+	}
+}
+
+// SetLimits changes the quotas; existing data over a lowered quota is kept
+// but nothing more can be added
+func (s *QuotaService) SetLimits(limits QuotaLimits) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.limits = limits
+}
+
+// Limits returns the current quotas
+func (s *QuotaService) Limits() QuotaLimits {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.limits
+}
+
+// Usage reports how much of each quota a user has used
+func (s *QuotaService) Usage(userID string) (QuotaUsage, error) {
+	limits := s.Limits()
+	coffees, err := s.coffeeCount(userID)
+	if err != nil {
+		return QuotaUsage{}, err
+	}
+	photoBytes, _, err := s.photoBytes(userID, "")
+	if err != nil {
+		return QuotaUsage{}, err
+	}
+
+	return QuotaUsage{
+		Coffees:    QuotaMeter{Used: int64(coffees), Limit: int64(limits.MaxCoffees)},
+		PhotoBytes: QuotaMeter{Used: photoBytes, Limit: limits.MaxPhotoBytes},
+	}, nil
+}
+
+// CheckCoffee fails when a user may not add another coffee
+func (s *QuotaService) CheckCoffee(userID string) error {
+	return s.CheckCoffees(userID, 1)
+}
+
+// CheckCoffees fails when a user may not add this many more coffees at once
+func (s *QuotaService) CheckCoffees(userID string, adding int) error {
+	limit := s.Limits().MaxCoffees
+	if limit == 0 {
+		return nil
+	}
+
+	count, err := s.coffeeCount(userID)
+	if err != nil {
+		return err
+	}
+	if count+adding > limit {
+		return fmt.Errorf("quota exceeded: each user may add %d coffees", limit)
+	}
+	return nil
+}
+
+// CheckPhoto fails when a user uploading a photo of size bytes would go
+// over their photo quota. Photos they already uploaded under hash cost
+// nothing, since the blob is reused.
+func (s *QuotaService) CheckPhoto(userID, hash string, size int64) error {
+	limit := s.Limits().MaxPhotoBytes
+	if limit == 0 {
+		return nil
+	}
+
+	used, stored, err := s.photoBytes(userID, hash)
+	if err != nil {
+		return err
+	}
+	if !stored && used+size > limit {
+		return fmt.Errorf("quota exceeded: photos are limited to %d bytes and %d are used", limit, used)
+	}
+	return nil
+}
+
+// coffeeCount counts the coffees a user added
+func (s *QuotaService) coffeeCount(userID string) (int, error) {
+	coffees, err := s.coffees.GetAll()
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, coffee := range coffees {
+		if coffee.AddedBy == userID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// photoBytes sums the size of the photo blobs a user uploaded, counting
+// blobs attached to several coffees once, and reports whether hash is
+// among them
+func (s *QuotaService) photoBytes(userID, hash string) (int64, bool, error) {
+	photos, err := s.photos.GetAllPhotos()
+	if err != nil {
+		return 0, false, err
+	}
+
+	var total int64
+	seen := make(map[string]bool)
+	for _, photo := range photos {
+		if photo.UploadedBy != userID || seen[photo.Hash] {
+			continue
+		}
+		seen[photo.Hash] = true
+		total += photo.Size
+	}
+	return total, seen[hash], nil
+}
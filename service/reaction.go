@@ -0,0 +1,119 @@
+package service
+
+import (
+	"fmt"
+	"go-coffee-log/models"
+	"go-coffee-log/storage"
+	"time"
+)
+
+// ReactionService handles emoji reactions on Pokedex entries
+type ReactionService struct {
+	storage        storage.ReactionStorage
+	pokemonStorage storage.PokemonStorage
+	bus            *EventBus
+}
+
+// NewReactionService creates a new reaction service
+func NewReactionService(storage storage.ReactionStorage, pokemonStorage storage.PokemonStorage, bus *EventBus) *ReactionService {
+	return &ReactionService{
+		storage:        storage,
+		pokemonStorage: pokemonStorage,
+		bus:            bus,
+	}
+}
+
+// React adds a reaction to a catch and returns the updated counts. Only new
+// reactions are announced on the event bus.
+func (s *ReactionService) React(coffeeID, userID, userName, emoji string) (map[string]int, error) {
+	if err := models.ValidateEmoji(emoji); err != nil {
+		return nil, err
+	}
+
+	mapping, err := s.catch(coffeeID)
+	if err != nil {
+		return nil, err
+	}
+
+	added, err := s.storage.AddReaction(models.Reaction{
+		CoffeeID:  coffeeID,
+		UserID:    userID,
+		Emoji:     emoji,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if added {
+		name := mapping.PokemonName
+		if mapping.Nickname != "" {
+			name = mapping.Nickname
+		}
+		if userName == "" {
+			userName = "someone"
+		}
+		s.bus.Publish(Event{
+			Type:     "reaction.added",
+			Message:  fmt.Sprintf("%s reacted %s to %s", userName, emoji, name),
+			CoffeeID: coffeeID,
+			Data: map[string]interface{}{
+				"emoji":        emoji,
+				"pokemon_name": name,
+			},
+		})
+	}
+
+	return s.GetCounts(coffeeID)
+}
+
+// catch returns the catch reactions on a coffee are about. Reactions belong
+// to the coffee, so a coffee caught in several dex profiles is announced by
+// its Kanto catch when it has one.
+func (s *ReactionService) catch(coffeeID string) (*models.CoffeePokemon, error) {
+	if mapping, err := s.pokemonStorage.GetCoffeePokemon(coffeeID, models.DefaultDex); err == nil {
+		return mapping, nil
+	}
+	mappings, err := s.pokemonStorage.GetAllCoffeePokemon()
+	if err != nil {
+		return nil, err
+	}
+	for i := range mappings {
+		if mappings[i].CoffeeID == coffeeID {
+			return &mappings[i], nil
+		}
+	}
+	return nil, fmt.Errorf("pokemon mapping not found")
+}
+
+// Unreact removes the caller's reaction and returns the updated counts
+func (s *ReactionService) Unreact(coffeeID, userID, emoji string) (map[string]int, error) {
+	if err := s.storage.RemoveReaction(coffeeID, userID, emoji); err != nil {
+		return nil, err
+	}
+	return s.GetCounts(coffeeID)
+}
+
+// GetCounts returns the reaction counts of one catch
+func (s *ReactionService) GetCounts(coffeeID string) (map[string]int, error) {
+	counts, err := s.storage.GetReactionCounts()
+	if err != nil {
+		return nil, err
+	}
+	if counts[coffeeID] == nil {
+		return map[string]int{}, nil
+	}
+	return counts[coffeeID], nil
+}
+
+// AttachCounts fills in the reaction counts of Pokedex entries
+func (s *ReactionService) AttachCounts(mappings []models.CoffeePokemon) error {
+	counts, err := s.storage.GetReactionCounts()
+	if err != nil {
+		return err
+	}
+	for i := range mappings {
+		mappings[i].Reactions = counts[mappings[i].CoffeeID]
+	}
+	return nil
+}
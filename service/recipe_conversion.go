@@ -0,0 +1,203 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"go-coffee-log/models"
+)
+
+// ConvertedRecipe is a coffee's recipe adapted from the brewer it was
+// dialed in on to another one
+type ConvertedRecipe struct {
+	SuggestedRecipe
+	From        string       `json:"from"`         // brewer the recipe was written for
+	Source      RecipeParams `json:"source"`       // what was read from the coffee's recipe
+	GrindChange string       `json:"grind_change"` // relative to the grind used on From
+}
+
+// styleOffsets are how each brew style departs from a pour-over: ratio
+// (water per gram of coffee), temperature in °C and grind steps (positive
+// is coarser)
+var styleOffsets = map[brewStyle]struct {
+	ratio, temperature float64
+	grind              int
+}{
+	stylePourOver:  {0, 0, 0},
+	styleImmersion: {-0.5, -1, 1},
+	stylePressure:  {-2, -3, -1},
+}
+
+// aeropressMaxWater is what fits in an AeroPress chamber
+const aeropressMaxWater = 250
+
+// ConvertRecipe adapts a coffee's recipe to another brewer, given by a
+// registered brewer's ID or by name. Ratio, temperature and grind move by
+// the difference between the two brew styles, and the brew time keeps its
+// place within each brewer's usual window, so a fast dial-in stays fast.
+func (s *RecipeService) ConvertRecipe(coffeeID, toBrewerID, toBrewer string) (*ConvertedRecipe, error) {
+	coffee, err := s.coffeeService.GetCoffee(coffeeID)
+	if err != nil {
+		return nil, err
+	}
+
+	converted := &ConvertedRecipe{}
+	converted.CoffeeID = coffeeID
+	converted.Brewer = toBrewer
+	if toBrewerID != "" {
+		brewer, err := s.lookupBrewer(toBrewerID)
+		if err != nil {
+			return nil, err
+		}
+		converted.Brewer = brewer.Name
+		converted.BrewerID = brewer.ID
+	}
+	if converted.Brewer == "" {
+		return nil, fmt.Errorf("invalid conversion: a target brewer is required")
+	}
+
+	s.convert(coffee, converted)
+	return converted, nil
+}
+
+// ConvertRecipeForBrewers adapts a coffee's recipe to every registered
+// brewer it was not written for
+func (s *RecipeService) ConvertRecipeForBrewers(coffeeID string) ([]ConvertedRecipe, error) {
+	if s.brewerService == nil {
+		return nil, fmt.Errorf("brewer lookups require MySQL storage")
+	}
+	coffee, err := s.coffeeService.GetCoffee(coffeeID)
+	if err != nil {
+		return nil, err
+	}
+	brewers, err := s.brewerService.GetAllBrewers()
+	if err != nil {
+		return nil, err
+	}
+
+	conversions := []ConvertedRecipe{}
+	for _, brewer := range brewers {
+		if brewer.ID == coffee.BrewerID || strings.EqualFold(brewer.Name, coffee.Dripper) {
+			continue
+		}
+		converted := ConvertedRecipe{}
+		converted.CoffeeID = coffeeID
+		converted.Brewer = brewer.Name
+		converted.BrewerID = brewer.ID
+		s.convert(coffee, &converted)
+		conversions = append(conversions, converted)
+	}
+	return conversions, nil
+}
+
+// lookupBrewer finds a registered brewer
+func (s *RecipeService) lookupBrewer(id string) (models.Brewer, error) {
+	if s.brewerService == nil {
+		return models.Brewer{}, fmt.Errorf("brewer lookups require MySQL storage")
+	}
+	return s.brewerService.GetBrewerByID(id)
+}
+
+// convert fills in converted, whose Brewer is set, from coffee's recipe
+func (s *RecipeService) convert(coffee models.Coffee, converted *ConvertedRecipe) {
+	converted.From = coffee.Dripper
+	if converted.From == "" && coffee.BrewerID != "" && s.brewerService != nil {
+		if brewer, err := s.brewerService.GetBrewerByID(coffee.BrewerID); err == nil {
+			converted.From = brewer.Name
+		}
+	}
+	if converted.From == "" {
+		converted.From = "V60"
+		converted.Rationale = append(converted.Rationale, "The coffee names no brewer, assuming its recipe is for a V60-style pour-over")
+	}
+
+	// Start from the coffee's own numbers where the recipe gives them
+	converted.Source = parseRecipe(coffee.Recipe)
+	dose, ratio, temperature := 15.0, 16.0, 93.0
+	if converted.Source.DoseGrams > 0 {
+		dose = converted.Source.DoseGrams
+	}
+	if r := converted.Source.Ratio(); r > 0 {
+		ratio = r
+	} else {
+		converted.Rationale = append(converted.Rationale, "The recipe gives no dose and water, starting from 1:16")
+	}
+	if converted.Source.TemperatureC > 0 {
+		temperature = converted.Source.TemperatureC
+	}
+
+	fromStyle, toStyle := brewStyleFor(converted.From), brewStyleFor(converted.Brewer)
+	from, to := styleOffsets[fromStyle], styleOffsets[toStyle]
+	ratio = math.Max(12, math.Min(ratio-from.ratio+to.ratio, 18))
+	temperature = math.Max(85, math.Min(temperature-from.temperature+to.temperature, 100))
+	toGrind, fromGrind := to.grind+chemexGrind(converted.Brewer), from.grind+chemexGrind(converted.From)
+	if fromStyle != toStyle {
+		converted.Rationale = append(converted.Rationale, styleRationale(toStyle))
+	}
+
+	water := math.Round(dose * ratio)
+	if toStyle == stylePressure && water > aeropressMaxWater {
+		dose = math.Floor(aeropressMaxWater / ratio)
+		water = math.Round(dose * ratio)
+		converted.Rationale = append(converted.Rationale, fmt.Sprintf("Scaled down to %.0fg of coffee to fit the AeroPress chamber", dose))
+	}
+
+	// Keep the brew time's place in the brewer's usual window
+	fromMin, fromMax := drawdownWindow(converted.From)
+	toMin, toMax := drawdownWindow(converted.Brewer)
+	position := 0.5
+	if seconds := coffee.EndTime.Minutes*60 + coffee.EndTime.Seconds; seconds > 0 {
+		position = math.Max(0, math.Min(float64(seconds-fromMin)/float64(fromMax-fromMin), 1))
+		converted.Rationale = append(converted.Rationale,
+			fmt.Sprintf("The %d:%02d brew on the %s maps to the same part of the %s window", seconds/60, seconds%60, converted.From, converted.Brewer))
+	}
+	target := toMin + int(math.Round(position*float64(toMax-toMin)))
+	window := [2]int{max(toMin, target-15), min(toMax, target+15)}
+
+	converted.DoseGrams = dose
+	converted.WaterGrams = water
+	converted.Ratio = fmt.Sprintf("1:%.1f", ratio)
+	converted.TemperatureC = temperature
+	converted.GrindSize = grindLabel(3 + toGrind)
+	converted.GrindChange = grindChange(toGrind - fromGrind)
+	converted.TargetTime = formatWindow(window)
+	converted.Pours = buildPours(toStyle, dose, water, window[0])
+	converted.Steps = buildRecipeSteps(&converted.SuggestedRecipe)
+}
+
+// chemexGrind is the extra step coarser a Chemex's thick filter needs
+func chemexGrind(brewer string) int {
+	if containsAny(strings.ToLower(brewer), "chemex") {
+		return 1
+	}
+	return 0
+}
+
+// styleRationale explains the adjustments for a brew style
+func styleRationale(style brewStyle) string {
+	switch style {
+	case styleImmersion:
+		return "Immersion extracts for the whole brew: a slightly tighter ratio, a degree cooler and a coarser grind"
+	case stylePressure:
+		return "The AeroPress brews a stronger cup under pressure: a tighter ratio, cooler water and a finer grind"
+	default:
+		return "Pour-over extracts as water flows through: a longer ratio and full temperature"
+	}
+}
+
+// grindChange describes a grind adjustment in steps of the 1-5 scale
+func grindChange(steps int) string {
+	switch {
+	case steps == 0:
+		return "same grind"
+	case steps == 1:
+		return "1 step coarser"
+	case steps == -1:
+		return "1 step finer"
+	case steps > 0:
+		return fmt.Sprintf("%d steps coarser", steps)
+	default:
+		return fmt.Sprintf("%d steps finer", -steps)
+	}
+}
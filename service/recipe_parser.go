@@ -0,0 +1,94 @@
+package service
+
+import (
+	"go-coffee-log/models"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// RecipeParams represents the numbers extracted from free-form recipe steps
+type RecipeParams struct {
+	DoseGrams    float64 `json:"dose_grams,omitempty"`
+	WaterGrams   float64 `json:"water_grams,omitempty"`
+	TemperatureC float64 `json:"temperature_c,omitempty"`
+}
+
+// Ratio returns water/dose, or 0 when either is unknown
+func (p RecipeParams) Ratio() float64 {
+	if p.DoseGrams <= 0 || p.WaterGrams <= 0 {
+		return 0
+	}
+	return p.WaterGrams / p.DoseGrams
+}
+
+var (
+	gramsPattern       = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*(?:g|grams?)\b`)
+	temperaturePattern = regexp.MustCompile(`(\d{2,3}(?:\.\d+)?)\s*°?\s*([cCfF])\b`)
+	drawdownPattern    = regexp.MustCompile(`(\d{1,2}):([0-5]\d)`)
+)
+
+// parseRecipe extracts dose, water and temperature from recipe steps such as
+// "20g coffee", "320g water" or "94°C". Grams on a line mentioning water are
+// treated as water; the first other gram amount is the dose.
+func parseRecipe(steps []string) RecipeParams {
+	var params RecipeParams
+
+	for _, step := range steps {
+		lower := strings.ToLower(step)
+
+		if match := temperaturePattern.FindStringSubmatch(step); match != nil && params.TemperatureC == 0 {
+			value, _ := strconv.ParseFloat(match[1], 64)
+			if strings.EqualFold(match[2], "f") {
+				value = models.FahrenheitToCelsius(value)
+			}
+			params.TemperatureC = value
+		}
+
+		for _, match := range gramsPattern.FindAllStringSubmatch(lower, -1) {
+			value, _ := strconv.ParseFloat(match[1], 64)
+			switch {
+			case strings.Contains(lower, "water") || strings.Contains(lower, "pour"):
+				// Later pours are cumulative targets, so keep the largest
+				if value > params.WaterGrams {
+					params.WaterGrams = value
+				}
+			case params.DoseGrams == 0:
+				params.DoseGrams = value
+			}
+		}
+	}
+
+	return params
+}
+
+// parseDrawdownSeconds finds a "m:ss" time in text and returns it in seconds
+func parseDrawdownSeconds(text string) (int, bool) {
+	match := drawdownPattern.FindStringSubmatch(text)
+	if match == nil {
+		return 0, false
+	}
+	minutes, _ := strconv.Atoi(match[1])
+	seconds, _ := strconv.Atoi(match[2])
+	return minutes*60 + seconds, true
+}
+
+// drawdownWindow returns the typical total brew time range, in seconds, for a
+// brewer. Unknown brewers get a generic pour-over window.
+func drawdownWindow(dripper string) (int, int) {
+	lower := strings.ToLower(dripper)
+	switch {
+	case strings.Contains(lower, "aeropress"):
+		return 90, 150
+	case strings.Contains(lower, "chemex"):
+		return 210, 300
+	case strings.Contains(lower, "kalita"), strings.Contains(lower, "wave"):
+		return 180, 240
+	case strings.Contains(lower, "french"), strings.Contains(lower, "press"):
+		return 240, 300
+	case strings.Contains(lower, "clever"):
+		return 150, 240
+	default:
+		return 150, 210
+	}
+}
@@ -0,0 +1,248 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// RecipeService proposes starting recipes from a coffee's profile
+type RecipeService struct {
+	coffeeService *CoffeeService
+	brewerService *BrewerService // optional, needed for brewer_id lookups
+}
+
+// NewRecipeService creates a new recipe suggestion service
+func NewRecipeService(coffeeService *CoffeeService, brewerService *BrewerService) *RecipeService {
+	return &RecipeService{
+		coffeeService: coffeeService,
+		brewerService: brewerService,
+	}
+}
+
+// Pour represents a single pour in a suggested recipe
+type Pour struct {
+	Label        string  `json:"label"`
+	StartSeconds int     `json:"start_seconds"`
+	TotalWater   float64 `json:"total_water_grams"` // cumulative scale reading after the pour
+}
+
+// SuggestedRecipe represents a proposed starting recipe for a coffee
+type SuggestedRecipe struct {
+	CoffeeID     string   `json:"coffee_id"`
+	Brewer       string   `json:"brewer"`
+	BrewerID     string   `json:"brewer_id,omitempty"`
+	DoseGrams    float64  `json:"dose_grams"`
+	WaterGrams   float64  `json:"water_grams"`
+	Ratio        string   `json:"ratio"`
+	TemperatureC float64  `json:"temperature_c"`
+	GrindSize    string   `json:"grind_size"`
+	TargetTime   string   `json:"target_time"`
+	Pours        []Pour   `json:"pours"`
+	Steps        []string `json:"steps"` // same shape as Coffee.Recipe, ready to save
+	Rationale    []string `json:"rationale"`
+}
+
+// brewStyle describes how a brewer extracts coffee
+type brewStyle int
+
+const (
+	stylePourOver brewStyle = iota
+	styleImmersion
+	stylePressure
+)
+
+// highDensityOrigins are typically grown at altitude, producing dense beans
+// that need more heat and a finer grind
+var highDensityOrigins = []string{
+	"ethiopia", "kenya", "colombia", "guatemala", "panama", "bolivia", "peru",
+	"rwanda", "burundi", "costa rica", "honduras", "yemen", "el salvador", "ecuador",
+}
+
+// lowDensityOrigins are typically lower grown, producing softer beans
+var lowDensityOrigins = []string{
+	"brazil", "sumatra", "indonesia", "india", "vietnam", "hawaii", "uganda", "papua", "mexico",
+}
+
+// SuggestRecipe proposes a recipe for a coffee, optionally for a registered brewer
+func (s *RecipeService) SuggestRecipe(coffeeID, brewerID string) (*SuggestedRecipe, error) {
+	coffee, err := s.coffeeService.GetCoffee(coffeeID)
+	if err != nil {
+		return nil, err
+	}
+
+	suggestion := &SuggestedRecipe{
+		CoffeeID: coffeeID,
+		Brewer:   coffee.Dripper,
+	}
+
+	if brewerID != "" {
+		if s.brewerService == nil {
+			return nil, fmt.Errorf("brewer lookups require MySQL storage")
+		}
+		brewer, err := s.brewerService.GetBrewerByID(brewerID)
+		if err != nil {
+			return nil, err
+		}
+		suggestion.Brewer = brewer.Name
+		suggestion.BrewerID = brewer.ID
+	}
+	if suggestion.Brewer == "" {
+		suggestion.Brewer = "V60"
+		suggestion.Rationale = append(suggestion.Rationale, "No brewer given, assuming a V60-style pour-over")
+	}
+
+	style := brewStyleFor(suggestion.Brewer)
+
+	// Roast level sets the baseline temperature and ratio
+	temperature, ratio, grind := 93.0, 16.0, 3 // grind: 1 (fine) .. 5 (coarse)
+	switch coffee.RoastLevel {
+	case "light":
+		temperature, ratio, grind = 96, 16.5, 2
+		suggestion.Rationale = append(suggestion.Rationale, "Light roasts are dense and soluble-poor: hot water and a finer grind")
+	case "light medium":
+		temperature, ratio = 94, 16
+	case "medium":
+		temperature, ratio = 92, 15.5
+	case "medium dark":
+		temperature, ratio, grind = 90, 15, 4
+	case "dark":
+		temperature, ratio, grind = 88, 15, 4
+		suggestion.Rationale = append(suggestion.Rationale, "Dark roasts extract easily: cooler water and a coarser grind avoid bitterness")
+	}
+
+	// Processing affects solubility and fines
+	switch coffee.ProcessingMethod {
+	case "natural", "coferment", "experimental":
+		temperature--
+		suggestion.Rationale = append(suggestion.Rationale,
+			fmt.Sprintf("%s coffees extract fruit quickly; a degree cooler keeps them clean", capitalize(coffee.ProcessingMethod)))
+	case "washed":
+		suggestion.Rationale = append(suggestion.Rationale, "Washed coffees take heat well; full temperature brings out clarity")
+	}
+
+	// Density heuristic from origin altitude
+	origin := strings.ToLower(coffee.Origin)
+	switch {
+	case containsAny(origin, highDensityOrigins...):
+		temperature++
+		grind--
+		suggestion.Rationale = append(suggestion.Rationale,
+			fmt.Sprintf("%s coffees are usually high-grown and dense: slightly hotter and finer", coffee.Origin))
+	case containsAny(origin, lowDensityOrigins...):
+		temperature--
+		grind++
+		suggestion.Rationale = append(suggestion.Rationale,
+			fmt.Sprintf("%s coffees are usually lower-grown and softer: slightly cooler and coarser", coffee.Origin))
+	}
+
+	variety := strings.ToLower(coffee.Variety)
+	if containsAny(variety, "gesha", "geisha", "sl28", "sl34") {
+		ratio += 0.5
+		suggestion.Rationale = append(suggestion.Rationale, "Delicate varieties shine with a slightly longer ratio")
+	}
+
+	temperature = math.Max(85, math.Min(temperature, 100))
+
+	switch style {
+	case styleImmersion:
+		grind += 1
+		ratio = math.Max(ratio-0.5, 14)
+	case stylePressure:
+		grind -= 1
+		ratio = math.Max(ratio-2, 12)
+	default:
+		// Thick Chemex filters slow the flow; compensate with a coarser grind
+		if containsAny(strings.ToLower(suggestion.Brewer), "chemex") {
+			grind++
+		}
+	}
+
+	suggestion.TemperatureC = temperature
+	suggestion.GrindSize = grindLabel(grind)
+	suggestion.DoseGrams = 15
+	if style == stylePressure {
+		suggestion.DoseGrams = 17
+	}
+	suggestion.WaterGrams = math.Round(suggestion.DoseGrams * ratio)
+	suggestion.Ratio = fmt.Sprintf("1:%.1f", ratio)
+
+	minTime, maxTime := drawdownWindow(suggestion.Brewer)
+	suggestion.TargetTime = formatWindow([2]int{minTime, maxTime})
+	suggestion.Pours = buildPours(style, suggestion.DoseGrams, suggestion.WaterGrams, minTime)
+	suggestion.Steps = buildRecipeSteps(suggestion)
+
+	return suggestion, nil
+}
+
+// brewStyleFor classifies a brewer by name
+func brewStyleFor(brewer string) brewStyle {
+	lower := strings.ToLower(brewer)
+	switch {
+	case containsAny(lower, "aeropress"):
+		return stylePressure
+	case containsAny(lower, "french", "press", "clever", "immersion", "hario switch"):
+		return styleImmersion
+	default:
+		return stylePourOver
+	}
+}
+
+// buildPours lays out the pour schedule for a brew style
+func buildPours(style brewStyle, dose, water float64, minTime int) []Pour {
+	bloom := math.Round(dose * 2.5)
+
+	switch style {
+	case styleImmersion:
+		return []Pour{
+			{Label: "Add all water and stir", StartSeconds: 0, TotalWater: water},
+			{Label: "Break the crust / release", StartSeconds: minTime, TotalWater: water},
+		}
+	case stylePressure:
+		return []Pour{
+			{Label: "Add water and stir", StartSeconds: 0, TotalWater: water},
+			{Label: "Press gently", StartSeconds: 60, TotalWater: water},
+		}
+	}
+
+	remaining := water - bloom
+	return []Pour{
+		{Label: "Bloom", StartSeconds: 0, TotalWater: bloom},
+		{Label: "First pour", StartSeconds: 45, TotalWater: math.Round(bloom + remaining*0.5)},
+		{Label: "Second pour", StartSeconds: 75, TotalWater: math.Round(bloom + remaining*0.8)},
+		{Label: "Final pour", StartSeconds: 105, TotalWater: water},
+	}
+}
+
+// buildRecipeSteps renders a suggestion as Coffee.Recipe style strings
+func buildRecipeSteps(s *SuggestedRecipe) []string {
+	steps := []string{
+		fmt.Sprintf("%.0fg coffee, %s grind", s.DoseGrams, s.GrindSize),
+		fmt.Sprintf("%.0fg water at %.0f°C", s.WaterGrams, s.TemperatureC),
+	}
+	for _, pour := range s.Pours {
+		steps = append(steps, fmt.Sprintf("%d:%02d %s to %.0fg", pour.StartSeconds/60, pour.StartSeconds%60, strings.ToLower(pour.Label), pour.TotalWater))
+	}
+	steps = append(steps, fmt.Sprintf("Target total time %s", s.TargetTime))
+	return steps
+}
+
+// grindLabel converts a 1-5 grind level into a description
+func grindLabel(level int) string {
+	labels := []string{"fine", "medium-fine", "medium", "medium-coarse", "coarse"}
+	if level < 1 {
+		level = 1
+	}
+	if level > len(labels) {
+		level = len(labels)
+	}
+	return labels[level-1]
+}
+
+// capitalize upper-cases the first letter of s
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
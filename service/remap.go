@@ -0,0 +1,236 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"go-coffee-log/models"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Remap job statuses
+const (
+	RemapRunning = "running"
+	RemapDone    = "done"
+	RemapFailed  = "failed" // stopped before every coffee was tried
+)
+
+const (
+	// remapJobsKept caps the finished remap jobs kept for polling
+	remapJobsKept = 20
+	// remapErrorsKept caps the per-coffee failures a remap job reports
+	remapErrorsKept = 50
+)
+
+// RemapError is a coffee a remap job could not map again
+type RemapError struct {
+	CoffeeID string `json:"coffee_id"`
+	Error    string `json:"error"`
+}
+
+// RemapJob is a background run that maps a dex profile's catches again,
+// e.g. after the mapper rules or LLM models changed
+type RemapJob struct {
+	ID              string       `json:"id"`
+	Dex             string       `json:"dex"`
+	BelowConfidence float64      `json:"below_confidence,omitempty"` // only catches below it; 0 for all
+	RulesOnly       bool         `json:"rules_only"`
+	Status          string       `json:"status"`
+	Total           int          `json:"total"` // catches to map again
+	Processed       int          `json:"processed"`
+	Remapped        int          `json:"remapped"`
+	Changed         int          `json:"changed"` // remapped to a different Pokemon
+	Failed          int          `json:"failed"`
+	Errors          []RemapError `json:"errors,omitempty"`
+	Error           string       `json:"error,omitempty"` // why a failed job stopped
+	StartedAt       time.Time    `json:"started_at"`
+	FinishedAt      *time.Time   `json:"finished_at,omitempty"`
+}
+
+// RemapService runs remap jobs, one at a time. Jobs live in memory; a
+// restart forgets them, and stops one in progress.
+type RemapService struct {
+	pokemon *PokemonService
+	coffees *CoffeeService
+	bus     *EventBus // optional
+
+	mu      sync.Mutex
+	jobs    map[string]*RemapJob
+	running string // ID of the running job, if any
+}
+
+// NewRemapService creates a new remap service
+func NewRemapService(pokemon *PokemonService, coffees *CoffeeService, bus *EventBus) *RemapService {
+	return &RemapService{
+		pokemon: pokemon,
+		coffees: coffees,
+		bus:     bus,
+		jobs:    make(map[string]*RemapJob),
+	}
+}
+
+// Start maps every catch of a dex profile ("" for the default) again in
+// the background, or only those with a mapping confidence below
+// belowConfidence when it is above 0. Each catch is replaced the way
+// ReplaceCoffeePokemon does, so it may keep its Pokemon. Coffees not caught
+// in the dex are left alone. Poll Job with the returned job's ID.
+func (s *RemapService) Start(ctx context.Context, dexID string, belowConfidence float64) (RemapJob, error) {
+	if belowConfidence < 0 || belowConfidence > 1 {
+		return RemapJob{}, fmt.Errorf("invalid below_confidence: must be between 0 and 1")
+	}
+	dexID, err := s.pokemon.dexID(dexID)
+	if err != nil {
+		return RemapJob{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running != "" {
+		return RemapJob{}, fmt.Errorf("remap already running: job %s", s.running)
+	}
+
+	job := &RemapJob{
+		ID:              uuid.New().String(),
+		Dex:             dexID,
+		BelowConfidence: belowConfidence,
+		RulesOnly:       rulesOnly(ctx),
+		Status:          RemapRunning,
+		StartedAt:       time.Now(),
+	}
+	s.jobs[job.ID] = job
+	s.running = job.ID
+	s.pruneJobs()
+
+	// The job outlives the request that started it
+	runCtx := context.Background()
+	if job.RulesOnly {
+		runCtx = WithRulesOnly(runCtx)
+	}
+	go s.run(runCtx, job)
+	return *job, nil
+}
+
+// Job returns a remap job's progress
+func (s *RemapService) Job(id string) (RemapJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return RemapJob{}, fmt.Errorf("remap job not found")
+	}
+	copied := *job
+	copied.Errors = append([]RemapError(nil), job.Errors...)
+	return copied, nil
+}
+
+// run maps a job's catches again, one coffee at a time
+func (s *RemapService) run(ctx context.Context, job *RemapJob) {
+	catches, err := s.pokemon.GetDexEntries(job.Dex)
+	if err != nil {
+		s.finish(job, err)
+		return
+	}
+	targets := []models.CoffeePokemon{}
+	for _, catch := range catches {
+		if job.BelowConfidence == 0 || catch.MappingConfidence < job.BelowConfidence {
+			targets = append(targets, catch)
+		}
+	}
+	sort.Slice(targets, func(i, j int) bool { return targets[i].MappingConfidence < targets[j].MappingConfidence })
+
+	s.mu.Lock()
+	job.Total = len(targets)
+	s.mu.Unlock()
+	log.Printf("INFO: Remap job %s started: %d catches in the %s dex", job.ID, len(targets), job.Dex)
+
+	for _, catch := range targets {
+		mapping, err := s.remap(ctx, catch)
+
+		s.mu.Lock()
+		job.Processed++
+		switch {
+		case err != nil:
+			job.Failed++
+			if len(job.Errors) < remapErrorsKept {
+				job.Errors = append(job.Errors, RemapError{CoffeeID: catch.CoffeeID, Error: err.Error()})
+			}
+		default:
+			job.Remapped++
+			if mapping.PokemonID != catch.PokemonID {
+				job.Changed++
+			}
+		}
+		s.mu.Unlock()
+		if err != nil {
+			log.Printf("ERROR: Remap job %s failed for coffee %s: %v", job.ID, catch.CoffeeID, err)
+		}
+	}
+	s.finish(job, nil)
+}
+
+// remap maps one caught coffee again
+func (s *RemapService) remap(ctx context.Context, catch models.CoffeePokemon) (*models.CoffeePokemon, error) {
+	coffee, err := s.coffees.GetCoffee(catch.CoffeeID)
+	if err != nil {
+		return nil, err
+	}
+	return s.pokemon.ReplaceCoffeePokemon(ctx, coffee, catch.Dex)
+}
+
+// finish records the outcome of a job and frees the slot for the next
+func (s *RemapService) finish(job *RemapJob, err error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	job.FinishedAt = &now
+	job.Status = RemapDone
+	if err != nil {
+		job.Status = RemapFailed
+		job.Error = err.Error()
+	}
+	s.running = ""
+	finished := *job
+	s.mu.Unlock()
+
+	if err != nil {
+		log.Printf("ERROR: Remap job %s failed: %v", job.ID, err)
+		return
+	}
+	log.Printf("INFO: Remap job %s done: %d remapped (%d changed), %d failed", job.ID, finished.Remapped, finished.Changed, finished.Failed)
+	if s.bus != nil {
+		s.bus.Publish(Event{
+			Type:    "pokedex.remapped",
+			Message: fmt.Sprintf("Remapped %d catches in the %s dex, %d to a different Pokemon", finished.Remapped, finished.Dex, finished.Changed),
+			Data: map[string]interface{}{
+				"job_id":   finished.ID,
+				"dex":      finished.Dex,
+				"remapped": finished.Remapped,
+				"changed":  finished.Changed,
+				"failed":   finished.Failed,
+			},
+		})
+	}
+}
+
+// pruneJobs drops the oldest finished jobs beyond remapJobsKept. The caller
+// holds s.mu.
+func (s *RemapService) pruneJobs() {
+	finished := []*RemapJob{}
+	for _, job := range s.jobs {
+		if job.Status != RemapRunning {
+			finished = append(finished, job)
+		}
+	}
+	if len(finished) <= remapJobsKept {
+		return
+	}
+	sort.Slice(finished, func(i, j int) bool { return finished[i].StartedAt.Before(finished[j].StartedAt) })
+	for _, job := range finished[:len(finished)-remapJobsKept] {
+		delete(s.jobs, job.ID)
+	}
+}
@@ -0,0 +1,198 @@
+package service
+
+import (
+	"fmt"
+	"go-coffee-log/models"
+	"go-coffee-log/storage"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Reminder stages, in order of urgency
+const (
+	ReminderApproaching = "approaching"
+	ReminderStale       = "stale"
+)
+
+// Reminder represents a bag that is getting old
+type Reminder struct {
+	CoffeeID     string `json:"coffee_id"`
+	CoffeeName   string `json:"coffee_name"`
+	PokemonName  string `json:"pokemon_name,omitempty"`
+	RoastDate    string `json:"roast_date"`
+	DaysOffRoast int    `json:"days_off_roast"`
+	Stage        string `json:"stage"`
+	Message      string `json:"message"`
+}
+
+// ReminderService flags bags approaching the staleness threshold and
+// publishes an event the first time a bag reaches each stage
+type ReminderService struct {
+	coffeeService  *CoffeeService
+	pokemonStorage storage.PokemonStorage // optional, for Pokemon names
+	bus            *EventBus
+	staleAfterDays int
+	warnDays       int
+	thresholdsMu   sync.RWMutex // guards staleAfterDays and warnDays
+
+	mu       sync.Mutex
+	notified map[string]string // coffee ID -> last stage published
+}
+
+// NewReminderService creates a new reminder service. Bags are "approaching"
+// warnDays before staleAfterDays off roast and "stale" from then on.
+func NewReminderService(
+	coffeeService *CoffeeService,
+	pokemonStorage storage.PokemonStorage,
+	bus *EventBus,
+	staleAfterDays, warnDays int,
+) *ReminderService {
+	return &ReminderService{
+		coffeeService:  coffeeService,
+		pokemonStorage: pokemonStorage,
+		bus:            bus,
+		staleAfterDays: staleAfterDays,
+		warnDays:       warnDays,
+		notified:       make(map[string]string),
+	}
+}
+
+// GetReminders lists bags that are approaching or past the threshold, oldest first.
+// Bags more than twice the threshold off roast are assumed finished and skipped.
+func (s *ReminderService) GetReminders(now time.Time) ([]Reminder, error) {
+	coffees, err := s.coffeeService.ListCoffees()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get coffees: %w", err)
+	}
+
+	pokemonNames := s.pokemonNames()
+	reminders := []Reminder{}
+	for _, coffee := range coffees {
+		if coffee.RoastDate == nil {
+			continue
+		}
+
+		days := coffee.RoastDate.DaysSince(now)
+		stage := s.stageFor(days)
+		if stage == "" {
+			continue
+		}
+
+		reminder := Reminder{
+			CoffeeID:     coffee.ID,
+			CoffeeName:   coffee.Name,
+			PokemonName:  pokemonNames[coffee.ID],
+			RoastDate:    coffee.RoastDate.Format("2006-01-02"),
+			DaysOffRoast: days,
+			Stage:        stage,
+		}
+		reminder.Message = reminderMessage(reminder)
+		reminders = append(reminders, reminder)
+	}
+
+	sort.Slice(reminders, func(i, j int) bool { return reminders[i].DaysOffRoast > reminders[j].DaysOffRoast })
+	return reminders, nil
+}
+
+// Check publishes an event for every bag that reached a new stage since the last check
+func (s *ReminderService) Check(now time.Time) error {
+	reminders, err := s.GetReminders(now)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, reminder := range reminders {
+		if s.notified[reminder.CoffeeID] == reminder.Stage {
+			continue
+		}
+		s.notified[reminder.CoffeeID] = reminder.Stage
+
+		s.bus.Publish(Event{
+			Type:     "reminder." + reminder.Stage,
+			Message:  reminder.Message,
+			CoffeeID: reminder.CoffeeID,
+			Data: map[string]interface{}{
+				"days_off_roast": reminder.DaysOffRoast,
+				"roast_date":     reminder.RoastDate,
+				"pokemon_name":   reminder.PokemonName,
+			},
+		})
+	}
+
+	return nil
+}
+
+// SetThresholds changes the staleness thresholds for subsequent checks
+func (s *ReminderService) SetThresholds(staleAfterDays, warnDays int) {
+	s.thresholdsMu.Lock()
+	defer s.thresholdsMu.Unlock()
+	s.staleAfterDays = staleAfterDays
+	s.warnDays = warnDays
+}
+
+// stageFor returns the reminder stage for a bag, or "" when none applies
+func (s *ReminderService) stageFor(days int) string {
+	s.thresholdsMu.RLock()
+	defer s.thresholdsMu.RUnlock()
+
+	switch {
+	case days > s.staleAfterDays*2:
+		return ""
+	case days >= s.staleAfterDays:
+		return ReminderStale
+	case days >= s.staleAfterDays-s.warnDays:
+		return ReminderApproaching
+	default:
+		return ""
+	}
+}
+
+// reminderMessage renders a reminder, starring the coffee's Pokemon when it has one
+func reminderMessage(r Reminder) string {
+	subject := r.CoffeeName
+	if r.PokemonName != "" {
+		subject = fmt.Sprintf("%s's %s", r.CoffeeName, r.PokemonName)
+	}
+
+	if r.Stage == ReminderStale {
+		if r.PokemonName != "" {
+			return fmt.Sprintf("your %s has fainted — %d days off roast, time to finish the bag", subject, r.DaysOffRoast)
+		}
+		return fmt.Sprintf("your %s has gone stale — %d days off roast, time to finish the bag", subject, r.DaysOffRoast)
+	}
+
+	if r.PokemonName != "" {
+		return fmt.Sprintf("your %s is getting restless — %d days off roast", subject, r.DaysOffRoast)
+	}
+	return fmt.Sprintf("your %s is getting close to stale — %d days off roast", subject, r.DaysOffRoast)
+}
+
+// pokemonNames maps coffee IDs to Pokemon display names (nickname first)
+func (s *ReminderService) pokemonNames() map[string]string {
+	names := make(map[string]string)
+	if s.pokemonStorage == nil {
+		return names
+	}
+
+	mappings, err := s.pokemonStorage.GetAllCoffeePokemon()
+	if err != nil {
+		log.Printf("Reminders: failed to load Pokemon mappings: %v", err)
+		return names
+	}
+	for _, mapping := range mappings {
+		if _, ok := names[mapping.CoffeeID]; ok && mapping.Dex != models.DefaultDex {
+			continue // prefer the Kanto catch of coffees caught in several dexes
+		}
+		name := mapping.PokemonName
+		if mapping.Nickname != "" {
+			name = mapping.Nickname
+		}
+		names[mapping.CoffeeID] = name
+	}
+	return names
+}
@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-coffee-log/models"
+	"go-coffee-log/storage"
+	"log"
+	"sort"
+	"time"
+)
+
+// ReplicaDiff lists the IDs where a secondary differs from the primary
+type ReplicaDiff struct {
+	Missing    []string `json:"missing"`    // on the primary only
+	Extra      []string `json:"extra"`      // on the secondary only
+	Mismatched []string `json:"mismatched"` // on both, with different contents
+}
+
+// consistent reports whether there are no differences
+func (d ReplicaDiff) consistent() bool {
+	return len(d.Missing) == 0 && len(d.Extra) == 0 && len(d.Mismatched) == 0
+}
+
+// ReplicationReport is the outcome of a consistency check
+type ReplicationReport struct {
+	CheckedAt     time.Time   `json:"checked_at"`
+	Consistent    bool        `json:"consistent"`
+	Repaired      bool        `json:"repaired"`
+	WriteFailures int64       `json:"write_failures"` // failed secondary writes since startup
+	Coffees       ReplicaDiff `json:"coffees"`
+	Brews         ReplicaDiff `json:"brews"`
+}
+
+// ReplicationService checks that the secondary store mirrors the primary
+// and can repair it by copying the primary over
+type ReplicationService struct {
+	coffees *storage.ReplicatedStorage
+	brews   *storage.ReplicatedBrewStorage
+	stats   *storage.ReplicaStats
+}
+
+// NewReplicationService creates a new replication service
+func NewReplicationService(coffees *storage.ReplicatedStorage, brews *storage.ReplicatedBrewStorage, stats *storage.ReplicaStats) *ReplicationService {
+	return &ReplicationService{
+		coffees: coffees,
+		brews:   brews,
+		stats:   stats,
+	}
+}
+
+// Check compares the secondary with the primary. With repair, the
+// secondary is then made to match: missing and mismatched entries are
+// copied from the primary and extra entries are deleted.
+func (s *ReplicationService) Check(repair bool) (*ReplicationReport, error) {
+	report := &ReplicationReport{
+		CheckedAt:     time.Now(),
+		WriteFailures: s.stats.Failures(),
+	}
+
+	primaryCoffees, err := s.coffees.Primary.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read primary coffees: %w", err)
+	}
+	secondaryCoffees, err := s.coffees.Secondary.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secondary coffees: %w", err)
+	}
+	primaryBrews, err := s.brews.Primary.GetAllBrews()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read primary brews: %w", err)
+	}
+	secondaryBrews, err := s.brews.Secondary.GetAllBrews()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secondary brews: %w", err)
+	}
+
+	coffeesByID := make(map[string]models.Coffee, len(primaryCoffees))
+	for _, coffee := range primaryCoffees {
+		coffeesByID[coffee.ID] = coffee
+	}
+	report.Coffees = diffReplica(coffeeFingerprints(primaryCoffees), coffeeFingerprints(secondaryCoffees))
+
+	brewsByID := make(map[string]models.Brew, len(primaryBrews))
+	for _, brew := range primaryBrews {
+		brewsByID[brew.ID] = brew
+	}
+	report.Brews = diffReplica(brewFingerprints(primaryBrews), brewFingerprints(secondaryBrews))
+
+	report.Consistent = report.Coffees.consistent() && report.Brews.consistent()
+	if !repair || report.Consistent {
+		return report, nil
+	}
+
+	// Coffees first so brews never reference a coffee the secondary lacks
+	for _, id := range report.Coffees.Missing {
+		if err := s.coffees.Secondary.Save(coffeesByID[id]); err != nil {
+			return report, fmt.Errorf("failed to copy coffee %s: %w", id, err)
+		}
+	}
+	for _, id := range report.Coffees.Mismatched {
+		if err := s.coffees.Secondary.Update(id, coffeesByID[id]); err != nil {
+			return report, fmt.Errorf("failed to update coffee %s: %w", id, err)
+		}
+	}
+	for _, id := range report.Brews.Missing {
+		if err := s.brews.Secondary.SaveBrew(brewsByID[id]); err != nil {
+			return report, fmt.Errorf("failed to copy brew %s: %w", id, err)
+		}
+	}
+	for _, id := range report.Brews.Mismatched {
+		// Brews have no update; replace the row
+		if err := s.brews.Secondary.DeleteBrew(id); err != nil {
+			return report, fmt.Errorf("failed to replace brew %s: %w", id, err)
+		}
+		if err := s.brews.Secondary.SaveBrew(brewsByID[id]); err != nil {
+			return report, fmt.Errorf("failed to replace brew %s: %w", id, err)
+		}
+	}
+	for _, id := range report.Brews.Extra {
+		if err := s.brews.Secondary.DeleteBrew(id); err != nil {
+			return report, fmt.Errorf("failed to delete brew %s: %w", id, err)
+		}
+	}
+	for _, id := range report.Coffees.Extra {
+		if err := s.coffees.Secondary.Delete(id); err != nil {
+			return report, fmt.Errorf("failed to delete coffee %s: %w", id, err)
+		}
+	}
+
+	report.Repaired = true
+	return report, nil
+}
+
+// Run checks consistency periodically and logs any drift until ctx is
+// cancelled. It never repairs on its own.
+func (s *ReplicationService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		report, err := s.Check(false)
+		switch {
+		case err != nil:
+			log.Printf("Replication: check failed: %v", err)
+		case !report.Consistent:
+			log.Printf("Replication: secondary has drifted (coffees: %d missing, %d extra, %d mismatched; brews: %d missing, %d extra, %d mismatched)",
+				len(report.Coffees.Missing), len(report.Coffees.Extra), len(report.Coffees.Mismatched),
+				len(report.Brews.Missing), len(report.Brews.Extra), len(report.Brews.Mismatched))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// coffeeFingerprints maps each coffee ID to its normalized JSON encoding
+func coffeeFingerprints(coffees []models.Coffee) map[string]string {
+	prints := make(map[string]string, len(coffees))
+	for _, coffee := range coffees {
+		data, _ := json.Marshal(normalizeCoffee(coffee))
+		prints[coffee.ID] = string(data)
+	}
+	return prints
+}
+
+// brewFingerprints maps each brew ID to its normalized JSON encoding
+func brewFingerprints(brews []models.Brew) map[string]string {
+	prints := make(map[string]string, len(brews))
+	for _, brew := range brews {
+		data, _ := json.Marshal(normalizeBrew(brew))
+		prints[brew.ID] = string(data)
+	}
+	return prints
+}
+
+// diffReplica compares primary and secondary fingerprints
+func diffReplica(primary, secondary map[string]string) ReplicaDiff {
+	diff := ReplicaDiff{Missing: []string{}, Extra: []string{}, Mismatched: []string{}}
+	for id, fingerprint := range primary {
+		other, ok := secondary[id]
+		switch {
+		case !ok:
+			diff.Missing = append(diff.Missing, id)
+		case other != fingerprint:
+			diff.Mismatched = append(diff.Mismatched, id)
+		}
+	}
+	for id := range secondary {
+		if _, ok := primary[id]; !ok {
+			diff.Extra = append(diff.Extra, id)
+		}
+	}
+	sort.Strings(diff.Missing)
+	sort.Strings(diff.Extra)
+	sort.Strings(diff.Mismatched)
+	return diff
+}
+
+// normalizeCoffee strips differences that come from the backend rather than
+// the data: timestamp precision and time zone, and nil vs empty recipes
+func normalizeCoffee(coffee models.Coffee) models.Coffee {
+	coffee.DialIn = nil
+	coffee.CreatedAt = coffee.CreatedAt.UTC().Truncate(time.Second)
+	coffee.UpdatedAt = coffee.UpdatedAt.UTC().Truncate(time.Second)
+	if len(coffee.Recipe) == 0 {
+		coffee.Recipe = nil
+	}
+	return coffee
+}
+
+// normalizeBrew strips backend-specific timestamp differences
+func normalizeBrew(brew models.Brew) models.Brew {
+	brew.CreatedAt = brew.CreatedAt.UTC().Truncate(time.Second)
+	return brew
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// permanentError marks an error that retrying cannot fix
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// StopRetry wraps err so that Retry and RetryN give up at once and return
+// err instead of trying again
+func StopRetry(err error) error {
+	return permanentError{err: err}
+}
+
+// Retry calls fn until it succeeds or ctx is cancelled, waiting between
+// attempts with exponential backoff that starts at initial and is capped
+// at max. onFailure, if set, is told about each failed attempt and how
+// long until the next one.
+func Retry(ctx context.Context, initial, max time.Duration, fn func() error, onFailure func(attempt int, err error, next time.Duration)) error {
+	return RetryN(ctx, 0, initial, max, fn, onFailure)
+}
+
+// RetryN is Retry limited to attempts calls of fn (unlimited when attempts
+// is 0). It returns the last error once the attempts are used up.
+func RetryN(ctx context.Context, attempts int, initial, max time.Duration, fn func() error, onFailure func(attempt int, err error, next time.Duration)) error {
+	delay := initial
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		var permanent permanentError
+		if errors.As(err, &permanent) {
+			return permanent.err
+		}
+		if attempts > 0 && attempt >= attempts {
+			return err
+		}
+		if onFailure != nil {
+			onFailure(attempt, err, delay)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+		if delay > max {
+			delay = max
+		}
+	}
+}
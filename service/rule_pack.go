@@ -0,0 +1,284 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-coffee-log/models"
+	"go-coffee-log/storage"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// builtinRulePackVersion versions the built-in rules; bump it when
+// initializeTypeRules changes so catches record which rules made them
+const builtinRulePackVersion = "1"
+
+// builtinRulePackLabel names the built-in rules in mapping provenance
+const builtinRulePackLabel = models.BuiltinRulePack + "@" + builtinRulePackVersion
+
+// rulePackLabel names a pack in mapping provenance
+func rulePackLabel(pack models.RulePack) string {
+	return pack.Name + "@" + pack.Version
+}
+
+// RulePackList lists the imported rule packs and names the active one
+type RulePackList struct {
+	Active string            `json:"active"` // "builtin" when no pack is active
+	Packs  []models.RulePack `json:"packs"`  // without their rules
+}
+
+// RulePackService exports the type-mapping rules in use as a shareable
+// rule pack and imports packs from others. The active pack replaces the
+// built-in rules; mapper_rules overrides from the config file still apply
+// on top of it.
+type RulePackService struct {
+	storage        storage.RulePackStorage
+	pokemonService *PokemonService
+	mu             sync.Mutex // serializes activation
+}
+
+// NewRulePackService creates a new rule pack service
+func NewRulePackService(storage storage.RulePackStorage, pokemonService *PokemonService) *RulePackService {
+	return &RulePackService{
+		storage:        storage,
+		pokemonService: pokemonService,
+	}
+}
+
+// Load switches the mapper to the stored active pack, if any. Call it at
+// startup.
+func (s *RulePackService) Load() error {
+	packs, err := s.storage.GetAllPacks()
+	if err != nil {
+		return err
+	}
+	for _, pack := range packs {
+		if pack.Active {
+			if err := s.pokemonService.SetBaseRules(rulePackLabel(pack), packRules(pack)); err != nil {
+				return fmt.Errorf("rule pack %s: %w", pack.Name, err)
+			}
+			log.Printf("INFO: Using mapper rule pack %s %s by %s", pack.Name, pack.Version, pack.Author)
+		}
+	}
+	return nil
+}
+
+// Export returns the rules in use, overrides included, as a pack
+func (s *RulePackService) Export(name, version, author, description string) (models.RulePack, error) {
+	pack := models.RulePack{
+		Name:        name,
+		Version:     version,
+		Author:      author,
+		Description: description,
+		Rules:       make(map[string]models.RulePackRule),
+	}
+	for typeName, rule := range s.pokemonService.MapperRules() {
+		pack.Rules[typeName] = toRulePackRule(rule)
+	}
+	if err := pack.Validate(); err != nil {
+		return models.RulePack{}, fmt.Errorf("invalid rule pack: %w", err)
+	}
+	return pack, nil
+}
+
+// Import stores a pack, replacing an earlier version with the same name,
+// and optionally makes it active. Re-importing the active pack applies
+// the new version right away.
+func (s *RulePackService) Import(pack models.RulePack, activate bool) (models.RulePack, error) {
+	if err := pack.Validate(); err != nil {
+		return models.RulePack{}, fmt.Errorf("invalid rule pack: %w", err)
+	}
+	if err := CheckTypeRules(packRules(pack)); err != nil {
+		return models.RulePack{}, fmt.Errorf("invalid rule pack: %w", err)
+	}
+
+	now := time.Now()
+	pack.ImportedAt = &now
+	pack.Active = false
+	if err := s.storage.SavePack(pack); err != nil {
+		return models.RulePack{}, err
+	}
+
+	stored, err := s.storage.GetPack(pack.Name)
+	if err != nil {
+		return models.RulePack{}, err
+	}
+	if activate || stored.Active {
+		if err := s.Activate(pack.Name); err != nil {
+			return models.RulePack{}, err
+		}
+		stored.Active = true
+	}
+	return stored, nil
+}
+
+// ListPacks returns the imported packs without their rules
+func (s *RulePackService) ListPacks() (RulePackList, error) {
+	packs, err := s.storage.GetAllPacks()
+	if err != nil {
+		return RulePackList{}, err
+	}
+
+	list := RulePackList{Active: models.BuiltinRulePack, Packs: packs}
+	for i := range list.Packs {
+		if list.Packs[i].Active {
+			list.Active = list.Packs[i].Name
+		}
+		list.Packs[i].Rules = nil
+	}
+	return list, nil
+}
+
+// GetPack returns a pack with its rules; "builtin" returns the rules the
+// server ships with
+func (s *RulePackService) GetPack(name string) (models.RulePack, error) {
+	if name != models.BuiltinRulePack {
+		return s.storage.GetPack(name)
+	}
+
+	pack := models.RulePack{
+		Name:    models.BuiltinRulePack,
+		Version: builtinRulePackVersion,
+		Author:  "Coffee Dex",
+		Rules:   make(map[string]models.RulePackRule),
+	}
+	for typeName, rule := range NewPokemonMapper().rules() {
+		pack.Rules[typeName] = toRulePackRule(rule)
+	}
+	return pack, nil
+}
+
+// Activate switches the mapper to a pack; "builtin" goes back to the
+// built-in rules
+func (s *RulePackService) Activate(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if name == models.BuiltinRulePack {
+		if err := s.storage.SetActivePack(""); err != nil {
+			return err
+		}
+		return s.pokemonService.SetBaseRules(builtinRulePackLabel, nil)
+	}
+
+	pack, err := s.storage.GetPack(name)
+	if err != nil {
+		return err
+	}
+	rules := packRules(pack)
+	if err := CheckTypeRules(rules); err != nil {
+		return fmt.Errorf("invalid rule pack: %w", err)
+	}
+	if err := s.storage.SetActivePack(name); err != nil {
+		return err
+	}
+	return s.pokemonService.SetBaseRules(rulePackLabel(pack), rules)
+}
+
+// DeletePack removes an imported pack. Deleting the active pack switches
+// back to the built-in rules.
+func (s *RulePackService) DeletePack(name string) error {
+	if name == models.BuiltinRulePack {
+		return fmt.Errorf("the builtin rule pack cannot be deleted")
+	}
+
+	pack, err := s.storage.GetPack(name)
+	if err != nil {
+		return err
+	}
+	if pack.Active {
+		if err := s.Activate(models.BuiltinRulePack); err != nil {
+			return err
+		}
+	}
+	return s.storage.DeletePack(name)
+}
+
+// ReadRulePackFile loads a rule pack from a JSON file in the format of
+// GET /admin/rule-packs/export and checks it like an import
+func ReadRulePackFile(path string) (models.RulePack, error) {
+	var pack models.RulePack
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return pack, fmt.Errorf("failed to read mapper rules: %w", err)
+	}
+	if err := json.Unmarshal(data, &pack); err != nil {
+		return pack, fmt.Errorf("invalid mapper rules %s: %w", path, err)
+	}
+	if err := pack.Validate(); err != nil {
+		return pack, fmt.Errorf("invalid mapper rules %s: %w", path, err)
+	}
+	if err := CheckTypeRules(packRules(pack)); err != nil {
+		return pack, fmt.Errorf("invalid mapper rules %s: %w", path, err)
+	}
+	return pack, nil
+}
+
+// LoadRulesFile switches the mapper's base rules to the pack in a rules
+// file and returns its name@version. Types the file leaves out use the
+// built-in rule. An invalid file leaves the current rules in place.
+func (pm *PokemonMapper) LoadRulesFile(path string) (string, error) {
+	pack, err := ReadRulePackFile(path)
+	if err != nil {
+		return "", err
+	}
+	label := rulePackLabel(pack)
+	if err := pm.SetBaseRules(label, packRules(pack)); err != nil {
+		return "", err
+	}
+	return label, nil
+}
+
+// toRulePackRule converts a mapper rule to its shareable form
+func toRulePackRule(rule TypeMappingRule) models.RulePackRule {
+	return models.RulePackRule{
+		PrimaryTraits:    toRulePackTraits(rule.PrimaryTraits),
+		SecondaryTraits:  toRulePackTraits(rule.SecondaryTraits),
+		Keywords:         rule.KeywordMatches,
+		ProcessingBonus:  rule.ProcessingBonus,
+		RoastLevelBonus:  rule.RoastLevelBonus,
+		MinimumThreshold: rule.MinimumThreshold,
+	}
+}
+
+// toRulePackTraits converts trait weights to their shareable form
+func toRulePackTraits(traits []TraitWeight) []models.RulePackTrait {
+	converted := make([]models.RulePackTrait, len(traits))
+	for i, tw := range traits {
+		converted[i] = models.RulePackTrait{Trait: tw.Trait, Weight: tw.Weight, Min: tw.Min, Max: tw.Max}
+	}
+	return converted
+}
+
+// packRules converts a pack's rules to mapper rules
+func packRules(pack models.RulePack) map[string]TypeMappingRule {
+	rules := make(map[string]TypeMappingRule, len(pack.Rules))
+	for typeName, rule := range pack.Rules {
+		keywords := make([]string, len(rule.Keywords))
+		for i, keyword := range rule.Keywords {
+			keywords[i] = strings.ToLower(strings.TrimSpace(keyword))
+		}
+		rules[typeName] = TypeMappingRule{
+			Type:             typeName,
+			PrimaryTraits:    fromRulePackTraits(rule.PrimaryTraits),
+			SecondaryTraits:  fromRulePackTraits(rule.SecondaryTraits),
+			KeywordMatches:   keywords,
+			ProcessingBonus:  rule.ProcessingBonus,
+			RoastLevelBonus:  rule.RoastLevelBonus,
+			MinimumThreshold: rule.MinimumThreshold,
+		}
+	}
+	return rules
+}
+
+// fromRulePackTraits converts shareable traits to trait weights
+func fromRulePackTraits(traits []models.RulePackTrait) []TraitWeight {
+	converted := make([]TraitWeight, len(traits))
+	for i, trait := range traits {
+		converted[i] = TraitWeight{Trait: trait.Trait, Weight: trait.Weight, Min: trait.Min, Max: trait.Max}
+	}
+	return converted
+}
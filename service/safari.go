@@ -0,0 +1,331 @@
+package service
+
+import (
+	"fmt"
+	"go-coffee-log/models"
+	"go-coffee-log/storage"
+	"log"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultSafariWindow is how long a safari Pokemon waits for a matching coffee
+const DefaultSafariWindow = 24 * time.Hour
+
+// SafariLevelBonus is the extra levels a safari catch starts with
+const SafariLevelBonus = 10
+
+// SafariService runs the Safari Zone: an attempt rolls a random Pokemon the
+// caller has not caught in their dex profile and turns its type's primary
+// traits into a target coffee spec. The first coffee logged before the
+// attempt expires that meets the spec catches the Pokemon, with bonus
+// levels and a Safari Zone Pokedex entry.
+type SafariService struct {
+	storage        storage.SafariStorage
+	pokemonService *PokemonService
+	pokemonStorage storage.PokemonStorage
+	dexes          *DexService
+	bus            *EventBus
+	window         time.Duration
+	badges         *BadgeService
+
+	mu sync.Mutex // serializes rolling and catching
+}
+
+// NewSafariService creates a safari service and subscribes it to coffees
+// logged through coffeeService; window is how long an attempt stays open
+// (DefaultSafariWindow if not positive)
+func NewSafariService(
+	storage storage.SafariStorage,
+	coffeeService *CoffeeService,
+	pokemonService *PokemonService,
+	pokemonStorage storage.PokemonStorage,
+	dexes *DexService,
+	bus *EventBus,
+	window time.Duration,
+) *SafariService {
+	if window <= 0 {
+		window = DefaultSafariWindow
+	}
+	s := &SafariService{
+		storage:        storage,
+		pokemonService: pokemonService,
+		pokemonStorage: pokemonStorage,
+		dexes:          dexes,
+		bus:            bus,
+		window:         window,
+	}
+	coffeeService.OnCreate(s.catchForCoffee)
+	coffeeService.OnPreview(s.previewCatch)
+	return s
+}
+
+// SetBadgeService awards gym badges for safari catches
+func (s *SafariService) SetBadgeService(badges *BadgeService) {
+	s.badges = badges
+}
+
+// Attempt rolls a new safari Pokemon for a user. A user has one open
+// attempt at a time.
+func (s *SafariService) Attempt(userID string) (models.SafariAttempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	open, err := s.storage.GetOpenAttempts(now)
+	if err != nil {
+		return models.SafariAttempt{}, err
+	}
+	for _, attempt := range open {
+		if attempt.UserID == userID {
+			return models.SafariAttempt{}, fmt.Errorf("safari attempt %s is already active until %s", attempt.ID, attempt.ExpiresAt.Format(time.RFC3339))
+		}
+	}
+
+	dex, err := s.dexes.Selected(userID)
+	if err != nil {
+		return models.SafariAttempt{}, err
+	}
+	candidates, err := s.candidates(dex, open)
+	if err != nil {
+		return models.SafariAttempt{}, err
+	}
+	if len(candidates) == 0 {
+		return models.SafariAttempt{}, fmt.Errorf("no uncaught Pokemon left in the %s dex", dex.Name)
+	}
+
+	pick := candidates[rand.Intn(len(candidates))]
+	attempt := models.SafariAttempt{
+		ID:          uuid.New().String(),
+		UserID:      userID,
+		Dex:         dex.ID,
+		PokemonID:   pick.pokemon.ID,
+		PokemonName: pick.pokemon.Name,
+		Type:        primaryType(pick.pokemon),
+		Hints:       pick.rule.KeywordMatches,
+		ExpiresAt:   now.Add(s.window),
+		CreatedAt:   now,
+	}
+	for _, trait := range pick.rule.PrimaryTraits {
+		attempt.Target = append(attempt.Target, models.SafariTarget{Trait: trait.Trait, Min: trait.Min, Max: trait.Max})
+	}
+	if err := s.storage.SaveAttempt(attempt); err != nil {
+		return models.SafariAttempt{}, err
+	}
+	attempt.SetStatus(now)
+
+	s.bus.Publish(Event{
+		Type:    "safari.encounter",
+		Message: fmt.Sprintf("A wild %s appeared in the Safari Zone! Log a matching %s-type coffee within %s to catch it", attempt.PokemonName, attempt.Type, s.window),
+		Data: map[string]interface{}{
+			"attempt_id": attempt.ID,
+			"pokemon":    attempt.PokemonName,
+			"expires_at": attempt.ExpiresAt.Format(time.RFC3339),
+		},
+	})
+	return attempt, nil
+}
+
+// List returns a user's attempts, newest first
+func (s *SafariService) List(userID string) ([]models.SafariAttempt, error) {
+	attempts, err := s.storage.GetAttempts(userID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for i := range attempts {
+		attempts[i].SetStatus(now)
+	}
+	return attempts, nil
+}
+
+// Get returns one of a user's attempts
+func (s *SafariService) Get(userID, id string) (models.SafariAttempt, error) {
+	attempt, err := s.storage.GetAttempt(id)
+	if err != nil {
+		return models.SafariAttempt{}, err
+	}
+	if attempt.UserID != userID {
+		return models.SafariAttempt{}, fmt.Errorf("safari attempt not found")
+	}
+	attempt.SetStatus(time.Now())
+	return attempt, nil
+}
+
+// safariCandidate is a Pokemon that can be rolled and the rule of the type
+// its target spec comes from
+type safariCandidate struct {
+	pokemon models.Pokemon
+	rule    TypeMappingRule
+}
+
+// candidates returns the dex's uncaught Pokemon whose primary type has a
+// mapper rule, leaving out the ones another open attempt already targets
+func (s *SafariService) candidates(dex models.DexProfile, open []models.SafariAttempt) ([]safariCandidate, error) {
+	all, err := s.pokemonStorage.GetAllPokemon()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Pokemon: %w", err)
+	}
+	catches, err := s.pokemonStorage.GetAllCoffeePokemon()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get catches: %w", err)
+	}
+
+	taken := make(map[int]bool)
+	for _, catch := range filterDex(catches, dex.ID) {
+		taken[catch.PokemonID] = true
+	}
+	for _, attempt := range open {
+		if attempt.Dex == dex.ID {
+			taken[attempt.PokemonID] = true
+		}
+	}
+
+	rules := s.pokemonService.MapperRules()
+	candidates := []safariCandidate{}
+	for _, pokemon := range inDex(all, dex) {
+		if taken[pokemon.ID] {
+			continue
+		}
+		rule, ok := rules[strings.ToLower(primaryType(pokemon))]
+		if !ok || len(rule.PrimaryTraits) == 0 {
+			continue
+		}
+		candidates = append(candidates, safariCandidate{pokemon: pokemon, rule: rule})
+	}
+	return candidates, nil
+}
+
+// primaryType returns the first of a Pokemon's types, e.g. "Grass" for
+// "Grass/Poison"
+func primaryType(pokemon models.Pokemon) string {
+	return strings.TrimSpace(strings.Split(pokemon.Type, "/")[0])
+}
+
+// matches reports whether a coffee meets an attempt's target spec
+func (s *SafariService) matches(attempt models.SafariAttempt, coffee models.Coffee) bool {
+	for _, target := range attempt.Target {
+		value := s.pokemonService.mapper.getTraitValue(coffee.TastingTraits, target.Trait)
+		if value < target.Min || value > target.Max {
+			return false
+		}
+	}
+	return len(attempt.Target) > 0
+}
+
+// catchForCoffee catches the oldest open attempt a newly logged coffee
+// matches. A coffee catches one safari Pokemon at most.
+func (s *SafariService) catchForCoffee(coffee models.Coffee) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	open, err := s.storage.GetOpenAttempts(time.Now())
+	if err != nil {
+		log.Printf("Safari: failed to load attempts for coffee %s: %v", coffee.ID, err)
+		return
+	}
+	for _, attempt := range open {
+		if !s.matches(attempt, coffee) {
+			continue
+		}
+		if err := s.catch(attempt, coffee); err != nil {
+			log.Printf("Safari: failed to catch %s for attempt %s: %v", attempt.PokemonName, attempt.ID, err)
+			continue
+		}
+		return
+	}
+}
+
+// previewCatch reports the Pokemon catchForCoffee would catch with a coffee
+func (s *SafariService) previewCatch(coffee models.Coffee) []CatchPreview {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	open, err := s.storage.GetOpenAttempts(time.Now())
+	if err != nil {
+		log.Printf("Safari: failed to load attempts for preview: %v", err)
+		return nil
+	}
+	for _, attempt := range open {
+		if !s.matches(attempt, coffee) {
+			continue
+		}
+		if used, err := s.pokemonStorage.IsPokemonUsed(attempt.Dex, attempt.PokemonID); err != nil || used {
+			continue
+		}
+		return []CatchPreview{{
+			Source:      "safari",
+			Dex:         attempt.Dex,
+			PokemonID:   attempt.PokemonID,
+			PokemonName: attempt.PokemonName,
+			Level:       min(s.pokemonService.calculateLevel(coffee.Rating)+SafariLevelBonus, 100),
+		}}
+	}
+	return nil
+}
+
+// catch records the attempt's Pokemon as the coffee's catch in the
+// attempt's dex, with the safari bonus, and announces it
+func (s *SafariService) catch(attempt models.SafariAttempt, coffee models.Coffee) error {
+	used, err := s.pokemonStorage.IsPokemonUsed(attempt.Dex, attempt.PokemonID)
+	if err != nil {
+		return fmt.Errorf("failed to check Pokemon usage: %w", err)
+	}
+	if used {
+		return fmt.Errorf("%s was caught in the %s dex meanwhile", attempt.PokemonName, attempt.Dex)
+	}
+	if _, err := s.pokemonStorage.GetCoffeePokemon(coffee.ID, attempt.Dex); err == nil {
+		return fmt.Errorf("coffee %s already has a Pokemon in the %s dex", coffee.ID, attempt.Dex)
+	}
+	pokemon, err := s.pokemonStorage.GetPokemonByID(attempt.PokemonID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	level := min(s.pokemonService.calculateLevel(coffee.Rating)+SafariLevelBonus, 100)
+	mapping := models.CoffeePokemon{
+		ID:                uuid.New().String(),
+		CoffeeID:          coffee.ID,
+		Dex:               attempt.Dex,
+		PokemonID:         pokemon.ID,
+		PokemonName:       pokemon.Name,
+		Level:             level,
+		MappingConfidence: 1,
+		LLMDescription: fmt.Sprintf("Safari Zone catch! %s was lured out by a coffee that met its %s-type spec in %s.\n\nType Analysis: %s",
+			pokemon.Name, attempt.Type, now.Sub(attempt.CreatedAt).Round(time.Minute), s.pokemonService.describeTypes(coffee, strings.ToLower(attempt.Type), "")),
+		TraitMapping: s.pokemonService.buildTraitMapping(coffee.TastingTraits, *pokemon),
+		Provenance:   s.pokemonService.newProvenance(models.MappingMethodSafari, nil),
+		CreatedAt:    now,
+	}
+	if err := s.pokemonStorage.ReservePokemon(mapping); err != nil {
+		return fmt.Errorf("failed to create Pokemon mapping: %w", err)
+	}
+	s.pokemonService.live.Publish(ResourcePokemon, ChangeCreated, coffee.ID, mapping)
+	if _, err := s.storage.CatchAttempt(attempt.ID, coffee.ID, level, now); err != nil {
+		return err
+	}
+
+	log.Printf("INFO: Safari attempt %s caught %s with coffee %s", attempt.ID, pokemon.Name, coffee.ID)
+	s.bus.Publish(Event{
+		Type:     "safari.caught",
+		Message:  fmt.Sprintf("Gotcha! %s was caught in the Safari Zone with %s (Lv. %d)", pokemon.Name, coffee.Name, level),
+		CoffeeID: coffee.ID,
+		Data: map[string]interface{}{
+			"attempt_id": attempt.ID,
+			"pokemon":    pokemon.Name,
+			"level":      level,
+		},
+	})
+
+	if s.badges != nil {
+		if _, err := s.badges.Award(attempt.UserID); err != nil {
+			log.Printf("ERROR: Failed to award badges: %v", err)
+		}
+	}
+	return nil
+}
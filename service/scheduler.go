@@ -0,0 +1,274 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JobStatus reports a scheduled job's schedule and recent runs
+type JobStatus struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Schedule    string     `json:"schedule"` // empty when the job only runs on demand
+	Running     bool       `json:"running"`
+	NextRun     *time.Time `json:"next_run,omitempty"`
+	LastRun     *time.Time `json:"last_run,omitempty"`
+	LastSeconds float64    `json:"last_duration_seconds"`
+	LastError   string     `json:"last_error,omitempty"`
+	Runs        int        `json:"runs"`
+	Failures    int        `json:"failures"`
+}
+
+// scheduledJob is a registered job and its state. Guarded by Scheduler.mu.
+type scheduledJob struct {
+	status          JobStatus
+	run             func(context.Context) error
+	defaultSchedule string
+	schedule        *CronSchedule
+}
+
+// Scheduler runs background jobs on cron schedules and on demand. A job
+// never overlaps itself: a run that comes due while the previous one is
+// still going is skipped.
+type Scheduler struct {
+	mu   sync.Mutex
+	jobs map[string]*scheduledJob
+	ctx  context.Context
+	wake chan struct{}
+}
+
+// NewScheduler creates a scheduler with no jobs
+func NewScheduler() *Scheduler {
+	return &Scheduler{
+		jobs: make(map[string]*scheduledJob),
+		ctx:  context.Background(),
+		wake: make(chan struct{}, 1),
+	}
+}
+
+// Register adds a job. An empty schedule registers a job that only runs
+// when triggered. Register jobs at startup, before calling Run.
+func (s *Scheduler) Register(name, description, schedule string, run func(context.Context) error) error {
+	job := &scheduledJob{
+		status:          JobStatus{Name: name, Description: description},
+		run:             run,
+		defaultSchedule: schedule,
+	}
+	if err := s.setSchedule(job, schedule, time.Now()); err != nil {
+		return fmt.Errorf("job %s: %w", name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.jobs[name]; ok {
+		return fmt.Errorf("job %s is already registered", name)
+	}
+	s.jobs[name] = job
+	return nil
+}
+
+// SetSchedules replaces job schedules, e.g. from a reloaded config file.
+// Jobs missing from schedules go back to the schedule they were registered
+// with; "off" or "" disables a job. Nothing changes unless every entry is
+// valid.
+func (s *Scheduler) SetSchedules(schedules map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for name, expr := range schedules {
+		if _, ok := s.jobs[name]; !ok {
+			return fmt.Errorf("unknown job %q, expected one of %s", name, strings.Join(s.names(), ", "))
+		}
+		if expr != "" && expr != "off" {
+			if _, err := ParseCron(expr); err != nil {
+				return fmt.Errorf("job %s: %w", name, err)
+			}
+		}
+	}
+
+	now := time.Now()
+	for name, job := range s.jobs {
+		expr, ok := schedules[name]
+		if !ok {
+			expr = job.defaultSchedule
+		}
+		if expr == job.status.Schedule {
+			continue
+		}
+		if err := s.setSchedule(job, expr, now); err != nil {
+			return err
+		}
+	}
+	s.poke()
+	return nil
+}
+
+// setSchedule parses expr and computes the job's next run. Caller holds mu
+// or owns job.
+func (s *Scheduler) setSchedule(job *scheduledJob, expr string, now time.Time) error {
+	if expr == "off" {
+		expr = ""
+	}
+	job.status.Schedule = expr
+	job.schedule = nil
+	job.status.NextRun = nil
+	if expr == "" {
+		return nil
+	}
+
+	schedule, err := ParseCron(expr)
+	if err != nil {
+		return err
+	}
+	job.schedule = schedule
+	if next := schedule.Next(now); !next.IsZero() {
+		job.status.NextRun = &next
+	}
+	return nil
+}
+
+// Jobs returns the status of every job, by name
+func (s *Scheduler) Jobs() []JobStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statuses := []JobStatus{}
+	for _, name := range s.names() {
+		statuses = append(statuses, s.jobs[name].status)
+	}
+	return statuses
+}
+
+// Job returns the status of one job
+func (s *Scheduler) Job(name string) (JobStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[name]
+	if !ok {
+		return JobStatus{}, fmt.Errorf("job not found")
+	}
+	return job.status, nil
+}
+
+// Trigger starts a job now, outside its schedule
+func (s *Scheduler) Trigger(name string) (JobStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[name]
+	if !ok {
+		return JobStatus{}, fmt.Errorf("job not found")
+	}
+	if job.status.Running {
+		return JobStatus{}, fmt.Errorf("job %s is already running", name)
+	}
+	s.start(name, job)
+	return job.status, nil
+}
+
+// Run starts due jobs until ctx is cancelled
+func (s *Scheduler) Run(ctx context.Context) {
+	s.mu.Lock()
+	s.ctx = ctx
+	s.mu.Unlock()
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		case <-s.wake:
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+		}
+
+		timer.Reset(s.startDue(time.Now()))
+	}
+}
+
+// startDue starts every job whose next run has come and returns how long
+// to wait until the next one is due
+func (s *Scheduler) startDue(now time.Time) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wait := time.Hour
+	for name, job := range s.jobs {
+		if job.status.NextRun == nil {
+			continue
+		}
+		if !job.status.NextRun.After(now) {
+			if job.status.Running {
+				log.Printf("Scheduler: skipping %s, the previous run is still going", name)
+			} else {
+				s.start(name, job)
+			}
+			next := job.schedule.Next(now)
+			job.status.NextRun = nil
+			if !next.IsZero() {
+				job.status.NextRun = &next
+			}
+		}
+		if job.status.NextRun != nil && job.status.NextRun.Sub(now) < wait {
+			wait = job.status.NextRun.Sub(now)
+		}
+	}
+	return wait
+}
+
+// start runs a job in the background. Caller holds mu.
+func (s *Scheduler) start(name string, job *scheduledJob) {
+	job.status.Running = true
+	ctx := s.ctx
+
+	go func() {
+		started := time.Now()
+		err := job.run(ctx)
+		if err != nil {
+			log.Printf("Scheduler: job %s failed: %v", name, err)
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		job.status.Running = false
+		job.status.LastRun = &started
+		job.status.LastSeconds = time.Since(started).Seconds()
+		job.status.Runs++
+		job.status.LastError = ""
+		if err != nil {
+			job.status.LastError = err.Error()
+			job.status.Failures++
+		}
+	}()
+}
+
+// poke wakes Run so it picks up changed schedules
+func (s *Scheduler) poke() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// names returns the job names in order. Caller holds mu.
+func (s *Scheduler) names() []string {
+	names := make([]string, 0, len(s.jobs))
+	for name := range s.jobs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,278 @@
+package service
+
+import (
+	"fmt"
+	"go-coffee-log/models"
+	"go-coffee-log/storage"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CategoryResult aggregates the judges' scores in one category
+type CategoryResult struct {
+	Name    string  `json:"name"`
+	Weight  float64 `json:"weight"`
+	Average float64 `json:"average"`
+	Min     float64 `json:"min"`
+	Max     float64 `json:"max"`
+	Spread  float64 `json:"spread"` // max - min; a wide spread flags judges to calibrate
+}
+
+// JudgeTotal is one judge's weighted total
+type JudgeTotal struct {
+	Judge string  `json:"judge"`
+	Total float64 `json:"total"`
+}
+
+// ScoresheetResult is the aggregate score of a scoresheet. A judge's total
+// is the weighted sum of their category scores; the sheet's total is the
+// mean of the judges' totals.
+type ScoresheetResult struct {
+	Scoresheet  models.Scoresheet `json:"scoresheet"`
+	CoffeeName  string            `json:"coffee_name"`
+	Judges      int               `json:"judges"`
+	Categories  []CategoryResult  `json:"categories"`
+	JudgeTotals []JudgeTotal      `json:"judge_totals"`
+	Total       float64           `json:"total"`
+	MaxTotal    float64           `json:"max_total"`
+	Percent     float64           `json:"percent"`
+}
+
+// Standing is a scoresheet's place in its competition
+type Standing struct {
+	Rank         int     `json:"rank"` // 0 until a judge has scored the sheet; ties share a rank
+	ScoresheetID string  `json:"scoresheet_id"`
+	CoffeeID     string  `json:"coffee_id"`
+	CoffeeName   string  `json:"coffee_name"`
+	Competitor   string  `json:"competitor"`
+	Judges       int     `json:"judges"`
+	Total        float64 `json:"total"`
+	Percent      float64 `json:"percent"`
+}
+
+// ScoresheetService runs small home competitions: scoresheets attached to
+// coffees, scored by several judges in weighted categories
+type ScoresheetService struct {
+	storage       storage.ScoresheetStorage
+	coffeeService *CoffeeService
+}
+
+// NewScoresheetService creates a new scoresheet service
+func NewScoresheetService(scoresheetStorage storage.ScoresheetStorage, coffeeService *CoffeeService) *ScoresheetService {
+	return &ScoresheetService{
+		storage:       scoresheetStorage,
+		coffeeService: coffeeService,
+	}
+}
+
+// CreateScoresheet attaches a new scoresheet to a coffee. Without
+// categories it uses the Brewers Cup ones.
+func (s *ScoresheetService) CreateScoresheet(coffeeID string, sheet models.Scoresheet) (models.Scoresheet, error) {
+	if _, err := s.coffeeService.GetCoffee(coffeeID); err != nil {
+		return models.Scoresheet{}, err
+	}
+	sheet.CoffeeID = coffeeID
+	if err := sheet.Validate(); err != nil {
+		return models.Scoresheet{}, err
+	}
+	sheet.ID = uuid.New().String()
+	sheet.CreatedAt = time.Now()
+
+	if err := s.storage.SaveScoresheet(sheet); err != nil {
+		return models.Scoresheet{}, err
+	}
+	return sheet, nil
+}
+
+// GetScoresheet retrieves a scoresheet
+func (s *ScoresheetService) GetScoresheet(id string) (models.Scoresheet, error) {
+	return s.storage.GetScoresheet(id)
+}
+
+// ListScoresheets returns the scoresheets of a coffee or of a competition;
+// empty filters match everything
+func (s *ScoresheetService) ListScoresheets(coffeeID, competition string) ([]models.Scoresheet, error) {
+	if coffeeID != "" {
+		if _, err := s.coffeeService.GetCoffee(coffeeID); err != nil {
+			return nil, err
+		}
+	}
+
+	sheets, err := s.storage.GetAllScoresheets()
+	if err != nil {
+		return nil, err
+	}
+	kept := []models.Scoresheet{}
+	for _, sheet := range sheets {
+		if coffeeID != "" && sheet.CoffeeID != coffeeID {
+			continue
+		}
+		if competition != "" && sheet.Competition != competition {
+			continue
+		}
+		kept = append(kept, sheet)
+	}
+	return kept, nil
+}
+
+// DeleteScoresheet removes a scoresheet and its scores
+func (s *ScoresheetService) DeleteScoresheet(id string) error {
+	return s.storage.DeleteScoresheet(id)
+}
+
+// ScoreJudge records one judge's scores, replacing any they gave before
+func (s *ScoresheetService) ScoreJudge(sheetID string, score models.JudgeScore) (models.JudgeScore, error) {
+	sheet, err := s.storage.GetScoresheet(sheetID)
+	if err != nil {
+		return models.JudgeScore{}, err
+	}
+	if err := score.Validate(sheet); err != nil {
+		return models.JudgeScore{}, err
+	}
+	score.ScoresheetID = sheetID
+	score.UpdatedAt = time.Now()
+
+	if err := s.storage.SaveJudgeScore(score); err != nil {
+		return models.JudgeScore{}, err
+	}
+	return score, nil
+}
+
+// RemoveJudge deletes a judge's scores from a sheet
+func (s *ScoresheetService) RemoveJudge(sheetID, judge string) error {
+	if _, err := s.storage.GetScoresheet(sheetID); err != nil {
+		return err
+	}
+	return s.storage.DeleteJudgeScore(sheetID, judge)
+}
+
+// Results aggregates the judges' scores on a sheet
+func (s *ScoresheetService) Results(sheetID string) (*ScoresheetResult, error) {
+	sheet, err := s.storage.GetScoresheet(sheetID)
+	if err != nil {
+		return nil, err
+	}
+	coffee, err := s.coffeeService.GetCoffee(sheet.CoffeeID)
+	if err != nil {
+		return nil, err
+	}
+	scores, err := s.storage.GetJudgeScores(sheetID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := aggregateScores(sheet, scores)
+	result.CoffeeName = coffee.Name
+	return result, nil
+}
+
+// Standings ranks the sheets of a competition by total, best first
+func (s *ScoresheetService) Standings(competition string) ([]Standing, error) {
+	if competition == "" {
+		return nil, fmt.Errorf("competition is required")
+	}
+	sheets, err := s.ListScoresheets("", competition)
+	if err != nil {
+		return nil, err
+	}
+	coffees, err := s.coffeeService.ListCoffees()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get coffees: %w", err)
+	}
+	names := make(map[string]string, len(coffees))
+	for _, coffee := range coffees {
+		names[coffee.ID] = coffee.Name
+	}
+
+	standings := []Standing{}
+	for _, sheet := range sheets {
+		name, ok := names[sheet.CoffeeID]
+		if !ok {
+			continue // the coffee was deleted
+		}
+		scores, err := s.storage.GetJudgeScores(sheet.ID)
+		if err != nil {
+			return nil, err
+		}
+		result := aggregateScores(sheet, scores)
+		standings = append(standings, Standing{
+			ScoresheetID: sheet.ID,
+			CoffeeID:     sheet.CoffeeID,
+			CoffeeName:   name,
+			Competitor:   sheet.Competitor,
+			Judges:       result.Judges,
+			Total:        result.Total,
+			Percent:      result.Percent,
+		})
+	}
+
+	// Sheets are compared by percentage, so ones on different scales rank fairly
+	sort.SliceStable(standings, func(i, j int) bool {
+		if (standings[i].Judges > 0) != (standings[j].Judges > 0) {
+			return standings[i].Judges > 0
+		}
+		return standings[i].Percent > standings[j].Percent
+	})
+	for i := range standings {
+		switch {
+		case standings[i].Judges == 0:
+		case i > 0 && standings[i-1].Judges > 0 && standings[i].Percent == standings[i-1].Percent:
+			standings[i].Rank = standings[i-1].Rank
+		default:
+			standings[i].Rank = i + 1
+		}
+	}
+	return standings, nil
+}
+
+// aggregateScores computes per-category and per-judge results for a sheet
+func aggregateScores(sheet models.Scoresheet, scores []models.JudgeScore) *ScoresheetResult {
+	result := &ScoresheetResult{
+		Scoresheet:  sheet,
+		Judges:      len(scores),
+		Categories:  []CategoryResult{},
+		JudgeTotals: []JudgeTotal{},
+	}
+
+	weightSum := 0.0
+	for _, category := range sheet.Categories {
+		weightSum += category.Weight
+		categoryResult := CategoryResult{Name: category.Name, Weight: category.Weight}
+		for i, score := range scores {
+			value := score.Scores[category.Name]
+			categoryResult.Average += value
+			if i == 0 || value < categoryResult.Min {
+				categoryResult.Min = value
+			}
+			if i == 0 || value > categoryResult.Max {
+				categoryResult.Max = value
+			}
+		}
+		if len(scores) > 0 {
+			categoryResult.Average = math.Round(categoryResult.Average/float64(len(scores))*100) / 100
+			categoryResult.Spread = categoryResult.Max - categoryResult.Min
+		}
+		result.Categories = append(result.Categories, categoryResult)
+	}
+	result.MaxTotal = sheet.MaxScore * weightSum
+
+	totalSum := 0.0
+	for _, score := range scores {
+		total := 0.0
+		for _, category := range sheet.Categories {
+			total += score.Scores[category.Name] * category.Weight
+		}
+		totalSum += total
+		result.JudgeTotals = append(result.JudgeTotals, JudgeTotal{Judge: score.Judge, Total: math.Round(total*100) / 100})
+	}
+	if len(scores) > 0 {
+		result.Total = math.Round(totalSum/float64(len(scores))*100) / 100
+	}
+	if result.MaxTotal > 0 {
+		result.Percent = math.Round(result.Total/result.MaxTotal*1000) / 10
+	}
+	return result
+}
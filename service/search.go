@@ -0,0 +1,151 @@
+package service
+
+import (
+	"fmt"
+	"go-coffee-log/models"
+	"go-coffee-log/storage"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SavedSearchService saves coffee searches, re-runs them, and notifies
+// their webhooks when a newly logged coffee matches
+type SavedSearchService struct {
+	storage       storage.SavedSearchStorage
+	coffeeService *CoffeeService
+	outbox        *Outbox // optional, retries failed webhook deliveries
+}
+
+// NewSavedSearchService creates a new saved search service and subscribes
+// it to coffees logged through coffeeService
+func NewSavedSearchService(storage storage.SavedSearchStorage, coffeeService *CoffeeService) *SavedSearchService {
+	s := &SavedSearchService{
+		storage:       storage,
+		coffeeService: coffeeService,
+	}
+	coffeeService.OnCreate(s.notifyMatches)
+	return s
+}
+
+// SetOutbox queues webhook notifications in the outbox instead of sending
+// them once
+func (s *SavedSearchService) SetOutbox(outbox *Outbox) {
+	s.outbox = outbox
+}
+
+// SaveSearch stores a named search
+func (s *SavedSearchService) SaveSearch(search models.SavedSearch) (models.SavedSearch, error) {
+	search, err := newSearch(search)
+	if err != nil {
+		return models.SavedSearch{}, err
+	}
+
+	if err := s.storage.SaveSearch(search); err != nil {
+		return models.SavedSearch{}, err
+	}
+	return search, nil
+}
+
+// PreviewSaveSearch validates a search like SaveSearch, without saving it
+func (s *SavedSearchService) PreviewSaveSearch(search models.SavedSearch) (DryRun, error) {
+	search, err := newSearch(search)
+	if err != nil {
+		return DryRun{}, err
+	}
+	return previewCreate(search), nil
+}
+
+// newSearch gives a search its ID and validates it
+func newSearch(search models.SavedSearch) (models.SavedSearch, error) {
+	search.ID = uuid.New().String()
+	search.CreatedAt = time.Now()
+	if err := search.Validate(); err != nil {
+		return models.SavedSearch{}, err
+	}
+	return search, nil
+}
+
+// GetSearch retrieves a saved search
+func (s *SavedSearchService) GetSearch(id string) (models.SavedSearch, error) {
+	return s.storage.GetSearch(id)
+}
+
+// ListSearches returns every saved search
+func (s *SavedSearchService) ListSearches() ([]models.SavedSearch, error) {
+	return s.storage.GetAllSearches()
+}
+
+// DeleteSearch removes a saved search
+func (s *SavedSearchService) DeleteSearch(id string) error {
+	return s.storage.DeleteSearch(id)
+}
+
+// PreviewDeleteSearch reports the search DeleteSearch would remove
+func (s *SavedSearchService) PreviewDeleteSearch(id string) (DryRun, error) {
+	search, err := s.storage.GetSearch(id)
+	if err != nil {
+		return DryRun{}, err
+	}
+	return previewDelete(search), nil
+}
+
+// Results runs a saved search against the current collection
+func (s *SavedSearchService) Results(id string) ([]models.Coffee, error) {
+	search, err := s.storage.GetSearch(id)
+	if err != nil {
+		return nil, err
+	}
+	coffees, err := s.coffeeService.ListCoffees()
+	if err != nil {
+		return nil, err
+	}
+
+	matches := []models.Coffee{}
+	for _, coffee := range coffees {
+		if search.Query.Matches(coffee) {
+			matches = append(matches, coffee)
+		}
+	}
+	return matches, nil
+}
+
+// notifyMatches sends a "search.matched" event to the webhook of every
+// saved search the new coffee matches
+func (s *SavedSearchService) notifyMatches(coffee models.Coffee) {
+	searches, err := s.storage.GetAllSearches()
+	if err != nil {
+		log.Printf("ERROR: Failed to load saved searches for notifications: %v", err)
+		return
+	}
+
+	for _, search := range searches {
+		if search.WebhookURL == "" || !search.Query.Matches(coffee) {
+			continue
+		}
+		event := Event{
+			ID:        uuid.New().String(),
+			Type:      "search.matched",
+			Message:   fmt.Sprintf("%s matches your saved search %q", coffee.Name, search.Name),
+			CoffeeID:  coffee.ID,
+			CreatedAt: time.Now(),
+			Data: map[string]interface{}{
+				"search_id":   search.ID,
+				"search_name": search.Name,
+				"coffee":      coffee,
+			},
+		}
+		if s.outbox != nil {
+			if err := s.outbox.EnqueueWebhook(search.WebhookURL, event); err != nil {
+				log.Printf("Events: failed to queue webhook for saved search %s: %v", search.ID, err)
+			}
+			continue
+		}
+		go func(sink *WebhookSink, searchID string) {
+			if err := sink.Send(event); err != nil {
+				log.Printf("Events: webhook delivery failed for saved search %s: %v", searchID, err)
+			}
+		}(NewWebhookSink(search.WebhookURL), search.ID)
+	}
+}
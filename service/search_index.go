@@ -0,0 +1,90 @@
+package service
+
+import (
+	"go-coffee-log/storage"
+	"math"
+	"sort"
+	"strings"
+)
+
+// prefixMatchWeight discounts a query word matching only the start of an
+// indexed word, e.g. "choc" in "chocolate"
+const prefixMatchWeight = 0.5
+
+// searchField is one weighted field of an indexed document
+type searchField struct {
+	name   string
+	text   string
+	weight float64
+}
+
+// searchIndex is an in-memory inverted index scored by TF-IDF, standing in
+// for the database full-text index when there is none
+type searchIndex struct {
+	docs     []storage.SearchHit
+	postings map[string]map[int]float64 // word -> document -> weighted frequency
+	words    []string                   // sorted vocabulary, for prefix lookups
+}
+
+// newSearchIndex creates an empty index
+func newSearchIndex() *searchIndex {
+	return &searchIndex{postings: make(map[string]map[int]float64)}
+}
+
+// add indexes a document under the given fields
+func (idx *searchIndex) add(hit storage.SearchHit, fields []searchField) {
+	doc := len(idx.docs)
+	hit.Fields = make(map[string]string, len(fields))
+	for _, field := range fields {
+		hit.Fields[field.name] = field.text
+		for _, word := range storage.SearchTerms(field.text) {
+			if idx.postings[word] == nil {
+				idx.postings[word] = make(map[int]float64)
+				idx.words = append(idx.words, word)
+			}
+			idx.postings[word][doc] += field.weight
+		}
+	}
+	idx.docs = append(idx.docs, hit)
+}
+
+// search returns up to limit documents matching any query word, best first
+func (idx *searchIndex) search(query string, limit int) []storage.SearchHit {
+	sort.Strings(idx.words)
+
+	scores := make(map[int]float64)
+	for _, term := range storage.SearchTerms(query) {
+		start := sort.SearchStrings(idx.words, term)
+		for _, word := range idx.words[start:] {
+			if !strings.HasPrefix(word, term) {
+				break
+			}
+			postings := idx.postings[word]
+			idf := math.Log(1 + float64(len(idx.docs))/float64(len(postings)))
+			weight := 1.0
+			if word != term {
+				weight = prefixMatchWeight
+			}
+			for doc, frequency := range postings {
+				scores[doc] += frequency * idf * weight
+			}
+		}
+	}
+
+	hits := make([]storage.SearchHit, 0, len(scores))
+	for doc, score := range scores {
+		hit := idx.docs[doc]
+		hit.Score = math.Round(score*1000) / 1000
+		hits = append(hits, hit)
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Title < hits[j].Title
+	})
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits
+}
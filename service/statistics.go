@@ -6,12 +6,14 @@ import (
 	"go-coffee-log/storage"
 	"math"
 	"sort"
+	"strings"
 )
 
 // StatisticsService handles analytics and statistics calculations
 type StatisticsService struct {
 	coffeeStorage  storage.CoffeeStorage
 	pokemonStorage storage.PokemonStorage
+	brewerStorage  storage.BrewerStorage // optional, enables pokeball stats
 	mapper         *PokemonMapper
 }
 
@@ -27,6 +29,11 @@ func NewStatisticsService(
 	}
 }
 
+// SetBrewers enables statistics by the pokeball type of the brewer used
+func (s *StatisticsService) SetBrewers(brewerStorage storage.BrewerStorage) {
+	s.brewerStorage = brewerStorage
+}
+
 // Statistics represents overall coffee collection statistics
 type Statistics struct {
 	// Basic counts
@@ -59,6 +66,7 @@ type Statistics struct {
 	
 	// Brewer analysis
 	BrewerStats       map[string]BrewerStat     `json:"brewer_stats"`
+	PokeballStats     map[string]PokeballStat   `json:"pokeball_stats,omitempty"` // by brewer pokeball type
 	
 	// Confidence metrics
 	AverageConfidence float64                   `json:"average_confidence"`
@@ -95,6 +103,15 @@ type BrewerStat struct {
 	AvgBrewTime   float64 `json:"avg_brew_time_seconds"`
 }
 
+// PokeballStat represents statistics for the coffees brewed with brewers of
+// one pokeball type
+type PokeballStat struct {
+	Brewers       []string `json:"brewers"`
+	Count         int      `json:"count"`
+	AverageRating float64  `json:"average_rating"`
+	AvgBrewTime   float64  `json:"avg_brew_time_seconds"`
+}
+
 // TraitRanges represents min/max ranges for tasting traits
 type TraitRanges struct {
 	BerryRange      Range `json:"berry_range"`
@@ -117,10 +134,46 @@ type Range struct {
 	Max int `json:"max"`
 }
 
-// CalculateStatistics computes all statistics from the database
-func (s *StatisticsService) CalculateStatistics() (*Statistics, error) {
+// StatisticsFilter limits which coffees statistics are computed over
+type StatisticsFilter struct {
+	FavoritesOnly bool
+	CoffeeIDs     map[string]bool // e.g. the coffees of a collection; nil means all
+}
+
+// active reports whether the filter excludes anything
+func (f StatisticsFilter) active() bool {
+	return f.FavoritesOnly || f.CoffeeIDs != nil
+}
+
+// apply keeps the coffees the filter includes and their Pokemon mappings
+func (f StatisticsFilter) apply(coffees []models.Coffee, mappings []models.CoffeePokemon) ([]models.Coffee, []models.CoffeePokemon) {
+	included := make(map[string]bool)
+	var kept []models.Coffee
+	for _, coffee := range coffees {
+		if f.FavoritesOnly && !coffee.Favorite {
+			continue
+		}
+		if f.CoffeeIDs != nil && !f.CoffeeIDs[coffee.ID] {
+			continue
+		}
+		included[coffee.ID] = true
+		kept = append(kept, coffee)
+	}
+	
+	var keptMappings []models.CoffeePokemon
+	for _, mapping := range mappings {
+		if included[mapping.CoffeeID] {
+			keptMappings = append(keptMappings, mapping)
+		}
+	}
+	return kept, keptMappings
+}
+
+// CalculateStatistics computes all statistics from the database, over the
+// coffees the filter includes
+func (s *StatisticsService) CalculateStatistics(filter StatisticsFilter) (*Statistics, error) {
 	// Get all coffees and pokemon mappings
-	coffees, err := s.coffeeStorage.GetAll()
+	allCoffees, err := s.coffeeStorage.GetAll()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get coffees: %w", err)
 	}
@@ -129,6 +182,14 @@ func (s *StatisticsService) CalculateStatistics() (*Statistics, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to get pokemon mappings: %w", err)
 	}
+	// Statistics follow the classic Kanto dex; other dex profiles report
+	// their own progress under /pokedex/profiles
+	pokemonMappings = filterDex(pokemonMappings, models.DefaultDex)
+	
+	coffees := allCoffees
+	if filter.active() {
+		coffees, pokemonMappings = filter.apply(allCoffees, pokemonMappings)
+	}
 	
 	stats := &Statistics{
 		TotalCoffees:      len(coffees),
@@ -149,6 +210,13 @@ func (s *StatisticsService) CalculateStatistics() (*Statistics, error) {
 	s.calculateRoastDistribution(coffees, stats)
 	s.calculateTraitAverages(coffees, stats)
 	s.calculateBrewerStats(coffees, stats)
+	if s.brewerStorage != nil {
+		brewers, err := s.brewerStorage.GetAllBrewers()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get brewers: %w", err)
+		}
+		s.calculatePokeballStats(coffees, brewers, stats)
+	}
 	s.calculateConfidenceMetrics(pokemonMappings, stats)
 	
 	return stats, nil
@@ -468,6 +536,59 @@ func (s *StatisticsService) calculateBrewerStats(coffees []models.Coffee, stats
 	}
 }
 
+// calculatePokeballStats groups coffees by the pokeball type of their
+// brewer. A coffee's brewer is the one its brewer_id names or, for coffees
+// logged before the link existed, the one its dripper names.
+func (s *StatisticsService) calculatePokeballStats(coffees []models.Coffee, brewers []models.Brewer, stats *Statistics) {
+	stats.PokeballStats = make(map[string]PokeballStat)
+	byID := make(map[string]models.Brewer)
+	for _, brewer := range brewers {
+		byID[brewer.ID] = brewer
+		stat := stats.PokeballStats[brewer.PokeballType]
+		stat.Brewers = append(stat.Brewers, brewer.Name)
+		stats.PokeballStats[brewer.PokeballType] = stat
+	}
+	
+	ratingSums := make(map[string]int)
+	timeSums := make(map[string]float64)
+	timeCounts := make(map[string]int)
+	for _, coffee := range coffees {
+		brewer, ok := byID[coffee.BrewerID]
+		if !ok {
+			dripper := strings.TrimSpace(coffee.Dripper)
+			for _, candidate := range brewers {
+				if dripper != "" && strings.EqualFold(dripper, candidate.Name) {
+					brewer, ok = candidate, true
+					break
+				}
+			}
+		}
+		if !ok {
+			continue
+		}
+		
+		stat := stats.PokeballStats[brewer.PokeballType]
+		stat.Count++
+		stats.PokeballStats[brewer.PokeballType] = stat
+		ratingSums[brewer.PokeballType] += coffee.Rating
+		if brewTime := float64(coffee.EndTime.Minutes*60 + coffee.EndTime.Seconds); brewTime > 0 {
+			timeSums[brewer.PokeballType] += brewTime
+			timeCounts[brewer.PokeballType]++
+		}
+	}
+	
+	for pokeball, stat := range stats.PokeballStats {
+		sort.Strings(stat.Brewers)
+		if stat.Count > 0 {
+			stat.AverageRating = math.Round(float64(ratingSums[pokeball])/float64(stat.Count)*10) / 10
+		}
+		if timeCounts[pokeball] > 0 {
+			stat.AvgBrewTime = math.Round(timeSums[pokeball]/float64(timeCounts[pokeball])*10) / 10
+		}
+		stats.PokeballStats[pokeball] = stat
+	}
+}
+
 // calculateConfidenceMetrics calculates Pokemon mapping confidence metrics
 func (s *StatisticsService) calculateConfidenceMetrics(mappings []models.CoffeePokemon, stats *Statistics) {
 	if len(mappings) == 0 {
@@ -0,0 +1,271 @@
+package service
+
+import (
+	"fmt"
+	"go-coffee-log/models"
+	"go-coffee-log/storage"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maxMissedDrops caps how many eggs one subscription lays when the server
+// was down across several deliveries; older missed drops are skipped
+const maxMissedDrops = 4
+
+// SubscriptionService tracks coffee subscriptions. When a delivery is due it
+// lays an egg (a wishlist entry for the bag) and publishes an event; the
+// egg hatches when a coffee from the same roaster is logged.
+type SubscriptionService struct {
+	storage       storage.SubscriptionStorage
+	coffeeService *CoffeeService
+	bus           *EventBus
+
+	mu sync.Mutex // serializes laying and hatching eggs
+}
+
+// NewSubscriptionService creates a new subscription service and subscribes
+// it to coffees logged through coffeeService
+func NewSubscriptionService(subscriptionStorage storage.SubscriptionStorage, coffeeService *CoffeeService, bus *EventBus) *SubscriptionService {
+	s := &SubscriptionService{
+		storage:       subscriptionStorage,
+		coffeeService: coffeeService,
+		bus:           bus,
+	}
+	coffeeService.OnCreate(s.hatchForCoffee)
+	return s
+}
+
+// CreateSubscription starts tracking a subscription
+func (s *SubscriptionService) CreateSubscription(subscription models.Subscription) (models.Subscription, error) {
+	if err := subscription.Validate(); err != nil {
+		return models.Subscription{}, err
+	}
+	subscription.ID = uuid.New().String()
+	subscription.CreatedAt = time.Now()
+	subscription.UpdatedAt = subscription.CreatedAt
+
+	if err := s.storage.SaveSubscription(subscription); err != nil {
+		return models.Subscription{}, err
+	}
+	return subscription, nil
+}
+
+// GetSubscription retrieves a subscription
+func (s *SubscriptionService) GetSubscription(id string) (models.Subscription, error) {
+	return s.storage.GetSubscription(id)
+}
+
+// ListSubscriptions returns every subscription, next delivery first
+func (s *SubscriptionService) ListSubscriptions() ([]models.Subscription, error) {
+	return s.storage.GetAllSubscriptions()
+}
+
+// UpdateSubscription changes a subscription's roaster, schedule or notes,
+// or pauses it
+func (s *SubscriptionService) UpdateSubscription(id string, subscription models.Subscription) (models.Subscription, error) {
+	existing, err := s.storage.GetSubscription(id)
+	if err != nil {
+		return models.Subscription{}, err
+	}
+	if err := subscription.Validate(); err != nil {
+		return models.Subscription{}, err
+	}
+	subscription.ID = id
+	subscription.CreatedAt = existing.CreatedAt
+	subscription.UpdatedAt = time.Now()
+
+	if err := s.storage.UpdateSubscription(subscription); err != nil {
+		return models.Subscription{}, err
+	}
+	return subscription, nil
+}
+
+// DeleteSubscription stops tracking a subscription; its hatched eggs are kept
+func (s *SubscriptionService) DeleteSubscription(id string) error {
+	return s.storage.DeleteSubscription(id)
+}
+
+// ListEggs returns eggs, oldest expected first, optionally only those with
+// the given status ("waiting" or "hatched")
+func (s *SubscriptionService) ListEggs(status string) ([]models.Egg, error) {
+	if status != "" && status != models.EggWaiting && status != models.EggHatched {
+		return nil, fmt.Errorf("invalid status %q: use waiting or hatched", status)
+	}
+
+	eggs, err := s.storage.GetAllEggs()
+	if err != nil {
+		return nil, err
+	}
+	kept := []models.Egg{}
+	for _, egg := range eggs {
+		egg.SetStatus()
+		if status == "" || egg.Status == status {
+			kept = append(kept, egg)
+		}
+	}
+	return kept, nil
+}
+
+// DeleteEgg removes an egg, e.g. for a delivery that was skipped
+func (s *SubscriptionService) DeleteEgg(id string) error {
+	return s.storage.DeleteEgg(id)
+}
+
+// HatchEgg links a waiting egg to the coffee its bag was logged as
+func (s *SubscriptionService) HatchEgg(id, coffeeID string) (models.Egg, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	egg, err := s.storage.GetEgg(id)
+	if err != nil {
+		return models.Egg{}, err
+	}
+	if egg.Hatched() {
+		return models.Egg{}, fmt.Errorf("egg already hatched as coffee %s", egg.CoffeeID)
+	}
+	coffee, err := s.coffeeService.GetCoffee(coffeeID)
+	if err != nil {
+		return models.Egg{}, err
+	}
+
+	return s.hatch(egg, coffee)
+}
+
+// Check lays an egg for every delivery due by now and moves each
+// subscription's next delivery forward
+func (s *SubscriptionService) Check(now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subscriptions, err := s.storage.GetAllSubscriptions()
+	if err != nil {
+		return err
+	}
+	today := models.NewDate(now)
+
+	for _, subscription := range subscriptions {
+		if subscription.Paused || subscription.NextDelivery.After(today.Time) {
+			continue
+		}
+
+		var due []models.Date
+		for !subscription.NextDelivery.After(today.Time) {
+			due = append(due, subscription.NextDelivery)
+			subscription.NextDelivery = models.NewDate(subscription.NextDelivery.AddDate(0, 0, subscription.IntervalDays))
+		}
+		if len(due) > maxMissedDrops {
+			due = due[len(due)-maxMissedDrops:]
+		}
+
+		// Advance first, so a failure cannot lay the same eggs twice
+		subscription.UpdatedAt = now
+		if err := s.storage.UpdateSubscription(subscription); err != nil {
+			return err
+		}
+		for _, expectedOn := range due {
+			if err := s.layEgg(subscription, expectedOn, now); err != nil {
+				log.Printf("Subscriptions: failed to lay egg for %s on %s: %v", subscription.ID, expectedOn.Format("2006-01-02"), err)
+			}
+		}
+	}
+	return nil
+}
+
+// layEgg stores an egg for one delivery and announces it
+func (s *SubscriptionService) layEgg(subscription models.Subscription, expectedOn models.Date, now time.Time) error {
+	egg := models.Egg{
+		ID:             uuid.New().String(),
+		SubscriptionID: subscription.ID,
+		Roaster:        subscription.Roaster,
+		Name:           subscription.Name,
+		ExpectedOn:     expectedOn,
+		CreatedAt:      now,
+	}
+	if err := s.storage.SaveEgg(egg); err != nil {
+		return err
+	}
+
+	s.bus.Publish(Event{
+		Type:    "subscription.drop_due",
+		Message: fmt.Sprintf("a new egg from %s is due — log the bag when it arrives to hatch it", subscriptionLabel(subscription)),
+		Data: map[string]interface{}{
+			"egg_id":          egg.ID,
+			"subscription_id": subscription.ID,
+			"roaster":         subscription.Roaster,
+			"expected_on":     expectedOn.Format("2006-01-02"),
+		},
+	})
+	return nil
+}
+
+// hatchForCoffee hatches the oldest waiting egg from the roaster of a newly
+// logged coffee
+func (s *SubscriptionService) hatchForCoffee(coffee models.Coffee) {
+	if strings.TrimSpace(coffee.Roaster) == "" {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	eggs, err := s.storage.GetAllEggs()
+	if err != nil {
+		log.Printf("Subscriptions: failed to load eggs for coffee %s: %v", coffee.ID, err)
+		return
+	}
+	for _, egg := range eggs {
+		if egg.Hatched() || !sameRoaster(egg.Roaster, coffee.Roaster) {
+			continue
+		}
+		if _, err := s.hatch(egg, coffee); err != nil {
+			log.Printf("Subscriptions: failed to hatch egg %s: %v", egg.ID, err)
+		}
+		return
+	}
+}
+
+// hatch links an egg to a coffee and announces it
+func (s *SubscriptionService) hatch(egg models.Egg, coffee models.Coffee) (models.Egg, error) {
+	hatchedAt := time.Now()
+	egg.CoffeeID = coffee.ID
+	egg.HatchedAt = &hatchedAt
+	if err := s.storage.UpdateEgg(egg); err != nil {
+		return models.Egg{}, err
+	}
+	egg.SetStatus()
+
+	s.bus.Publish(Event{
+		Type:     "subscription.egg_hatched",
+		Message:  fmt.Sprintf("the egg from %s hatched into %s", egg.Roaster, coffee.Name),
+		CoffeeID: coffee.ID,
+		Data: map[string]interface{}{
+			"egg_id":          egg.ID,
+			"subscription_id": egg.SubscriptionID,
+			"expected_on":     egg.ExpectedOn.Format("2006-01-02"),
+		},
+	})
+	return egg, nil
+}
+
+// sameRoaster matches roaster names ignoring case and spacing, and allows
+// one to be a shortened form of the other ("Onyx" and "Onyx Coffee Lab")
+func sameRoaster(a, b string) bool {
+	a = strings.ToLower(strings.Join(strings.Fields(a), " "))
+	b = strings.ToLower(strings.Join(strings.Fields(b), " "))
+	if a == "" || b == "" {
+		return false
+	}
+	return a == b || strings.HasPrefix(a, b+" ") || strings.HasPrefix(b, a+" ")
+}
+
+// subscriptionLabel names a subscription in messages
+func subscriptionLabel(subscription models.Subscription) string {
+	if subscription.Name != "" {
+		return fmt.Sprintf("%s (%s)", subscription.Roaster, subscription.Name)
+	}
+	return subscription.Roaster
+}
@@ -0,0 +1,397 @@
+package service
+
+import (
+	"fmt"
+	"go-coffee-log/models"
+	"go-coffee-log/storage"
+	"time"
+)
+
+// Conflict resolution policies for Sync. A conflict is a pushed change to
+// an entity that changed (or was deleted) on the server since the version
+// the client last saw.
+const (
+	PolicyServerWins = "server_wins" // keep the server version; the client must rebase
+	PolicyClientWins = "client_wins" // apply the client change
+	PolicyLatestWins = "latest_wins" // whichever side changed last wins
+)
+
+// Sync operations
+const (
+	OpUpsert = "upsert"
+	OpDelete = "delete"
+)
+
+// SyncChange is one change made offline by a client
+type SyncChange struct {
+	Entity string `json:"entity"` // "coffee", "brew"
+	Op     string `json:"op"`     // "upsert", "delete"
+	ID     string `json:"id"`     // client-generated for new entities
+
+	// BaseUpdatedAt is the server updated_at of the version the client
+	// edited; omit for entities created offline
+	BaseUpdatedAt *time.Time `json:"base_updated_at,omitempty"`
+	// ModifiedAt is when the change was made on the client (latest_wins)
+	ModifiedAt time.Time `json:"modified_at"`
+
+	Coffee *models.Coffee `json:"coffee,omitempty"`
+	Brew   *models.Brew   `json:"brew,omitempty"`
+}
+
+// SyncRequest is a batch of offline changes plus the client's sync cursor
+type SyncRequest struct {
+	Since   time.Time    `json:"since"` // server_time of the previous sync; zero for a full sync
+	Policy  string       `json:"policy"`
+	Changes []SyncChange `json:"changes"`
+}
+
+// SyncConflict reports a conflicting change and how it was resolved
+type SyncConflict struct {
+	Entity        string      `json:"entity"`
+	ID            string      `json:"id"`
+	Op            string      `json:"op"`
+	Resolution    string      `json:"resolution"` // "server_wins" or "client_wins"
+	ServerDeleted bool        `json:"server_deleted,omitempty"`
+	ServerVersion interface{} `json:"server_version,omitempty"`
+}
+
+// SyncRejection reports a change that could not be applied at all
+type SyncRejection struct {
+	Entity string `json:"entity"`
+	ID     string `json:"id"`
+	Reason string `json:"reason"`
+}
+
+// SyncChanges are the server-side changes since the client's cursor
+type SyncChanges struct {
+	Coffees []models.Coffee    `json:"coffees"`
+	Brews   []models.Brew      `json:"brews"`
+	Deleted []models.Tombstone `json:"deleted"`
+}
+
+// SyncResult is the outcome of a sync
+type SyncResult struct {
+	ServerTime time.Time       `json:"server_time"` // send as since next time
+	Applied    []string        `json:"applied"`
+	Conflicts  []SyncConflict  `json:"conflicts"`
+	Rejected   []SyncRejection `json:"rejected"`
+	Changes    SyncChanges     `json:"changes"`
+}
+
+// SyncService reconciles offline clients with the server using updated_at
+// versions and a journal of deletions
+type SyncService struct {
+	coffeeService *CoffeeService
+	coffeeStorage storage.CoffeeStorage
+	brewService   *BrewService
+	brewStorage   storage.BrewStorage
+	tombstones    storage.TombstoneStorage
+}
+
+// NewSyncService creates a new sync service
+func NewSyncService(
+	coffeeService *CoffeeService,
+	coffeeStorage storage.CoffeeStorage,
+	brewService *BrewService,
+	brewStorage storage.BrewStorage,
+	tombstones storage.TombstoneStorage,
+) *SyncService {
+	return &SyncService{
+		coffeeService: coffeeService,
+		coffeeStorage: coffeeStorage,
+		brewService:   brewService,
+		brewStorage:   brewStorage,
+		tombstones:    tombstones,
+	}
+}
+
+// Sync applies the client's changes and returns everything that changed on
+// the server since req.Since. userID is recorded as the brewer of pushed
+// brews and as who added pushed coffees, which count against their quota.
+func (s *SyncService) Sync(req SyncRequest, userID string) (*SyncResult, error) {
+	if req.Policy == "" {
+		req.Policy = PolicyServerWins
+	}
+	if req.Policy != PolicyServerWins && req.Policy != PolicyClientWins && req.Policy != PolicyLatestWins {
+		return nil, fmt.Errorf("invalid policy %q: must be server_wins, client_wins or latest_wins", req.Policy)
+	}
+
+	// Take the cursor before reading so nothing written meanwhile is missed
+	result := &SyncResult{
+		ServerTime: time.Now(),
+		Applied:    []string{},
+		Conflicts:  []SyncConflict{},
+		Rejected:   []SyncRejection{},
+	}
+
+	for _, change := range req.Changes {
+		var err error
+		switch change.Entity {
+		case models.EntityCoffee:
+			err = s.applyCoffee(change, req.Policy, userID, result)
+		case models.EntityBrew:
+			err = s.applyBrew(change, req.Policy, userID, result)
+		default:
+			err = fmt.Errorf("unknown entity %q", change.Entity)
+		}
+		if err != nil {
+			result.Rejected = append(result.Rejected, SyncRejection{Entity: change.Entity, ID: change.ID, Reason: err.Error()})
+		}
+	}
+
+	changes, err := s.changesSince(req.Since)
+	if err != nil {
+		return nil, err
+	}
+	result.Changes = *changes
+	return result, nil
+}
+
+// applyCoffee applies one coffee change, resolving conflicts by policy
+func (s *SyncService) applyCoffee(change SyncChange, policy, addedBy string, result *SyncResult) error {
+	if change.ID == "" {
+		return fmt.Errorf("id is required")
+	}
+	if change.Op == OpUpsert && change.Coffee == nil {
+		return fmt.Errorf("coffee is required for upsert")
+	}
+
+	current, err := s.coffeeStorage.GetByID(change.ID)
+	exists := err == nil
+	tombstone, err := s.tombstones.GetDeletion(models.EntityCoffee, change.ID)
+	if err != nil {
+		return err
+	}
+
+	switch change.Op {
+	case OpUpsert:
+		coffee := *change.Coffee
+		coffee.ID = change.ID
+		coffee.DialIn = nil
+
+		if exists {
+			if isStale(current.UpdatedAt, change.BaseUpdatedAt) &&
+				!clientWins(policy, change.ModifiedAt, current.UpdatedAt) {
+				result.addConflict(change, PolicyServerWins, current, false)
+				return nil
+			}
+			if isStale(current.UpdatedAt, change.BaseUpdatedAt) {
+				result.addConflict(change, PolicyClientWins, current, false)
+			}
+			coffee.CreatedAt = current.CreatedAt
+			if _, err := s.coffeeService.UpdateCoffee(coffee.ID, coffee); err != nil {
+				return err
+			}
+			result.Applied = append(result.Applied, change.ID)
+			return nil
+		}
+
+		if tombstone != nil {
+			if !clientWins(policy, change.ModifiedAt, tombstone.DeletedAt) {
+				result.addConflict(change, PolicyServerWins, nil, true)
+				return nil
+			}
+			result.addConflict(change, PolicyClientWins, nil, true)
+		}
+
+		now := time.Now()
+		if coffee.CreatedAt.IsZero() {
+			coffee.CreatedAt = now
+		}
+		coffee.UpdatedAt = now
+		coffee.AddedBy = addedBy
+		if err := coffee.Validate(); err != nil {
+			return err
+		}
+		if err := s.coffeeService.checkQuota(coffee); err != nil {
+			return err
+		}
+		if err := s.coffeeStorage.Save(coffee); err != nil {
+			return err
+		}
+		if tombstone != nil {
+			if err := s.tombstones.ClearDeletion(models.EntityCoffee, coffee.ID); err != nil {
+				return err
+			}
+		}
+		result.Applied = append(result.Applied, change.ID)
+		return nil
+
+	case OpDelete:
+		if !exists {
+			// Already gone: deleting again is a no-op
+			result.Applied = append(result.Applied, change.ID)
+			return nil
+		}
+		if isStale(current.UpdatedAt, change.BaseUpdatedAt) {
+			if !clientWins(policy, change.ModifiedAt, current.UpdatedAt) {
+				result.addConflict(change, PolicyServerWins, current, false)
+				return nil
+			}
+			result.addConflict(change, PolicyClientWins, current, false)
+		}
+		if err := s.coffeeService.DeleteCoffee(change.ID); err != nil {
+			return err
+		}
+		result.Applied = append(result.Applied, change.ID)
+		return nil
+	}
+
+	return fmt.Errorf("unknown op %q", change.Op)
+}
+
+// applyBrew applies one brew change. Brews are immutable once logged, so
+// the only conflict is a brew deleted on the server being pushed again.
+func (s *SyncService) applyBrew(change SyncChange, policy, brewedBy string, result *SyncResult) error {
+	if change.ID == "" {
+		return fmt.Errorf("id is required")
+	}
+
+	brews, err := s.brewStorage.GetAllBrews()
+	if err != nil {
+		return err
+	}
+	var current *models.Brew
+	for i := range brews {
+		if brews[i].ID == change.ID {
+			current = &brews[i]
+			break
+		}
+	}
+
+	switch change.Op {
+	case OpUpsert:
+		if change.Brew == nil {
+			return fmt.Errorf("brew is required for upsert")
+		}
+		if current != nil {
+			// Already synced
+			result.Applied = append(result.Applied, change.ID)
+			return nil
+		}
+
+		tombstone, err := s.tombstones.GetDeletion(models.EntityBrew, change.ID)
+		if err != nil {
+			return err
+		}
+		if tombstone != nil {
+			if !clientWins(policy, change.ModifiedAt, tombstone.DeletedAt) {
+				result.addConflict(change, PolicyServerWins, nil, true)
+				return nil
+			}
+			result.addConflict(change, PolicyClientWins, nil, true)
+		}
+
+		brew := *change.Brew
+		brew.ID = change.ID
+		brew.BrewedBy = brewedBy
+		if _, err := s.coffeeStorage.GetByID(brew.CoffeeID); err != nil {
+			return fmt.Errorf("coffee not found")
+		}
+		if brew.CreatedAt.IsZero() {
+			brew.CreatedAt = time.Now()
+		}
+		brew.ComputeExtractionYield()
+		if err := brew.Validate(); err != nil {
+			return err
+		}
+		if err := s.brewStorage.SaveBrew(brew); err != nil {
+			return err
+		}
+		if tombstone != nil {
+			if err := s.tombstones.ClearDeletion(models.EntityBrew, brew.ID); err != nil {
+				return err
+			}
+		}
+		result.Applied = append(result.Applied, change.ID)
+		return nil
+
+	case OpDelete:
+		if current != nil {
+			if err := s.brewService.DeleteBrew(current.CoffeeID, current.ID); err != nil {
+				return err
+			}
+		}
+		result.Applied = append(result.Applied, change.ID)
+		return nil
+	}
+
+	return fmt.Errorf("unknown op %q", change.Op)
+}
+
+// changesSince collects coffees, brews and deletions changed at or after since
+func (s *SyncService) changesSince(since time.Time) (*SyncChanges, error) {
+	changes := &SyncChanges{
+		Coffees: []models.Coffee{},
+		Brews:   []models.Brew{},
+		Deleted: []models.Tombstone{},
+	}
+
+	// MySQL DATETIME columns have second precision
+	cutoff := since.Truncate(time.Second)
+
+	coffees, err := s.coffeeStorage.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get coffees: %w", err)
+	}
+	for _, coffee := range coffees {
+		if !coffee.UpdatedAt.Before(cutoff) {
+			changes.Coffees = append(changes.Coffees, coffee)
+		}
+	}
+
+	brews, err := s.brewStorage.GetAllBrews()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get brews: %w", err)
+	}
+	for _, brew := range brews {
+		if !brew.CreatedAt.Before(cutoff) {
+			changes.Brews = append(changes.Brews, brew)
+		}
+	}
+
+	deleted, err := s.tombstones.GetDeletionsSince(cutoff)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deletions: %w", err)
+	}
+	changes.Deleted = append(changes.Deleted, deleted...)
+
+	return changes, nil
+}
+
+// addConflict records a conflict on the result
+func (r *SyncResult) addConflict(change SyncChange, resolution string, serverVersion interface{}, serverDeleted bool) {
+	conflict := SyncConflict{
+		Entity:        change.Entity,
+		ID:            change.ID,
+		Op:            change.Op,
+		Resolution:    resolution,
+		ServerDeleted: serverDeleted,
+	}
+	if serverVersion != nil {
+		conflict.ServerVersion = serverVersion
+	}
+	r.Conflicts = append(r.Conflicts, conflict)
+}
+
+// isStale reports whether the server changed an entity after the version
+// the client based its change on. Compared at second precision, which is
+// what MySQL stores.
+func isStale(serverUpdatedAt time.Time, base *time.Time) bool {
+	if base == nil {
+		return true
+	}
+	return serverUpdatedAt.Truncate(time.Second).After(base.Truncate(time.Second))
+}
+
+// clientWins applies the policy to a conflict
+func clientWins(policy string, clientModifiedAt, serverChangedAt time.Time) bool {
+	switch policy {
+	case PolicyClientWins:
+		return true
+	case PolicyLatestWins:
+		return clientModifiedAt.After(serverChangedAt)
+	default:
+		return false
+	}
+}
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"go-coffee-log/models"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TasteProfileVersion versions the taste profile format, so consumers can
+// tell what they were given
+const TasteProfileVersion = 1
+
+// likedRating is the rating from which a coffee counts as liked
+const likedRating = 7
+
+// TraitPreference is how one tasting trait relates to the ratings
+type TraitPreference struct {
+	Trait       string  `json:"trait"`
+	Preferred   float64 `json:"preferred"`   // mean score among liked coffees
+	Average     float64 `json:"average"`     // mean score among all rated coffees
+	Correlation float64 `json:"correlation"` // Pearson r between the trait and the rating
+}
+
+// CategoryPreference is how coffees of one processing method, roast level
+// or Pokemon type were rated
+type CategoryPreference struct {
+	Value         string  `json:"value"`
+	Count         int     `json:"count"`
+	AverageRating float64 `json:"average_rating"`
+	Affinity      float64 `json:"affinity"` // average rating minus the overall average
+}
+
+// TasteProfile is a compact, machine-readable summary of what the coffee
+// log says about taste preferences
+type TasteProfile struct {
+	Version        int                  `json:"version"`
+	GeneratedAt    time.Time            `json:"generated_at"`
+	RatedCoffees   int                  `json:"rated_coffees"`
+	LikedCoffees   int                  `json:"liked_coffees"`
+	AverageRating  float64              `json:"average_rating"`
+	TraitVector    map[string]float64   `json:"trait_vector"` // preferred score per trait, 0-10
+	Traits         []TraitPreference    `json:"traits"`
+	Processing     []CategoryPreference `json:"processing"`
+	RoastLevels    []CategoryPreference `json:"roast_levels"`
+	TypeAffinity   []CategoryPreference `json:"type_affinity"` // by the Pokemon type the mapper gives each coffee
+	PreferredNotes []string             `json:"preferred_notes"`
+}
+
+// TasteProfileService summarizes the coffee log into a taste profile
+type TasteProfileService struct {
+	coffeeService *CoffeeService
+	mapper        *PokemonMapper
+}
+
+// NewTasteProfileService creates a new taste profile service. Type
+// affinity uses the built-in mapper rules until SetPokemon is called.
+func NewTasteProfileService(coffeeService *CoffeeService) *TasteProfileService {
+	return &TasteProfileService{
+		coffeeService: coffeeService,
+		mapper:        NewPokemonMapper(),
+	}
+}
+
+// SetPokemon makes type affinity follow the Pokemon service's mapper rules,
+// overrides and rule packs included
+func (s *TasteProfileService) SetPokemon(pokemonService *PokemonService) {
+	s.mapper = pokemonService.mapper
+}
+
+// preferredNotesKept caps the tasting notes in a profile
+const preferredNotesKept = 10
+
+// GetTasteProfile builds the taste profile from every rated coffee. Liked
+// coffees are those rated likedRating or more, or favorites; when there are
+// none, the trait vector falls back to every rated coffee.
+func (s *TasteProfileService) GetTasteProfile() (*TasteProfile, error) {
+	coffees, err := s.coffeeService.ListCoffees()
+	if err != nil {
+		return nil, err
+	}
+
+	rated, liked := []models.Coffee{}, []models.Coffee{}
+	for _, coffee := range coffees {
+		if coffee.Rating <= 0 {
+			continue
+		}
+		rated = append(rated, coffee)
+		if coffee.Rating >= likedRating || coffee.Favorite {
+			liked = append(liked, coffee)
+		}
+	}
+
+	profile := &TasteProfile{
+		Version:        TasteProfileVersion,
+		GeneratedAt:    time.Now(),
+		RatedCoffees:   len(rated),
+		LikedCoffees:   len(liked),
+		TraitVector:    make(map[string]float64),
+		Traits:         []TraitPreference{},
+		Processing:     []CategoryPreference{},
+		RoastLevels:    []CategoryPreference{},
+		TypeAffinity:   []CategoryPreference{},
+		PreferredNotes: []string{},
+	}
+	if len(rated) == 0 {
+		return profile, nil
+	}
+
+	ratings := make([]float64, len(rated))
+	for i, coffee := range rated {
+		ratings[i] = float64(coffee.Rating)
+	}
+	overall, _ := meanStdDev(ratings)
+	profile.AverageRating = round2(overall)
+
+	basis := liked
+	if len(basis) == 0 {
+		basis = rated
+	}
+	for _, trait := range traitNames {
+		all := make([]float64, len(rated))
+		for i, coffee := range rated {
+			all[i] = float64(traitValue(coffee.TastingTraits, trait))
+		}
+		preferred := make([]float64, len(basis))
+		for i, coffee := range basis {
+			preferred[i] = float64(traitValue(coffee.TastingTraits, trait))
+		}
+		preferredMean, _ := meanStdDev(preferred)
+		average, _ := meanStdDev(all)
+
+		profile.TraitVector[trait] = round2(preferredMean)
+		profile.Traits = append(profile.Traits, TraitPreference{
+			Trait:       trait,
+			Preferred:   round2(preferredMean),
+			Average:     round2(average),
+			Correlation: round2(pearson(all, ratings)),
+		})
+	}
+
+	profile.Processing = categoryPreferences(rated, overall, func(coffee models.Coffee) string {
+		return strings.ToLower(strings.TrimSpace(coffee.ProcessingMethod))
+	})
+	profile.RoastLevels = categoryPreferences(rated, overall, func(coffee models.Coffee) string {
+		return strings.ToLower(strings.TrimSpace(coffee.RoastLevel))
+	})
+	profile.TypeAffinity = categoryPreferences(rated, overall, func(coffee models.Coffee) string {
+		primary, _, _ := s.mapper.CalculatePokemonTypes(coffee)
+		return primary
+	})
+	profile.PreferredNotes = preferredNotes(liked)
+	return profile, nil
+}
+
+// categoryPreferences groups coffees by a category, leaving out those
+// without one, with the best liked first
+func categoryPreferences(coffees []models.Coffee, overall float64, category func(models.Coffee) string) []CategoryPreference {
+	sums := make(map[string]int)
+	counts := make(map[string]int)
+	for _, coffee := range coffees {
+		value := category(coffee)
+		if value == "" {
+			continue
+		}
+		sums[value] += coffee.Rating
+		counts[value]++
+	}
+
+	preferences := []CategoryPreference{}
+	for value, count := range counts {
+		average := float64(sums[value]) / float64(count)
+		preferences = append(preferences, CategoryPreference{
+			Value:         value,
+			Count:         count,
+			AverageRating: round2(average),
+			Affinity:      round2(average - overall),
+		})
+	}
+	sort.Slice(preferences, func(i, j int) bool {
+		if preferences[i].AverageRating != preferences[j].AverageRating {
+			return preferences[i].AverageRating > preferences[j].AverageRating
+		}
+		if preferences[i].Count != preferences[j].Count {
+			return preferences[i].Count > preferences[j].Count
+		}
+		return preferences[i].Value < preferences[j].Value
+	})
+	return preferences
+}
+
+// preferredNotes returns the tasting notes most often given to liked
+// coffees, most common first
+func preferredNotes(liked []models.Coffee) []string {
+	counts := make(map[string]int)
+	for _, coffee := range liked {
+		for _, note := range coffee.TastingNotes {
+			if note = strings.ToLower(strings.TrimSpace(note)); note != "" {
+				counts[note]++
+			}
+		}
+	}
+
+	notes := make([]string, 0, len(counts))
+	for note := range counts {
+		notes = append(notes, note)
+	}
+	sort.Slice(notes, func(i, j int) bool {
+		if counts[notes[i]] != counts[notes[j]] {
+			return counts[notes[i]] > counts[notes[j]]
+		}
+		return notes[i] < notes[j]
+	})
+	return notes[:min(len(notes), preferredNotesKept)]
+}
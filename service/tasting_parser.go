@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"go-coffee-log/models"
+)
+
+// maxTastingDescription is the longest free-text description sent to the LLM
+const maxTastingDescription = 2000
+
+// ParsedTasting is what the LLM read from a free-text tasting description,
+// ready to be copied into a coffee
+type ParsedTasting struct {
+	TastingTraits models.TastingTraits `json:"tasting_traits"`
+	TastingNotes  []string             `json:"tasting_notes"`
+	Model         string               `json:"model"` // the model that answered
+}
+
+// tastingSchema is the JSON schema of a parsed tasting. Like mappingSchema
+// it keeps to OpenAI's strict subset; trait ranges are checked in code.
+func tastingSchema() map[string]interface{} {
+	traits := make(map[string]interface{}, len(traitNames))
+	for _, name := range traitNames {
+		traits[name] = map[string]interface{}{"type": "integer"}
+	}
+	return map[string]interface{}{
+		"type":                 "object",
+		"additionalProperties": false,
+		"required":             []string{"tasting_traits", "tasting_notes"},
+		"properties": map[string]interface{}{
+			"tasting_traits": map[string]interface{}{
+				"type":                 "object",
+				"additionalProperties": false,
+				"required":             traitNames,
+				"properties":           traits,
+			},
+			"tasting_notes": map[string]interface{}{
+				"type":  "array",
+				"items": map[string]interface{}{"type": "string"},
+			},
+		},
+	}
+}
+
+// ParseTasting turns a free-text tasting description such as "juicy
+// blueberry, jasmine, super clean" into trait scores and up to five
+// tasting notes. Answers out of range are retried like malformed JSON.
+func (s *LLMService) ParseTasting(ctx context.Context, description string) (*ParsedTasting, error) {
+	if !s.Available() {
+		return nil, fmt.Errorf("LLM service is not available")
+	}
+	description = strings.TrimSpace(description)
+	if description == "" {
+		return nil, fmt.Errorf("invalid description: it is empty")
+	}
+	if len(description) > maxTastingDescription {
+		return nil, fmt.Errorf("invalid description: longer than %d characters", maxTastingDescription)
+	}
+
+	prompt := s.buildTastingPrompt(description)
+	schema := tastingSchema()
+
+	var parsed *ParsedTasting
+	model, err := s.withRetry(ctx, func(model string) error {
+		response, err := s.generate(ctx, model, prompt, schema)
+		if err != nil {
+			return err
+		}
+		parsed, err = parseTastingResponse(response)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	parsed.Model = model
+	return parsed, nil
+}
+
+// buildTastingPrompt asks the LLM to score a tasting description
+func (s *LLMService) buildTastingPrompt(description string) string {
+	return fmt.Sprintf(`You are a specialty coffee cupper. Score the coffee described below on each trait from 0 (absent) to 10 (dominant), and pick up to 5 short tasting notes from the description.
+
+Traits: %s
+Score only what the description says or clearly implies; leave the rest at 0.
+
+Description: %s
+
+Respond with ONLY valid JSON:
+{
+  "tasting_traits": {"berry_intensity": 8, "florality": 6, "cleanliness": 9, ...},
+  "tasting_notes": ["blueberry", "jasmine"]
+}`, strings.Join(traitNames, ", "), description)
+}
+
+// parseTastingResponse decodes and checks a parsed tasting, since not every
+// provider enforces the schema. Notes are trimmed and deduplicated.
+func parseTastingResponse(response string) (*ParsedTasting, error) {
+	response = strings.TrimSpace(response)
+	response = strings.ReplaceAll(response, "```json", "")
+	response = strings.ReplaceAll(response, "```", "")
+
+	var parsed ParsedTasting
+	if err := json.Unmarshal([]byte(response), &parsed); err != nil {
+		return nil, fmt.Errorf("%w: %v", errMalformedLLMResponse, err)
+	}
+	if err := parsed.TastingTraits.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", errMalformedLLMResponse, err)
+	}
+
+	notes := []string{}
+	seen := make(map[string]bool)
+	for _, note := range parsed.TastingNotes {
+		note = strings.TrimSpace(note)
+		if note == "" || seen[strings.ToLower(note)] {
+			continue
+		}
+		seen[strings.ToLower(note)] = true
+		notes = append(notes, note)
+	}
+	if len(notes) > 5 {
+		notes = notes[:5]
+	}
+	parsed.TastingNotes = notes
+	return &parsed, nil
+}
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// telemetrySchema versions the report layout for the receiving end
+const telemetrySchema = 1
+
+// FeatureUsage counts requests to one feature
+type FeatureUsage struct {
+	Requests int `json:"requests"`
+	Errors   int `json:"errors"`
+}
+
+// TelemetryReport is the complete payload sent to the telemetry endpoint.
+// It holds only aggregate counts: no IDs, names, notes, IP addresses or
+// user agents, and timestamps are rounded to the hour.
+type TelemetryReport struct {
+	Schema      int                     `json:"schema"`
+	PeriodStart time.Time               `json:"period_start"`
+	PeriodEnd   time.Time               `json:"period_end"`
+	Storage     string                  `json:"storage"`
+	Requests    int                     `json:"requests"`
+	Errors      int                     `json:"errors"` // 5xx responses
+	ErrorRate   float64                 `json:"error_rate"`
+	Features    map[string]FeatureUsage `json:"features"` // keyed by "METHOD /route"
+}
+
+// Telemetry aggregates anonymous usage counts and, when an endpoint is
+// configured (opt-in), periodically reports them. Counts are always kept
+// locally so the preview shows what would be sent before opting in.
+type Telemetry struct {
+	mu          sync.Mutex
+	endpoint    string
+	storage     string
+	client      *http.Client
+	periodStart time.Time
+	requests    int
+	errors      int
+	features    map[string]FeatureUsage
+}
+
+// NewTelemetry creates a telemetry collector. An empty endpoint disables
+// reporting.
+func NewTelemetry(endpoint, storage string) *Telemetry {
+	return &Telemetry{
+		endpoint:    endpoint,
+		storage:     storage,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		periodStart: time.Now(),
+		features:    make(map[string]FeatureUsage),
+	}
+}
+
+// Enabled reports whether the user opted in to sending reports
+func (t *Telemetry) Enabled() bool {
+	return t.endpoint != ""
+}
+
+// Endpoint returns the configured report endpoint
+func (t *Telemetry) Endpoint() string {
+	return t.endpoint
+}
+
+// Record counts one request to a feature and its response status
+func (t *Telemetry) Record(feature string, status int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	usage := t.features[feature]
+	usage.Requests++
+	t.requests++
+	if status >= 500 {
+		usage.Errors++
+		t.errors++
+	}
+	t.features[feature] = usage
+}
+
+// Preview returns the report that the next send would deliver
+func (t *Telemetry) Preview() TelemetryReport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.reportLocked(time.Now())
+}
+
+// reportLocked builds a report from the current counters. Callers hold the lock.
+func (t *Telemetry) reportLocked(now time.Time) TelemetryReport {
+	report := TelemetryReport{
+		Schema:      telemetrySchema,
+		PeriodStart: t.periodStart.UTC().Truncate(time.Hour),
+		PeriodEnd:   now.UTC().Truncate(time.Hour),
+		Storage:     t.storage,
+		Requests:    t.requests,
+		Errors:      t.errors,
+		Features:    make(map[string]FeatureUsage, len(t.features)),
+	}
+	if t.requests > 0 {
+		report.ErrorRate = float64(t.errors) / float64(t.requests)
+	}
+	for feature, usage := range t.features {
+		report.Features[feature] = usage
+	}
+	return report
+}
+
+// Send delivers the current report and starts a new period. Counts are
+// kept for the next attempt if delivery fails.
+func (t *Telemetry) Send() error {
+	if !t.Enabled() {
+		return nil
+	}
+
+	t.mu.Lock()
+	now := time.Now()
+	report := t.reportLocked(now)
+	t.mu.Unlock()
+
+	if report.Requests == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to marshal telemetry: %w", err)
+	}
+	resp, err := t.client.Post(t.endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to send telemetry: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("telemetry endpoint returned status %d", resp.StatusCode)
+	}
+
+	// Subtract what was sent; requests recorded meanwhile carry over
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.periodStart = now
+	t.requests -= report.Requests
+	t.errors -= report.Errors
+	for feature, sent := range report.Features {
+		usage := t.features[feature]
+		usage.Requests -= sent.Requests
+		usage.Errors -= sent.Errors
+		if usage.Requests <= 0 {
+			delete(t.features, feature)
+		} else {
+			t.features[feature] = usage
+		}
+	}
+	return nil
+}
+
+// Run sends a report every interval until ctx is cancelled
+func (t *Telemetry) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := t.Send(); err != nil {
+			log.Printf("Telemetry: %v", err)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"fmt"
+	"go-coffee-log/models"
+	"math"
+	"sort"
+)
+
+// temperatureBucketWidth groups samples into 2°C bands
+const temperatureBucketWidth = 2.0
+
+// TemperatureBucket represents the ratings observed within a temperature band
+type TemperatureBucket struct {
+	Range         string  `json:"range"` // e.g. "92-94°C"
+	Min           float64 `json:"min"`
+	Max           float64 `json:"max"`
+	Count         int     `json:"count"`
+	AverageRating float64 `json:"average_rating"`
+}
+
+// RoastTemperatureStats represents rating vs temperature for one roast level
+type RoastTemperatureStats struct {
+	RoastLevel         string              `json:"roast_level"`
+	Samples            int                 `json:"samples"`
+	AverageTemperature float64             `json:"average_temperature"`
+	BestRange          string              `json:"best_range,omitempty"`
+	Correlation        float64             `json:"correlation"` // Pearson r between temperature and rating
+	Buckets            []TemperatureBucket `json:"buckets"`
+}
+
+// TemperatureStats represents rating vs brew temperature across roast levels
+type TemperatureStats struct {
+	Unit         string                  `json:"unit"`
+	TotalSamples int                     `json:"total_samples"`
+	RoastLevels  []RoastTemperatureStats `json:"roast_levels"`
+}
+
+// temperatureSample is a rated brew at a known temperature, in Celsius
+type temperatureSample struct {
+	roastLevel string
+	celsius    float64
+	rating     int
+}
+
+// GetTemperatureStats relates ratings to brew temperature per roast level.
+// Rated brews with a structured temperature are used, along with coffees
+// whose recipe text mentions a temperature. Values are reported in unit.
+func (s *BrewService) GetTemperatureStats(unit string) (*TemperatureStats, error) {
+	unit, err := models.NormalizeUnit(unit)
+	if err != nil {
+		return nil, err
+	}
+
+	coffees, err := s.coffeeService.ListCoffees()
+	if err != nil {
+		return nil, err
+	}
+	brews, err := s.storage.GetAllBrews()
+	if err != nil {
+		return nil, err
+	}
+
+	roastLevels := make(map[string]string)
+	var samples []temperatureSample
+	for _, coffee := range coffees {
+		roastLevels[coffee.ID] = roastLevelOrUnknown(coffee.RoastLevel)
+
+		if params := parseRecipe(coffee.Recipe); params.TemperatureC > 0 && coffee.Rating > 0 {
+			samples = append(samples, temperatureSample{roastLevels[coffee.ID], params.TemperatureC, coffee.Rating})
+		}
+	}
+	for _, brew := range brews {
+		roastLevel, ok := roastLevels[brew.CoffeeID]
+		if !ok || brew.Temperature == nil || brew.Rating == 0 {
+			continue
+		}
+		samples = append(samples, temperatureSample{roastLevel, brew.Temperature.Celsius(), brew.Rating})
+	}
+
+	grouped := make(map[string][]temperatureSample)
+	for _, sample := range samples {
+		grouped[sample.roastLevel] = append(grouped[sample.roastLevel], sample)
+	}
+
+	stats := &TemperatureStats{
+		Unit:         unit,
+		TotalSamples: len(samples),
+		RoastLevels:  []RoastTemperatureStats{},
+	}
+	for roastLevel, group := range grouped {
+		stats.RoastLevels = append(stats.RoastLevels, summarizeTemperatures(roastLevel, group, unit))
+	}
+	sort.Slice(stats.RoastLevels, func(i, j int) bool {
+		return stats.RoastLevels[i].RoastLevel < stats.RoastLevels[j].RoastLevel
+	})
+
+	return stats, nil
+}
+
+// summarizeTemperatures buckets one roast level's samples and correlates
+// temperature with rating
+func summarizeTemperatures(roastLevel string, samples []temperatureSample, unit string) RoastTemperatureStats {
+	result := RoastTemperatureStats{
+		RoastLevel: roastLevel,
+		Samples:    len(samples),
+		Buckets:    []TemperatureBucket{},
+	}
+
+	type bucketTotals struct {
+		count  int
+		rating int
+	}
+	totals := make(map[float64]*bucketTotals)
+	var sumTemperature float64
+	temperatures := make([]float64, len(samples))
+	ratings := make([]float64, len(samples))
+
+	for i, sample := range samples {
+		sumTemperature += sample.celsius
+		temperatures[i] = sample.celsius
+		ratings[i] = float64(sample.rating)
+
+		lower := math.Floor(sample.celsius/temperatureBucketWidth) * temperatureBucketWidth
+		if totals[lower] == nil {
+			totals[lower] = &bucketTotals{}
+		}
+		totals[lower].count++
+		totals[lower].rating += sample.rating
+	}
+
+	result.AverageTemperature = convertCelsius(sumTemperature/float64(len(samples)), unit)
+	result.Correlation = math.Round(pearson(temperatures, ratings)*100) / 100
+
+	for lower, total := range totals {
+		bucket := TemperatureBucket{
+			Min:           convertCelsius(lower, unit),
+			Max:           convertCelsius(lower+temperatureBucketWidth, unit),
+			Count:         total.count,
+			AverageRating: math.Round(float64(total.rating)/float64(total.count)*10) / 10,
+		}
+		bucket.Range = fmt.Sprintf("%g-%g°%s", bucket.Min, bucket.Max, unit)
+		result.Buckets = append(result.Buckets, bucket)
+	}
+	sort.Slice(result.Buckets, func(i, j int) bool { return result.Buckets[i].Min < result.Buckets[j].Min })
+
+	bestAverage := -1.0
+	for _, bucket := range result.Buckets {
+		if bucket.AverageRating > bestAverage {
+			bestAverage = bucket.AverageRating
+			result.BestRange = bucket.Range
+		}
+	}
+
+	return result
+}
+
+// convertCelsius converts a Celsius value into unit, rounded to 0.1
+func convertCelsius(celsius float64, unit string) float64 {
+	return models.Temperature{Value: celsius, Unit: "C"}.In(unit).Value
+}
+
+// pearson computes the correlation coefficient of two equal-length series,
+// or 0 when either series has no variance
+func pearson(xs, ys []float64) float64 {
+	n := float64(len(xs))
+	if n < 2 {
+		return 0
+	}
+
+	var sumX, sumY float64
+	for i := range xs {
+		sumX += xs[i]
+		sumY += ys[i]
+	}
+	meanX, meanY := sumX/n, sumY/n
+
+	var cov, varX, varY float64
+	for i := range xs {
+		dx, dy := xs[i]-meanX, ys[i]-meanY
+		cov += dx * dy
+		varX += dx * dx
+		varY += dy * dy
+	}
+	if varX == 0 || varY == 0 {
+		return 0
+	}
+	return cov / math.Sqrt(varX*varY)
+}
+
+// roastLevelOrUnknown labels coffees without a roast level
+func roastLevelOrUnknown(roastLevel string) string {
+	if roastLevel == "" {
+		return "unknown"
+	}
+	return roastLevel
+}
@@ -0,0 +1,238 @@
+package service
+
+import (
+	"fmt"
+	"go-coffee-log/models"
+	"math"
+	"sort"
+	"time"
+)
+
+// Trait drift periods
+const (
+	DriftYear    = "year"
+	DriftQuarter = "quarter"
+	DriftMonth   = "month"
+)
+
+const (
+	// driftMinSamples is how many scored coffees both the latest period and
+	// the baseline need before a trait can be flagged as drifting
+	driftMinSamples = 5
+	// driftEffectSize is the effect size (Cohen's d) from which a shift is
+	// flagged: 0.5 is a "medium" effect
+	driftEffectSize = 0.5
+)
+
+// traitNames are the tasting traits, in the order they are reported
+var traitNames = []string{
+	"berry_intensity", "stonefruit_intensity", "roast_intensity", "citrus_fruits_intensity",
+	"bitterness", "florality", "spice", "sweetness", "aromatic_intensity", "savory", "body", "cleanliness",
+}
+
+// TraitPeriodStat summarizes the scores of one trait in one period
+type TraitPeriodStat struct {
+	Period string  `json:"period"` // e.g. "2025", "2025-Q3", "2025-07"
+	Count  int     `json:"count"`
+	Mean   float64 `json:"mean"`
+	StdDev float64 `json:"std_dev"`
+}
+
+// TraitDriftStat is how the scoring of one trait moved over time. The
+// latest period is compared against every coffee scored before it.
+type TraitDriftStat struct {
+	Trait        string            `json:"trait"`
+	Periods      []TraitPeriodStat `json:"periods"`
+	BaselineMean float64           `json:"baseline_mean"`
+	LatestMean   float64           `json:"latest_mean"`
+	Change       float64           `json:"change"`         // latest minus baseline mean
+	EffectSize   float64           `json:"effect_size"`    // Cohen's d of the change
+	SlopePerYear float64           `json:"slope_per_year"` // least-squares trend across every coffee
+	Drifting     bool              `json:"drifting"`
+}
+
+// TraitDrift compares trait scoring across periods, to catch scoring that
+// creeps up or down over time rather than following the coffees
+type TraitDrift struct {
+	Period         string           `json:"period"`
+	Latest         string           `json:"latest,omitempty"` // the period compared against the baseline
+	LatestCount    int              `json:"latest_count"`
+	BaselineCount  int              `json:"baseline_count"`
+	ScoredCoffees  int              `json:"scored_coffees"`
+	Traits         []TraitDriftStat `json:"traits"`
+	DriftingTraits []string         `json:"drifting_traits"`
+}
+
+// driftSample is one scored coffee
+type driftSample struct {
+	at     time.Time
+	period string
+	traits models.TastingTraits
+}
+
+// GetTraitDrift groups scored coffees by when they were logged and reports,
+// per trait, the mean of every period and how the latest period differs
+// from all earlier ones. A trait drifts when the difference is at least a
+// medium effect and both sides have enough coffees. Coffees without any
+// trait scored are left out.
+func (s *CoffeeService) GetTraitDrift(period string) (*TraitDrift, error) {
+	if period == "" {
+		period = DriftYear
+	}
+	if period != DriftYear && period != DriftQuarter && period != DriftMonth {
+		return nil, fmt.Errorf("invalid period %q: use year, quarter or month", period)
+	}
+
+	coffees, err := s.ListCoffees()
+	if err != nil {
+		return nil, err
+	}
+
+	samples := []driftSample{}
+	for _, coffee := range coffees {
+		if coffee.TastingTraits == (models.TastingTraits{}) {
+			continue
+		}
+		samples = append(samples, driftSample{
+			at:     coffee.CreatedAt,
+			period: driftPeriod(coffee.CreatedAt, period),
+			traits: coffee.TastingTraits,
+		})
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i].at.Before(samples[j].at) })
+
+	drift := &TraitDrift{
+		Period:         period,
+		ScoredCoffees:  len(samples),
+		Traits:         []TraitDriftStat{},
+		DriftingTraits: []string{},
+	}
+	if len(samples) == 0 {
+		return drift, nil
+	}
+
+	periods := []string{}
+	for _, sample := range samples {
+		if len(periods) == 0 || periods[len(periods)-1] != sample.period {
+			periods = append(periods, sample.period)
+		}
+	}
+	drift.Latest = periods[len(periods)-1]
+	for _, sample := range samples {
+		if sample.period == drift.Latest {
+			drift.LatestCount++
+		}
+	}
+	drift.BaselineCount = len(samples) - drift.LatestCount
+
+	for _, trait := range traitNames {
+		stat := TraitDriftStat{Trait: trait, Periods: []TraitPeriodStat{}}
+		byPeriod := make(map[string][]float64)
+		var baseline, latest, years, scores []float64
+		for _, sample := range samples {
+			value := float64(traitValue(sample.traits, trait))
+			byPeriod[sample.period] = append(byPeriod[sample.period], value)
+			if sample.period == drift.Latest {
+				latest = append(latest, value)
+			} else {
+				baseline = append(baseline, value)
+			}
+			years = append(years, sample.at.Sub(samples[0].at).Hours()/(24*365.25))
+			scores = append(scores, value)
+		}
+		for _, label := range periods {
+			mean, stdDev := meanStdDev(byPeriod[label])
+			stat.Periods = append(stat.Periods, TraitPeriodStat{
+				Period: label, Count: len(byPeriod[label]), Mean: round2(mean), StdDev: round2(stdDev),
+			})
+		}
+
+		baselineMean, baselineSD := meanStdDev(baseline)
+		latestMean, latestSD := meanStdDev(latest)
+		stat.BaselineMean = round2(baselineMean)
+		stat.LatestMean = round2(latestMean)
+		if len(baseline) > 0 {
+			stat.Change = round2(latestMean - baselineMean)
+			stat.EffectSize = round2(cohensD(latestMean-baselineMean, latestSD, baselineSD, len(latest), len(baseline)))
+		}
+		stat.SlopePerYear = round2(leastSquaresSlope(years, scores))
+		stat.Drifting = len(latest) >= driftMinSamples && len(baseline) >= driftMinSamples &&
+			math.Abs(stat.EffectSize) >= driftEffectSize
+		if stat.Drifting {
+			drift.DriftingTraits = append(drift.DriftingTraits, trait)
+		}
+		drift.Traits = append(drift.Traits, stat)
+	}
+	return drift, nil
+}
+
+// driftPeriod labels the period a time falls in
+func driftPeriod(at time.Time, period string) string {
+	switch period {
+	case DriftQuarter:
+		return fmt.Sprintf("%d-Q%d", at.Year(), (int(at.Month())-1)/3+1)
+	case DriftMonth:
+		return at.Format("2006-01")
+	}
+	return at.Format("2006")
+}
+
+// meanStdDev returns the mean and sample standard deviation of values
+func meanStdDev(values []float64) (float64, float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	var sum float64
+	for _, value := range values {
+		sum += value
+	}
+	mean := sum / float64(len(values))
+	if len(values) < 2 {
+		return mean, 0
+	}
+	var squares float64
+	for _, value := range values {
+		squares += (value - mean) * (value - mean)
+	}
+	return mean, math.Sqrt(squares / float64(len(values)-1))
+}
+
+// cohensD scales a difference of means by the pooled standard deviation.
+// With no spread on either side, any difference counts as a large effect.
+func cohensD(difference, sd1, sd2 float64, n1, n2 int) float64 {
+	if n1+n2 <= 2 {
+		return 0
+	}
+	pooled := math.Sqrt((float64(n1-1)*sd1*sd1 + float64(n2-1)*sd2*sd2) / float64(n1+n2-2))
+	if pooled == 0 {
+		switch {
+		case difference > 0:
+			return 1
+		case difference < 0:
+			return -1
+		}
+		return 0
+	}
+	return difference / pooled
+}
+
+// round2 rounds to two decimals
+func round2(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+// leastSquaresSlope fits ys = a + b*xs and returns b, or 0 when xs has no
+// spread
+func leastSquaresSlope(xs, ys []float64) float64 {
+	meanX, _ := meanStdDev(xs)
+	meanY, _ := meanStdDev(ys)
+	var cov, varX float64
+	for i := range xs {
+		cov += (xs[i] - meanX) * (ys[i] - meanY)
+		varX += (xs[i] - meanX) * (xs[i] - meanX)
+	}
+	if varX == 0 {
+		return 0
+	}
+	return cov / varX
+}
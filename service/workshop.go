@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go-coffee-log/models"
+	"log"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultWorkshopTTL is how long a workshop lives unless it asks for less
+const DefaultWorkshopTTL = 3 * time.Hour
+
+// WorkshopLauncher starts and stops the isolated servers behind workshops
+type WorkshopLauncher interface {
+	// Start brings up a workshop's server and returns where it listens
+	// and the storage it uses
+	Start(ctx context.Context, id string) (target *url.URL, storage string, err error)
+	// Stop shuts a workshop's server down and deletes its data
+	Stop(id string) error
+}
+
+// WorkshopService runs ephemeral workshops for tasting classes: each is a
+// separate server with its own coffees, brewers and Pokedex, started on
+// demand and deleted once its TTL has passed. Workshops are not persisted;
+// they end with the server that started them.
+type WorkshopService struct {
+	launcher WorkshopLauncher
+	ttl      time.Duration
+	max      int
+
+	mu        sync.Mutex
+	workshops map[string]*runningWorkshop
+	starting  int // workshops being started, counted against max
+}
+
+// runningWorkshop is a workshop and the server behind it
+type runningWorkshop struct {
+	workshop models.Workshop
+	target   *url.URL
+}
+
+// NewWorkshopService creates a workshop service. ttl is the longest a
+// workshop lives; max caps how many run at once (0 = unlimited).
+func NewWorkshopService(launcher WorkshopLauncher, ttl time.Duration, max int) *WorkshopService {
+	if ttl <= 0 {
+		ttl = DefaultWorkshopTTL
+	}
+	return &WorkshopService{
+		launcher:  launcher,
+		ttl:       ttl,
+		max:       max,
+		workshops: make(map[string]*runningWorkshop),
+	}
+}
+
+// CreateWorkshop starts a workshop that expires after ttl, or after the
+// service's TTL when ttl is zero
+func (s *WorkshopService) CreateWorkshop(ctx context.Context, name string, ttl time.Duration) (models.Workshop, error) {
+	if ttl == 0 {
+		ttl = s.ttl
+	}
+	if ttl < time.Minute || ttl > s.ttl {
+		return models.Workshop{}, fmt.Errorf("invalid ttl: must be between 1m and %s", s.ttl)
+	}
+	workshop := models.Workshop{Name: name}
+	if err := workshop.Validate(); err != nil {
+		return models.Workshop{}, fmt.Errorf("invalid workshop: %w", err)
+	}
+	id, err := generateWorkshopID()
+	if err != nil {
+		return models.Workshop{}, err
+	}
+
+	s.mu.Lock()
+	if s.max > 0 && len(s.workshops)+s.starting >= s.max {
+		s.mu.Unlock()
+		return models.Workshop{}, fmt.Errorf("workshop limit reached: %d are running", s.max)
+	}
+	s.starting++
+	s.mu.Unlock()
+
+	target, storage, err := s.launcher.Start(ctx, id)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.starting--
+	if err != nil {
+		return models.Workshop{}, fmt.Errorf("failed to start workshop: %w", err)
+	}
+
+	now := time.Now()
+	workshop.ID = id
+	workshop.Storage = storage
+	workshop.URL = "/workshops/" + id + "/"
+	workshop.CreatedAt = now
+	workshop.ExpiresAt = now.Add(ttl)
+	s.workshops[id] = &runningWorkshop{workshop: workshop, target: target}
+	log.Printf("INFO: Started workshop %s (%q) on %s until %s", id, workshop.Name, storage, workshop.ExpiresAt.Format(time.RFC3339))
+	return workshop, nil
+}
+
+// ListWorkshops returns the running workshops, soonest to expire first
+func (s *WorkshopService) ListWorkshops() []models.Workshop {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	workshops := make([]models.Workshop, 0, len(s.workshops))
+	for _, running := range s.workshops {
+		workshops = append(workshops, running.workshop)
+	}
+	sort.Slice(workshops, func(i, j int) bool {
+		return workshops[i].ExpiresAt.Before(workshops[j].ExpiresAt)
+	})
+	return workshops
+}
+
+// GetWorkshop returns a running workshop
+func (s *WorkshopService) GetWorkshop(id string) (models.Workshop, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	running, ok := s.workshops[id]
+	if !ok {
+		return models.Workshop{}, fmt.Errorf("workshop not found")
+	}
+	return running.workshop, nil
+}
+
+// Target returns where a workshop's server listens
+func (s *WorkshopService) Target(id string) (*url.URL, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	running, ok := s.workshops[id]
+	if !ok || time.Now().After(running.workshop.ExpiresAt) {
+		return nil, fmt.Errorf("workshop not found")
+	}
+	return running.target, nil
+}
+
+// DeleteWorkshop stops a workshop early and deletes its data
+func (s *WorkshopService) DeleteWorkshop(id string) error {
+	s.mu.Lock()
+	_, ok := s.workshops[id]
+	delete(s.workshops, id)
+	s.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("workshop not found")
+	}
+	return s.launcher.Stop(id)
+}
+
+// Expire stops every workshop whose TTL has passed and deletes its data
+func (s *WorkshopService) Expire(now time.Time) error {
+	s.mu.Lock()
+	var expired []models.Workshop
+	for id, running := range s.workshops {
+		if now.After(running.workshop.ExpiresAt) {
+			expired = append(expired, running.workshop)
+			delete(s.workshops, id)
+		}
+	}
+	s.mu.Unlock()
+
+	var errs []error
+	for _, workshop := range expired {
+		if err := s.launcher.Stop(workshop.ID); err != nil {
+			errs = append(errs, fmt.Errorf("workshop %s: %w", workshop.ID, err))
+			continue
+		}
+		log.Printf("INFO: Workshop %s (%q) expired and was deleted", workshop.ID, workshop.Name)
+	}
+	return errors.Join(errs...)
+}
+
+// generateWorkshopID returns a random ID that doubles as the workshop's
+// access key and fits in a MySQL schema name
+func generateWorkshopID() (string, error) {
+	buf := make([]byte, 10)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate workshop ID: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
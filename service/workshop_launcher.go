@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Timeouts for workshop servers to start and to stop once asked
+const (
+	workshopStartTimeout = time.Minute
+	workshopStopTimeout  = 10 * time.Second
+)
+
+// WorkshopSchemas creates and drops the MySQL schemas workshops live in
+type WorkshopSchemas interface {
+	CreateSchema(id string) (string, error)
+	DropSchema(id string) error
+}
+
+// ProcessLauncher runs each workshop as a child process of this server's
+// executable, listening on a free loopback port. Children run on in-memory
+// storage, or on a schema of their own when schemas are set, and keep
+// photos in a temporary directory of their own. A child exits when its
+// stdin closes, so children never outlive the parent.
+type ProcessLauncher struct {
+	executable string
+	args       []string // flags every child gets, e.g. how to reach the LLM
+	env        []string // secrets every child gets, kept off its command line
+	schemas    WorkshopSchemas
+
+	mu    sync.Mutex
+	procs map[string]*workshopProcess
+}
+
+// workshopProcess is a running child server
+type workshopProcess struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	media string        // the child's photo directory
+	done  chan struct{} // closed when the process exits
+}
+
+// NewProcessLauncher creates a launcher that starts executable with args
+// plus the workshop's address and storage flags, and with env added to this
+// server's environment
+func NewProcessLauncher(executable string, args, env []string) *ProcessLauncher {
+	return &ProcessLauncher{
+		executable: executable,
+		args:       args,
+		env:        env,
+		procs:      make(map[string]*workshopProcess),
+	}
+}
+
+// SetSchemas gives every workshop a MySQL schema of its own instead of
+// in-memory storage
+func (l *ProcessLauncher) SetSchemas(schemas WorkshopSchemas) {
+	l.schemas = schemas
+}
+
+// Start implements WorkshopLauncher. It returns once the child's /health
+// reports it ready.
+func (l *ProcessLauncher) Start(ctx context.Context, id string) (*url.URL, string, error) {
+	port, err := freePort()
+	if err != nil {
+		return nil, "", err
+	}
+	address := "127.0.0.1:" + strconv.Itoa(port)
+	media, err := os.MkdirTemp("", "coffee-dex-workshop-")
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create workshop media directory: %w", err)
+	}
+	args := append(append([]string{}, l.args...), "-addr="+address, "-workshop-child", "-media=file:"+media)
+
+	storage := "memory"
+	if l.schemas != nil {
+		if storage, err = l.schemas.CreateSchema(id); err != nil {
+			os.RemoveAll(media)
+			return nil, "", err
+		}
+		args = append(args, "-storage=mysql", "-mysql-db="+storage)
+	} else {
+		args = append(args, "-storage=memory")
+	}
+
+	cmd := exec.Command(l.executable, args...)
+	cmd.Env = append(os.Environ(), l.env...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err == nil {
+		err = cmd.Start()
+	}
+	if err != nil {
+		l.dropSchema(id)
+		os.RemoveAll(media)
+		return nil, "", fmt.Errorf("failed to start workshop server: %w", err)
+	}
+	proc := &workshopProcess{cmd: cmd, stdin: stdin, media: media, done: make(chan struct{})}
+	go func() {
+		cmd.Wait()
+		close(proc.done)
+	}()
+
+	l.mu.Lock()
+	l.procs[id] = proc
+	l.mu.Unlock()
+
+	target := &url.URL{Scheme: "http", Host: address}
+	if err := waitForWorkshop(ctx, target, proc.done); err != nil {
+		l.Stop(id)
+		return nil, "", err
+	}
+	return target, storage, nil
+}
+
+// Stop implements WorkshopLauncher. It closes the child's stdin, kills it
+// if it has not exited within workshopStopTimeout and deletes its photos and
+// schema.
+func (l *ProcessLauncher) Stop(id string) error {
+	l.mu.Lock()
+	proc, ok := l.procs[id]
+	delete(l.procs, id)
+	l.mu.Unlock()
+
+	var mediaErr error
+	if ok {
+		proc.stdin.Close()
+		select {
+		case <-proc.done:
+		case <-time.After(workshopStopTimeout):
+			proc.cmd.Process.Kill()
+			<-proc.done
+		}
+		if err := os.RemoveAll(proc.media); err != nil {
+			mediaErr = fmt.Errorf("failed to delete workshop media: %w", err)
+		}
+	}
+	return errors.Join(mediaErr, l.dropSchema(id))
+}
+
+func (l *ProcessLauncher) dropSchema(id string) error {
+	if l.schemas == nil {
+		return nil
+	}
+	return l.schemas.DropSchema(id)
+}
+
+// waitForWorkshop polls a child's /health until it answers 200, the child
+// exits or workshopStartTimeout passes
+func waitForWorkshop(ctx context.Context, target *url.URL, done <-chan struct{}) error {
+	ctx, cancel := context.WithTimeout(ctx, workshopStartTimeout)
+	defer cancel()
+	ticker := time.NewTicker(200 * time.Millisecond)
+	defer ticker.Stop()
+
+	health := target.JoinPath("/health").String()
+	for {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, health, nil)
+		if err != nil {
+			return err
+		}
+		if resp, err := http.DefaultClient.Do(req); err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return nil
+			}
+		}
+
+		select {
+		case <-done:
+			return fmt.Errorf("workshop server exited while starting")
+		case <-ctx.Done():
+			return fmt.Errorf("workshop server did not become ready: %w", ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// freePort asks the kernel for an unused loopback port
+func freePort() (int, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, fmt.Errorf("failed to find a free port: %w", err)
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
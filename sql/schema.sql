@@ -17,8 +17,21 @@ CREATE TABLE IF NOT EXISTS coffees (
     dripper VARCHAR(100),
     end_time_minutes INT,
     end_time_seconds INT,
+    roast_date DATE NULL,  -- Used for staleness reminders
+    favorite BOOLEAN NOT NULL DEFAULT FALSE,  -- Pinned in lists; filters statistics
+    brewer_id VARCHAR(36) NOT NULL DEFAULT '',  -- Brewer used; statistics by pokeball type
+    altitude VARCHAR(100) NOT NULL DEFAULT '',  -- e.g. "1,800-2,000 masl"
+    lot VARCHAR(255) NOT NULL DEFAULT '',
+    price_amount DECIMAL(12,2) NULL,  -- Price of the bag, in price_currency
+    price_currency CHAR(3) NOT NULL DEFAULT '',
+    bag_grams INT NOT NULL DEFAULT 0,
+    added_by VARCHAR(36) NOT NULL DEFAULT '',  -- users.id of whoever added it; quotas
+    tags JSON NULL,  -- Array of lowercased labels; POST /batch add_tags
+    tasting_notes_text TEXT GENERATED ALWAYS AS (CAST(tasting_notes AS CHAR)) STORED,  -- FULLTEXT cannot index JSON
     created_at DATETIME,
-    updated_at DATETIME
+    updated_at DATETIME,
+    FULLTEXT INDEX ft_coffees (name, roaster, origin, variety, processing_method),  -- GET /search
+    FULLTEXT INDEX ft_coffee_notes (tasting_notes_text)
 );
 
 -- Brewers table: Stores coffee brewing equipment with pokeball sprites
@@ -28,7 +41,8 @@ CREATE TABLE IF NOT EXISTS brewers (
     name VARCHAR(255) NOT NULL,
     pokeball_type VARCHAR(50) NOT NULL,
     recipes JSON,  -- Array of Recipe objects: {id, name, steps[]}
-    created_at DATETIME
+    created_at DATETIME,
+    FULLTEXT INDEX ft_brewers (name)  -- GET /search
 );
 
 -- Pokemon table: Stores Pokemon data for coffee-to-Pokemon mappings
@@ -42,15 +56,329 @@ CREATE TABLE IF NOT EXISTS pokemon (
 );
 
 -- Coffee-Pokemon mappings: Links coffees to their Pokemon representations
+-- The server adds llm_description and, for GET /search, a FULLTEXT index
+-- ft_catches (nickname, llm_description)
 CREATE TABLE IF NOT EXISTS coffee_pokemon (
-    coffee_id VARCHAR(36) PRIMARY KEY,
+    coffee_id VARCHAR(36) NOT NULL,
+    dex VARCHAR(36) NOT NULL DEFAULT 'kanto',  -- dex profile the catch belongs to
     pokemon_id INT NOT NULL,
     nickname VARCHAR(100),
     created_at DATETIME,
+    PRIMARY KEY (coffee_id, dex),
+    UNIQUE KEY idx_unique_dex_pokemon (pokemon_id, dex),  -- one catch per Pokemon per dex
     FOREIGN KEY (coffee_id) REFERENCES coffees(id) ON DELETE CASCADE,
     FOREIGN KEY (pokemon_id) REFERENCES pokemon(id)
 );
 
+-- Mapping disagreements: Consensus rounds where the LLM mappers did not
+-- agree on a coffee's Pokemon, with every mapper's proposal
+CREATE TABLE IF NOT EXISTS mapping_disagreements (
+    id VARCHAR(36) PRIMARY KEY,
+    coffee_id VARCHAR(36) NOT NULL,
+    selected_pokemon VARCHAR(50) NOT NULL,
+    agreement REAL,  -- share of the total weight behind the winner
+    proposals JSON,
+    created_at DATETIME
+);
+
+-- Gym badges: Awarded to a user once a brewer has been used for enough
+-- coffees of the badge's Pokemon type (see -badge-catches)
+CREATE TABLE IF NOT EXISTS gym_badges (
+    user_id VARCHAR(36) NOT NULL DEFAULT '',
+    badge VARCHAR(20) NOT NULL,  -- e.g. rainbow
+    name VARCHAR(50) NOT NULL,   -- e.g. Rainbow Badge
+    pokemon_type VARCHAR(20) NOT NULL,
+    brewer_id VARCHAR(36) NOT NULL,
+    brewer_name VARCHAR(255) NOT NULL,
+    catches INT NOT NULL,
+    awarded_at DATETIME,
+    PRIMARY KEY (user_id, badge)
+);
+
+-- Safari attempts: A random uncaught Pokemon whose type's primary traits
+-- are the target coffee spec; a matching coffee logged before expires_at
+-- catches it (see -safari-window)
+CREATE TABLE IF NOT EXISTS safari_attempts (
+    id VARCHAR(36) PRIMARY KEY,
+    user_id VARCHAR(36) NOT NULL DEFAULT '',
+    dex VARCHAR(36) NOT NULL,
+    pokemon_id INT NOT NULL,
+    pokemon_name VARCHAR(50) NOT NULL,
+    pokemon_type VARCHAR(20) NOT NULL,  -- the type the spec comes from
+    target JSON NOT NULL,               -- [{"trait", "min", "max"}, ...]
+    hints JSON,                         -- tasting notes the type favours
+    coffee_id VARCHAR(36),              -- the coffee that caught it
+    level INT NOT NULL DEFAULT 0,
+    expires_at DATETIME NOT NULL,
+    caught_at DATETIME,
+    created_at DATETIME,
+    INDEX idx_safari_user (user_id, created_at)
+);
+
+-- Dex profiles: Custom Pokedexes such as a best-of-all-gens 151; the
+-- built-in kanto and johto profiles are not stored
+CREATE TABLE IF NOT EXISTS dex_profiles (
+    id VARCHAR(36) PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    pokemon_ids JSON NOT NULL,  -- National Dex numbers, in dex order
+    created_at DATETIME
+);
+
+-- Dex selections: The profile each user plays; users without one play the
+-- server default (see -default-dex)
+CREATE TABLE IF NOT EXISTS dex_selections (
+    user_id VARCHAR(36) PRIMARY KEY,
+    dex VARCHAR(36) NOT NULL,
+    updated_at DATETIME
+);
+
+-- User preferences: Per-user settings such as the default currency
+CREATE TABLE IF NOT EXISTS user_preferences (
+    user_id VARCHAR(36) NOT NULL,
+    name VARCHAR(64) NOT NULL,
+    value VARCHAR(255) NOT NULL,
+    updated_at DATETIME,
+    PRIMARY KEY (user_id, name)
+);
+
+-- Mapper rule packs: Imported sets of the rules that map coffees to Pokemon
+-- types; the active pack replaces the built-in rules
+CREATE TABLE IF NOT EXISTS mapper_rule_packs (
+    name VARCHAR(50) PRIMARY KEY,
+    version VARCHAR(20) NOT NULL,
+    author VARCHAR(100) NOT NULL,
+    description TEXT,
+    rules JSON NOT NULL,  -- Pokemon type -> traits, keywords, bonuses and threshold
+    active BOOLEAN NOT NULL DEFAULT FALSE,
+    imported_at DATETIME
+);
+
+-- Coffee photos: Uploaded images are stored once per content hash in media
+-- storage (see -media); this table references them from coffees
+CREATE TABLE IF NOT EXISTS coffee_photos (
+    coffee_id VARCHAR(36) NOT NULL,
+    hash CHAR(64) NOT NULL,  -- hex SHA-256 of the image
+    content_type VARCHAR(50) NOT NULL,
+    size BIGINT NOT NULL,
+    uploaded_by VARCHAR(36) NOT NULL DEFAULT '',  -- users.id of the uploader; quotas
+    created_at DATETIME,
+    PRIMARY KEY (coffee_id, hash),
+    INDEX idx_hash (hash),
+    FOREIGN KEY (coffee_id) REFERENCES coffees(id) ON DELETE CASCADE
+);
+
+-- Collections: User-defined groups of coffees ("Office beans")
+CREATE TABLE IF NOT EXISTS collections (
+    id VARCHAR(36) PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    created_at DATETIME,
+    updated_at DATETIME
+);
+
+CREATE TABLE IF NOT EXISTS collection_coffees (
+    collection_id VARCHAR(36) NOT NULL,
+    coffee_id VARCHAR(36) NOT NULL,
+    added_at DATETIME(6) NOT NULL,  -- orders coffees within the collection
+    PRIMARY KEY (collection_id, coffee_id),
+    FOREIGN KEY (collection_id) REFERENCES collections(id) ON DELETE CASCADE,
+    FOREIGN KEY (coffee_id) REFERENCES coffees(id) ON DELETE CASCADE
+);
+
+-- Saved searches: Named coffee filters, optionally notifying a webhook
+-- when a newly logged coffee matches
+CREATE TABLE IF NOT EXISTS saved_searches (
+    id VARCHAR(36) PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    query JSON NOT NULL,  -- models.SearchQuery
+    webhook_url VARCHAR(2048),
+    created_at DATETIME
+);
+
+-- Planned brews: Brews scheduled ahead ("Saturday: Kenya on the Chemex");
+-- brew_id is set once the plan is logged as a brew
+CREATE TABLE IF NOT EXISTS planned_brews (
+    id VARCHAR(36) PRIMARY KEY,
+    coffee_id VARCHAR(36) NOT NULL,
+    dripper VARCHAR(100),
+    notes TEXT,
+    scheduled_for DATETIME NOT NULL,
+    reminder_minutes INT NOT NULL,  -- Reminder lead time
+    reminded_at DATETIME NULL,
+    brew_id VARCHAR(36),
+    created_at DATETIME,
+    INDEX idx_planned_brews_scheduled (scheduled_for),
+    FOREIGN KEY (coffee_id) REFERENCES coffees(id) ON DELETE CASCADE
+);
+
+-- Subscriptions: Recurring deliveries from a roaster
+CREATE TABLE IF NOT EXISTS subscriptions (
+    id VARCHAR(36) PRIMARY KEY,
+    roaster VARCHAR(255) NOT NULL,
+    name VARCHAR(255),
+    interval_days INT NOT NULL,
+    next_delivery DATE NOT NULL,
+    notes TEXT,
+    paused BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at DATETIME,
+    updated_at DATETIME
+);
+
+-- Subscription eggs: Bags due from a subscription, laid when a delivery is
+-- due and hatched (linked to a coffee) when the bag is logged
+CREATE TABLE IF NOT EXISTS subscription_eggs (
+    id VARCHAR(36) PRIMARY KEY,
+    subscription_id VARCHAR(36) NOT NULL,  -- Not a foreign key; hatched eggs outlive their subscription
+    roaster VARCHAR(255) NOT NULL,
+    name VARCHAR(255),
+    expected_on DATE NOT NULL,
+    coffee_id VARCHAR(36) NULL,
+    hatched_at DATETIME NULL,
+    created_at DATETIME,
+    UNIQUE KEY uniq_subscription_drop (subscription_id, expected_on),
+    FOREIGN KEY (coffee_id) REFERENCES coffees(id) ON DELETE SET NULL
+);
+
+-- Scoresheets: Competition entries for a coffee with weighted categories,
+-- e.g. [{"name": "flavor", "weight": 2}]; ranked within a competition
+CREATE TABLE IF NOT EXISTS scoresheets (
+    id VARCHAR(36) PRIMARY KEY,
+    coffee_id VARCHAR(36) NOT NULL,
+    competition VARCHAR(255) NOT NULL,
+    competitor VARCHAR(255),
+    categories JSON NOT NULL,
+    max_score DOUBLE NOT NULL,  -- Per category; scores run from 0
+    created_at DATETIME(6),
+    INDEX idx_scoresheets_competition (competition),
+    FOREIGN KEY (coffee_id) REFERENCES coffees(id) ON DELETE CASCADE
+);
+
+-- Judge scores: One row per judge per scoresheet, scores keyed by category
+CREATE TABLE IF NOT EXISTS judge_scores (
+    scoresheet_id VARCHAR(36) NOT NULL,
+    judge VARCHAR(100) NOT NULL,
+    scores JSON NOT NULL,
+    notes TEXT,
+    updated_at DATETIME,
+    PRIMARY KEY (scoresheet_id, judge),
+    FOREIGN KEY (scoresheet_id) REFERENCES scoresheets(id) ON DELETE CASCADE
+);
+
+-- Brews table: Individual brewing attempts used to dial in a coffee's grind
+CREATE TABLE IF NOT EXISTS brews (
+    id VARCHAR(36) PRIMARY KEY,
+    coffee_id VARCHAR(36) NOT NULL,
+    grind_setting REAL,  -- Higher settings are coarser
+    brew_time_minutes INT,
+    brew_time_seconds INT,
+    outcome VARCHAR(20) NOT NULL,  -- "under", "balanced", "over"
+    rating INT,
+    notes TEXT,
+    dose_grams REAL,
+    water_grams REAL,
+    beverage_grams REAL,
+    tds REAL,  -- Total dissolved solids, percent
+    extraction_yield REAL,  -- Percent, computed from dose/beverage/TDS when omitted
+    temperature_value REAL,
+    temperature_unit VARCHAR(1),  -- "C" or "F", as entered
+    brewed_by VARCHAR(36),  -- users.id, recorded in shared-collection mode
+    created_at DATETIME,
+    INDEX idx_brews_coffee (coffee_id)
+);
+
+-- Comments table: threaded comments on coffees
+CREATE TABLE IF NOT EXISTS comments (
+    id VARCHAR(36) PRIMARY KEY,
+    coffee_id VARCHAR(36) NOT NULL,
+    parent_id VARCHAR(36) NULL,  -- comments.id of the comment replied to
+    author_id VARCHAR(36) NULL,  -- users.id; NULL without authentication
+    author_name VARCHAR(100) NOT NULL,
+    body TEXT NOT NULL,
+    created_at DATETIME,
+    updated_at DATETIME,
+    INDEX idx_comments_coffee (coffee_id)
+);
+
+-- Reactions table: one emoji per user per Pokedex entry
+CREATE TABLE IF NOT EXISTS reactions (
+    coffee_id VARCHAR(36) NOT NULL,
+    user_id VARCHAR(36) NOT NULL DEFAULT '',  -- users.id; empty without authentication
+    emoji VARCHAR(16) NOT NULL,
+    created_at DATETIME,
+    PRIMARY KEY (coffee_id, user_id, emoji)
+) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin;
+
+-- Tombstones table: deletion journal for offline sync clients
+CREATE TABLE IF NOT EXISTS tombstones (
+    entity_type VARCHAR(20) NOT NULL,  -- "coffee", "brew"
+    entity_id VARCHAR(36) NOT NULL,
+    deleted_at DATETIME(3) NOT NULL,
+    PRIMARY KEY (entity_type, entity_id),
+    INDEX idx_tombstones_deleted (deleted_at)
+);
+
+-- Daily stats table: per-day aggregates recomputed in the background for
+-- /statistics/trends; safe to truncate, it is rebuilt from coffees and brews
+CREATE TABLE IF NOT EXISTS daily_stats (
+    day DATE NOT NULL,
+    dimension VARCHAR(30) NOT NULL,  -- "all", "origin", "roaster", "roast_level", "processing_method"
+    value VARCHAR(255) NOT NULL DEFAULT '',  -- e.g. "Ethiopia"; empty for "all"
+    coffees INT NOT NULL,  -- coffees logged that day
+    average_rating REAL,
+    brews INT NOT NULL,  -- brews of those coffees that day
+    average_brew_rating REAL,
+    computed_at DATETIME,
+    PRIMARY KEY (dimension, day, value)
+);
+
+-- Activity table: instance-wide event log behind GET /activity
+CREATE TABLE IF NOT EXISTS activity (
+    id VARCHAR(36) PRIMARY KEY,
+    type VARCHAR(100) NOT NULL,  -- e.g. "comment.added"
+    message TEXT NOT NULL,
+    coffee_id VARCHAR(36) NOT NULL DEFAULT '',
+    data JSON NULL,
+    created_at DATETIME(6) NOT NULL,
+    INDEX idx_activity_created (created_at),
+    INDEX idx_activity_type (type, created_at)
+);
+
+-- Users table (only used when the server runs with -admin-token)
+CREATE TABLE IF NOT EXISTS users (
+    id VARCHAR(36) PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    is_admin BOOLEAN NOT NULL DEFAULT FALSE,
+    token_hash CHAR(64) NOT NULL UNIQUE,  -- SHA-256 of the API token
+    created_at DATETIME
+);
+
+-- Invites table: single-use codes for joining a shared server
+CREATE TABLE IF NOT EXISTS invites (
+    code VARCHAR(20) PRIMARY KEY,  -- e.g. "K7QM-3XPA"
+    created_by VARCHAR(36) NOT NULL,
+    expires_at DATETIME NOT NULL,
+    used_by VARCHAR(36) NULL,
+    used_at DATETIME NULL,
+    created_at DATETIME
+);
+
+-- Outbox table: events queued for webhooks, Discord and email, retried
+-- with backoff until delivered or given up on
+CREATE TABLE IF NOT EXISTS outbox (
+    id VARCHAR(36) PRIMARY KEY,
+    sink VARCHAR(20) NOT NULL,  -- "webhook", "discord" or "email"
+    target VARCHAR(2048) NOT NULL DEFAULT '',  -- per-message webhook URL, e.g. a saved search's
+    payload JSON NOT NULL,  -- the event
+    status VARCHAR(20) NOT NULL,  -- "pending", "delivered" or "dead"
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at DATETIME NOT NULL,
+    last_error TEXT,
+    created_at DATETIME,
+    delivered_at DATETIME NULL,
+    INDEX idx_outbox_due (status, next_attempt_at)
+);
+
 -- DEPRECATED TABLES (kept for backward compatibility, will be removed in future)
 -- These tables are no longer used in the application
 
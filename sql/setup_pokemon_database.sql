@@ -45,21 +45,41 @@ CREATE TABLE IF NOT EXISTS pokemons (
 CREATE TABLE IF NOT EXISTS coffee_pokemon (
     id VARCHAR(36) PRIMARY KEY,
     coffee_id VARCHAR(36) NOT NULL,
+    dex VARCHAR(36) NOT NULL DEFAULT 'kanto',  -- dex profile the catch belongs to
     pokemon_id INT NOT NULL,
     nickname VARCHAR(100),
     level INT DEFAULT 1,
     mapping_confidence REAL,
     llm_description TEXT,
     trait_mapping JSON,
+    provenance JSON NULL,  -- how the Pokemon was picked, e.g. {"method": "rules"}
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     FOREIGN KEY (coffee_id) REFERENCES coffees(id) ON DELETE CASCADE,
     FOREIGN KEY (pokemon_id) REFERENCES pokemons(id) ON DELETE CASCADE
 );
 
--- Create unique index to ensure each Pokemon is used only once
+-- Create unique index to ensure each Pokemon is used only once per dex profile
 -- Note: Index will only be created if it doesn't already exist (handled by CREATE TABLE IF NOT EXISTS)
 -- If running setup multiple times, this may fail harmlessly if index exists
-CREATE UNIQUE INDEX IF NOT EXISTS idx_unique_pokemon ON coffee_pokemon(pokemon_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_unique_dex_pokemon ON coffee_pokemon(pokemon_id, dex);
+
+-- Each coffee is caught at most once per dex profile
+CREATE UNIQUE INDEX IF NOT EXISTS idx_unique_coffee_dex ON coffee_pokemon(coffee_id, dex);
+
+-- Create dex profile tables; the built-in kanto and johto profiles are not stored
+CREATE TABLE IF NOT EXISTS dex_profiles (
+    id VARCHAR(36) PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    pokemon_ids JSON NOT NULL,
+    created_at DATETIME
+);
+
+CREATE TABLE IF NOT EXISTS dex_selections (
+    user_id VARCHAR(36) PRIMARY KEY,
+    dex VARCHAR(36) NOT NULL,
+    updated_at DATETIME
+);
 
 -- Create brewers table (for brewing equipment)
 CREATE TABLE IF NOT EXISTS brewers (
@@ -0,0 +1,194 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"go-coffee-log/models"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ActivityStorage defines the interface for the activity log
+type ActivityStorage interface {
+	SaveActivity(activity models.Activity) error
+	// GetActivity returns up to limit entries of a type (all when empty),
+	// newest first, skipping offset, and the total number of matches
+	GetActivity(eventType string, offset, limit int) ([]models.Activity, int, error)
+	// DeleteActivityBefore removes entries created before cutoff
+	DeleteActivityBefore(cutoff time.Time) (int, error)
+}
+
+// MemoryActivityStorage implements ActivityStorage in memory
+type MemoryActivityStorage struct {
+	activity []models.Activity // in insertion order
+	mu       sync.RWMutex
+}
+
+// NewMemoryActivityStorage creates a new in-memory activity storage
+func NewMemoryActivityStorage() *MemoryActivityStorage {
+	return &MemoryActivityStorage{}
+}
+
+// SaveActivity stores a new entry
+func (m *MemoryActivityStorage) SaveActivity(activity models.Activity) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.activity = append(m.activity, activity)
+	return nil
+}
+
+// GetActivity returns a page of entries, newest first
+func (m *MemoryActivityStorage) GetActivity(eventType string, offset, limit int) ([]models.Activity, int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	matches := []models.Activity{}
+	for _, activity := range m.activity {
+		if eventType == "" || activity.Type == eventType {
+			matches = append(matches, activity)
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].CreatedAt.After(matches[j].CreatedAt) })
+
+	total := len(matches)
+	if offset > total {
+		offset = total
+	}
+	end := total
+	if limit > 0 && offset+limit < total {
+		end = offset + limit
+	}
+	return matches[offset:end], total, nil
+}
+
+// DeleteActivityBefore removes entries created before cutoff
+func (m *MemoryActivityStorage) DeleteActivityBefore(cutoff time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	kept := m.activity[:0]
+	for _, activity := range m.activity {
+		if !activity.CreatedAt.Before(cutoff) {
+			kept = append(kept, activity)
+		}
+	}
+	deleted := len(m.activity) - len(kept)
+	m.activity = kept
+	return deleted, nil
+}
+
+// MySQLActivityStorage implements ActivityStorage using MySQL, so the
+// activity log survives restarts
+type MySQLActivityStorage struct {
+	db *sql.DB
+}
+
+// NewMySQLActivityStorage creates a new MySQL activity storage
+func NewMySQLActivityStorage(db *sql.DB) *MySQLActivityStorage {
+	storage := &MySQLActivityStorage{db: db}
+
+	if err := storage.initTables(); err != nil {
+		panic(fmt.Sprintf("failed to initialize activity tables: %v", err))
+	}
+
+	return storage
+}
+
+// initTables creates the activity table if it doesn't exist
+func (m *MySQLActivityStorage) initTables() error {
+	query := `
+		CREATE TABLE IF NOT EXISTS activity (
+			id VARCHAR(36) PRIMARY KEY,
+			type VARCHAR(100) NOT NULL,
+			message TEXT NOT NULL,
+			coffee_id VARCHAR(36) NOT NULL DEFAULT '',
+			data JSON NULL,
+			created_at DATETIME(6) NOT NULL,
+			INDEX idx_activity_created (created_at),
+			INDEX idx_activity_type (type, created_at)
+		)
+	`
+	if _, err := m.db.Exec(query); err != nil {
+		log.Printf("ERROR: initTables - Failed to create activity table: %v", err)
+		return fmt.Errorf("failed to create activity table: %w", err)
+	}
+	return nil
+}
+
+// SaveActivity stores a new entry
+func (m *MySQLActivityStorage) SaveActivity(activity models.Activity) error {
+	var data []byte
+	if len(activity.Data) > 0 {
+		encoded, err := json.Marshal(activity.Data)
+		if err != nil {
+			return fmt.Errorf("failed to marshal activity data: %w", err)
+		}
+		data = encoded
+	}
+
+	_, err := m.db.Exec(`
+		INSERT INTO activity (id, type, message, coffee_id, data, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, activity.ID, activity.Type, activity.Message, activity.CoffeeID, data, activity.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save activity: %w", err)
+	}
+	return nil
+}
+
+// GetActivity returns a page of entries, newest first
+func (m *MySQLActivityStorage) GetActivity(eventType string, offset, limit int) ([]models.Activity, int, error) {
+	where, args := "", []interface{}{}
+	if eventType != "" {
+		where, args = "WHERE type = ?", append(args, eventType)
+	}
+
+	var total int
+	if err := m.db.QueryRow("SELECT COUNT(*) FROM activity "+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count activity: %w", err)
+	}
+
+	rows, err := m.db.Query(`
+		SELECT id, type, message, coffee_id, data, created_at
+		FROM activity `+where+` ORDER BY created_at DESC, id LIMIT ? OFFSET ?`,
+		append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query activity: %w", err)
+	}
+	defer rows.Close()
+
+	activity := []models.Activity{}
+	for rows.Next() {
+		var entry models.Activity
+		var data []byte
+		if err := rows.Scan(&entry.ID, &entry.Type, &entry.Message, &entry.CoffeeID, &data, &entry.CreatedAt); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan activity: %w", err)
+		}
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, &entry.Data); err != nil {
+				return nil, 0, fmt.Errorf("failed to unmarshal activity data: %w", err)
+			}
+		}
+		activity = append(activity, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating activity: %w", err)
+	}
+	return activity, total, nil
+}
+
+// DeleteActivityBefore removes entries created before cutoff
+func (m *MySQLActivityStorage) DeleteActivityBefore(cutoff time.Time) (int, error) {
+	result, err := m.db.Exec("DELETE FROM activity WHERE created_at < ?", cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete old activity: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return int(deleted), nil
+}
@@ -0,0 +1,103 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"go-coffee-log/models"
+	"log"
+)
+
+// BadgeStorage defines the interface for the gym badges users earned
+type BadgeStorage interface {
+	// AwardBadge is idempotent: a user holds each badge once, and
+	// re-awarding it reports false
+	AwardBadge(badge models.Badge) (bool, error)
+	GetBadges(userID string) ([]models.Badge, error)
+}
+
+// MySQLBadgeStorage implements BadgeStorage using MySQL database
+type MySQLBadgeStorage struct {
+	db *sql.DB
+}
+
+// NewMySQLBadgeStorage creates a new MySQL badge storage
+func NewMySQLBadgeStorage(db *sql.DB) *MySQLBadgeStorage {
+	storage := &MySQLBadgeStorage{db: db}
+
+	if err := storage.initTables(); err != nil {
+		panic(fmt.Sprintf("failed to initialize gym_badges table: %v", err))
+	}
+
+	return storage
+}
+
+// initTables creates the gym_badges table if it doesn't exist
+func (m *MySQLBadgeStorage) initTables() error {
+	query := `
+		CREATE TABLE IF NOT EXISTS gym_badges (
+			user_id VARCHAR(36) NOT NULL DEFAULT '',
+			badge VARCHAR(20) NOT NULL,
+			name VARCHAR(50) NOT NULL,
+			pokemon_type VARCHAR(20) NOT NULL,
+			brewer_id VARCHAR(36) NOT NULL,
+			brewer_name VARCHAR(255) NOT NULL,
+			catches INT NOT NULL,
+			awarded_at DATETIME,
+			PRIMARY KEY (user_id, badge)
+		)
+	`
+
+	if _, err := m.db.Exec(query); err != nil {
+		log.Printf("ERROR: initTables - Failed to create gym_badges table: %v", err)
+		return fmt.Errorf("failed to create gym_badges table: %w", err)
+	}
+
+	return nil
+}
+
+// AwardBadge stores a badge and reports whether the user is new to it
+func (m *MySQLBadgeStorage) AwardBadge(badge models.Badge) (bool, error) {
+	result, err := m.db.Exec(
+		`INSERT IGNORE INTO gym_badges (user_id, badge, name, pokemon_type, brewer_id, brewer_name, catches, awarded_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		badge.UserID, badge.Badge, badge.Name, badge.Type, badge.BrewerID, badge.BrewerName, badge.Catches, badge.AwardedAt,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to save badge: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected > 0, nil
+}
+
+// GetBadges returns a user's badges in the order they were earned
+func (m *MySQLBadgeStorage) GetBadges(userID string) ([]models.Badge, error) {
+	rows, err := m.db.Query(
+		`SELECT user_id, badge, name, pokemon_type, brewer_id, brewer_name, catches, awarded_at
+		FROM gym_badges WHERE user_id = ? ORDER BY awarded_at ASC`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query badges: %w", err)
+	}
+	defer rows.Close()
+
+	badges := []models.Badge{}
+	for rows.Next() {
+		var badge models.Badge
+		if err := rows.Scan(&badge.UserID, &badge.Badge, &badge.Name, &badge.Type,
+			&badge.BrewerID, &badge.BrewerName, &badge.Catches, &badge.AwardedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan badge: %w", err)
+		}
+		badges = append(badges, badge)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return badges, nil
+}
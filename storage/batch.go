@@ -0,0 +1,137 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"go-coffee-log/models"
+)
+
+// Coffee write kinds
+const (
+	CoffeeWriteSave   = "save"
+	CoffeeWriteUpdate = "update"
+	CoffeeWriteDelete = "delete"
+)
+
+// CoffeeWrite is one change in a coffee batch
+type CoffeeWrite struct {
+	Kind   string
+	ID     string        // update, delete
+	Coffee models.Coffee // save, update
+}
+
+// CoffeeBatcher applies several coffee writes in one transaction: either
+// all of them are stored or none are, and no reader sees some without the
+// rest. Updates and deletes of a coffee that does not exist fail the batch.
+type CoffeeBatcher interface {
+	ApplyCoffeeWrites(writes []CoffeeWrite) error
+}
+
+// MySQLCoffeeBatcher implements CoffeeBatcher with a single MySQL transaction
+type MySQLCoffeeBatcher struct {
+	db *sql.DB
+}
+
+// NewMySQLCoffeeBatcher creates a new MySQL coffee batcher
+func NewMySQLCoffeeBatcher(db *sql.DB) *MySQLCoffeeBatcher {
+	return &MySQLCoffeeBatcher{db: db}
+}
+
+// ApplyCoffeeWrites applies the writes in order, or nothing if any fails.
+// Deleted coffees release their Pokemon catches, as in MySQLStorage.Delete.
+func (m *MySQLCoffeeBatcher) ApplyCoffeeWrites(writes []CoffeeWrite) error {
+	// The Pokemon tables only exist once Pokemon features have started
+	hasCatches, err := tableExists(m.db, "coffee_pokemon")
+	if err != nil {
+		return err
+	}
+
+	tx, err := m.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, write := range writes {
+		switch write.Kind {
+		case CoffeeWriteSave:
+			err = saveCoffee(tx, write.Coffee)
+		case CoffeeWriteUpdate:
+			err = updateCoffee(tx, write.ID, write.Coffee)
+		case CoffeeWriteDelete:
+			err = deleteCoffee(tx, write.ID, hasCatches)
+		default:
+			err = fmt.Errorf("failed to apply coffee write: unknown kind %q", write.Kind)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit coffee batch: %w", err)
+	}
+	return nil
+}
+
+// MemoryCoffeeBatcher implements CoffeeBatcher for the in-memory store.
+// Pokemon catches only exist in MySQL, so deletes have none to release.
+type MemoryCoffeeBatcher struct {
+	coffees *MemoryStorage
+}
+
+// NewMemoryCoffeeBatcher creates a new in-memory coffee batcher
+func NewMemoryCoffeeBatcher(coffees *MemoryStorage) *MemoryCoffeeBatcher {
+	return &MemoryCoffeeBatcher{coffees: coffees}
+}
+
+// ApplyCoffeeWrites checks every write against the coffees as the earlier
+// writes leave them, then applies them all while holding the lock
+func (m *MemoryCoffeeBatcher) ApplyCoffeeWrites(writes []CoffeeWrite) error {
+	m.coffees.mu.Lock()
+	defer m.coffees.mu.Unlock()
+
+	staged := make(map[string]*models.Coffee) // nil once deleted
+	current := func(id string) (models.Coffee, bool) {
+		if coffee, ok := staged[id]; ok {
+			if coffee == nil {
+				return models.Coffee{}, false
+			}
+			return *coffee, true
+		}
+		coffee, ok := m.coffees.coffees[id]
+		return coffee, ok
+	}
+
+	for _, write := range writes {
+		switch write.Kind {
+		case CoffeeWriteSave:
+			coffee := write.Coffee
+			staged[coffee.ID] = &coffee
+		case CoffeeWriteUpdate:
+			existing, ok := current(write.ID)
+			if !ok {
+				return fmt.Errorf("coffee not found")
+			}
+			coffee := write.Coffee
+			coffee.AddedBy = existing.AddedBy
+			staged[write.ID] = &coffee
+		case CoffeeWriteDelete:
+			if _, ok := current(write.ID); !ok {
+				return fmt.Errorf("coffee not found")
+			}
+			staged[write.ID] = nil
+		default:
+			return fmt.Errorf("failed to apply coffee write: unknown kind %q", write.Kind)
+		}
+	}
+
+	for id, coffee := range staged {
+		if coffee == nil {
+			delete(m.coffees.coffees, id)
+		} else {
+			m.coffees.coffees[id] = *coffee
+		}
+	}
+	return nil
+}
@@ -0,0 +1,248 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-coffee-log/models"
+	"log"
+	"sort"
+	"sync"
+)
+
+// BrewStorage defines the interface for brew session persistence
+type BrewStorage interface {
+	SaveBrew(brew models.Brew) error
+	GetBrewsByCoffee(coffeeID string) ([]models.Brew, error)
+	GetAllBrews() ([]models.Brew, error)
+	DeleteBrew(id string) error
+}
+
+// MemoryBrewStorage implements BrewStorage using an in-memory map
+type MemoryBrewStorage struct {
+	brews map[string]models.Brew
+	mu    sync.RWMutex
+}
+
+// NewMemoryBrewStorage creates a new in-memory brew storage
+func NewMemoryBrewStorage() *MemoryBrewStorage {
+	return &MemoryBrewStorage{
+		brews: make(map[string]models.Brew),
+	}
+}
+
+// SaveBrew stores a brew session
+func (m *MemoryBrewStorage) SaveBrew(brew models.Brew) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.brews[brew.ID] = brew
+	return nil
+}
+
+// GetBrewsByCoffee retrieves all brews of a coffee, oldest first
+func (m *MemoryBrewStorage) GetBrewsByCoffee(coffeeID string) ([]models.Brew, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var brews []models.Brew
+	for _, brew := range m.brews {
+		if brew.CoffeeID == coffeeID {
+			brews = append(brews, brew)
+		}
+	}
+
+	sort.Slice(brews, func(i, j int) bool { return brews[i].CreatedAt.Before(brews[j].CreatedAt) })
+	return brews, nil
+}
+
+// GetAllBrews retrieves every brew, oldest first
+func (m *MemoryBrewStorage) GetAllBrews() ([]models.Brew, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var brews []models.Brew
+	for _, brew := range m.brews {
+		brews = append(brews, brew)
+	}
+
+	sort.Slice(brews, func(i, j int) bool { return brews[i].CreatedAt.Before(brews[j].CreatedAt) })
+	return brews, nil
+}
+
+// DeleteBrew removes a brew session
+func (m *MemoryBrewStorage) DeleteBrew(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.brews[id]; !ok {
+		return errors.New("brew not found")
+	}
+	delete(m.brews, id)
+	return nil
+}
+
+// MySQLBrewStorage implements BrewStorage using MySQL database
+type MySQLBrewStorage struct {
+	db *sql.DB
+}
+
+// NewMySQLBrewStorage creates a new MySQL brew storage
+func NewMySQLBrewStorage(db *sql.DB) *MySQLBrewStorage {
+	storage := &MySQLBrewStorage{db: db}
+
+	if err := storage.initTables(); err != nil {
+		panic(fmt.Sprintf("failed to initialize brew tables: %v", err))
+	}
+
+	return storage
+}
+
+// initTables creates the brews table if it doesn't exist
+func (m *MySQLBrewStorage) initTables() error {
+	query := `
+		CREATE TABLE IF NOT EXISTS brews (
+			id VARCHAR(36) PRIMARY KEY,
+			coffee_id VARCHAR(36) NOT NULL,
+			grind_setting REAL,
+			brew_time_minutes INT,
+			brew_time_seconds INT,
+			outcome VARCHAR(20) NOT NULL,
+			rating INT,
+			notes TEXT,
+			dose_grams REAL,
+			water_grams REAL,
+			beverage_grams REAL,
+			tds REAL,
+			extraction_yield REAL,
+			temperature_value REAL,
+			temperature_unit VARCHAR(1),
+			brewed_by VARCHAR(36),
+			created_at DATETIME,
+			INDEX idx_brews_coffee (coffee_id)
+		)
+	`
+
+	if _, err := m.db.Exec(query); err != nil {
+		log.Printf("ERROR: initTables - Failed to create brews table: %v", err)
+		return fmt.Errorf("failed to create brews table: %w", err)
+	}
+
+	// Columns added after the table was first introduced
+	for _, column := range [][2]string{
+		{"dose_grams", "REAL"},
+		{"water_grams", "REAL"},
+		{"beverage_grams", "REAL"},
+		{"tds", "REAL"},
+		{"extraction_yield", "REAL"},
+		{"temperature_value", "REAL"},
+		{"temperature_unit", "VARCHAR(1)"},
+		{"brewed_by", "VARCHAR(36)"},
+	} {
+		if err := ensureColumn(m.db, "brews", column[0], column[1]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SaveBrew stores a brew session
+func (m *MySQLBrewStorage) SaveBrew(brew models.Brew) error {
+	query := `
+		INSERT INTO brews (
+			id, coffee_id, grind_setting, brew_time_minutes, brew_time_seconds,
+			outcome, rating, notes, dose_grams, water_grams, beverage_grams,
+			tds, extraction_yield, temperature_value, temperature_unit, brewed_by, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	var temperatureValue sql.NullFloat64
+	var temperatureUnit sql.NullString
+	if brew.Temperature != nil {
+		temperatureValue = sql.NullFloat64{Float64: brew.Temperature.Value, Valid: true}
+		temperatureUnit = sql.NullString{String: brew.Temperature.Unit, Valid: true}
+	}
+
+	_, err := m.db.Exec(
+		query,
+		brew.ID, brew.CoffeeID, brew.GrindSetting, brew.BrewTime.Minutes, brew.BrewTime.Seconds,
+		brew.Outcome, brew.Rating, brew.Notes, brew.DoseGrams, brew.WaterGrams, brew.BeverageGrams,
+		brew.TDS, brew.ExtractionYield, temperatureValue, temperatureUnit, brew.BrewedBy, brew.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save brew: %w", err)
+	}
+
+	return nil
+}
+
+// brewColumns lists the brews columns in scan order
+const brewColumns = `
+	id, coffee_id, grind_setting, brew_time_minutes, brew_time_seconds,
+	outcome, rating, notes, COALESCE(dose_grams, 0), COALESCE(water_grams, 0),
+	COALESCE(beverage_grams, 0), COALESCE(tds, 0), COALESCE(extraction_yield, 0),
+	temperature_value, temperature_unit, COALESCE(brewed_by, ''), created_at
+`
+
+// GetBrewsByCoffee retrieves all brews of a coffee, oldest first
+func (m *MySQLBrewStorage) GetBrewsByCoffee(coffeeID string) ([]models.Brew, error) {
+	return m.queryBrews("SELECT "+brewColumns+" FROM brews WHERE coffee_id = ? ORDER BY created_at ASC", coffeeID)
+}
+
+// GetAllBrews retrieves every brew, oldest first
+func (m *MySQLBrewStorage) GetAllBrews() ([]models.Brew, error) {
+	return m.queryBrews("SELECT " + brewColumns + " FROM brews ORDER BY created_at ASC")
+}
+
+// queryBrews runs a brews query and scans the rows
+func (m *MySQLBrewStorage) queryBrews(query string, args ...interface{}) ([]models.Brew, error) {
+	rows, err := m.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query brews: %w", err)
+	}
+	defer rows.Close()
+
+	var brews []models.Brew
+	for rows.Next() {
+		var brew models.Brew
+		var temperatureValue sql.NullFloat64
+		var temperatureUnit sql.NullString
+		err := rows.Scan(
+			&brew.ID, &brew.CoffeeID, &brew.GrindSetting, &brew.BrewTime.Minutes, &brew.BrewTime.Seconds,
+			&brew.Outcome, &brew.Rating, &brew.Notes, &brew.DoseGrams, &brew.WaterGrams,
+			&brew.BeverageGrams, &brew.TDS, &brew.ExtractionYield,
+			&temperatureValue, &temperatureUnit, &brew.BrewedBy, &brew.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan brew: %w", err)
+		}
+		if temperatureValue.Valid {
+			brew.Temperature = &models.Temperature{Value: temperatureValue.Float64, Unit: temperatureUnit.String}
+		}
+		brews = append(brews, brew)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return brews, nil
+}
+
+// DeleteBrew removes a brew session
+func (m *MySQLBrewStorage) DeleteBrew(id string) error {
+	result, err := m.db.Exec("DELETE FROM brews WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete brew: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("brew not found")
+	}
+
+	return nil
+}
@@ -0,0 +1,350 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"go-coffee-log/models"
+	"log"
+	"sort"
+	"sync"
+)
+
+// CollectionStorage defines the interface for user-defined coffee collections
+type CollectionStorage interface {
+	SaveCollection(collection models.Collection) error
+	GetCollection(id string) (models.Collection, error)
+	GetAllCollections() ([]models.Collection, error)
+	// UpdateCollection changes the name and description; membership is
+	// changed with AddCoffee and RemoveCoffee
+	UpdateCollection(collection models.Collection) error
+	DeleteCollection(id string) error
+	// AddCoffee adds a coffee to a collection; adding it twice is a no-op
+	AddCoffee(collectionID, coffeeID string) error
+	RemoveCoffee(collectionID, coffeeID string) error
+}
+
+// MemoryCollectionStorage implements CollectionStorage in memory
+type MemoryCollectionStorage struct {
+	collections map[string]models.Collection
+	mu          sync.RWMutex
+}
+
+// NewMemoryCollectionStorage creates a new in-memory collection storage
+func NewMemoryCollectionStorage() *MemoryCollectionStorage {
+	return &MemoryCollectionStorage{collections: make(map[string]models.Collection)}
+}
+
+// copyCollection returns a collection that does not share its coffee list
+func copyCollection(collection models.Collection) models.Collection {
+	collection.CoffeeIDs = append([]string{}, collection.CoffeeIDs...)
+	return collection
+}
+
+// SaveCollection stores a new collection
+func (m *MemoryCollectionStorage) SaveCollection(collection models.Collection) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.collections[collection.ID] = copyCollection(collection)
+	return nil
+}
+
+// GetCollection retrieves a collection by ID
+func (m *MemoryCollectionStorage) GetCollection(id string) (models.Collection, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	collection, ok := m.collections[id]
+	if !ok {
+		return models.Collection{}, fmt.Errorf("collection not found")
+	}
+	return copyCollection(collection), nil
+}
+
+// GetAllCollections returns every collection ordered by name
+func (m *MemoryCollectionStorage) GetAllCollections() ([]models.Collection, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	collections := make([]models.Collection, 0, len(m.collections))
+	for _, collection := range m.collections {
+		collections = append(collections, copyCollection(collection))
+	}
+	sort.Slice(collections, func(i, j int) bool { return collections[i].Name < collections[j].Name })
+	return collections, nil
+}
+
+// UpdateCollection changes a collection's name and description
+func (m *MemoryCollectionStorage) UpdateCollection(collection models.Collection) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.collections[collection.ID]
+	if !ok {
+		return fmt.Errorf("collection not found")
+	}
+	existing.Name = collection.Name
+	existing.Description = collection.Description
+	existing.UpdatedAt = collection.UpdatedAt
+	m.collections[collection.ID] = existing
+	return nil
+}
+
+// DeleteCollection removes a collection; its coffees are kept
+func (m *MemoryCollectionStorage) DeleteCollection(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.collections[id]; !ok {
+		return fmt.Errorf("collection not found")
+	}
+	delete(m.collections, id)
+	return nil
+}
+
+// AddCoffee adds a coffee to a collection
+func (m *MemoryCollectionStorage) AddCoffee(collectionID, coffeeID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	collection, ok := m.collections[collectionID]
+	if !ok {
+		return fmt.Errorf("collection not found")
+	}
+	for _, id := range collection.CoffeeIDs {
+		if id == coffeeID {
+			return nil
+		}
+	}
+	collection.CoffeeIDs = append(collection.CoffeeIDs, coffeeID)
+	m.collections[collectionID] = collection
+	return nil
+}
+
+// RemoveCoffee removes a coffee from a collection
+func (m *MemoryCollectionStorage) RemoveCoffee(collectionID, coffeeID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	collection, ok := m.collections[collectionID]
+	if !ok {
+		return fmt.Errorf("collection not found")
+	}
+	for i, id := range collection.CoffeeIDs {
+		if id == coffeeID {
+			collection.CoffeeIDs = append(collection.CoffeeIDs[:i:i], collection.CoffeeIDs[i+1:]...)
+			m.collections[collectionID] = collection
+			return nil
+		}
+	}
+	return fmt.Errorf("coffee not in collection")
+}
+
+// MySQLCollectionStorage implements CollectionStorage using MySQL
+type MySQLCollectionStorage struct {
+	db *sql.DB
+}
+
+// NewMySQLCollectionStorage creates a new MySQL collection storage
+func NewMySQLCollectionStorage(db *sql.DB) *MySQLCollectionStorage {
+	storage := &MySQLCollectionStorage{db: db}
+
+	if err := storage.initTables(); err != nil {
+		panic(fmt.Sprintf("failed to initialize collection tables: %v", err))
+	}
+
+	return storage
+}
+
+// initTables creates the collections and collection_coffees tables if they don't exist
+func (m *MySQLCollectionStorage) initTables() error {
+	queries := []string{
+		`CREATE TABLE IF NOT EXISTS collections (
+			id VARCHAR(36) PRIMARY KEY,
+			name VARCHAR(100) NOT NULL,
+			description TEXT,
+			created_at DATETIME,
+			updated_at DATETIME
+		)`,
+		`CREATE TABLE IF NOT EXISTS collection_coffees (
+			collection_id VARCHAR(36) NOT NULL,
+			coffee_id VARCHAR(36) NOT NULL,
+			added_at DATETIME(6) NOT NULL,
+			PRIMARY KEY (collection_id, coffee_id),
+			FOREIGN KEY (collection_id) REFERENCES collections(id) ON DELETE CASCADE,
+			FOREIGN KEY (coffee_id) REFERENCES coffees(id) ON DELETE CASCADE
+		)`,
+	}
+
+	for _, query := range queries {
+		if _, err := m.db.Exec(query); err != nil {
+			log.Printf("ERROR: initTables - Failed to create collection tables: %v", err)
+			return fmt.Errorf("failed to create collection tables: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// SaveCollection stores a new collection and its initial coffees
+func (m *MySQLCollectionStorage) SaveCollection(collection models.Collection) error {
+	_, err := m.db.Exec(
+		"INSERT INTO collections (id, name, description, created_at, updated_at) VALUES (?, ?, ?, ?, ?)",
+		collection.ID, collection.Name, collection.Description, collection.CreatedAt, collection.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save collection: %w", err)
+	}
+
+	for _, coffeeID := range collection.CoffeeIDs {
+		if err := m.AddCoffee(collection.ID, coffeeID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetCollection retrieves a collection by ID
+func (m *MySQLCollectionStorage) GetCollection(id string) (models.Collection, error) {
+	var collection models.Collection
+	var description sql.NullString
+	var createdAt, updatedAt sql.NullTime
+	err := m.db.QueryRow(
+		"SELECT id, name, description, created_at, updated_at FROM collections WHERE id = ?", id,
+	).Scan(&collection.ID, &collection.Name, &description, &createdAt, &updatedAt)
+	if err == sql.ErrNoRows {
+		return models.Collection{}, fmt.Errorf("collection not found")
+	}
+	if err != nil {
+		return models.Collection{}, fmt.Errorf("failed to get collection: %w", err)
+	}
+	collection.Description = description.String
+	collection.CreatedAt = createdAt.Time
+	collection.UpdatedAt = updatedAt.Time
+
+	members, err := m.members("WHERE collection_id = ?", id)
+	if err != nil {
+		return models.Collection{}, err
+	}
+	collection.CoffeeIDs = members[id]
+	if collection.CoffeeIDs == nil {
+		collection.CoffeeIDs = []string{}
+	}
+	return collection, nil
+}
+
+// GetAllCollections returns every collection ordered by name
+func (m *MySQLCollectionStorage) GetAllCollections() ([]models.Collection, error) {
+	rows, err := m.db.Query("SELECT id, name, description, created_at, updated_at FROM collections ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query collections: %w", err)
+	}
+	defer rows.Close()
+
+	collections := []models.Collection{}
+	for rows.Next() {
+		var collection models.Collection
+		var description sql.NullString
+		var createdAt, updatedAt sql.NullTime
+		if err := rows.Scan(&collection.ID, &collection.Name, &description, &createdAt, &updatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan collection: %w", err)
+		}
+		collection.Description = description.String
+		collection.CreatedAt = createdAt.Time
+		collection.UpdatedAt = updatedAt.Time
+		collections = append(collections, collection)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating collections: %w", err)
+	}
+
+	members, err := m.members("")
+	if err != nil {
+		return nil, err
+	}
+	for i := range collections {
+		collections[i].CoffeeIDs = members[collections[i].ID]
+		if collections[i].CoffeeIDs == nil {
+			collections[i].CoffeeIDs = []string{}
+		}
+	}
+	return collections, nil
+}
+
+// members returns the coffee IDs of the matching collections, by collection
+func (m *MySQLCollectionStorage) members(where string, args ...interface{}) (map[string][]string, error) {
+	rows, err := m.db.Query("SELECT collection_id, coffee_id FROM collection_coffees "+where+" ORDER BY added_at", args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query collection coffees: %w", err)
+	}
+	defer rows.Close()
+
+	members := make(map[string][]string)
+	for rows.Next() {
+		var collectionID, coffeeID string
+		if err := rows.Scan(&collectionID, &coffeeID); err != nil {
+			return nil, fmt.Errorf("failed to scan collection coffee: %w", err)
+		}
+		members[collectionID] = append(members[collectionID], coffeeID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating collection coffees: %w", err)
+	}
+	return members, nil
+}
+
+// UpdateCollection changes a collection's name and description
+func (m *MySQLCollectionStorage) UpdateCollection(collection models.Collection) error {
+	result, err := m.db.Exec(
+		"UPDATE collections SET name = ?, description = ?, updated_at = ? WHERE id = ?",
+		collection.Name, collection.Description, collection.UpdatedAt, collection.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update collection: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		// MySQL reports 0 rows for updates that change nothing, so check
+		if _, err := m.GetCollection(collection.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteCollection removes a collection; its coffees are kept
+func (m *MySQLCollectionStorage) DeleteCollection(id string) error {
+	result, err := m.db.Exec("DELETE FROM collections WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete collection: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("collection not found")
+	}
+	return nil
+}
+
+// AddCoffee adds a coffee to a collection
+func (m *MySQLCollectionStorage) AddCoffee(collectionID, coffeeID string) error {
+	_, err := m.db.Exec(
+		"INSERT IGNORE INTO collection_coffees (collection_id, coffee_id, added_at) VALUES (?, ?, NOW(6))",
+		collectionID, coffeeID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to add coffee to collection: %w", err)
+	}
+	return nil
+}
+
+// RemoveCoffee removes a coffee from a collection
+func (m *MySQLCollectionStorage) RemoveCoffee(collectionID, coffeeID string) error {
+	result, err := m.db.Exec(
+		"DELETE FROM collection_coffees WHERE collection_id = ? AND coffee_id = ?",
+		collectionID, coffeeID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to remove coffee from collection: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("coffee not in collection")
+	}
+	return nil
+}
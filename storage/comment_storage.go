@@ -0,0 +1,275 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-coffee-log/models"
+	"log"
+	"sort"
+	"sync"
+)
+
+// CommentStorage defines the interface for coffee comments
+type CommentStorage interface {
+	SaveComment(comment models.Comment) error
+	GetComment(id string) (models.Comment, error)
+	GetCommentsByCoffee(coffeeID string) ([]models.Comment, error)
+	GetAllComments() ([]models.Comment, error)
+	UpdateComment(comment models.Comment) error
+	// DeleteComments removes several comments at once, e.g. a comment and
+	// its replies
+	DeleteComments(ids []string) error
+}
+
+// MemoryCommentStorage implements CommentStorage using an in-memory map
+type MemoryCommentStorage struct {
+	comments map[string]models.Comment
+	mu       sync.RWMutex
+}
+
+// NewMemoryCommentStorage creates a new in-memory comment storage
+func NewMemoryCommentStorage() *MemoryCommentStorage {
+	return &MemoryCommentStorage{
+		comments: make(map[string]models.Comment),
+	}
+}
+
+// SaveComment stores a new comment
+func (m *MemoryCommentStorage) SaveComment(comment models.Comment) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.comments[comment.ID] = comment
+	return nil
+}
+
+// GetComment retrieves a comment by ID
+func (m *MemoryCommentStorage) GetComment(id string) (models.Comment, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	comment, ok := m.comments[id]
+	if !ok {
+		return models.Comment{}, errors.New("comment not found")
+	}
+	return comment, nil
+}
+
+// GetCommentsByCoffee retrieves all comments on a coffee, oldest first
+func (m *MemoryCommentStorage) GetCommentsByCoffee(coffeeID string) ([]models.Comment, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var comments []models.Comment
+	for _, comment := range m.comments {
+		if comment.CoffeeID == coffeeID {
+			comments = append(comments, comment)
+		}
+	}
+	sortComments(comments)
+	return comments, nil
+}
+
+// GetAllComments retrieves every comment, oldest first
+func (m *MemoryCommentStorage) GetAllComments() ([]models.Comment, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var comments []models.Comment
+	for _, comment := range m.comments {
+		comments = append(comments, comment)
+	}
+	sortComments(comments)
+	return comments, nil
+}
+
+// UpdateComment replaces an existing comment
+func (m *MemoryCommentStorage) UpdateComment(comment models.Comment) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.comments[comment.ID]; !ok {
+		return errors.New("comment not found")
+	}
+	m.comments[comment.ID] = comment
+	return nil
+}
+
+// DeleteComments removes the given comments
+func (m *MemoryCommentStorage) DeleteComments(ids []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, id := range ids {
+		delete(m.comments, id)
+	}
+	return nil
+}
+
+// sortComments orders comments oldest first
+func sortComments(comments []models.Comment) {
+	sort.Slice(comments, func(i, j int) bool { return comments[i].CreatedAt.Before(comments[j].CreatedAt) })
+}
+
+// MySQLCommentStorage implements CommentStorage using MySQL database
+type MySQLCommentStorage struct {
+	db *sql.DB
+}
+
+// NewMySQLCommentStorage creates a new MySQL comment storage
+func NewMySQLCommentStorage(db *sql.DB) *MySQLCommentStorage {
+	storage := &MySQLCommentStorage{db: db}
+
+	if err := storage.initTables(); err != nil {
+		panic(fmt.Sprintf("failed to initialize comments table: %v", err))
+	}
+
+	return storage
+}
+
+// initTables creates the comments table if it doesn't exist
+func (m *MySQLCommentStorage) initTables() error {
+	query := `
+		CREATE TABLE IF NOT EXISTS comments (
+			id VARCHAR(36) PRIMARY KEY,
+			coffee_id VARCHAR(36) NOT NULL,
+			parent_id VARCHAR(36) NULL,
+			author_id VARCHAR(36) NULL,
+			author_name VARCHAR(100) NOT NULL,
+			body TEXT NOT NULL,
+			created_at DATETIME,
+			updated_at DATETIME,
+			INDEX idx_comments_coffee (coffee_id)
+		)
+	`
+
+	if _, err := m.db.Exec(query); err != nil {
+		log.Printf("ERROR: initTables - Failed to create comments table: %v", err)
+		return fmt.Errorf("failed to create comments table: %w", err)
+	}
+
+	return nil
+}
+
+// SaveComment stores a new comment
+func (m *MySQLCommentStorage) SaveComment(comment models.Comment) error {
+	query := `
+		INSERT INTO comments (id, coffee_id, parent_id, author_id, author_name, body, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := m.db.Exec(
+		query,
+		comment.ID, comment.CoffeeID, nullableString(comment.ParentID), nullableString(comment.AuthorID),
+		comment.AuthorName, comment.Body, comment.CreatedAt, comment.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save comment: %w", err)
+	}
+
+	return nil
+}
+
+// commentColumns lists the comments columns in scan order
+const commentColumns = `
+	id, coffee_id, COALESCE(parent_id, ''), COALESCE(author_id, ''), author_name, body, created_at, updated_at
+`
+
+// GetComment retrieves a comment by ID
+func (m *MySQLCommentStorage) GetComment(id string) (models.Comment, error) {
+	comments, err := m.queryComments("SELECT "+commentColumns+" FROM comments WHERE id = ?", id)
+	if err != nil {
+		return models.Comment{}, err
+	}
+	if len(comments) == 0 {
+		return models.Comment{}, fmt.Errorf("comment not found")
+	}
+	return comments[0], nil
+}
+
+// GetCommentsByCoffee retrieves all comments on a coffee, oldest first
+func (m *MySQLCommentStorage) GetCommentsByCoffee(coffeeID string) ([]models.Comment, error) {
+	return m.queryComments("SELECT "+commentColumns+" FROM comments WHERE coffee_id = ? ORDER BY created_at ASC", coffeeID)
+}
+
+// GetAllComments retrieves every comment, oldest first
+func (m *MySQLCommentStorage) GetAllComments() ([]models.Comment, error) {
+	return m.queryComments("SELECT " + commentColumns + " FROM comments ORDER BY created_at ASC")
+}
+
+// queryComments runs a comments query and scans the rows
+func (m *MySQLCommentStorage) queryComments(query string, args ...interface{}) ([]models.Comment, error) {
+	rows, err := m.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query comments: %w", err)
+	}
+	defer rows.Close()
+
+	var comments []models.Comment
+	for rows.Next() {
+		var comment models.Comment
+		err := rows.Scan(
+			&comment.ID, &comment.CoffeeID, &comment.ParentID, &comment.AuthorID,
+			&comment.AuthorName, &comment.Body, &comment.CreatedAt, &comment.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan comment: %w", err)
+		}
+		comments = append(comments, comment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return comments, nil
+}
+
+// UpdateComment replaces the body of an existing comment
+func (m *MySQLCommentStorage) UpdateComment(comment models.Comment) error {
+	result, err := m.db.Exec(
+		"UPDATE comments SET body = ?, updated_at = ? WHERE id = ?",
+		comment.Body, comment.UpdatedAt, comment.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update comment: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("comment not found")
+	}
+
+	return nil
+}
+
+// DeleteComments removes the given comments in one transaction
+func (m *MySQLCommentStorage) DeleteComments(ids []string) error {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, id := range ids {
+		if _, err := tx.Exec("DELETE FROM comments WHERE id = ?", id); err != nil {
+			return fmt.Errorf("failed to delete comment: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit comment deletion: %w", err)
+	}
+	return nil
+}
+
+// nullableString stores empty strings as NULL
+func nullableString(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
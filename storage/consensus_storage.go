@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"go-coffee-log/models"
+	"log"
+)
+
+// ConsensusStorage defines the interface for persisting consensus disagreements
+type ConsensusStorage interface {
+	SaveDisagreement(disagreement models.MappingDisagreement) error
+	GetAllDisagreements() ([]models.MappingDisagreement, error)
+}
+
+// MySQLConsensusStorage implements ConsensusStorage using MySQL database
+type MySQLConsensusStorage struct {
+	db *sql.DB
+}
+
+// NewMySQLConsensusStorage creates a new MySQL consensus storage
+func NewMySQLConsensusStorage(db *sql.DB) *MySQLConsensusStorage {
+	storage := &MySQLConsensusStorage{db: db}
+
+	if err := storage.initTables(); err != nil {
+		panic(fmt.Sprintf("failed to initialize consensus tables: %v", err))
+	}
+
+	return storage
+}
+
+// initTables creates the mapping_disagreements table if it doesn't exist
+func (m *MySQLConsensusStorage) initTables() error {
+	query := `
+		CREATE TABLE IF NOT EXISTS mapping_disagreements (
+			id VARCHAR(36) PRIMARY KEY,
+			coffee_id VARCHAR(36) NOT NULL,
+			selected_pokemon VARCHAR(50) NOT NULL,
+			agreement REAL,
+			proposals JSON,
+			created_at DATETIME
+		)
+	`
+
+	if _, err := m.db.Exec(query); err != nil {
+		log.Printf("ERROR: initTables - Failed to create mapping_disagreements table: %v", err)
+		return fmt.Errorf("failed to create mapping_disagreements table: %w", err)
+	}
+
+	return nil
+}
+
+// SaveDisagreement stores a consensus disagreement record
+func (m *MySQLConsensusStorage) SaveDisagreement(disagreement models.MappingDisagreement) error {
+	proposalsJSON, err := json.Marshal(disagreement.Proposals)
+	if err != nil {
+		return fmt.Errorf("failed to marshal proposals: %w", err)
+	}
+
+	query := `
+		INSERT INTO mapping_disagreements (id, coffee_id, selected_pokemon, agreement, proposals, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+
+	_, err = m.db.Exec(
+		query,
+		disagreement.ID, disagreement.CoffeeID, disagreement.SelectedPokemon,
+		disagreement.Agreement, proposalsJSON, disagreement.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save disagreement: %w", err)
+	}
+
+	return nil
+}
+
+// GetAllDisagreements retrieves all recorded disagreements, newest first
+func (m *MySQLConsensusStorage) GetAllDisagreements() ([]models.MappingDisagreement, error) {
+	query := `
+		SELECT id, coffee_id, selected_pokemon, agreement, proposals, created_at
+		FROM mapping_disagreements
+		ORDER BY created_at DESC
+	`
+
+	rows, err := m.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query disagreements: %w", err)
+	}
+	defer rows.Close()
+
+	var disagreements []models.MappingDisagreement
+	for rows.Next() {
+		var disagreement models.MappingDisagreement
+		var proposalsJSON []byte
+
+		err := rows.Scan(
+			&disagreement.ID, &disagreement.CoffeeID, &disagreement.SelectedPokemon,
+			&disagreement.Agreement, &proposalsJSON, &disagreement.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan disagreement: %w", err)
+		}
+
+		if len(proposalsJSON) > 0 {
+			if err := json.Unmarshal(proposalsJSON, &disagreement.Proposals); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal proposals: %w", err)
+			}
+		}
+
+		disagreements = append(disagreements, disagreement)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return disagreements, nil
+}
@@ -0,0 +1,182 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"go-coffee-log/models"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DailyStatsStorage defines the interface for the materialized daily statistics
+type DailyStatsStorage interface {
+	// ReplaceDailyStats swaps in a complete recomputation in one step
+	ReplaceDailyStats(stats []models.DailyStat) error
+	// GetDailyStats returns one dimension between from and to inclusive,
+	// oldest first
+	GetDailyStats(dimension string, from, to time.Time) ([]models.DailyStat, error)
+}
+
+// sortDailyStats orders stats by day, then value
+func sortDailyStats(stats []models.DailyStat) {
+	sort.Slice(stats, func(i, j int) bool {
+		if !stats[i].Day.Equal(stats[j].Day.Time) {
+			return stats[i].Day.Before(stats[j].Day.Time)
+		}
+		return stats[i].Value < stats[j].Value
+	})
+}
+
+// MemoryDailyStatsStorage implements DailyStatsStorage in memory
+type MemoryDailyStatsStorage struct {
+	stats []models.DailyStat
+	mu    sync.RWMutex
+}
+
+// NewMemoryDailyStatsStorage creates a new in-memory daily stats storage
+func NewMemoryDailyStatsStorage() *MemoryDailyStatsStorage {
+	return &MemoryDailyStatsStorage{}
+}
+
+// ReplaceDailyStats replaces every stored row
+func (m *MemoryDailyStatsStorage) ReplaceDailyStats(stats []models.DailyStat) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stats = append([]models.DailyStat(nil), stats...)
+	return nil
+}
+
+// GetDailyStats returns the rows of one dimension within the date range
+func (m *MemoryDailyStatsStorage) GetDailyStats(dimension string, from, to time.Time) ([]models.DailyStat, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var stats []models.DailyStat
+	for _, stat := range m.stats {
+		if stat.Dimension == dimension && !stat.Day.Before(from) && !stat.Day.After(to) {
+			stats = append(stats, stat)
+		}
+	}
+	sortDailyStats(stats)
+	return stats, nil
+}
+
+// MySQLDailyStatsStorage implements DailyStatsStorage using MySQL
+type MySQLDailyStatsStorage struct {
+	db *sql.DB
+}
+
+// NewMySQLDailyStatsStorage creates a new MySQL daily stats storage
+func NewMySQLDailyStatsStorage(db *sql.DB) *MySQLDailyStatsStorage {
+	storage := &MySQLDailyStatsStorage{db: db}
+
+	if err := storage.initTables(); err != nil {
+		panic(fmt.Sprintf("failed to initialize daily stats table: %v", err))
+	}
+
+	return storage
+}
+
+// initTables creates the daily_stats table if it doesn't exist
+func (m *MySQLDailyStatsStorage) initTables() error {
+	query := `
+		CREATE TABLE IF NOT EXISTS daily_stats (
+			day DATE NOT NULL,
+			dimension VARCHAR(30) NOT NULL,
+			value VARCHAR(255) NOT NULL DEFAULT '',
+			coffees INT NOT NULL,
+			average_rating REAL,
+			brews INT NOT NULL,
+			average_brew_rating REAL,
+			computed_at DATETIME,
+			PRIMARY KEY (dimension, day, value)
+		)
+	`
+
+	if _, err := m.db.Exec(query); err != nil {
+		log.Printf("ERROR: initTables - Failed to create daily_stats table: %v", err)
+		return fmt.Errorf("failed to create daily_stats table: %w", err)
+	}
+
+	return nil
+}
+
+// ReplaceDailyStats rewrites the table in a single transaction, so readers
+// never see a half-written recomputation
+func (m *MySQLDailyStatsStorage) ReplaceDailyStats(stats []models.DailyStat) error {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM daily_stats"); err != nil {
+		return fmt.Errorf("failed to clear daily stats: %w", err)
+	}
+
+	insert, err := tx.Prepare(`
+		INSERT INTO daily_stats (
+			day, dimension, value, coffees, average_rating, brews, average_brew_rating, computed_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare daily stats insert: %w", err)
+	}
+	defer insert.Close()
+
+	for _, stat := range stats {
+		_, err := insert.Exec(
+			stat.Day.Time, stat.Dimension, stat.Value, stat.Coffees, stat.AverageRating,
+			stat.Brews, stat.AverageBrewRating, stat.ComputedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to save daily stat: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit daily stats: %w", err)
+	}
+	return nil
+}
+
+// GetDailyStats returns the rows of one dimension within the date range
+func (m *MySQLDailyStatsStorage) GetDailyStats(dimension string, from, to time.Time) ([]models.DailyStat, error) {
+	rows, err := m.db.Query(`
+		SELECT day, dimension, value, coffees, average_rating, brews, average_brew_rating, computed_at
+		FROM daily_stats
+		WHERE dimension = ? AND day BETWEEN ? AND ?
+		ORDER BY day, value
+	`, dimension, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query daily stats: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []models.DailyStat
+	for rows.Next() {
+		var stat models.DailyStat
+		var day time.Time
+		var averageRating, averageBrewRating sql.NullFloat64
+		var computedAt sql.NullTime
+		err := rows.Scan(
+			&day, &stat.Dimension, &stat.Value, &stat.Coffees, &averageRating,
+			&stat.Brews, &averageBrewRating, &computedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan daily stat: %w", err)
+		}
+		stat.Day = models.NewDate(day)
+		stat.AverageRating = averageRating.Float64
+		stat.AverageBrewRating = averageBrewRating.Float64
+		stat.ComputedAt = computedAt.Time
+		stats = append(stats, stat)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating daily stats: %w", err)
+	}
+	return stats, nil
+}
@@ -0,0 +1,186 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"go-coffee-log/models"
+	"log"
+)
+
+// DexStorage defines the interface for custom dex profiles and the profile
+// each user has selected. Built-in profiles are not stored.
+type DexStorage interface {
+	SaveProfile(profile models.DexProfile) error
+	GetProfile(id string) (models.DexProfile, error)
+	GetAllProfiles() ([]models.DexProfile, error)
+	DeleteProfile(id string) error
+	// GetSelection returns the profile a user selected, or "" if none
+	GetSelection(userID string) (string, error)
+	SetSelection(userID, dex string) error
+}
+
+// MySQLDexStorage implements DexStorage using MySQL database
+type MySQLDexStorage struct {
+	db *sql.DB
+}
+
+// NewMySQLDexStorage creates a new MySQL dex storage
+func NewMySQLDexStorage(db *sql.DB) *MySQLDexStorage {
+	storage := &MySQLDexStorage{db: db}
+
+	if err := storage.initTables(); err != nil {
+		panic(fmt.Sprintf("failed to initialize dex tables: %v", err))
+	}
+
+	return storage
+}
+
+// initTables creates the dex_profiles and dex_selections tables if they
+// don't exist
+func (m *MySQLDexStorage) initTables() error {
+	queries := map[string]string{
+		"dex_profiles": `
+			CREATE TABLE IF NOT EXISTS dex_profiles (
+				id VARCHAR(36) PRIMARY KEY,
+				name VARCHAR(100) NOT NULL,
+				description TEXT,
+				pokemon_ids JSON NOT NULL,
+				created_at DATETIME
+			)
+		`,
+		"dex_selections": `
+			CREATE TABLE IF NOT EXISTS dex_selections (
+				user_id VARCHAR(36) PRIMARY KEY,
+				dex VARCHAR(36) NOT NULL,
+				updated_at DATETIME
+			)
+		`,
+	}
+
+	for _, table := range []string{"dex_profiles", "dex_selections"} {
+		if _, err := m.db.Exec(queries[table]); err != nil {
+			log.Printf("ERROR: initTables - Failed to create %s table: %v", table, err)
+			return fmt.Errorf("failed to create %s table: %w", table, err)
+		}
+	}
+
+	return nil
+}
+
+// dexProfileColumns is the column list scanDexProfile expects
+const dexProfileColumns = "id, name, description, pokemon_ids, created_at"
+
+// scanDexProfile reads one dex profile row
+func scanDexProfile(scanner interface{ Scan(...interface{}) error }) (models.DexProfile, error) {
+	var profile models.DexProfile
+	var description sql.NullString
+	var idsJSON []byte
+	if err := scanner.Scan(&profile.ID, &profile.Name, &description, &idsJSON, &profile.CreatedAt); err != nil {
+		return models.DexProfile{}, err
+	}
+	profile.Description = description.String
+	if err := json.Unmarshal(idsJSON, &profile.PokemonIDs); err != nil {
+		return models.DexProfile{}, fmt.Errorf("failed to unmarshal pokemon_ids: %w", err)
+	}
+	return profile, nil
+}
+
+// SaveProfile stores a new custom profile
+func (m *MySQLDexStorage) SaveProfile(profile models.DexProfile) error {
+	idsJSON, err := json.Marshal(profile.PokemonIDs)
+	if err != nil {
+		return fmt.Errorf("failed to marshal pokemon_ids: %w", err)
+	}
+
+	var exists int
+	if err := m.db.QueryRow("SELECT COUNT(*) FROM dex_profiles WHERE id = ?", profile.ID).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check dex profile: %w", err)
+	}
+	if exists > 0 {
+		return fmt.Errorf("dex profile already exists")
+	}
+
+	query := `INSERT INTO dex_profiles (` + dexProfileColumns + `) VALUES (?, ?, ?, ?, ?)`
+	if _, err := m.db.Exec(query, profile.ID, profile.Name, profile.Description, idsJSON, profile.CreatedAt); err != nil {
+		return fmt.Errorf("failed to save dex profile: %w", err)
+	}
+	return nil
+}
+
+// GetProfile retrieves a custom profile by ID
+func (m *MySQLDexStorage) GetProfile(id string) (models.DexProfile, error) {
+	profile, err := scanDexProfile(m.db.QueryRow("SELECT "+dexProfileColumns+" FROM dex_profiles WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return models.DexProfile{}, fmt.Errorf("dex profile not found")
+	}
+	if err != nil {
+		return models.DexProfile{}, fmt.Errorf("failed to get dex profile: %w", err)
+	}
+	return profile, nil
+}
+
+// GetAllProfiles returns every custom profile, oldest first
+func (m *MySQLDexStorage) GetAllProfiles() ([]models.DexProfile, error) {
+	rows, err := m.db.Query("SELECT " + dexProfileColumns + " FROM dex_profiles ORDER BY created_at ASC")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query dex profiles: %w", err)
+	}
+	defer rows.Close()
+
+	profiles := []models.DexProfile{}
+	for rows.Next() {
+		profile, err := scanDexProfile(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan dex profile: %w", err)
+		}
+		profiles = append(profiles, profile)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return profiles, nil
+}
+
+// DeleteProfile removes a custom profile and every selection of it
+func (m *MySQLDexStorage) DeleteProfile(id string) error {
+	result, err := m.db.Exec("DELETE FROM dex_profiles WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete dex profile: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return fmt.Errorf("dex profile not found")
+	}
+
+	if _, err := m.db.Exec("DELETE FROM dex_selections WHERE dex = ?", id); err != nil {
+		return fmt.Errorf("failed to clear dex selections: %w", err)
+	}
+	return nil
+}
+
+// GetSelection returns the profile a user selected, or "" if none
+func (m *MySQLDexStorage) GetSelection(userID string) (string, error) {
+	var dex string
+	err := m.db.QueryRow("SELECT dex FROM dex_selections WHERE user_id = ?", userID).Scan(&dex)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get dex selection: %w", err)
+	}
+	return dex, nil
+}
+
+// SetSelection records the profile a user selected
+func (m *MySQLDexStorage) SetSelection(userID, dex string) error {
+	query := `
+		INSERT INTO dex_selections (user_id, dex, updated_at) VALUES (?, ?, NOW())
+		ON DUPLICATE KEY UPDATE dex = VALUES(dex), updated_at = VALUES(updated_at)
+	`
+	if _, err := m.db.Exec(query, userID, dex); err != nil {
+		return fmt.Errorf("failed to save dex selection: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,238 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-coffee-log/models"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// FileStorage implements CoffeeStorage and BrewStorage on top of a single
+// JSON file that is rewritten after every change. It is meant as a
+// replication target, not as a primary store for large collections.
+// With a cipher the file is encrypted at rest.
+type FileStorage struct {
+	path    string
+	cipher  *Cipher // optional
+	coffees map[string]models.Coffee
+	brews   map[string]models.Brew
+	mu      sync.RWMutex
+}
+
+// fileSnapshot is the on-disk layout of a FileStorage
+type fileSnapshot struct {
+	Coffees []models.Coffee `json:"coffees"`
+	Brews   []models.Brew   `json:"brews"`
+}
+
+// NewFileStorage opens (or creates) a JSON file store at path
+func NewFileStorage(path string) (*FileStorage, error) {
+	return NewEncryptedFileStorage(path, nil)
+}
+
+// NewEncryptedFileStorage opens (or creates) a file store encrypted with
+// cipher. An existing plaintext file is read and encrypted on the next
+// write; a nil cipher stores plaintext.
+func NewEncryptedFileStorage(path string, cipher *Cipher) (*FileStorage, error) {
+	f := &FileStorage{
+		path:    path,
+		cipher:  cipher,
+		coffees: make(map[string]models.Coffee),
+		brews:   make(map[string]models.Brew),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return f, f.flush()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if IsEncrypted(data) {
+		if cipher == nil {
+			return nil, fmt.Errorf("%s is encrypted and no data key is configured", path)
+		}
+		if data, err = cipher.Open(data); err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", path, err)
+		}
+	}
+
+	var snapshot fileSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	for _, coffee := range snapshot.Coffees {
+		f.coffees[coffee.ID] = coffee
+	}
+	for _, brew := range snapshot.Brews {
+		f.brews[brew.ID] = brew
+	}
+
+	return f, nil
+}
+
+// flush writes the current contents to disk atomically. Callers hold the lock.
+func (f *FileStorage) flush() error {
+	snapshot := fileSnapshot{
+		Coffees: make([]models.Coffee, 0, len(f.coffees)),
+		Brews:   make([]models.Brew, 0, len(f.brews)),
+	}
+	for _, coffee := range f.coffees {
+		snapshot.Coffees = append(snapshot.Coffees, coffee)
+	}
+	for _, brew := range f.brews {
+		snapshot.Brews = append(snapshot.Brews, brew)
+	}
+	sort.Slice(snapshot.Coffees, func(i, j int) bool { return snapshot.Coffees[i].ID < snapshot.Coffees[j].ID })
+	sort.Slice(snapshot.Brews, func(i, j int) bool { return snapshot.Brews[i].ID < snapshot.Brews[j].ID })
+
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", f.path, err)
+	}
+	if f.cipher != nil {
+		if data, err = f.cipher.Seal(data); err != nil {
+			return fmt.Errorf("failed to encrypt %s: %w", f.path, err)
+		}
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), ".coffee-log-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", f.path, err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write %s: %w", f.path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write %s: %w", f.path, err)
+	}
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write %s: %w", f.path, err)
+	}
+
+	return nil
+}
+
+// Save stores a new coffee entry
+func (f *FileStorage) Save(coffee models.Coffee) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.coffees[coffee.ID] = coffee
+	return f.flush()
+}
+
+// GetByID retrieves a coffee by ID
+func (f *FileStorage) GetByID(id string) (models.Coffee, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	coffee, ok := f.coffees[id]
+	if !ok {
+		return models.Coffee{}, errors.New("coffee not found")
+	}
+	return coffee, nil
+}
+
+// GetAll retrieves all coffees
+func (f *FileStorage) GetAll() ([]models.Coffee, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	var coffees []models.Coffee
+	for _, coffee := range f.coffees {
+		coffees = append(coffees, coffee)
+	}
+	return coffees, nil
+}
+
+// Find retrieves the coffees matching a query and how many match in total
+func (f *FileStorage) Find(query CoffeeQuery) ([]models.Coffee, int, error) {
+	coffees, _ := f.GetAll()
+	matches, total := findCoffees(coffees, query)
+	return matches, total, nil
+}
+
+// GetRecent retrieves the most recent coffees (sorted by creation date)
+func (f *FileStorage) GetRecent(limit int) ([]models.Coffee, error) {
+	coffees, _ := f.GetAll()
+	sort.Slice(coffees, func(i, j int) bool { return coffees[i].CreatedAt.After(coffees[j].CreatedAt) })
+	if limit > 0 && limit < len(coffees) {
+		coffees = coffees[:limit]
+	}
+	return coffees, nil
+}
+
+// Update modifies an existing coffee entry
+func (f *FileStorage) Update(id string, coffee models.Coffee) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	existing, ok := f.coffees[id]
+	if !ok {
+		return errors.New("coffee not found")
+	}
+	coffee.AddedBy = existing.AddedBy
+	f.coffees[id] = coffee
+	return f.flush()
+}
+
+// Delete removes a coffee entry
+func (f *FileStorage) Delete(id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.coffees[id]; !ok {
+		return errors.New("coffee not found")
+	}
+	delete(f.coffees, id)
+	return f.flush()
+}
+
+// SaveBrew stores a brew session
+func (f *FileStorage) SaveBrew(brew models.Brew) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.brews[brew.ID] = brew
+	return f.flush()
+}
+
+// GetBrewsByCoffee retrieves all brews of a coffee, oldest first
+func (f *FileStorage) GetBrewsByCoffee(coffeeID string) ([]models.Brew, error) {
+	brews, _ := f.GetAllBrews()
+	var matching []models.Brew
+	for _, brew := range brews {
+		if brew.CoffeeID == coffeeID {
+			matching = append(matching, brew)
+		}
+	}
+	return matching, nil
+}
+
+// GetAllBrews retrieves every brew, oldest first
+func (f *FileStorage) GetAllBrews() ([]models.Brew, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	var brews []models.Brew
+	for _, brew := range f.brews {
+		brews = append(brews, brew)
+	}
+	sort.Slice(brews, func(i, j int) bool { return brews[i].CreatedAt.Before(brews[j].CreatedAt) })
+	return brews, nil
+}
+
+// DeleteBrew removes a brew session
+func (f *FileStorage) DeleteBrew(id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.brews[id]; !ok {
+		return errors.New("brew not found")
+	}
+	delete(f.brews, id)
+	return f.flush()
+}
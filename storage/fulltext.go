@@ -0,0 +1,293 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Global search result types
+const (
+	SearchTypeCoffee  = "coffee"
+	SearchTypePokemon = "pokemon" // a coffee's catch, found by Pokemon name, nickname or LLM description
+	SearchTypeBrewer  = "brewer"
+)
+
+// maxSearchTerms bounds how many words of a query are searched for
+const maxSearchTerms = 10
+
+// SearchHit is one result of a global search
+type SearchHit struct {
+	Type          string            `json:"type"`
+	ID            string            `json:"id"`
+	CoffeeID      string            `json:"coffee_id,omitempty"`
+	Title         string            `json:"title"`
+	Score         float64           `json:"score"`
+	MatchedFields []string          `json:"matched_fields"`
+	Snippet       string            `json:"snippet,omitempty"`
+	Fields        map[string]string `json:"-"` // searchable text by field name
+}
+
+// FullTextSearcher searches coffees, catches and brewers with a database
+// full-text index
+type FullTextSearcher interface {
+	FullTextSearch(query string, limit int) ([]SearchHit, error)
+}
+
+// SearchTerms splits a query into lowercase words, ignoring punctuation
+func SearchTerms(query string) []string {
+	terms := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(terms) > maxSearchTerms {
+		terms = terms[:maxSearchTerms]
+	}
+	return terms
+}
+
+// MySQLFullTextSearcher implements FullTextSearcher with InnoDB FULLTEXT
+// indexes, which it adds to the existing tables on startup
+type MySQLFullTextSearcher struct {
+	db     *sql.DB
+	tables map[string]bool
+}
+
+// NewMySQLFullTextSearcher creates a MySQL full-text searcher. Create it
+// after the coffee, Pokemon and brewer storages so their tables exist.
+func NewMySQLFullTextSearcher(db *sql.DB) *MySQLFullTextSearcher {
+	searcher := &MySQLFullTextSearcher{db: db, tables: make(map[string]bool)}
+
+	if err := searcher.initIndexes(); err != nil {
+		panic(fmt.Sprintf("failed to initialize full-text indexes: %v", err))
+	}
+
+	return searcher
+}
+
+// initIndexes adds the full-text indexes to whichever searchable tables exist
+func (m *MySQLFullTextSearcher) initIndexes() error {
+	rows, err := m.db.Query("SELECT table_name FROM information_schema.tables WHERE table_schema = DATABASE()")
+	if err != nil {
+		return fmt.Errorf("failed to list tables: %w", err)
+	}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan table name: %w", err)
+		}
+		m.tables[name] = true
+	}
+	rows.Close()
+
+	if m.tables["coffees"] {
+		// Tasting notes are a JSON array, which FULLTEXT cannot index directly
+		if err := ensureColumn(m.db, "coffees", "tasting_notes_text", "TEXT GENERATED ALWAYS AS (CAST(tasting_notes AS CHAR)) STORED"); err != nil {
+			return err
+		}
+		if err := ensureFullTextIndex(m.db, "coffees", "ft_coffees", "name, roaster, origin, variety, processing_method"); err != nil {
+			return err
+		}
+		if err := ensureFullTextIndex(m.db, "coffees", "ft_coffee_notes", "tasting_notes_text"); err != nil {
+			return err
+		}
+	}
+	if m.tables["coffee_pokemon"] && m.tables["pokemons"] {
+		if err := ensureFullTextIndex(m.db, "coffee_pokemon", "ft_catches", "nickname, llm_description"); err != nil {
+			return err
+		}
+		if err := ensureFullTextIndex(m.db, "pokemons", "ft_pokemon_names", "name"); err != nil {
+			return err
+		}
+	}
+	if m.tables["brewers"] {
+		if err := ensureFullTextIndex(m.db, "brewers", "ft_brewers", "name"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// booleanQuery turns a query into a MySQL boolean-mode expression in which
+// any word, or a word it is a prefix of, matches
+func booleanQuery(query string) string {
+	terms := SearchTerms(query)
+	for i, term := range terms {
+		terms[i] = term + "*"
+	}
+	return strings.Join(terms, " ")
+}
+
+// FullTextSearch returns the best-scoring coffees, catches and brewers.
+// Coffee names and details and Pokemon names weigh double.
+func (m *MySQLFullTextSearcher) FullTextSearch(query string, limit int) ([]SearchHit, error) {
+	against := booleanQuery(query)
+	if against == "" {
+		return []SearchHit{}, nil
+	}
+
+	var hits []SearchHit
+	if m.tables["coffees"] {
+		coffees, err := m.searchCoffees(against, limit)
+		if err != nil {
+			return nil, err
+		}
+		hits = append(hits, coffees...)
+	}
+	if m.tables["coffee_pokemon"] && m.tables["pokemons"] {
+		catches, err := m.searchCatches(against, limit)
+		if err != nil {
+			return nil, err
+		}
+		hits = append(hits, catches...)
+	}
+	if m.tables["brewers"] {
+		brewers, err := m.searchBrewers(against, limit)
+		if err != nil {
+			return nil, err
+		}
+		hits = append(hits, brewers...)
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+// searchCoffees matches coffee details and tasting notes
+func (m *MySQLFullTextSearcher) searchCoffees(against string, limit int) ([]SearchHit, error) {
+	rows, err := m.db.Query(`
+		SELECT id, name, roaster, origin, variety, processing_method, tasting_notes,
+		       MATCH(name, roaster, origin, variety, processing_method) AGAINST (? IN BOOLEAN MODE) * 2
+		       + MATCH(tasting_notes_text) AGAINST (? IN BOOLEAN MODE) AS score
+		FROM coffees
+		WHERE MATCH(name, roaster, origin, variety, processing_method) AGAINST (? IN BOOLEAN MODE)
+		   OR MATCH(tasting_notes_text) AGAINST (? IN BOOLEAN MODE)
+		ORDER BY score DESC
+		LIMIT ?
+	`, against, against, against, against, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search coffees: %w", err)
+	}
+	defer rows.Close()
+
+	var hits []SearchHit
+	for rows.Next() {
+		var hit SearchHit
+		var name, roaster, origin, variety, processing sql.NullString
+		var notesJSON []byte
+		if err := rows.Scan(&hit.ID, &name, &roaster, &origin, &variety, &processing, &notesJSON, &hit.Score); err != nil {
+			return nil, fmt.Errorf("failed to scan coffee search result: %w", err)
+		}
+		var notes []string
+		json.Unmarshal(notesJSON, &notes)
+
+		hit.Type = SearchTypeCoffee
+		hit.CoffeeID = hit.ID
+		hit.Title = name.String
+		hit.Fields = map[string]string{
+			"name":              name.String,
+			"roaster":           roaster.String,
+			"origin":            origin.String,
+			"variety":           variety.String,
+			"processing_method": processing.String,
+			"tasting_notes":     joinNonEmpty(notes, ", "),
+		}
+		hits = append(hits, hit)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating coffee search results: %w", err)
+	}
+	return hits, nil
+}
+
+// searchCatches matches Pokemon names, nicknames and LLM descriptions
+func (m *MySQLFullTextSearcher) searchCatches(against string, limit int) ([]SearchHit, error) {
+	rows, err := m.db.Query(`
+		SELECT cp.coffee_id, p.name, cp.nickname, cp.llm_description,
+		       MATCH(p.name) AGAINST (? IN BOOLEAN MODE) * 2
+		       + MATCH(cp.nickname, cp.llm_description) AGAINST (? IN BOOLEAN MODE) AS score
+		FROM coffee_pokemon cp
+		JOIN pokemons p ON p.id = cp.pokemon_id
+		WHERE MATCH(p.name) AGAINST (? IN BOOLEAN MODE)
+		   OR MATCH(cp.nickname, cp.llm_description) AGAINST (? IN BOOLEAN MODE)
+		ORDER BY score DESC
+		LIMIT ?
+	`, against, against, against, against, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search catches: %w", err)
+	}
+	defer rows.Close()
+
+	var hits []SearchHit
+	for rows.Next() {
+		var hit SearchHit
+		var pokemonName string
+		var nickname, description sql.NullString
+		if err := rows.Scan(&hit.CoffeeID, &pokemonName, &nickname, &description, &hit.Score); err != nil {
+			return nil, fmt.Errorf("failed to scan catch search result: %w", err)
+		}
+		hit.Type = SearchTypePokemon
+		hit.ID = hit.CoffeeID
+		hit.Title = pokemonName
+		if nickname.String != "" {
+			hit.Title = nickname.String + " (" + pokemonName + ")"
+		}
+		hit.Fields = map[string]string{
+			"pokemon_name":    pokemonName,
+			"nickname":        nickname.String,
+			"llm_description": description.String,
+		}
+		hits = append(hits, hit)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating catch search results: %w", err)
+	}
+	return hits, nil
+}
+
+// searchBrewers matches brewer names
+func (m *MySQLFullTextSearcher) searchBrewers(against string, limit int) ([]SearchHit, error) {
+	rows, err := m.db.Query(`
+		SELECT id, name, MATCH(name) AGAINST (? IN BOOLEAN MODE) AS score
+		FROM brewers
+		WHERE MATCH(name) AGAINST (? IN BOOLEAN MODE)
+		ORDER BY score DESC
+		LIMIT ?
+	`, against, against, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search brewers: %w", err)
+	}
+	defer rows.Close()
+
+	var hits []SearchHit
+	for rows.Next() {
+		var hit SearchHit
+		if err := rows.Scan(&hit.ID, &hit.Title, &hit.Score); err != nil {
+			return nil, fmt.Errorf("failed to scan brewer search result: %w", err)
+		}
+		hit.Type = SearchTypeBrewer
+		hit.Fields = map[string]string{"name": hit.Title}
+		hits = append(hits, hit)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating brewer search results: %w", err)
+	}
+	return hits, nil
+}
+
+// joinNonEmpty joins the non-blank values
+func joinNonEmpty(values []string, sep string) string {
+	var kept []string
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			kept = append(kept, strings.TrimSpace(value))
+		}
+	}
+	return strings.Join(kept, sep)
+}
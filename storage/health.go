@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+// pingTimeout bounds a single health ping
+const pingTimeout = 2 * time.Second
+
+// DBMonitor tracks whether the database is reachable by pinging it. The
+// connection pool drops broken connections and dials fresh ones by itself
+// once MySQL is back; the monitor makes both transitions visible.
+type DBMonitor struct {
+	dbs      []*sql.DB
+	healthy  atomic.Bool
+	onChange func(healthy bool) // optional
+}
+
+// NewDBMonitor creates a monitor for the given pools, assumed healthy
+func NewDBMonitor(onChange func(healthy bool), dbs ...*sql.DB) *DBMonitor {
+	m := &DBMonitor{dbs: dbs, onChange: onChange}
+	m.healthy.Store(true)
+	return m
+}
+
+// Healthy reports the result of the last check
+func (m *DBMonitor) Healthy() bool {
+	return m.healthy.Load()
+}
+
+// Check pings every pool and records the outcome
+func (m *DBMonitor) Check(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+
+	var err error
+	for _, db := range m.dbs {
+		if err = db.PingContext(ctx); err != nil {
+			break
+		}
+	}
+
+	healthy := err == nil
+	if m.healthy.Swap(healthy) != healthy {
+		if healthy {
+			log.Printf("INFO: Database is reachable again")
+		} else {
+			log.Printf("ERROR: Database is unreachable: %v", err)
+		}
+		if m.onChange != nil {
+			m.onChange(healthy)
+		}
+	}
+	return err
+}
+
+// Run checks the database every interval until ctx is cancelled
+func (m *DBMonitor) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		m.Check(ctx)
+	}
+}
@@ -0,0 +1,328 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"go-coffee-log/models"
+	"log"
+	"time"
+)
+
+// Integrity check names
+const (
+	CheckOrphanedPokemon   = "orphaned_coffee_pokemon"
+	CheckInvalidJSON       = "invalid_json"
+	CheckTraitRange        = "trait_out_of_range"
+	CheckDanglingBrewerRef = "dangling_brewer_reference"
+)
+
+// IntegrityIssue is one problem found in the stored data
+type IntegrityIssue struct {
+	Check    string `json:"check"`
+	Table    string `json:"table"`
+	ID       string `json:"id"`
+	Detail   string `json:"detail"`
+	Repaired bool   `json:"repaired"`
+}
+
+// IntegrityReport is the outcome of an integrity check
+type IntegrityReport struct {
+	CheckedAt time.Time        `json:"checked_at"`
+	Repair    bool             `json:"repair"`
+	Issues    []IntegrityIssue `json:"issues"`
+	Repaired  int              `json:"repaired"`
+}
+
+// IntegrityChecker finds, and optionally repairs, inconsistent stored data
+type IntegrityChecker interface {
+	CheckIntegrity(repair bool) (IntegrityReport, error)
+}
+
+// MySQLIntegrityChecker implements IntegrityChecker with direct SQL, so it
+// can see rows the regular storage code would fail to load
+type MySQLIntegrityChecker struct {
+	db *sql.DB
+}
+
+// NewMySQLIntegrityChecker creates a new MySQL integrity checker
+func NewMySQLIntegrityChecker(db *sql.DB) *MySQLIntegrityChecker {
+	return &MySQLIntegrityChecker{db: db}
+}
+
+// CheckIntegrity runs every check. With repair set, orphaned and dangling
+// rows are deleted, unreadable JSON columns are reset to empty values and
+// trait values are clamped into range.
+func (m *MySQLIntegrityChecker) CheckIntegrity(repair bool) (IntegrityReport, error) {
+	report := IntegrityReport{CheckedAt: time.Now(), Repair: repair, Issues: []IntegrityIssue{}}
+
+	// Optional features create their tables lazily, so only check what exists
+	existing, err := m.existingTables()
+	if err != nil {
+		return report, err
+	}
+
+	checks := []struct {
+		table string
+		run   func(repair bool) ([]IntegrityIssue, error)
+	}{
+		{"coffee_pokemon", m.checkOrphanedPokemon},
+		{"coffees", m.checkCoffees},
+		{"brewer_recipes", m.checkBrewerReferences},
+	}
+	for _, check := range checks {
+		if !existing[check.table] {
+			continue
+		}
+		issues, err := check.run(repair)
+		if err != nil {
+			return report, err
+		}
+		report.Issues = append(report.Issues, issues...)
+	}
+
+	for _, issue := range report.Issues {
+		if issue.Repaired {
+			report.Repaired++
+		}
+	}
+	if repair && report.Repaired > 0 {
+		log.Printf("INFO: Integrity repair fixed %d of %d issues", report.Repaired, len(report.Issues))
+	}
+	return report, nil
+}
+
+// existingTables lists the tables of the current database
+func (m *MySQLIntegrityChecker) existingTables() (map[string]bool, error) {
+	rows, err := m.db.Query("SELECT table_name FROM information_schema.tables WHERE table_schema = DATABASE()")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+	defer rows.Close()
+
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan table name: %w", err)
+		}
+		existing[name] = true
+	}
+	return existing, rows.Err()
+}
+
+// checkOrphanedPokemon finds Pokemon mappings whose coffee no longer exists
+func (m *MySQLIntegrityChecker) checkOrphanedPokemon(repair bool) ([]IntegrityIssue, error) {
+	rows, err := m.db.Query(`
+		SELECT cp.id, cp.coffee_id, cp.pokemon_id
+		FROM coffee_pokemon cp
+		LEFT JOIN coffees c ON c.id = cp.coffee_id
+		WHERE c.id IS NULL
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query orphaned Pokemon mappings: %w", err)
+	}
+
+	var issues []IntegrityIssue
+	for rows.Next() {
+		var id, coffeeID string
+		var pokemonID int
+		if err := rows.Scan(&id, &coffeeID, &pokemonID); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan Pokemon mapping: %w", err)
+		}
+		issues = append(issues, IntegrityIssue{
+			Check:  CheckOrphanedPokemon,
+			Table:  "coffee_pokemon",
+			ID:     id,
+			Detail: fmt.Sprintf("Pokemon #%d is mapped to missing coffee %s", pokemonID, coffeeID),
+		})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating Pokemon mappings: %w", err)
+	}
+
+	if repair {
+		for i := range issues {
+			if _, err := m.db.Exec("DELETE FROM coffee_pokemon WHERE id = ?", issues[i].ID); err != nil {
+				return nil, fmt.Errorf("failed to delete Pokemon mapping %s: %w", issues[i].ID, err)
+			}
+			issues[i].Repaired = true
+		}
+	}
+	return issues, nil
+}
+
+// emptyCoffeeJSON holds the value each JSON column is reset to on repair
+var emptyCoffeeJSON = map[string]string{
+	"tasting_notes":  `["","","","",""]`,
+	"tasting_traits": `{}`,
+	"recipe":         `[]`,
+}
+
+// checkCoffees finds coffees whose JSON columns cannot be decoded and
+// coffees with tasting traits outside 0-10
+func (m *MySQLIntegrityChecker) checkCoffees(repair bool) ([]IntegrityIssue, error) {
+	rows, err := m.db.Query("SELECT id, tasting_notes, tasting_traits, recipe FROM coffees")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query coffees: %w", err)
+	}
+
+	type brokenColumn struct {
+		issue  int
+		column string
+	}
+	type outOfRange struct {
+		issue  int
+		traits models.TastingTraits
+	}
+	var issues []IntegrityIssue
+	var broken []brokenColumn
+	var clamp []outOfRange
+
+	for rows.Next() {
+		var id string
+		var notesJSON, traitsJSON, recipeJSON []byte
+		if err := rows.Scan(&id, &notesJSON, &traitsJSON, &recipeJSON); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan coffee: %w", err)
+		}
+
+		var notes [5]string
+		var traits models.TastingTraits
+		var recipe []string
+		columns := []struct {
+			name   string
+			data   []byte
+			target interface{}
+		}{
+			{"tasting_notes", notesJSON, &notes},
+			{"tasting_traits", traitsJSON, &traits},
+			{"recipe", recipeJSON, &recipe},
+		}
+		traitsOK := true
+		for _, column := range columns {
+			if err := json.Unmarshal(column.data, column.target); err != nil {
+				broken = append(broken, brokenColumn{issue: len(issues), column: column.name})
+				issues = append(issues, IntegrityIssue{
+					Check:  CheckInvalidJSON,
+					Table:  "coffees",
+					ID:     id,
+					Detail: fmt.Sprintf("%s cannot be decoded: %v", column.name, err),
+				})
+				if column.name == "tasting_traits" {
+					traitsOK = false
+				}
+			}
+		}
+
+		if traitsOK {
+			if err := traits.Validate(); err != nil {
+				clamp = append(clamp, outOfRange{issue: len(issues), traits: traits})
+				issues = append(issues, IntegrityIssue{
+					Check:  CheckTraitRange,
+					Table:  "coffees",
+					ID:     id,
+					Detail: err.Error(),
+				})
+			}
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating coffees: %w", err)
+	}
+
+	if !repair {
+		return issues, nil
+	}
+
+	for _, b := range broken {
+		id := issues[b.issue].ID
+		query := fmt.Sprintf("UPDATE coffees SET %s = ? WHERE id = ?", b.column)
+		if _, err := m.db.Exec(query, emptyCoffeeJSON[b.column], id); err != nil {
+			return nil, fmt.Errorf("failed to reset %s of coffee %s: %w", b.column, id, err)
+		}
+		issues[b.issue].Repaired = true
+	}
+	for _, c := range clamp {
+		id := issues[c.issue].ID
+		traitsJSON, err := json.Marshal(clampTraits(c.traits))
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal tasting traits: %w", err)
+		}
+		if _, err := m.db.Exec("UPDATE coffees SET tasting_traits = ? WHERE id = ?", traitsJSON, id); err != nil {
+			return nil, fmt.Errorf("failed to clamp tasting traits of coffee %s: %w", id, err)
+		}
+		issues[c.issue].Repaired = true
+	}
+	return issues, nil
+}
+
+// clampTraits forces every trait into the valid 0-10 range
+func clampTraits(t models.TastingTraits) models.TastingTraits {
+	for _, value := range []*int{
+		&t.BerryIntensity, &t.StonefruitIntensity, &t.RoastIntensity,
+		&t.CitrusFruitsIntensity, &t.Bitterness, &t.Florality, &t.Spice,
+		&t.Sweetness, &t.AromaticIntensity, &t.Savory, &t.Body, &t.Cleanliness,
+	} {
+		if *value < 0 {
+			*value = 0
+		} else if *value > 10 {
+			*value = 10
+		}
+	}
+	return t
+}
+
+// checkBrewerReferences finds legacy brewer_recipes rows pointing at a
+// brewer or coffee that no longer exists
+func (m *MySQLIntegrityChecker) checkBrewerReferences(repair bool) ([]IntegrityIssue, error) {
+	rows, err := m.db.Query(`
+		SELECT br.id, br.brewer_id, br.coffee_id, b.id IS NULL, c.id IS NULL
+		FROM brewer_recipes br
+		LEFT JOIN brewers b ON b.id = br.brewer_id
+		LEFT JOIN coffees c ON c.id = br.coffee_id
+		WHERE b.id IS NULL OR c.id IS NULL
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query brewer references: %w", err)
+	}
+
+	var issues []IntegrityIssue
+	for rows.Next() {
+		var id, brewerID, coffeeID string
+		var missingBrewer, missingCoffee bool
+		if err := rows.Scan(&id, &brewerID, &coffeeID, &missingBrewer, &missingCoffee); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan brewer reference: %w", err)
+		}
+		detail := fmt.Sprintf("references missing brewer %s", brewerID)
+		if !missingBrewer {
+			detail = fmt.Sprintf("references missing coffee %s", coffeeID)
+		} else if missingCoffee {
+			detail += fmt.Sprintf(" and missing coffee %s", coffeeID)
+		}
+		issues = append(issues, IntegrityIssue{
+			Check:  CheckDanglingBrewerRef,
+			Table:  "brewer_recipes",
+			ID:     id,
+			Detail: detail,
+		})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating brewer references: %w", err)
+	}
+
+	if repair {
+		for i := range issues {
+			if _, err := m.db.Exec("DELETE FROM brewer_recipes WHERE id = ?", issues[i].ID); err != nil {
+				return nil, fmt.Errorf("failed to delete brewer reference %s: %w", issues[i].ID, err)
+			}
+			issues[i].Repaired = true
+		}
+	}
+	return issues, nil
+}
@@ -0,0 +1,151 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// MediaBlob describes one stored blob
+type MediaBlob struct {
+	Hash    string    `json:"hash"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// MediaStorage stores uploaded media by the SHA-256 of its content, so the
+// same photo uploaded twice is stored once
+type MediaStorage interface {
+	// Put stores data unless a blob with the same hash exists, and returns the hash
+	Put(data []byte) (string, error)
+	// Open returns a blob and when it was stored; the error contains
+	// "not found" for unknown hashes
+	Open(hash string) (io.ReadCloser, time.Time, error)
+	Delete(hash string) error
+	List() ([]MediaBlob, error)
+}
+
+// ContentHash returns the hex SHA-256 media blobs are addressed by
+func ContentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// validMediaHash reports whether hash is a hex SHA-256, which also keeps
+// hashes from escaping the storage directory or bucket prefix
+func validMediaHash(hash string) bool {
+	if len(hash) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(hash)
+	return err == nil
+}
+
+// FileMediaStorage implements MediaStorage in a directory, sharded by the
+// first two hash characters: dir/ab/abcdef...
+type FileMediaStorage struct {
+	dir string
+}
+
+// NewFileMediaStorage creates a media store in dir
+func NewFileMediaStorage(dir string) (*FileMediaStorage, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create media directory: %w", err)
+	}
+	return &FileMediaStorage{dir: dir}, nil
+}
+
+// path returns where a blob lives
+func (f *FileMediaStorage) path(hash string) string {
+	return filepath.Join(f.dir, hash[:2], hash)
+}
+
+// Put writes data under its hash unless it is already stored
+func (f *FileMediaStorage) Put(data []byte) (string, error) {
+	hash := ContentHash(data)
+	path := f.path(hash)
+	if _, err := os.Stat(path); err == nil {
+		return hash, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create media directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), hash+".tmp*")
+	if err != nil {
+		return "", fmt.Errorf("failed to store media: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("failed to store media: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("failed to store media: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("failed to store media: %w", err)
+	}
+	return hash, nil
+}
+
+// Open opens a stored blob
+func (f *FileMediaStorage) Open(hash string) (io.ReadCloser, time.Time, error) {
+	if !validMediaHash(hash) {
+		return nil, time.Time{}, fmt.Errorf("media %q not found", hash)
+	}
+	file, err := os.Open(f.path(hash))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, time.Time{}, fmt.Errorf("media %q not found", hash)
+	}
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to open media: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, time.Time{}, fmt.Errorf("failed to open media: %w", err)
+	}
+	return file, info.ModTime(), nil
+}
+
+// Delete removes a blob; deleting a missing blob is not an error
+func (f *FileMediaStorage) Delete(hash string) error {
+	if !validMediaHash(hash) {
+		return fmt.Errorf("media %q not found", hash)
+	}
+	if err := os.Remove(f.path(hash)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete media: %w", err)
+	}
+	return nil
+}
+
+// List returns every stored blob
+func (f *FileMediaStorage) List() ([]MediaBlob, error) {
+	var blobs []MediaBlob
+	err := filepath.WalkDir(f.dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || !validMediaHash(entry.Name()) {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		blobs = append(blobs, MediaBlob{Hash: entry.Name(), Size: info.Size(), ModTime: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list media: %w", err)
+	}
+	return blobs, nil
+}
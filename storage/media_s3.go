@@ -0,0 +1,295 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// emptyPayloadHash is the SHA-256 of an empty request body
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// S3Config locates a bucket on S3 or an S3-compatible service
+type S3Config struct {
+	Endpoint        string // e.g. "https://s3.eu-west-1.amazonaws.com" or a MinIO URL
+	Region          string
+	Bucket          string
+	Prefix          string // key prefix, e.g. "media/"
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// S3MediaStorage implements MediaStorage in an S3 bucket using path-style
+// requests signed with AWS Signature Version 4
+type S3MediaStorage struct {
+	config S3Config
+	client *http.Client
+}
+
+// NewS3MediaStorage creates a media store in an S3 bucket
+func NewS3MediaStorage(config S3Config) (*S3MediaStorage, error) {
+	if config.Bucket == "" {
+		return nil, fmt.Errorf("S3 bucket is required")
+	}
+	if config.AccessKeyID == "" || config.SecretAccessKey == "" {
+		return nil, fmt.Errorf("S3 credentials are required")
+	}
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+	if config.Endpoint == "" {
+		config.Endpoint = "https://s3." + config.Region + ".amazonaws.com"
+	}
+	config.Endpoint = strings.TrimSuffix(config.Endpoint, "/")
+	if config.Prefix != "" && !strings.HasSuffix(config.Prefix, "/") {
+		config.Prefix += "/"
+	}
+
+	return &S3MediaStorage{
+		config: config,
+		client: &http.Client{Timeout: 60 * time.Second},
+	}, nil
+}
+
+// key returns the object key of a blob
+func (s *S3MediaStorage) key(hash string) string {
+	return s.config.Prefix + hash
+}
+
+// Put uploads data under its hash unless the object already exists
+func (s *S3MediaStorage) Put(data []byte) (string, error) {
+	hash := ContentHash(data)
+
+	resp, err := s.do(http.MethodHead, s.key(hash), nil, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to store media: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return hash, nil
+	}
+
+	resp, err = s.do(http.MethodPut, s.key(hash), nil, data)
+	if err != nil {
+		return "", fmt.Errorf("failed to store media: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to store media: %s", s3Error(resp))
+	}
+	return hash, nil
+}
+
+// Open downloads a blob
+func (s *S3MediaStorage) Open(hash string) (io.ReadCloser, time.Time, error) {
+	if !validMediaHash(hash) {
+		return nil, time.Time{}, fmt.Errorf("media %q not found", hash)
+	}
+	resp, err := s.do(http.MethodGet, s.key(hash), nil, nil)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to open media: %w", err)
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return nil, time.Time{}, fmt.Errorf("media %q not found", hash)
+	default:
+		defer resp.Body.Close()
+		return nil, time.Time{}, fmt.Errorf("failed to open media: %s", s3Error(resp))
+	}
+
+	modTime, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	return resp.Body, modTime, nil
+}
+
+// Delete removes a blob; S3 treats deleting a missing object as success
+func (s *S3MediaStorage) Delete(hash string) error {
+	if !validMediaHash(hash) {
+		return fmt.Errorf("media %q not found", hash)
+	}
+	resp, err := s.do(http.MethodDelete, s.key(hash), nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete media: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to delete media: %s", s3Error(resp))
+	}
+	return nil
+}
+
+// s3ListResult is the subset of a ListObjectsV2 response the store uses
+type s3ListResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// List returns every blob under the prefix, following continuation tokens
+func (s *S3MediaStorage) List() ([]MediaBlob, error) {
+	var blobs []MediaBlob
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {s.config.Prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		resp, err := s.do(http.MethodGet, "", query, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list media: %w", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			err := fmt.Errorf("failed to list media: %s", s3Error(resp))
+			resp.Body.Close()
+			return nil, err
+		}
+
+		var result s3ListResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse media listing: %w", err)
+		}
+
+		for _, object := range result.Contents {
+			hash := strings.TrimPrefix(object.Key, s.config.Prefix)
+			if validMediaHash(hash) {
+				blobs = append(blobs, MediaBlob{Hash: hash, Size: object.Size, ModTime: object.LastModified})
+			}
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return blobs, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// do sends a signed request for an object key, or for the bucket when key is empty
+func (s *S3MediaStorage) do(method, key string, query url.Values, body []byte) (*http.Response, error) {
+	path := "/" + s.config.Bucket
+	if key != "" {
+		path += "/" + key
+	}
+	rawQuery := canonicalQuery(query)
+	target := s.config.Endpoint + path
+	if rawQuery != "" {
+		target += "?" + rawQuery
+	}
+
+	req, err := http.NewRequest(method, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(body))
+	s.sign(req, path, rawQuery, body, time.Now().UTC())
+	return s.client.Do(req)
+}
+
+// sign adds AWS Signature Version 4 headers to req
+func (s *S3MediaStorage) sign(req *http.Request, path, rawQuery string, body []byte, now time.Time) {
+	payloadHash := emptyPayloadHash
+	if len(body) > 0 {
+		sum := sha256.Sum256(body)
+		payloadHash = hex.EncodeToString(sum[:])
+	}
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		uriEncode(path, false),
+		rawQuery,
+		"host:" + req.URL.Host + "\n" +
+			"x-amz-content-sha256:" + payloadHash + "\n" +
+			"x-amz-date:" + amzDate + "\n",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.config.Region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.config.SecretAccessKey), date)
+	key = hmacSHA256(key, s.config.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.config.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// hmacSHA256 computes HMAC-SHA256(key, data)
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalQuery encodes query parameters sorted by name, as SigV4 requires
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		for _, v := range query[k] {
+			parts = append(parts, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode percent-encodes everything but unreserved characters; slashes
+// are kept in paths
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// s3Error summarizes an unsuccessful S3 response
+func s3Error(resp *http.Response) string {
+	var body struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	if err := xml.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&body); err == nil && body.Code != "" {
+		return fmt.Sprintf("%s: %s (%s)", resp.Status, body.Code, body.Message)
+	}
+	return resp.Status
+}
@@ -63,6 +63,16 @@ func (m *MemoryStorage) GetAll() ([]models.Coffee, error) {
 	return coffees, nil
 }
 
+// Find retrieves the coffees matching a query and how many match in total
+func (m *MemoryStorage) Find(query CoffeeQuery) ([]models.Coffee, int, error) {
+	coffees, err := m.GetAll()
+	if err != nil {
+		return nil, 0, err
+	}
+	matches, total := findCoffees(coffees, query)
+	return matches, total, nil
+}
+
 // GetRecent retrieves the most recent coffees (sorted by creation date)
 func (m *MemoryStorage) GetRecent(limit int) ([]models.Coffee, error) {
 	if m == nil {
@@ -100,12 +110,13 @@ func (m *MemoryStorage) Update(id string, coffee models.Coffee) error {
 		return errors.New("memory storage is not initialized")
 	}
 
-	if _, ok := m.coffees[id]; !ok {
-		return errors.New("coffee not found")
-	}
-
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	existing, ok := m.coffees[id]
+	if !ok {
+		return errors.New("coffee not found")
+	}
+	coffee.AddedBy = existing.AddedBy
 	m.coffees[id] = coffee
 	return nil
 }
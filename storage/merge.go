@@ -0,0 +1,219 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"go-coffee-log/models"
+	"log"
+)
+
+// CatchRef names one Pokemon catch: a coffee's catch in a dex profile
+type CatchRef struct {
+	CoffeeID string
+	Dex      string
+}
+
+// CoffeeMerger folds a duplicate coffee into the coffee it duplicates in
+// one step: everything attached to the duplicate moves to the kept coffee,
+// and the duplicate is deleted
+type CoffeeMerger interface {
+	// MergeCoffee moves the duplicate's data to keepID after dropping the
+	// given catches, which must leave at most one catch per dex profile
+	MergeCoffee(keepID, duplicateID string, dropCatches []CatchRef) error
+}
+
+// mergeTable is a table that refers to coffees by coffee_id
+type mergeTable struct {
+	name string
+	// unique tables allow a row once per coffee, e.g. a user's reaction;
+	// rows the kept coffee already has are dropped rather than moved
+	unique bool
+}
+
+// mergeTables lists the tables whose rows follow a merged coffee
+var mergeTables = []mergeTable{
+	{"brews", false},
+	{"comments", false},
+	{"reactions", true},
+	{"coffee_pokemon", false},
+	{"coffee_photos", true},
+	{"collection_coffees", true},
+	{"planned_brews", false},
+	{"scoresheets", false},
+	{"subscription_eggs", false},
+	{"safari_attempts", false},
+	{"mapping_disagreements", false},
+}
+
+// MySQLCoffeeMerger implements CoffeeMerger with a single MySQL transaction
+type MySQLCoffeeMerger struct {
+	db *sql.DB
+}
+
+// NewMySQLCoffeeMerger creates a new MySQL coffee merger
+func NewMySQLCoffeeMerger(db *sql.DB) *MySQLCoffeeMerger {
+	return &MySQLCoffeeMerger{db: db}
+}
+
+// MergeCoffee moves every row of the duplicate, or nothing if any step fails
+func (m *MySQLCoffeeMerger) MergeCoffee(keepID, duplicateID string, dropCatches []CatchRef) error {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Optional features create their tables lazily, so only merge what exists
+	existing := make(map[string]bool)
+	rows, err := tx.Query("SELECT table_name FROM information_schema.tables WHERE table_schema = DATABASE()")
+	if err != nil {
+		return fmt.Errorf("failed to list tables: %w", err)
+	}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan table name: %w", err)
+		}
+		existing[name] = true
+	}
+	rows.Close()
+
+	for _, catch := range dropCatches {
+		if _, err := tx.Exec("DELETE FROM coffee_pokemon WHERE coffee_id = ? AND dex = ?", catch.CoffeeID, catch.Dex); err != nil {
+			return fmt.Errorf("failed to drop catch of %s in %s: %w", catch.CoffeeID, catch.Dex, err)
+		}
+	}
+
+	for _, table := range mergeTables {
+		if !existing[table.name] {
+			continue
+		}
+		if !table.unique {
+			if _, err := tx.Exec("UPDATE "+table.name+" SET coffee_id = ? WHERE coffee_id = ?", keepID, duplicateID); err != nil {
+				return fmt.Errorf("failed to merge %s: %w", table.name, err)
+			}
+			continue
+		}
+		if _, err := tx.Exec("UPDATE IGNORE "+table.name+" SET coffee_id = ? WHERE coffee_id = ?", keepID, duplicateID); err != nil {
+			return fmt.Errorf("failed to merge %s: %w", table.name, err)
+		}
+		if _, err := tx.Exec("DELETE FROM "+table.name+" WHERE coffee_id = ?", duplicateID); err != nil {
+			return fmt.Errorf("failed to drop merged %s: %w", table.name, err)
+		}
+	}
+
+	result, err := tx.Exec("DELETE FROM coffees WHERE id = ?", duplicateID)
+	if err != nil {
+		return fmt.Errorf("failed to delete merged coffee: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("coffee not found")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit merge: %w", err)
+	}
+
+	log.Printf("INFO: Merged coffee %s into %s", duplicateID, keepID)
+	return nil
+}
+
+// MemoryCoffeeMerger implements CoffeeMerger for the in-memory stores.
+// Pokemon catches only exist in MySQL, so there are none to drop.
+type MemoryCoffeeMerger struct {
+	coffees     *MemoryStorage
+	brews       *MemoryBrewStorage
+	comments    *MemoryCommentStorage
+	photos      *MemoryPhotoStorage
+	collections *MemoryCollectionStorage
+}
+
+// NewMemoryCoffeeMerger creates a new in-memory coffee merger
+func NewMemoryCoffeeMerger(
+	coffees *MemoryStorage,
+	brews *MemoryBrewStorage,
+	comments *MemoryCommentStorage,
+	photos *MemoryPhotoStorage,
+	collections *MemoryCollectionStorage,
+) *MemoryCoffeeMerger {
+	return &MemoryCoffeeMerger{
+		coffees:     coffees,
+		brews:       brews,
+		comments:    comments,
+		photos:      photos,
+		collections: collections,
+	}
+}
+
+// MergeCoffee moves the duplicate's data while holding all locks
+func (m *MemoryCoffeeMerger) MergeCoffee(keepID, duplicateID string, dropCatches []CatchRef) error {
+	m.coffees.mu.Lock()
+	defer m.coffees.mu.Unlock()
+	m.brews.mu.Lock()
+	defer m.brews.mu.Unlock()
+	m.comments.mu.Lock()
+	defer m.comments.mu.Unlock()
+	m.photos.mu.Lock()
+	defer m.photos.mu.Unlock()
+	m.collections.mu.Lock()
+	defer m.collections.mu.Unlock()
+
+	if _, ok := m.coffees.coffees[duplicateID]; !ok {
+		return fmt.Errorf("coffee not found")
+	}
+
+	for id, brew := range m.brews.brews {
+		if brew.CoffeeID == duplicateID {
+			brew.CoffeeID = keepID
+			m.brews.brews[id] = brew
+		}
+	}
+	for id, comment := range m.comments.comments {
+		if comment.CoffeeID == duplicateID {
+			comment.CoffeeID = keepID
+			m.comments.comments[id] = comment
+		}
+	}
+
+	kept := m.photos.photos[keepID]
+	for _, photo := range m.photos.photos[duplicateID] {
+		if !hasPhoto(kept, photo.Hash) {
+			photo.CoffeeID = keepID
+			kept = append(kept, photo)
+		}
+	}
+	if len(kept) > 0 {
+		m.photos.photos[keepID] = kept
+	}
+	delete(m.photos.photos, duplicateID)
+
+	for id, collection := range m.collections.collections {
+		coffeeIDs := make([]string, 0, len(collection.CoffeeIDs))
+		seen := make(map[string]bool, len(collection.CoffeeIDs))
+		for _, coffeeID := range collection.CoffeeIDs {
+			if coffeeID == duplicateID {
+				coffeeID = keepID
+			}
+			if !seen[coffeeID] {
+				seen[coffeeID] = true
+				coffeeIDs = append(coffeeIDs, coffeeID)
+			}
+		}
+		collection.CoffeeIDs = coffeeIDs
+		m.collections.collections[id] = collection
+	}
+
+	delete(m.coffees.coffees, duplicateID)
+	return nil
+}
+
+// hasPhoto reports whether photos include one with the given hash
+func hasPhoto(photos []models.CoffeePhoto, hash string) bool {
+	for _, photo := range photos {
+		if photo.Hash == hash {
+			return true
+		}
+	}
+	return false
+}
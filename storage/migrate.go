@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+)
+
+// ensureColumn adds a column to an existing table when it is missing, so
+// tables created by older versions pick up new fields on startup
+func ensureColumn(db *sql.DB, table, column, definition string) error {
+	var count int
+	err := db.QueryRow(`
+		SELECT COUNT(*) FROM information_schema.columns
+		WHERE table_schema = DATABASE() AND table_name = ? AND column_name = ?
+	`, table, column).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("failed to inspect %s.%s: %w", table, column, err)
+	}
+	if count > 0 {
+		return nil
+	}
+
+	log.Printf("INFO: Adding column %s.%s", table, column)
+	if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)); err != nil {
+		return fmt.Errorf("failed to add column %s.%s: %w", table, column, err)
+	}
+	return nil
+}
+
+// ensureFullTextIndex adds a FULLTEXT index over columns when the table has
+// no index of that name yet
+func ensureFullTextIndex(db *sql.DB, table, index, columns string) error {
+	var count int
+	err := db.QueryRow(`
+		SELECT COUNT(*) FROM information_schema.statistics
+		WHERE table_schema = DATABASE() AND table_name = ? AND index_name = ?
+	`, table, index).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("failed to inspect index %s.%s: %w", table, index, err)
+	}
+	if count > 0 {
+		return nil
+	}
+
+	log.Printf("INFO: Adding full-text index %s.%s", table, index)
+	if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD FULLTEXT INDEX %s (%s)", table, index, columns)); err != nil {
+		return fmt.Errorf("failed to add full-text index %s.%s: %w", table, index, err)
+	}
+	return nil
+}
+
+// ensureUniqueIndex adds a unique index over columns when the table has no
+// index of that name yet
+func ensureUniqueIndex(db *sql.DB, table, index, columns string) error {
+	exists, err := indexExists(db, table, index)
+	if err != nil || exists {
+		return err
+	}
+
+	log.Printf("INFO: Adding unique index %s.%s", table, index)
+	if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD UNIQUE INDEX %s (%s)", table, index, columns)); err != nil {
+		return fmt.Errorf("failed to add unique index %s.%s: %w", table, index, err)
+	}
+	return nil
+}
+
+// dropIndex removes an index that older versions created, if it is still
+// there
+func dropIndex(db *sql.DB, table, index string) error {
+	exists, err := indexExists(db, table, index)
+	if err != nil || !exists {
+		return err
+	}
+
+	log.Printf("INFO: Dropping index %s.%s", table, index)
+	if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s DROP INDEX %s", table, index)); err != nil {
+		return fmt.Errorf("failed to drop index %s.%s: %w", table, index, err)
+	}
+	return nil
+}
+
+// indexExists reports whether a table has an index of that name
+func indexExists(db *sql.DB, table, index string) (bool, error) {
+	var count int
+	err := db.QueryRow(`
+		SELECT COUNT(*) FROM information_schema.statistics
+		WHERE table_schema = DATABASE() AND table_name = ? AND index_name = ?
+	`, table, index).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to inspect index %s.%s: %w", table, index, err)
+	}
+	return count > 0, nil
+}
+
+// tableExists reports whether a table exists in the current database
+func tableExists(db *sql.DB, table string) (bool, error) {
+	var count int
+	err := db.QueryRow(`
+		SELECT COUNT(*) FROM information_schema.tables
+		WHERE table_schema = DATABASE() AND table_name = ?
+	`, table).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to inspect table %s: %w", table, err)
+	}
+	return count > 0, nil
+}
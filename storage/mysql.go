@@ -5,17 +5,23 @@ import (
 	"encoding/json"
 	"fmt"
 	"go-coffee-log/models"
+	"strings"
+	"time"
 
 	_ "github.com/go-sql-driver/mysql" // MySQL driver
 )
 
-// MySQLStorage implements CoffeeStorage using MySQL database
-type MySQLStorage struct {
-	db *sql.DB
+// PoolConfig sizes the MySQL connection pool every storage shares. Zero
+// values keep the database/sql defaults.
+type PoolConfig struct {
+	MaxOpenConns    int           // 0 = unlimited
+	MaxIdleConns    int           // 0 = database/sql default of 2
+	ConnMaxLifetime time.Duration // 0 = connections are reused forever
 }
 
-// NewMySQLStorage creates a new MySQL storage and initializes the database
-func NewMySQLStorage(host, user, password, dbname string) (*MySQLStorage, error) {
+// OpenMySQL opens the connection pool all MySQL storages share. The pool
+// connects lazily; callers ping it to wait for the server.
+func OpenMySQL(host, user, password, dbname string, pool PoolConfig) (*sql.DB, error) {
 	dsn := fmt.Sprintf("%s:%s@tcp(%s)/%s?parseTime=true", user, password, host, dbname)
 	
 	db, err := sql.Open("mysql", dsn)
@@ -23,20 +29,43 @@ func NewMySQLStorage(host, user, password, dbname string) (*MySQLStorage, error)
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 	
-	if err := db.Ping(); err != nil {
-		return nil, fmt.Errorf("failed to ping database: %w", err)
+	if pool.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(pool.MaxOpenConns)
+	}
+	if pool.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(pool.MaxIdleConns)
+	}
+	if pool.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(pool.ConnMaxLifetime)
 	}
 	
+	return db, nil
+}
+
+// MySQLStorage implements CoffeeStorage using MySQL database
+type MySQLStorage struct {
+	db *sql.DB
+}
+
+// NewMySQLStorage creates a new MySQL storage on a shared connection pool
+// and initializes the database
+func NewMySQLStorage(db *sql.DB) (*MySQLStorage, error) {
 	storage := &MySQLStorage{db: db}
 	
 	if err := storage.initTable(); err != nil {
-		db.Close()
 		return nil, fmt.Errorf("failed to initialize table: %w", err)
 	}
 	
 	return storage, nil
 }
 
+// NewMySQLReadStorage creates a coffee storage on a read replica's pool.
+// It leaves the tables alone, as the replica gets them from the primary;
+// use it for reads only.
+func NewMySQLReadStorage(db *sql.DB) *MySQLStorage {
+	return &MySQLStorage{db: db}
+}
+
 // initTable creates the coffees table if it doesn't exist
 func (m *MySQLStorage) initTable() error {
 	query := `
@@ -55,6 +84,8 @@ func (m *MySQLStorage) initTable() error {
 			dripper VARCHAR(100),
 			end_time_minutes INT,
 			end_time_seconds INT,
+			roast_date DATE NULL,
+			favorite BOOLEAN NOT NULL DEFAULT FALSE,
 			created_at DATETIME,
 			updated_at DATETIME
 		)
@@ -65,11 +96,56 @@ func (m *MySQLStorage) initTable() error {
 		return fmt.Errorf("failed to create table: %w", err)
 	}
 	
+	// Columns added after the table was first introduced
+	if err := ensureColumn(m.db, "coffees", "roast_date", "DATE NULL"); err != nil {
+		return err
+	}
+	if err := ensureColumn(m.db, "coffees", "favorite", "BOOLEAN NOT NULL DEFAULT FALSE"); err != nil {
+		return err
+	}
+	if err := ensureColumn(m.db, "coffees", "brewer_id", "VARCHAR(36) NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := ensureColumn(m.db, "coffees", "altitude", "VARCHAR(100) NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := ensureColumn(m.db, "coffees", "lot", "VARCHAR(255) NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := ensureColumn(m.db, "coffees", "price_amount", "DECIMAL(12,2) NULL"); err != nil {
+		return err
+	}
+	if err := ensureColumn(m.db, "coffees", "price_currency", "CHAR(3) NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := ensureColumn(m.db, "coffees", "bag_grams", "INT NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := ensureColumn(m.db, "coffees", "lineage", "VARCHAR(36) NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := ensureColumn(m.db, "coffees", "added_by", "VARCHAR(36) NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := ensureColumn(m.db, "coffees", "tags", "JSON NULL"); err != nil {
+		return err
+	}
+	
 	return nil
 }
 
+// sqlExecer runs statements on the pool or inside a transaction
+type sqlExecer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
 // Save stores a coffee entry in the database
 func (m *MySQLStorage) Save(coffee models.Coffee) error {
+	return saveCoffee(m.db, coffee)
+}
+
+// saveCoffee inserts a coffee row
+func saveCoffee(db sqlExecer, coffee models.Coffee) error {
 	tastingNotesJSON, err := json.Marshal(coffee.TastingNotes)
 	if err != nil {
 		return fmt.Errorf("failed to marshal tasting notes: %w", err)
@@ -85,21 +161,29 @@ func (m *MySQLStorage) Save(coffee models.Coffee) error {
 		return fmt.Errorf("failed to marshal recipe: %w", err)
 	}
 	
+	tagsJSON, err := marshalTags(coffee.Tags)
+	if err != nil {
+		return err
+	}
+	
+	priceAmount, priceCurrency := nullablePrice(coffee.Price)
+	
 	query := `
 		INSERT INTO coffees (
 			id, name, origin, roaster, variety, roast_level, processing_method,
 			tasting_notes, tasting_traits, rating, recipe, dripper,
-			end_time_minutes, end_time_seconds, created_at, updated_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			end_time_minutes, end_time_seconds, roast_date, favorite, brewer_id, altitude, lot, price_amount, price_currency, bag_grams, lineage, added_by, tags, created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 	
-	_, err = m.db.Exec(
+	_, err = db.Exec(
 		query,
 		coffee.ID, coffee.Name, coffee.Origin, coffee.Roaster, coffee.Variety,
 		coffee.RoastLevel, coffee.ProcessingMethod,
 		tastingNotesJSON, tastingTraitsJSON, coffee.Rating, recipeJSON, coffee.Dripper,
 		coffee.EndTime.Minutes, coffee.EndTime.Seconds,
-		coffee.CreatedAt, coffee.UpdatedAt,
+		nullableDate(coffee.RoastDate), coffee.Favorite, coffee.BrewerID, coffee.Altitude, coffee.Lot,
+		priceAmount, priceCurrency, coffee.BagGrams, coffee.Lineage, coffee.AddedBy, tagsJSON, coffee.CreatedAt, coffee.UpdatedAt,
 	)
 	
 	if err != nil {
@@ -114,21 +198,25 @@ func (m *MySQLStorage) GetByID(id string) (models.Coffee, error) {
 	query := `
 		SELECT id, name, origin, roaster, variety, roast_level, processing_method,
 		       tasting_notes, tasting_traits, rating, recipe, dripper,
-		       end_time_minutes, end_time_seconds, created_at, updated_at
+		       end_time_minutes, end_time_seconds, roast_date, favorite, brewer_id, altitude, lot, price_amount, price_currency, bag_grams, lineage, added_by, tags, created_at, updated_at
 		FROM coffees WHERE id = ?
 	`
 	
 	row := m.db.QueryRow(query, id)
 	
 	var coffee models.Coffee
-	var tastingNotesJSON, tastingTraitsJSON, recipeJSON []byte
+	var tastingNotesJSON, tastingTraitsJSON, recipeJSON, tagsJSON []byte
+	var roastDate sql.NullTime
+	var priceAmount sql.NullFloat64
+	var priceCurrency string
 	
 	err := row.Scan(
 		&coffee.ID, &coffee.Name, &coffee.Origin, &coffee.Roaster, &coffee.Variety,
 		&coffee.RoastLevel, &coffee.ProcessingMethod,
 		&tastingNotesJSON, &tastingTraitsJSON, &coffee.Rating, &recipeJSON, &coffee.Dripper,
 		&coffee.EndTime.Minutes, &coffee.EndTime.Seconds,
-		&coffee.CreatedAt, &coffee.UpdatedAt,
+		&roastDate, &coffee.Favorite, &coffee.BrewerID, &coffee.Altitude, &coffee.Lot,
+		&priceAmount, &priceCurrency, &coffee.BagGrams, &coffee.Lineage, &coffee.AddedBy, &tagsJSON, &coffee.CreatedAt, &coffee.UpdatedAt,
 	)
 	
 	if err == sql.ErrNoRows {
@@ -150,6 +238,13 @@ func (m *MySQLStorage) GetByID(id string) (models.Coffee, error) {
 		return models.Coffee{}, fmt.Errorf("failed to unmarshal recipe: %w", err)
 	}
 	
+	if err := unmarshalTags(tagsJSON, &coffee.Tags); err != nil {
+		return models.Coffee{}, err
+	}
+	
+	coffee.RoastDate = scanDate(roastDate)
+	coffee.Price = scanPrice(priceAmount, priceCurrency)
+	
 	return coffee, nil
 }
 
@@ -158,7 +253,7 @@ func (m *MySQLStorage) GetAll() ([]models.Coffee, error) {
 	query := `
 		SELECT id, name, origin, roaster, variety, roast_level, processing_method,
 		       tasting_notes, tasting_traits, rating, recipe, dripper,
-		       end_time_minutes, end_time_seconds, created_at, updated_at
+		       end_time_minutes, end_time_seconds, roast_date, favorite, brewer_id, altitude, lot, price_amount, price_currency, bag_grams, lineage, added_by, tags, created_at, updated_at
 		FROM coffees
 	`
 	
@@ -168,44 +263,7 @@ func (m *MySQLStorage) GetAll() ([]models.Coffee, error) {
 	}
 	defer rows.Close()
 	
-	var coffees []models.Coffee
-	
-	for rows.Next() {
-		var coffee models.Coffee
-		var tastingNotesJSON, tastingTraitsJSON, recipeJSON []byte
-		
-		err := rows.Scan(
-			&coffee.ID, &coffee.Name, &coffee.Origin, &coffee.Roaster, &coffee.Variety,
-			&coffee.RoastLevel, &coffee.ProcessingMethod,
-			&tastingNotesJSON, &tastingTraitsJSON, &coffee.Rating, &recipeJSON, &coffee.Dripper,
-			&coffee.EndTime.Minutes, &coffee.EndTime.Seconds,
-			&coffee.CreatedAt, &coffee.UpdatedAt,
-		)
-		
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan coffee: %w", err)
-		}
-		
-		if err := json.Unmarshal(tastingNotesJSON, &coffee.TastingNotes); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal tasting notes: %w", err)
-		}
-		
-		if err := json.Unmarshal(tastingTraitsJSON, &coffee.TastingTraits); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal tasting traits: %w", err)
-		}
-		
-		if err := json.Unmarshal(recipeJSON, &coffee.Recipe); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal recipe: %w", err)
-		}
-		
-		coffees = append(coffees, coffee)
-	}
-	
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating rows: %w", err)
-	}
-	
-	return coffees, nil
+	return scanCoffees(rows)
 }
 
 // GetRecent retrieves the most recent coffees from the database
@@ -213,7 +271,7 @@ func (m *MySQLStorage) GetRecent(limit int) ([]models.Coffee, error) {
 	query := `
 		SELECT id, name, origin, roaster, variety, roast_level, processing_method,
 		       tasting_notes, tasting_traits, rating, recipe, dripper,
-		       end_time_minutes, end_time_seconds, created_at, updated_at
+		       end_time_minutes, end_time_seconds, roast_date, favorite, brewer_id, altitude, lot, price_amount, price_currency, bag_grams, lineage, added_by, tags, created_at, updated_at
 		FROM coffees
 		ORDER BY created_at DESC
 		LIMIT ?
@@ -225,18 +283,119 @@ func (m *MySQLStorage) GetRecent(limit int) ([]models.Coffee, error) {
 	}
 	defer rows.Close()
 	
+	return scanCoffees(rows)
+}
+
+// coffeeSortOrders are the ORDER BY clauses of CoffeeSorts
+var coffeeSortOrders = map[string]string{
+	"created_desc": "created_at DESC",
+	"created_asc":  "created_at ASC",
+	"rating_desc":  "rating DESC",
+	"rating_asc":   "rating ASC",
+	"name_asc":     "LOWER(name) ASC",
+	"name_desc":    "LOWER(name) DESC",
+}
+
+// likeEscaper escapes the LIKE wildcards in user text
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// coffeeWhere translates a search query into a WHERE clause with the same
+// matching rules as SearchQuery.Matches
+func coffeeWhere(q models.SearchQuery) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	for _, field := range []struct{ column, want string }{
+		{"origin", q.Origin},
+		{"roaster", q.Roaster},
+		{"variety", q.Variety},
+		{"roast_level", q.RoastLevel},
+		{"processing_method", q.ProcessingMethod},
+		{"dripper", q.Dripper},
+	} {
+		if want := strings.TrimSpace(field.want); want != "" {
+			conditions = append(conditions, "LOWER(TRIM("+field.column+")) = ?")
+			args = append(args, strings.ToLower(want))
+		}
+	}
+	if q.MinRating > 0 {
+		conditions = append(conditions, "rating >= ?")
+		args = append(args, q.MinRating)
+	}
+	if q.FavoritesOnly {
+		conditions = append(conditions, "favorite = TRUE")
+	}
+	if note := strings.TrimSpace(q.TastingNote); note != "" {
+		conditions = append(conditions, "JSON_SEARCH(LOWER(tasting_notes), 'one', ?) IS NOT NULL")
+		args = append(args, likeEscaper.Replace(strings.ToLower(note)))
+	}
+	if text := strings.TrimSpace(q.Text); text != "" {
+		conditions = append(conditions, "LOWER(CONCAT_WS(' ', name, roaster, origin, variety, tasting_notes)) LIKE ?")
+		args = append(args, "%"+likeEscaper.Replace(strings.ToLower(text))+"%")
+	}
+
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// Find retrieves the coffees matching a query and how many match in total.
+// Filtering, sorting and paging all happen in the database.
+func (m *MySQLStorage) Find(query CoffeeQuery) ([]models.Coffee, int, error) {
+	where, args := coffeeWhere(query.Filter)
+	
+	var total int
+	if err := m.db.QueryRow("SELECT COUNT(*) FROM coffees"+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count coffees: %w", err)
+	}
+	
+	order, ok := coffeeSortOrders[query.Sort]
+	if !ok {
+		order = coffeeSortOrders[DefaultCoffeeSort]
+	}
+	sqlQuery := `
+		SELECT id, name, origin, roaster, variety, roast_level, processing_method,
+		       tasting_notes, tasting_traits, rating, recipe, dripper,
+		       end_time_minutes, end_time_seconds, roast_date, favorite, brewer_id, altitude, lot, price_amount, price_currency, bag_grams, lineage, added_by, tags, created_at, updated_at
+		FROM coffees` + where + `
+		ORDER BY ` + order + `, created_at DESC, id ASC`
+	if query.Limit > 0 {
+		sqlQuery += " LIMIT ? OFFSET ?"
+		args = append(args, query.Limit, max(query.Offset, 0))
+	}
+	
+	rows, err := m.db.Query(sqlQuery, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query coffees: %w", err)
+	}
+	defer rows.Close()
+	
+	coffees, err := scanCoffees(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+	return coffees, total, nil
+}
+
+// scanCoffees reads the coffee rows of a GetAll-style query
+func scanCoffees(rows *sql.Rows) ([]models.Coffee, error) {
 	var coffees []models.Coffee
 	
 	for rows.Next() {
 		var coffee models.Coffee
-		var tastingNotesJSON, tastingTraitsJSON, recipeJSON []byte
+		var tastingNotesJSON, tastingTraitsJSON, recipeJSON, tagsJSON []byte
+		var roastDate sql.NullTime
+		var priceAmount sql.NullFloat64
+		var priceCurrency string
 		
 		err := rows.Scan(
 			&coffee.ID, &coffee.Name, &coffee.Origin, &coffee.Roaster, &coffee.Variety,
 			&coffee.RoastLevel, &coffee.ProcessingMethod,
 			&tastingNotesJSON, &tastingTraitsJSON, &coffee.Rating, &recipeJSON, &coffee.Dripper,
 			&coffee.EndTime.Minutes, &coffee.EndTime.Seconds,
-			&coffee.CreatedAt, &coffee.UpdatedAt,
+			&roastDate, &coffee.Favorite, &coffee.BrewerID, &coffee.Altitude, &coffee.Lot,
+			&priceAmount, &priceCurrency, &coffee.BagGrams, &coffee.Lineage, &coffee.AddedBy, &tagsJSON, &coffee.CreatedAt, &coffee.UpdatedAt,
 		)
 		
 		if err != nil {
@@ -255,6 +414,13 @@ func (m *MySQLStorage) GetRecent(limit int) ([]models.Coffee, error) {
 			return nil, fmt.Errorf("failed to unmarshal recipe: %w", err)
 		}
 		
+		if err := unmarshalTags(tagsJSON, &coffee.Tags); err != nil {
+			return nil, err
+		}
+		
+		coffee.RoastDate = scanDate(roastDate)
+		coffee.Price = scanPrice(priceAmount, priceCurrency)
+		
 		coffees = append(coffees, coffee)
 	}
 	
@@ -267,6 +433,11 @@ func (m *MySQLStorage) GetRecent(limit int) ([]models.Coffee, error) {
 
 // Update modifies an existing coffee entry
 func (m *MySQLStorage) Update(id string, coffee models.Coffee) error {
+	return updateCoffee(m.db, id, coffee)
+}
+
+// updateCoffee replaces a coffee row; who added it never changes
+func updateCoffee(db sqlExecer, id string, coffee models.Coffee) error {
 	tastingNotesJSON, err := json.Marshal(coffee.TastingNotes)
 	if err != nil {
 		return fmt.Errorf("failed to marshal tasting notes: %w", err)
@@ -282,21 +453,30 @@ func (m *MySQLStorage) Update(id string, coffee models.Coffee) error {
 		return fmt.Errorf("failed to marshal recipe: %w", err)
 	}
 	
+	tagsJSON, err := marshalTags(coffee.Tags)
+	if err != nil {
+		return err
+	}
+	
+	priceAmount, priceCurrency := nullablePrice(coffee.Price)
+	
 	query := `
 		UPDATE coffees SET
 			name=?, origin=?, roaster=?, variety=?, roast_level=?, processing_method=?,
 			tasting_notes=?, tasting_traits=?, rating=?, recipe=?, dripper=?,
-			end_time_minutes=?, end_time_seconds=?, updated_at=?
+			end_time_minutes=?, end_time_seconds=?, roast_date=?, favorite=?, brewer_id=?, altitude=?, lot=?,
+			price_amount=?, price_currency=?, bag_grams=?, lineage=?, tags=?, updated_at=?
 		WHERE id=?
 	`
 	
-	result, err := m.db.Exec(
+	result, err := db.Exec(
 		query,
 		coffee.Name, coffee.Origin, coffee.Roaster, coffee.Variety,
 		coffee.RoastLevel, coffee.ProcessingMethod,
 		tastingNotesJSON, tastingTraitsJSON, coffee.Rating, recipeJSON, coffee.Dripper,
 		coffee.EndTime.Minutes, coffee.EndTime.Seconds,
-		coffee.UpdatedAt, id,
+		nullableDate(coffee.RoastDate), coffee.Favorite, coffee.BrewerID, coffee.Altitude, coffee.Lot,
+		priceAmount, priceCurrency, coffee.BagGrams, coffee.Lineage, tagsJSON, coffee.UpdatedAt, id,
 	)
 	
 	if err != nil {
@@ -315,11 +495,41 @@ func (m *MySQLStorage) Update(id string, coffee models.Coffee) error {
 	return nil
 }
 
-// Delete removes a coffee entry from the database
+// Delete removes a coffee entry from the database, together with its
+// Pokemon catches so the Pokemon can be caught again
 func (m *MySQLStorage) Delete(id string) error {
-	query := "DELETE FROM coffees WHERE id = ?"
+	// The Pokemon tables only exist once Pokemon features have started
+	hasCatches, err := tableExists(m.db, "coffee_pokemon")
+	if err != nil {
+		return err
+	}
+	
+	tx, err := m.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	
+	if err := deleteCoffee(tx, id, hasCatches); err != nil {
+		return err
+	}
 	
-	result, err := m.db.Exec(query, id)
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit coffee deletion: %w", err)
+	}
+	return nil
+}
+
+// deleteCoffee removes a coffee row and, if the Pokemon tables exist, its
+// catches. Run it inside a transaction.
+func deleteCoffee(tx *sql.Tx, id string, hasCatches bool) error {
+	if hasCatches {
+		if _, err := tx.Exec("DELETE FROM coffee_pokemon WHERE coffee_id = ?", id); err != nil {
+			return fmt.Errorf("failed to release Pokemon of coffee: %w", err)
+		}
+	}
+	
+	result, err := tx.Exec("DELETE FROM coffees WHERE id = ?", id)
 	if err != nil {
 		return fmt.Errorf("failed to delete coffee: %w", err)
 	}
@@ -332,14 +542,61 @@ func (m *MySQLStorage) Delete(id string) error {
 	if rowsAffected == 0 {
 		return fmt.Errorf("coffee not found")
 	}
-	
 	return nil
 }
 
-// Close closes the database connection
-func (m *MySQLStorage) Close() error {
-	if m.db != nil {
-		return m.db.Close()
+// marshalTags encodes a coffee's tags; no tags are stored as NULL
+func marshalTags(tags []string) (interface{}, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	tagsJSON, err := json.Marshal(tags)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal tags: %w", err)
+	}
+	return tagsJSON, nil
+}
+
+// unmarshalTags decodes a scanned tags column, which is NULL without tags
+func unmarshalTags(tagsJSON []byte, tags *[]string) error {
+	if len(tagsJSON) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(tagsJSON, tags); err != nil {
+		return fmt.Errorf("failed to unmarshal tags: %w", err)
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+// nullableDate converts an optional date into a driver value
+func nullableDate(date *models.Date) interface{} {
+	if date == nil {
+		return nil
+	}
+	return date.Time
+}
+
+// scanDate converts a scanned nullable DATE column into an optional date
+func scanDate(value sql.NullTime) *models.Date {
+	if !value.Valid {
+		return nil
+	}
+	date := models.NewDate(value.Time)
+	return &date
+}
+
+// nullablePrice splits an optional price into its amount and currency columns
+func nullablePrice(price *models.Money) (interface{}, string) {
+	if price == nil {
+		return nil, ""
+	}
+	return price.Amount, price.Currency
+}
+
+// scanPrice rebuilds an optional price from its amount and currency columns
+func scanPrice(amount sql.NullFloat64, currency string) *models.Money {
+	if !amount.Valid {
+		return nil
+	}
+	return &models.Money{Amount: amount.Float64, Currency: currency}
+}
@@ -0,0 +1,259 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"go-coffee-log/models"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// OutboxStorage defines the interface for queued external deliveries
+type OutboxStorage interface {
+	SaveMessage(message models.OutboxMessage) error
+	GetMessage(id string) (models.OutboxMessage, error)
+	// GetDueMessages returns up to limit pending messages whose next attempt
+	// is at or before now, oldest attempt first
+	GetDueMessages(now time.Time, limit int) ([]models.OutboxMessage, error)
+	// GetMessages returns messages with the given status (all when empty),
+	// newest first
+	GetMessages(status string) ([]models.OutboxMessage, error)
+	UpdateMessage(message models.OutboxMessage) error
+	// DeleteDelivered removes messages delivered before cutoff
+	DeleteDelivered(cutoff time.Time) (int, error)
+}
+
+// MemoryOutboxStorage implements OutboxStorage in memory
+type MemoryOutboxStorage struct {
+	messages map[string]models.OutboxMessage
+	mu       sync.RWMutex
+}
+
+// NewMemoryOutboxStorage creates a new in-memory outbox storage
+func NewMemoryOutboxStorage() *MemoryOutboxStorage {
+	return &MemoryOutboxStorage{messages: make(map[string]models.OutboxMessage)}
+}
+
+// SaveMessage stores a new message
+func (m *MemoryOutboxStorage) SaveMessage(message models.OutboxMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages[message.ID] = message
+	return nil
+}
+
+// GetMessage retrieves a message by ID
+func (m *MemoryOutboxStorage) GetMessage(id string) (models.OutboxMessage, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	message, ok := m.messages[id]
+	if !ok {
+		return models.OutboxMessage{}, fmt.Errorf("message not found")
+	}
+	return message, nil
+}
+
+// GetDueMessages returns pending messages that are due, oldest attempt first
+func (m *MemoryOutboxStorage) GetDueMessages(now time.Time, limit int) ([]models.OutboxMessage, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	due := []models.OutboxMessage{}
+	for _, message := range m.messages {
+		if message.Status == models.OutboxPending && !message.NextAttemptAt.After(now) {
+			due = append(due, message)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].NextAttemptAt.Before(due[j].NextAttemptAt) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+// GetMessages returns messages with a status, newest first
+func (m *MemoryOutboxStorage) GetMessages(status string) ([]models.OutboxMessage, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	messages := []models.OutboxMessage{}
+	for _, message := range m.messages {
+		if status == "" || message.Status == status {
+			messages = append(messages, message)
+		}
+	}
+	sort.Slice(messages, func(i, j int) bool { return messages[i].CreatedAt.After(messages[j].CreatedAt) })
+	return messages, nil
+}
+
+// UpdateMessage replaces an existing message
+func (m *MemoryOutboxStorage) UpdateMessage(message models.OutboxMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.messages[message.ID]; !ok {
+		return fmt.Errorf("message not found")
+	}
+	m.messages[message.ID] = message
+	return nil
+}
+
+// DeleteDelivered removes messages delivered before cutoff
+func (m *MemoryOutboxStorage) DeleteDelivered(cutoff time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	deleted := 0
+	for id, message := range m.messages {
+		if message.DeliveredAt != nil && message.DeliveredAt.Before(cutoff) {
+			delete(m.messages, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// MySQLOutboxStorage implements OutboxStorage using MySQL, so queued
+// deliveries survive restarts
+type MySQLOutboxStorage struct {
+	db *sql.DB
+}
+
+// NewMySQLOutboxStorage creates a new MySQL outbox storage
+func NewMySQLOutboxStorage(db *sql.DB) *MySQLOutboxStorage {
+	storage := &MySQLOutboxStorage{db: db}
+
+	if err := storage.initTables(); err != nil {
+		panic(fmt.Sprintf("failed to initialize outbox tables: %v", err))
+	}
+
+	return storage
+}
+
+// initTables creates the outbox table if it doesn't exist
+func (m *MySQLOutboxStorage) initTables() error {
+	query := `
+		CREATE TABLE IF NOT EXISTS outbox (
+			id VARCHAR(36) PRIMARY KEY,
+			sink VARCHAR(20) NOT NULL,
+			target VARCHAR(2048) NOT NULL DEFAULT '',
+			payload JSON NOT NULL,
+			status VARCHAR(20) NOT NULL,
+			attempts INT NOT NULL DEFAULT 0,
+			next_attempt_at DATETIME NOT NULL,
+			last_error TEXT,
+			created_at DATETIME,
+			delivered_at DATETIME NULL,
+			INDEX idx_outbox_due (status, next_attempt_at)
+		)
+	`
+	if _, err := m.db.Exec(query); err != nil {
+		log.Printf("ERROR: initTables - Failed to create outbox table: %v", err)
+		return fmt.Errorf("failed to create outbox table: %w", err)
+	}
+	return nil
+}
+
+// SaveMessage stores a new message
+func (m *MySQLOutboxStorage) SaveMessage(message models.OutboxMessage) error {
+	_, err := m.db.Exec(`
+		INSERT INTO outbox (id, sink, target, payload, status, attempts, next_attempt_at, last_error, created_at, delivered_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, message.ID, message.Sink, message.Target, []byte(message.Payload), message.Status, message.Attempts,
+		message.NextAttemptAt, message.LastError, message.CreatedAt, message.DeliveredAt)
+	if err != nil {
+		return fmt.Errorf("failed to save outbox message: %w", err)
+	}
+	return nil
+}
+
+// GetMessage retrieves a message by ID
+func (m *MySQLOutboxStorage) GetMessage(id string) (models.OutboxMessage, error) {
+	messages, err := m.queryMessages("WHERE id = ?", id)
+	if err != nil {
+		return models.OutboxMessage{}, err
+	}
+	if len(messages) == 0 {
+		return models.OutboxMessage{}, fmt.Errorf("message not found")
+	}
+	return messages[0], nil
+}
+
+// GetDueMessages returns pending messages that are due, oldest attempt first
+func (m *MySQLOutboxStorage) GetDueMessages(now time.Time, limit int) ([]models.OutboxMessage, error) {
+	return m.queryMessages("WHERE status = ? AND next_attempt_at <= ? ORDER BY next_attempt_at LIMIT ?",
+		models.OutboxPending, now, limit)
+}
+
+// GetMessages returns messages with a status, newest first
+func (m *MySQLOutboxStorage) GetMessages(status string) ([]models.OutboxMessage, error) {
+	if status == "" {
+		return m.queryMessages("ORDER BY created_at DESC")
+	}
+	return m.queryMessages("WHERE status = ? ORDER BY created_at DESC", status)
+}
+
+// UpdateMessage replaces an existing message
+func (m *MySQLOutboxStorage) UpdateMessage(message models.OutboxMessage) error {
+	result, err := m.db.Exec(`
+		UPDATE outbox SET status = ?, attempts = ?, next_attempt_at = ?, last_error = ?, delivered_at = ?
+		WHERE id = ?
+	`, message.Status, message.Attempts, message.NextAttemptAt, message.LastError, message.DeliveredAt, message.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update outbox message: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		// MySQL reports 0 rows for updates that change nothing, so check existence
+		if _, err := m.GetMessage(message.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteDelivered removes messages delivered before cutoff
+func (m *MySQLOutboxStorage) DeleteDelivered(cutoff time.Time) (int, error) {
+	result, err := m.db.Exec("DELETE FROM outbox WHERE delivered_at IS NOT NULL AND delivered_at < ?", cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete delivered outbox messages: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return int(deleted), nil
+}
+
+// queryMessages selects messages with the given WHERE/ORDER clause
+func (m *MySQLOutboxStorage) queryMessages(clause string, args ...interface{}) ([]models.OutboxMessage, error) {
+	rows, err := m.db.Query(`
+		SELECT id, sink, target, payload, status, attempts, next_attempt_at, COALESCE(last_error, ''), created_at, delivered_at
+		FROM outbox `+clause, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query outbox: %w", err)
+	}
+	defer rows.Close()
+
+	messages := []models.OutboxMessage{}
+	for rows.Next() {
+		var message models.OutboxMessage
+		var payload []byte
+		var deliveredAt sql.NullTime
+		if err := rows.Scan(&message.ID, &message.Sink, &message.Target, &payload, &message.Status, &message.Attempts,
+			&message.NextAttemptAt, &message.LastError, &message.CreatedAt, &deliveredAt); err != nil {
+			return nil, fmt.Errorf("failed to scan outbox message: %w", err)
+		}
+		message.Payload = payload
+		if deliveredAt.Valid {
+			message.DeliveredAt = &deliveredAt.Time
+		}
+		messages = append(messages, message)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating outbox: %w", err)
+	}
+	return messages, nil
+}
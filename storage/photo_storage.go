@@ -0,0 +1,188 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"go-coffee-log/models"
+	"log"
+	"sort"
+	"sync"
+)
+
+// PhotoStorage defines the interface for the photos attached to coffees
+type PhotoStorage interface {
+	// SavePhoto attaches a photo; attaching the same photo twice is a no-op
+	SavePhoto(photo models.CoffeePhoto) error
+	GetPhotos(coffeeID string) ([]models.CoffeePhoto, error)
+	GetAllPhotos() ([]models.CoffeePhoto, error)
+	DeletePhoto(coffeeID, hash string) error
+}
+
+// MemoryPhotoStorage implements PhotoStorage in memory
+type MemoryPhotoStorage struct {
+	photos map[string][]models.CoffeePhoto // by coffee ID, oldest first
+	mu     sync.RWMutex
+}
+
+// NewMemoryPhotoStorage creates a new in-memory photo storage
+func NewMemoryPhotoStorage() *MemoryPhotoStorage {
+	return &MemoryPhotoStorage{photos: make(map[string][]models.CoffeePhoto)}
+}
+
+// SavePhoto attaches a photo to a coffee
+func (m *MemoryPhotoStorage) SavePhoto(photo models.CoffeePhoto) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, existing := range m.photos[photo.CoffeeID] {
+		if existing.Hash == photo.Hash {
+			return nil
+		}
+	}
+	m.photos[photo.CoffeeID] = append(m.photos[photo.CoffeeID], photo)
+	return nil
+}
+
+// GetPhotos returns the photos of one coffee, oldest first
+func (m *MemoryPhotoStorage) GetPhotos(coffeeID string) ([]models.CoffeePhoto, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]models.CoffeePhoto{}, m.photos[coffeeID]...), nil
+}
+
+// GetAllPhotos returns every attached photo
+func (m *MemoryPhotoStorage) GetAllPhotos() ([]models.CoffeePhoto, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var photos []models.CoffeePhoto
+	for _, coffeePhotos := range m.photos {
+		photos = append(photos, coffeePhotos...)
+	}
+	sort.Slice(photos, func(i, j int) bool { return photos[i].CreatedAt.Before(photos[j].CreatedAt) })
+	return photos, nil
+}
+
+// DeletePhoto detaches a photo from a coffee
+func (m *MemoryPhotoStorage) DeletePhoto(coffeeID, hash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	photos := m.photos[coffeeID]
+	for i, photo := range photos {
+		if photo.Hash == hash {
+			m.photos[coffeeID] = append(photos[:i:i], photos[i+1:]...)
+			if len(m.photos[coffeeID]) == 0 {
+				delete(m.photos, coffeeID)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("photo %s not found", hash)
+}
+
+// MySQLPhotoStorage implements PhotoStorage using MySQL
+type MySQLPhotoStorage struct {
+	db *sql.DB
+}
+
+// NewMySQLPhotoStorage creates a new MySQL photo storage
+func NewMySQLPhotoStorage(db *sql.DB) *MySQLPhotoStorage {
+	storage := &MySQLPhotoStorage{db: db}
+
+	if err := storage.initTables(); err != nil {
+		panic(fmt.Sprintf("failed to initialize coffee_photos table: %v", err))
+	}
+
+	return storage
+}
+
+// initTables creates the coffee_photos table if it doesn't exist
+func (m *MySQLPhotoStorage) initTables() error {
+	query := `
+		CREATE TABLE IF NOT EXISTS coffee_photos (
+			coffee_id VARCHAR(36) NOT NULL,
+			hash CHAR(64) NOT NULL,
+			content_type VARCHAR(50) NOT NULL,
+			size BIGINT NOT NULL,
+			uploaded_by VARCHAR(36) NOT NULL DEFAULT '',
+			created_at DATETIME,
+			PRIMARY KEY (coffee_id, hash),
+			INDEX idx_hash (hash),
+			FOREIGN KEY (coffee_id) REFERENCES coffees(id) ON DELETE CASCADE
+		)
+	`
+
+	if _, err := m.db.Exec(query); err != nil {
+		log.Printf("ERROR: initTables - Failed to create coffee_photos table: %v", err)
+		return fmt.Errorf("failed to create coffee_photos table: %w", err)
+	}
+
+	// Columns added after the table was first introduced
+	return ensureColumn(m.db, "coffee_photos", "uploaded_by", "VARCHAR(36) NOT NULL DEFAULT ''")
+}
+
+// SavePhoto attaches a photo to a coffee
+func (m *MySQLPhotoStorage) SavePhoto(photo models.CoffeePhoto) error {
+	_, err := m.db.Exec(`
+		INSERT IGNORE INTO coffee_photos (coffee_id, hash, content_type, size, uploaded_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, photo.CoffeeID, photo.Hash, photo.ContentType, photo.Size, photo.UploadedBy, photo.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save photo: %w", err)
+	}
+	return nil
+}
+
+// GetPhotos returns the photos of one coffee, oldest first
+func (m *MySQLPhotoStorage) GetPhotos(coffeeID string) ([]models.CoffeePhoto, error) {
+	return m.queryPhotos(`
+		SELECT coffee_id, hash, content_type, size, uploaded_by, created_at
+		FROM coffee_photos WHERE coffee_id = ? ORDER BY created_at
+	`, coffeeID)
+}
+
+// GetAllPhotos returns every attached photo
+func (m *MySQLPhotoStorage) GetAllPhotos() ([]models.CoffeePhoto, error) {
+	return m.queryPhotos(`
+		SELECT coffee_id, hash, content_type, size, uploaded_by, created_at
+		FROM coffee_photos ORDER BY created_at
+	`)
+}
+
+// queryPhotos runs a photo query and scans the rows
+func (m *MySQLPhotoStorage) queryPhotos(query string, args ...interface{}) ([]models.CoffeePhoto, error) {
+	rows, err := m.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query photos: %w", err)
+	}
+	defer rows.Close()
+
+	photos := []models.CoffeePhoto{}
+	for rows.Next() {
+		var photo models.CoffeePhoto
+		var createdAt sql.NullTime
+		if err := rows.Scan(&photo.CoffeeID, &photo.Hash, &photo.ContentType, &photo.Size, &photo.UploadedBy, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan photo: %w", err)
+		}
+		photo.CreatedAt = createdAt.Time
+		photos = append(photos, photo)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating photos: %w", err)
+	}
+	return photos, nil
+}
+
+// DeletePhoto detaches a photo from a coffee
+func (m *MySQLPhotoStorage) DeletePhoto(coffeeID, hash string) error {
+	result, err := m.db.Exec("DELETE FROM coffee_photos WHERE coffee_id = ? AND hash = ?", coffeeID, hash)
+	if err != nil {
+		return fmt.Errorf("failed to delete photo: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("photo %s not found", hash)
+	}
+	return nil
+}
@@ -0,0 +1,228 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"go-coffee-log/models"
+	"log"
+	"sort"
+	"sync"
+)
+
+// PlanStorage defines the interface for planned brews
+type PlanStorage interface {
+	SavePlan(plan models.PlannedBrew) error
+	GetPlan(id string) (models.PlannedBrew, error)
+	GetAllPlans() ([]models.PlannedBrew, error)
+	UpdatePlan(plan models.PlannedBrew) error
+	DeletePlan(id string) error
+}
+
+// sortPlans orders plans by when they are scheduled
+func sortPlans(plans []models.PlannedBrew) {
+	sort.Slice(plans, func(i, j int) bool { return plans[i].ScheduledFor.Before(plans[j].ScheduledFor) })
+}
+
+// MemoryPlanStorage implements PlanStorage in memory
+type MemoryPlanStorage struct {
+	plans map[string]models.PlannedBrew
+	mu    sync.RWMutex
+}
+
+// NewMemoryPlanStorage creates a new in-memory plan storage
+func NewMemoryPlanStorage() *MemoryPlanStorage {
+	return &MemoryPlanStorage{plans: make(map[string]models.PlannedBrew)}
+}
+
+// SavePlan stores a new plan
+func (m *MemoryPlanStorage) SavePlan(plan models.PlannedBrew) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.plans[plan.ID] = plan
+	return nil
+}
+
+// GetPlan retrieves a plan by ID
+func (m *MemoryPlanStorage) GetPlan(id string) (models.PlannedBrew, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	plan, ok := m.plans[id]
+	if !ok {
+		return models.PlannedBrew{}, fmt.Errorf("plan not found")
+	}
+	return plan, nil
+}
+
+// GetAllPlans returns every plan, soonest first
+func (m *MemoryPlanStorage) GetAllPlans() ([]models.PlannedBrew, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	plans := make([]models.PlannedBrew, 0, len(m.plans))
+	for _, plan := range m.plans {
+		plans = append(plans, plan)
+	}
+	sortPlans(plans)
+	return plans, nil
+}
+
+// UpdatePlan replaces an existing plan
+func (m *MemoryPlanStorage) UpdatePlan(plan models.PlannedBrew) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.plans[plan.ID]; !ok {
+		return fmt.Errorf("plan not found")
+	}
+	m.plans[plan.ID] = plan
+	return nil
+}
+
+// DeletePlan removes a plan
+func (m *MemoryPlanStorage) DeletePlan(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.plans[id]; !ok {
+		return fmt.Errorf("plan not found")
+	}
+	delete(m.plans, id)
+	return nil
+}
+
+// MySQLPlanStorage implements PlanStorage using MySQL
+type MySQLPlanStorage struct {
+	db *sql.DB
+}
+
+// NewMySQLPlanStorage creates a new MySQL plan storage
+func NewMySQLPlanStorage(db *sql.DB) *MySQLPlanStorage {
+	storage := &MySQLPlanStorage{db: db}
+
+	if err := storage.initTables(); err != nil {
+		panic(fmt.Sprintf("failed to initialize planned_brews table: %v", err))
+	}
+
+	return storage
+}
+
+// initTables creates the planned_brews table if it doesn't exist
+func (m *MySQLPlanStorage) initTables() error {
+	query := `
+		CREATE TABLE IF NOT EXISTS planned_brews (
+			id VARCHAR(36) PRIMARY KEY,
+			coffee_id VARCHAR(36) NOT NULL,
+			dripper VARCHAR(100),
+			notes TEXT,
+			scheduled_for DATETIME NOT NULL,
+			reminder_minutes INT NOT NULL,
+			reminded_at DATETIME NULL,
+			brew_id VARCHAR(36),
+			created_at DATETIME,
+			INDEX idx_planned_brews_scheduled (scheduled_for),
+			FOREIGN KEY (coffee_id) REFERENCES coffees(id) ON DELETE CASCADE
+		)
+	`
+
+	if _, err := m.db.Exec(query); err != nil {
+		log.Printf("ERROR: initTables - Failed to create planned_brews table: %v", err)
+		return fmt.Errorf("failed to create planned_brews table: %w", err)
+	}
+
+	return nil
+}
+
+// SavePlan stores a new plan
+func (m *MySQLPlanStorage) SavePlan(plan models.PlannedBrew) error {
+	_, err := m.db.Exec(`
+		INSERT INTO planned_brews (id, coffee_id, dripper, notes, scheduled_for, reminder_minutes, reminded_at, brew_id, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, plan.ID, plan.CoffeeID, plan.Dripper, plan.Notes, plan.ScheduledFor, plan.ReminderMinutes,
+		plan.RemindedAt, plan.BrewID, plan.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save plan: %w", err)
+	}
+	return nil
+}
+
+// GetPlan retrieves a plan by ID
+func (m *MySQLPlanStorage) GetPlan(id string) (models.PlannedBrew, error) {
+	plans, err := m.queryPlans("WHERE id = ?", id)
+	if err != nil {
+		return models.PlannedBrew{}, err
+	}
+	if len(plans) == 0 {
+		return models.PlannedBrew{}, fmt.Errorf("plan not found")
+	}
+	return plans[0], nil
+}
+
+// GetAllPlans returns every plan, soonest first
+func (m *MySQLPlanStorage) GetAllPlans() ([]models.PlannedBrew, error) {
+	return m.queryPlans("ORDER BY scheduled_for")
+}
+
+// UpdatePlan replaces an existing plan
+func (m *MySQLPlanStorage) UpdatePlan(plan models.PlannedBrew) error {
+	result, err := m.db.Exec(`
+		UPDATE planned_brews
+		SET dripper = ?, notes = ?, scheduled_for = ?, reminder_minutes = ?, reminded_at = ?, brew_id = ?
+		WHERE id = ?
+	`, plan.Dripper, plan.Notes, plan.ScheduledFor, plan.ReminderMinutes, plan.RemindedAt, plan.BrewID, plan.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update plan: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		// MySQL reports 0 rows for updates that change nothing, so check existence
+		if _, err := m.GetPlan(plan.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeletePlan removes a plan
+func (m *MySQLPlanStorage) DeletePlan(id string) error {
+	result, err := m.db.Exec("DELETE FROM planned_brews WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete plan: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return fmt.Errorf("plan not found")
+	}
+	return nil
+}
+
+// queryPlans selects plans with the given WHERE/ORDER clause
+func (m *MySQLPlanStorage) queryPlans(clause string, args ...interface{}) ([]models.PlannedBrew, error) {
+	rows, err := m.db.Query(`
+		SELECT id, coffee_id, dripper, notes, scheduled_for, reminder_minutes, reminded_at, brew_id, created_at
+		FROM planned_brews `+clause, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query plans: %w", err)
+	}
+	defer rows.Close()
+
+	plans := []models.PlannedBrew{}
+	for rows.Next() {
+		var plan models.PlannedBrew
+		var dripper, notes, brewID sql.NullString
+		var remindedAt sql.NullTime
+		if err := rows.Scan(&plan.ID, &plan.CoffeeID, &dripper, &notes, &plan.ScheduledFor,
+			&plan.ReminderMinutes, &remindedAt, &brewID, &plan.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan plan: %w", err)
+		}
+		plan.Dripper = dripper.String
+		plan.Notes = notes.String
+		plan.BrewID = brewID.String
+		if remindedAt.Valid {
+			plan.RemindedAt = &remindedAt.Time
+		}
+		plans = append(plans, plan)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating plans: %w", err)
+	}
+	return plans, nil
+}
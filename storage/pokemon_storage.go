@@ -3,11 +3,13 @@ package storage
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"go-coffee-log/models"
-	"time"
+	"log"
+	"strings"
 
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
 )
 
 // PokemonStorage defines the interface for Pokemon data operations
@@ -15,12 +17,26 @@ type PokemonStorage interface {
 	GetAllPokemon() ([]models.Pokemon, error)
 	GetPokemonByID(id int) (*models.Pokemon, error)
 	GetPokemonByType(pokemonType string) ([]models.Pokemon, error)
-	IsPokemonUsed(pokemonID int) (bool, error)
-	ReservePokemon(pokemonID int, coffeeID string) error
+	// IsPokemonUsed reports whether a Pokemon is caught in a dex profile;
+	// each profile is its own uniqueness scope
+	IsPokemonUsed(dex string, pokemonID int) (bool, error)
+	// ReservePokemon records a new catch only if its Pokemon is still free in
+	// the mapping's dex profile, checking and inserting in one step
+	ReservePokemon(mapping models.CoffeePokemon) error
 	CreateCoffeePokemon(mapping models.CoffeePokemon) error
-	GetCoffeePokemon(coffeeID string) (*models.CoffeePokemon, error)
+	// ReplaceCoffeePokemon swaps a coffee's catch in the mapping's dex
+	// profile for the mapping, in one step, if no other coffee holds its
+	// Pokemon
+	ReplaceCoffeePokemon(mapping models.CoffeePokemon) error
+	GetCoffeePokemon(coffeeID, dex string) (*models.CoffeePokemon, error)
+	// GetAllCoffeePokemon returns the catches of every dex profile
 	GetAllCoffeePokemon() ([]models.CoffeePokemon, error)
-	UpdateCoffeePokemonNickname(coffeeID, nickname string) error
+	// GetCoffeeCatches returns a coffee's catches in every dex profile
+	GetCoffeeCatches(coffeeID string) ([]models.CoffeePokemon, error)
+	UpdateCoffeePokemonNickname(coffeeID, dex, nickname string) error
+	UpdateCoffeePokemonDescription(coffeeID, dex, description string) error
+	// UpsertPokemon inserts or replaces reference data in one step
+	UpsertPokemon(pokemon []models.Pokemon) error
 }
 
 // MySQLPokemonStorage implements PokemonStorage using MySQL
@@ -30,9 +46,50 @@ type MySQLPokemonStorage struct {
 
 // NewMySQLPokemonStorage creates a new Pokemon storage
 func NewMySQLPokemonStorage(db *sql.DB) *MySQLPokemonStorage {
+	storage := &MySQLPokemonStorage{db: db}
+
+	if err := storage.migrateDexProfiles(); err != nil {
+		log.Printf("ERROR: migrateDexProfiles - %v", err)
+		panic(fmt.Sprintf("failed to migrate coffee_pokemon for dex profiles: %v", err))
+	}
+
+	return storage
+}
+
+// NewMySQLPokemonReadStorage creates a Pokemon storage on a read replica's
+// pool. It skips the migrations, which the replica gets from the primary;
+// use it for reads only.
+func NewMySQLPokemonReadStorage(db *sql.DB) *MySQLPokemonStorage {
 	return &MySQLPokemonStorage{db: db}
 }
 
+// migrateDexProfiles scopes catches to dex profiles: existing catches move
+// to the default profile, and a Pokemon becomes unique per profile rather
+// than overall. The tables themselves come from sql/setup_pokemon_database.sql.
+func (m *MySQLPokemonStorage) migrateDexProfiles() error {
+	exists, err := tableExists(m.db, "coffee_pokemon")
+	if err != nil || !exists {
+		return err
+	}
+
+	if err := ensureColumn(m.db, "coffee_pokemon", "dex", fmt.Sprintf("VARCHAR(36) NOT NULL DEFAULT '%s'", models.DefaultDex)); err != nil {
+		return err
+	}
+	if err := ensureColumn(m.db, "coffee_pokemon", "provenance", "JSON NULL"); err != nil {
+		return err
+	}
+	// pokemon_id leads so the index still serves the foreign key
+	if err := ensureUniqueIndex(m.db, "coffee_pokemon", "idx_unique_dex_pokemon", "pokemon_id, dex"); err != nil {
+		return err
+	}
+	// One catch per coffee and dex, so racing catches cannot both land;
+	// older data may hold duplicates, which the integrity check reports
+	if err := ensureUniqueIndex(m.db, "coffee_pokemon", "idx_unique_coffee_dex", "coffee_id, dex"); err != nil {
+		log.Printf("WARNING: %v; clean up coffees caught twice in a dex and restart", err)
+	}
+	return dropIndex(m.db, "coffee_pokemon", "idx_unique_pokemon")
+}
+
 // initPokemonTable creates the Pokemon-related tables
 func (m *MySQLPokemonStorage) initPokemonTable() error {
 	// Pokemon reference table
@@ -57,12 +114,14 @@ func (m *MySQLPokemonStorage) initPokemonTable() error {
 		CREATE TABLE IF NOT EXISTS coffee_pokemon (
 			id VARCHAR(36) PRIMARY KEY,
 			coffee_id VARCHAR(36) NOT NULL,
+			dex VARCHAR(36) NOT NULL DEFAULT 'kanto',
 			pokemon_id INT NOT NULL,
 			nickname VARCHAR(100),
 			level INT DEFAULT 1,
 			mapping_confidence REAL,
 			llm_description TEXT,
 			trait_mapping JSON,
+			provenance JSON NULL,
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			FOREIGN KEY (coffee_id) REFERENCES coffees(id),
 			FOREIGN KEY (pokemon_id) REFERENCES pokemons(id)
@@ -74,8 +133,8 @@ func (m *MySQLPokemonStorage) initPokemonTable() error {
 		return fmt.Errorf("failed to create coffee_pokemon table: %w", err)
 	}
 	
-	// Unique index to prevent duplicate Pokemon
-	query = `CREATE UNIQUE INDEX IF NOT EXISTS idx_unique_pokemon ON coffee_pokemon(pokemon_id)`
+	// Unique index to prevent duplicate Pokemon within a dex profile
+	query = `CREATE UNIQUE INDEX IF NOT EXISTS idx_unique_dex_pokemon ON coffee_pokemon(pokemon_id, dex)`
 	_, err = m.db.Exec(query)
 	if err != nil {
 		return fmt.Errorf("failed to create unique index: %w", err)
@@ -193,12 +252,13 @@ func (m *MySQLPokemonStorage) GetPokemonByType(pokemonType string) ([]models.Pok
 	return pokemons, nil
 }
 
-// IsPokemonUsed checks if a Pokemon is already mapped to a coffee
-func (m *MySQLPokemonStorage) IsPokemonUsed(pokemonID int) (bool, error) {
-	query := "SELECT COUNT(*) FROM coffee_pokemon WHERE pokemon_id = ?"
+// IsPokemonUsed checks if a Pokemon is already mapped to a coffee in a dex
+// profile
+func (m *MySQLPokemonStorage) IsPokemonUsed(dex string, pokemonID int) (bool, error) {
+	query := "SELECT COUNT(*) FROM coffee_pokemon WHERE pokemon_id = ? AND dex = ?"
 	
 	var count int
-	err := m.db.QueryRow(query, pokemonID).Scan(&count)
+	err := m.db.QueryRow(query, pokemonID, dex).Scan(&count)
 	if err != nil {
 		return false, fmt.Errorf("failed to check Pokemon usage: %w", err)
 	}
@@ -206,72 +266,188 @@ func (m *MySQLPokemonStorage) IsPokemonUsed(pokemonID int) (bool, error) {
 	return count > 0, nil
 }
 
-// ReservePokemon reserves a Pokemon for a coffee (placeholder for future use)
-func (m *MySQLPokemonStorage) ReservePokemon(pokemonID int, coffeeID string) error {
-	// For now, just create the mapping to reserve the Pokemon
-	mapping := models.CoffeePokemon{
-		ID:          fmt.Sprintf("reserved_%d_%s", pokemonID, coffeeID),
-		CoffeeID:    coffeeID,
-		PokemonID:   pokemonID,
-		PokemonName: "Reserved",
-		Level:       1,
-		CreatedAt:   time.Now(),
-	}
-	
-	return m.CreateCoffeePokemon(mapping)
+// ReservePokemon locks the Pokemon's row in the dex, if it is caught, and
+// inserts the mapping in a single transaction
+func (m *MySQLPokemonStorage) ReservePokemon(mapping models.CoffeePokemon) error {
+	if mapping.Dex == "" {
+		mapping.Dex = models.DefaultDex
+	}
+	
+	tx, err := m.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	
+	if err := reservePokemon(tx, mapping); err != nil {
+		return err
+	}
+	if err := insertCoffeePokemon(tx.Exec, mapping); err != nil {
+		return err
+	}
+	
+	if err := tx.Commit(); err != nil {
+		if conflict := catchConflict(err, mapping); conflict != nil {
+			return conflict
+		}
+		return fmt.Errorf("failed to commit Pokemon reservation: %w", err)
+	}
+	return nil
+}
+
+// reservePokemon checks, holding a lock until tx ends, that no other
+// coffee has caught the mapping's Pokemon in its dex
+func reservePokemon(tx *sql.Tx, mapping models.CoffeePokemon) error {
+	var holder string
+	err := tx.QueryRow(
+		"SELECT coffee_id FROM coffee_pokemon WHERE pokemon_id = ? AND dex = ? FOR UPDATE",
+		mapping.PokemonID, mapping.Dex,
+	).Scan(&holder)
+	switch {
+	case err == sql.ErrNoRows:
+		return nil
+	case err != nil:
+		if conflict := catchConflict(err, mapping); conflict != nil {
+			return conflict
+		}
+		return fmt.Errorf("failed to reserve Pokemon: %w", err)
+	case holder != mapping.CoffeeID:
+		return pokemonTakenError(mapping)
+	}
+	return nil
 }
 
 // CreateCoffeePokemon creates a new coffee-Pokemon mapping
 func (m *MySQLPokemonStorage) CreateCoffeePokemon(mapping models.CoffeePokemon) error {
+	return insertCoffeePokemon(m.db.Exec, mapping)
+}
+
+// ReplaceCoffeePokemon deletes a coffee's catch in a dex profile and
+// inserts the new mapping in a single transaction
+func (m *MySQLPokemonStorage) ReplaceCoffeePokemon(mapping models.CoffeePokemon) error {
+	if mapping.Dex == "" {
+		mapping.Dex = models.DefaultDex
+	}
+	
+	tx, err := m.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	
+	if err := reservePokemon(tx, mapping); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM coffee_pokemon WHERE coffee_id = ? AND dex = ?", mapping.CoffeeID, mapping.Dex); err != nil {
+		return fmt.Errorf("failed to delete coffee Pokemon mapping: %w", err)
+	}
+	if err := insertCoffeePokemon(tx.Exec, mapping); err != nil {
+		return err
+	}
+	
+	if err := tx.Commit(); err != nil {
+		if conflict := catchConflict(err, mapping); conflict != nil {
+			return conflict
+		}
+		return fmt.Errorf("failed to commit Pokemon replacement: %w", err)
+	}
+	return nil
+}
+
+// insertCoffeePokemon inserts a mapping with exec, which is the Exec of a
+// database or a transaction
+func insertCoffeePokemon(exec func(query string, args ...interface{}) (sql.Result, error), mapping models.CoffeePokemon) error {
 	traitMappingJSON, err := json.Marshal(mapping.TraitMapping)
 	if err != nil {
 		return fmt.Errorf("failed to marshal trait mapping: %w", err)
 	}
+	var provenanceJSON []byte
+	if mapping.Provenance != nil {
+		if provenanceJSON, err = json.Marshal(mapping.Provenance); err != nil {
+			return fmt.Errorf("failed to marshal provenance: %w", err)
+		}
+	}
+	
+	if mapping.Dex == "" {
+		mapping.Dex = models.DefaultDex
+	}
 	
 	query := `
 		INSERT INTO coffee_pokemon (
-			id, coffee_id, pokemon_id, nickname, level,
-			mapping_confidence, llm_description, trait_mapping
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+			id, coffee_id, dex, pokemon_id, nickname, level,
+			mapping_confidence, llm_description, trait_mapping, provenance
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 	
-	_, err = m.db.Exec(
+	_, err = exec(
 		query,
-		mapping.ID, mapping.CoffeeID, mapping.PokemonID,
+		mapping.ID, mapping.CoffeeID, mapping.Dex, mapping.PokemonID,
 		mapping.Nickname, mapping.Level,
 		mapping.MappingConfidence, mapping.LLMDescription,
-		traitMappingJSON,
+		traitMappingJSON, provenanceJSON,
 	)
 	
 	if err != nil {
+		if conflict := catchConflict(err, mapping); conflict != nil {
+			return conflict
+		}
 		return fmt.Errorf("failed to create coffee Pokemon mapping: %w", err)
 	}
 	
 	return nil
 }
 
-// GetCoffeePokemon retrieves Pokemon mapping for a coffee
-func (m *MySQLPokemonStorage) GetCoffeePokemon(coffeeID string) (*models.CoffeePokemon, error) {
+// MySQL errors racing catches run into
+const (
+	mysqlDuplicateKey = 1062
+	mysqlDeadlock     = 1213 // two reservations locked the same gap
+)
+
+// catchConflict turns an error of racing catches, a duplicate key on one of
+// the unique indexes of coffee_pokemon or a deadlock the other catch won,
+// into the error the service layer reports for it; other errors give nil
+func catchConflict(err error, mapping models.CoffeePokemon) error {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return nil
+	}
+	switch {
+	case mysqlErr.Number == mysqlDuplicateKey && strings.Contains(mysqlErr.Message, "idx_unique_coffee_dex"):
+		return fmt.Errorf("coffee already has a Pokemon in the %s dex", mapping.Dex)
+	case mysqlErr.Number == mysqlDuplicateKey, mysqlErr.Number == mysqlDeadlock:
+		return pokemonTakenError(mapping)
+	}
+	return nil
+}
+
+// pokemonTakenError reports that another coffee holds the mapping's Pokemon
+func pokemonTakenError(mapping models.CoffeePokemon) error {
+	return fmt.Errorf("Pokemon %s was just caught by another coffee in the %s dex", mapping.PokemonName, mapping.Dex)
+}
+
+// GetCoffeePokemon retrieves the Pokemon mapping for a coffee in a dex
+// profile
+func (m *MySQLPokemonStorage) GetCoffeePokemon(coffeeID, dex string) (*models.CoffeePokemon, error) {
 	query := `
-		SELECT cp.id, cp.coffee_id, cp.pokemon_id, cp.nickname, cp.level,
+		SELECT cp.id, cp.coffee_id, cp.dex, cp.pokemon_id, cp.nickname, cp.level,
 		       cp.mapping_confidence, cp.llm_description, cp.created_at,
-		       p.name, cp.trait_mapping
+		       p.name, cp.trait_mapping, cp.provenance
 		FROM coffee_pokemon cp
 		JOIN pokemons p ON cp.pokemon_id = p.id
-		WHERE cp.coffee_id = ?
+		WHERE cp.coffee_id = ? AND cp.dex = ?
 	`
 	
-	row := m.db.QueryRow(query, coffeeID)
+	row := m.db.QueryRow(query, coffeeID, dex)
 	
 	var mapping models.CoffeePokemon
-	var traitMappingJSON []byte
+	var traitMappingJSON, provenanceJSON []byte
 	
 	err := row.Scan(
-		&mapping.ID, &mapping.CoffeeID, &mapping.PokemonID,
+		&mapping.ID, &mapping.CoffeeID, &mapping.Dex, &mapping.PokemonID,
 		&mapping.Nickname, &mapping.Level,
 		&mapping.MappingConfidence, &mapping.LLMDescription,
 		&mapping.CreatedAt, &mapping.PokemonName,
-		&traitMappingJSON,
+		&traitMappingJSON, &provenanceJSON,
 	)
 	
 	if err == sql.ErrNoRows {
@@ -284,6 +460,9 @@ func (m *MySQLPokemonStorage) GetCoffeePokemon(coffeeID string) (*models.CoffeeP
 	if err := json.Unmarshal(traitMappingJSON, &mapping.TraitMapping); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal trait mapping: %w", err)
 	}
+	if err := scanProvenance(provenanceJSON, &mapping); err != nil {
+		return nil, err
+	}
 	
 	return &mapping, nil
 }
@@ -291,15 +470,36 @@ func (m *MySQLPokemonStorage) GetCoffeePokemon(coffeeID string) (*models.CoffeeP
 // GetAllCoffeePokemon retrieves all coffee-Pokemon mappings
 func (m *MySQLPokemonStorage) GetAllCoffeePokemon() ([]models.CoffeePokemon, error) {
 	query := `
-		SELECT cp.id, cp.coffee_id, cp.pokemon_id, cp.nickname, cp.level,
+		SELECT cp.id, cp.coffee_id, cp.dex, cp.pokemon_id, cp.nickname, cp.level,
 		       cp.mapping_confidence, cp.llm_description, cp.created_at,
-		       p.name, cp.trait_mapping
+		       p.name, cp.trait_mapping, cp.provenance
 		FROM coffee_pokemon cp
 		JOIN pokemons p ON cp.pokemon_id = p.id
 		ORDER BY cp.created_at DESC
 	`
 	
-	rows, err := m.db.Query(query)
+	return m.queryCoffeePokemon(query)
+}
+
+// GetCoffeeCatches retrieves a coffee's mappings in every dex profile
+func (m *MySQLPokemonStorage) GetCoffeeCatches(coffeeID string) ([]models.CoffeePokemon, error) {
+	query := `
+		SELECT cp.id, cp.coffee_id, cp.dex, cp.pokemon_id, cp.nickname, cp.level,
+		       cp.mapping_confidence, cp.llm_description, cp.created_at,
+		       p.name, cp.trait_mapping, cp.provenance
+		FROM coffee_pokemon cp
+		JOIN pokemons p ON cp.pokemon_id = p.id
+		WHERE cp.coffee_id = ?
+		ORDER BY cp.dex
+	`
+	
+	return m.queryCoffeePokemon(query, coffeeID)
+}
+
+// queryCoffeePokemon runs a query selecting the coffee_pokemon columns
+// GetAllCoffeePokemon selects and scans its rows
+func (m *MySQLPokemonStorage) queryCoffeePokemon(query string, args ...interface{}) ([]models.CoffeePokemon, error) {
+	rows, err := m.db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query coffee Pokemon: %w", err)
 	}
@@ -309,14 +509,14 @@ func (m *MySQLPokemonStorage) GetAllCoffeePokemon() ([]models.CoffeePokemon, err
 	
 	for rows.Next() {
 		var mapping models.CoffeePokemon
-		var traitMappingJSON []byte
+		var traitMappingJSON, provenanceJSON []byte
 		
 		err := rows.Scan(
-			&mapping.ID, &mapping.CoffeeID, &mapping.PokemonID,
+			&mapping.ID, &mapping.CoffeeID, &mapping.Dex, &mapping.PokemonID,
 			&mapping.Nickname, &mapping.Level,
 			&mapping.MappingConfidence, &mapping.LLMDescription,
 			&mapping.CreatedAt, &mapping.PokemonName,
-			&traitMappingJSON,
+			&traitMappingJSON, &provenanceJSON,
 		)
 		
 		if err != nil {
@@ -326,6 +526,9 @@ func (m *MySQLPokemonStorage) GetAllCoffeePokemon() ([]models.CoffeePokemon, err
 		if err := json.Unmarshal(traitMappingJSON, &mapping.TraitMapping); err != nil {
 			return nil, fmt.Errorf("failed to unmarshal trait mapping: %w", err)
 		}
+		if err := scanProvenance(provenanceJSON, &mapping); err != nil {
+			return nil, err
+		}
 		
 		mappings = append(mappings, mapping)
 	}
@@ -333,11 +536,24 @@ func (m *MySQLPokemonStorage) GetAllCoffeePokemon() ([]models.CoffeePokemon, err
 	return mappings, nil
 }
 
+// scanProvenance decodes a scanned provenance column, which is NULL for
+// catches made before provenance was recorded
+func scanProvenance(data []byte, mapping *models.CoffeePokemon) error {
+	if len(data) == 0 {
+		return nil
+	}
+	mapping.Provenance = &models.MappingProvenance{}
+	if err := json.Unmarshal(data, mapping.Provenance); err != nil {
+		return fmt.Errorf("failed to unmarshal provenance: %w", err)
+	}
+	return nil
+}
+
 // UpdateCoffeePokemonNickname updates the nickname of a Pokemon
-func (m *MySQLPokemonStorage) UpdateCoffeePokemonNickname(coffeeID, nickname string) error {
-	query := "UPDATE coffee_pokemon SET nickname = ? WHERE coffee_id = ?"
+func (m *MySQLPokemonStorage) UpdateCoffeePokemonNickname(coffeeID, dex, nickname string) error {
+	query := "UPDATE coffee_pokemon SET nickname = ? WHERE coffee_id = ? AND dex = ?"
 	
-	result, err := m.db.Exec(query, nickname, coffeeID)
+	result, err := m.db.Exec(query, nickname, coffeeID, dex)
 	if err != nil {
 		return fmt.Errorf("failed to update nickname: %w", err)
 	}
@@ -352,4 +568,58 @@ func (m *MySQLPokemonStorage) UpdateCoffeePokemonNickname(coffeeID, nickname str
 	}
 	
 	return nil
-}
\ No newline at end of file
+}
+
+// UpdateCoffeePokemonDescription replaces the Pokedex entry of a mapping
+func (m *MySQLPokemonStorage) UpdateCoffeePokemonDescription(coffeeID, dex, description string) error {
+	query := "UPDATE coffee_pokemon SET llm_description = ? WHERE coffee_id = ? AND dex = ?"
+	
+	result, err := m.db.Exec(query, description, coffeeID, dex)
+	if err != nil {
+		return fmt.Errorf("failed to update description: %w", err)
+	}
+	
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	
+	if rowsAffected == 0 {
+		return fmt.Errorf("Pokemon mapping not found for coffee")
+	}
+	
+	return nil
+}
+
+// UpsertPokemon inserts or replaces Pokemon reference rows in a single
+// transaction, so a re-sync is applied completely or not at all
+func (m *MySQLPokemonStorage) UpsertPokemon(pokemon []models.Pokemon) error {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	
+	query := `
+		INSERT INTO pokemons (id, name, type, sprite_path, base_stats, description)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			name = VALUES(name), type = VALUES(type), sprite_path = VALUES(sprite_path),
+			base_stats = VALUES(base_stats), description = VALUES(description)
+	`
+	
+	for _, p := range pokemon {
+		statsJSON, err := json.Marshal(p.BaseStats)
+		if err != nil {
+			return fmt.Errorf("failed to marshal stats: %w", err)
+		}
+		if _, err := tx.Exec(query, p.ID, p.Name, p.Type, p.SpritePath, statsJSON, p.Description); err != nil {
+			return fmt.Errorf("failed to save Pokemon %d: %w", p.ID, err)
+		}
+	}
+	
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit Pokemon data: %w", err)
+	}
+	return nil
+}
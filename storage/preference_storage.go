@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"sync"
+)
+
+// PreferenceStorage defines the interface for per-user settings such as
+// the default currency. Values are stored as strings under a name.
+type PreferenceStorage interface {
+	// GetPreference returns a user's setting, or "" if unset
+	GetPreference(userID, name string) (string, error)
+	SetPreference(userID, name, value string) error
+}
+
+// MemoryPreferenceStorage implements PreferenceStorage in memory
+type MemoryPreferenceStorage struct {
+	preferences map[string]map[string]string // user ID -> name -> value
+	mu          sync.RWMutex
+}
+
+// NewMemoryPreferenceStorage creates a new in-memory preference storage
+func NewMemoryPreferenceStorage() *MemoryPreferenceStorage {
+	return &MemoryPreferenceStorage{preferences: make(map[string]map[string]string)}
+}
+
+// GetPreference returns a user's setting, or "" if unset
+func (m *MemoryPreferenceStorage) GetPreference(userID, name string) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.preferences[userID][name], nil
+}
+
+// SetPreference records a user's setting
+func (m *MemoryPreferenceStorage) SetPreference(userID, name, value string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.preferences[userID] == nil {
+		m.preferences[userID] = make(map[string]string)
+	}
+	m.preferences[userID][name] = value
+	return nil
+}
+
+// MySQLPreferenceStorage implements PreferenceStorage using MySQL
+type MySQLPreferenceStorage struct {
+	db *sql.DB
+}
+
+// NewMySQLPreferenceStorage creates a new MySQL preference storage
+func NewMySQLPreferenceStorage(db *sql.DB) *MySQLPreferenceStorage {
+	storage := &MySQLPreferenceStorage{db: db}
+
+	if err := storage.initTables(); err != nil {
+		panic(fmt.Sprintf("failed to initialize preference tables: %v", err))
+	}
+
+	return storage
+}
+
+// initTables creates the user_preferences table if it doesn't exist
+func (m *MySQLPreferenceStorage) initTables() error {
+	query := `
+		CREATE TABLE IF NOT EXISTS user_preferences (
+			user_id VARCHAR(36) NOT NULL,
+			name VARCHAR(64) NOT NULL,
+			value VARCHAR(255) NOT NULL,
+			updated_at DATETIME,
+			PRIMARY KEY (user_id, name)
+		)
+	`
+	if _, err := m.db.Exec(query); err != nil {
+		log.Printf("ERROR: initTables - Failed to create user_preferences table: %v", err)
+		return fmt.Errorf("failed to create user_preferences table: %w", err)
+	}
+	return nil
+}
+
+// GetPreference returns a user's setting, or "" if unset
+func (m *MySQLPreferenceStorage) GetPreference(userID, name string) (string, error) {
+	var value string
+	err := m.db.QueryRow("SELECT value FROM user_preferences WHERE user_id = ? AND name = ?", userID, name).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get preference: %w", err)
+	}
+	return value, nil
+}
+
+// SetPreference records a user's setting
+func (m *MySQLPreferenceStorage) SetPreference(userID, name, value string) error {
+	query := `
+		INSERT INTO user_preferences (user_id, name, value, updated_at) VALUES (?, ?, ?, NOW())
+		ON DUPLICATE KEY UPDATE value = VALUES(value), updated_at = VALUES(updated_at)
+	`
+	if _, err := m.db.Exec(query, userID, name, value); err != nil {
+		return fmt.Errorf("failed to save preference: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,373 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"go-coffee-log/models"
+	"log"
+	"sync"
+)
+
+// DataPurger removes stored user data in one step
+type DataPurger interface {
+	PurgeAll() error
+	// PurgeUser deletes what one user keeps for themselves and removes
+	// their ID from what they added to the shared collection
+	PurgeUser(userID string) error
+}
+
+// purgeTables lists the user data tables, children before parents
+var purgeTables = []string{
+	"planned_brews",
+	"judge_scores",
+	"scoresheets",
+	"brews",
+	"comments",
+	"reactions",
+	"coffee_pokemon",
+	"coffee_photos",
+	"collection_coffees",
+	"collections",
+	"saved_searches",
+	"dex_selections",
+	"gym_badges",
+	"safari_attempts",
+	"subscription_eggs",
+	"subscriptions",
+	"mapping_disagreements",
+	"activity",
+	"user_preferences",
+	"daily_stats",
+	"tombstones",
+	"outbox",
+	"brewer_recipes",
+	"brewers",
+	"coffees",
+}
+
+// userTables are the tables whose rows belong to one user, by the column
+// holding their ID. Comments are deleted separately, with their replies.
+var userTables = []struct{ table, column string }{
+	{"brews", "brewed_by"},
+	{"reactions", "user_id"},
+	{"gym_badges", "user_id"},
+	{"safari_attempts", "user_id"},
+	{"dex_selections", "user_id"},
+	{"user_preferences", "user_id"},
+}
+
+// sharedUserTables are shared collection tables that only record which
+// user added a row; PurgeUser clears the column instead of deleting
+var sharedUserTables = []struct{ table, column string }{
+	{"coffees", "added_by"},
+	{"coffee_photos", "uploaded_by"},
+}
+
+// MySQLPurger implements DataPurger with a single MySQL transaction
+type MySQLPurger struct {
+	db *sql.DB
+}
+
+// NewMySQLPurger creates a new MySQL purger
+func NewMySQLPurger(db *sql.DB) *MySQLPurger {
+	return &MySQLPurger{db: db}
+}
+
+// PurgeAll deletes every row of user data, or nothing if any delete fails.
+// Reference data such as the pokemon table is kept.
+func (m *MySQLPurger) PurgeAll() error {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	existing, err := existingTables(tx)
+	if err != nil {
+		return err
+	}
+
+	for _, table := range purgeTables {
+		if !existing[table] {
+			continue
+		}
+		if _, err := tx.Exec("DELETE FROM " + table); err != nil {
+			return fmt.Errorf("failed to purge %s: %w", table, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit purge: %w", err)
+	}
+
+	log.Printf("INFO: Purged all user data")
+	return nil
+}
+
+// PurgeUser deletes a user's brews, comments with the replies below them,
+// reactions, badges, safari attempts and settings, and clears their ID
+// from the coffees and photos they added, or changes nothing if any
+// statement fails
+func (m *MySQLPurger) PurgeUser(userID string) error {
+	if userID == "" {
+		return fmt.Errorf("user ID is required")
+	}
+
+	tx, err := m.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	existing, err := existingTables(tx)
+	if err != nil {
+		return err
+	}
+
+	if existing["comments"] {
+		comments, err := commentAuthors(tx)
+		if err != nil {
+			return err
+		}
+		for _, id := range authoredThreads(comments, userID) {
+			if _, err := tx.Exec("DELETE FROM comments WHERE id = ?", id); err != nil {
+				return fmt.Errorf("failed to delete comment: %w", err)
+			}
+		}
+	}
+
+	for _, t := range userTables {
+		if !existing[t.table] {
+			continue
+		}
+		if _, err := tx.Exec("DELETE FROM "+t.table+" WHERE "+t.column+" = ?", userID); err != nil {
+			return fmt.Errorf("failed to purge %s: %w", t.table, err)
+		}
+	}
+	for _, t := range sharedUserTables {
+		if !existing[t.table] {
+			continue
+		}
+		if _, err := tx.Exec("UPDATE "+t.table+" SET "+t.column+" = '' WHERE "+t.column+" = ?", userID); err != nil {
+			return fmt.Errorf("failed to anonymize %s: %w", t.table, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit purge: %w", err)
+	}
+
+	log.Printf("INFO: Purged the data of user %s", userID)
+	return nil
+}
+
+// existingTables lists the tables of the database. Optional features create
+// their tables lazily, so purges only touch what exists.
+func existingTables(tx *sql.Tx) (map[string]bool, error) {
+	existing := make(map[string]bool)
+	rows, err := tx.Query("SELECT table_name FROM information_schema.tables WHERE table_schema = DATABASE()")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan table name: %w", err)
+		}
+		existing[name] = true
+	}
+	return existing, rows.Err()
+}
+
+// commentAuthors reads the thread structure of every comment
+func commentAuthors(tx *sql.Tx) ([]models.Comment, error) {
+	rows, err := tx.Query("SELECT id, COALESCE(parent_id, ''), COALESCE(author_id, '') FROM comments")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read comments: %w", err)
+	}
+	defer rows.Close()
+
+	var comments []models.Comment
+	for rows.Next() {
+		var comment models.Comment
+		if err := rows.Scan(&comment.ID, &comment.ParentID, &comment.AuthorID); err != nil {
+			return nil, fmt.Errorf("failed to scan comment: %w", err)
+		}
+		comments = append(comments, comment)
+	}
+	return comments, rows.Err()
+}
+
+// authoredThreads returns the IDs of authorID's comments and of every reply
+// below them, as deleting a comment removes its replies
+func authoredThreads(comments []models.Comment, authorID string) []string {
+	replies := make(map[string][]string)
+	var queue []string
+	for _, comment := range comments {
+		if comment.ParentID != "" {
+			replies[comment.ParentID] = append(replies[comment.ParentID], comment.ID)
+		}
+		if comment.AuthorID == authorID {
+			queue = append(queue, comment.ID)
+		}
+	}
+
+	seen := make(map[string]bool)
+	var ids []string
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+		queue = append(queue, replies[id]...)
+	}
+	return ids
+}
+
+// MemoryPurger implements DataPurger for the in-memory stores
+type MemoryPurger struct {
+	stores []MemoryPurgeable
+}
+
+// MemoryPurgeable is an in-memory store MemoryPurger can clear. Every
+// Memory*Storage of user data implements it.
+type MemoryPurgeable interface {
+	locker() sync.Locker
+	reset() // called with the lock held
+}
+
+// memoryUserPurgeable is a MemoryPurgeable store with rows naming a user
+type memoryUserPurgeable interface {
+	purgeUser(userID string) // called with the lock held
+}
+
+// NewMemoryPurger creates a new in-memory purger over every store of user
+// data: coffees, brews and comments, and any of the others the server uses
+func NewMemoryPurger(stores ...MemoryPurgeable) *MemoryPurger {
+	return &MemoryPurger{stores: stores}
+}
+
+// PurgeAll clears every store while holding all locks
+func (m *MemoryPurger) PurgeAll() error {
+	for _, store := range m.stores {
+		lock := store.locker()
+		lock.Lock()
+		defer lock.Unlock()
+	}
+	for _, store := range m.stores {
+		store.reset()
+	}
+	return nil
+}
+
+// PurgeUser removes a user's data from every store while holding all locks
+func (m *MemoryPurger) PurgeUser(userID string) error {
+	if userID == "" {
+		return fmt.Errorf("user ID is required")
+	}
+	for _, store := range m.stores {
+		lock := store.locker()
+		lock.Lock()
+		defer lock.Unlock()
+	}
+	for _, store := range m.stores {
+		if store, ok := store.(memoryUserPurgeable); ok {
+			store.purgeUser(userID)
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStorage) locker() sync.Locker { return &m.mu }
+func (m *MemoryStorage) reset()              { m.coffees = make(map[string]models.Coffee) }
+
+func (m *MemoryBrewStorage) locker() sync.Locker { return &m.mu }
+func (m *MemoryBrewStorage) reset()              { m.brews = make(map[string]models.Brew) }
+
+func (m *MemoryCommentStorage) locker() sync.Locker { return &m.mu }
+func (m *MemoryCommentStorage) reset()              { m.comments = make(map[string]models.Comment) }
+
+func (m *MemoryPhotoStorage) locker() sync.Locker { return &m.mu }
+func (m *MemoryPhotoStorage) reset()              { m.photos = make(map[string][]models.CoffeePhoto) }
+
+func (m *MemoryCollectionStorage) locker() sync.Locker { return &m.mu }
+func (m *MemoryCollectionStorage) reset()              { m.collections = make(map[string]models.Collection) }
+
+func (m *MemoryPlanStorage) locker() sync.Locker { return &m.mu }
+func (m *MemoryPlanStorage) reset()              { m.plans = make(map[string]models.PlannedBrew) }
+
+func (m *MemorySavedSearchStorage) locker() sync.Locker { return &m.mu }
+func (m *MemorySavedSearchStorage) reset()              { m.searches = make(map[string]models.SavedSearch) }
+
+func (m *MemorySubscriptionStorage) locker() sync.Locker { return &m.mu }
+func (m *MemorySubscriptionStorage) reset() {
+	m.subscriptions = make(map[string]models.Subscription)
+	m.eggs = make(map[string]models.Egg)
+}
+
+func (m *MemoryScoresheetStorage) locker() sync.Locker { return &m.mu }
+func (m *MemoryScoresheetStorage) reset() {
+	m.sheets = make(map[string]models.Scoresheet)
+	m.scores = make(map[string]map[string]models.JudgeScore)
+}
+
+func (m *MemoryActivityStorage) locker() sync.Locker { return &m.mu }
+func (m *MemoryActivityStorage) reset()              { m.activity = nil }
+
+func (m *MemoryPreferenceStorage) locker() sync.Locker { return &m.mu }
+func (m *MemoryPreferenceStorage) reset()              { m.preferences = make(map[string]map[string]string) }
+
+func (m *MemoryDailyStatsStorage) locker() sync.Locker { return &m.mu }
+func (m *MemoryDailyStatsStorage) reset()              { m.stats = nil }
+
+func (m *MemoryTombstoneStorage) locker() sync.Locker { return &m.mu }
+func (m *MemoryTombstoneStorage) reset()              { m.tombstones = make(map[string]models.Tombstone) }
+
+func (m *MemoryOutboxStorage) locker() sync.Locker { return &m.mu }
+func (m *MemoryOutboxStorage) reset()              { m.messages = make(map[string]models.OutboxMessage) }
+
+func (m *MemoryStorage) purgeUser(userID string) {
+	for id, coffee := range m.coffees {
+		if coffee.AddedBy == userID {
+			coffee.AddedBy = ""
+			m.coffees[id] = coffee
+		}
+	}
+}
+
+func (m *MemoryBrewStorage) purgeUser(userID string) {
+	for id, brew := range m.brews {
+		if brew.BrewedBy == userID {
+			delete(m.brews, id)
+		}
+	}
+}
+
+func (m *MemoryCommentStorage) purgeUser(userID string) {
+	comments := make([]models.Comment, 0, len(m.comments))
+	for _, comment := range m.comments {
+		comments = append(comments, comment)
+	}
+	for _, id := range authoredThreads(comments, userID) {
+		delete(m.comments, id)
+	}
+}
+
+func (m *MemoryPhotoStorage) purgeUser(userID string) {
+	for coffeeID, photos := range m.photos {
+		anonymized := make([]models.CoffeePhoto, len(photos))
+		for i, photo := range photos {
+			if photo.UploadedBy == userID {
+				photo.UploadedBy = ""
+			}
+			anonymized[i] = photo
+		}
+		m.photos[coffeeID] = anonymized
+	}
+}
+
+func (m *MemoryPreferenceStorage) purgeUser(userID string) { delete(m.preferences, userID) }
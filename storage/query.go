@@ -0,0 +1,359 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Limits for ad-hoc analytics queries
+const (
+	DefaultQueryRows = 100
+	MaxQueryRows     = 1000
+	QueryTimeout     = 10 * time.Second
+)
+
+// queryTables are the tables analytics queries may read. Users, invites
+// and the MySQL system schemas are deliberately absent.
+var queryTables = map[string]bool{
+	"coffees":               true,
+	"brews":                 true,
+	"brewers":               true,
+	"comments":              true,
+	"reactions":             true,
+	"coffee_pokemon":        true,
+	"dex_profiles":          true,
+	"gym_badges":            true,
+	"safari_attempts":       true,
+	"coffee_photos":         true,
+	"collections":           true,
+	"collection_coffees":    true,
+	"planned_brews":         true,
+	"subscriptions":         true,
+	"subscription_eggs":     true,
+	"scoresheets":           true,
+	"judge_scores":          true,
+	"pokemons":              true,
+	"mapping_disagreements": true,
+	"daily_stats":           true,
+}
+
+// deniedQueryWords may not appear anywhere in an analytics query: they
+// write data, take locks, read files or stall the server
+var deniedQueryWords = map[string]bool{
+	"INTO": true, "UPDATE": true, "SHARE": true, "LOCK": true,
+	"SLEEP": true, "BENCHMARK": true, "LOAD_FILE": true,
+	"GET_LOCK": true, "RELEASE_LOCK": true, "RELEASE_ALL_LOCKS": true,
+	"INFORMATION_SCHEMA": true, "PERFORMANCE_SCHEMA": true, "MYSQL": true, "SYS": true,
+	"USERS": true, "INVITES": true,
+}
+
+// deniedQueryKeywords read rows without a FROM or JOIN, as MySQL 8's
+// TABLE t and VALUES ROW(...) do, or name tables the allowlist can't see,
+// as a common table expression does. WITH ROLLUP is still allowed.
+var deniedQueryKeywords = map[string]bool{
+	"TABLE": true, "VALUES": true, "WITH": true,
+}
+
+// fromFunctions take FROM as an argument keyword, as in
+// EXTRACT(YEAR FROM created_at); any other parenthesis may hold a subquery
+var fromFunctions = map[string]bool{
+	"EXTRACT": true, "SUBSTRING": true, "SUBSTR": true, "TRIM": true,
+}
+
+// clauseWords end the table list of a FROM clause
+var clauseWords = map[string]bool{
+	"WHERE": true, "GROUP": true, "HAVING": true, "ORDER": true, "LIMIT": true,
+	"UNION": true, "WINDOW": true, "ON": true, "USING": true, "JOIN": true,
+	"INNER": true, "LEFT": true, "RIGHT": true, "CROSS": true, "NATURAL": true,
+	"STRAIGHT_JOIN": true,
+}
+
+// QueryResult is the outcome of an analytics query
+type QueryResult struct {
+	Columns   []string        `json:"columns"`
+	Rows      [][]interface{} `json:"rows"`
+	Truncated bool            `json:"truncated"`
+	ElapsedMS int64           `json:"elapsed_ms"`
+}
+
+// AnalyticsQuerier runs validated, read-only SQL for power users
+type AnalyticsQuerier interface {
+	Query(ctx context.Context, query string, limit int) (QueryResult, error)
+}
+
+// queryToken is one lexical token of a query
+type queryToken struct {
+	kind string // "word", "ident" (backquoted), "string", "number" or "punct"
+	text string // words are upper-cased
+}
+
+// tokenizeQuery splits a query into tokens. Comments are rejected because
+// MySQL executes /*! ... */ comments as code.
+func tokenizeQuery(query string) ([]queryToken, error) {
+	var tokens []queryToken
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '#' || strings.HasPrefix(query[i:], "--") || strings.HasPrefix(query[i:], "/*"):
+			return nil, fmt.Errorf("comments are not allowed")
+		case c == '\'' || c == '"' || c == '`':
+			end := i + 1
+			for ; end < len(query); end++ {
+				if query[end] == '\\' && c != '`' {
+					end++
+					continue
+				}
+				if query[end] == c {
+					if end+1 < len(query) && query[end+1] == c {
+						end++ // doubled quote
+						continue
+					}
+					break
+				}
+			}
+			if end >= len(query) {
+				return nil, fmt.Errorf("unterminated quoted text")
+			}
+			kind := "string"
+			if c == '`' {
+				kind = "ident"
+			}
+			tokens = append(tokens, queryToken{kind: kind, text: query[i+1 : end]})
+			i = end + 1
+		case isWordByte(c):
+			end := i
+			for end < len(query) && isWordByte(query[end]) {
+				end++
+			}
+			kind := "word"
+			if c >= '0' && c <= '9' {
+				kind = "number"
+			}
+			tokens = append(tokens, queryToken{kind: kind, text: strings.ToUpper(query[i:end])})
+			i = end
+		default:
+			tokens = append(tokens, queryToken{kind: "punct", text: string(c)})
+			i++
+		}
+	}
+	return tokens, nil
+}
+
+// isWordByte reports whether c can be part of an unquoted word
+func isWordByte(c byte) bool {
+	return c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// ValidateReadOnlyQuery checks that query is a single SELECT statement that
+// reads only the coffee tables
+func ValidateReadOnlyQuery(query string) error {
+	tokens, err := tokenizeQuery(query)
+	if err != nil {
+		return err
+	}
+	if n := len(tokens); n > 0 && tokens[n-1].text == ";" && tokens[n-1].kind == "punct" {
+		tokens = tokens[:n-1]
+	}
+	if len(tokens) == 0 {
+		return fmt.Errorf("query is empty")
+	}
+	if tokens[0].kind != "word" || tokens[0].text != "SELECT" {
+		return fmt.Errorf("only SELECT statements are allowed")
+	}
+
+	// Track whether each open parenthesis is a call to one of fromFunctions,
+	// where FROM is an argument keyword rather than the start of a table list
+	var inCall []bool
+	for i, token := range tokens {
+		switch token.kind {
+		case "punct":
+			switch token.text {
+			case "(":
+				call := i > 0 && tokens[i-1].kind == "word" && fromFunctions[tokens[i-1].text] &&
+					!(i+1 < len(tokens) && tokens[i+1].kind == "word" && tokens[i+1].text == "SELECT")
+				inCall = append(inCall, call)
+			case ")":
+				if len(inCall) > 0 {
+					inCall = inCall[:len(inCall)-1]
+				}
+			}
+			if token.text == ";" {
+				return fmt.Errorf("only a single statement is allowed")
+			}
+			if token.text == "@" {
+				return fmt.Errorf("variables are not allowed")
+			}
+		case "word", "ident":
+			name := strings.ToUpper(token.text)
+			rollup := name == "WITH" && i+1 < len(tokens) && tokens[i+1].kind == "word" && tokens[i+1].text == "ROLLUP"
+			if deniedQueryWords[name] || token.kind == "word" && deniedQueryKeywords[name] && !rollup {
+				return fmt.Errorf("%s is not allowed", strings.ToLower(name))
+			}
+			if len(inCall) > 0 && inCall[len(inCall)-1] {
+				continue
+			}
+			if token.kind == "word" && (name == "FROM" || name == "JOIN") {
+				if err := checkTableList(tokens, i+1, name == "FROM"); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// checkTableList validates the table references starting at tokens[start].
+// A FROM clause may list several tables separated by commas.
+func checkTableList(tokens []queryToken, start int, list bool) error {
+	i := start
+	for {
+		if i >= len(tokens) {
+			return fmt.Errorf("missing table name")
+		}
+		token := tokens[i]
+		switch {
+		case token.kind == "punct" && token.text == "(":
+			// Derived table: its own SELECT is checked separately. Other
+			// parenthesized references such as (outbox o) are not.
+			if i+1 >= len(tokens) || tokens[i+1].kind != "word" || tokens[i+1].text != "SELECT" {
+				return fmt.Errorf("parenthesized table references are not allowed")
+			}
+			i = skipParens(tokens, i)
+		case token.kind == "word" || token.kind == "ident":
+			if i+1 < len(tokens) && tokens[i+1].text == "." && tokens[i+1].kind == "punct" {
+				return fmt.Errorf("tables from other databases are not allowed")
+			}
+			if !queryTables[strings.ToLower(token.text)] {
+				return fmt.Errorf("table %s is not available for queries", strings.ToLower(token.text))
+			}
+			i++
+		default:
+			return fmt.Errorf("missing table name")
+		}
+		if !list {
+			return nil
+		}
+
+		// Skip an optional alias, then continue at a comma
+		for i < len(tokens) {
+			token := tokens[i]
+			if token.kind == "punct" && token.text == "," {
+				break
+			}
+			if token.kind == "punct" && token.text == ")" || token.kind == "word" && clauseWords[token.text] {
+				return nil
+			}
+			if token.kind == "punct" && token.text == "(" {
+				i = skipParens(tokens, i)
+				continue
+			}
+			i++
+		}
+		if i >= len(tokens) {
+			return nil
+		}
+		i++ // the comma
+	}
+}
+
+// skipParens returns the index just past the parenthesis opened at tokens[i]
+func skipParens(tokens []queryToken, i int) int {
+	depth := 0
+	for ; i < len(tokens); i++ {
+		if tokens[i].kind != "punct" {
+			continue
+		}
+		switch tokens[i].text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return i
+}
+
+// MySQLAnalyticsQuerier implements AnalyticsQuerier inside a read-only
+// transaction with a server-side execution time limit
+type MySQLAnalyticsQuerier struct {
+	db *sql.DB
+}
+
+// NewMySQLAnalyticsQuerier creates a new MySQL analytics querier
+func NewMySQLAnalyticsQuerier(db *sql.DB) *MySQLAnalyticsQuerier {
+	return &MySQLAnalyticsQuerier{db: db}
+}
+
+// Query validates and runs query, returning at most limit rows
+func (m *MySQLAnalyticsQuerier) Query(ctx context.Context, query string, limit int) (QueryResult, error) {
+	if err := ValidateReadOnlyQuery(query); err != nil {
+		return QueryResult{}, fmt.Errorf("invalid query: %w", err)
+	}
+	if limit <= 0 {
+		limit = DefaultQueryRows
+	}
+	if limit > MaxQueryRows {
+		limit = MaxQueryRows
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	// Let MySQL abort the query too, rather than only dropping the connection
+	query = strings.TrimSpace(query)
+	query = fmt.Sprintf("SELECT /*+ MAX_EXECUTION_TIME(%d) */%s", QueryTimeout.Milliseconds(), query[len("SELECT"):])
+
+	start := time.Now()
+	tx, err := m.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return QueryResult{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return QueryResult{}, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return QueryResult{}, fmt.Errorf("failed to read columns: %w", err)
+	}
+
+	result := QueryResult{Columns: columns, Rows: [][]interface{}{}}
+	for rows.Next() {
+		if len(result.Rows) == limit {
+			result.Truncated = true
+			break
+		}
+		values := make([]interface{}, len(columns))
+		targets := make([]interface{}, len(columns))
+		for i := range values {
+			targets[i] = &values[i]
+		}
+		if err := rows.Scan(targets...); err != nil {
+			return QueryResult{}, fmt.Errorf("failed to scan row: %w", err)
+		}
+		for i, value := range values {
+			if b, ok := value.([]byte); ok {
+				values[i] = string(b)
+			}
+		}
+		result.Rows = append(result.Rows, values)
+	}
+	if err := rows.Err(); err != nil {
+		return QueryResult{}, fmt.Errorf("query failed: %w", err)
+	}
+
+	result.ElapsedMS = time.Since(start).Milliseconds()
+	return result, nil
+}
@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"go-coffee-log/models"
+	"log"
+)
+
+// ReactionStorage defines the interface for emoji reactions on Pokedex entries
+type ReactionStorage interface {
+	// AddReaction is idempotent: reacting twice with the same emoji is a no-op
+	AddReaction(reaction models.Reaction) (bool, error)
+	RemoveReaction(coffeeID, userID, emoji string) error
+	// GetReactionCounts returns emoji counts keyed by coffee ID
+	GetReactionCounts() (map[string]map[string]int, error)
+	GetAllReactions() ([]models.Reaction, error)
+}
+
+// MySQLReactionStorage implements ReactionStorage using MySQL database
+type MySQLReactionStorage struct {
+	db *sql.DB
+}
+
+// NewMySQLReactionStorage creates a new MySQL reaction storage
+func NewMySQLReactionStorage(db *sql.DB) *MySQLReactionStorage {
+	storage := &MySQLReactionStorage{db: db}
+
+	if err := storage.initTables(); err != nil {
+		panic(fmt.Sprintf("failed to initialize reactions table: %v", err))
+	}
+
+	return storage
+}
+
+// initTables creates the reactions table if it doesn't exist
+func (m *MySQLReactionStorage) initTables() error {
+	query := `
+		CREATE TABLE IF NOT EXISTS reactions (
+			coffee_id VARCHAR(36) NOT NULL,
+			user_id VARCHAR(36) NOT NULL DEFAULT '',
+			emoji VARCHAR(16) NOT NULL,
+			created_at DATETIME,
+			PRIMARY KEY (coffee_id, user_id, emoji)
+		) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin
+	`
+
+	if _, err := m.db.Exec(query); err != nil {
+		log.Printf("ERROR: initTables - Failed to create reactions table: %v", err)
+		return fmt.Errorf("failed to create reactions table: %w", err)
+	}
+
+	return nil
+}
+
+// AddReaction stores a reaction and reports whether it is new
+func (m *MySQLReactionStorage) AddReaction(reaction models.Reaction) (bool, error) {
+	result, err := m.db.Exec(
+		"INSERT IGNORE INTO reactions (coffee_id, user_id, emoji, created_at) VALUES (?, ?, ?, ?)",
+		reaction.CoffeeID, reaction.UserID, reaction.Emoji, reaction.CreatedAt,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to save reaction: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected > 0, nil
+}
+
+// RemoveReaction deletes a user's reaction
+func (m *MySQLReactionStorage) RemoveReaction(coffeeID, userID, emoji string) error {
+	result, err := m.db.Exec(
+		"DELETE FROM reactions WHERE coffee_id = ? AND user_id = ? AND emoji = ?",
+		coffeeID, userID, emoji,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete reaction: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("reaction not found")
+	}
+
+	return nil
+}
+
+// GetReactionCounts returns emoji counts keyed by coffee ID
+func (m *MySQLReactionStorage) GetReactionCounts() (map[string]map[string]int, error) {
+	rows, err := m.db.Query("SELECT coffee_id, emoji, COUNT(*) FROM reactions GROUP BY coffee_id, emoji")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reaction counts: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]map[string]int)
+	for rows.Next() {
+		var coffeeID, emoji string
+		var count int
+		if err := rows.Scan(&coffeeID, &emoji, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan reaction count: %w", err)
+		}
+		if counts[coffeeID] == nil {
+			counts[coffeeID] = make(map[string]int)
+		}
+		counts[coffeeID][emoji] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return counts, nil
+}
+
+// GetAllReactions retrieves every reaction, oldest first
+func (m *MySQLReactionStorage) GetAllReactions() ([]models.Reaction, error) {
+	rows, err := m.db.Query("SELECT coffee_id, user_id, emoji, created_at FROM reactions ORDER BY created_at ASC")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reactions: %w", err)
+	}
+	defer rows.Close()
+
+	var reactions []models.Reaction
+	for rows.Next() {
+		var reaction models.Reaction
+		if err := rows.Scan(&reaction.CoffeeID, &reaction.UserID, &reaction.Emoji, &reaction.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan reaction: %w", err)
+		}
+		reactions = append(reactions, reaction)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return reactions, nil
+}
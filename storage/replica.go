@@ -0,0 +1,320 @@
+package storage
+
+import (
+	"go-coffee-log/models"
+	"log"
+	"strings"
+	"sync/atomic"
+)
+
+// ReplicaStats counts writes that reached the primary but not the secondary
+type ReplicaStats struct {
+	failures atomic.Int64
+}
+
+// Failures returns the number of failed secondary writes since startup
+func (s *ReplicaStats) Failures() int64 {
+	return s.failures.Load()
+}
+
+// record logs a failed secondary write
+func (s *ReplicaStats) record(op, id string, err error) {
+	s.failures.Add(1)
+	log.Printf("ERROR: Replica %s %s failed: %v", op, id, err)
+}
+
+// ReplicatedStorage implements CoffeeStorage by writing to a primary and
+// mirroring every successful write to a secondary. Reads only use the
+// primary; a failed secondary write is logged and counted, never returned,
+// and is left for the consistency checker to repair.
+type ReplicatedStorage struct {
+	Primary   CoffeeStorage
+	Secondary CoffeeStorage
+	stats     *ReplicaStats
+}
+
+// NewReplicatedStorage wraps a primary coffee store with a secondary copy
+func NewReplicatedStorage(primary, secondary CoffeeStorage, stats *ReplicaStats) *ReplicatedStorage {
+	return &ReplicatedStorage{Primary: primary, Secondary: secondary, stats: stats}
+}
+
+// Save stores a new coffee entry
+func (r *ReplicatedStorage) Save(coffee models.Coffee) error {
+	if err := r.Primary.Save(coffee); err != nil {
+		return err
+	}
+	if err := r.Secondary.Save(coffee); err != nil {
+		r.stats.record("save coffee", coffee.ID, err)
+	}
+	return nil
+}
+
+// GetByID retrieves a coffee by ID
+func (r *ReplicatedStorage) GetByID(id string) (models.Coffee, error) {
+	return r.Primary.GetByID(id)
+}
+
+// GetAll retrieves all coffees
+func (r *ReplicatedStorage) GetAll() ([]models.Coffee, error) {
+	return r.Primary.GetAll()
+}
+
+// GetRecent retrieves the most recent coffees
+func (r *ReplicatedStorage) GetRecent(limit int) ([]models.Coffee, error) {
+	return r.Primary.GetRecent(limit)
+}
+
+// Find retrieves the coffees matching a query and how many match in total
+func (r *ReplicatedStorage) Find(query CoffeeQuery) ([]models.Coffee, int, error) {
+	return r.Primary.Find(query)
+}
+
+// Update modifies an existing coffee entry
+func (r *ReplicatedStorage) Update(id string, coffee models.Coffee) error {
+	if err := r.Primary.Update(id, coffee); err != nil {
+		return err
+	}
+	err := r.Secondary.Update(id, coffee)
+	if err != nil && strings.Contains(err.Error(), "not found") {
+		// The secondary missed the original write; catch it up
+		err = r.Secondary.Save(coffee)
+	}
+	if err != nil {
+		r.stats.record("update coffee", id, err)
+	}
+	return nil
+}
+
+// Delete removes a coffee entry
+func (r *ReplicatedStorage) Delete(id string) error {
+	if err := r.Primary.Delete(id); err != nil {
+		return err
+	}
+	if err := r.Secondary.Delete(id); err != nil && !strings.Contains(err.Error(), "not found") {
+		r.stats.record("delete coffee", id, err)
+	}
+	return nil
+}
+
+// ReplicatedBrewStorage implements BrewStorage with the same dual-write
+// behaviour as ReplicatedStorage
+type ReplicatedBrewStorage struct {
+	Primary   BrewStorage
+	Secondary BrewStorage
+	stats     *ReplicaStats
+}
+
+// NewReplicatedBrewStorage wraps a primary brew store with a secondary copy
+func NewReplicatedBrewStorage(primary, secondary BrewStorage, stats *ReplicaStats) *ReplicatedBrewStorage {
+	return &ReplicatedBrewStorage{Primary: primary, Secondary: secondary, stats: stats}
+}
+
+// SaveBrew stores a brew session
+func (r *ReplicatedBrewStorage) SaveBrew(brew models.Brew) error {
+	if err := r.Primary.SaveBrew(brew); err != nil {
+		return err
+	}
+	if err := r.Secondary.SaveBrew(brew); err != nil {
+		r.stats.record("save brew", brew.ID, err)
+	}
+	return nil
+}
+
+// GetBrewsByCoffee retrieves all brews of a coffee, oldest first
+func (r *ReplicatedBrewStorage) GetBrewsByCoffee(coffeeID string) ([]models.Brew, error) {
+	return r.Primary.GetBrewsByCoffee(coffeeID)
+}
+
+// GetAllBrews retrieves every brew, oldest first
+func (r *ReplicatedBrewStorage) GetAllBrews() ([]models.Brew, error) {
+	return r.Primary.GetAllBrews()
+}
+
+// DeleteBrew removes a brew session
+func (r *ReplicatedBrewStorage) DeleteBrew(id string) error {
+	if err := r.Primary.DeleteBrew(id); err != nil {
+		return err
+	}
+	if err := r.Secondary.DeleteBrew(id); err != nil && !strings.Contains(err.Error(), "not found") {
+		r.stats.record("delete brew", id, err)
+	}
+	return nil
+}
+
+// ReplicatedPurger purges the primary and then empties the secondary copy
+// so purged data does not survive in the replica
+type ReplicatedPurger struct {
+	primary DataPurger
+	coffees CoffeeStorage
+	brews   BrewStorage
+	stats   *ReplicaStats
+}
+
+// NewReplicatedPurger wraps a primary purger; coffees and brews are the
+// secondary stores
+func NewReplicatedPurger(primary DataPurger, coffees CoffeeStorage, brews BrewStorage, stats *ReplicaStats) *ReplicatedPurger {
+	return &ReplicatedPurger{primary: primary, coffees: coffees, brews: brews, stats: stats}
+}
+
+// PurgeAll purges the primary, then deletes every coffee and brew from the secondary
+func (r *ReplicatedPurger) PurgeAll() error {
+	if err := r.primary.PurgeAll(); err != nil {
+		return err
+	}
+
+	brews, err := r.brews.GetAllBrews()
+	if err != nil {
+		r.stats.record("purge", "brews", err)
+	}
+	for _, brew := range brews {
+		if err := r.brews.DeleteBrew(brew.ID); err != nil {
+			r.stats.record("purge brew", brew.ID, err)
+		}
+	}
+
+	coffees, err := r.coffees.GetAll()
+	if err != nil {
+		r.stats.record("purge", "coffees", err)
+	}
+	for _, coffee := range coffees {
+		if err := r.coffees.Delete(coffee.ID); err != nil {
+			r.stats.record("purge coffee", coffee.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// PurgeUser purges the user in the primary, then deletes their brews from
+// the secondary and clears their ID from its coffees
+func (r *ReplicatedPurger) PurgeUser(userID string) error {
+	if err := r.primary.PurgeUser(userID); err != nil {
+		return err
+	}
+
+	brews, err := r.brews.GetAllBrews()
+	if err != nil {
+		r.stats.record("purge user", "brews", err)
+	}
+	for _, brew := range brews {
+		if brew.BrewedBy != userID {
+			continue
+		}
+		if err := r.brews.DeleteBrew(brew.ID); err != nil {
+			r.stats.record("purge brew", brew.ID, err)
+		}
+	}
+
+	// Update never changes who added a coffee, so replace the row
+	coffees, err := r.coffees.GetAll()
+	if err != nil {
+		r.stats.record("purge user", "coffees", err)
+	}
+	for _, coffee := range coffees {
+		if coffee.AddedBy != userID {
+			continue
+		}
+		coffee.AddedBy = ""
+		if err := r.coffees.Delete(coffee.ID); err != nil {
+			r.stats.record("purge coffee", coffee.ID, err)
+			continue
+		}
+		if err := r.coffees.Save(coffee); err != nil {
+			r.stats.record("purge coffee", coffee.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// ReplicatedMerger merges in the primary and then repeats the merge on the
+// secondary copy, so the duplicate does not survive in the replica
+type ReplicatedMerger struct {
+	primary CoffeeMerger
+	coffees CoffeeStorage
+	brews   BrewStorage
+	stats   *ReplicaStats
+}
+
+// NewReplicatedMerger wraps a primary merger; coffees and brews are the
+// secondary stores
+func NewReplicatedMerger(primary CoffeeMerger, coffees CoffeeStorage, brews BrewStorage, stats *ReplicaStats) *ReplicatedMerger {
+	return &ReplicatedMerger{primary: primary, coffees: coffees, brews: brews, stats: stats}
+}
+
+// MergeCoffee merges in the primary, then moves the duplicate's brews to the
+// kept coffee and deletes the duplicate in the secondary
+func (r *ReplicatedMerger) MergeCoffee(keepID, duplicateID string, dropCatches []CatchRef) error {
+	if err := r.primary.MergeCoffee(keepID, duplicateID, dropCatches); err != nil {
+		return err
+	}
+
+	brews, err := r.brews.GetBrewsByCoffee(duplicateID)
+	if err != nil {
+		r.stats.record("merge", duplicateID, err)
+	}
+	for _, brew := range brews {
+		if err := r.brews.DeleteBrew(brew.ID); err != nil {
+			r.stats.record("merge brew", brew.ID, err)
+			continue
+		}
+		brew.CoffeeID = keepID
+		if err := r.brews.SaveBrew(brew); err != nil {
+			r.stats.record("merge brew", brew.ID, err)
+		}
+	}
+
+	if err := r.coffees.Delete(duplicateID); err != nil {
+		r.stats.record("merge coffee", duplicateID, err)
+	}
+
+	return nil
+}
+
+// ReplicatedBatcher applies a batch in the primary and then repeats its
+// writes on the secondary copy, one at a time
+type ReplicatedBatcher struct {
+	primary CoffeeBatcher
+	coffees CoffeeStorage
+	stats   *ReplicaStats
+}
+
+// NewReplicatedBatcher wraps a primary batcher; coffees is the secondary store
+func NewReplicatedBatcher(primary CoffeeBatcher, coffees CoffeeStorage, stats *ReplicaStats) *ReplicatedBatcher {
+	return &ReplicatedBatcher{primary: primary, coffees: coffees, stats: stats}
+}
+
+// ApplyCoffeeWrites applies the batch in the primary, then mirrors each write
+func (r *ReplicatedBatcher) ApplyCoffeeWrites(writes []CoffeeWrite) error {
+	if err := r.primary.ApplyCoffeeWrites(writes); err != nil {
+		return err
+	}
+
+	for _, write := range writes {
+		var err error
+		switch write.Kind {
+		case CoffeeWriteSave:
+			err = r.coffees.Save(write.Coffee)
+		case CoffeeWriteUpdate:
+			err = r.coffees.Update(write.ID, write.Coffee)
+			if err != nil && strings.Contains(err.Error(), "not found") {
+				// The secondary missed the original write; catch it up
+				err = r.coffees.Save(write.Coffee)
+			}
+		case CoffeeWriteDelete:
+			err = r.coffees.Delete(write.ID)
+			if err != nil && strings.Contains(err.Error(), "not found") {
+				err = nil
+			}
+		}
+		if err != nil {
+			id := write.ID
+			if id == "" {
+				id = write.Coffee.ID
+			}
+			r.stats.record(write.Kind+" coffee", id, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,158 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"go-coffee-log/models"
+	"log"
+)
+
+// RulePackStorage defines the interface for imported mapper rule packs. At
+// most one pack is active; none means the built-in rules are used.
+type RulePackStorage interface {
+	SavePack(pack models.RulePack) error
+	GetPack(name string) (models.RulePack, error)
+	GetAllPacks() ([]models.RulePack, error)
+	SetActivePack(name string) error
+	DeletePack(name string) error
+}
+
+// MySQLRulePackStorage implements RulePackStorage using MySQL database
+type MySQLRulePackStorage struct {
+	db *sql.DB
+}
+
+// NewMySQLRulePackStorage creates a new MySQL rule pack storage
+func NewMySQLRulePackStorage(db *sql.DB) *MySQLRulePackStorage {
+	storage := &MySQLRulePackStorage{db: db}
+
+	if err := storage.initTables(); err != nil {
+		panic(fmt.Sprintf("failed to initialize rule pack tables: %v", err))
+	}
+
+	return storage
+}
+
+// initTables creates the mapper_rule_packs table if it doesn't exist
+func (m *MySQLRulePackStorage) initTables() error {
+	query := `
+		CREATE TABLE IF NOT EXISTS mapper_rule_packs (
+			name VARCHAR(50) PRIMARY KEY,
+			version VARCHAR(20) NOT NULL,
+			author VARCHAR(100) NOT NULL,
+			description TEXT,
+			rules JSON NOT NULL,
+			active BOOLEAN NOT NULL DEFAULT FALSE,
+			imported_at DATETIME
+		)
+	`
+
+	if _, err := m.db.Exec(query); err != nil {
+		log.Printf("ERROR: initTables - Failed to create mapper_rule_packs table: %v", err)
+		return fmt.Errorf("failed to create mapper_rule_packs table: %w", err)
+	}
+
+	return nil
+}
+
+// rulePackColumns is the column list scanRulePack expects
+const rulePackColumns = "name, version, author, description, rules, active, imported_at"
+
+// scanRulePack reads one rule pack row
+func scanRulePack(scanner interface{ Scan(...interface{}) error }) (models.RulePack, error) {
+	var pack models.RulePack
+	var description sql.NullString
+	var rulesJSON []byte
+	if err := scanner.Scan(&pack.Name, &pack.Version, &pack.Author, &description, &rulesJSON,
+		&pack.Active, &pack.ImportedAt); err != nil {
+		return models.RulePack{}, err
+	}
+	pack.Description = description.String
+	if err := json.Unmarshal(rulesJSON, &pack.Rules); err != nil {
+		return models.RulePack{}, fmt.Errorf("failed to unmarshal rules: %w", err)
+	}
+	return pack, nil
+}
+
+// SavePack stores a pack, replacing an earlier import with the same name.
+// A replaced pack stays active if it was.
+func (m *MySQLRulePackStorage) SavePack(pack models.RulePack) error {
+	rulesJSON, err := json.Marshal(pack.Rules)
+	if err != nil {
+		return fmt.Errorf("failed to marshal rules: %w", err)
+	}
+
+	query := `
+		INSERT INTO mapper_rule_packs (` + rulePackColumns + `)
+		VALUES (?, ?, ?, ?, ?, FALSE, ?)
+		ON DUPLICATE KEY UPDATE version = VALUES(version), author = VALUES(author),
+			description = VALUES(description), rules = VALUES(rules), imported_at = VALUES(imported_at)
+	`
+	if _, err := m.db.Exec(query, pack.Name, pack.Version, pack.Author, pack.Description, rulesJSON, pack.ImportedAt); err != nil {
+		return fmt.Errorf("failed to save rule pack: %w", err)
+	}
+	return nil
+}
+
+// GetPack retrieves a pack by name
+func (m *MySQLRulePackStorage) GetPack(name string) (models.RulePack, error) {
+	pack, err := scanRulePack(m.db.QueryRow("SELECT "+rulePackColumns+" FROM mapper_rule_packs WHERE name = ?", name))
+	if err == sql.ErrNoRows {
+		return models.RulePack{}, fmt.Errorf("rule pack not found")
+	}
+	if err != nil {
+		return models.RulePack{}, fmt.Errorf("failed to get rule pack: %w", err)
+	}
+	return pack, nil
+}
+
+// GetAllPacks returns every imported pack, by name
+func (m *MySQLRulePackStorage) GetAllPacks() ([]models.RulePack, error) {
+	rows, err := m.db.Query("SELECT " + rulePackColumns + " FROM mapper_rule_packs ORDER BY name ASC")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rule packs: %w", err)
+	}
+	defer rows.Close()
+
+	packs := []models.RulePack{}
+	for rows.Next() {
+		pack, err := scanRulePack(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan rule pack: %w", err)
+		}
+		packs = append(packs, pack)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return packs, nil
+}
+
+// SetActivePack marks one pack active and every other inactive; an empty
+// name deactivates them all
+func (m *MySQLRulePackStorage) SetActivePack(name string) error {
+	if name != "" {
+		if _, err := m.GetPack(name); err != nil {
+			return err
+		}
+	}
+	if _, err := m.db.Exec("UPDATE mapper_rule_packs SET active = (name = ?)", name); err != nil {
+		return fmt.Errorf("failed to activate rule pack: %w", err)
+	}
+	return nil
+}
+
+// DeletePack removes a pack
+func (m *MySQLRulePackStorage) DeletePack(name string) error {
+	result, err := m.db.Exec("DELETE FROM mapper_rule_packs WHERE name = ?", name)
+	if err != nil {
+		return fmt.Errorf("failed to delete rule pack: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return fmt.Errorf("rule pack not found")
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"go-coffee-log/models"
+	"log"
+	"time"
+)
+
+// SafariStorage defines the interface for Safari Zone attempts
+type SafariStorage interface {
+	SaveAttempt(attempt models.SafariAttempt) error
+	GetAttempt(id string) (models.SafariAttempt, error)
+	// GetAttempts returns a user's attempts, newest first
+	GetAttempts(userID string) ([]models.SafariAttempt, error)
+	// GetOpenAttempts returns every user's uncaught attempts that expire
+	// after now, oldest first
+	GetOpenAttempts(now time.Time) ([]models.SafariAttempt, error)
+	// CatchAttempt records the coffee that caught an attempt's Pokemon. It
+	// is idempotent: an attempt is caught once, and catching it again
+	// reports false.
+	CatchAttempt(id, coffeeID string, level int, caughtAt time.Time) (bool, error)
+}
+
+// MySQLSafariStorage implements SafariStorage using MySQL database
+type MySQLSafariStorage struct {
+	db *sql.DB
+}
+
+// NewMySQLSafariStorage creates a new MySQL safari storage
+func NewMySQLSafariStorage(db *sql.DB) *MySQLSafariStorage {
+	storage := &MySQLSafariStorage{db: db}
+
+	if err := storage.initTables(); err != nil {
+		panic(fmt.Sprintf("failed to initialize safari_attempts table: %v", err))
+	}
+
+	return storage
+}
+
+// initTables creates the safari_attempts table if it doesn't exist
+func (m *MySQLSafariStorage) initTables() error {
+	query := `
+		CREATE TABLE IF NOT EXISTS safari_attempts (
+			id VARCHAR(36) PRIMARY KEY,
+			user_id VARCHAR(36) NOT NULL DEFAULT '',
+			dex VARCHAR(36) NOT NULL,
+			pokemon_id INT NOT NULL,
+			pokemon_name VARCHAR(50) NOT NULL,
+			pokemon_type VARCHAR(20) NOT NULL,
+			target JSON NOT NULL,
+			hints JSON,
+			coffee_id VARCHAR(36),
+			level INT NOT NULL DEFAULT 0,
+			expires_at DATETIME NOT NULL,
+			caught_at DATETIME,
+			created_at DATETIME,
+			INDEX idx_safari_user (user_id, created_at)
+		)
+	`
+
+	if _, err := m.db.Exec(query); err != nil {
+		log.Printf("ERROR: initTables - Failed to create safari_attempts table: %v", err)
+		return fmt.Errorf("failed to create safari_attempts table: %w", err)
+	}
+
+	return nil
+}
+
+const safariColumns = `id, user_id, dex, pokemon_id, pokemon_name, pokemon_type, target, hints,
+	coffee_id, level, expires_at, caught_at, created_at`
+
+// SaveAttempt stores a new attempt
+func (m *MySQLSafariStorage) SaveAttempt(attempt models.SafariAttempt) error {
+	target, err := json.Marshal(attempt.Target)
+	if err != nil {
+		return fmt.Errorf("failed to marshal safari target: %w", err)
+	}
+	hints, err := json.Marshal(attempt.Hints)
+	if err != nil {
+		return fmt.Errorf("failed to marshal safari hints: %w", err)
+	}
+
+	_, err = m.db.Exec(
+		`INSERT INTO safari_attempts (id, user_id, dex, pokemon_id, pokemon_name, pokemon_type, target, hints, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		attempt.ID, attempt.UserID, attempt.Dex, attempt.PokemonID, attempt.PokemonName, attempt.Type,
+		target, hints, attempt.ExpiresAt, attempt.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save safari attempt: %w", err)
+	}
+	return nil
+}
+
+// GetAttempt retrieves an attempt by ID
+func (m *MySQLSafariStorage) GetAttempt(id string) (models.SafariAttempt, error) {
+	attempts, err := m.query(`SELECT `+safariColumns+` FROM safari_attempts WHERE id = ?`, id)
+	if err != nil {
+		return models.SafariAttempt{}, err
+	}
+	if len(attempts) == 0 {
+		return models.SafariAttempt{}, fmt.Errorf("safari attempt not found")
+	}
+	return attempts[0], nil
+}
+
+// GetAttempts returns a user's attempts, newest first
+func (m *MySQLSafariStorage) GetAttempts(userID string) ([]models.SafariAttempt, error) {
+	return m.query(`SELECT `+safariColumns+` FROM safari_attempts WHERE user_id = ? ORDER BY created_at DESC`, userID)
+}
+
+// GetOpenAttempts returns the uncaught attempts that expire after now
+func (m *MySQLSafariStorage) GetOpenAttempts(now time.Time) ([]models.SafariAttempt, error) {
+	return m.query(
+		`SELECT `+safariColumns+` FROM safari_attempts
+		WHERE caught_at IS NULL AND expires_at > ? ORDER BY created_at ASC`,
+		now,
+	)
+}
+
+// CatchAttempt marks an uncaught attempt as caught by a coffee
+func (m *MySQLSafariStorage) CatchAttempt(id, coffeeID string, level int, caughtAt time.Time) (bool, error) {
+	result, err := m.db.Exec(
+		`UPDATE safari_attempts SET coffee_id = ?, level = ?, caught_at = ? WHERE id = ? AND caught_at IS NULL`,
+		coffeeID, level, caughtAt, id,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to catch safari attempt: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected > 0, nil
+}
+
+// query scans the attempts a SELECT of safariColumns returns
+func (m *MySQLSafariStorage) query(query string, args ...interface{}) ([]models.SafariAttempt, error) {
+	rows, err := m.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query safari attempts: %w", err)
+	}
+	defer rows.Close()
+
+	attempts := []models.SafariAttempt{}
+	for rows.Next() {
+		var attempt models.SafariAttempt
+		var target, hints []byte
+		var coffeeID sql.NullString
+		var caughtAt sql.NullTime
+		if err := rows.Scan(&attempt.ID, &attempt.UserID, &attempt.Dex, &attempt.PokemonID, &attempt.PokemonName,
+			&attempt.Type, &target, &hints, &coffeeID, &attempt.Level, &attempt.ExpiresAt, &caughtAt, &attempt.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan safari attempt: %w", err)
+		}
+		if err := json.Unmarshal(target, &attempt.Target); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal safari target: %w", err)
+		}
+		if len(hints) > 0 {
+			if err := json.Unmarshal(hints, &attempt.Hints); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal safari hints: %w", err)
+			}
+		}
+		attempt.CoffeeID = coffeeID.String
+		if caughtAt.Valid {
+			attempt.CaughtAt = &caughtAt.Time
+		}
+		attempts = append(attempts, attempt)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return attempts, nil
+}
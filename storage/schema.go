@@ -0,0 +1,183 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// SchemaColumn describes one column of a table
+type SchemaColumn struct {
+	Name     string  `json:"name"`
+	Type     string  `json:"type"`
+	Nullable bool    `json:"nullable"`
+	Key      string  `json:"key,omitempty"` // "PRI", "UNI" or "MUL"
+	Default  *string `json:"default,omitempty"`
+}
+
+// SchemaTable describes one table
+type SchemaTable struct {
+	Name    string         `json:"name"`
+	Columns []SchemaColumn `json:"columns"`
+}
+
+// SchemaRelation is a reference from one column to another table's column.
+// Enforced relations are foreign keys; the rest are honoured by the
+// application only, but are just as useful for joins.
+type SchemaRelation struct {
+	FromTable  string `json:"from_table"`
+	FromColumn string `json:"from_column"`
+	ToTable    string `json:"to_table"`
+	ToColumn   string `json:"to_column"`
+	Enforced   bool   `json:"enforced"`
+}
+
+// Schema is the current table layout and the relationships between tables
+type Schema struct {
+	Tables    []SchemaTable    `json:"tables"`
+	Relations []SchemaRelation `json:"relations"`
+}
+
+// SchemaInspector reads the live database schema
+type SchemaInspector interface {
+	Schema() (Schema, error)
+}
+
+// applicationRelations are references the application maintains without a
+// foreign key constraint
+var applicationRelations = []SchemaRelation{
+	{FromTable: "brews", FromColumn: "coffee_id", ToTable: "coffees", ToColumn: "id"},
+	{FromTable: "brews", FromColumn: "brewed_by", ToTable: "users", ToColumn: "id"},
+	{FromTable: "comments", FromColumn: "coffee_id", ToTable: "coffees", ToColumn: "id"},
+	{FromTable: "comments", FromColumn: "parent_id", ToTable: "comments", ToColumn: "id"},
+	{FromTable: "comments", FromColumn: "author_id", ToTable: "users", ToColumn: "id"},
+	{FromTable: "reactions", FromColumn: "coffee_id", ToTable: "coffees", ToColumn: "id"},
+	{FromTable: "reactions", FromColumn: "user_id", ToTable: "users", ToColumn: "id"},
+	{FromTable: "mapping_disagreements", FromColumn: "coffee_id", ToTable: "coffees", ToColumn: "id"},
+	{FromTable: "invites", FromColumn: "created_by", ToTable: "users", ToColumn: "id"},
+	{FromTable: "invites", FromColumn: "used_by", ToTable: "users", ToColumn: "id"},
+}
+
+// MySQLSchemaInspector implements SchemaInspector using information_schema
+type MySQLSchemaInspector struct {
+	db *sql.DB
+}
+
+// NewMySQLSchemaInspector creates a new MySQL schema inspector
+func NewMySQLSchemaInspector(db *sql.DB) *MySQLSchemaInspector {
+	return &MySQLSchemaInspector{db: db}
+}
+
+// Schema lists every table of the current database with its columns, the
+// declared foreign keys and the application-level references between them
+func (m *MySQLSchemaInspector) Schema() (Schema, error) {
+	rows, err := m.db.Query(`
+		SELECT table_name, column_name, column_type, is_nullable, column_key, column_default
+		FROM information_schema.columns
+		WHERE table_schema = DATABASE()
+		ORDER BY table_name, ordinal_position
+	`)
+	if err != nil {
+		return Schema{}, fmt.Errorf("failed to query columns: %w", err)
+	}
+	defer rows.Close()
+
+	schema := Schema{Tables: []SchemaTable{}, Relations: []SchemaRelation{}}
+	columns := make(map[string]bool) // "table.column"
+	for rows.Next() {
+		var table, nullable string
+		var column SchemaColumn
+		var def sql.NullString
+		if err := rows.Scan(&table, &column.Name, &column.Type, &nullable, &column.Key, &def); err != nil {
+			return Schema{}, fmt.Errorf("failed to scan column: %w", err)
+		}
+		column.Nullable = nullable == "YES"
+		if def.Valid {
+			column.Default = &def.String
+		}
+
+		if n := len(schema.Tables); n == 0 || schema.Tables[n-1].Name != table {
+			schema.Tables = append(schema.Tables, SchemaTable{Name: table})
+		}
+		last := &schema.Tables[len(schema.Tables)-1]
+		last.Columns = append(last.Columns, column)
+		columns[table+"."+column.Name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return Schema{}, fmt.Errorf("error iterating columns: %w", err)
+	}
+
+	fkRows, err := m.db.Query(`
+		SELECT table_name, column_name, referenced_table_name, referenced_column_name
+		FROM information_schema.key_column_usage
+		WHERE table_schema = DATABASE() AND referenced_table_name IS NOT NULL
+		ORDER BY table_name, column_name
+	`)
+	if err != nil {
+		return Schema{}, fmt.Errorf("failed to query foreign keys: %w", err)
+	}
+	defer fkRows.Close()
+
+	declared := make(map[string]bool)
+	for fkRows.Next() {
+		relation := SchemaRelation{Enforced: true}
+		if err := fkRows.Scan(&relation.FromTable, &relation.FromColumn, &relation.ToTable, &relation.ToColumn); err != nil {
+			return Schema{}, fmt.Errorf("failed to scan foreign key: %w", err)
+		}
+		schema.Relations = append(schema.Relations, relation)
+		declared[relation.FromTable+"."+relation.FromColumn] = true
+	}
+	if err := fkRows.Err(); err != nil {
+		return Schema{}, fmt.Errorf("error iterating foreign keys: %w", err)
+	}
+
+	// Only describe references between tables this database actually has
+	for _, relation := range applicationRelations {
+		from := relation.FromTable + "." + relation.FromColumn
+		if declared[from] || !columns[from] || !columns[relation.ToTable+"."+relation.ToColumn] {
+			continue
+		}
+		schema.Relations = append(schema.Relations, relation)
+	}
+
+	return schema, nil
+}
+
+// DOT renders the schema as a Graphviz entity-relationship diagram
+func (s Schema) DOT() string {
+	var b strings.Builder
+	b.WriteString("digraph schema {\n")
+	b.WriteString("\trankdir=LR;\n")
+	b.WriteString("\tnode [shape=plaintext];\n")
+
+	for _, table := range s.Tables {
+		fmt.Fprintf(&b, "\t%q [label=<<table border=\"0\" cellborder=\"1\" cellspacing=\"0\">", table.Name)
+		fmt.Fprintf(&b, "<tr><td bgcolor=\"lightgrey\"><b>%s</b></td></tr>", dotEscape(table.Name))
+		for _, column := range table.Columns {
+			name := dotEscape(column.Name)
+			if column.Key == "PRI" {
+				name = "<u>" + name + "</u>"
+			}
+			fmt.Fprintf(&b, "<tr><td port=%q align=\"left\">%s <font color=\"grey40\">%s</font></td></tr>",
+				column.Name, name, dotEscape(column.Type))
+		}
+		b.WriteString("</table>>];\n")
+	}
+
+	for _, relation := range s.Relations {
+		style := "solid"
+		if !relation.Enforced {
+			style = "dashed"
+		}
+		fmt.Fprintf(&b, "\t%q:%q -> %q:%q [style=%s];\n",
+			relation.FromTable, relation.FromColumn, relation.ToTable, relation.ToColumn, style)
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// dotEscape escapes text for a Graphviz HTML-like label
+func dotEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;").Replace(s)
+}
@@ -0,0 +1,319 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"go-coffee-log/models"
+	"log"
+	"sort"
+	"sync"
+)
+
+// ScoresheetStorage defines the interface for competition scoresheets and
+// the judges' scores on them
+type ScoresheetStorage interface {
+	SaveScoresheet(sheet models.Scoresheet) error
+	GetScoresheet(id string) (models.Scoresheet, error)
+	GetAllScoresheets() ([]models.Scoresheet, error)
+	DeleteScoresheet(id string) error
+
+	// SaveJudgeScore adds a judge's scores or replaces the judge's earlier ones
+	SaveJudgeScore(score models.JudgeScore) error
+	GetJudgeScores(sheetID string) ([]models.JudgeScore, error)
+	DeleteJudgeScore(sheetID, judge string) error
+}
+
+// MemoryScoresheetStorage implements ScoresheetStorage in memory
+type MemoryScoresheetStorage struct {
+	sheets map[string]models.Scoresheet
+	scores map[string]map[string]models.JudgeScore // sheet ID -> judge -> scores
+	mu     sync.RWMutex
+}
+
+// NewMemoryScoresheetStorage creates a new in-memory scoresheet storage
+func NewMemoryScoresheetStorage() *MemoryScoresheetStorage {
+	return &MemoryScoresheetStorage{
+		sheets: make(map[string]models.Scoresheet),
+		scores: make(map[string]map[string]models.JudgeScore),
+	}
+}
+
+// SaveScoresheet stores a new scoresheet
+func (m *MemoryScoresheetStorage) SaveScoresheet(sheet models.Scoresheet) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sheets[sheet.ID] = sheet
+	return nil
+}
+
+// GetScoresheet retrieves a scoresheet by ID
+func (m *MemoryScoresheetStorage) GetScoresheet(id string) (models.Scoresheet, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	sheet, ok := m.sheets[id]
+	if !ok {
+		return models.Scoresheet{}, fmt.Errorf("scoresheet not found")
+	}
+	return sheet, nil
+}
+
+// GetAllScoresheets returns every scoresheet, oldest first
+func (m *MemoryScoresheetStorage) GetAllScoresheets() ([]models.Scoresheet, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	sheets := make([]models.Scoresheet, 0, len(m.sheets))
+	for _, sheet := range m.sheets {
+		sheets = append(sheets, sheet)
+	}
+	sort.Slice(sheets, func(i, j int) bool { return sheets[i].CreatedAt.Before(sheets[j].CreatedAt) })
+	return sheets, nil
+}
+
+// DeleteScoresheet removes a scoresheet and its scores
+func (m *MemoryScoresheetStorage) DeleteScoresheet(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.sheets[id]; !ok {
+		return fmt.Errorf("scoresheet not found")
+	}
+	delete(m.sheets, id)
+	delete(m.scores, id)
+	return nil
+}
+
+// SaveJudgeScore adds or replaces a judge's scores
+func (m *MemoryScoresheetStorage) SaveJudgeScore(score models.JudgeScore) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.sheets[score.ScoresheetID]; !ok {
+		return fmt.Errorf("scoresheet not found")
+	}
+	if m.scores[score.ScoresheetID] == nil {
+		m.scores[score.ScoresheetID] = make(map[string]models.JudgeScore)
+	}
+	m.scores[score.ScoresheetID][score.Judge] = score
+	return nil
+}
+
+// GetJudgeScores returns a sheet's scores ordered by judge
+func (m *MemoryScoresheetStorage) GetJudgeScores(sheetID string) ([]models.JudgeScore, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	scores := make([]models.JudgeScore, 0, len(m.scores[sheetID]))
+	for _, score := range m.scores[sheetID] {
+		scores = append(scores, score)
+	}
+	sort.Slice(scores, func(i, j int) bool { return scores[i].Judge < scores[j].Judge })
+	return scores, nil
+}
+
+// DeleteJudgeScore removes a judge's scores
+func (m *MemoryScoresheetStorage) DeleteJudgeScore(sheetID, judge string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.scores[sheetID][judge]; !ok {
+		return fmt.Errorf("judge score not found")
+	}
+	delete(m.scores[sheetID], judge)
+	return nil
+}
+
+// MySQLScoresheetStorage implements ScoresheetStorage using MySQL
+type MySQLScoresheetStorage struct {
+	db *sql.DB
+}
+
+// NewMySQLScoresheetStorage creates a new MySQL scoresheet storage
+func NewMySQLScoresheetStorage(db *sql.DB) *MySQLScoresheetStorage {
+	storage := &MySQLScoresheetStorage{db: db}
+
+	if err := storage.initTables(); err != nil {
+		panic(fmt.Sprintf("failed to initialize scoresheet tables: %v", err))
+	}
+
+	return storage
+}
+
+// initTables creates the scoresheets and judge_scores tables if they don't exist
+func (m *MySQLScoresheetStorage) initTables() error {
+	sheetsTable := `
+		CREATE TABLE IF NOT EXISTS scoresheets (
+			id VARCHAR(36) PRIMARY KEY,
+			coffee_id VARCHAR(36) NOT NULL,
+			competition VARCHAR(255) NOT NULL,
+			competitor VARCHAR(255),
+			categories JSON NOT NULL,
+			max_score DOUBLE NOT NULL,
+			created_at DATETIME(6),
+			INDEX idx_scoresheets_competition (competition),
+			FOREIGN KEY (coffee_id) REFERENCES coffees(id) ON DELETE CASCADE
+		)
+	`
+	if _, err := m.db.Exec(sheetsTable); err != nil {
+		log.Printf("ERROR: initTables - Failed to create scoresheets table: %v", err)
+		return fmt.Errorf("failed to create scoresheets table: %w", err)
+	}
+
+	scoresTable := `
+		CREATE TABLE IF NOT EXISTS judge_scores (
+			scoresheet_id VARCHAR(36) NOT NULL,
+			judge VARCHAR(100) NOT NULL,
+			scores JSON NOT NULL,
+			notes TEXT,
+			updated_at DATETIME,
+			PRIMARY KEY (scoresheet_id, judge),
+			FOREIGN KEY (scoresheet_id) REFERENCES scoresheets(id) ON DELETE CASCADE
+		)
+	`
+	if _, err := m.db.Exec(scoresTable); err != nil {
+		log.Printf("ERROR: initTables - Failed to create judge_scores table: %v", err)
+		return fmt.Errorf("failed to create judge_scores table: %w", err)
+	}
+
+	return nil
+}
+
+// SaveScoresheet stores a new scoresheet
+func (m *MySQLScoresheetStorage) SaveScoresheet(sheet models.Scoresheet) error {
+	categoriesJSON, err := json.Marshal(sheet.Categories)
+	if err != nil {
+		return fmt.Errorf("failed to marshal categories: %w", err)
+	}
+
+	_, err = m.db.Exec(`
+		INSERT INTO scoresheets (id, coffee_id, competition, competitor, categories, max_score, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, sheet.ID, sheet.CoffeeID, sheet.Competition, sheet.Competitor, categoriesJSON, sheet.MaxScore, sheet.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save scoresheet: %w", err)
+	}
+	return nil
+}
+
+// GetScoresheet retrieves a scoresheet by ID
+func (m *MySQLScoresheetStorage) GetScoresheet(id string) (models.Scoresheet, error) {
+	sheets, err := m.querySheets("WHERE id = ?", id)
+	if err != nil {
+		return models.Scoresheet{}, err
+	}
+	if len(sheets) == 0 {
+		return models.Scoresheet{}, fmt.Errorf("scoresheet not found")
+	}
+	return sheets[0], nil
+}
+
+// GetAllScoresheets returns every scoresheet, oldest first
+func (m *MySQLScoresheetStorage) GetAllScoresheets() ([]models.Scoresheet, error) {
+	return m.querySheets("ORDER BY created_at")
+}
+
+// DeleteScoresheet removes a scoresheet; its scores cascade
+func (m *MySQLScoresheetStorage) DeleteScoresheet(id string) error {
+	result, err := m.db.Exec("DELETE FROM scoresheets WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete scoresheet: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return fmt.Errorf("scoresheet not found")
+	}
+	return nil
+}
+
+// querySheets selects scoresheets with the given WHERE/ORDER clause
+func (m *MySQLScoresheetStorage) querySheets(clause string, args ...interface{}) ([]models.Scoresheet, error) {
+	rows, err := m.db.Query(`
+		SELECT id, coffee_id, competition, competitor, categories, max_score, created_at
+		FROM scoresheets `+clause, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query scoresheets: %w", err)
+	}
+	defer rows.Close()
+
+	sheets := []models.Scoresheet{}
+	for rows.Next() {
+		var sheet models.Scoresheet
+		var competitor sql.NullString
+		var categoriesJSON []byte
+		if err := rows.Scan(&sheet.ID, &sheet.CoffeeID, &sheet.Competition, &competitor,
+			&categoriesJSON, &sheet.MaxScore, &sheet.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan scoresheet: %w", err)
+		}
+		sheet.Competitor = competitor.String
+		if err := json.Unmarshal(categoriesJSON, &sheet.Categories); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal categories: %w", err)
+		}
+		sheets = append(sheets, sheet)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating scoresheets: %w", err)
+	}
+	return sheets, nil
+}
+
+// SaveJudgeScore adds or replaces a judge's scores
+func (m *MySQLScoresheetStorage) SaveJudgeScore(score models.JudgeScore) error {
+	scoresJSON, err := json.Marshal(score.Scores)
+	if err != nil {
+		return fmt.Errorf("failed to marshal scores: %w", err)
+	}
+
+	_, err = m.db.Exec(`
+		INSERT INTO judge_scores (scoresheet_id, judge, scores, notes, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE scores = VALUES(scores), notes = VALUES(notes), updated_at = VALUES(updated_at)
+	`, score.ScoresheetID, score.Judge, scoresJSON, score.Notes, score.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save judge score: %w", err)
+	}
+	return nil
+}
+
+// GetJudgeScores returns a sheet's scores ordered by judge
+func (m *MySQLScoresheetStorage) GetJudgeScores(sheetID string) ([]models.JudgeScore, error) {
+	rows, err := m.db.Query(`
+		SELECT scoresheet_id, judge, scores, notes, updated_at
+		FROM judge_scores WHERE scoresheet_id = ? ORDER BY judge
+	`, sheetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query judge scores: %w", err)
+	}
+	defer rows.Close()
+
+	scores := []models.JudgeScore{}
+	for rows.Next() {
+		var score models.JudgeScore
+		var scoresJSON []byte
+		var notes sql.NullString
+		if err := rows.Scan(&score.ScoresheetID, &score.Judge, &scoresJSON, &notes, &score.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan judge score: %w", err)
+		}
+		score.Notes = notes.String
+		if err := json.Unmarshal(scoresJSON, &score.Scores); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal scores: %w", err)
+		}
+		scores = append(scores, score)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating judge scores: %w", err)
+	}
+	return scores, nil
+}
+
+// DeleteJudgeScore removes a judge's scores
+func (m *MySQLScoresheetStorage) DeleteJudgeScore(sheetID, judge string) error {
+	result, err := m.db.Exec("DELETE FROM judge_scores WHERE scoresheet_id = ? AND judge = ?", sheetID, judge)
+	if err != nil {
+		return fmt.Errorf("failed to delete judge score: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return fmt.Errorf("judge score not found")
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"go-coffee-log/models"
+	"log"
+	"sort"
+	"sync"
+)
+
+// SavedSearchStorage defines the interface for saved coffee searches
+type SavedSearchStorage interface {
+	SaveSearch(search models.SavedSearch) error
+	GetSearch(id string) (models.SavedSearch, error)
+	GetAllSearches() ([]models.SavedSearch, error)
+	DeleteSearch(id string) error
+}
+
+// MemorySavedSearchStorage implements SavedSearchStorage in memory
+type MemorySavedSearchStorage struct {
+	searches map[string]models.SavedSearch
+	mu       sync.RWMutex
+}
+
+// NewMemorySavedSearchStorage creates a new in-memory saved search storage
+func NewMemorySavedSearchStorage() *MemorySavedSearchStorage {
+	return &MemorySavedSearchStorage{searches: make(map[string]models.SavedSearch)}
+}
+
+// SaveSearch stores a saved search
+func (m *MemorySavedSearchStorage) SaveSearch(search models.SavedSearch) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.searches[search.ID] = search
+	return nil
+}
+
+// GetSearch retrieves a saved search by ID
+func (m *MemorySavedSearchStorage) GetSearch(id string) (models.SavedSearch, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	search, ok := m.searches[id]
+	if !ok {
+		return models.SavedSearch{}, fmt.Errorf("search not found")
+	}
+	return search, nil
+}
+
+// GetAllSearches returns every saved search ordered by name
+func (m *MemorySavedSearchStorage) GetAllSearches() ([]models.SavedSearch, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	searches := make([]models.SavedSearch, 0, len(m.searches))
+	for _, search := range m.searches {
+		searches = append(searches, search)
+	}
+	sort.Slice(searches, func(i, j int) bool { return searches[i].Name < searches[j].Name })
+	return searches, nil
+}
+
+// DeleteSearch removes a saved search
+func (m *MemorySavedSearchStorage) DeleteSearch(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.searches[id]; !ok {
+		return fmt.Errorf("search not found")
+	}
+	delete(m.searches, id)
+	return nil
+}
+
+// MySQLSavedSearchStorage implements SavedSearchStorage using MySQL
+type MySQLSavedSearchStorage struct {
+	db *sql.DB
+}
+
+// NewMySQLSavedSearchStorage creates a new MySQL saved search storage
+func NewMySQLSavedSearchStorage(db *sql.DB) *MySQLSavedSearchStorage {
+	storage := &MySQLSavedSearchStorage{db: db}
+
+	if err := storage.initTables(); err != nil {
+		panic(fmt.Sprintf("failed to initialize saved_searches table: %v", err))
+	}
+
+	return storage
+}
+
+// initTables creates the saved_searches table if it doesn't exist
+func (m *MySQLSavedSearchStorage) initTables() error {
+	query := `
+		CREATE TABLE IF NOT EXISTS saved_searches (
+			id VARCHAR(36) PRIMARY KEY,
+			name VARCHAR(100) NOT NULL,
+			query JSON NOT NULL,
+			webhook_url VARCHAR(2048),
+			created_at DATETIME
+		)
+	`
+
+	if _, err := m.db.Exec(query); err != nil {
+		log.Printf("ERROR: initTables - Failed to create saved_searches table: %v", err)
+		return fmt.Errorf("failed to create saved_searches table: %w", err)
+	}
+
+	return nil
+}
+
+// SaveSearch stores a saved search
+func (m *MySQLSavedSearchStorage) SaveSearch(search models.SavedSearch) error {
+	queryJSON, err := json.Marshal(search.Query)
+	if err != nil {
+		return fmt.Errorf("failed to marshal search query: %w", err)
+	}
+
+	_, err = m.db.Exec(
+		"INSERT INTO saved_searches (id, name, query, webhook_url, created_at) VALUES (?, ?, ?, ?, ?)",
+		search.ID, search.Name, queryJSON, search.WebhookURL, search.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save search: %w", err)
+	}
+	return nil
+}
+
+// GetSearch retrieves a saved search by ID
+func (m *MySQLSavedSearchStorage) GetSearch(id string) (models.SavedSearch, error) {
+	searches, err := m.querySearches("SELECT id, name, query, webhook_url, created_at FROM saved_searches WHERE id = ?", id)
+	if err != nil {
+		return models.SavedSearch{}, err
+	}
+	if len(searches) == 0 {
+		return models.SavedSearch{}, fmt.Errorf("search not found")
+	}
+	return searches[0], nil
+}
+
+// GetAllSearches returns every saved search ordered by name
+func (m *MySQLSavedSearchStorage) GetAllSearches() ([]models.SavedSearch, error) {
+	return m.querySearches("SELECT id, name, query, webhook_url, created_at FROM saved_searches ORDER BY name")
+}
+
+// querySearches runs a saved search query and scans the rows
+func (m *MySQLSavedSearchStorage) querySearches(query string, args ...interface{}) ([]models.SavedSearch, error) {
+	rows, err := m.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query saved searches: %w", err)
+	}
+	defer rows.Close()
+
+	searches := []models.SavedSearch{}
+	for rows.Next() {
+		var search models.SavedSearch
+		var queryJSON []byte
+		var webhookURL sql.NullString
+		var createdAt sql.NullTime
+		if err := rows.Scan(&search.ID, &search.Name, &queryJSON, &webhookURL, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan saved search: %w", err)
+		}
+		if err := json.Unmarshal(queryJSON, &search.Query); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal search query: %w", err)
+		}
+		search.WebhookURL = webhookURL.String
+		search.CreatedAt = createdAt.Time
+		searches = append(searches, search)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating saved searches: %w", err)
+	}
+	return searches, nil
+}
+
+// DeleteSearch removes a saved search
+func (m *MySQLSavedSearchStorage) DeleteSearch(id string) error {
+	result, err := m.db.Exec("DELETE FROM saved_searches WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete search: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("search not found")
+	}
+	return nil
+}
@@ -1,6 +1,10 @@
 package storage
 
-import "go-coffee-log/models"
+import (
+	"go-coffee-log/models"
+	"sort"
+	"strings"
+)
 
 // CoffeeStorage defines the interface for coffee data persistence
 // This allows us to swap different storage implementations (memory, database, etc.)
@@ -15,6 +19,69 @@ type CoffeeStorage interface {
 	GetByID(id string) (models.Coffee, error)
 	GetAll() ([]models.Coffee, error)
 	GetRecent(limit int) ([]models.Coffee, error)
+	// Find returns the coffees matching a query, sorted and paged, along
+	// with how many match in total
+	Find(query CoffeeQuery) ([]models.Coffee, int, error)
 	Update(id string, coffee models.Coffee) error
 	Delete(id string) error
-}
\ No newline at end of file
+}
+
+// CoffeeQuery filters, sorts and pages a coffee listing
+type CoffeeQuery struct {
+	Filter models.SearchQuery
+	Sort   string // one of CoffeeSorts; "" sorts newest first
+	Offset int    // ignored without a limit
+	Limit  int    // 0 = every match
+}
+
+// DefaultCoffeeSort lists the newest coffees first
+const DefaultCoffeeSort = "created_desc"
+
+// CoffeeSorts are the orders a coffee listing can be sorted in. Ties fall
+// back to newest first, then ID, so pages don't shift between requests.
+var CoffeeSorts = map[string]func(a, b models.Coffee) int{
+	"created_desc": func(a, b models.Coffee) int { return b.CreatedAt.Compare(a.CreatedAt) },
+	"created_asc":  func(a, b models.Coffee) int { return a.CreatedAt.Compare(b.CreatedAt) },
+	"rating_desc":  func(a, b models.Coffee) int { return b.Rating - a.Rating },
+	"rating_asc":   func(a, b models.Coffee) int { return a.Rating - b.Rating },
+	"name_asc":     func(a, b models.Coffee) int { return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name)) },
+	"name_desc":    func(a, b models.Coffee) int { return strings.Compare(strings.ToLower(b.Name), strings.ToLower(a.Name)) },
+}
+
+// findCoffees filters, sorts and pages coffees held in memory, the way
+// MySQLStorage.Find does in SQL
+func findCoffees(coffees []models.Coffee, query CoffeeQuery) ([]models.Coffee, int) {
+	matches := []models.Coffee{}
+	for _, coffee := range coffees {
+		if query.Filter.Matches(coffee) {
+			matches = append(matches, coffee)
+		}
+	}
+
+	order, ok := CoffeeSorts[query.Sort]
+	if !ok {
+		order = CoffeeSorts[DefaultCoffeeSort]
+	}
+	newest := CoffeeSorts[DefaultCoffeeSort]
+	sort.SliceStable(matches, func(i, j int) bool {
+		if c := order(matches[i], matches[j]); c != 0 {
+			return c < 0
+		}
+		if c := newest(matches[i], matches[j]); c != 0 {
+			return c < 0
+		}
+		return matches[i].ID < matches[j].ID
+	})
+
+	total := len(matches)
+	if query.Limit > 0 {
+		if query.Offset >= len(matches) {
+			return []models.Coffee{}, total
+		}
+		matches = matches[max(query.Offset, 0):]
+		if query.Limit < len(matches) {
+			matches = matches[:query.Limit]
+		}
+	}
+	return matches, total
+}
@@ -0,0 +1,426 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"go-coffee-log/models"
+	"log"
+	"sort"
+	"sync"
+)
+
+// SubscriptionStorage defines the interface for coffee subscriptions and
+// the eggs (expected bags) they lay
+type SubscriptionStorage interface {
+	SaveSubscription(subscription models.Subscription) error
+	GetSubscription(id string) (models.Subscription, error)
+	GetAllSubscriptions() ([]models.Subscription, error)
+	UpdateSubscription(subscription models.Subscription) error
+	DeleteSubscription(id string) error
+
+	SaveEgg(egg models.Egg) error
+	GetEgg(id string) (models.Egg, error)
+	GetAllEggs() ([]models.Egg, error)
+	UpdateEgg(egg models.Egg) error
+	DeleteEgg(id string) error
+}
+
+// sortEggs orders eggs by expected date, oldest first
+func sortEggs(eggs []models.Egg) {
+	sort.Slice(eggs, func(i, j int) bool {
+		if !eggs[i].ExpectedOn.Equal(eggs[j].ExpectedOn.Time) {
+			return eggs[i].ExpectedOn.Before(eggs[j].ExpectedOn.Time)
+		}
+		return eggs[i].CreatedAt.Before(eggs[j].CreatedAt)
+	})
+}
+
+// MemorySubscriptionStorage implements SubscriptionStorage in memory
+type MemorySubscriptionStorage struct {
+	subscriptions map[string]models.Subscription
+	eggs          map[string]models.Egg
+	mu            sync.RWMutex
+}
+
+// NewMemorySubscriptionStorage creates a new in-memory subscription storage
+func NewMemorySubscriptionStorage() *MemorySubscriptionStorage {
+	return &MemorySubscriptionStorage{
+		subscriptions: make(map[string]models.Subscription),
+		eggs:          make(map[string]models.Egg),
+	}
+}
+
+// SaveSubscription stores a new subscription
+func (m *MemorySubscriptionStorage) SaveSubscription(subscription models.Subscription) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.subscriptions[subscription.ID] = subscription
+	return nil
+}
+
+// GetSubscription retrieves a subscription by ID
+func (m *MemorySubscriptionStorage) GetSubscription(id string) (models.Subscription, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	subscription, ok := m.subscriptions[id]
+	if !ok {
+		return models.Subscription{}, fmt.Errorf("subscription not found")
+	}
+	return subscription, nil
+}
+
+// GetAllSubscriptions returns every subscription by next delivery
+func (m *MemorySubscriptionStorage) GetAllSubscriptions() ([]models.Subscription, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	subscriptions := make([]models.Subscription, 0, len(m.subscriptions))
+	for _, subscription := range m.subscriptions {
+		subscriptions = append(subscriptions, subscription)
+	}
+	sort.Slice(subscriptions, func(i, j int) bool {
+		return subscriptions[i].NextDelivery.Before(subscriptions[j].NextDelivery.Time)
+	})
+	return subscriptions, nil
+}
+
+// UpdateSubscription replaces an existing subscription
+func (m *MemorySubscriptionStorage) UpdateSubscription(subscription models.Subscription) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.subscriptions[subscription.ID]; !ok {
+		return fmt.Errorf("subscription not found")
+	}
+	m.subscriptions[subscription.ID] = subscription
+	return nil
+}
+
+// DeleteSubscription removes a subscription along with its unhatched eggs
+func (m *MemorySubscriptionStorage) DeleteSubscription(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.subscriptions[id]; !ok {
+		return fmt.Errorf("subscription not found")
+	}
+	delete(m.subscriptions, id)
+	for eggID, egg := range m.eggs {
+		if egg.SubscriptionID == id && !egg.Hatched() {
+			delete(m.eggs, eggID)
+		}
+	}
+	return nil
+}
+
+// SaveEgg stores a new egg
+func (m *MemorySubscriptionStorage) SaveEgg(egg models.Egg) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.eggs[egg.ID] = egg
+	return nil
+}
+
+// GetEgg retrieves an egg by ID
+func (m *MemorySubscriptionStorage) GetEgg(id string) (models.Egg, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	egg, ok := m.eggs[id]
+	if !ok {
+		return models.Egg{}, fmt.Errorf("egg not found")
+	}
+	return egg, nil
+}
+
+// GetAllEggs returns every egg, oldest expected first
+func (m *MemorySubscriptionStorage) GetAllEggs() ([]models.Egg, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	eggs := make([]models.Egg, 0, len(m.eggs))
+	for _, egg := range m.eggs {
+		eggs = append(eggs, egg)
+	}
+	sortEggs(eggs)
+	return eggs, nil
+}
+
+// UpdateEgg replaces an existing egg
+func (m *MemorySubscriptionStorage) UpdateEgg(egg models.Egg) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.eggs[egg.ID]; !ok {
+		return fmt.Errorf("egg not found")
+	}
+	m.eggs[egg.ID] = egg
+	return nil
+}
+
+// DeleteEgg removes an egg
+func (m *MemorySubscriptionStorage) DeleteEgg(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.eggs[id]; !ok {
+		return fmt.Errorf("egg not found")
+	}
+	delete(m.eggs, id)
+	return nil
+}
+
+// MySQLSubscriptionStorage implements SubscriptionStorage using MySQL
+type MySQLSubscriptionStorage struct {
+	db *sql.DB
+}
+
+// NewMySQLSubscriptionStorage creates a new MySQL subscription storage
+func NewMySQLSubscriptionStorage(db *sql.DB) *MySQLSubscriptionStorage {
+	storage := &MySQLSubscriptionStorage{db: db}
+
+	if err := storage.initTables(); err != nil {
+		panic(fmt.Sprintf("failed to initialize subscription tables: %v", err))
+	}
+
+	return storage
+}
+
+// initTables creates the subscriptions and subscription_eggs tables if they don't exist
+func (m *MySQLSubscriptionStorage) initTables() error {
+	subscriptionsTable := `
+		CREATE TABLE IF NOT EXISTS subscriptions (
+			id VARCHAR(36) PRIMARY KEY,
+			roaster VARCHAR(255) NOT NULL,
+			name VARCHAR(255),
+			interval_days INT NOT NULL,
+			next_delivery DATE NOT NULL,
+			notes TEXT,
+			paused BOOLEAN NOT NULL DEFAULT FALSE,
+			created_at DATETIME,
+			updated_at DATETIME
+		)
+	`
+	if _, err := m.db.Exec(subscriptionsTable); err != nil {
+		log.Printf("ERROR: initTables - Failed to create subscriptions table: %v", err)
+		return fmt.Errorf("failed to create subscriptions table: %w", err)
+	}
+
+	// Hatched eggs outlive their subscription as a record of the drop, so
+	// subscription_id is not a foreign key
+	eggsTable := `
+		CREATE TABLE IF NOT EXISTS subscription_eggs (
+			id VARCHAR(36) PRIMARY KEY,
+			subscription_id VARCHAR(36) NOT NULL,
+			roaster VARCHAR(255) NOT NULL,
+			name VARCHAR(255),
+			expected_on DATE NOT NULL,
+			coffee_id VARCHAR(36) NULL,
+			hatched_at DATETIME NULL,
+			created_at DATETIME,
+			UNIQUE KEY uniq_subscription_drop (subscription_id, expected_on),
+			FOREIGN KEY (coffee_id) REFERENCES coffees(id) ON DELETE SET NULL
+		)
+	`
+	if _, err := m.db.Exec(eggsTable); err != nil {
+		log.Printf("ERROR: initTables - Failed to create subscription_eggs table: %v", err)
+		return fmt.Errorf("failed to create subscription_eggs table: %w", err)
+	}
+
+	return nil
+}
+
+// SaveSubscription stores a new subscription
+func (m *MySQLSubscriptionStorage) SaveSubscription(subscription models.Subscription) error {
+	_, err := m.db.Exec(`
+		INSERT INTO subscriptions (id, roaster, name, interval_days, next_delivery, notes, paused, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, subscription.ID, subscription.Roaster, subscription.Name, subscription.IntervalDays,
+		subscription.NextDelivery.Time, subscription.Notes, subscription.Paused, subscription.CreatedAt, subscription.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save subscription: %w", err)
+	}
+	return nil
+}
+
+// GetSubscription retrieves a subscription by ID
+func (m *MySQLSubscriptionStorage) GetSubscription(id string) (models.Subscription, error) {
+	subscriptions, err := m.querySubscriptions("WHERE id = ?", id)
+	if err != nil {
+		return models.Subscription{}, err
+	}
+	if len(subscriptions) == 0 {
+		return models.Subscription{}, fmt.Errorf("subscription not found")
+	}
+	return subscriptions[0], nil
+}
+
+// GetAllSubscriptions returns every subscription by next delivery
+func (m *MySQLSubscriptionStorage) GetAllSubscriptions() ([]models.Subscription, error) {
+	return m.querySubscriptions("ORDER BY next_delivery")
+}
+
+// UpdateSubscription replaces an existing subscription
+func (m *MySQLSubscriptionStorage) UpdateSubscription(subscription models.Subscription) error {
+	if _, err := m.GetSubscription(subscription.ID); err != nil {
+		return err
+	}
+	_, err := m.db.Exec(`
+		UPDATE subscriptions
+		SET roaster = ?, name = ?, interval_days = ?, next_delivery = ?, notes = ?, paused = ?, updated_at = ?
+		WHERE id = ?
+	`, subscription.Roaster, subscription.Name, subscription.IntervalDays, subscription.NextDelivery.Time,
+		subscription.Notes, subscription.Paused, subscription.UpdatedAt, subscription.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update subscription: %w", err)
+	}
+	return nil
+}
+
+// DeleteSubscription removes a subscription along with its unhatched eggs
+func (m *MySQLSubscriptionStorage) DeleteSubscription(id string) error {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("DELETE FROM subscriptions WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete subscription: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return fmt.Errorf("subscription not found")
+	}
+	if _, err := tx.Exec("DELETE FROM subscription_eggs WHERE subscription_id = ? AND hatched_at IS NULL", id); err != nil {
+		return fmt.Errorf("failed to delete subscription eggs: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit subscription delete: %w", err)
+	}
+	return nil
+}
+
+// querySubscriptions selects subscriptions with the given WHERE/ORDER clause
+func (m *MySQLSubscriptionStorage) querySubscriptions(clause string, args ...interface{}) ([]models.Subscription, error) {
+	rows, err := m.db.Query(`
+		SELECT id, roaster, name, interval_days, next_delivery, notes, paused, created_at, updated_at
+		FROM subscriptions `+clause, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	subscriptions := []models.Subscription{}
+	for rows.Next() {
+		var subscription models.Subscription
+		var name, notes sql.NullString
+		var nextDelivery sql.NullTime
+		if err := rows.Scan(&subscription.ID, &subscription.Roaster, &name, &subscription.IntervalDays,
+			&nextDelivery, &notes, &subscription.Paused, &subscription.CreatedAt, &subscription.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan subscription: %w", err)
+		}
+		subscription.Name = name.String
+		subscription.Notes = notes.String
+		if date := scanDate(nextDelivery); date != nil {
+			subscription.NextDelivery = *date
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating subscriptions: %w", err)
+	}
+	return subscriptions, nil
+}
+
+// SaveEgg stores a new egg
+func (m *MySQLSubscriptionStorage) SaveEgg(egg models.Egg) error {
+	_, err := m.db.Exec(`
+		INSERT INTO subscription_eggs (id, subscription_id, roaster, name, expected_on, coffee_id, hatched_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, egg.ID, egg.SubscriptionID, egg.Roaster, egg.Name, egg.ExpectedOn.Time,
+		nullableString(egg.CoffeeID), egg.HatchedAt, egg.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save egg: %w", err)
+	}
+	return nil
+}
+
+// GetEgg retrieves an egg by ID
+func (m *MySQLSubscriptionStorage) GetEgg(id string) (models.Egg, error) {
+	eggs, err := m.queryEggs("WHERE id = ?", id)
+	if err != nil {
+		return models.Egg{}, err
+	}
+	if len(eggs) == 0 {
+		return models.Egg{}, fmt.Errorf("egg not found")
+	}
+	return eggs[0], nil
+}
+
+// GetAllEggs returns every egg, oldest expected first
+func (m *MySQLSubscriptionStorage) GetAllEggs() ([]models.Egg, error) {
+	return m.queryEggs("ORDER BY expected_on, created_at")
+}
+
+// UpdateEgg records an egg hatching
+func (m *MySQLSubscriptionStorage) UpdateEgg(egg models.Egg) error {
+	if _, err := m.GetEgg(egg.ID); err != nil {
+		return err
+	}
+	_, err := m.db.Exec("UPDATE subscription_eggs SET coffee_id = ?, hatched_at = ? WHERE id = ?",
+		nullableString(egg.CoffeeID), egg.HatchedAt, egg.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update egg: %w", err)
+	}
+	return nil
+}
+
+// DeleteEgg removes an egg
+func (m *MySQLSubscriptionStorage) DeleteEgg(id string) error {
+	result, err := m.db.Exec("DELETE FROM subscription_eggs WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete egg: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return fmt.Errorf("egg not found")
+	}
+	return nil
+}
+
+// queryEggs selects eggs with the given WHERE/ORDER clause
+func (m *MySQLSubscriptionStorage) queryEggs(clause string, args ...interface{}) ([]models.Egg, error) {
+	rows, err := m.db.Query(`
+		SELECT id, subscription_id, roaster, name, expected_on, coffee_id, hatched_at, created_at
+		FROM subscription_eggs `+clause, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query eggs: %w", err)
+	}
+	defer rows.Close()
+
+	eggs := []models.Egg{}
+	for rows.Next() {
+		var egg models.Egg
+		var name, coffeeID sql.NullString
+		var expectedOn, hatchedAt sql.NullTime
+		if err := rows.Scan(&egg.ID, &egg.SubscriptionID, &egg.Roaster, &name, &expectedOn,
+			&coffeeID, &hatchedAt, &egg.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan egg: %w", err)
+		}
+		egg.Name = name.String
+		egg.CoffeeID = coffeeID.String
+		if date := scanDate(expectedOn); date != nil {
+			egg.ExpectedOn = *date
+		}
+		if hatchedAt.Valid {
+			egg.HatchedAt = &hatchedAt.Time
+		}
+		eggs = append(eggs, egg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating eggs: %w", err)
+	}
+	return eggs, nil
+}
@@ -0,0 +1,176 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"go-coffee-log/models"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// TombstoneStorage defines the interface for the deletion journal
+type TombstoneStorage interface {
+	RecordDeletion(tombstone models.Tombstone) error
+	// GetDeletion returns the tombstone of an entity, or nil if it was never deleted
+	GetDeletion(entityType, entityID string) (*models.Tombstone, error)
+	GetDeletionsSince(since time.Time) ([]models.Tombstone, error)
+	// ClearDeletion forgets a tombstone when the entity is recreated
+	ClearDeletion(entityType, entityID string) error
+}
+
+// MemoryTombstoneStorage implements TombstoneStorage using an in-memory map
+type MemoryTombstoneStorage struct {
+	tombstones map[string]models.Tombstone // keyed by entity type + "/" + ID
+	mu         sync.RWMutex
+}
+
+// NewMemoryTombstoneStorage creates a new in-memory tombstone storage
+func NewMemoryTombstoneStorage() *MemoryTombstoneStorage {
+	return &MemoryTombstoneStorage{
+		tombstones: make(map[string]models.Tombstone),
+	}
+}
+
+// RecordDeletion stores a tombstone, replacing any earlier one
+func (m *MemoryTombstoneStorage) RecordDeletion(tombstone models.Tombstone) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tombstones[tombstone.EntityType+"/"+tombstone.EntityID] = tombstone
+	return nil
+}
+
+// GetDeletion returns the tombstone of an entity, if any
+func (m *MemoryTombstoneStorage) GetDeletion(entityType, entityID string) (*models.Tombstone, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	tombstone, ok := m.tombstones[entityType+"/"+entityID]
+	if !ok {
+		return nil, nil
+	}
+	return &tombstone, nil
+}
+
+// GetDeletionsSince returns tombstones recorded at or after since, oldest first
+func (m *MemoryTombstoneStorage) GetDeletionsSince(since time.Time) ([]models.Tombstone, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var tombstones []models.Tombstone
+	for _, tombstone := range m.tombstones {
+		if !tombstone.DeletedAt.Before(since) {
+			tombstones = append(tombstones, tombstone)
+		}
+	}
+	sort.Slice(tombstones, func(i, j int) bool { return tombstones[i].DeletedAt.Before(tombstones[j].DeletedAt) })
+	return tombstones, nil
+}
+
+// ClearDeletion removes a tombstone
+func (m *MemoryTombstoneStorage) ClearDeletion(entityType, entityID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.tombstones, entityType+"/"+entityID)
+	return nil
+}
+
+// MySQLTombstoneStorage implements TombstoneStorage using MySQL database
+type MySQLTombstoneStorage struct {
+	db *sql.DB
+}
+
+// NewMySQLTombstoneStorage creates a new MySQL tombstone storage
+func NewMySQLTombstoneStorage(db *sql.DB) *MySQLTombstoneStorage {
+	storage := &MySQLTombstoneStorage{db: db}
+
+	if err := storage.initTables(); err != nil {
+		panic(fmt.Sprintf("failed to initialize tombstones table: %v", err))
+	}
+
+	return storage
+}
+
+// initTables creates the tombstones table if it doesn't exist
+func (m *MySQLTombstoneStorage) initTables() error {
+	query := `
+		CREATE TABLE IF NOT EXISTS tombstones (
+			entity_type VARCHAR(20) NOT NULL,
+			entity_id VARCHAR(36) NOT NULL,
+			deleted_at DATETIME(3) NOT NULL,
+			PRIMARY KEY (entity_type, entity_id),
+			INDEX idx_tombstones_deleted (deleted_at)
+		)
+	`
+
+	if _, err := m.db.Exec(query); err != nil {
+		log.Printf("ERROR: initTables - Failed to create tombstones table: %v", err)
+		return fmt.Errorf("failed to create tombstones table: %w", err)
+	}
+
+	return nil
+}
+
+// RecordDeletion stores a tombstone, replacing any earlier one
+func (m *MySQLTombstoneStorage) RecordDeletion(tombstone models.Tombstone) error {
+	_, err := m.db.Exec(`
+		INSERT INTO tombstones (entity_type, entity_id, deleted_at) VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE deleted_at = VALUES(deleted_at)
+	`, tombstone.EntityType, tombstone.EntityID, tombstone.DeletedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record deletion: %w", err)
+	}
+	return nil
+}
+
+// GetDeletion returns the tombstone of an entity, if any
+func (m *MySQLTombstoneStorage) GetDeletion(entityType, entityID string) (*models.Tombstone, error) {
+	tombstone := models.Tombstone{EntityType: entityType, EntityID: entityID}
+	err := m.db.QueryRow(
+		"SELECT deleted_at FROM tombstones WHERE entity_type = ? AND entity_id = ?",
+		entityType, entityID,
+	).Scan(&tombstone.DeletedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deletion: %w", err)
+	}
+	return &tombstone, nil
+}
+
+// GetDeletionsSince returns tombstones recorded at or after since, oldest first
+func (m *MySQLTombstoneStorage) GetDeletionsSince(since time.Time) ([]models.Tombstone, error) {
+	rows, err := m.db.Query(
+		"SELECT entity_type, entity_id, deleted_at FROM tombstones WHERE deleted_at >= ? ORDER BY deleted_at ASC",
+		since,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query deletions: %w", err)
+	}
+	defer rows.Close()
+
+	var tombstones []models.Tombstone
+	for rows.Next() {
+		var tombstone models.Tombstone
+		if err := rows.Scan(&tombstone.EntityType, &tombstone.EntityID, &tombstone.DeletedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan deletion: %w", err)
+		}
+		tombstones = append(tombstones, tombstone)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return tombstones, nil
+}
+
+// ClearDeletion removes a tombstone
+func (m *MySQLTombstoneStorage) ClearDeletion(entityType, entityID string) error {
+	if _, err := m.db.Exec("DELETE FROM tombstones WHERE entity_type = ? AND entity_id = ?", entityType, entityID); err != nil {
+		return fmt.Errorf("failed to clear deletion: %w", err)
+	}
+	return nil
+}
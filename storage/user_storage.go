@@ -0,0 +1,372 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-coffee-log/models"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// UserStorage defines the interface for users and invitations
+type UserStorage interface {
+	SaveUser(user models.User) error
+	GetUserByID(id string) (models.User, error)
+	GetUserByTokenHash(tokenHash string) (models.User, error)
+	GetAllUsers() ([]models.User, error)
+	DeleteUser(id string) error
+	SaveInvite(invite models.Invite) error
+	GetAllInvites() ([]models.Invite, error)
+	// RedeemInvite marks a pending, unexpired invite as used by userID.
+	// It fails if the invite was already used, so redemption is single-use
+	// even under concurrent requests.
+	RedeemInvite(code, userID string, at time.Time) error
+	DeleteInvite(code string) error
+}
+
+// MemoryUserStorage implements UserStorage using in-memory maps
+type MemoryUserStorage struct {
+	users   map[string]models.User
+	invites map[string]models.Invite
+	mu      sync.RWMutex
+}
+
+// NewMemoryUserStorage creates a new in-memory user storage
+func NewMemoryUserStorage() *MemoryUserStorage {
+	return &MemoryUserStorage{
+		users:   make(map[string]models.User),
+		invites: make(map[string]models.Invite),
+	}
+}
+
+// SaveUser stores a new user
+func (m *MemoryUserStorage) SaveUser(user models.User) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.users[user.ID] = user
+	return nil
+}
+
+// GetUserByID retrieves a user by ID
+func (m *MemoryUserStorage) GetUserByID(id string) (models.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	user, ok := m.users[id]
+	if !ok {
+		return models.User{}, errors.New("user not found")
+	}
+	return user, nil
+}
+
+// GetUserByTokenHash retrieves the user owning a token
+func (m *MemoryUserStorage) GetUserByTokenHash(tokenHash string) (models.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, user := range m.users {
+		if user.TokenHash == tokenHash {
+			return user, nil
+		}
+	}
+	return models.User{}, errors.New("user not found")
+}
+
+// GetAllUsers retrieves all users, oldest first
+func (m *MemoryUserStorage) GetAllUsers() ([]models.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var users []models.User
+	for _, user := range m.users {
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].CreatedAt.Before(users[j].CreatedAt) })
+	return users, nil
+}
+
+// DeleteUser removes a user
+func (m *MemoryUserStorage) DeleteUser(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[id]; !ok {
+		return errors.New("user not found")
+	}
+	delete(m.users, id)
+	return nil
+}
+
+// SaveInvite stores a new invite
+func (m *MemoryUserStorage) SaveInvite(invite models.Invite) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.invites[invite.Code] = invite
+	return nil
+}
+
+// GetAllInvites retrieves all invites, newest first
+func (m *MemoryUserStorage) GetAllInvites() ([]models.Invite, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var invites []models.Invite
+	for _, invite := range m.invites {
+		invites = append(invites, invite)
+	}
+	sort.Slice(invites, func(i, j int) bool { return invites[i].CreatedAt.After(invites[j].CreatedAt) })
+	return invites, nil
+}
+
+// RedeemInvite marks an invite as used
+func (m *MemoryUserStorage) RedeemInvite(code, userID string, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	invite, ok := m.invites[code]
+	if !ok {
+		return errors.New("invite not found")
+	}
+	if status := invite.Status(at); status != "pending" {
+		return fmt.Errorf("invite is %s", status)
+	}
+
+	invite.UsedBy = userID
+	invite.UsedAt = &at
+	m.invites[code] = invite
+	return nil
+}
+
+// DeleteInvite revokes an invite
+func (m *MemoryUserStorage) DeleteInvite(code string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.invites[code]; !ok {
+		return errors.New("invite not found")
+	}
+	delete(m.invites, code)
+	return nil
+}
+
+// MySQLUserStorage implements UserStorage using MySQL database
+type MySQLUserStorage struct {
+	db *sql.DB
+}
+
+// NewMySQLUserStorage creates a new MySQL user storage
+func NewMySQLUserStorage(db *sql.DB) *MySQLUserStorage {
+	storage := &MySQLUserStorage{db: db}
+
+	if err := storage.initTables(); err != nil {
+		panic(fmt.Sprintf("failed to initialize user tables: %v", err))
+	}
+
+	return storage
+}
+
+// initTables creates the users and invites tables if they don't exist
+func (m *MySQLUserStorage) initTables() error {
+	usersQuery := `
+		CREATE TABLE IF NOT EXISTS users (
+			id VARCHAR(36) PRIMARY KEY,
+			name VARCHAR(100) NOT NULL,
+			is_admin BOOLEAN NOT NULL DEFAULT FALSE,
+			token_hash CHAR(64) NOT NULL UNIQUE,
+			created_at DATETIME
+		)
+	`
+	if _, err := m.db.Exec(usersQuery); err != nil {
+		log.Printf("ERROR: initTables - Failed to create users table: %v", err)
+		return fmt.Errorf("failed to create users table: %w", err)
+	}
+
+	invitesQuery := `
+		CREATE TABLE IF NOT EXISTS invites (
+			code VARCHAR(20) PRIMARY KEY,
+			created_by VARCHAR(36) NOT NULL,
+			expires_at DATETIME NOT NULL,
+			used_by VARCHAR(36) NULL,
+			used_at DATETIME NULL,
+			created_at DATETIME
+		)
+	`
+	if _, err := m.db.Exec(invitesQuery); err != nil {
+		log.Printf("ERROR: initTables - Failed to create invites table: %v", err)
+		return fmt.Errorf("failed to create invites table: %w", err)
+	}
+
+	return nil
+}
+
+// SaveUser stores a new user
+func (m *MySQLUserStorage) SaveUser(user models.User) error {
+	query := `INSERT INTO users (id, name, is_admin, token_hash, created_at) VALUES (?, ?, ?, ?, ?)`
+	if _, err := m.db.Exec(query, user.ID, user.Name, user.IsAdmin, user.TokenHash, user.CreatedAt); err != nil {
+		return fmt.Errorf("failed to save user: %w", err)
+	}
+	return nil
+}
+
+// GetUserByID retrieves a user by ID
+func (m *MySQLUserStorage) GetUserByID(id string) (models.User, error) {
+	return m.getUser("SELECT id, name, is_admin, token_hash, created_at FROM users WHERE id = ?", id)
+}
+
+// GetUserByTokenHash retrieves the user owning a token
+func (m *MySQLUserStorage) GetUserByTokenHash(tokenHash string) (models.User, error) {
+	return m.getUser("SELECT id, name, is_admin, token_hash, created_at FROM users WHERE token_hash = ?", tokenHash)
+}
+
+// getUser runs a single-user query
+func (m *MySQLUserStorage) getUser(query string, arg interface{}) (models.User, error) {
+	var user models.User
+	err := m.db.QueryRow(query, arg).Scan(&user.ID, &user.Name, &user.IsAdmin, &user.TokenHash, &user.CreatedAt)
+	if err == sql.ErrNoRows {
+		return models.User{}, fmt.Errorf("user not found")
+	}
+	if err != nil {
+		return models.User{}, fmt.Errorf("failed to get user: %w", err)
+	}
+	return user, nil
+}
+
+// GetAllUsers retrieves all users, oldest first
+func (m *MySQLUserStorage) GetAllUsers() ([]models.User, error) {
+	rows, err := m.db.Query("SELECT id, name, is_admin, token_hash, created_at FROM users ORDER BY created_at ASC")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []models.User
+	for rows.Next() {
+		var user models.User
+		if err := rows.Scan(&user.ID, &user.Name, &user.IsAdmin, &user.TokenHash, &user.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return users, nil
+}
+
+// DeleteUser removes a user
+func (m *MySQLUserStorage) DeleteUser(id string) error {
+	result, err := m.db.Exec("DELETE FROM users WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
+
+// SaveInvite stores a new invite
+func (m *MySQLUserStorage) SaveInvite(invite models.Invite) error {
+	query := `INSERT INTO invites (code, created_by, expires_at, created_at) VALUES (?, ?, ?, ?)`
+	if _, err := m.db.Exec(query, invite.Code, invite.CreatedBy, invite.ExpiresAt, invite.CreatedAt); err != nil {
+		return fmt.Errorf("failed to save invite: %w", err)
+	}
+	return nil
+}
+
+// GetAllInvites retrieves all invites, newest first
+func (m *MySQLUserStorage) GetAllInvites() ([]models.Invite, error) {
+	query := `
+		SELECT code, created_by, expires_at, COALESCE(used_by, ''), used_at, created_at
+		FROM invites ORDER BY created_at DESC
+	`
+
+	rows, err := m.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query invites: %w", err)
+	}
+	defer rows.Close()
+
+	var invites []models.Invite
+	for rows.Next() {
+		var invite models.Invite
+		var usedAt sql.NullTime
+		if err := rows.Scan(&invite.Code, &invite.CreatedBy, &invite.ExpiresAt, &invite.UsedBy, &usedAt, &invite.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan invite: %w", err)
+		}
+		if usedAt.Valid {
+			invite.UsedAt = &usedAt.Time
+		}
+		invites = append(invites, invite)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return invites, nil
+}
+
+// RedeemInvite marks an invite as used. The conditional update makes
+// redemption single-use even when two requests race for the same code.
+func (m *MySQLUserStorage) RedeemInvite(code, userID string, at time.Time) error {
+	result, err := m.db.Exec(`
+		UPDATE invites SET used_by = ?, used_at = ?
+		WHERE code = ? AND used_at IS NULL AND expires_at > ?
+	`, userID, at, code, at)
+	if err != nil {
+		return fmt.Errorf("failed to redeem invite: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 1 {
+		return nil
+	}
+
+	// Work out why the update matched nothing
+	var expiresAt time.Time
+	var usedAt sql.NullTime
+	err = m.db.QueryRow("SELECT expires_at, used_at FROM invites WHERE code = ?", code).Scan(&expiresAt, &usedAt)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("invite not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get invite: %w", err)
+	}
+	if usedAt.Valid {
+		return fmt.Errorf("invite is used")
+	}
+	return fmt.Errorf("invite is expired")
+}
+
+// DeleteInvite revokes an invite
+func (m *MySQLUserStorage) DeleteInvite(code string) error {
+	result, err := m.db.Exec("DELETE FROM invites WHERE code = ?", code)
+	if err != nil {
+		return fmt.Errorf("failed to delete invite: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("invite not found")
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// workshopReferenceTables are copied into every workshop schema so its
+// Pokedex has the Pokemon and custom dex profiles of the main database
+var workshopReferenceTables = []string{"pokemons", "dex_profiles"}
+
+// MySQLWorkshopSchemas creates and drops the MySQL schemas workshops live
+// in, one per workshop, named after the main database, e.g.
+// coffee_log_ws_<id>. The workshop server creates its own tables on
+// startup.
+type MySQLWorkshopSchemas struct {
+	db       *sql.DB
+	database string // the main database
+}
+
+// NewMySQLWorkshopSchemas creates workshop schemas next to database, on the
+// main connection pool. The MySQL user needs CREATE and DROP privileges on
+// them.
+func NewMySQLWorkshopSchemas(db *sql.DB, database string) *MySQLWorkshopSchemas {
+	return &MySQLWorkshopSchemas{db: db, database: database}
+}
+
+// prefix starts the name of every workshop schema
+func (s *MySQLWorkshopSchemas) prefix() string {
+	return s.database + "_ws_"
+}
+
+// CreateSchema creates the schema of a workshop, seeded with the reference
+// tables, and returns its name
+func (s *MySQLWorkshopSchemas) CreateSchema(id string) (string, error) {
+	schema := s.prefix() + id
+	if len(schema) > 64 {
+		return "", fmt.Errorf("workshop schema name %s is longer than MySQL allows", schema)
+	}
+	if _, err := s.db.Exec("CREATE DATABASE " + quoteIdentifier(schema)); err != nil {
+		return "", fmt.Errorf("failed to create workshop schema %s: %w", schema, err)
+	}
+
+	for _, table := range workshopReferenceTables {
+		source := quoteIdentifier(s.database) + "." + quoteIdentifier(table)
+		target := quoteIdentifier(schema) + "." + quoteIdentifier(table)
+		_, err := s.db.Exec(fmt.Sprintf("CREATE TABLE %s LIKE %s", target, source))
+		if err == nil {
+			_, err = s.db.Exec(fmt.Sprintf("INSERT INTO %s SELECT * FROM %s", target, source))
+		}
+		if err != nil {
+			s.DropSchema(id)
+			return "", fmt.Errorf("failed to copy %s into workshop schema %s: %w", table, schema, err)
+		}
+	}
+	return schema, nil
+}
+
+// DropSchema drops a workshop's schema and everything in it
+func (s *MySQLWorkshopSchemas) DropSchema(id string) error {
+	schema := s.prefix() + id
+	if _, err := s.db.Exec("DROP DATABASE IF EXISTS " + quoteIdentifier(schema)); err != nil {
+		return fmt.Errorf("failed to drop workshop schema %s: %w", schema, err)
+	}
+	return nil
+}
+
+// DropOrphanedSchemas drops every workshop schema. Workshops do not outlive
+// the server that started them, so any schema found at startup was left
+// behind by a crash.
+func (s *MySQLWorkshopSchemas) DropOrphanedSchemas() (int, error) {
+	// Escape LIKE wildcards, since database names may contain "_"
+	pattern := strings.NewReplacer(`\`, `\\`, "_", `\_`, "%", `\%`).Replace(s.prefix()) + "%"
+	rows, err := s.db.Query("SELECT schema_name FROM information_schema.schemata WHERE schema_name LIKE ?", pattern)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list workshop schemas: %w", err)
+	}
+	var schemas []string
+	for rows.Next() {
+		var schema string
+		if err := rows.Scan(&schema); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		schemas = append(schemas, schema)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, schema := range schemas {
+		if err := s.DropSchema(strings.TrimPrefix(schema, s.prefix())); err != nil {
+			return 0, err
+		}
+		log.Printf("INFO: Dropped orphaned workshop schema %s", schema)
+	}
+	return len(schemas), nil
+}
+
+// quoteIdentifier quotes a schema or table name for MySQL
+func quoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
@@ -0,0 +1,152 @@
+// Package typechart holds the Gen 1 type effectiveness chart and scores
+// how well a Pokemon's type combination fits a set of per-type scores. It
+// has no dependencies so the mapper and any other feature that reasons
+// about types can share one chart.
+package typechart
+
+import (
+	"sort"
+	"strings"
+)
+
+// The 15 Gen 1 types
+const (
+	Normal   = "normal"
+	Fire     = "fire"
+	Water    = "water"
+	Electric = "electric"
+	Grass    = "grass"
+	Ice      = "ice"
+	Fighting = "fighting"
+	Poison   = "poison"
+	Ground   = "ground"
+	Flying   = "flying"
+	Psychic  = "psychic"
+	Bug      = "bug"
+	Rock     = "rock"
+	Ghost    = "ghost"
+	Dragon   = "dragon"
+)
+
+// Types lists the Gen 1 types in the games' order
+var Types = []string{Normal, Fire, Water, Electric, Grass, Ice, Fighting, Poison, Ground, Flying, Psychic, Bug, Rock, Ghost, Dragon}
+
+// DualTypeWeight is how much a Pokemon's weaker-scoring type adds to its
+// combination score
+const DualTypeWeight = 0.5
+
+// chart holds every matchup that is not neutral, attacker first. It follows
+// the Gen 1 games, quirks included: Ghost moves cannot hit Psychic, Ice is
+// neutral against Fire, and Bug and Poison are super effective on each other.
+var chart = map[string]map[string]float64{
+	Normal:   {Rock: 0.5, Ghost: 0},
+	Fire:     {Fire: 0.5, Water: 0.5, Grass: 2, Ice: 2, Bug: 2, Rock: 0.5, Dragon: 0.5},
+	Water:    {Fire: 2, Water: 0.5, Grass: 0.5, Ground: 2, Rock: 2, Dragon: 0.5},
+	Electric: {Water: 2, Electric: 0.5, Grass: 0.5, Ground: 0, Flying: 2, Dragon: 0.5},
+	Grass:    {Fire: 0.5, Water: 2, Grass: 0.5, Poison: 0.5, Ground: 2, Flying: 0.5, Bug: 0.5, Rock: 2, Dragon: 0.5},
+	Ice:      {Water: 0.5, Grass: 2, Ice: 0.5, Ground: 2, Flying: 2, Dragon: 2},
+	Fighting: {Normal: 2, Ice: 2, Poison: 0.5, Flying: 0.5, Psychic: 0.5, Bug: 0.5, Rock: 2, Ghost: 0},
+	Poison:   {Grass: 2, Poison: 0.5, Ground: 0.5, Bug: 2, Rock: 0.5, Ghost: 0.5},
+	Ground:   {Fire: 2, Electric: 2, Grass: 0.5, Poison: 2, Flying: 0, Bug: 0.5, Rock: 2},
+	Flying:   {Electric: 0.5, Grass: 2, Fighting: 2, Bug: 2, Rock: 0.5},
+	Psychic:  {Fighting: 2, Poison: 2, Psychic: 0.5},
+	Bug:      {Fire: 0.5, Grass: 2, Fighting: 0.5, Poison: 2, Flying: 0.5, Psychic: 2, Ghost: 0.5},
+	Rock:     {Fire: 2, Ice: 2, Fighting: 0.5, Ground: 0.5, Flying: 2, Bug: 2},
+	Ghost:    {Normal: 0, Psychic: 0, Ghost: 2},
+	Dragon:   {Dragon: 2},
+}
+
+// Known reports whether t is a Gen 1 type
+func Known(t string) bool {
+	_, ok := chart[strings.ToLower(t)]
+	return ok
+}
+
+// Parse splits a Pokemon's type field such as "Grass/Poison" into lower-case
+// type names
+func Parse(field string) []string {
+	var types []string
+	for _, t := range strings.Split(field, "/") {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t != "" {
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
+// Effectiveness returns the damage multiplier of an attacking type against
+// a single defending type: 0, 0.5, 1 or 2. Types missing from the Gen 1
+// chart (dark, steel, fairy) are neutral.
+func Effectiveness(attack, defend string) float64 {
+	if m, ok := chart[strings.ToLower(attack)][strings.ToLower(defend)]; ok {
+		return m
+	}
+	return 1
+}
+
+// Against returns the multiplier of an attacking type against a Pokemon of
+// one or two types, the product of the single-type multipliers
+func Against(attack string, defend []string) float64 {
+	multiplier := 1.0
+	for _, t := range defend {
+		multiplier *= Effectiveness(attack, t)
+	}
+	return multiplier
+}
+
+// Matchups returns the multiplier of every Gen 1 attacking type against a
+// Pokemon of the given types
+func Matchups(defend []string) map[string]float64 {
+	matchups := make(map[string]float64, len(Types))
+	for _, attack := range Types {
+		matchups[attack] = Against(attack, defend)
+	}
+	return matchups
+}
+
+// Weaknesses returns the attacking types that hit a Pokemon of the given
+// types super effectively, in chart order
+func Weaknesses(defend []string) []string {
+	var weak []string
+	for _, attack := range Types {
+		if Against(attack, defend) > 1 {
+			weak = append(weak, attack)
+		}
+	}
+	return weak
+}
+
+// Resistances returns the attacking types a Pokemon of the given types
+// resists or is immune to, in chart order
+func Resistances(defend []string) []string {
+	var resists []string
+	for _, attack := range Types {
+		if Against(attack, defend) < 1 {
+			resists = append(resists, attack)
+		}
+	}
+	return resists
+}
+
+// CombinationScore rates how well a Pokemon of the given types fits scores,
+// a 0-1 score per type name. The best-scoring type counts fully and a
+// second type adds DualTypeWeight of its own score, so a dual-type Pokemon
+// matching two of the scored types ranks above one matching only the
+// first. The result is capped at 1.
+func CombinationScore(scores map[string]float64, types []string) float64 {
+	var matched []float64
+	for _, t := range types {
+		matched = append(matched, scores[strings.ToLower(t)])
+	}
+	if len(matched) == 0 {
+		return 0
+	}
+	sort.Sort(sort.Reverse(sort.Float64Slice(matched)))
+
+	score := matched[0]
+	if len(matched) > 1 {
+		score += DualTypeWeight * matched[1]
+	}
+	return min(score, 1)
+}
@@ -0,0 +1,95 @@
+package typechart
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEffectiveness(t *testing.T) {
+	tests := []struct {
+		name           string
+		attack, defend string
+		want           float64
+	}{
+		{"super effective", Water, Fire, 2},
+		{"super effective on grass", Fire, Grass, 2},
+		{"not very effective", Fire, Water, 0.5},
+		{"resisted by itself", Electric, Electric, 0.5},
+		{"immune to normal", Ghost, Normal, 0},
+		{"ground immune to electric", Electric, Ground, 0},
+		{"flying immune to ground", Ground, Flying, 0},
+		{"neutral", Normal, Fire, 1},
+		{"gen 1 ghost cannot hit psychic", Ghost, Psychic, 0},
+		{"gen 1 ice neutral on fire", Ice, Fire, 1},
+		{"gen 1 bug super effective on poison", Bug, Poison, 2},
+		{"case insensitive", "WATER", "Fire", 2},
+		{"unknown type is neutral", Fire, "steel", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Effectiveness(tt.attack, tt.defend); got != tt.want {
+				t.Errorf("Effectiveness(%q, %q) = %v, want %v", tt.attack, tt.defend, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAgainstDualTypes(t *testing.T) {
+	tests := []struct {
+		name   string
+		attack string
+		defend []string
+		want   float64
+	}{
+		{"double weakness", Electric, []string{Water, Flying}, 4},
+		{"double weakness to ice", Ice, []string{Ground, Flying}, 4},
+		{"weakness and resistance cancel", Ground, []string{Grass, Poison}, 1},
+		{"double resistance", Fire, []string{Water, Rock}, 0.25},
+		{"immunity wins", Electric, []string{Ground, Rock}, 0},
+		{"weakness against one type", Water, []string{Fire, Flying}, 2},
+		{"single type", Water, []string{Fire}, 2},
+		{"no types is neutral", Fire, nil, 1},
+		{"parsed type field", Electric, Parse("Water/Flying"), 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Against(tt.attack, tt.defend); got != tt.want {
+				t.Errorf("Against(%q, %v) = %v, want %v", tt.attack, tt.defend, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWeaknessesAndResistances(t *testing.T) {
+	// Ice is neutral on Fire in Gen 1, so it still hits Fire/Flying twice as hard
+	charizard := []string{Fire, Flying}
+	if got, want := Weaknesses(charizard), []string{Water, Electric, Ice, Rock}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Weaknesses(%v) = %v, want %v", charizard, got, want)
+	}
+	if got, want := Resistances(charizard), []string{Fire, Grass, Fighting, Ground, Bug}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Resistances(%v) = %v, want %v", charizard, got, want)
+	}
+}
+
+func TestCombinationScore(t *testing.T) {
+	scores := map[string]float64{Grass: 0.8, Poison: 0.4, Water: 0.9}
+	tests := []struct {
+		name  string
+		types []string
+		want  float64
+	}{
+		{"single type", []string{Grass}, 0.8},
+		{"second type adds half", []string{Grass, Poison}, 1},
+		{"order does not matter", []string{Poison, Grass}, 1},
+		{"unscored second type", []string{Poison, Fire}, 0.4},
+		{"capped at one", []string{Water, Grass}, 1},
+		{"no types", nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CombinationScore(scores, tt.types); got != tt.want {
+				t.Errorf("CombinationScore(%v) = %v, want %v", tt.types, got, tt.want)
+			}
+		})
+	}
+}
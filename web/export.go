@@ -0,0 +1,229 @@
+package web
+
+import (
+	"bytes"
+	"fmt"
+	"go-coffee-log/models"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// IndexEntry is one coffee in the Pokedex grid
+type IndexEntry struct {
+	Coffee    models.Coffee
+	Pokemon   *models.CoffeePokemon
+	SpriteURL string
+	Href      string
+}
+
+// IndexPage is the Pokedex grid of the whole collection
+type IndexPage struct {
+	Page
+	Entries []IndexEntry
+	Caught  int
+}
+
+// Count is a labelled tally for the statistics page
+type Count struct {
+	Name  string
+	Count int
+}
+
+// SiteStats is what the statistics page shows. It is computed from the
+// coffees alone so exports work with any storage.
+type SiteStats struct {
+	TotalCoffees  int
+	Caught        int
+	AverageRating float64
+	TopRated      *models.Coffee
+	TopRatedHref  string
+	Origins       []Count
+	RoastLevels   []Count
+}
+
+// StatsPage is the collection statistics page
+type StatsPage struct {
+	Page
+	Stats SiteStats
+}
+
+// Export renders the collection into dir: a Pokedex grid (index.html), a
+// page per coffee, a statistics page and a sitemap. Sprites of caught
+// Pokemon are copied from spriteDir when it exists.
+func (s *Site) Export(dir string, coffees []models.Coffee, mappings []models.CoffeePokemon, spriteDir string) error {
+	if err := os.MkdirAll(filepath.Join(dir, "coffees"), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	byCoffee := make(map[string]*models.CoffeePokemon)
+	for i := range mappings {
+		byCoffee[mappings[i].CoffeeID] = &mappings[i]
+	}
+
+	for _, coffee := range coffees {
+		page := s.NewCoffeePage(coffee, byCoffee[coffee.ID])
+		if err := s.writePage(filepath.Join(dir, s.coffeePath(coffee.ID)), "coffee", page); err != nil {
+			return err
+		}
+	}
+
+	if err := s.writePage(filepath.Join(dir, "index.html"), "index", s.newIndexPage(coffees, byCoffee)); err != nil {
+		return err
+	}
+	if err := s.writePage(filepath.Join(dir, "stats.html"), "stats", s.newStatsPage(coffees, len(mappings))); err != nil {
+		return err
+	}
+
+	var sitemap bytes.Buffer
+	if err := s.WriteSitemap(&sitemap, coffees); err != nil {
+		return fmt.Errorf("failed to write sitemap: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "sitemap.xml"), sitemap.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write sitemap: %w", err)
+	}
+
+	// GitHub Pages would otherwise run the output through Jekyll
+	if err := os.WriteFile(filepath.Join(dir, ".nojekyll"), nil, 0644); err != nil {
+		return fmt.Errorf("failed to write .nojekyll: %w", err)
+	}
+
+	return copySprites(dir, mappings, spriteDir)
+}
+
+// newIndexPage lists caught coffees in Pokedex order, then the rest by name
+func (s *Site) newIndexPage(coffees []models.Coffee, byCoffee map[string]*models.CoffeePokemon) IndexPage {
+	page := IndexPage{Page: s.newPage("index.html", 0)}
+	page.Meta.Title = "CoffeeDex"
+
+	for _, coffee := range coffees {
+		entry := IndexEntry{
+			Coffee:  coffee,
+			Pokemon: byCoffee[coffee.ID],
+			Href:    s.coffeePath(coffee.ID),
+		}
+		if entry.Pokemon != nil {
+			entry.SpriteURL = page.Root + SpritePath(entry.Pokemon.PokemonID)
+			page.Caught++
+		}
+		page.Entries = append(page.Entries, entry)
+	}
+
+	sort.SliceStable(page.Entries, func(i, j int) bool {
+		a, b := page.Entries[i], page.Entries[j]
+		if (a.Pokemon == nil) != (b.Pokemon == nil) {
+			return a.Pokemon != nil
+		}
+		if a.Pokemon != nil && a.Pokemon.PokemonID != b.Pokemon.PokemonID {
+			return a.Pokemon.PokemonID < b.Pokemon.PokemonID
+		}
+		return a.Coffee.Name < b.Coffee.Name
+	})
+
+	page.Meta.Description = fmt.Sprintf("%d coffees, %d caught", len(coffees), page.Caught)
+	return page
+}
+
+// newStatsPage summarizes ratings, origins and roast levels
+func (s *Site) newStatsPage(coffees []models.Coffee, caught int) StatsPage {
+	page := StatsPage{Page: s.newPage("stats.html", 0)}
+	page.Meta.Title = "CoffeeDex statistics"
+
+	stats := SiteStats{TotalCoffees: len(coffees), Caught: caught}
+	origins := make(map[string]int)
+	roastLevels := make(map[string]int)
+	ratingSum := 0
+	for i, coffee := range coffees {
+		ratingSum += coffee.Rating
+		if stats.TopRated == nil || coffee.Rating > stats.TopRated.Rating {
+			stats.TopRated = &coffees[i]
+		}
+		if coffee.Origin != "" {
+			origins[coffee.Origin]++
+		}
+		if coffee.RoastLevel != "" {
+			roastLevels[coffee.RoastLevel]++
+		}
+	}
+	if len(coffees) > 0 {
+		stats.AverageRating = float64(ratingSum) / float64(len(coffees))
+	}
+	if stats.TopRated != nil {
+		stats.TopRatedHref = s.coffeePath(stats.TopRated.ID)
+	}
+	stats.Origins = sortedCounts(origins)
+	stats.RoastLevels = sortedCounts(roastLevels)
+
+	page.Stats = stats
+	page.Meta.Description = fmt.Sprintf("%d coffees, average rating %.1f/10", stats.TotalCoffees, stats.AverageRating)
+	return page
+}
+
+// sortedCounts orders tallies by count, then name
+func sortedCounts(tally map[string]int) []Count {
+	counts := make([]Count, 0, len(tally))
+	for name, count := range tally {
+		counts = append(counts, Count{Name: name, Count: count})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Name < counts[j].Name
+	})
+	return counts
+}
+
+// writePage renders a template into a file
+func (s *Site) writePage(path, name string, data interface{}) error {
+	var page bytes.Buffer
+	if err := s.pages[name].ExecuteTemplate(&page, "layout", data); err != nil {
+		return fmt.Errorf("failed to render %s: %w", path, err)
+	}
+	if err := os.WriteFile(path, page.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// copySprites copies the sprites of caught Pokemon into the export
+func copySprites(dir string, mappings []models.CoffeePokemon, spriteDir string) error {
+	if _, err := os.Stat(spriteDir); err != nil {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, "static", "pokemon-sprites"), 0755); err != nil {
+		return fmt.Errorf("failed to create sprite directory: %w", err)
+	}
+
+	for _, mapping := range mappings {
+		name := fmt.Sprintf("%03d.png", mapping.PokemonID)
+		if err := copyFile(filepath.Join(spriteDir, name), filepath.Join(dir, SpritePath(mapping.PokemonID))); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return fmt.Errorf("failed to copy sprite %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// copyFile copies src to dst
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,193 @@
+// Package web renders the public HTML views of a collection: share pages
+// with their Open Graph metadata, the sitemap, and a static site export.
+package web
+
+import (
+	"embed"
+	"encoding/xml"
+	"fmt"
+	"go-coffee-log/models"
+	"html/template"
+	"io"
+	"strings"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+// sitemapNamespace is the sitemaps.org schema
+const sitemapNamespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// Meta holds the Open Graph / Twitter card fields of a page
+type Meta struct {
+	Title       string
+	Description string
+	Image       string // absolute URL
+	URL         string // absolute URL
+	Type        string // og:type, e.g. "article"
+}
+
+// Page holds what every page needs besides its content
+type Page struct {
+	Meta Meta
+	Root string // prefix for links to other pages and assets
+	Nav  bool   // show links to the Pokedex and statistics pages
+}
+
+// CoffeePage is the data rendered on a coffee's share page
+type CoffeePage struct {
+	Page
+	Coffee    models.Coffee
+	Pokemon   *models.CoffeePokemon // nil when the coffee has not been caught
+	SpriteURL string
+	Notes     string
+}
+
+// Site renders pages whose links are absolute to baseURL. A static site
+// uses relative links and .html files so it can be hosted anywhere.
+type Site struct {
+	baseURL string
+	static  bool
+	pages   map[string]*template.Template
+}
+
+// NewSite creates a site for share pages served by the API server
+func NewSite(baseURL string) (*Site, error) {
+	return newSite(baseURL, false)
+}
+
+// NewStaticSite creates a site for a static HTML export
+func NewStaticSite(baseURL string) (*Site, error) {
+	return newSite(baseURL, true)
+}
+
+// newSite parses the embedded templates
+func newSite(baseURL string, static bool) (*Site, error) {
+	site := &Site{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		static:  static,
+		pages:   make(map[string]*template.Template),
+	}
+
+	for _, page := range []string{"coffee", "index", "stats"} {
+		tmpl, err := template.ParseFS(templateFS, "templates/layout.html", "templates/"+page+".html")
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s template: %w", page, err)
+		}
+		site.pages[page] = tmpl
+	}
+
+	return site, nil
+}
+
+// coffeePath is the path of a coffee's page, relative to the site root
+func (s *Site) coffeePath(coffeeID string) string {
+	if s.static {
+		return "coffees/" + coffeeID + ".html"
+	}
+	return "share/coffees/" + coffeeID
+}
+
+// newPage fills in the common page fields. depth is how many directories
+// below the root the page lives, for relative links in static exports.
+func (s *Site) newPage(path string, depth int) Page {
+	page := Page{
+		Meta: Meta{URL: s.baseURL + "/" + path, Type: "website"},
+		Root: "/",
+		Nav:  s.static,
+	}
+	if s.static {
+		page.Root = strings.Repeat("../", depth)
+	}
+	return page
+}
+
+// SpritePath is where a Pokemon's sprite lives, relative to the site root
+func SpritePath(pokemonID int) string {
+	return fmt.Sprintf("static/pokemon-sprites/%03d.png", pokemonID)
+}
+
+// NewCoffeePage builds a coffee's share page and its unfurl metadata
+func (s *Site) NewCoffeePage(coffee models.Coffee, mapping *models.CoffeePokemon) CoffeePage {
+	depth := strings.Count(s.coffeePath(coffee.ID), "/")
+	page := CoffeePage{
+		Page:    s.newPage(s.coffeePath(coffee.ID), depth),
+		Coffee:  coffee,
+		Pokemon: mapping,
+		Notes:   joinNotes(coffee.TastingNotes),
+	}
+
+	description := []string{fmt.Sprintf("Rated %d/10", coffee.Rating)}
+	if coffee.Roaster != "" {
+		description = append(description, coffee.Roaster)
+	}
+	if coffee.Origin != "" {
+		description = append(description, coffee.Origin)
+	}
+	if page.Notes != "" {
+		description = append(description, page.Notes)
+	}
+
+	page.Meta.Title = coffee.Name
+	page.Meta.Description = strings.Join(description, " · ")
+	page.Meta.Type = "article"
+	if mapping != nil {
+		page.Meta.Title = fmt.Sprintf("%s — caught as %s", coffee.Name, mapping.PokemonName)
+		page.Meta.Image = s.baseURL + "/" + SpritePath(mapping.PokemonID)
+		page.SpriteURL = page.Root + SpritePath(mapping.PokemonID)
+	}
+
+	return page
+}
+
+// RenderCoffee writes a coffee's share page
+func (s *Site) RenderCoffee(w io.Writer, page CoffeePage) error {
+	return s.pages["coffee"].ExecuteTemplate(w, "layout", page)
+}
+
+// sitemapURL is one <url> entry of a sitemap
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// WriteSitemap writes a sitemap.xml listing every coffee's share page
+func (s *Site) WriteSitemap(w io.Writer, coffees []models.Coffee) error {
+	urlset := struct {
+		XMLName xml.Name     `xml:"urlset"`
+		Xmlns   string       `xml:"xmlns,attr"`
+		URLs    []sitemapURL `xml:"url"`
+	}{Xmlns: sitemapNamespace}
+
+	if s.static {
+		urlset.URLs = append(urlset.URLs,
+			sitemapURL{Loc: s.baseURL + "/index.html"},
+			sitemapURL{Loc: s.baseURL + "/stats.html"},
+		)
+	}
+	for _, coffee := range coffees {
+		entry := sitemapURL{Loc: s.baseURL + "/" + s.coffeePath(coffee.ID)}
+		if !coffee.UpdatedAt.IsZero() {
+			entry.LastMod = coffee.UpdatedAt.Format("2006-01-02")
+		}
+		urlset.URLs = append(urlset.URLs, entry)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	return encoder.Encode(urlset)
+}
+
+// joinNotes lists the non-empty tasting notes
+func joinNotes(notes [5]string) string {
+	var filled []string
+	for _, note := range notes {
+		if note = strings.TrimSpace(note); note != "" {
+			filled = append(filled, note)
+		}
+	}
+	return strings.Join(filled, ", ")
+}
@@ -0,0 +1,181 @@
+package web
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Sprite sheet files, written next to the individual sprites
+const (
+	SpriteSheetFile    = "sheet.png"
+	SpriteManifestFile = "sheet.json"
+)
+
+// spriteSheetColumns is the width of the sheet in sprites
+const spriteSheetColumns = 16
+
+// spriteFilePattern matches individual sprites such as "025.png"
+var spriteFilePattern = regexp.MustCompile(`^(\d{3})\.png$`)
+
+// SpriteFrame is where one Pokemon's sprite sits on the sheet
+type SpriteFrame struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"w"`
+	Height int `json:"h"`
+}
+
+// SpriteManifest describes the sprite sheet, so a Pokedex grid can load
+// one image and crop each entry from it (e.g. with CSS background-position)
+type SpriteManifest struct {
+	Image       string              `json:"image"`   // URL, versioned for caching
+	Version     string              `json:"version"` // content hash of the sheet
+	Width       int                 `json:"width"`
+	Height      int                 `json:"height"`
+	CellWidth   int                 `json:"cell_width"`
+	CellHeight  int                 `json:"cell_height"`
+	Columns     int                 `json:"columns"`
+	GeneratedAt time.Time           `json:"generated_at"`
+	Sprites     map[int]SpriteFrame `json:"sprites"` // keyed by Pokemon ID
+}
+
+// BuildSpriteSheet combines the sprites in spriteDir into one sheet plus a
+// JSON manifest, both served from urlPrefix (e.g. "/static/pokemon-sprites").
+// It does nothing when the manifest is newer than every sprite, and reports
+// whether a sheet was written.
+func BuildSpriteSheet(spriteDir, urlPrefix string) (bool, error) {
+	entries, err := os.ReadDir(spriteDir)
+	if err != nil {
+		return false, err
+	}
+
+	type sprite struct {
+		id   int
+		path string
+	}
+	var sprites []sprite
+	var newest time.Time
+	for _, entry := range entries {
+		match := spriteFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return false, err
+		}
+		if info.ModTime().After(newest) {
+			newest = info.ModTime()
+		}
+		id, _ := strconv.Atoi(match[1])
+		sprites = append(sprites, sprite{id: id, path: filepath.Join(spriteDir, entry.Name())})
+	}
+	if len(sprites) == 0 {
+		return false, nil
+	}
+	sort.Slice(sprites, func(i, j int) bool { return sprites[i].id < sprites[j].id })
+
+	manifestPath := filepath.Join(spriteDir, SpriteManifestFile)
+	if info, err := os.Stat(manifestPath); err == nil && !info.ModTime().Before(newest) {
+		return false, nil
+	}
+
+	images := make([]image.Image, len(sprites))
+	cellWidth, cellHeight := 0, 0
+	for i, s := range sprites {
+		img, err := decodePNG(s.path)
+		if err != nil {
+			return false, err
+		}
+		images[i] = img
+		cellWidth = max(cellWidth, img.Bounds().Dx())
+		cellHeight = max(cellHeight, img.Bounds().Dy())
+	}
+
+	columns := min(spriteSheetColumns, len(sprites))
+	rows := (len(sprites) + columns - 1) / columns
+	sheet := image.NewNRGBA(image.Rect(0, 0, columns*cellWidth, rows*cellHeight))
+	manifest := SpriteManifest{
+		Width:       sheet.Bounds().Dx(),
+		Height:      sheet.Bounds().Dy(),
+		CellWidth:   cellWidth,
+		CellHeight:  cellHeight,
+		Columns:     columns,
+		GeneratedAt: time.Now(),
+		Sprites:     make(map[int]SpriteFrame, len(sprites)),
+	}
+	for i, img := range images {
+		frame := SpriteFrame{
+			X:      (i % columns) * cellWidth,
+			Y:      (i / columns) * cellHeight,
+			Width:  img.Bounds().Dx(),
+			Height: img.Bounds().Dy(),
+		}
+		target := image.Rect(frame.X, frame.Y, frame.X+frame.Width, frame.Y+frame.Height)
+		draw.Draw(sheet, target, img, img.Bounds().Min, draw.Src)
+		manifest.Sprites[sprites[i].id] = frame
+	}
+
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, sheet); err != nil {
+		return false, fmt.Errorf("failed to encode sprite sheet: %w", err)
+	}
+	sum := sha256.Sum256(encoded.Bytes())
+	manifest.Version = hex.EncodeToString(sum[:])[:12]
+	manifest.Image = fmt.Sprintf("%s/%s?v=%s", urlPrefix, SpriteSheetFile, manifest.Version)
+
+	manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return false, fmt.Errorf("failed to encode sprite manifest: %w", err)
+	}
+
+	// The sheet goes first so the manifest never points at a missing image
+	if err := writeFileAtomic(filepath.Join(spriteDir, SpriteSheetFile), encoded.Bytes()); err != nil {
+		return false, err
+	}
+	if err := writeFileAtomic(manifestPath, manifestJSON); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// decodePNG reads one sprite
+func decodePNG(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, err := png.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return img, nil
+}
+
+// writeFileAtomic replaces path via a temporary file, so the static file
+// server never serves a half-written file
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return nil
+}